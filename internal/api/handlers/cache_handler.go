@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/Mieluoxxx/Siriusx-API/internal/cache"
+	"github.com/gin-gonic/gin"
+)
+
+// CacheHandler 响应缓存管理处理器
+type CacheHandler struct {
+	cache *cache.ResponseCache
+}
+
+// NewCacheHandler 创建响应缓存管理处理器
+func NewCacheHandler(responseCache *cache.ResponseCache) *CacheHandler {
+	return &CacheHandler{cache: responseCache}
+}
+
+// GetStats 获取响应缓存统计（命中、未命中、跳过、写入、淘汰次数与命中率）
+// @Summary 获取响应缓存统计
+// @Tags cache
+// @Produce json
+// @Success 200 {object} cache.Stats
+// @Router /api/cache/stats [get]
+func (h *CacheHandler) GetStats(c *gin.Context) {
+	c.JSON(http.StatusOK, h.cache.Stats())
+}
+
+// ClearCache 清空响应缓存（含持久化条目）
+// @Summary 清空响应缓存
+// @Tags cache
+// @Success 204 "No Content"
+// @Router /api/cache [delete]
+func (h *CacheHandler) ClearCache(c *gin.Context) {
+	if err := h.cache.Clear(); err != nil {
+		log.Printf("❌ [响应缓存] 清空失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Failed to clear response cache",
+			},
+		})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -351,12 +351,12 @@ func (h *ProviderHandler) HealthCheckProvider(c *gin.Context) {
 	}
 
 	// 执行健康检查
-	healthChecker := provider.NewHealthChecker(15 * time.Second)
+	healthChecker := provider.NewHealthCheckerWithTransports(15*time.Second, h.service.Transports())
 
 	// 记录健康检查开始
 	c.Set("health_check_start", time.Now())
 
-	checkResult, err := healthChecker.CheckHealthSimple(prov.BaseURL, prov.APIKey, prov.TestModel)
+	checkResult, err := healthChecker.CheckProviderHealth(c.Request.Context(), prov, prov.TestModel)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, provider.ErrorResponse{
 			Error: provider.ErrorDetail{
@@ -488,18 +488,9 @@ type ToggleEnabledRequest struct {
 }
 
 // toProviderResponse 将 Provider 模型转换为响应（API Key 脱敏）
+// Service 返回的 Provider 中 API Key 已解密
 func toProviderResponse(p *models.Provider) provider.ProviderResponse {
-	return provider.ProviderResponse{
-		ID:           p.ID,
-		Name:         p.Name,
-		BaseURL:      p.BaseURL,
-		APIKey:       provider.MaskAPIKey(p.APIKey),
-		TestModel:    p.TestModel,
-		Enabled:      p.Enabled,
-		HealthStatus: p.HealthStatus,
-		CreatedAt:    p.CreatedAt,
-		UpdatedAt:    p.UpdatedAt,
-	}
+	return *provider.ToProviderResponseWithDecryption(p, p.APIKey)
 }
 
 // GetAvailableModels 获取供应商可用模型列表
@@ -608,10 +599,10 @@ func (h *ProviderHandler) TestProviderModel(c *gin.Context) {
 	}
 
 	// 执行模型测试
-	healthChecker := provider.NewHealthChecker(15 * time.Second)
+	healthChecker := provider.NewHealthCheckerWithTransports(15*time.Second, h.service.Transports())
 	startTime := time.Now()
 
-	checkResult, err := healthChecker.CheckHealthSimple(prov.BaseURL, prov.APIKey, req.ModelName)
+	checkResult, err := healthChecker.CheckProviderHealth(c.Request.Context(), prov, req.ModelName)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, provider.ErrorResponse{
 			Error: provider.ErrorDetail{
@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/Mieluoxxx/Siriusx-API/internal/converter"
+	"github.com/Mieluoxxx/Siriusx-API/internal/models"
+	"github.com/Mieluoxxx/Siriusx-API/internal/provider"
+	"github.com/gin-gonic/gin"
+)
+
+// forwardClaudeViaBedrock 将 Claude Messages 请求转发到 AWS Bedrock
+// 非流式请求走 InvokeModel，流式请求走 InvokeModelWithResponseStream 并将 event-stream 转回 Claude SSE
+func (h *ProxyHandler) forwardClaudeViaBedrock(c *gin.Context, prov *models.Provider, targetModel string, req map[string]interface{}) {
+	req = withoutGatewayThinking(req)
+	stream, _ := req["stream"].(bool)
+
+	body, err := json.Marshal(provider.BuildBedrockBody(req, c.GetHeader("anthropic-beta")))
+	if err != nil {
+		log.Printf("❌ [序列化失败] Bedrock 请求: %v", err)
+		h.respondClaudeError(c, http.StatusInternalServerError, "api_error", "生成上游请求失败")
+		return
+	}
+
+	targetURL := provider.BedrockInvokeURL(prov, targetModel, stream)
+	log.Printf("➡️  [转发] Claude→Bedrock 目标URL: %s, 请求体大小: %d bytes", targetURL, len(body))
+
+	proxyReq, err := http.NewRequestWithContext(c.Request.Context(), "POST", targetURL, bytes.NewReader(body))
+	if err != nil {
+		log.Printf("❌ [转发失败] 创建 Bedrock 请求失败: %v", err)
+		h.respondClaudeError(c, http.StatusInternalServerError, "api_error", "创建代理请求失败")
+		return
+	}
+
+	proxyReq.Header.Set("Content-Type", "application/json")
+	if stream {
+		proxyReq.Header.Set("Accept", "application/vnd.amazon.eventstream")
+	} else {
+		proxyReq.Header.Set("Accept", "application/json")
+	}
+
+	if err := h.copyForwardHeaders(c, prov, proxyReq.Header); err != nil {
+		log.Printf("❌ [转发失败] Provider: %s, 请求头转发策略无效: %v", prov.Name, err)
+		h.respondClaudeError(c, http.StatusInternalServerError, "api_error", "供应商请求头转发配置无效")
+		return
+	}
+
+	vars := h.templateVars(c, prov, targetModel)
+	if err := provider.ApplyCustomHeaders(proxyReq.Header, prov, vars); err != nil {
+		log.Printf("❌ [转发失败] Provider: %s, 自定义请求头无效: %v", prov.Name, err)
+		h.respondClaudeError(c, http.StatusInternalServerError, "api_error", "供应商自定义请求头配置无效")
+		return
+	}
+
+	// 签名必须在所有请求头设置完成之后
+	if err := provider.AuthorizeRequest(c.Request.Context(), proxyReq, prov, body); err != nil {
+		log.Printf("❌ [转发失败] Provider: %s, 认证失败: %v", prov.Name, err)
+		h.respondClaudeError(c, http.StatusInternalServerError, "api_error", fmt.Sprintf("供应商认证失败: %v", err))
+		return
+	}
+
+	client, err := h.httpClient(prov)
+	if err != nil {
+		log.Printf("❌ [转发失败] Provider: %s, 连接配置无效: %v", prov.Name, err)
+		h.respondClaudeError(c, http.StatusInternalServerError, "api_error", fmt.Sprintf("供应商连接配置无效: %v", err))
+		return
+	}
+
+	resp, err := client.Do(proxyReq)
+	if err != nil {
+		log.Printf("❌ [转发失败] Provider: %s, 错误: %v", prov.Name, err)
+		h.respondClaudeError(c, http.StatusBadGateway, "api_error", fmt.Sprintf("请求供应商失败: %v", err))
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		h.respondBedrockError(c, resp)
+		return
+	}
+
+	if stream {
+		convertedReader, err := converter.ConvertBedrockStream(c.Request.Context(), resp.Body)
+		if err != nil {
+			log.Printf("❌ [流式转换失败] Provider: %s, 错误: %v", prov.Name, err)
+			h.respondClaudeError(c, http.StatusBadGateway, "api_error", "上游流式响应转换失败")
+			return
+		}
+
+		c.Header("Content-Type", "text/event-stream; charset=utf-8")
+		c.Header("Cache-Control", "no-cache")
+		c.Status(http.StatusOK)
+
+		totalBytes, err := pipeStream(c, convertedReader)
+		if err != nil {
+			log.Printf("❌ [流式转发] Bedrock event-stream: %v", err)
+			return
+		}
+		log.Printf("✅ [完成] Bedrock 流式响应转换完成，共 %d bytes", totalBytes)
+		return
+	}
+
+	// InvokeModel 的响应体即 Claude Messages 响应
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		log.Printf("❌ [响应失败] 读取 Bedrock 响应体失败: %v", err)
+		h.respondClaudeError(c, http.StatusInternalServerError, "api_error", "读取上游响应失败")
+		return
+	}
+
+	c.Data(resp.StatusCode, "application/json", respBody)
+	log.Printf("✅ [完成] Bedrock 非流式响应，状态: %d, 响应体大小: %d bytes", resp.StatusCode, len(respBody))
+}
+
+// respondBedrockError 将 Bedrock 错误响应转换为 Claude 错误格式
+// 错误类型来自 X-Amzn-ErrorType 请求头（如 ValidationException:http://...）
+func (h *ProxyHandler) respondBedrockError(c *gin.Context, resp *http.Response) {
+	respBody, _ := io.ReadAll(resp.Body)
+
+	var bedrockErr struct {
+		Message string `json:"message"`
+	}
+	_ = json.Unmarshal(respBody, &bedrockErr)
+	if bedrockErr.Message == "" {
+		bedrockErr.Message = "上游返回错误响应"
+	}
+
+	exceptionType := resp.Header.Get("X-Amzn-ErrorType")
+	if idx := strings.Index(exceptionType, ":"); idx >= 0 {
+		exceptionType = exceptionType[:idx]
+	}
+	if exceptionType != "" {
+		exceptionType = strings.ToLower(exceptionType[:1]) + exceptionType[1:]
+	}
+
+	log.Printf("❌ [Bedrock 错误响应] 状态: %d, 类型: %s, 内容: %s", resp.StatusCode, exceptionType, bedrockErr.Message)
+	h.respondClaudeError(c, resp.StatusCode, converter.BedrockErrorType(exceptionType), bedrockErr.Message)
+}
@@ -0,0 +1,233 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Mieluoxxx/Siriusx-API/internal/converter"
+	"github.com/Mieluoxxx/Siriusx-API/internal/models"
+	"github.com/Mieluoxxx/Siriusx-API/internal/provider"
+	"github.com/gin-gonic/gin"
+)
+
+const bedrockTestModel = "anthropic.claude-3-5-sonnet-20240620-v1:0"
+
+// verifyBedrockSignature 以服务端收到的请求重新签名并比对
+func verifyBedrockSignature(r *http.Request, body []byte, prov *models.Provider) bool {
+	signedAt, err := time.Parse("20060102T150405Z", r.Header.Get("X-Amz-Date"))
+	if err != nil {
+		return false
+	}
+	check, _ := http.NewRequest(r.Method, "http://"+r.Host+r.URL.RequestURI(), nil)
+	check.Header.Set("Content-Type", r.Header.Get("Content-Type"))
+	creds := provider.AWSCredentials{AccessKeyID: prov.APIKey, SecretAccessKey: prov.AWSSecretKey}
+	if err := provider.SignV4(check, body, creds, prov.AWSRegion, "bedrock", signedAt); err != nil {
+		return false
+	}
+	return check.Header.Get("Authorization") == r.Header.Get("Authorization")
+}
+
+// newBedrockHandlerStandIn 模拟 Bedrock 运行时接口
+func newBedrockHandlerStandIn(t *testing.T, prov *models.Provider, captured *map[string]interface{}) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if !verifyBedrockSignature(r, body, prov) {
+			w.Header().Set("X-Amzn-ErrorType", "InvalidSignatureException:http://internal.amazon.com/coral/com.amazon.coral.service/")
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"message":"The request signature we calculated does not match"}`))
+			return
+		}
+		_ = json.Unmarshal(body, captured)
+
+		switch r.URL.EscapedPath() {
+		case "/model/anthropic.claude-3-5-sonnet-20240620-v1%3A0/invoke":
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"id":"msg_bdrk","type":"message","role":"assistant","content":[{"type":"text","text":"pong"}],"stop_reason":"end_turn","usage":{"input_tokens":3,"output_tokens":1}}`))
+		case "/model/anthropic.claude-3-5-sonnet-20240620-v1%3A0/invoke-with-response-stream":
+			w.Header().Set("Content-Type", "application/vnd.amazon.eventstream")
+			for _, event := range []string{
+				`{"type":"message_start","message":{"id":"msg_bdrk","role":"assistant"}}`,
+				`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"pong"}}`,
+				`{"type":"message_stop"}`,
+			} {
+				payload := `{"bytes":"` + base64.StdEncoding.EncodeToString([]byte(event)) + `"}`
+				_, _ = w.Write(converter.EncodeEventStreamMessage(map[string]string{
+					":event-type":   "chunk",
+					":message-type": "event",
+				}, []byte(payload)))
+			}
+		case "/model/anthropic.claude-unknown/invoke":
+			w.Header().Set("X-Amzn-ErrorType", "ValidationException:http://internal.amazon.com/coral/com.amazon.bedrock/")
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"message":"The provided model identifier is invalid."}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func newBedrockTestProvider() *models.Provider {
+	return &models.Provider{
+		Name:         "bedrock",
+		Type:         provider.ProviderTypeBedrock,
+		APIKey:       "AKIDEXAMPLE",
+		AWSSecretKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+		AWSRegion:    "us-east-1",
+	}
+}
+
+func newBedrockTestRequest(stream bool) map[string]interface{} {
+	return map[string]interface{}{
+		"model":      bedrockTestModel,
+		"stream":     stream,
+		"max_tokens": 16,
+		"messages": []interface{}{map[string]interface{}{
+			"role":    "user",
+			"content": []interface{}{map[string]interface{}{"type": "text", "text": "ping"}},
+		}},
+		"tools": []interface{}{map[string]interface{}{
+			"name":         "get_weather",
+			"input_schema": map[string]interface{}{"type": "object"},
+		}},
+	}
+}
+
+func TestForwardClaudeViaBedrock_InvokeModel(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	prov := newBedrockTestProvider()
+	var captured map[string]interface{}
+	server := newBedrockHandlerStandIn(t, prov, &captured)
+	defer server.Close()
+	prov.BaseURL = server.URL
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("POST", "/v1/messages", nil)
+	c.Request.Header.Set("anthropic-version", "2023-06-01")
+	c.Request.Header.Set("anthropic-beta", "prompt-caching-2024-07-31")
+
+	handler := &ProxyHandler{}
+	handler.forwardClaudeViaBedrock(c, prov, bedrockTestModel, newBedrockTestRequest(false))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status code: %d, body: %s", w.Code, w.Body.String())
+	}
+	if captured["anthropic_version"] != provider.BedrockAnthropicVersion {
+		t.Fatalf("expected anthropic_version in body, got %v", captured["anthropic_version"])
+	}
+	if _, exists := captured["model"]; exists {
+		t.Fatalf("model should be carried by the URL, not the body")
+	}
+	if betas, ok := captured["anthropic_beta"].([]interface{}); !ok || len(betas) != 1 || betas[0] != "prompt-caching-2024-07-31" {
+		t.Fatalf("expected anthropic_beta from header, got %v", captured["anthropic_beta"])
+	}
+	if tools, ok := captured["tools"].([]interface{}); !ok || len(tools) != 1 {
+		t.Fatalf("expected Claude tools to be kept, got %v", captured["tools"])
+	}
+
+	var claudeResp converter.ClaudeResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &claudeResp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(claudeResp.Content) == 0 || claudeResp.Content[0].Text == nil || *claudeResp.Content[0].Text != "pong" {
+		t.Fatalf("unexpected response content: %s", w.Body.String())
+	}
+}
+
+func TestForwardClaudeViaBedrock_Stream(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	prov := newBedrockTestProvider()
+	var captured map[string]interface{}
+	server := newBedrockHandlerStandIn(t, prov, &captured)
+	defer server.Close()
+	prov.BaseURL = server.URL
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("POST", "/v1/messages", nil)
+
+	handler := &ProxyHandler{}
+	handler.forwardClaudeViaBedrock(c, prov, bedrockTestModel, newBedrockTestRequest(true))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status code: %d, body: %s", w.Code, w.Body.String())
+	}
+	if _, exists := captured["stream"]; exists {
+		t.Fatalf("stream should be carried by the URL, not the body")
+	}
+	if !strings.HasPrefix(w.Header().Get("Content-Type"), "text/event-stream") {
+		t.Fatalf("expected SSE content type, got %s", w.Header().Get("Content-Type"))
+	}
+
+	body := w.Body.String()
+	for _, expected := range []string{
+		"event: message_start\n",
+		"event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"text_delta\",\"text\":\"pong\"}}\n\n",
+		"event: message_stop\n",
+	} {
+		if !strings.Contains(body, expected) {
+			t.Fatalf("expected SSE output to contain %q, got:\n%s", expected, body)
+		}
+	}
+}
+
+func TestForwardClaudeViaBedrock_Errors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	prov := newBedrockTestProvider()
+	var captured map[string]interface{}
+	server := newBedrockHandlerStandIn(t, prov, &captured)
+	defer server.Close()
+	prov.BaseURL = server.URL
+
+	tests := []struct {
+		name      string
+		model     string
+		secret    string
+		status    int
+		errorType string
+	}{
+		{"validation", "anthropic.claude-unknown", prov.AWSSecretKey, http.StatusBadRequest, "invalid_request_error"},
+		{"bad signature", bedrockTestModel, "wrong-secret", http.StatusForbidden, "api_error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testProv := *prov
+			testProv.AWSSecretKey = tt.secret
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest("POST", "/v1/messages", bytes.NewBufferString(`{}`))
+
+			handler := &ProxyHandler{}
+			handler.forwardClaudeViaBedrock(c, &testProv, tt.model, newBedrockTestRequest(false))
+
+			if w.Code != tt.status {
+				t.Fatalf("expected status %d, got %d", tt.status, w.Code)
+			}
+			var errResp struct {
+				Type  string `json:"type"`
+				Error struct {
+					Type string `json:"type"`
+				} `json:"error"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &errResp); err != nil {
+				t.Fatalf("failed to decode error response: %v", err)
+			}
+			if errResp.Type != "error" || errResp.Error.Type != tt.errorType {
+				t.Fatalf("unexpected error response: %s", w.Body.String())
+			}
+		})
+	}
+}
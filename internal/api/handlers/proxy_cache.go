@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/Mieluoxxx/Siriusx-API/internal/cache"
+	"github.com/Mieluoxxx/Siriusx-API/internal/converter"
+	"github.com/Mieluoxxx/Siriusx-API/internal/mapping"
+	"github.com/Mieluoxxx/Siriusx-API/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// 响应缓存的请求类型（参与缓存键计算，决定流式回放格式）
+const (
+	cacheKindChat     = "chat"
+	cacheKindMessages = "messages"
+)
+
+// responseCacheHeader 响应缓存状态响应头：HIT / MISS / BYPASS
+const responseCacheHeader = "X-Cache"
+
+// withResponseCache 在 Token 或统一模型开启响应缓存时查找缓存，未命中则执行 forward 并缓存非流式成功响应
+// 流式请求命中时将缓存的响应回放为 SSE；客户端 Cache-Control: no-cache 跳过查找，no-store 同时不写入
+func (h *ProxyHandler) withResponseCache(c *gin.Context, kind, modelName string, selected *mapping.ResolvedMapping, req map[string]interface{}, forward func()) {
+	tok := requestToken(c)
+	if h.responseCache == nil || !(selected.ResponseCache || (tok != nil && tok.ResponseCache)) {
+		forward()
+		return
+	}
+
+	var tokenID uint
+	if tok != nil {
+		tokenID = tok.ID
+	}
+	key, err := cache.Key(kind, modelName, tokenID, req)
+	if err != nil {
+		forward()
+		return
+	}
+	stream, _ := req["stream"].(bool)
+
+	noCache, noStore := cache.ParseCacheControl(c.GetHeader("Cache-Control"))
+	if noCache || noStore {
+		h.responseCache.RecordBypass()
+		c.Header(responseCacheHeader, "BYPASS")
+	} else if body, ok := h.responseCache.Get(key); ok {
+		c.Header(responseCacheHeader, "HIT")
+		log.Printf("💾 [响应缓存] 命中 - 模型: %s, 流式: %v, 大小: %d bytes", modelName, stream, len(body))
+		writeCachedResponse(c, kind, body, stream, req)
+		return
+	} else {
+		c.Header(responseCacheHeader, "MISS")
+	}
+
+	if stream || noStore {
+		forward()
+		return
+	}
+
+	writer := &cacheCaptureWriter{ResponseWriter: c.Writer, limit: h.responseCache.MaxBytes()}
+	c.Writer = writer
+	forward()
+	c.Writer = writer.ResponseWriter
+
+	if writer.cacheable() {
+		h.responseCache.Set(key, writer.body.Bytes())
+		log.Printf("💾 [响应缓存] 写入 - 模型: %s, 大小: %d bytes", modelName, writer.body.Len())
+	}
+}
+
+// writeCachedResponse 写出缓存的响应，流式请求按协议回放为 SSE
+func writeCachedResponse(c *gin.Context, kind string, body []byte, stream bool, req map[string]interface{}) {
+	if !stream {
+		c.Data(http.StatusOK, "application/json", body)
+		return
+	}
+
+	events, err := replayResponseStream(kind, body, req)
+	if err != nil {
+		log.Printf("❌ [响应缓存] 回放失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "回放缓存响应失败"})
+		return
+	}
+
+	c.Header("Cache-Control", "no-cache")
+	c.Data(http.StatusOK, "text/event-stream; charset=utf-8", events)
+}
+
+// replayResponseStream 按请求类型将非流式响应回放为 SSE；Chat 请求开启 stream_options.include_usage 时追加 usage chunk
+func replayResponseStream(kind string, body []byte, req map[string]interface{}) ([]byte, error) {
+	if kind == cacheKindMessages {
+		return converter.ReplayClaudeStream(body)
+	}
+	includeUsage := false
+	if options, ok := req["stream_options"].(map[string]interface{}); ok {
+		includeUsage, _ = options["include_usage"].(bool)
+	}
+	return converter.ReplayOpenAIStream(body, includeUsage)
+}
+
+// requestToken 获取认证中间件写入的 Token
+func requestToken(c *gin.Context) *models.Token {
+	if value, exists := c.Get("token"); exists {
+		if tok, ok := value.(*models.Token); ok {
+			return tok
+		}
+	}
+	return nil
+}
+
+// cacheCaptureWriter 在写出响应的同时记录响应体，超过 limit 后停止记录
+type cacheCaptureWriter struct {
+	gin.ResponseWriter
+	body     bytes.Buffer
+	limit    int64
+	overflow bool
+}
+
+// Write 写出并记录响应数据
+func (w *cacheCaptureWriter) Write(data []byte) (int, error) {
+	if !w.overflow {
+		if int64(w.body.Len()+len(data)) > w.limit {
+			w.overflow = true
+			w.body.Reset()
+		} else {
+			w.body.Write(data)
+		}
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+// WriteString 同 Write
+func (w *cacheCaptureWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// cacheable 仅缓存完整记录的 200 JSON 响应
+func (w *cacheCaptureWriter) cacheable() bool {
+	if w.overflow || w.Status() != http.StatusOK || w.body.Len() == 0 {
+		return false
+	}
+	if !strings.Contains(w.Header().Get("Content-Type"), "application/json") {
+		return false
+	}
+	return json.Valid(w.body.Bytes())
+}
@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Mieluoxxx/Siriusx-API/internal/cache"
+	"github.com/Mieluoxxx/Siriusx-API/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// newCountingUpstream 模拟上游，按路径返回固定的 Chat Completions / Messages 响应并记录请求次数
+func newCountingUpstream(t *testing.T, calls *int) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*calls++
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/v1/chat/completions":
+			_, _ = w.Write([]byte(`{"id":"chatcmpl-1","object":"chat.completion","created":1,"model":"gpt-4o-mini","choices":[{"index":0,"message":{"role":"assistant","content":"pong"},"finish_reason":"stop"}],"usage":{"prompt_tokens":1,"completion_tokens":1,"total_tokens":2}}`))
+		case "/v1/messages":
+			_, _ = w.Write([]byte(`{"id":"msg_1","type":"message","role":"assistant","model":"claude-sonnet-4-5","content":[{"type":"text","text":"pong"}],"stop_reason":"end_turn","stop_sequence":null,"usage":{"input_tokens":1,"output_tokens":1}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+// newResponseCacheTestEngine 创建开启响应缓存的测试路由，tok 不为 nil 时模拟认证中间件写入 Token
+func newResponseCacheTestEngine(t *testing.T, targetModel, baseURL string, modelCache bool, tok *models.Token) (*gin.Engine, *cache.ResponseCache) {
+	t.Helper()
+	handler := newMultiProviderTestHandler(t, targetModel, &models.Provider{Name: "upstream", BaseURL: baseURL, APIKey: "sk-test"})
+	for _, resolved := range handler.router.(*stubMultiRouter).resolved {
+		resolved.ResponseCache = modelCache
+	}
+	handler.responseCache = cache.NewResponseCache(nil, nil)
+
+	engine := gin.New()
+	engine.Use(func(c *gin.Context) {
+		if tok != nil {
+			c.Set("token", tok)
+		}
+	})
+	engine.POST("/v1/chat/completions", handler.ChatCompletions)
+	engine.POST("/v1/messages", handler.Messages)
+	return engine, handler.responseCache
+}
+
+func TestResponseCache_ChatCompletions(t *testing.T) {
+	var calls int
+	server := newCountingUpstream(t, &calls)
+	defer server.Close()
+
+	engine, responseCache := newResponseCacheTestEngine(t, "gpt-4o-mini", server.URL, true, nil)
+	send := func(body string, headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/v1/chat/completions", strings.NewReader(body))
+		for key, value := range headers {
+			req.Header.Set(key, value)
+		}
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		return w
+	}
+	const request = `{"model":"my-model","temperature":0,"messages":[{"role":"user","content":"ping"}]}`
+
+	first := send(request, nil)
+	if first.Code != http.StatusOK || first.Header().Get("X-Cache") != "MISS" || calls != 1 {
+		t.Fatalf("expected upstream MISS, got %d %q (calls %d): %s", first.Code, first.Header().Get("X-Cache"), calls, first.Body.String())
+	}
+
+	second := send(request, nil)
+	if second.Header().Get("X-Cache") != "HIT" || calls != 1 || second.Body.String() != first.Body.String() {
+		t.Fatalf("expected cache HIT, got %q (calls %d): %s", second.Header().Get("X-Cache"), calls, second.Body.String())
+	}
+
+	t.Run("stream hit replays SSE", func(t *testing.T) {
+		w := send(`{"model":"my-model","temperature":0,"stream":true,"stream_options":{"include_usage":true},"messages":[{"role":"user","content":"ping"}]}`, nil)
+		if w.Header().Get("X-Cache") != "HIT" || calls != 1 || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/event-stream") {
+			t.Fatalf("expected SSE replay, got %q %q (calls %d)", w.Header().Get("X-Cache"), w.Header().Get("Content-Type"), calls)
+		}
+		body := w.Body.String()
+		if !strings.Contains(body, `"content":"pong"`) || !strings.Contains(body, `"total_tokens":2`) || !strings.HasSuffix(body, "data: [DONE]\n\n") {
+			t.Fatalf("unexpected replayed stream:\n%s", body)
+		}
+	})
+
+	t.Run("cache-control bypass", func(t *testing.T) {
+		w := send(request, map[string]string{"Cache-Control": "no-cache"})
+		if w.Header().Get("X-Cache") != "BYPASS" || calls != 2 {
+			t.Fatalf("expected BYPASS to reach upstream, got %q (calls %d)", w.Header().Get("X-Cache"), calls)
+		}
+	})
+
+	t.Run("different request misses", func(t *testing.T) {
+		w := send(`{"model":"my-model","temperature":0,"messages":[{"role":"user","content":"other"}]}`, nil)
+		if w.Header().Get("X-Cache") != "MISS" || calls != 3 {
+			t.Fatalf("expected MISS, got %q (calls %d)", w.Header().Get("X-Cache"), calls)
+		}
+	})
+
+	stats := responseCache.Stats()
+	if stats.Hits != 2 || stats.Misses != 2 || stats.Bypasses != 1 || stats.Stores != 3 {
+		t.Fatalf("unexpected cache stats: %+v", stats)
+	}
+}
+
+func TestResponseCache_MessagesTokenOptIn(t *testing.T) {
+	var calls int
+	server := newCountingUpstream(t, &calls)
+	defer server.Close()
+
+	send := func(engine *gin.Engine, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest("POST", "/v1/messages", strings.NewReader(body)))
+		return w
+	}
+	const request = `{"model":"my-model","max_tokens":16,"messages":[{"role":"user","content":"ping"}]}`
+
+	t.Run("disabled", func(t *testing.T) {
+		engine, _ := newResponseCacheTestEngine(t, "claude-sonnet-4-5", server.URL, false, &models.Token{ID: 1})
+		send(engine, request)
+		w := send(engine, request)
+		if w.Header().Get("X-Cache") != "" || calls != 2 {
+			t.Fatalf("cache should be disabled, got %q (calls %d)", w.Header().Get("X-Cache"), calls)
+		}
+	})
+
+	t.Run("enabled per token", func(t *testing.T) {
+		calls = 0
+		engine, _ := newResponseCacheTestEngine(t, "claude-sonnet-4-5", server.URL, false, &models.Token{ID: 1, ResponseCache: true})
+		send(engine, request)
+
+		w := send(engine, strings.Replace(request, `"max_tokens":16`, `"max_tokens":16,"stream":true`, 1))
+		if w.Header().Get("X-Cache") != "HIT" || calls != 1 {
+			t.Fatalf("expected cache HIT, got %q (calls %d)", w.Header().Get("X-Cache"), calls)
+		}
+		body := w.Body.String()
+		for _, event := range []string{"event: message_start", `"text":"pong"`, "event: message_stop"} {
+			if !strings.Contains(body, event) {
+				t.Fatalf("replayed stream missing %q:\n%s", event, body)
+			}
+		}
+	})
+}
@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/Mieluoxxx/Siriusx-API/internal/converter"
+	"github.com/Mieluoxxx/Siriusx-API/internal/mapping"
+	"github.com/Mieluoxxx/Siriusx-API/internal/models"
+	"github.com/Mieluoxxx/Siriusx-API/internal/provider"
+	"github.com/gin-gonic/gin"
+)
+
+// usesClaudeAPI 上游是否只接受 Claude Messages 格式（Bedrock、Vertex 上的 Claude 模型，以及 Anthropic 原生接口）
+func (h *ProxyHandler) usesClaudeAPI(prov *models.Provider, targetModel string) bool {
+	switch provider.ProviderType(prov) {
+	case provider.ProviderTypeBedrock:
+		return true
+	case provider.ProviderTypeVertex:
+		return !provider.UsesGeminiAPI(prov, targetModel)
+	case provider.ProviderTypeOpenAI:
+		return !h.shouldConvertToOpenAI(prov, targetModel)
+	}
+	return false
+}
+
+// forwardOpenAIViaClaude 将 OpenAI Chat Completions 请求转换为 Claude Messages 请求，复用 Messages 的转发逻辑，再将响应转换回 OpenAI 格式
+// response_format 以强制工具调用模拟，工具输入还原为 choices[0].message.content
+func (h *ProxyHandler) forwardOpenAIViaClaude(c *gin.Context, prov *models.Provider, providerName, modelName string, selectedMapping *mapping.ResolvedMapping, req map[string]interface{}) {
+	payloadBytes, err := json.Marshal(req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "序列化请求失败"})
+		return
+	}
+
+	var openaiReq converter.OpenAIRequest
+	if err := json.Unmarshal(payloadBytes, &openaiReq); err != nil {
+		log.Printf("❌ [解析失败] OpenAI 请求无法解析: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求格式不符合 OpenAI Chat Completions 规范"})
+		return
+	}
+
+	claudeReq, err := converter.ConvertOpenAIRequestToClaude(&openaiReq)
+	if err != nil {
+		log.Printf("❌ [转换失败] OpenAI→Claude: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("OpenAI 请求转换 Claude 格式失败: %v", err)})
+		return
+	}
+	claudeReq.Model = selectedMapping.TargetModel
+
+	structured, err := converter.EmulateResponseFormat(claudeReq, openaiReq.ResponseFormat)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("OpenAI 请求转换 Claude 格式失败: %v", err)})
+		return
+	}
+
+	emulation := emulateClaudeTools(selectedMapping, claudeReq)
+
+	log.Printf("🔁 [ChatCompletions] 检测到 Claude 上游，执行 OpenAI→Claude 转换 [Provider: %s, Target: %s]", providerName, selectedMapping.TargetModel)
+
+	writer := newClaudeResponseWriter(c.Writer, claudeReq.Stream, withToolEmulation(withStructuredOutput(newOpenAIChatTranslator(), structured), emulation))
+	c.Writer = writer
+	h.dispatchConvertedClaudeRequest(c, writer, prov, providerName, modelName, selectedMapping, claudeReq, structured, emulation)
+	c.Writer = writer.ResponseWriter
+	writer.finish()
+}
+
+// openAIChatTranslator 将 Claude 响应转换为 OpenAI Chat Completions 格式
+type openAIChatTranslator struct {
+	converter *converter.ClaudeOpenAIStreamConverter
+}
+
+// newOpenAIChatTranslator 创建 Chat Completions 响应转换器
+func newOpenAIChatTranslator() *openAIChatTranslator {
+	return &openAIChatTranslator{converter: converter.NewClaudeOpenAIStreamConverter()}
+}
+
+// label 日志中使用的协议名
+func (t *openAIChatTranslator) label() string {
+	return "OpenAI"
+}
+
+// streamEvent 将 Claude 事件转换为 OpenAI 流式响应块（data: {...}\n\n），最后一个块之后追加 data: [DONE]
+func (t *openAIChatTranslator) streamEvent(data []byte) ([]byte, error) {
+	chunks, err := t.converter.ProcessEvent(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	for _, chunk := range chunks {
+		chunkData, err := json.Marshal(chunk)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(&out, "data: %s\n\n", chunkData)
+		if chunk.Choices[0].FinishReason != nil {
+			out.WriteString("data: [DONE]\n\n")
+		}
+	}
+	return out.Bytes(), nil
+}
+
+// streamError 流式转换失败时以 OpenAI 错误格式写出
+func (t *openAIChatTranslator) streamError(err error) []byte {
+	errData, _ := json.Marshal(responsesErrorBody(http.StatusInternalServerError, err.Error(), ""))
+	return []byte(fmt.Sprintf("data: %s\n\ndata: [DONE]\n\n", errData))
+}
+
+// response 转换非流式响应
+func (t *openAIChatTranslator) response(resp *converter.ClaudeResponse) (interface{}, error) {
+	return converter.ConvertClaudeResponseToOpenAI(resp)
+}
+
+// errorBody 构建 OpenAI API 错误响应体
+func (t *openAIChatTranslator) errorBody(status int, message string) interface{} {
+	return responsesErrorBody(status, message, "")
+}
@@ -0,0 +1,225 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/Mieluoxxx/Siriusx-API/internal/converter"
+	"github.com/gin-gonic/gin"
+)
+
+// claudeResponseTranslator 将 Claude Messages 响应转换为客户端协议格式
+// Gemini、Responses 等入口先把请求转换为 Claude 格式复用 Messages 转发链路，响应经由该接口转换回来
+type claudeResponseTranslator interface {
+	// label 日志中使用的协议名
+	label() string
+	// streamEvent 转换单个 Claude SSE 事件的 data，返回需要写出的原始字节
+	streamEvent(data []byte) ([]byte, error)
+	// streamError 流式转换失败时写出的错误事件
+	streamError(err error) []byte
+	// response 转换非流式 Claude 响应
+	response(resp *converter.ClaudeResponse) (interface{}, error)
+	// errorBody 构建客户端协议的错误响应体
+	errorBody(status int, message string) interface{}
+}
+
+// claudeResponseWriter 将 Messages 转发链路写出的 Claude 响应转换为客户端协议格式
+// 非流式响应与错误响应先缓存，由 finish 统一转换；流式响应经 SSE 解析器按事件逐个转换并立即写出
+type claudeResponseWriter struct {
+	gin.ResponseWriter
+	stream     bool
+	status     int
+	buffer     bytes.Buffer
+	parser     *converter.SSEParser
+	translator claudeResponseTranslator
+	started    bool // 流式响应头已写出
+	failed     bool // 流式转换出错，忽略后续数据
+}
+
+// newClaudeResponseWriter 创建响应转换 writer
+func newClaudeResponseWriter(w gin.ResponseWriter, stream bool, translator claudeResponseTranslator) *claudeResponseWriter {
+	return &claudeResponseWriter{
+		ResponseWriter: w,
+		stream:         stream,
+		status:         http.StatusOK,
+		parser:         converter.NewSSEFeedParser(),
+		translator:     translator,
+	}
+}
+
+// WriteHeader 仅记录状态码，实际状态码在转换后写出
+func (w *claudeResponseWriter) WriteHeader(code int) {
+	if !w.started {
+		w.status = code
+	}
+}
+
+// WriteHeaderNow 延迟到转换后写出
+func (w *claudeResponseWriter) WriteHeaderNow() {}
+
+// WriteString 同 Write
+func (w *claudeResponseWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Write 缓存或转换 Claude 响应数据
+func (w *claudeResponseWriter) Write(data []byte) (int, error) {
+	if !w.stream || w.status >= 400 {
+		return w.buffer.Write(data)
+	}
+	if w.failed {
+		return len(data), nil
+	}
+
+	if !w.started {
+		w.prepareHeaders("text/event-stream; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.ResponseWriter.WriteHeader(http.StatusOK)
+		w.started = true
+	}
+
+	events, err := w.parser.Feed(data)
+	for _, event := range events {
+		if writeErr := w.writeStreamEvent([]byte(event.Data)); writeErr != nil || w.failed {
+			return len(data), writeErr
+		}
+	}
+	if err != nil {
+		return len(data), w.failStream(err)
+	}
+	return len(data), nil
+}
+
+// claudeResponse 返回已缓存的非流式成功响应
+func (w *claudeResponseWriter) claudeResponse() (*converter.ClaudeResponse, bool) {
+	if w.stream || w.status >= 400 {
+		return nil, false
+	}
+	var resp converter.ClaudeResponse
+	if err := json.Unmarshal(w.buffer.Bytes(), &resp); err != nil {
+		return nil, false
+	}
+	return &resp, true
+}
+
+// reset 丢弃已缓存的非流式响应，用于重试
+func (w *claudeResponseWriter) reset() {
+	w.buffer.Reset()
+	w.status = http.StatusOK
+}
+
+// Flush 流式响应开始后才向客户端刷新
+func (w *claudeResponseWriter) Flush() {
+	if w.started {
+		w.ResponseWriter.Flush()
+	}
+}
+
+// writeStreamEvent 转换单个 Claude SSE 事件并写出
+func (w *claudeResponseWriter) writeStreamEvent(eventData []byte) error {
+	out, err := w.translator.streamEvent(eventData)
+	if err != nil {
+		return w.failStream(err)
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	_, err = w.ResponseWriter.Write(out)
+	return err
+}
+
+// failStream 流式转换失败时写出错误事件，并忽略后续数据
+func (w *claudeResponseWriter) failStream(err error) error {
+	log.Printf("❌ [流式转换失败] Claude→%s: %v", w.translator.label(), err)
+	w.failed = true
+	_, writeErr := w.ResponseWriter.Write(w.translator.streamError(err))
+	return writeErr
+}
+
+// finish 在 Messages 转发链路结束后写出转换后的响应
+func (w *claudeResponseWriter) finish() {
+	label := w.translator.label()
+	if w.status >= 400 {
+		w.writeError()
+		return
+	}
+
+	if w.stream {
+		if !w.started {
+			w.writeJSON(http.StatusBadGateway, w.translator.errorBody(http.StatusBadGateway, "上游未返回流式响应"))
+			return
+		}
+		// 最后一个事件可能缺少结尾空行
+		if event := w.parser.Flush(); event != nil && !w.failed {
+			_ = w.writeStreamEvent([]byte(event.Data))
+		}
+		w.ResponseWriter.Flush()
+		log.Printf("✅ [完成] Claude→%s 流式响应转换完成", label)
+		return
+	}
+
+	var claudeResp converter.ClaudeResponse
+	if err := json.Unmarshal(w.buffer.Bytes(), &claudeResp); err != nil {
+		log.Printf("❌ [解析失败] Claude 响应: %v", err)
+		w.writeJSON(http.StatusBadGateway, w.translator.errorBody(http.StatusBadGateway, "解析上游响应失败"))
+		return
+	}
+
+	resp, err := w.translator.response(&claudeResp)
+	if err != nil {
+		log.Printf("❌ [转换失败] Claude→%s: %v", label, err)
+		w.writeJSON(http.StatusBadGateway, w.translator.errorBody(http.StatusBadGateway, "上游响应转换 "+label+" 格式失败"))
+		return
+	}
+
+	w.writeJSON(http.StatusOK, resp)
+	log.Printf("✅ [完成] Claude→%s 非流式响应转换成功，stop_reason: %s", label, claudeResp.StopReason)
+}
+
+// writeError 将缓存的错误响应（Claude 格式或网关错误）转换为客户端协议的错误格式
+func (w *claudeResponseWriter) writeError() {
+	message := "上游返回错误响应"
+
+	var errResp struct {
+		Error json.RawMessage `json:"error"`
+	}
+	if err := json.Unmarshal(w.buffer.Bytes(), &errResp); err == nil && len(errResp.Error) > 0 {
+		var detail struct {
+			Message string `json:"message"`
+		}
+		var text string
+		if err := json.Unmarshal(errResp.Error, &text); err == nil && text != "" {
+			message = text
+		} else if err := json.Unmarshal(errResp.Error, &detail); err == nil && detail.Message != "" {
+			message = detail.Message
+		}
+	}
+
+	w.writeJSON(w.status, w.translator.errorBody(w.status, message))
+}
+
+// writeJSON 直接向客户端写出 JSON 响应（覆盖上游复制过来的 Content-Length 等响应头）
+func (w *claudeResponseWriter) writeJSON(status int, body interface{}) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		log.Printf("❌ [序列化失败] %s 响应: %v", w.translator.label(), err)
+		status = http.StatusInternalServerError
+		data, _ = json.Marshal(w.translator.errorBody(status, "序列化响应失败"))
+	}
+
+	w.prepareHeaders("application/json; charset=utf-8")
+	w.ResponseWriter.WriteHeader(status)
+	if _, err := w.ResponseWriter.Write(data); err != nil {
+		log.Printf("❌ [响应写入失败] %s 响应: %v", w.translator.label(), err)
+	}
+}
+
+// prepareHeaders 设置转换后的 Content-Type，并移除与原始响应体绑定的响应头
+func (w *claudeResponseWriter) prepareHeaders(contentType string) {
+	header := w.Header()
+	header.Del("Content-Length")
+	header.Del("Content-Encoding")
+	header.Set("Content-Type", contentType)
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Mieluoxxx/Siriusx-API/internal/converter"
+	"github.com/gin-gonic/gin"
+)
+
+// 流式响应经 SSE 解析器切分：单独的 CR 换行、注释、多行 data 与缺少结尾空行的最后一个事件都应正确处理
+func TestClaudeResponseWriter_SSEFraming(t *testing.T) {
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	writer := newClaudeResponseWriter(c.Writer, true, newOpenAIChatTranslator())
+
+	input := ": ping\r\r" +
+		"event: message_start\rdata: {\"type\":\"message_start\",\"message\":{\"id\":\"msg_1\",\"type\":\"message\",\"role\":\"assistant\",\"model\":\"m\",\"content\":[],\"usage\":{\"input_tokens\":3,\"output_tokens\":0}}}\r\r" +
+		"event: content_block_start\r\ndata: {\"type\":\"content_block_start\",\"index\":0,\r\ndata: \"content_block\":{\"type\":\"text\",\"text\":\"\"}}\r\n\r\n" +
+		"event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"text_delta\",\"text\":\"Hi\"}}\n\n" +
+		"event: message_delta\ndata: {\"type\":\"message_delta\",\"delta\":{\"stop_reason\":\"end_turn\"},\"usage\":{\"output_tokens\":1}}\n\n" +
+		"event: message_stop\ndata: {\"type\":\"message_stop\"}"
+	for i := 0; i < len(input); i += 7 {
+		end := min(i+7, len(input))
+		if _, err := writer.Write([]byte(input[i:end])); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	writer.finish()
+
+	body := rec.Body.String()
+	if !strings.Contains(body, `"role":"assistant"`) || !strings.Contains(body, `"content":"Hi"`) || !strings.Contains(body, `"finish_reason":"stop"`) {
+		t.Fatalf("unexpected converted stream: %s", body)
+	}
+	if !strings.HasSuffix(body, "data: [DONE]\n\n") {
+		t.Fatalf("stream should end with [DONE]: %s", body)
+	}
+}
+
+// 超出 MaxSSEEventSize 的事件应中止流式转换并写出错误事件
+func TestClaudeResponseWriter_OversizedEvent(t *testing.T) {
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	writer := newClaudeResponseWriter(c.Writer, true, newOpenAIChatTranslator())
+
+	if _, err := writer.Write([]byte("data: " + strings.Repeat("a", converter.MaxSSEEventSize) + "\n\n")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := writer.Write([]byte("data: {\"type\":\"message_stop\"}\n\n")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	writer.finish()
+
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "sse event exceeds size limit") || strings.Count(rec.Body.String(), "data: ") != 2 {
+		t.Fatalf("expected a single error event, got %q", rec.Body.String())
+	}
+}
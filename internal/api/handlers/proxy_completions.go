@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/Mieluoxxx/Siriusx-API/internal/converter"
+	"github.com/Mieluoxxx/Siriusx-API/internal/mapping"
+	"github.com/Mieluoxxx/Siriusx-API/internal/models"
+	"github.com/Mieluoxxx/Siriusx-API/internal/provider"
+	"github.com/gin-gonic/gin"
+)
+
+// Completions 处理 OpenAI 旧版 Completions 请求
+// 开启 completions_api 的供应商直接转发，其余 OpenAI 兼容供应商转换为 Chat Completions
+func (h *ProxyHandler) Completions(c *gin.Context) {
+	req, selectedMapping, prov, ok := h.resolveOpenAIRequest(c, "Completions")
+	if !ok {
+		return
+	}
+	modelName := req["model"].(string)
+	req["model"] = selectedMapping.TargetModel
+
+	if provider.SupportsCompletionsAPI(prov) {
+		log.Printf("🔀 [Completions] 映射选择 - 统一模型: %s -> 供应商: %s (Completions), 目标模型: %s",
+			modelName, prov.Name, selectedMapping.TargetModel)
+		h.forwardRequest(c, prov, req, provider.EndpointCompletions)
+		return
+	}
+
+	if !provider.SupportsOpenAIChat(prov) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("%s 类型的供应商不支持 OpenAI Completions 接口，请使用 /v1/chat/completions", provider.ProviderType(prov)),
+		})
+		return
+	}
+
+	log.Printf("🔁 [Completions] 执行 Completions→Chat Completions 转换 [Provider: %s, Target: %s]",
+		prov.Name, selectedMapping.TargetModel)
+	h.forwardCompletionsViaChat(c, prov, selectedMapping.TargetModel, req)
+}
+
+// ImageGenerations 处理 OpenAI Images 生成请求，请求与响应（b64_json / url）原样转发
+func (h *ProxyHandler) ImageGenerations(c *gin.Context) {
+	req, selectedMapping, prov, ok := h.resolveOpenAIRequest(c, "Images")
+	if !ok {
+		return
+	}
+
+	if !provider.SupportsImages(prov) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("%s 类型的供应商不支持 OpenAI Images 接口", provider.ProviderType(prov)),
+		})
+		return
+	}
+
+	log.Printf("🔀 [Images] 映射选择 - 统一模型: %s -> 供应商: %s, 目标模型: %s",
+		req["model"], prov.Name, selectedMapping.TargetModel)
+	req["model"] = selectedMapping.TargetModel
+	h.forwardRequest(c, prov, req, provider.EndpointImages)
+}
+
+// resolveOpenAIRequest 解析 OpenAI 风格请求体并按 model 选择供应商，失败时已写入错误响应
+func (h *ProxyHandler) resolveOpenAIRequest(c *gin.Context, endpointName string) (map[string]interface{}, *mapping.ResolvedMapping, *models.Provider, bool) {
+	req, bodyBytes, err := parseJSONBody(c)
+	if err != nil {
+		if bodyBytes == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无法读取请求体"})
+		} else {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的 JSON 格式"})
+		}
+		return nil, nil, nil, false
+	}
+
+	modelName, ok := req["model"].(string)
+	if !ok || modelName == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "缺少 model 参数"})
+		return nil, nil, nil, false
+	}
+
+	log.Printf("📥 [%s] 收到请求 - 模型: %s, IP: %s", endpointName, modelName, c.ClientIP())
+
+	selectedMapping, err := h.resolveMapping(c.Request.Context(), modelName)
+	if err != nil {
+		if !h.handleOpenAIRouterError(c, err) {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "解析模型映射失败"})
+		}
+		return nil, nil, nil, false
+	}
+
+	prov, err := h.providerService.GetProvider(selectedMapping.ProviderID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取供应商信息失败"})
+		return nil, nil, nil, false
+	}
+	return req, selectedMapping, prov, true
+}
+
+// forwardCompletionsViaChat 将旧版 Completions 请求转换为 Chat Completions 请求再转发，响应转换回 Completions 格式
+func (h *ProxyHandler) forwardCompletionsViaChat(c *gin.Context, prov *models.Provider, targetModel string, req map[string]interface{}) {
+	chatReq, err := converter.ConvertCompletionToChat(req)
+	if err != nil {
+		log.Printf("❌ [转换失败] Completions→Chat: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Completions 请求转换 Chat Completions 格式失败: %v", err)})
+		return
+	}
+	h.sanitizeRequest(chatReq, prov.Name)
+
+	body, err := json.Marshal(chatReq)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "序列化请求失败"})
+		return
+	}
+
+	targetURL := provider.EndpointURL(prov, provider.EndpointChat, h.templateVars(c, prov, targetModel))
+	log.Printf("➡️  [转发] 目标URL: %s, 请求体大小: %d bytes", targetURL, len(body))
+
+	resp, status, err := h.sendUpstreamJSON(c, prov, targetURL, targetModel, body)
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		respBody, _ := readUpstreamBody(resp)
+		log.Printf("❌ [完成] 状态: %d (错误响应)", resp.StatusCode)
+		c.Data(resp.StatusCode, "application/json", respBody)
+		return
+	}
+
+	if stream, _ := chatReq["stream"].(bool); stream {
+		c.Header("Content-Type", "text/event-stream; charset=utf-8")
+		c.Header("Cache-Control", "no-cache")
+		c.Status(http.StatusOK)
+
+		totalBytes, err := pipeStream(c, converter.ConvertChatStreamToCompletion(c.Request.Context(), resp.Body))
+		if err != nil {
+			log.Printf("❌ [流式转发] Chat→Completions: %v", err)
+			return
+		}
+		log.Printf("✅ [完成] Chat→Completions 流式响应转换完成，共 %d bytes", totalBytes)
+		return
+	}
+
+	respBody, err := readUpstreamBody(resp)
+	if err != nil {
+		log.Printf("❌ [响应失败] 读取响应体失败: %v", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "读取上游响应失败"})
+		return
+	}
+
+	var chatResp converter.OpenAIResponse
+	if err := json.Unmarshal(respBody, &chatResp); err != nil {
+		log.Printf("❌ [解析失败] Chat Completions 响应: %v", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "解析上游响应失败"})
+		return
+	}
+
+	completionResp, err := converter.ConvertChatToCompletion(&chatResp)
+	if err != nil {
+		log.Printf("❌ [转换失败] Chat→Completions: %v", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "上游响应转换 Completions 格式失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, completionResp)
+	log.Printf("✅ [完成] Chat→Completions 非流式响应转换成功，Tokens: prompt=%d + completion=%d = %d",
+		chatResp.Usage.PromptTokens, chatResp.Usage.CompletionTokens, chatResp.Usage.TotalTokens)
+}
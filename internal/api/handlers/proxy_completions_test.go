@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Mieluoxxx/Siriusx-API/internal/converter"
+	"github.com/Mieluoxxx/Siriusx-API/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// newOpenAIEndpointStandIn 模拟 OpenAI 兼容上游，记录请求路径与请求体
+func newOpenAIEndpointStandIn(t *testing.T, path *string, captured *map[string]interface{}) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*path = r.URL.Path
+		body, _ := io.ReadAll(r.Body)
+		*captured = nil
+		_ = json.Unmarshal(body, captured)
+
+		switch r.URL.Path {
+		case "/v1/completions":
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"id":"cmpl-1","object":"text_completion","model":"gpt-3.5-turbo-instruct","choices":[{"text":" native","index":0,"logprobs":null,"finish_reason":"stop"}],"usage":{"prompt_tokens":2,"completion_tokens":1,"total_tokens":3}}`))
+		case "/v1/chat/completions":
+			if stream, _ := (*captured)["stream"].(bool); stream {
+				w.Header().Set("Content-Type", "text/event-stream")
+				_, _ = w.Write([]byte(`data: {"id":"chatcmpl-9","object":"chat.completion.chunk","created":1,"model":"gpt-4o-mini","choices":[{"index":0,"delta":{"role":"assistant","content":"po"},"finish_reason":null}]}` + "\n\n"))
+				_, _ = w.Write([]byte(`data: {"id":"chatcmpl-9","object":"chat.completion.chunk","created":1,"model":"gpt-4o-mini","choices":[{"index":0,"delta":{"content":"ng"},"finish_reason":"stop"}]}` + "\n\n"))
+				_, _ = w.Write([]byte("data: [DONE]\n\n"))
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"id":"chatcmpl-9","object":"chat.completion","created":1,"model":"gpt-4o-mini","choices":[{"index":0,"message":{"role":"assistant","content":"pong"},"finish_reason":"length"}],"usage":{"prompt_tokens":5,"completion_tokens":1,"total_tokens":6}}`))
+		case "/v1/images/generations":
+			w.Header().Set("Content-Type", "application/json")
+			if (*captured)["response_format"] == "b64_json" {
+				_, _ = w.Write([]byte(`{"created":1,"data":[{"b64_json":"iVBORw0KGgo=","revised_prompt":"a cat"}]}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"created":1,"data":[{"url":"https://images.example.com/cat.png"}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestCompletions_ViaChat(t *testing.T) {
+	var path string
+	var captured map[string]interface{}
+	server := newOpenAIEndpointStandIn(t, &path, &captured)
+	defer server.Close()
+
+	engine := gin.New()
+	engine.POST("/v1/completions", newMultiProviderTestHandler(t, "gpt-4o-mini",
+		&models.Provider{Name: "openai", BaseURL: server.URL, APIKey: "sk-test"}).Completions)
+
+	t.Run("non-stream", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest("POST", "/v1/completions",
+			strings.NewReader(`{"model":"my-model","prompt":"ping","temperature":0.2,"stop":"\n"}`)))
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("unexpected status code: %d, body: %s", w.Code, w.Body.String())
+		}
+		if path != "/v1/chat/completions" || captured["model"] != "gpt-4o-mini" || captured["max_tokens"] != float64(16) || captured["stop"] != "\n" {
+			t.Fatalf("unexpected upstream request %s: %+v", path, captured)
+		}
+		messages, _ := captured["messages"].([]interface{})
+		if len(messages) != 1 || messages[0].(map[string]interface{})["content"] != "ping" {
+			t.Fatalf("unexpected upstream messages: %+v", captured["messages"])
+		}
+
+		var resp converter.CompletionResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if resp.Object != "text_completion" || resp.ID != "cmpl-9" || resp.Choices[0].Text != "pong" || *resp.Choices[0].FinishReason != "length" {
+			t.Fatalf("unexpected response: %s", w.Body.String())
+		}
+		if resp.Usage == nil || resp.Usage.TotalTokens != 6 {
+			t.Fatalf("unexpected usage: %s", w.Body.String())
+		}
+	})
+
+	t.Run("stream", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest("POST", "/v1/completions",
+			strings.NewReader(`{"model":"my-model","prompt":["ping"],"stream":true,"max_tokens":8}`)))
+
+		if captured["max_tokens"] != float64(8) || captured["stream"] != true {
+			t.Fatalf("unexpected upstream request: %+v", captured)
+		}
+
+		events := strings.Split(strings.TrimSpace(w.Body.String()), "\n\n")
+		if len(events) != 3 || events[2] != "data: [DONE]" {
+			t.Fatalf("unexpected stream output:\n%s", w.Body.String())
+		}
+		var text strings.Builder
+		for _, event := range events[:2] {
+			var chunk converter.CompletionResponse
+			if err := json.Unmarshal([]byte(strings.TrimPrefix(event, "data: ")), &chunk); err != nil {
+				t.Fatalf("invalid stream chunk %q: %v", event, err)
+			}
+			if chunk.Object != "text_completion" {
+				t.Fatalf("unexpected chunk object: %s", event)
+			}
+			text.WriteString(chunk.Choices[0].Text)
+		}
+		if text.String() != "pong" {
+			t.Fatalf("unexpected stream text: %q", text.String())
+		}
+	})
+
+	t.Run("unsupported parameters rejected", func(t *testing.T) {
+		for _, body := range []string{
+			`{"model":"my-model","prompt":["a","b"]}`,
+			`{"model":"my-model","prompt":[1,2,3]}`,
+			`{"model":"my-model","prompt":"a","echo":true}`,
+			`{"model":"my-model","prompt":"a","suffix":"b"}`,
+		} {
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, httptest.NewRequest("POST", "/v1/completions", strings.NewReader(body)))
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("expected 400 for %s, got %d %s", body, w.Code, w.Body.String())
+			}
+		}
+	})
+}
+
+func TestCompletions_Native(t *testing.T) {
+	var path string
+	var captured map[string]interface{}
+	server := newOpenAIEndpointStandIn(t, &path, &captured)
+	defer server.Close()
+
+	engine := gin.New()
+	engine.POST("/v1/completions", newMultiProviderTestHandler(t, "gpt-3.5-turbo-instruct",
+		&models.Provider{Name: "openai", BaseURL: server.URL, APIKey: "sk-test", CompletionsAPI: true}).Completions)
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest("POST", "/v1/completions",
+		strings.NewReader(`{"model":"my-model","prompt":["a","b"],"echo":true}`)))
+
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `" native"`) {
+		t.Fatalf("unexpected response: %d %s", w.Code, w.Body.String())
+	}
+	if path != "/v1/completions" || captured["model"] != "gpt-3.5-turbo-instruct" || captured["echo"] != true {
+		t.Fatalf("unexpected upstream request %s: %+v", path, captured)
+	}
+}
+
+func TestImageGenerations(t *testing.T) {
+	var path string
+	var captured map[string]interface{}
+	server := newOpenAIEndpointStandIn(t, &path, &captured)
+	defer server.Close()
+
+	engine := gin.New()
+	engine.POST("/v1/images/generations", newMultiProviderTestHandler(t, "dall-e-3",
+		&models.Provider{Name: "openai", BaseURL: server.URL, APIKey: "sk-test"}).ImageGenerations)
+
+	for format, want := range map[string]string{
+		"b64_json": `"b64_json":"iVBORw0KGgo="`,
+		"url":      `"url":"https://images.example.com/cat.png"`,
+	} {
+		t.Run(format, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, httptest.NewRequest("POST", "/v1/images/generations",
+				strings.NewReader(`{"model":"my-model","prompt":"a cat","response_format":"`+format+`","size":"1024x1024"}`)))
+
+			if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), want) {
+				t.Fatalf("unexpected response: %d %s", w.Code, w.Body.String())
+			}
+			if path != "/v1/images/generations" || captured["model"] != "dall-e-3" || captured["size"] != "1024x1024" {
+				t.Fatalf("unexpected upstream request %s: %+v", path, captured)
+			}
+		})
+	}
+
+	t.Run("unsupported provider type", func(t *testing.T) {
+		engine := gin.New()
+		engine.POST("/v1/images/generations", newMultiProviderTestHandler(t, "llava", newOllamaTestProvider(server.URL)).ImageGenerations)
+
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest("POST", "/v1/images/generations", strings.NewReader(`{"model":"my-model","prompt":"a cat"}`)))
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("expected 400, got %d %s", w.Code, w.Body.String())
+		}
+	})
+}
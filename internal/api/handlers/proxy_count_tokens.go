@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/Mieluoxxx/Siriusx-API/internal/mapping"
+	"github.com/Mieluoxxx/Siriusx-API/internal/models"
+	"github.com/Mieluoxxx/Siriusx-API/internal/provider"
+	"github.com/Mieluoxxx/Siriusx-API/internal/tokenizer"
+	"github.com/gin-gonic/gin"
+)
+
+// MessagesCountTokens 计算 Claude 请求的 token 用量
+// 映射到 Anthropic 原生供应商时转发到其 /v1/messages/count_tokens，否则（或上游失败时）使用内嵌 BPE 词表本地计算
+func (h *ProxyHandler) MessagesCountTokens(c *gin.Context) {
+	req, bodyBytes, err := parseJSONBody(c)
+	if err != nil {
+		switch {
+		case bodyBytes == nil:
+			h.respondClaudeError(c, http.StatusBadRequest, "invalid_request_error", "无法读取请求体")
+		case len(bodyBytes) == 0:
+			h.respondClaudeError(c, http.StatusBadRequest, "invalid_request_error", "请求体不能为空")
+		default:
+			h.respondClaudeError(c, http.StatusBadRequest, "invalid_request_error", "无效的 JSON 格式")
+		}
+		return
+	}
+
+	modelName, _ := req["model"].(string)
+	targetModel := modelName
+
+	if selectedMapping, prov := h.resolveCountTokensProvider(c, modelName); prov != nil {
+		targetModel = selectedMapping.TargetModel
+		if h.usesAnthropicCountTokens(prov, targetModel) && h.forwardCountTokens(c, prov, targetModel, req) {
+			return
+		}
+	}
+
+	enc := tokenizer.ForModel(targetModel)
+	inputTokens := enc.CountClaudeRequest(req)
+	log.Printf("📊 [CountTokens] 本地计算 - 模型: %s, 编码: %s, input_tokens: %d", targetModel, enc.Name(), inputTokens)
+
+	c.JSON(http.StatusOK, gin.H{
+		"type":         "message",
+		"input_tokens": inputTokens,
+		"usage": gin.H{
+			"input_tokens":                inputTokens,
+			"output_tokens":               0,
+			"cache_creation_input_tokens": 0,
+			"cache_read_input_tokens":     0,
+		},
+	})
+}
+
+// resolveCountTokensProvider 按 model 选择供应商；未配置路由或解析失败时返回 nil，由调用方本地计算
+func (h *ProxyHandler) resolveCountTokensProvider(c *gin.Context, modelName string) (*mapping.ResolvedMapping, *models.Provider) {
+	if h.router == nil || modelName == "" {
+		return nil, nil
+	}
+
+	selectedMapping, err := h.resolveMapping(c.Request.Context(), modelName)
+	if err != nil {
+		log.Printf("⚠️  [CountTokens] 解析模型映射失败，使用本地计算 - 模型: %s, 错误: %v", modelName, err)
+		return nil, nil
+	}
+	prov, err := h.providerService.GetProvider(selectedMapping.ProviderID)
+	if err != nil {
+		log.Printf("⚠️  [CountTokens] 获取供应商信息失败，使用本地计算 - 模型: %s, 错误: %v", modelName, err)
+		return nil, nil
+	}
+	return selectedMapping, prov
+}
+
+// usesAnthropicCountTokens 供应商是否为 Anthropic 原生接口（与 dispatchClaudeRequest 的原生透传条件一致）
+func (h *ProxyHandler) usesAnthropicCountTokens(prov *models.Provider, targetModel string) bool {
+	switch provider.ProviderType(prov) {
+	case provider.ProviderTypeBedrock, provider.ProviderTypeVertex, provider.ProviderTypeGemini, provider.ProviderTypeOllama:
+		return false
+	}
+	return !provider.UsesGeminiAPI(prov, targetModel) && !h.shouldConvertToOpenAI(prov, targetModel)
+}
+
+// forwardCountTokens 转发到上游 count_tokens 接口，成功时写入响应并返回 true；失败时不写响应，由调用方回退本地计算
+func (h *ProxyHandler) forwardCountTokens(c *gin.Context, prov *models.Provider, targetModel string, req map[string]interface{}) bool {
+	upstreamReq := make(map[string]interface{}, len(req))
+	for key, value := range req {
+		upstreamReq[key] = value
+	}
+	upstreamReq["model"] = targetModel
+
+	body, err := json.Marshal(upstreamReq)
+	if err != nil {
+		return false
+	}
+
+	targetURL := provider.EndpointURL(prov, provider.EndpointCountTokens, h.templateVars(c, prov, targetModel))
+	log.Printf("➡️  [CountTokens] 转发到上游 - 供应商: %s, 目标URL: %s", prov.Name, targetURL)
+
+	headers := http.Header{}
+	setAnthropicHeaders(c, headers)
+	resp, _, err := h.sendUpstreamJSONWithHeaders(c, prov, targetURL, targetModel, body, headers)
+	if err != nil {
+		log.Printf("⚠️  [CountTokens] 上游请求失败，使用本地计算: %v", err)
+		return false
+	}
+	defer resp.Body.Close()
+
+	respBody, err := readUpstreamBody(resp)
+	if err != nil || resp.StatusCode < 200 || resp.StatusCode >= 300 || !json.Valid(respBody) {
+		log.Printf("⚠️  [CountTokens] 上游响应无效 (状态: %d)，使用本地计算", resp.StatusCode)
+		return false
+	}
+
+	c.Data(resp.StatusCode, "application/json", respBody)
+	log.Printf("✅ [CountTokens] 上游计算完成 - 供应商: %s, 目标模型: %s", prov.Name, targetModel)
+	return true
+}
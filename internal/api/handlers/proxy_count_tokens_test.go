@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Mieluoxxx/Siriusx-API/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+const countTokensRequest = `{"model":"my-model","system":[{"type":"text","text":"be brief"}],"messages":[{"role":"user","content":"hello world"}]}`
+
+func TestMessagesCountTokens_ForwardsToAnthropic(t *testing.T) {
+	var captured map[string]interface{}
+	var version string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/messages/count_tokens" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		version = r.Header.Get("anthropic-version")
+		body, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(body, &captured)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"input_tokens":42}`))
+	}))
+	defer server.Close()
+
+	engine := gin.New()
+	engine.POST("/v1/messages/count_tokens", newMultiProviderTestHandler(t, "claude-sonnet-4-5",
+		&models.Provider{Name: "anthropic", BaseURL: server.URL, APIKey: "sk-ant"}).MessagesCountTokens)
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest("POST", "/v1/messages/count_tokens", strings.NewReader(countTokensRequest)))
+
+	if w.Code != http.StatusOK || strings.TrimSpace(w.Body.String()) != `{"input_tokens":42}` {
+		t.Fatalf("unexpected response: %d %s", w.Code, w.Body.String())
+	}
+	if captured["model"] != "claude-sonnet-4-5" || version != "2023-06-01" {
+		t.Fatalf("unexpected upstream request (version %q): %+v", version, captured)
+	}
+	if _, ok := captured["system"].([]interface{}); !ok {
+		t.Fatalf("system blocks should be forwarded unchanged: %+v", captured["system"])
+	}
+}
+
+func TestMessagesCountTokens_LocalFallback(t *testing.T) {
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	countLocally := func(t *testing.T, targetModel string, prov *models.Provider) float64 {
+		t.Helper()
+		engine := gin.New()
+		engine.POST("/v1/messages/count_tokens", newMultiProviderTestHandler(t, targetModel, prov).MessagesCountTokens)
+
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest("POST", "/v1/messages/count_tokens", strings.NewReader(countTokensRequest)))
+		if w.Code != http.StatusOK {
+			t.Fatalf("unexpected status code: %d, body: %s", w.Code, w.Body.String())
+		}
+
+		var payload struct {
+			InputTokens float64 `json:"input_tokens"`
+			Usage       struct {
+				InputTokens float64 `json:"input_tokens"`
+			} `json:"usage"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &payload); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if payload.InputTokens == 0 || payload.InputTokens != payload.Usage.InputTokens {
+			t.Fatalf("unexpected local count: %s", w.Body.String())
+		}
+		return payload.InputTokens
+	}
+
+	t.Run("anthropic upstream error", func(t *testing.T) {
+		countLocally(t, "claude-sonnet-4-5", &models.Provider{Name: "anthropic", BaseURL: server.URL, APIKey: "sk-ant"})
+		if calls != 1 {
+			t.Fatalf("expected one upstream attempt, got %d", calls)
+		}
+	})
+
+	t.Run("non-anthropic upstream", func(t *testing.T) {
+		calls = 0
+		countLocally(t, "gpt-4o-mini", &models.Provider{Name: "openai", BaseURL: server.URL, APIKey: "sk-test"})
+		countLocally(t, "gemini-2.5-flash", newGeminiTestProvider(server.URL))
+		if calls != 0 {
+			t.Fatalf("non-anthropic upstreams should not be called, got %d", calls)
+		}
+	})
+}
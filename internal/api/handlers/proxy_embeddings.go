@@ -0,0 +1,326 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/Mieluoxxx/Siriusx-API/internal/converter"
+	"github.com/Mieluoxxx/Siriusx-API/internal/mapping"
+	"github.com/Mieluoxxx/Siriusx-API/internal/models"
+	"github.com/Mieluoxxx/Siriusx-API/internal/provider"
+	"github.com/gin-gonic/gin"
+)
+
+// maxEmbeddingAttempts 单个 Embeddings 请求最多尝试的供应商数量
+const maxEmbeddingAttempts = 3
+
+// embeddingResult 单个供应商的 Embeddings 调用结果（已按 OpenAI 格式编码）
+type embeddingResult struct {
+	status int
+	body   []byte
+	err    error // 网关侧错误（连接失败、配置无效等），body 为空
+}
+
+// retryable 是否应切换到其他供应商重试（网关侧错误、限流或上游 5xx）
+func (r *embeddingResult) retryable() bool {
+	return r.err != nil || r.status == http.StatusTooManyRequests || r.status >= 500
+}
+
+// Embeddings 处理 OpenAI Embeddings API 请求
+// 嵌入请求没有副作用：上游连接失败、限流或 5xx 时剔除该供应商，按负载均衡从剩余映射中重新选择
+func (h *ProxyHandler) Embeddings(c *gin.Context) {
+	req, bodyBytes, err := parseJSONBody(c)
+	if err != nil {
+		if bodyBytes == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无法读取请求体"})
+		} else {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的 JSON 格式"})
+		}
+		return
+	}
+
+	var embeddingReq converter.EmbeddingRequest
+	if err := json.Unmarshal(bodyBytes, &embeddingReq); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求格式不符合 OpenAI Embeddings 规范"})
+		return
+	}
+	if embeddingReq.Model == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "缺少 model 参数"})
+		return
+	}
+	if err := converter.ValidateEmbeddingRequest(&embeddingReq); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	log.Printf("📥 [Embeddings] 收到请求 - 模型: %s, IP: %s", embeddingReq.Model, c.ClientIP())
+
+	candidates, err := h.router.ResolveModel(c.Request.Context(), embeddingReq.Model)
+	if err != nil {
+		if h.handleOpenAIRouterError(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "解析模型映射失败"})
+		return
+	}
+
+	var last *embeddingResult
+	for attempt := 1; attempt <= maxEmbeddingAttempts && len(candidates) > 0; attempt++ {
+		selected := h.selectAvailableMapping(candidates)
+		if selected == nil {
+			break
+		}
+		candidates = removeMapping(candidates, selected)
+
+		prov, err := h.providerService.GetProvider(selected.ProviderID)
+		if err != nil {
+			log.Printf("❌ [Embeddings] 获取供应商 %d 失败: %v", selected.ProviderID, err)
+			continue
+		}
+		if !provider.SupportsEmbeddings(prov) {
+			log.Printf("⚠️  [Embeddings] %s 类型的供应商不支持 Embeddings，跳过 [Provider: %s]", provider.ProviderType(prov), prov.Name)
+			continue
+		}
+
+		log.Printf("🔀 [Embeddings] 第 %d 次尝试 - 统一模型: %s -> 供应商: %s, 目标模型: %s",
+			attempt, embeddingReq.Model, prov.Name, selected.TargetModel)
+
+		result := h.sendEmbeddings(c, prov, selected.TargetModel, req, &embeddingReq)
+		if c.Request.Context().Err() != nil {
+			return
+		}
+		h.recordEmbeddingOutcome(prov, result)
+
+		if result.retryable() {
+			log.Printf("🔁 [Embeddings] 供应商 %s 失败（状态: %d, 错误: %v），尝试其他供应商", prov.Name, result.status, result.err)
+			last = result
+			continue
+		}
+
+		writeEmbeddingResult(c, result)
+		if result.status < 400 {
+			logEmbeddingUsage(prov.Name, selected.TargetModel, result.body)
+		}
+		return
+	}
+
+	if last != nil {
+		writeEmbeddingResult(c, last)
+		return
+	}
+	c.JSON(http.StatusServiceUnavailable, gin.H{
+		"error": fmt.Sprintf("模型 %s 没有可用于 Embeddings 的供应商", embeddingReq.Model),
+	})
+}
+
+// selectAvailableMapping 跳过冷却期中的供应商后按负载均衡选择映射
+func (h *ProxyHandler) selectAvailableMapping(candidates []*mapping.ResolvedMapping) *mapping.ResolvedMapping {
+	if h.failureDetector == nil {
+		return h.balancer.SelectProvider(candidates)
+	}
+
+	available := make([]*mapping.ResolvedMapping, 0, len(candidates))
+	for _, candidate := range candidates {
+		if h.failureDetector.IsAvailable(candidate.ProviderID) {
+			available = append(available, candidate)
+		}
+	}
+	if len(available) == 0 {
+		return nil
+	}
+	return h.balancer.SelectProvider(available)
+}
+
+// removeMapping 从候选列表中移除已尝试的映射
+func removeMapping(candidates []*mapping.ResolvedMapping, tried *mapping.ResolvedMapping) []*mapping.ResolvedMapping {
+	remaining := make([]*mapping.ResolvedMapping, 0, len(candidates))
+	for _, candidate := range candidates {
+		if candidate != tried {
+			remaining = append(remaining, candidate)
+		}
+	}
+	return remaining
+}
+
+// recordEmbeddingOutcome 将调用结果记录到故障检测器，连续失败的供应商会进入冷却期
+func (h *ProxyHandler) recordEmbeddingOutcome(prov *models.Provider, result *embeddingResult) {
+	if h.failureDetector == nil {
+		return
+	}
+	resp := &http.Response{StatusCode: result.status}
+	if h.failureDetector.IsFailure(result.err, resp) {
+		h.failureDetector.RecordFailure(prov.ID, h.failureDetector.GetFailureType(result.err, resp))
+		return
+	}
+	if result.status < 400 {
+		h.failureDetector.RecordSuccess(prov.ID)
+	}
+}
+
+// sendEmbeddings 按供应商类型发送 Embeddings 请求，结果统一为 OpenAI 格式
+func (h *ProxyHandler) sendEmbeddings(c *gin.Context, prov *models.Provider, targetModel string, req map[string]interface{}, embeddingReq *converter.EmbeddingRequest) *embeddingResult {
+	switch provider.ProviderType(prov) {
+	case provider.ProviderTypeGemini:
+		return h.sendGeminiEmbeddings(c, prov, targetModel, embeddingReq)
+	case provider.ProviderTypeOllama:
+		return h.sendOllamaEmbeddings(c, prov, targetModel, embeddingReq)
+	}
+
+	// OpenAI 兼容上游：替换模型后原样转发，响应原样返回
+	req["model"] = targetModel
+	body, err := json.Marshal(req)
+	if err != nil {
+		return &embeddingResult{status: http.StatusInternalServerError, err: fmt.Errorf("序列化请求失败")}
+	}
+
+	targetURL := provider.EndpointURL(prov, provider.EndpointEmbeddings, h.templateVars(c, prov, targetModel))
+	log.Printf("➡️  [转发] Embeddings 目标URL: %s, 请求体大小: %d bytes", targetURL, len(body))
+
+	resp, status, err := h.sendUpstreamJSON(c, prov, targetURL, targetModel, body)
+	if err != nil {
+		return &embeddingResult{status: status, err: err}
+	}
+	defer resp.Body.Close()
+
+	respBody, err := readUpstreamBody(resp)
+	if err != nil {
+		return &embeddingResult{status: http.StatusBadGateway, err: fmt.Errorf("读取上游响应失败: %v", err)}
+	}
+	return &embeddingResult{status: resp.StatusCode, body: respBody}
+}
+
+// sendGeminiEmbeddings 转换为 Gemini batchEmbedContents 请求
+func (h *ProxyHandler) sendGeminiEmbeddings(c *gin.Context, prov *models.Provider, targetModel string, embeddingReq *converter.EmbeddingRequest) *embeddingResult {
+	geminiReq, err := converter.ConvertEmbeddingToGemini(embeddingReq, targetModel)
+	if err != nil {
+		return embeddingErrorResult(http.StatusBadRequest, err.Error())
+	}
+	body, err := json.Marshal(geminiReq)
+	if err != nil {
+		return &embeddingResult{status: http.StatusInternalServerError, err: fmt.Errorf("生成上游请求失败")}
+	}
+
+	targetURL := provider.GeminiEmbedURL(prov, targetModel)
+	log.Printf("➡️  [转发] Gemini Embeddings 目标URL: %s, 请求体大小: %d bytes", targetURL, len(body))
+
+	resp, status, err := h.sendUpstreamJSON(c, prov, targetURL, targetModel, body)
+	if err != nil {
+		return &embeddingResult{status: status, err: err}
+	}
+	defer resp.Body.Close()
+
+	respBody, err := readUpstreamBody(resp)
+	if err != nil {
+		return &embeddingResult{status: http.StatusBadGateway, err: fmt.Errorf("读取上游响应失败: %v", err)}
+	}
+	if resp.StatusCode >= 400 {
+		googleStatus, message := parseGoogleError(respBody)
+		log.Printf("❌ [Google 错误响应] 状态: %d, 类型: %s, 内容: %s", resp.StatusCode, googleStatus, message)
+		return embeddingErrorResult(resp.StatusCode, message)
+	}
+
+	var geminiResp converter.GeminiBatchEmbedResponse
+	if err := json.Unmarshal(respBody, &geminiResp); err != nil {
+		return embeddingErrorResult(http.StatusBadGateway, "解析上游响应失败")
+	}
+	embeddingResp, err := converter.ConvertGeminiEmbeddingToOpenAI(&geminiResp, targetModel, embeddingReq.EncodingFormat)
+	if err != nil {
+		return embeddingErrorResult(http.StatusBadGateway, "上游响应转换 OpenAI 格式失败")
+	}
+	return marshalEmbeddingResult(embeddingResp)
+}
+
+// sendOllamaEmbeddings 转换为 Ollama /api/embed 请求
+func (h *ProxyHandler) sendOllamaEmbeddings(c *gin.Context, prov *models.Provider, targetModel string, embeddingReq *converter.EmbeddingRequest) *embeddingResult {
+	ollamaReq, err := converter.ConvertEmbeddingToOllama(embeddingReq, targetModel)
+	if err != nil {
+		return embeddingErrorResult(http.StatusBadRequest, err.Error())
+	}
+	body, err := json.Marshal(ollamaReq)
+	if err != nil {
+		return &embeddingResult{status: http.StatusInternalServerError, err: fmt.Errorf("生成上游请求失败")}
+	}
+
+	targetURL := provider.EndpointURL(prov, provider.EndpointEmbeddings, h.templateVars(c, prov, targetModel))
+	log.Printf("➡️  [转发] Ollama Embeddings 目标URL: %s, 请求体大小: %d bytes", targetURL, len(body))
+
+	resp, status, err := h.sendUpstreamJSON(c, prov, targetURL, targetModel, body)
+	if err != nil {
+		return &embeddingResult{status: status, err: err}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return embeddingErrorResult(resp.StatusCode, readOllamaError(resp))
+	}
+
+	respBody, err := readUpstreamBody(resp)
+	if err != nil {
+		return &embeddingResult{status: http.StatusBadGateway, err: fmt.Errorf("读取上游响应失败: %v", err)}
+	}
+	var ollamaResp converter.OllamaEmbedResponse
+	if err := json.Unmarshal(respBody, &ollamaResp); err != nil {
+		return embeddingErrorResult(http.StatusBadGateway, "解析上游响应失败")
+	}
+	embeddingResp, err := converter.ConvertOllamaEmbeddingToOpenAI(&ollamaResp, targetModel, embeddingReq.EncodingFormat)
+	if err != nil {
+		return embeddingErrorResult(http.StatusBadGateway, "上游响应转换 OpenAI 格式失败")
+	}
+	return marshalEmbeddingResult(embeddingResp)
+}
+
+// readUpstreamBody 读取上游响应体并按需解压
+func readUpstreamBody(resp *http.Response) ([]byte, error) {
+	rawBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	body, _, err := decompressIfNeeded(rawBody, resp.Header)
+	return body, err
+}
+
+// marshalEmbeddingResult 序列化转换后的 Embeddings 响应
+func marshalEmbeddingResult(resp *converter.EmbeddingResponse) *embeddingResult {
+	body, err := json.Marshal(resp)
+	if err != nil {
+		return embeddingErrorResult(http.StatusInternalServerError, "序列化响应失败")
+	}
+	return &embeddingResult{status: http.StatusOK, body: body}
+}
+
+// embeddingErrorResult 构建 OpenAI 格式的错误结果
+func embeddingErrorResult(status int, message string) *embeddingResult {
+	body, _ := json.Marshal(gin.H{
+		"error": gin.H{
+			"message": message,
+			"type":    "upstream_error",
+		},
+	})
+	return &embeddingResult{status: status, body: body}
+}
+
+// writeEmbeddingResult 输出调用结果
+func writeEmbeddingResult(c *gin.Context, result *embeddingResult) {
+	if result.err != nil {
+		c.JSON(result.status, gin.H{"error": result.err.Error()})
+		return
+	}
+	c.Data(result.status, "application/json", result.body)
+}
+
+// logEmbeddingUsage 记录 Embeddings 的输入条数与 token 用量
+func logEmbeddingUsage(providerName, targetModel string, body []byte) {
+	var resp struct {
+		Data  []json.RawMessage        `json:"data"`
+		Usage converter.EmbeddingUsage `json:"usage"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		log.Printf("⚠️  [Embeddings] 无法解析响应中的 usage: %v", err)
+		return
+	}
+	log.Printf("✅ [完成] Embeddings 成功 - 供应商: %s, 模型: %s, 向量数: %d, Tokens: prompt=%d, total=%d",
+		providerName, targetModel, len(resp.Data), resp.Usage.PromptTokens, resp.Usage.TotalTokens)
+}
@@ -0,0 +1,250 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Mieluoxxx/Siriusx-API/internal/balancer"
+	"github.com/Mieluoxxx/Siriusx-API/internal/converter"
+	"github.com/Mieluoxxx/Siriusx-API/internal/mapping"
+	"github.com/Mieluoxxx/Siriusx-API/internal/models"
+	"github.com/Mieluoxxx/Siriusx-API/internal/provider"
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// stubMultiRouter 将统一模型 my-model 解析到多个映射，用于验证故障转移
+type stubMultiRouter struct {
+	stubModelRouter
+	resolved []*mapping.ResolvedMapping
+}
+
+func (r *stubMultiRouter) ResolveModel(_ context.Context, modelName string) ([]*mapping.ResolvedMapping, error) {
+	if modelName != "my-model" {
+		return nil, mapping.NewModelNotFoundError(modelName)
+	}
+	return append([]*mapping.ResolvedMapping(nil), r.resolved...), nil
+}
+
+// newMultiProviderTestHandler 创建代理处理器，统一模型 my-model 映射到所有给定供应商
+func newMultiProviderTestHandler(t *testing.T, targetModel string, providers ...*models.Provider) *ProxyHandler {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	database, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open test database: %v", err)
+	}
+	if err := database.AutoMigrate(&models.Provider{}); err != nil {
+		t.Fatalf("failed to migrate test database: %v", err)
+	}
+
+	router := &stubMultiRouter{}
+	for i, prov := range providers {
+		prov.Enabled = true
+		if err := provider.NewRepository(database).Create(prov); err != nil {
+			t.Fatalf("failed to create test provider: %v", err)
+		}
+		router.resolved = append(router.resolved, &mapping.ResolvedMapping{
+			ProviderID: prov.ID, TargetModel: targetModel, Weight: 1, Priority: i + 1, Enabled: true,
+		})
+	}
+
+	return &ProxyHandler{
+		providerService: provider.NewService(provider.NewRepository(database)),
+		router:          router,
+		balancer:        balancer.NewWeightedRandomBalancer(),
+	}
+}
+
+// newEmbeddingsTestEngine 创建挂载 /v1/embeddings 的测试引擎
+func newEmbeddingsTestEngine(t *testing.T, targetModel string, providers ...*models.Provider) *gin.Engine {
+	t.Helper()
+	engine := gin.New()
+	engine.POST("/v1/embeddings", newMultiProviderTestHandler(t, targetModel, providers...).Embeddings)
+	return engine
+}
+
+func TestEmbeddings_PassthroughWithFailover(t *testing.T) {
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = w.Write([]byte(`{"error":{"message":"overloaded"}}`))
+	}))
+	defer failing.Close()
+
+	var captured map[string]interface{}
+	healthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/embeddings" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(body, &captured)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"object":"list","data":[{"object":"embedding","index":0,"embedding":"AACAPw=="},{"object":"embedding","index":1,"embedding":"AAAAQA=="}],"model":"text-embedding-3-small","usage":{"prompt_tokens":4,"total_tokens":4}}`))
+	}))
+	defer healthy.Close()
+
+	engine := newEmbeddingsTestEngine(t, "text-embedding-3-small",
+		&models.Provider{Name: "primary", BaseURL: failing.URL, APIKey: "sk-a"},
+		&models.Provider{Name: "secondary", BaseURL: healthy.URL, APIKey: "sk-b"},
+	)
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest("POST", "/v1/embeddings",
+		strings.NewReader(`{"model":"my-model","input":["a","b"],"dimensions":1,"encoding_format":"base64"}`)))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status code: %d, body: %s", w.Code, w.Body.String())
+	}
+	if captured["model"] != "text-embedding-3-small" || captured["dimensions"] != float64(1) || captured["encoding_format"] != "base64" {
+		t.Fatalf("unexpected upstream request: %+v", captured)
+	}
+	var resp converter.EmbeddingResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(resp.Data) != 2 || resp.Usage.TotalTokens != 4 {
+		t.Fatalf("unexpected response: %s", w.Body.String())
+	}
+}
+
+func TestEmbeddings_AllProvidersFail(t *testing.T) {
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = w.Write([]byte(`{"error":{"message":"slow down"}}`))
+	}))
+	defer server.Close()
+
+	engine := newEmbeddingsTestEngine(t, "text-embedding-3-small",
+		&models.Provider{Name: "a", BaseURL: server.URL, APIKey: "sk-a"},
+		&models.Provider{Name: "b", BaseURL: server.URL, APIKey: "sk-b"},
+	)
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest("POST", "/v1/embeddings", strings.NewReader(`{"model":"my-model","input":"hi"}`)))
+
+	if w.Code != http.StatusTooManyRequests || !strings.Contains(w.Body.String(), "slow down") {
+		t.Fatalf("expected last upstream error, got %d %s", w.Code, w.Body.String())
+	}
+	if calls != 2 {
+		t.Fatalf("expected both providers to be tried, got %d calls", calls)
+	}
+}
+
+func TestEmbeddings_Gemini(t *testing.T) {
+	var captured converter.GeminiBatchEmbedRequest
+	var path string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		body, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(body, &captured)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"embeddings":[{"values":[0.1,0.2]},{"values":[0.3,0.4]}]}`))
+	}))
+	defer server.Close()
+
+	engine := newEmbeddingsTestEngine(t, "text-embedding-004", newGeminiTestProvider(server.URL))
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest("POST", "/v1/embeddings",
+		strings.NewReader(`{"model":"my-model","input":["a","b"],"dimensions":2}`)))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status code: %d, body: %s", w.Code, w.Body.String())
+	}
+	if path != "/v1beta/models/text-embedding-004:batchEmbedContents" {
+		t.Fatalf("unexpected upstream path: %s", path)
+	}
+	if len(captured.Requests) != 2 || captured.Requests[1].Model != "models/text-embedding-004" || *captured.Requests[1].OutputDimensionality != 2 {
+		t.Fatalf("unexpected upstream request: %+v", captured)
+	}
+
+	var resp struct {
+		Data []struct {
+			Index     int       `json:"index"`
+			Embedding []float64 `json:"embedding"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(resp.Data) != 2 || resp.Data[1].Index != 1 || resp.Data[1].Embedding[0] != 0.3 {
+		t.Fatalf("unexpected response: %s", w.Body.String())
+	}
+}
+
+func TestEmbeddings_Ollama(t *testing.T) {
+	var captured converter.OllamaEmbedRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/embed" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(body, &captured)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"model":"nomic-embed-text","embeddings":[[1,2]],"prompt_eval_count":3}`))
+	}))
+	defer server.Close()
+
+	engine := newEmbeddingsTestEngine(t, "nomic-embed-text", newOllamaTestProvider(server.URL))
+
+	t.Run("text input", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest("POST", "/v1/embeddings",
+			strings.NewReader(`{"model":"my-model","input":"hello","encoding_format":"base64"}`)))
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("unexpected status code: %d, body: %s", w.Code, w.Body.String())
+		}
+		if captured.Model != "nomic-embed-text" || len(captured.Input) != 1 || captured.Input[0] != "hello" {
+			t.Fatalf("unexpected upstream request: %+v", captured)
+		}
+		var resp converter.EmbeddingResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if resp.Data[0].Embedding != "AACAPwAAAEA=" || resp.Usage.PromptTokens != 3 {
+			t.Fatalf("unexpected response: %s", w.Body.String())
+		}
+	})
+
+	t.Run("token input rejected", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest("POST", "/v1/embeddings",
+			strings.NewReader(`{"model":"my-model","input":[[1,2,3]]}`)))
+
+		if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "token") {
+			t.Fatalf("expected token input to be rejected, got %d %s", w.Code, w.Body.String())
+		}
+	})
+}
+
+func TestEmbeddings_InvalidRequest(t *testing.T) {
+	engine := newEmbeddingsTestEngine(t, "text-embedding-3-small")
+
+	for name, body := range map[string]string{
+		"missing model":  `{"input":"hi"}`,
+		"missing input":  `{"model":"my-model"}`,
+		"empty input":    `{"model":"my-model","input":[]}`,
+		"bad encoding":   `{"model":"my-model","input":"hi","encoding_format":"int8"}`,
+		"bad dimensions": `{"model":"my-model","input":"hi","dimensions":0}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, httptest.NewRequest("POST", "/v1/embeddings", strings.NewReader(body)))
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("expected 400, got %d %s", w.Code, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,274 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/Mieluoxxx/Siriusx-API/internal/converter"
+	"github.com/Mieluoxxx/Siriusx-API/internal/models"
+	"github.com/Mieluoxxx/Siriusx-API/internal/provider"
+	"github.com/gin-gonic/gin"
+)
+
+// forwardClaudeViaGemini 将 Claude Messages 请求转换为 Gemini generateContent 请求再转发
+func (h *ProxyHandler) forwardClaudeViaGemini(c *gin.Context, prov *models.Provider, targetModel string, req map[string]interface{}) {
+	payloadBytes, err := json.Marshal(req)
+	if err != nil {
+		log.Printf("❌ [转换失败] 无法序列化 Claude 请求: %v", err)
+		h.respondClaudeError(c, http.StatusInternalServerError, "api_error", "生成上游请求失败")
+		return
+	}
+
+	var claudeReq converter.ClaudeRequest
+	if err := json.Unmarshal(payloadBytes, &claudeReq); err != nil {
+		log.Printf("❌ [解析失败] Claude 请求无法解析: %v", err)
+		h.respondClaudeError(c, http.StatusBadRequest, "invalid_request_error", "请求格式不符合 Claude Messages 规范")
+		return
+	}
+
+	geminiReq, err := converter.ConvertClaudeToGemini(&claudeReq)
+	if err != nil {
+		log.Printf("❌ [转换失败] Claude→Gemini: %v", err)
+		h.respondClaudeError(c, http.StatusBadRequest, "invalid_request_error", fmt.Sprintf("Claude 请求转换 Gemini 格式失败: %v", err))
+		return
+	}
+
+	resp, status, err := h.sendGeminiRequest(c, prov, targetModel, geminiReq, claudeReq.Stream)
+	if err != nil {
+		h.respondClaudeError(c, status, "api_error", err.Error())
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		h.respondGoogleError(c, resp)
+		return
+	}
+
+	if claudeReq.Stream {
+		convertedReader, err := converter.ConvertGeminiStreamToClaude(c.Request.Context(), resp.Body, targetModel)
+		if err != nil {
+			log.Printf("❌ [流式转换失败] Provider: %s, 错误: %v", prov.Name, err)
+			h.respondClaudeError(c, http.StatusBadGateway, "api_error", "上游流式响应转换失败")
+			return
+		}
+
+		c.Header("Content-Type", "text/event-stream; charset=utf-8")
+		c.Header("Cache-Control", "no-cache")
+		c.Status(http.StatusOK)
+
+		totalBytes, err := pipeStream(c, convertedReader)
+		if err != nil {
+			log.Printf("❌ [流式转发] Gemini: %v", err)
+			return
+		}
+		log.Printf("✅ [完成] Gemini→Claude 流式响应转换完成，共 %d bytes", totalBytes)
+		return
+	}
+
+	geminiResp, err := readGeminiResponse(resp)
+	if err != nil {
+		log.Printf("❌ [解析失败] Gemini 响应: %v", err)
+		h.respondClaudeError(c, http.StatusBadGateway, "api_error", "解析上游响应失败")
+		return
+	}
+
+	claudeResp, err := converter.ConvertGeminiToClaude(geminiResp, targetModel)
+	if err != nil {
+		log.Printf("❌ [转换失败] Gemini→Claude: %v", err)
+		h.respondClaudeError(c, http.StatusBadGateway, "api_error", "上游响应转换 Claude 格式失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, claudeResp)
+	log.Printf("✅ [完成] Gemini→Claude 非流式响应转换成功，stop_reason: %s", claudeResp.StopReason)
+}
+
+// forwardOpenAIViaGemini 将 OpenAI Chat Completions 请求转换为 Gemini generateContent 请求再转发
+func (h *ProxyHandler) forwardOpenAIViaGemini(c *gin.Context, prov *models.Provider, targetModel string, req map[string]interface{}) {
+	payloadBytes, err := json.Marshal(req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "序列化请求失败"})
+		return
+	}
+
+	var openaiReq converter.OpenAIRequest
+	if err := json.Unmarshal(payloadBytes, &openaiReq); err != nil {
+		log.Printf("❌ [解析失败] OpenAI 请求无法解析: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求格式不符合 OpenAI Chat Completions 规范"})
+		return
+	}
+
+	geminiReq, err := converter.ConvertOpenAIToGemini(&openaiReq)
+	if err != nil {
+		log.Printf("❌ [转换失败] OpenAI→Gemini: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("OpenAI 请求转换 Gemini 格式失败: %v", err)})
+		return
+	}
+
+	resp, status, err := h.sendGeminiRequest(c, prov, targetModel, geminiReq, openaiReq.Stream)
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		respBody, _ := io.ReadAll(resp.Body)
+		googleStatus, message := parseGoogleError(respBody)
+		log.Printf("❌ [Google 错误响应] 状态: %d, 类型: %s, 内容: %s", resp.StatusCode, googleStatus, message)
+		c.JSON(resp.StatusCode, gin.H{
+			"error": gin.H{
+				"message": message,
+				"type":    googleErrorType(resp.StatusCode, googleStatus),
+				"code":    googleStatus,
+			},
+		})
+		return
+	}
+
+	if openaiReq.Stream {
+		convertedReader, err := converter.ConvertGeminiStreamToOpenAI(c.Request.Context(), resp.Body, targetModel)
+		if err != nil {
+			log.Printf("❌ [流式转换失败] Provider: %s, 错误: %v", prov.Name, err)
+			c.JSON(http.StatusBadGateway, gin.H{"error": "上游流式响应转换失败"})
+			return
+		}
+
+		c.Header("Content-Type", "text/event-stream; charset=utf-8")
+		c.Header("Cache-Control", "no-cache")
+		c.Status(http.StatusOK)
+
+		totalBytes, err := pipeStream(c, convertedReader)
+		if err != nil {
+			log.Printf("❌ [流式转发] Gemini: %v", err)
+			return
+		}
+		log.Printf("✅ [完成] Gemini→OpenAI 流式响应转换完成，共 %d bytes", totalBytes)
+		return
+	}
+
+	geminiResp, err := readGeminiResponse(resp)
+	if err != nil {
+		log.Printf("❌ [解析失败] Gemini 响应: %v", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "解析上游响应失败"})
+		return
+	}
+
+	openaiResp, err := converter.ConvertGeminiToOpenAI(geminiResp, targetModel)
+	if err != nil {
+		log.Printf("❌ [转换失败] Gemini→OpenAI: %v", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "上游响应转换 OpenAI 格式失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, openaiResp)
+	log.Printf("✅ [完成] Gemini→OpenAI 非流式响应转换成功，Tokens: prompt=%d + completion=%d = %d",
+		openaiResp.Usage.PromptTokens, openaiResp.Usage.CompletionTokens, openaiResp.Usage.TotalTokens)
+}
+
+// sendGeminiRequest 发送 generateContent 请求（Gemini API 或 Vertex 上的 Gemini 模型）
+// 返回的错误为网关侧错误，附带应返回给客户端的状态码；上游错误响应由调用方按客户端格式转换
+func (h *ProxyHandler) sendGeminiRequest(c *gin.Context, prov *models.Provider, targetModel string, geminiReq *converter.GeminiRequest, stream bool) (*http.Response, int, error) {
+	body, err := json.Marshal(geminiReq)
+	if err != nil {
+		log.Printf("❌ [序列化失败] Gemini 请求: %v", err)
+		return nil, http.StatusInternalServerError, fmt.Errorf("生成上游请求失败")
+	}
+	return h.sendGeminiBody(c, prov, targetModel, body, stream)
+}
+
+// sendGeminiBody 发送已序列化的 generateContent 请求体
+func (h *ProxyHandler) sendGeminiBody(c *gin.Context, prov *models.Provider, targetModel string, body []byte, stream bool) (*http.Response, int, error) {
+	targetURL := provider.GenerateContentURL(prov, targetModel, stream)
+	log.Printf("➡️  [转发] Gemini 目标URL: %s, 请求体大小: %d bytes", targetURL, len(body))
+
+	proxyReq, err := http.NewRequestWithContext(c.Request.Context(), "POST", targetURL, bytes.NewReader(body))
+	if err != nil {
+		log.Printf("❌ [转发失败] 创建 Gemini 请求失败: %v", err)
+		return nil, http.StatusInternalServerError, fmt.Errorf("创建代理请求失败")
+	}
+
+	proxyReq.Header.Set("Content-Type", "application/json")
+	provider.ApplyAuth(proxyReq.Header, prov)
+
+	if err := h.copyForwardHeaders(c, prov, proxyReq.Header); err != nil {
+		log.Printf("❌ [转发失败] Provider: %s, 请求头转发策略无效: %v", prov.Name, err)
+		return nil, http.StatusInternalServerError, fmt.Errorf("供应商请求头转发配置无效")
+	}
+
+	vars := h.templateVars(c, prov, targetModel)
+	if err := provider.ApplyCustomHeaders(proxyReq.Header, prov, vars); err != nil {
+		log.Printf("❌ [转发失败] Provider: %s, 自定义请求头无效: %v", prov.Name, err)
+		return nil, http.StatusInternalServerError, fmt.Errorf("供应商自定义请求头配置无效")
+	}
+
+	if err := provider.AuthorizeRequest(c.Request.Context(), proxyReq, prov, body); err != nil {
+		log.Printf("❌ [转发失败] Provider: %s, 认证失败: %v", prov.Name, err)
+		return nil, http.StatusBadGateway, fmt.Errorf("供应商认证失败: %v", err)
+	}
+
+	client, err := h.httpClient(prov)
+	if err != nil {
+		log.Printf("❌ [转发失败] Provider: %s, 连接配置无效: %v", prov.Name, err)
+		return nil, http.StatusInternalServerError, fmt.Errorf("供应商连接配置无效: %v", err)
+	}
+
+	resp, err := client.Do(proxyReq)
+	if err != nil {
+		log.Printf("❌ [转发失败] Provider: %s, 错误: %v", prov.Name, err)
+		return nil, http.StatusBadGateway, fmt.Errorf("请求供应商失败: %v", err)
+	}
+
+	if resp.StatusCode == http.StatusUnauthorized && provider.ProviderType(prov) == provider.ProviderTypeVertex {
+		// 令牌可能已被吊销，下次请求重新换取
+		provider.DefaultGoogleTokenCache().Invalidate(prov.ID)
+	}
+	return resp, http.StatusOK, nil
+}
+
+// readGeminiResponse 读取并解析 Gemini 非流式响应
+func readGeminiResponse(resp *http.Response) (*converter.GeminiResponse, error) {
+	rawBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	body, _, err := decompressIfNeeded(rawBody, resp.Header)
+	if err != nil {
+		return nil, err
+	}
+
+	var geminiResp converter.GeminiResponse
+	if err := json.Unmarshal(body, &geminiResp); err != nil {
+		return nil, err
+	}
+	return &geminiResp, nil
+}
+
+// parseGoogleError 解析 Google API 错误响应，返回错误状态（如 INVALID_ARGUMENT）与错误信息
+// 流式接口的错误响应为数组形式 [{"error": {...}}]，取第一个元素
+func parseGoogleError(body []byte) (string, string) {
+	body = bytes.TrimSpace(body)
+	if len(body) > 0 && body[0] == '[' {
+		var items []json.RawMessage
+		if err := json.Unmarshal(body, &items); err == nil && len(items) > 0 {
+			body = items[0]
+		}
+	}
+
+	var googleErr struct {
+		Error struct {
+			Message string `json:"message"`
+			Status  string `json:"status"`
+		} `json:"error"`
+	}
+	_ = json.Unmarshal(body, &googleErr)
+	if googleErr.Error.Message == "" {
+		googleErr.Error.Message = "上游返回错误响应"
+	}
+	return googleErr.Error.Status, googleErr.Error.Message
+}
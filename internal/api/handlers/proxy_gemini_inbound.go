@@ -0,0 +1,281 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/Mieluoxxx/Siriusx-API/internal/converter"
+	"github.com/Mieluoxxx/Siriusx-API/internal/mapping"
+	"github.com/Mieluoxxx/Siriusx-API/internal/models"
+	"github.com/Mieluoxxx/Siriusx-API/internal/provider"
+	"github.com/gin-gonic/gin"
+)
+
+// Gemini API 方法名（路径 /v1beta/models/{model}:{method}）
+const (
+	geminiMethodGenerateContent       = "generateContent"
+	geminiMethodStreamGenerateContent = "streamGenerateContent"
+)
+
+// GenerateContent 处理 Gemini generateContent / streamGenerateContent 请求
+// {model} 通过模型映射解析；Gemini 上游直接透传，其他上游先转换为 Claude Messages 请求，复用 Messages 的转发逻辑，再将响应转换回 Gemini 格式
+func (h *ProxyHandler) GenerateContent(c *gin.Context) {
+	modelName, method, ok := parseGeminiAction(c.Param("action"))
+	if !ok {
+		respondGeminiError(c, http.StatusNotFound, fmt.Sprintf("无效的请求路径: %s", c.Request.URL.Path))
+		return
+	}
+	if method != geminiMethodGenerateContent && method != geminiMethodStreamGenerateContent {
+		respondGeminiError(c, http.StatusNotFound, fmt.Sprintf("不支持的方法: %s，仅支持 generateContent 与 streamGenerateContent", method))
+		return
+	}
+	stream := method == geminiMethodStreamGenerateContent
+
+	_, bodyBytes, err := parseJSONBody(c)
+	if err != nil {
+		if bodyBytes == nil {
+			respondGeminiError(c, http.StatusBadRequest, "无法读取请求体")
+		} else {
+			respondGeminiError(c, http.StatusBadRequest, "无效的 JSON 格式")
+		}
+		return
+	}
+
+	log.Printf("📥 [GenerateContent] 收到请求 - 模型: %s, 流式: %v, IP: %s", modelName, stream, c.ClientIP())
+
+	selectedMapping, err := h.resolveMapping(c.Request.Context(), modelName)
+	if err != nil {
+		if h.handleGeminiRouterError(c, err) {
+			return
+		}
+		respondGeminiError(c, http.StatusInternalServerError, "解析模型映射失败")
+		return
+	}
+
+	prov, err := h.providerService.GetProvider(selectedMapping.ProviderID)
+	if err != nil {
+		respondGeminiError(c, http.StatusInternalServerError, "获取供应商信息失败")
+		return
+	}
+
+	providerName := prov.Name
+	if selectedMapping.Provider != nil && selectedMapping.Provider.Name != "" {
+		providerName = selectedMapping.Provider.Name
+	}
+
+	if provider.UsesGeminiAPI(prov, selectedMapping.TargetModel) {
+		log.Printf("🔀 [GenerateContent] 映射选择 - 统一模型: %s -> 供应商: %s (Gemini), 目标模型: %s",
+			modelName, providerName, selectedMapping.TargetModel)
+		h.forwardGeminiNative(c, prov, selectedMapping.TargetModel, bodyBytes, stream)
+		return
+	}
+
+	claudeReq, structured, err := geminiBodyToClaudeRequest(bodyBytes, selectedMapping.TargetModel, stream)
+	if err != nil {
+		log.Printf("❌ [转换失败] Gemini→Claude: %v", err)
+		respondGeminiError(c, http.StatusBadRequest, fmt.Sprintf("Gemini 请求转换失败: %v", err))
+		return
+	}
+
+	emulation := emulateClaudeTools(selectedMapping, claudeReq)
+
+	log.Printf("🔁 [GenerateContent] 执行 Gemini→Claude 转换 [Provider: %s, Target: %s]", providerName, selectedMapping.TargetModel)
+
+	writer := newClaudeResponseWriter(c.Writer, stream, withToolEmulation(withStructuredOutput(newGeminiTranslator(), structured), emulation))
+	c.Writer = writer
+	h.dispatchConvertedClaudeRequest(c, writer, prov, providerName, modelName, selectedMapping, claudeReq, structured, emulation)
+	c.Writer = writer.ResponseWriter
+	writer.finish()
+}
+
+// parseGeminiAction 解析路径参数 /{model}:{method}
+func parseGeminiAction(action string) (string, string, bool) {
+	action = strings.TrimPrefix(action, "/")
+	idx := strings.LastIndex(action, ":")
+	if idx <= 0 || idx == len(action)-1 || strings.Contains(action[:idx], "/") {
+		return "", "", false
+	}
+	return action[:idx], action[idx+1:], true
+}
+
+// geminiBodyToClaudeRequest 将 Gemini 请求体转换为 Claude Messages 请求
+// responseMimeType 为 application/json 时以工具调用模拟结构化输出
+func geminiBodyToClaudeRequest(body []byte, targetModel string, stream bool) (*converter.ClaudeRequest, *converter.StructuredOutput, error) {
+	var geminiReq converter.GeminiRequest
+	if err := json.Unmarshal(body, &geminiReq); err != nil {
+		return nil, nil, fmt.Errorf("请求格式不符合 Gemini generateContent 规范: %w", err)
+	}
+
+	claudeReq, err := converter.ConvertGeminiRequestToClaude(&geminiReq)
+	if err != nil {
+		return nil, nil, err
+	}
+	claudeReq.Model = targetModel
+	claudeReq.Stream = stream
+
+	structured, err := converter.EmulateResponseFormat(claudeReq, converter.ResponseFormatFromGemini(geminiReq.GenerationConfig))
+	if err != nil {
+		return nil, nil, err
+	}
+	return claudeReq, structured, nil
+}
+
+// forwardGeminiNative 将 Gemini 请求原样转发到 Gemini API（或 Vertex 上的 Gemini 模型），模型名替换为映射的目标模型
+func (h *ProxyHandler) forwardGeminiNative(c *gin.Context, prov *models.Provider, targetModel string, body []byte, stream bool) {
+	resp, status, err := h.sendGeminiBody(c, prov, targetModel, body, stream)
+	if err != nil {
+		respondGeminiError(c, status, err.Error())
+		return
+	}
+	defer resp.Body.Close()
+
+	if stream && resp.StatusCode < 400 {
+		c.Header("Content-Type", "text/event-stream; charset=utf-8")
+		c.Header("Cache-Control", "no-cache")
+		c.Status(http.StatusOK)
+
+		totalBytes, err := pipeStream(c, resp.Body)
+		if err != nil {
+			log.Printf("❌ [流式转发] Gemini: %v", err)
+			return
+		}
+		log.Printf("✅ [完成] Gemini 流式响应转发完成，共 %d bytes", totalBytes)
+		return
+	}
+
+	rawBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		log.Printf("❌ [响应失败] 读取 Gemini 响应体失败: %v", err)
+		respondGeminiError(c, http.StatusBadGateway, "读取上游响应失败")
+		return
+	}
+	respBody, _, err := decompressIfNeeded(rawBody, resp.Header)
+	if err != nil {
+		log.Printf("❌ [解压失败] Gemini 响应: %v", err)
+		respondGeminiError(c, http.StatusBadGateway, "解压上游响应失败")
+		return
+	}
+
+	// Google 错误响应本身即 Gemini 格式，直接透传
+	c.Data(resp.StatusCode, "application/json", respBody)
+	if resp.StatusCode >= 400 {
+		googleStatus, message := parseGoogleError(respBody)
+		log.Printf("❌ [Google 错误响应] 状态: %d, 类型: %s, 内容: %s", resp.StatusCode, googleStatus, message)
+		return
+	}
+	log.Printf("✅ [完成] Gemini 非流式响应，响应体大小: %d bytes", len(respBody))
+}
+
+// handleGeminiRouterError 将路由错误映射为 Gemini 风格的响应
+func (h *ProxyHandler) handleGeminiRouterError(c *gin.Context, err error) bool {
+	var routerErr *mapping.RouterError
+	if !errors.As(err, &routerErr) {
+		return false
+	}
+
+	switch routerErr.Code {
+	case mapping.ErrRouterModelNotFound.Code:
+		respondGeminiError(c, http.StatusNotFound, routerErr.Message)
+	case mapping.ErrRouterNoAvailableProviders.Code:
+		respondGeminiError(c, http.StatusServiceUnavailable, routerErr.Message)
+	default:
+		respondGeminiError(c, http.StatusInternalServerError, routerErr.Message)
+	}
+
+	return true
+}
+
+// respondGeminiError 返回 Google API 格式的错误响应
+func respondGeminiError(c *gin.Context, status int, message string) {
+	c.JSON(status, geminiErrorBody(status, message))
+}
+
+// geminiErrorBody 构建 Google API 错误响应体 {"error":{"code","message","status"}}
+func geminiErrorBody(status int, message string) gin.H {
+	return gin.H{
+		"error": gin.H{
+			"code":    status,
+			"message": message,
+			"status":  googleErrorStatus(status),
+		},
+	}
+}
+
+// googleErrorStatus 将 HTTP 状态码映射为 Google API 错误状态
+func googleErrorStatus(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return "INVALID_ARGUMENT"
+	case http.StatusUnauthorized:
+		return "UNAUTHENTICATED"
+	case http.StatusForbidden:
+		return "PERMISSION_DENIED"
+	case http.StatusNotFound:
+		return "NOT_FOUND"
+	case http.StatusTooManyRequests:
+		return "RESOURCE_EXHAUSTED"
+	case http.StatusServiceUnavailable, 529:
+		return "UNAVAILABLE"
+	case http.StatusGatewayTimeout:
+		return "DEADLINE_EXCEEDED"
+	}
+	if status >= 500 {
+		return "INTERNAL"
+	}
+	return "FAILED_PRECONDITION"
+}
+
+// geminiTranslator 将 Claude 响应转换为 Gemini generateContent 格式
+type geminiTranslator struct {
+	converter *converter.ClaudeGeminiStreamConverter
+}
+
+// newGeminiTranslator 创建 Gemini 响应转换器
+func newGeminiTranslator() *geminiTranslator {
+	return &geminiTranslator{converter: converter.NewClaudeGeminiStreamConverter()}
+}
+
+// label 日志中使用的协议名
+func (t *geminiTranslator) label() string {
+	return "Gemini"
+}
+
+// streamEvent 将 Claude 事件转换为 Gemini 响应块（data: {...}\r\n\r\n）
+func (t *geminiTranslator) streamEvent(data []byte) ([]byte, error) {
+	chunks, err := t.converter.ProcessEvent(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	for _, chunk := range chunks {
+		chunkData, err := json.Marshal(chunk)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(&out, "data: %s\r\n\r\n", chunkData)
+	}
+	return out.Bytes(), nil
+}
+
+// streamError 流式转换失败时以 Google API 错误格式写出
+func (t *geminiTranslator) streamError(err error) []byte {
+	errData, _ := json.Marshal(geminiErrorBody(http.StatusInternalServerError, err.Error()))
+	return []byte(fmt.Sprintf("data: %s\r\n\r\n", errData))
+}
+
+// response 转换非流式响应
+func (t *geminiTranslator) response(resp *converter.ClaudeResponse) (interface{}, error) {
+	return converter.ConvertClaudeResponseToGemini(resp)
+}
+
+// errorBody 构建 Google API 错误响应体
+func (t *geminiTranslator) errorBody(status int, message string) interface{} {
+	return geminiErrorBody(status, message)
+}
@@ -0,0 +1,252 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Mieluoxxx/Siriusx-API/internal/balancer"
+	"github.com/Mieluoxxx/Siriusx-API/internal/converter"
+	"github.com/Mieluoxxx/Siriusx-API/internal/mapping"
+	"github.com/Mieluoxxx/Siriusx-API/internal/models"
+	"github.com/Mieluoxxx/Siriusx-API/internal/provider"
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// stubModelRouter 将唯一的统一模型名解析到固定的映射
+type stubModelRouter struct {
+	modelName string
+	resolved  *mapping.ResolvedMapping
+}
+
+func (r *stubModelRouter) ResolveModel(_ context.Context, modelName string) ([]*mapping.ResolvedMapping, error) {
+	if modelName != r.modelName {
+		return nil, mapping.NewModelNotFoundError(modelName)
+	}
+	return []*mapping.ResolvedMapping{r.resolved}, nil
+}
+
+func (r *stubModelRouter) InvalidateCache(string) {}
+
+func (r *stubModelRouter) ClearCache() {}
+
+func (r *stubModelRouter) GetCacheStats() *mapping.CacheStats { return &mapping.CacheStats{} }
+
+func (r *stubModelRouter) Close() error { return nil }
+
+// newGeminiInboundTestEngine 创建挂载 Gemini 路由的测试引擎，统一模型 my-model 映射到指定供应商的目标模型
+func newGeminiInboundTestEngine(t *testing.T, prov *models.Provider, targetModel string) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	database, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open test database: %v", err)
+	}
+	if err := database.AutoMigrate(&models.Provider{}); err != nil {
+		t.Fatalf("failed to migrate test database: %v", err)
+	}
+	if err := provider.NewRepository(database).Create(prov); err != nil {
+		t.Fatalf("failed to create test provider: %v", err)
+	}
+
+	handler := &ProxyHandler{
+		providerService: provider.NewService(provider.NewRepository(database)),
+		router: &stubModelRouter{
+			modelName: "my-model",
+			resolved:  &mapping.ResolvedMapping{ProviderID: prov.ID, TargetModel: targetModel, Weight: 1, Enabled: true},
+		},
+		balancer: balancer.NewWeightedRandomBalancer(),
+	}
+
+	engine := gin.New()
+	engine.POST("/v1beta/models/*action", handler.GenerateContent)
+	return engine
+}
+
+const geminiInboundTestRequest = `{
+	"systemInstruction": {"parts": [{"text": "Be brief."}]},
+	"contents": [
+		{"role": "user", "parts": [{"text": "weather?"}]},
+		{"role": "model", "parts": [{"functionCall": {"name": "get_weather", "args": {"city": "Paris"}}}]},
+		{"role": "user", "parts": [{"functionResponse": {"name": "get_weather", "response": {"output": "sunny"}}}]}
+	],
+	"tools": [{"functionDeclarations": [{"name": "get_weather", "parameters": {"type": "OBJECT", "properties": {"city": {"type": "STRING"}}}}]}],
+	"generationConfig": {"maxOutputTokens": 256}
+}`
+
+func TestGenerateContent_ViaOpenAI(t *testing.T) {
+	var captured converter.OpenAIRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(body, &captured)
+
+		if captured.Stream {
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = w.Write([]byte(`data: {"id":"c1","object":"chat.completion.chunk","model":"gpt-4o","choices":[{"index":0,"delta":{"role":"assistant","content":"po"},"finish_reason":null}]}` + "\n\n"))
+			_, _ = w.Write([]byte(`data: {"id":"c1","object":"chat.completion.chunk","model":"gpt-4o","choices":[{"index":0,"delta":{"content":"ng"},"finish_reason":"stop"}]}` + "\n\n"))
+			_, _ = w.Write([]byte("data: [DONE]\n\n"))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"c2","object":"chat.completion","model":"gpt-4o","choices":[{"index":0,"message":{"role":"assistant","content":"It is sunny."},"finish_reason":"stop"}],"usage":{"prompt_tokens":20,"completion_tokens":4,"total_tokens":24}}`))
+	}))
+	defer server.Close()
+
+	engine := newGeminiInboundTestEngine(t, &models.Provider{Name: "openai", BaseURL: server.URL, APIKey: "sk-test", Enabled: true}, "gpt-4o")
+
+	t.Run("non-stream", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest("POST", "/v1beta/models/my-model:generateContent", strings.NewReader(geminiInboundTestRequest)))
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("unexpected status code: %d, body: %s", w.Code, w.Body.String())
+		}
+		if captured.Model != "gpt-4o" || captured.MaxTokens != 256 || len(captured.Tools) != 1 {
+			t.Fatalf("unexpected upstream request: %+v", captured)
+		}
+		if len(captured.Messages) != 4 || captured.Messages[0].Role != "system" || captured.Messages[3].Role != "tool" {
+			t.Fatalf("unexpected upstream messages: %+v", captured.Messages)
+		}
+		if captured.Messages[2].ToolCalls[0].ID != captured.Messages[3].ToolCallID {
+			t.Fatalf("expected tool result to reference the tool call, got %+v", captured.Messages)
+		}
+
+		var geminiResp converter.GeminiResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &geminiResp); err != nil {
+			t.Fatalf("failed to decode response: %v, body: %s", err, w.Body.String())
+		}
+		candidate := geminiResp.Candidates[0]
+		if *candidate.Content.Parts[0].Text != "It is sunny." || candidate.FinishReason != "STOP" || candidate.Content.Role != "model" {
+			t.Fatalf("unexpected Gemini response: %s", w.Body.String())
+		}
+		if geminiResp.UsageMetadata.PromptTokenCount != 20 || geminiResp.UsageMetadata.TotalTokenCount != 24 {
+			t.Fatalf("unexpected usage: %+v", geminiResp.UsageMetadata)
+		}
+	})
+
+	t.Run("stream", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest("POST", "/v1beta/models/my-model:streamGenerateContent?alt=sse", strings.NewReader(geminiInboundTestRequest)))
+
+		if !captured.Stream {
+			t.Fatalf("expected upstream stream request")
+		}
+		if !strings.HasPrefix(w.Header().Get("Content-Type"), "text/event-stream") {
+			t.Fatalf("expected SSE content type, got %s", w.Header().Get("Content-Type"))
+		}
+
+		var text strings.Builder
+		var last converter.GeminiResponse
+		for _, event := range strings.Split(strings.TrimSpace(w.Body.String()), "\r\n\r\n") {
+			var chunk converter.GeminiResponse
+			if err := json.Unmarshal([]byte(strings.TrimPrefix(event, "data: ")), &chunk); err != nil {
+				t.Fatalf("invalid stream chunk %q: %v", event, err)
+			}
+			for _, part := range chunk.Candidates[0].Content.Parts {
+				if part.Text != nil {
+					text.WriteString(*part.Text)
+				}
+			}
+			last = chunk
+		}
+		if text.String() != "pong" || last.Candidates[0].FinishReason != "STOP" || last.UsageMetadata == nil {
+			t.Fatalf("unexpected stream output:\n%s", w.Body.String())
+		}
+	})
+}
+
+func TestGenerateContent_ViaOpenAIError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = w.Write([]byte(`{"error":{"message":"slow down","type":"rate_limit"}}`))
+	}))
+	defer server.Close()
+
+	engine := newGeminiInboundTestEngine(t, &models.Provider{Name: "openai", BaseURL: server.URL, APIKey: "sk-test", Enabled: true}, "gpt-4o")
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest("POST", "/v1beta/models/my-model:generateContent", strings.NewReader(geminiInboundTestRequest)))
+
+	var errResp struct {
+		Error struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+			Status  string `json:"status"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &errResp); err != nil {
+		t.Fatalf("failed to decode error response: %v", err)
+	}
+	if w.Code != http.StatusTooManyRequests || errResp.Error.Status != "RESOURCE_EXHAUSTED" || errResp.Error.Message != "slow down" {
+		t.Fatalf("unexpected error response: %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestGenerateContent_GeminiPassthrough(t *testing.T) {
+	var captured converter.GeminiRequest
+	server := newGeminiHandlerStandIn(t, &captured)
+	defer server.Close()
+
+	engine := newGeminiInboundTestEngine(t, newGeminiTestProvider(server.URL), "gemini-2.0-flash")
+
+	t.Run("non-stream", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest("POST", "/v1beta/models/my-model:generateContent", strings.NewReader(geminiInboundTestRequest)))
+
+		if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"functionCall"`) {
+			t.Fatalf("unexpected response: %d %s", w.Code, w.Body.String())
+		}
+		// 原生透传：参数 Schema 保持 Gemini 原始写法
+		if captured.Tools[0].FunctionDeclarations[0].Parameters["type"] != "OBJECT" {
+			t.Fatalf("expected request to be forwarded unchanged, got %+v", captured.Tools)
+		}
+	})
+
+	t.Run("stream", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest("POST", "/v1beta/models/my-model:streamGenerateContent?alt=sse", strings.NewReader(geminiInboundTestRequest)))
+
+		if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"text":"pong"`) {
+			t.Fatalf("unexpected stream response: %d %s", w.Code, w.Body.String())
+		}
+	})
+}
+
+func TestGenerateContent_RequestErrors(t *testing.T) {
+	engine := newGeminiInboundTestEngine(t, &models.Provider{Name: "openai", BaseURL: "http://127.0.0.1:1", APIKey: "sk-test", Enabled: true}, "gpt-4o")
+
+	cases := []struct {
+		name       string
+		path       string
+		body       string
+		wantStatus int
+		wantCode   string
+	}{
+		{"unknown model", "/v1beta/models/unknown:generateContent", `{"contents":[]}`, http.StatusNotFound, "NOT_FOUND"},
+		{"unsupported method", "/v1beta/models/my-model:embedContent", `{}`, http.StatusNotFound, "NOT_FOUND"},
+		{"missing method", "/v1beta/models/my-model", `{}`, http.StatusNotFound, "NOT_FOUND"},
+		{"invalid json", "/v1beta/models/my-model:generateContent", `{`, http.StatusBadRequest, "INVALID_ARGUMENT"},
+		{"unsupported part", "/v1beta/models/my-model:generateContent", `{"contents":[{"role":"user","parts":[{"fileData":{"fileUri":"gs://bucket/a.pdf"}}]}]}`, http.StatusBadRequest, "INVALID_ARGUMENT"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, httptest.NewRequest("POST", tc.path, bytes.NewBufferString(tc.body)))
+
+			if w.Code != tc.wantStatus || !strings.Contains(w.Body.String(), `"status":"`+tc.wantCode+`"`) {
+				t.Fatalf("unexpected response: %d %s", w.Code, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Mieluoxxx/Siriusx-API/internal/converter"
+	"github.com/Mieluoxxx/Siriusx-API/internal/models"
+	"github.com/Mieluoxxx/Siriusx-API/internal/provider"
+	"github.com/gin-gonic/gin"
+)
+
+// newGeminiHandlerStandIn 模拟 Gemini API 的 generateContent 与 streamGenerateContent
+func newGeminiHandlerStandIn(t *testing.T, captured *converter.GeminiRequest) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("x-goog-api-key") != "gemini-key" {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"error":{"code":403,"message":"API key not valid","status":"PERMISSION_DENIED"}}`))
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(body, captured)
+
+		switch r.URL.Path {
+		case "/v1beta/models/gemini-2.0-flash:generateContent":
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"candidates":[{"content":{"role":"model","parts":[{"functionCall":{"name":"get_weather","args":{"city":"Paris"}}}]},"finishReason":"STOP"}],"usageMetadata":{"promptTokenCount":9,"candidatesTokenCount":3}}`))
+		case "/v1beta/models/gemini-2.0-flash:streamGenerateContent":
+			if r.URL.Query().Get("alt") != "sse" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = w.Write([]byte("data: {\"candidates\":[{\"content\":{\"role\":\"model\",\"parts\":[{\"text\":\"pong\"}]},\"finishReason\":\"STOP\"}],\"usageMetadata\":{\"promptTokenCount\":9,\"candidatesTokenCount\":1}}\r\n\r\n"))
+		case "/v1beta/models/gemini-missing:generateContent":
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":{"code":404,"message":"models/gemini-missing is not found","status":"NOT_FOUND"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func newGeminiTestProvider(baseURL string) *models.Provider {
+	return &models.Provider{
+		Name:    "gemini",
+		Type:    provider.ProviderTypeGemini,
+		BaseURL: baseURL,
+		APIKey:  "gemini-key",
+	}
+}
+
+func TestForwardClaudeViaGemini(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var captured converter.GeminiRequest
+	server := newGeminiHandlerStandIn(t, &captured)
+	defer server.Close()
+	prov := newGeminiTestProvider(server.URL)
+
+	t.Run("non-stream tool call", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("POST", "/v1/messages", nil)
+		c.Request.Header.Set("Authorization", "Bearer client-token")
+
+		req := newBedrockTestRequest(false)
+		req["system"] = "Be brief."
+
+		handler := &ProxyHandler{}
+		handler.forwardClaudeViaGemini(c, prov, "gemini-2.0-flash", req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("unexpected status code: %d, body: %s", w.Code, w.Body.String())
+		}
+		if captured.SystemInstruction == nil || len(captured.Tools) != 1 {
+			t.Fatalf("expected system instruction and tools upstream, got %+v", captured)
+		}
+
+		var claudeResp converter.ClaudeResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &claudeResp); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if claudeResp.StopReason != "tool_use" || claudeResp.Content[0].Type != "tool_use" || *claudeResp.Content[0].Name != "get_weather" {
+			t.Fatalf("unexpected Claude response: %s", w.Body.String())
+		}
+		if claudeResp.Usage.InputTokens != 9 || claudeResp.Usage.OutputTokens != 3 {
+			t.Fatalf("unexpected usage: %+v", claudeResp.Usage)
+		}
+	})
+
+	t.Run("stream", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("POST", "/v1/messages", nil)
+
+		handler := &ProxyHandler{}
+		handler.forwardClaudeViaGemini(c, prov, "gemini-2.0-flash", newBedrockTestRequest(true))
+
+		body := w.Body.String()
+		if !strings.HasPrefix(w.Header().Get("Content-Type"), "text/event-stream") {
+			t.Fatalf("expected SSE content type, got %s", w.Header().Get("Content-Type"))
+		}
+		for _, expected := range []string{`"text":"pong"`, `"stop_reason":"end_turn"`, "event: message_stop\n"} {
+			if !strings.Contains(body, expected) {
+				t.Fatalf("expected SSE output to contain %q, got:\n%s", expected, body)
+			}
+		}
+	})
+
+	t.Run("upstream error", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("POST", "/v1/messages", nil)
+
+		handler := &ProxyHandler{}
+		handler.forwardClaudeViaGemini(c, prov, "gemini-missing", newBedrockTestRequest(false))
+
+		if w.Code != http.StatusNotFound || !strings.Contains(w.Body.String(), `"type":"not_found_error"`) {
+			t.Fatalf("unexpected error response: %d %s", w.Code, w.Body.String())
+		}
+	})
+}
+
+func TestForwardOpenAIViaGemini(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var captured converter.GeminiRequest
+	server := newGeminiHandlerStandIn(t, &captured)
+	defer server.Close()
+	prov := newGeminiTestProvider(server.URL)
+
+	newRequest := func(stream bool) map[string]interface{} {
+		var req map[string]interface{}
+		_ = json.Unmarshal([]byte(`{
+			"model": "gemini-2.0-flash",
+			"messages": [{"role": "system", "content": "Be brief."}, {"role": "user", "content": "ping"}],
+			"tools": [{"type": "function", "function": {"name": "get_weather", "parameters": {"type": "object", "properties": {"city": {"type": "string"}}}}}]
+		}`), &req)
+		req["stream"] = stream
+		return req
+	}
+
+	t.Run("non-stream", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("POST", "/v1/chat/completions", bytes.NewBufferString(`{}`))
+
+		handler := &ProxyHandler{}
+		handler.forwardOpenAIViaGemini(c, prov, "gemini-2.0-flash", newRequest(false))
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("unexpected status code: %d, body: %s", w.Code, w.Body.String())
+		}
+		if captured.SystemInstruction == nil || *captured.SystemInstruction.Parts[0].Text != "Be brief." {
+			t.Fatalf("expected system instruction upstream, got %+v", captured.SystemInstruction)
+		}
+
+		var openaiResp converter.OpenAIResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &openaiResp); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		choice := openaiResp.Choices[0]
+		if choice.FinishReason != "tool_calls" || len(choice.Message.ToolCalls) != 1 || choice.Message.ToolCalls[0].Function.Arguments != `{"city":"Paris"}` {
+			t.Fatalf("unexpected OpenAI response: %s", w.Body.String())
+		}
+		if openaiResp.Usage.TotalTokens != 12 {
+			t.Fatalf("unexpected usage: %+v", openaiResp.Usage)
+		}
+	})
+
+	t.Run("stream", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("POST", "/v1/chat/completions", nil)
+
+		handler := &ProxyHandler{}
+		handler.forwardOpenAIViaGemini(c, prov, "gemini-2.0-flash", newRequest(true))
+
+		body := w.Body.String()
+		for _, expected := range []string{`"content":"pong"`, `"finish_reason":"stop"`, "data: [DONE]\n\n"} {
+			if !strings.Contains(body, expected) {
+				t.Fatalf("expected stream to contain %q, got:\n%s", expected, body)
+			}
+		}
+	})
+
+	t.Run("upstream error", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("POST", "/v1/chat/completions", nil)
+
+		handler := &ProxyHandler{}
+		handler.forwardOpenAIViaGemini(c, prov, "gemini-missing", newRequest(false))
+
+		var errResp struct {
+			Error struct {
+				Message string `json:"message"`
+				Code    string `json:"code"`
+			} `json:"error"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &errResp); err != nil || w.Code != http.StatusNotFound || errResp.Error.Code != "NOT_FOUND" {
+			t.Fatalf("unexpected error response: %d %s", w.Code, w.Body.String())
+		}
+	})
+}
@@ -10,15 +10,17 @@ import (
 	"io"
 	"log"
 	"net/http"
+	"strconv"
 	"strings"
-	"time"
 	"unicode/utf8"
 
 	"github.com/Mieluoxxx/Siriusx-API/internal/balancer"
+	"github.com/Mieluoxxx/Siriusx-API/internal/cache"
 	"github.com/Mieluoxxx/Siriusx-API/internal/converter"
 	"github.com/Mieluoxxx/Siriusx-API/internal/mapping"
 	"github.com/Mieluoxxx/Siriusx-API/internal/models"
 	"github.com/Mieluoxxx/Siriusx-API/internal/provider"
+	"github.com/Mieluoxxx/Siriusx-API/internal/responses"
 	"github.com/gin-gonic/gin"
 )
 
@@ -27,17 +29,76 @@ type ProxyHandler struct {
 	providerService *provider.Service
 	router          mapping.Router
 	balancer        balancer.LoadBalancer
+	failureDetector *balancer.DefaultFailureDetector // 记录可重试请求（如 Embeddings）的供应商故障，为 nil 时不记录
+	transports      *provider.TransportRegistry
+	responseStore   *responses.Service   // Responses API 历史（previous_response_id），为 nil 时不保存
+	responseCache   *cache.ResponseCache // 精确匹配响应缓存，为 nil 时不缓存
+	toolArguments   *ToolArgumentStats   // 工具调用参数修复统计，为 nil 时不记录
 }
 
 // NewProxyHandler 创建代理处理器
-func NewProxyHandler(providerService *provider.Service, router mapping.Router) *ProxyHandler {
+func NewProxyHandler(providerService *provider.Service, router mapping.Router, responseStore *responses.Service, responseCache *cache.ResponseCache, toolArguments *ToolArgumentStats) *ProxyHandler {
 	return &ProxyHandler{
 		providerService: providerService,
 		router:          router,
 		balancer:        balancer.NewWeightedRandomBalancer(),
+		failureDetector: balancer.NewFailureDetector(nil),
+		transports:      providerService.Transports(),
+		responseStore:   responseStore,
+		responseCache:   responseCache,
+		toolArguments:   toolArguments,
 	}
 }
 
+// toolArgumentRecorder 返回转换上游响应时使用的参数修复记录者，未配置统计时返回 nil
+func (h *ProxyHandler) toolArgumentRecorder() converter.ToolArgumentRecorder {
+	if h.toolArguments == nil {
+		return nil
+	}
+	return h.toolArguments
+}
+
+// copyForwardHeaders 按供应商的请求头转发策略复制客户端请求头
+// 仅转发白名单内的请求头，逐跳请求头与凭证类请求头始终剥离
+func (h *ProxyHandler) copyForwardHeaders(c *gin.Context, prov *models.Provider, dst http.Header) error {
+	policy, err := provider.HeaderPolicyForProvider(prov)
+	if err != nil {
+		return err
+	}
+
+	dropped := policy.Copy(dst, c.Request.Header)
+	if gin.IsDebugging() {
+		log.Printf("🔍 [请求头策略] Provider: %s, 放行: [%s], 丢弃: %v", prov.Name, policy, dropped)
+	}
+	return nil
+}
+
+// templateVars 构建上游路径与自定义请求头使用的模板变量
+func (h *ProxyHandler) templateVars(c *gin.Context, prov *models.Provider, targetModel string) provider.TemplateVars {
+	vars := provider.TemplateVars{
+		provider.TemplateVarProviderName: prov.Name,
+		provider.TemplateVarModel:        targetModel,
+		provider.TemplateVarClientIP:     c.ClientIP(),
+	}
+
+	if value, exists := c.Get("token"); exists {
+		if tok, ok := value.(*models.Token); ok && tok != nil {
+			vars[provider.TemplateVarTokenName] = tok.Name
+			vars[provider.TemplateVarTokenID] = strconv.FormatUint(uint64(tok.ID), 10)
+		}
+	}
+
+	return vars
+}
+
+// httpClient 获取供应商复用的 HTTP 客户端（包含代理与 TLS 设置）
+func (h *ProxyHandler) httpClient(prov *models.Provider) (*http.Client, error) {
+	if h.transports == nil {
+		return provider.DefaultTransportRegistry().Client(prov)
+	}
+	return h.transports.Client(prov)
+}
+
 // parseJSONBody 读取并解析请求体
 func parseJSONBody(c *gin.Context) (map[string]interface{}, []byte, error) {
 	bodyBytes, err := io.ReadAll(c.Request.Body)
@@ -144,18 +205,62 @@ func (h *ProxyHandler) ChatCompletions(c *gin.Context) {
 		return
 	}
 
-	req["model"] = selectedMapping.TargetModel
-	h.sanitizeRequest(req, prov.Name)
+	h.withResponseCache(c, cacheKindChat, modelName, selectedMapping, req, func() {
+		if selectedMapping.EmulateTools && req["tools"] != nil {
+			h.dispatchChatWithToolEmulation(c, prov, selectedMapping, modelName, req)
+			return
+		}
+		h.dispatchChatRequest(c, prov, selectedMapping, modelName, req)
+	})
+}
+
+// dispatchChatRequest 转发 Chat Completions 请求，响应按请求的 stream 参数适配为流式或非流式
+func (h *ProxyHandler) dispatchChatRequest(c *gin.Context, prov *models.Provider, selectedMapping *mapping.ResolvedMapping, modelName string, req map[string]interface{}) {
+	h.withStreamMode(c, cacheKindChat, req, func() {
+		h.routeChatRequest(c, prov, selectedMapping, modelName, req)
+	})
+}
+
+// routeChatRequest 按供应商类型转发 Chat Completions 请求（原生透传或转换为上游格式）
+func (h *ProxyHandler) routeChatRequest(c *gin.Context, prov *models.Provider, selectedMapping *mapping.ResolvedMapping, modelName string, req map[string]interface{}) {
+	if provider.UsesGeminiAPI(prov, selectedMapping.TargetModel) {
+		log.Printf("🔁 [ChatCompletions] 检测到 Gemini 上游，执行 OpenAI→Gemini 转换 [Provider: %s, Target: %s]",
+			prov.Name, selectedMapping.TargetModel)
+		h.forwardOpenAIViaGemini(c, prov, selectedMapping.TargetModel, req)
+		return
+	}
+
+	if provider.UsesOllamaAPI(prov) {
+		log.Printf("🔁 [ChatCompletions] 检测到 Ollama 上游，执行 OpenAI→Ollama 转换 [Provider: %s, Target: %s]",
+			prov.Name, selectedMapping.TargetModel)
+		h.forwardOpenAIViaOllama(c, prov, selectedMapping.TargetModel, req)
+		return
+	}
 
 	providerName := prov.Name
 	if selectedMapping.Provider != nil && selectedMapping.Provider.Name != "" {
 		providerName = selectedMapping.Provider.Name
 	}
 
+	if h.usesClaudeAPI(prov, selectedMapping.TargetModel) {
+		h.forwardOpenAIViaClaude(c, prov, providerName, modelName, selectedMapping, req)
+		return
+	}
+
+	if !provider.SupportsOpenAIChat(prov) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("%s 类型的供应商不支持 OpenAI Chat Completions 接口，请使用 /v1/messages", provider.ProviderType(prov)),
+		})
+		return
+	}
+
+	req["model"] = selectedMapping.TargetModel
+	h.sanitizeRequest(req, prov.Name)
+
 	log.Printf("🔀 [ChatCompletions] 映射选择 - 统一模型: %s -> 供应商: %s, 目标模型: %s",
 		modelName, providerName, selectedMapping.TargetModel)
 
-	h.forwardRequest(c, prov, req, "/v1/chat/completions")
+	h.forwardRequest(c, prov, req, provider.EndpointChat)
 }
 
 // Messages 处理 Claude Messages API 请求
@@ -200,56 +305,84 @@ func (h *ProxyHandler) Messages(c *gin.Context) {
 		providerName = selectedMapping.Provider.Name
 	}
 
-	if h.shouldConvertToOpenAI(prov, selectedMapping.TargetModel) {
-		req["model"] = selectedMapping.TargetModel
-		h.sanitizeRequest(req, prov.Name)
-		log.Printf("🔁 [Messages] 检测到 OpenAI 上游，执行 Claude→OpenAI 转换 [Provider: %s, Target: %s]", providerName, selectedMapping.TargetModel)
-		h.forwardClaudeViaOpenAI(c, prov, selectedMapping.TargetModel, req)
-		return
-	}
+	h.withResponseCache(c, cacheKindMessages, modelName, selectedMapping, req, func() {
+		if selectedMapping.EmulateTools && req["tools"] != nil {
+			h.dispatchClaudeWithToolEmulation(c, prov, providerName, modelName, selectedMapping, req)
+			return
+		}
+		h.dispatchClaudeRequest(c, prov, providerName, modelName, selectedMapping, req)
+	})
+}
 
-	req["model"] = selectedMapping.TargetModel
-	h.sanitizeRequest(req, prov.Name)
+// dispatchClaudeRequest 转发 Claude Messages 请求，响应按请求的 stream 参数适配为流式或非流式
+func (h *ProxyHandler) dispatchClaudeRequest(c *gin.Context, prov *models.Provider, providerName, modelName string, selectedMapping *mapping.ResolvedMapping, req map[string]interface{}) {
+	h.withStreamMode(c, cacheKindMessages, req, func() {
+		h.routeClaudeRequest(c, prov, providerName, modelName, selectedMapping, req)
+	})
+}
 
-	log.Printf("🔀 [Messages] 映射选择 - 统一模型: %s -> 供应商: %s, 目标模型: %s",
-		modelName, providerName, selectedMapping.TargetModel)
+// routeClaudeRequest 按供应商类型转发 Claude Messages 请求（原生透传或转换为上游格式）
+func (h *ProxyHandler) routeClaudeRequest(c *gin.Context, prov *models.Provider, providerName, modelName string, selectedMapping *mapping.ResolvedMapping, req map[string]interface{}) {
+	targetModel := selectedMapping.TargetModel
 
-	h.forwardRequest(c, prov, req, "/v1/messages")
-}
+	// Bedrock 原生支持 Claude 格式，无需 sanitizeRequest（其 tools 清洗规则针对 OpenAI 兼容上游）
+	if provider.ProviderType(prov) == provider.ProviderTypeBedrock {
+		log.Printf("🔀 [Messages] 映射选择 - 统一模型: %s -> 供应商: %s (Bedrock), 目标模型: %s",
+			modelName, providerName, targetModel)
+		h.forwardClaudeViaBedrock(c, prov, targetModel, req)
+		return
+	}
 
-// MessagesCountTokens 计算 Claude 请求的 token 用量（本地估算）
-func (h *ProxyHandler) MessagesCountTokens(c *gin.Context) {
-	var req map[string]interface{}
-	bodyBytes, err := io.ReadAll(c.Request.Body)
-	if err != nil {
-		h.respondClaudeError(c, http.StatusBadRequest, "invalid_request_error", "无法读取请求体")
+	if provider.UsesGeminiAPI(prov, targetModel) {
+		log.Printf("🔁 [Messages] 检测到 Gemini 上游，执行 Claude→Gemini 转换 [Provider: %s, Target: %s]", providerName, targetModel)
+		h.forwardClaudeViaGemini(c, prov, targetModel, req)
 		return
 	}
 
-	if len(bodyBytes) == 0 {
-		h.respondClaudeError(c, http.StatusBadRequest, "invalid_request_error", "请求体不能为空")
+	if provider.UsesOllamaAPI(prov) {
+		log.Printf("🔁 [Messages] 检测到 Ollama 上游，执行 Claude→Ollama 转换 [Provider: %s, Target: %s]", providerName, targetModel)
+		h.forwardClaudeViaOllama(c, prov, targetModel, req)
 		return
 	}
 
-	if err := json.Unmarshal(bodyBytes, &req); err != nil {
-		h.respondClaudeError(c, http.StatusBadRequest, "invalid_request_error", "无效的 JSON 格式")
+	if provider.ProviderType(prov) == provider.ProviderTypeVertex {
+		log.Printf("🔀 [Messages] 映射选择 - 统一模型: %s -> 供应商: %s (Vertex), 目标模型: %s",
+			modelName, providerName, targetModel)
+		h.forwardClaudeViaVertex(c, prov, targetModel, req)
 		return
 	}
 
-	h.normalizeClaudePayload(req)
-	inputTokens := calculateInputTokens(req)
-
-	response := gin.H{
-		"type": "message",
-		"usage": gin.H{
-			"input_tokens":                inputTokens,
-			"output_tokens":               0,
-			"cache_creation_input_tokens": 0,
-			"cache_read_input_tokens":     0,
-		},
+	// Claude→OpenAI 转换会重新生成 tools，无需 sanitizeRequest（Claude 工具定义没有 type 字段，会被误删）
+	if h.shouldConvertToOpenAI(prov, targetModel) {
+		req["model"] = targetModel
+		log.Printf("🔁 [Messages] 检测到 OpenAI 上游，执行 Claude→OpenAI 转换 [Provider: %s, Target: %s]", providerName, targetModel)
+		h.forwardClaudeViaOpenAI(c, prov, selectedMapping, req)
+		return
+	}
+
+	req["model"] = targetModel
+	h.sanitizeRequest(req, prov.Name)
+
+	log.Printf("🔀 [Messages] 映射选择 - 统一模型: %s -> 供应商: %s, 目标模型: %s",
+		modelName, providerName, targetModel)
+
+	h.forwardRequest(c, prov, req, provider.EndpointMessages)
+}
+
+// setAnthropicHeaders 设置 Claude 原生接口所需的 anthropic-version / anthropic-beta 请求头
+func setAnthropicHeaders(c *gin.Context, header http.Header) {
+	// 传递 anthropic-version 头（如果客户端提供了的话）
+	if version := c.GetHeader("anthropic-version"); version != "" {
+		header.Set("anthropic-version", version)
+	} else {
+		// 使用默认版本
+		header.Set("anthropic-version", "2023-06-01")
 	}
 
-	c.JSON(http.StatusOK, response)
+	// 传递 anthropic-beta 头（如果客户端提供了的话）
+	if beta := c.GetHeader("anthropic-beta"); beta != "" {
+		header.Set("anthropic-beta", beta)
+	}
 }
 
 // respondClaudeError 返回 Claude API 格式的错误响应
@@ -264,7 +397,11 @@ func (h *ProxyHandler) respondClaudeError(c *gin.Context, status int, errorType,
 }
 
 // forwardRequest 转发请求到供应商
-func (h *ProxyHandler) forwardRequest(c *gin.Context, prov *models.Provider, req map[string]interface{}, endpoint string) {
+func (h *ProxyHandler) forwardRequest(c *gin.Context, prov *models.Provider, req map[string]interface{}, endpoint provider.EndpointKind) {
+	if endpoint == provider.EndpointMessages || endpoint == provider.EndpointCountTokens {
+		req = withoutGatewayThinking(req)
+	}
+
 	// 重新序列化请求体
 	newBody, err := json.Marshal(req)
 	if err != nil {
@@ -274,14 +411,16 @@ func (h *ProxyHandler) forwardRequest(c *gin.Context, prov *models.Provider, req
 		return
 	}
 
-	// 构建目标 URL
-	targetURL := strings.TrimSuffix(prov.BaseURL, "/") + endpoint
+	// 构建目标 URL（支持供应商自定义路径）
+	targetModel, _ := req["model"].(string)
+	vars := h.templateVars(c, prov, targetModel)
+	targetURL := provider.EndpointURL(prov, endpoint, vars)
 
 	// 📝 记录转发详情
 	log.Printf("➡️  [转发] 目标URL: %s, 请求体大小: %d bytes", targetURL, len(newBody))
 
 	// 创建新请求
-	proxyReq, err := http.NewRequest("POST", targetURL, bytes.NewBuffer(newBody))
+	proxyReq, err := http.NewRequestWithContext(c.Request.Context(), "POST", targetURL, bytes.NewBuffer(newBody))
 	if err != nil {
 		log.Printf("❌ [转发失败] 创建请求失败: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -292,37 +431,39 @@ func (h *ProxyHandler) forwardRequest(c *gin.Context, prov *models.Provider, req
 
 	// 设置基本请求头
 	proxyReq.Header.Set("Content-Type", "application/json")
-	proxyReq.Header.Set("Authorization", "Bearer "+prov.APIKey)
+	provider.ApplyAuth(proxyReq.Header, prov)
 
 	// 针对 Claude Messages API 设置特殊请求头
-	if endpoint == "/v1/messages" {
-		// 传递 anthropic-version 头（如果客户端提供了的话）
-		if version := c.GetHeader("anthropic-version"); version != "" {
-			proxyReq.Header.Set("anthropic-version", version)
-		} else {
-			// 使用默认版本
-			proxyReq.Header.Set("anthropic-version", "2023-06-01")
-		}
+	if endpoint == provider.EndpointMessages || endpoint == provider.EndpointCountTokens {
+		setAnthropicHeaders(c, proxyReq.Header)
+	}
 
-		// 传递 anthropic-beta 头（如果客户端提供了的话）
-		if beta := c.GetHeader("anthropic-beta"); beta != "" {
-			proxyReq.Header.Set("anthropic-beta", beta)
-		}
+	// 按转发策略复制客户端请求头（默认拒绝）
+	if err := h.copyForwardHeaders(c, prov, proxyReq.Header); err != nil {
+		log.Printf("❌ [转发失败] Provider: %s, 请求头转发策略无效: %v", prov.Name, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "供应商请求头转发配置无效",
+		})
+		return
 	}
 
-	// 复制其他相关请求头
-	for key, values := range c.Request.Header {
-		if key != "Host" && key != "Authorization" &&
-			key != "Anthropic-Version" && key != "Anthropic-Beta" {
-			for _, value := range values {
-				proxyReq.Header.Add(key, value)
-			}
-		}
+	// 应用供应商自定义请求头
+	if err := provider.ApplyCustomHeaders(proxyReq.Header, prov, vars); err != nil {
+		log.Printf("❌ [转发失败] Provider: %s, 自定义请求头无效: %v", prov.Name, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "供应商自定义请求头配置无效",
+		})
+		return
 	}
 
-	// 发送请求
-	client := &http.Client{
-		Timeout: 300 * time.Second, // 5分钟超时，增加对网络延迟的容忍度
+	// 发送请求（复用供应商连接池，超时由连接池配置控制）
+	client, err := h.httpClient(prov)
+	if err != nil {
+		log.Printf("❌ [转发失败] Provider: %s, 连接配置无效: %v", prov.Name, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("供应商连接配置无效: %v", err),
+		})
+		return
 	}
 
 	resp, err := client.Do(proxyReq)
@@ -354,36 +495,11 @@ func (h *ProxyHandler) forwardRequest(c *gin.Context, prov *models.Provider, req
 		// 设置响应状态
 		c.Status(resp.StatusCode)
 
-		// 流式转发响应体
-		flusher, ok := c.Writer.(http.Flusher)
-		if !ok {
-			log.Printf("❌ [流式转发失败] ResponseWriter 不支持流式传输")
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": "不支持流式传输",
-			})
-			return
-		}
-
 		// 边读边写，实现真正的流式转发
-		buffer := make([]byte, 4096)
-		totalBytes := 0
-		for {
-			n, readErr := resp.Body.Read(buffer)
-			if n > 0 {
-				totalBytes += n
-				if _, writeErr := c.Writer.Write(buffer[:n]); writeErr != nil {
-					log.Printf("❌ [流式转发] 写入失败: %v", writeErr)
-					return
-				}
-				flusher.Flush() // 立即刷新，确保客户端能实时接收
-			}
-			if readErr == io.EOF {
-				break
-			}
-			if readErr != nil {
-				log.Printf("❌ [流式转发] 读取失败: %v", readErr)
-				return
-			}
+		totalBytes, err := pipeStream(c, resp.Body)
+		if err != nil {
+			log.Printf("❌ [流式转发] Provider: %s, %v", prov.Name, err)
+			return
 		}
 
 		log.Printf("✅ [完成] 流式响应转发完成，共 %d bytes", totalBytes)
@@ -548,7 +664,9 @@ func (h *ProxyHandler) forwardRequest(c *gin.Context, prov *models.Provider, req
 }
 
 // forwardClaudeViaOpenAI 将 Claude Messages 请求转换为 OpenAI Chat Completions 请求再转发
-func (h *ProxyHandler) forwardClaudeViaOpenAI(c *gin.Context, prov *models.Provider, targetModel string, req map[string]interface{}) {
+// 映射标记工具调用不可靠时，流式响应的工具参数缓冲修复后一次性输出
+func (h *ProxyHandler) forwardClaudeViaOpenAI(c *gin.Context, prov *models.Provider, selectedMapping *mapping.ResolvedMapping, req map[string]interface{}) {
+	targetModel := selectedMapping.TargetModel
 	payloadBytes, err := json.Marshal(req)
 	if err != nil {
 		log.Printf("❌ [转换失败] 无法序列化 Claude 请求: %v", err)
@@ -570,10 +688,14 @@ func (h *ProxyHandler) forwardClaudeViaOpenAI(c *gin.Context, prov *models.Provi
 
 	claudeReq.Model = targetModel
 
-	openaiReq, err := converter.ConvertClaudeToOpenAI(&claudeReq)
+	openaiReq, err := converter.ConvertClaudeToOpenAIWithOptions(&claudeReq, converter.ClaudeToOpenAIOptions{
+		CacheHints:        provider.SupportsPromptCacheHints(prov),
+		PDFTextExtraction: provider.NeedsPDFTextExtraction(prov),
+		StreamUsage:       provider.SupportsStreamUsage(prov),
+	})
 	if err != nil {
 		log.Printf("❌ [转换失败] Claude→OpenAI: %v", err)
-		h.respondClaudeError(c, http.StatusBadRequest, "invalid_request_error", "Claude 请求转换 OpenAI 格式失败")
+		h.respondClaudeError(c, http.StatusBadRequest, "invalid_request_error", fmt.Sprintf("Claude 请求转换 OpenAI 格式失败: %v", err))
 		return
 	}
 
@@ -586,10 +708,11 @@ func (h *ProxyHandler) forwardClaudeViaOpenAI(c *gin.Context, prov *models.Provi
 		return
 	}
 
-	targetURL := strings.TrimSuffix(prov.BaseURL, "/") + "/v1/chat/completions"
+	vars := h.templateVars(c, prov, targetModel)
+	targetURL := provider.EndpointURL(prov, provider.EndpointChat, vars)
 	log.Printf("➡️  [转发] Claude→OpenAI 目标URL: %s, 请求体大小: %d bytes", targetURL, len(openaiBody))
 
-	proxyReq, err := http.NewRequest("POST", targetURL, bytes.NewBuffer(openaiBody))
+	proxyReq, err := http.NewRequestWithContext(c.Request.Context(), "POST", targetURL, bytes.NewBuffer(openaiBody))
 	if err != nil {
 		log.Printf("❌ [转发失败] 创建 OpenAI 请求失败: %v", err)
 		h.respondClaudeError(c, http.StatusInternalServerError, "api_error", "创建代理请求失败")
@@ -597,25 +720,30 @@ func (h *ProxyHandler) forwardClaudeViaOpenAI(c *gin.Context, prov *models.Provi
 	}
 
 	proxyReq.Header.Set("Content-Type", "application/json")
-	proxyReq.Header.Set("Authorization", "Bearer "+prov.APIKey)
+	provider.ApplyAuth(proxyReq.Header, prov)
 	if openaiReq.Stream {
 		proxyReq.Header.Set("Accept", "text/event-stream")
 	}
 
-	for key, values := range c.Request.Header {
-		if key == "Host" || key == "Authorization" {
-			continue
-		}
-		lowerKey := strings.ToLower(key)
-		if strings.HasPrefix(lowerKey, "anthropic") {
-			continue
-		}
-		for _, value := range values {
-			proxyReq.Header.Add(key, value)
-		}
+	if err := h.copyForwardHeaders(c, prov, proxyReq.Header); err != nil {
+		log.Printf("❌ [转发失败] Provider: %s, 请求头转发策略无效: %v", prov.Name, err)
+		h.respondClaudeError(c, http.StatusInternalServerError, "api_error", "供应商请求头转发配置无效")
+		return
+	}
+
+	if err := provider.ApplyCustomHeaders(proxyReq.Header, prov, vars); err != nil {
+		log.Printf("❌ [转发失败] Provider: %s, 自定义请求头无效: %v", prov.Name, err)
+		h.respondClaudeError(c, http.StatusInternalServerError, "api_error", "供应商自定义请求头配置无效")
+		return
+	}
+
+	client, err := h.httpClient(prov)
+	if err != nil {
+		log.Printf("❌ [转发失败] Provider: %s, 连接配置无效: %v", prov.Name, err)
+		h.respondClaudeError(c, http.StatusInternalServerError, "api_error", fmt.Sprintf("供应商连接配置无效: %v", err))
+		return
 	}
 
-	client := &http.Client{Timeout: 300 * time.Second} // 5分钟超时，增加对网络延迟的容忍度
 	resp, err := client.Do(proxyReq)
 	if err != nil {
 		log.Printf("❌ [转发失败] Provider: %s, 错误: %v", prov.Name, err)
@@ -628,7 +756,10 @@ func (h *ProxyHandler) forwardClaudeViaOpenAI(c *gin.Context, prov *models.Provi
 	isStreamResponse := strings.Contains(strings.ToLower(contentType), "text/event-stream")
 
 	if isStreamResponse {
-		convertedReader, err := converter.ConvertStream(c.Request.Context(), resp.Body)
+		convertedReader, err := converter.ConvertStreamWithOptions(c.Request.Context(), resp.Body, converter.StreamOptions{
+			BufferToolArguments: selectedMapping.UnreliableToolCalls,
+			ToolArguments:       h.toolArgumentRecorder(),
+		})
 		if err != nil {
 			log.Printf("❌ [流式转换失败] Provider: %s, 错误: %v", prov.Name, err)
 			h.respondClaudeError(c, http.StatusBadGateway, "api_error", "上游流式响应转换失败")
@@ -648,34 +779,12 @@ func (h *ProxyHandler) forwardClaudeViaOpenAI(c *gin.Context, prov *models.Provi
 
 		c.Status(resp.StatusCode)
 
-		flusher, ok := c.Writer.(http.Flusher)
-		if !ok {
-			log.Printf("❌ [流式转发失败] ResponseWriter 不支持流式传输")
-			h.respondClaudeError(c, http.StatusInternalServerError, "api_error", "不支持流式传输")
+		totalBytes, err := pipeStream(c, convertedReader)
+		if err != nil {
+			log.Printf("❌ [流式转发] Provider: %s, %v", prov.Name, err)
 			return
 		}
 
-		buffer := make([]byte, 4096)
-		totalBytes := 0
-		for {
-			n, readErr := convertedReader.Read(buffer)
-			if n > 0 {
-				totalBytes += n
-				if _, writeErr := c.Writer.Write(buffer[:n]); writeErr != nil {
-					log.Printf("❌ [流式转发] 写入失败: %v", writeErr)
-					return
-				}
-				flusher.Flush()
-			}
-			if readErr == io.EOF {
-				break
-			}
-			if readErr != nil {
-				log.Printf("❌ [流式转发] 读取失败: %v", readErr)
-				return
-			}
-		}
-
 		log.Printf("✅ [完成] Claude 流式响应转换完成，共 %d bytes", totalBytes)
 		return
 	}
@@ -730,7 +839,9 @@ func (h *ProxyHandler) forwardClaudeViaOpenAI(c *gin.Context, prov *models.Provi
 		return
 	}
 
-	claudeResp, err := converter.ConvertOpenAIToClaude(&openaiResp)
+	claudeResp, err := converter.ConvertOpenAIToClaudeWithOptions(&openaiResp, converter.OpenAIToClaudeOptions{
+		ToolArguments: h.toolArgumentRecorder(),
+	})
 	if err != nil {
 		log.Printf("❌ [转换失败] OpenAI→Claude: %v", err)
 		h.respondClaudeError(c, http.StatusInternalServerError, "api_error", "上游响应转换 Claude 格式失败")
@@ -762,6 +873,10 @@ func (h *ProxyHandler) forwardClaudeViaOpenAI(c *gin.Context, prov *models.Provi
 
 // shouldConvertToOpenAI 判断是否需要将 Claude 请求转换为 OpenAI 兼容请求
 func (h *ProxyHandler) shouldConvertToOpenAI(prov *models.Provider, targetModel string) bool {
+	if provider.IsOpenAIFormat(prov) {
+		return true
+	}
+
 	target := strings.ToLower(targetModel)
 	if strings.Contains(target, "claude") {
 		return false
@@ -775,6 +890,33 @@ func (h *ProxyHandler) shouldConvertToOpenAI(prov *models.Provider, targetModel
 	return true
 }
 
+// pipeStream 边读边写，将流式响应逐块转发给客户端，返回写出的字节数
+func pipeStream(c *gin.Context, reader io.Reader) (int, error) {
+	flusher, ok := c.Writer.(http.Flusher)
+	if !ok {
+		return 0, fmt.Errorf("ResponseWriter 不支持流式传输")
+	}
+
+	buffer := make([]byte, 4096)
+	totalBytes := 0
+	for {
+		n, readErr := reader.Read(buffer)
+		if n > 0 {
+			totalBytes += n
+			if _, writeErr := c.Writer.Write(buffer[:n]); writeErr != nil {
+				return totalBytes, fmt.Errorf("写入失败: %w", writeErr)
+			}
+			flusher.Flush()
+		}
+		if readErr == io.EOF {
+			return totalBytes, nil
+		}
+		if readErr != nil {
+			return totalBytes, fmt.Errorf("读取失败: %w", readErr)
+		}
+	}
+}
+
 // decompressIfNeeded 如果响应是 gzip 压缩则解压缩
 func decompressIfNeeded(raw []byte, header http.Header) ([]byte, bool, error) {
 	isGzipped := len(raw) >= 2 && raw[0] == 0x1f && raw[1] == 0x8b
@@ -826,28 +968,35 @@ func (h *ProxyHandler) normalizeClaudePayload(req map[string]interface{}) {
 		req["messages"] = messages
 	}
 
-	// 规范 system 字段
+	// 规范 system 字段：保留数组结构（cache_control 等字段原生透传），仅将字符串元素和单个 block 规范为 text block 数组
 	if systemVal, exists := req["system"]; exists {
 		switch val := systemVal.(type) {
 		case []interface{}:
-			var parts []string
-			for _, item := range val {
-				switch v := item.(type) {
-				case string:
-					parts = append(parts, v)
-				case map[string]interface{}:
-					if text, ok := v["text"].(string); ok {
-						parts = append(parts, text)
+			for idx, item := range val {
+				if text, ok := item.(string); ok {
+					val[idx] = map[string]interface{}{
+						"type": "text",
+						"text": text,
 					}
 				}
 			}
-			req["system"] = strings.Join(parts, "\n")
 		case map[string]interface{}:
-			if text, ok := val["text"].(string); ok {
-				req["system"] = text
-			}
+			req["system"] = []interface{}{val}
+		}
+	}
+}
+
+// withoutGatewayThinking 移除代理生成签名的 thinking 块，用于转发到原生 Claude 上游
+// 同一统一模型的映射可能先后落到 OpenAI 兼容上游与原生上游，后者会拒绝无法校验的签名
+func withoutGatewayThinking(req map[string]interface{}) map[string]interface{} {
+	stripped, removed := converter.StripGatewayThinking(req)
+	if removed > 0 {
+		log.Printf("🧹 [思考块] 移除 %d 个代理生成签名的 thinking 块", removed)
+		if _, ok := stripped["thinking"]; !ok && req["thinking"] != nil {
+			log.Printf("⚠️  [思考块] 工具调用轮次缺少原生 thinking 块，本次请求关闭 thinking")
 		}
 	}
+	return stripped
 }
 
 // sanitizeRequest 清洗请求参数，移除不兼容的字段
@@ -948,8 +1097,22 @@ func calculateInputTokens(req map[string]interface{}) int {
 	}
 
 	// 计算system的tokens
-	if system, ok := req["system"].(string); ok {
+	switch system := req["system"].(type) {
+	case string:
 		totalTokens += estimateTokens(system)
+	case []interface{}:
+		for _, block := range system {
+			if blockMap, ok := block.(map[string]interface{}); ok {
+				if text, ok := blockMap["text"].(string); ok {
+					totalTokens += estimateTokens(text)
+				}
+			}
+		}
+	}
+
+	// 计算prompt的tokens（旧版 Completions、Images）
+	if prompt, ok := req["prompt"].(string); ok {
+		totalTokens += estimateTokens(prompt)
 	}
 
 	return totalTokens
@@ -967,6 +1130,10 @@ func extractResponseText(respData map[string]interface{}) string {
 					return content
 				}
 			}
+			// 旧版 Completions 格式: choices[0].text
+			if choiceText, ok := choice["text"].(string); ok {
+				return choiceText
+			}
 		}
 	}
 
@@ -2,14 +2,19 @@ package handlers
 
 import (
 	"bytes"
+	"encoding/base64"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/Mieluoxxx/Siriusx-API/internal/converter"
+	"github.com/Mieluoxxx/Siriusx-API/internal/mapping"
 	"github.com/Mieluoxxx/Siriusx-API/internal/models"
+	"github.com/Mieluoxxx/Siriusx-API/internal/provider"
 	"github.com/gin-gonic/gin"
 )
 
@@ -90,7 +95,7 @@ func TestForwardClaudeViaOpenAI(t *testing.T) {
 	c.Request = httptest.NewRequest("POST", "/v1/messages", bytes.NewBuffer(raw))
 	c.Request.Header.Set("Content-Type", "application/json")
 
-	handler.forwardClaudeViaOpenAI(c, provider, "glm-4.6", req)
+	handler.forwardClaudeViaOpenAI(c, provider, &mapping.ResolvedMapping{TargetModel: "glm-4.6"}, req)
 
 	resp := w.Result()
 	if resp.StatusCode != http.StatusOK {
@@ -123,6 +128,427 @@ func TestForwardClaudeViaOpenAI(t *testing.T) {
 	}
 }
 
+func TestForwardClaudeViaOpenAI_ArrayToolResult(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var capturedBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		capturedBody, _ = io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"chatcmpl-1","object":"chat.completion","created":1,"model":"glm-4.6","choices":[{"index":0,"message":{"role":"assistant","content":"a login page"},"finish_reason":"stop"}],"usage":{"prompt_tokens":5,"completion_tokens":3,"total_tokens":8}}`))
+	}))
+	defer server.Close()
+
+	handler := &ProxyHandler{}
+	provider := &models.Provider{Name: "ixio", BaseURL: server.URL, APIKey: "sk-test"}
+	raw := []byte(`{
+        "model": "claude-sonnet-4-5-20250929",
+        "messages": [
+            {"role": "user", "content": [{"type": "text", "text": "take a screenshot"}]},
+            {"role": "assistant", "content": [{"type": "tool_use", "id": "toolu_1", "name": "screenshot", "input": {}}]},
+            {"role": "user", "content": [{
+                "type": "tool_result",
+                "tool_use_id": "toolu_1",
+                "is_error": true,
+                "content": [
+                    {"type": "text", "text": "partial capture"},
+                    {"type": "image", "source": {"type": "base64", "media_type": "image/png", "data": "iVBORw0KGgo="}}
+                ]
+            }]}
+        ]
+    }`)
+
+	var req map[string]interface{}
+	if err := json.Unmarshal(raw, &req); err != nil {
+		t.Fatalf("failed to prepare test payload: %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("POST", "/v1/messages", bytes.NewBuffer(raw))
+
+	handler.forwardClaudeViaOpenAI(c, provider, &mapping.ResolvedMapping{TargetModel: "glm-4.6"}, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status code: %d, body: %s", w.Code, w.Body.String())
+	}
+
+	var openaiReq struct {
+		Messages []map[string]interface{} `json:"messages"`
+	}
+	if err := json.Unmarshal(capturedBody, &openaiReq); err != nil {
+		t.Fatalf("failed to decode upstream request: %v", err)
+	}
+	if len(openaiReq.Messages) != 4 {
+		t.Fatalf("expected user, assistant, tool and follow-up user messages, got %s", capturedBody)
+	}
+	tool := openaiReq.Messages[2]
+	if tool["role"] != "tool" || tool["tool_call_id"] != "toolu_1" || tool["content"] != "[ERROR] partial capture" {
+		t.Fatalf("unexpected tool message: %+v", tool)
+	}
+	followUp, _ := json.Marshal(openaiReq.Messages[3])
+	if !strings.Contains(string(followUp), `"url":"data:image/png;base64,iVBORw0KGgo="`) {
+		t.Fatalf("expected screenshot in follow-up user message, got %s", followUp)
+	}
+}
+
+func TestForwardClaudeViaOpenAI_UnreliableToolCallsStream(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, chunk := range []string{
+			`{"id":"c1","model":"glm-4.6","choices":[{"index":0,"delta":{"role":"assistant","tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"get_weather","arguments":"{'city'"}}]}}]}`,
+			`{"id":"c1","model":"glm-4.6","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":": 'Paris',"}}]}}]}`,
+			`{"id":"c1","model":"glm-4.6","choices":[{"index":0,"delta":{},"finish_reason":"tool_calls"}]}`,
+		} {
+			_, _ = w.Write([]byte("data: " + chunk + "\n\n"))
+		}
+		_, _ = w.Write([]byte("data: [DONE]\n\n"))
+	}))
+	defer server.Close()
+
+	stats := NewToolArgumentStats()
+	handler := &ProxyHandler{toolArguments: stats}
+	provider := &models.Provider{Name: "weak-relay", BaseURL: server.URL, APIKey: "sk-test"}
+	raw := []byte(`{"model":"claude-sonnet-4-5","stream":true,"max_tokens":64,"messages":[{"role":"user","content":[{"type":"text","text":"weather in Paris?"}]}],
+		"tools":[{"name":"get_weather","input_schema":{"type":"object","properties":{"city":{"type":"string"}}}}]}`)
+	var req map[string]interface{}
+	if err := json.Unmarshal(raw, &req); err != nil {
+		t.Fatalf("failed to prepare test payload: %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("POST", "/v1/messages", bytes.NewBuffer(raw))
+
+	handler.forwardClaudeViaOpenAI(c, provider, &mapping.ResolvedMapping{TargetModel: "glm-4.6", UnreliableToolCalls: true}, req)
+
+	var deltas []string
+	for _, block := range strings.Split(w.Body.String(), "\n\n") {
+		_, data, found := strings.Cut(block, "data: ")
+		if !found || !strings.Contains(data, "input_json_delta") {
+			continue
+		}
+		var event struct {
+			Delta struct {
+				PartialJSON string `json:"partial_json"`
+			} `json:"delta"`
+		}
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			t.Fatalf("invalid event %q: %v", data, err)
+		}
+		deltas = append(deltas, event.Delta.PartialJSON)
+	}
+	if len(deltas) != 1 || deltas[0] != `{"city":"Paris"}` {
+		t.Fatalf("expected a single repaired input_json_delta, got %q", deltas)
+	}
+
+	// 修复结果计入处理器持有的统计，并由运行统计接口返回
+	engine := gin.New()
+	engine.GET("/api/stats/tool-arguments", NewStatsHandler(stats).GetToolArgumentRepairs)
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest("GET", "/api/stats/tool-arguments", nil))
+	var snapshot ToolArgumentRepairStats
+	if err := json.Unmarshal(w.Body.Bytes(), &snapshot); err != nil {
+		t.Fatalf("failed to decode stats: %v", err)
+	}
+	if snapshot.Repaired != 1 || snapshot.Failed != 0 || snapshot.Kinds[converter.RepairSingleQuotes] != 1 {
+		t.Fatalf("unexpected repair stats: %+v", snapshot)
+	}
+}
+
+func TestForwardRequest_CustomPathAndHeaders(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var capturedPath, capturedTitle, capturedReferer string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		capturedPath = r.URL.Path
+		capturedTitle = r.Header.Get("X-Title")
+		capturedReferer = r.Header.Get("HTTP-Referer")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"chatcmpl-1","choices":[]}`))
+	}))
+	defer server.Close()
+
+	handler := &ProxyHandler{}
+	prov := &models.Provider{
+		Name:          "glm",
+		BaseURL:       server.URL,
+		APIKey:        "sk-test",
+		ChatPath:      "/api/paas/v4/chat/completions",
+		CustomHeaders: `{"X-Title":"Siriusx {{token_name}}","HTTP-Referer":"https://siriusx.local"}`,
+	}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("POST", "/v1/chat/completions", nil)
+	c.Set("token", &models.Token{ID: 7, Name: "ci-bot"})
+
+	handler.forwardRequest(c, prov, map[string]interface{}{"model": "glm-4.6"}, provider.EndpointChat)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status code: %d", w.Code)
+	}
+	if capturedPath != "/api/paas/v4/chat/completions" {
+		t.Fatalf("expected custom chat path, got %s", capturedPath)
+	}
+	if capturedTitle != "Siriusx ci-bot" {
+		t.Fatalf("expected templated X-Title header, got %q", capturedTitle)
+	}
+	if capturedReferer != "https://siriusx.local" {
+		t.Fatalf("expected HTTP-Referer header, got %q", capturedReferer)
+	}
+}
+
+// newHeaderCaptureServer 记录上游收到的请求头
+func newHeaderCaptureServer(t *testing.T, captured *http.Header) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*captured = r.Header.Clone()
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{
+            "id": "chatcmpl-1",
+            "object": "chat.completion",
+            "model": "glm-4.6",
+            "choices": [{"index": 0, "message": {"role": "assistant", "content": "ok"}, "finish_reason": "stop"}]
+        }`))
+	}))
+}
+
+// newLeakyRequest 构造携带各类敏感与逐跳请求头的客户端请求
+func newLeakyRequest(path string) *http.Request {
+	req := httptest.NewRequest("POST", path, bytes.NewBufferString(`{}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer sk-client-token")
+	req.Header.Set("X-Api-Key", "sk-client-token")
+	req.Header.Set("Cookie", "session=secret")
+	req.Header.Set("X-Forwarded-For", "10.0.0.1")
+	req.Header.Set("Accept-Encoding", "br")
+	req.Header.Set("Connection", "keep-alive, X-Internal-Trace")
+	req.Header.Set("X-Internal-Trace", "trace-1")
+	req.Header.Set("Keep-Alive", "timeout=5")
+	req.Header.Set("Upgrade", "h2c")
+	req.Header.Set("Te", "trailers")
+	req.Header.Set("User-Agent", "claude-cli/1.0")
+	req.Header.Set("Accept-Language", "zh-CN")
+	req.Header.Set("X-Stainless-Lang", "js")
+	req.Header.Set("Anthropic-Version", "2023-06-01")
+	return req
+}
+
+func TestForwardRequest_HeaderPolicyDefaultDeny(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var captured http.Header
+	server := newHeaderCaptureServer(t, &captured)
+	defer server.Close()
+
+	handler := &ProxyHandler{}
+	prov := &models.Provider{Name: "glm", BaseURL: server.URL, APIKey: "sk-upstream"}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = newLeakyRequest("/v1/chat/completions")
+
+	handler.forwardRequest(c, prov, map[string]interface{}{"model": "glm-4.6"}, provider.EndpointChat)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status code: %d", w.Code)
+	}
+	if got := captured.Get("Authorization"); got != "Bearer sk-upstream" {
+		t.Fatalf("expected upstream credentials only, got %q", got)
+	}
+	if got := captured.Values("Content-Type"); len(got) != 1 {
+		t.Fatalf("expected a single Content-Type header, got %v", got)
+	}
+	for _, name := range []string{"User-Agent", "Accept-Language"} {
+		if captured.Get(name) == "" {
+			t.Fatalf("expected allowlisted header %s to be forwarded", name)
+		}
+	}
+	for _, name := range []string{"X-Api-Key", "Cookie", "X-Forwarded-For", "Keep-Alive", "Upgrade", "Te", "X-Internal-Trace", "X-Stainless-Lang", "Anthropic-Version"} {
+		if got := captured.Get(name); got != "" {
+			t.Fatalf("expected header %s to be stripped, got %q", name, got)
+		}
+	}
+	if got := captured.Get("Accept-Encoding"); got == "br" {
+		t.Fatalf("expected client Accept-Encoding to be stripped")
+	}
+}
+
+func TestForwardRequest_HeaderPolicyProviderAdditions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var captured http.Header
+	server := newHeaderCaptureServer(t, &captured)
+	defer server.Close()
+
+	handler := &ProxyHandler{}
+	prov := &models.Provider{
+		Name:           "openrouter",
+		BaseURL:        server.URL,
+		APIKey:         "sk-upstream",
+		ForwardHeaders: `["X-Forwarded-For","X-Stainless-*","X-Internal-Trace"]`,
+	}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = newLeakyRequest("/v1/chat/completions")
+
+	handler.forwardRequest(c, prov, map[string]interface{}{"model": "glm-4.6"}, provider.EndpointChat)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status code: %d", w.Code)
+	}
+	if got := captured.Get("X-Forwarded-For"); got != "10.0.0.1" {
+		t.Fatalf("expected X-Forwarded-For to be forwarded, got %q", got)
+	}
+	if got := captured.Get("X-Stainless-Lang"); got != "js" {
+		t.Fatalf("expected prefix-matched header to be forwarded, got %q", got)
+	}
+	// Connection 中列出的请求头即使在白名单中也属于逐跳请求头
+	if got := captured.Get("X-Internal-Trace"); got != "" {
+		t.Fatalf("expected Connection-listed header to be stripped, got %q", got)
+	}
+	if got := captured.Get("Cookie"); got != "" {
+		t.Fatalf("expected Cookie to be stripped, got %q", got)
+	}
+}
+
+func TestForwardClaudeViaOpenAI_HeaderPolicy(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var captured http.Header
+	server := newHeaderCaptureServer(t, &captured)
+	defer server.Close()
+
+	handler := &ProxyHandler{}
+	prov := &models.Provider{Name: "glm", BaseURL: server.URL, APIKey: "sk-upstream"}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = newLeakyRequest("/v1/messages")
+
+	req := map[string]interface{}{
+		"model":      "claude-sonnet-4-5-20250929",
+		"max_tokens": 16,
+		"messages": []interface{}{map[string]interface{}{
+			"role":    "user",
+			"content": []interface{}{map[string]interface{}{"type": "text", "text": "ping"}},
+		}},
+	}
+	handler.forwardClaudeViaOpenAI(c, prov, &mapping.ResolvedMapping{TargetModel: "glm-4.6"}, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status code: %d, body: %s", w.Code, w.Body.String())
+	}
+	if got := captured.Get("Authorization"); got != "Bearer sk-upstream" {
+		t.Fatalf("expected upstream credentials only, got %q", got)
+	}
+	for _, name := range []string{"X-Api-Key", "Cookie", "X-Forwarded-For", "Anthropic-Version", "Upgrade"} {
+		if got := captured.Get(name); got != "" {
+			t.Fatalf("expected header %s to be stripped, got %q", name, got)
+		}
+	}
+	if got := captured.Get("User-Agent"); got != "claude-cli/1.0" {
+		t.Fatalf("expected User-Agent to be forwarded, got %q", got)
+	}
+}
+
+// newAzureStandIn 模拟 Azure OpenAI 部署接口，记录命中的路径
+func newAzureStandIn(t *testing.T, capturedPath *string) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*capturedPath = r.URL.Path
+		if r.Header.Get("api-key") != "azure-key" || r.Header.Get("Authorization") != "" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Query().Get("api-version") != "2024-10-21" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{
+            "id": "chatcmpl-azure",
+            "object": "chat.completion",
+            "model": "gpt-4o",
+            "choices": [{"index": 0, "message": {"role": "assistant", "content": "pong"}, "finish_reason": "stop"}],
+            "usage": {"prompt_tokens": 5, "completion_tokens": 1, "total_tokens": 6}
+        }`))
+	}))
+}
+
+func TestForwardRequest_Azure(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var capturedPath string
+	server := newAzureStandIn(t, &capturedPath)
+	defer server.Close()
+
+	handler := &ProxyHandler{}
+	prov := &models.Provider{Name: "azure", Type: provider.ProviderTypeAzure, BaseURL: server.URL, APIKey: "azure-key"}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("POST", "/v1/chat/completions", nil)
+	c.Request.Header.Set("Authorization", "Bearer sk-client-token")
+
+	handler.forwardRequest(c, prov, map[string]interface{}{"model": "prod-gpt4o"}, provider.EndpointChat)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status code: %d", w.Code)
+	}
+	if capturedPath != "/openai/deployments/prod-gpt4o/chat/completions" {
+		t.Fatalf("expected deployment URL, got %s", capturedPath)
+	}
+}
+
+func TestForwardClaudeViaOpenAI_Azure(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var capturedPath string
+	server := newAzureStandIn(t, &capturedPath)
+	defer server.Close()
+
+	handler := &ProxyHandler{}
+	prov := &models.Provider{Name: "azure", Type: provider.ProviderTypeAzure, BaseURL: server.URL, APIKey: "azure-key"}
+
+	if !handler.shouldConvertToOpenAI(prov, "claude-sonnet-4-5") {
+		t.Fatalf("expected azure upstream to always require conversion")
+	}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("POST", "/v1/messages", nil)
+
+	req := map[string]interface{}{
+		"model":      "prod-gpt4o",
+		"max_tokens": 16,
+		"messages": []interface{}{map[string]interface{}{
+			"role":    "user",
+			"content": []interface{}{map[string]interface{}{"type": "text", "text": "ping"}},
+		}},
+	}
+	handler.forwardClaudeViaOpenAI(c, prov, &mapping.ResolvedMapping{TargetModel: "prod-gpt4o"}, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status code: %d, body: %s", w.Code, w.Body.String())
+	}
+	if capturedPath != "/openai/deployments/prod-gpt4o/chat/completions" {
+		t.Fatalf("expected deployment URL, got %s", capturedPath)
+	}
+
+	var claudeResp converter.ClaudeResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &claudeResp); err != nil {
+		t.Fatalf("failed to decode downstream response: %v", err)
+	}
+	if len(claudeResp.Content) == 0 || claudeResp.Content[0].Text == nil || *claudeResp.Content[0].Text != "pong" {
+		t.Fatalf("expected converted text 'pong', got %+v", claudeResp.Content)
+	}
+}
+
 func TestNormalizeClaudePayload(t *testing.T) {
 	handler := &ProxyHandler{}
 	req := map[string]interface{}{
@@ -134,7 +560,7 @@ func TestNormalizeClaudePayload(t *testing.T) {
 		},
 		"system": []interface{}{
 			"alpha",
-			map[string]interface{}{"text": "beta"},
+			map[string]interface{}{"type": "text", "text": "beta", "cache_control": map[string]interface{}{"type": "ephemeral"}},
 		},
 	}
 
@@ -160,10 +586,19 @@ func TestNormalizeClaudePayload(t *testing.T) {
 		t.Fatalf("content block mismatch: %+v", block)
 	}
 
-	system, ok := req["system"].(string)
-	if !ok || system != "alpha\nbeta" {
+	// system 保留数组结构，字符串元素规范为 text block，cache_control 原样保留
+	system, ok := req["system"].([]interface{})
+	if !ok || len(system) != 2 {
 		t.Fatalf("system field normalization failed: %v", req["system"])
 	}
+	first, _ := system[0].(map[string]interface{})
+	second, _ := system[1].(map[string]interface{})
+	if first["type"] != "text" || first["text"] != "alpha" {
+		t.Fatalf("string system element should become text block: %+v", system[0])
+	}
+	if second["text"] != "beta" || second["cache_control"] == nil {
+		t.Fatalf("system block cache_control should be preserved: %+v", system[1])
+	}
 }
 
 func TestMessagesCountTokens(t *testing.T) {
@@ -212,3 +647,327 @@ func TestMessagesCountTokens(t *testing.T) {
 		t.Fatalf("output_tokens should be 0, got %v", usage["output_tokens"])
 	}
 }
+
+func TestMessages_PromptCacheControl(t *testing.T) {
+	var captured map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		captured = nil
+		_ = json.NewDecoder(r.Body).Decode(&captured)
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/v1/chat/completions" {
+			_, _ = w.Write([]byte(`{"id":"chatcmpl-1","object":"chat.completion","created":1,"model":"m","choices":[{"index":0,"message":{"role":"assistant","content":"ok"},"finish_reason":"stop"}],"usage":{"prompt_tokens":1,"completion_tokens":1,"total_tokens":2}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"id":"msg_1","type":"message","role":"assistant","model":"m","content":[{"type":"text","text":"ok"}],"stop_reason":"end_turn","usage":{"input_tokens":1,"output_tokens":1}}`))
+	}))
+	defer server.Close()
+
+	const body = `{"model":"my-model","max_tokens":16,
+		"system":[{"type":"text","text":"You are Claude Code.","cache_control":{"type":"ephemeral"}}],
+		"messages":[{"role":"user","content":[{"type":"text","text":"hi","cache_control":{"type":"ephemeral","ttl":"1h"}}]}]}`
+	send := func(targetModel string, prov *models.Provider) {
+		t.Helper()
+		handler := newMultiProviderTestHandler(t, targetModel, prov)
+		engine := gin.New()
+		engine.POST("/v1/messages", handler.Messages)
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest("POST", "/v1/messages", strings.NewReader(body)))
+		if w.Code != http.StatusOK {
+			t.Fatalf("unexpected status %d: %s", w.Code, w.Body.String())
+		}
+	}
+	systemOf := func() string {
+		encoded, _ := json.Marshal(captured["system"])
+		return string(encoded)
+	}
+	messagesOf := func() string {
+		encoded, _ := json.Marshal(captured["messages"])
+		return string(encoded)
+	}
+
+	t.Run("native passthrough keeps structure", func(t *testing.T) {
+		send("claude-sonnet-4-5", &models.Provider{Name: "anthropic", BaseURL: server.URL, APIKey: "sk-test"})
+		if systemOf() != `[{"cache_control":{"type":"ephemeral"},"text":"You are Claude Code.","type":"text"}]` {
+			t.Fatalf("system should be forwarded unchanged, got %s", systemOf())
+		}
+		if !strings.Contains(messagesOf(), `"cache_control":{"ttl":"1h","type":"ephemeral"}`) {
+			t.Fatalf("message cache_control should be forwarded, got %s", messagesOf())
+		}
+	})
+
+	t.Run("openai upstream with cache hints", func(t *testing.T) {
+		send("glm-4.6", &models.Provider{Name: "openrouter", BaseURL: server.URL, APIKey: "sk-test", PromptCacheHints: true})
+		if !strings.Contains(messagesOf(), `{"cache_control":{"type":"ephemeral"},"text":"You are Claude Code.","type":"text"}`) ||
+			!strings.Contains(messagesOf(), `"cache_control":{"ttl":"1h","type":"ephemeral"}`) {
+			t.Fatalf("cache_control should be mapped to content parts, got %s", messagesOf())
+		}
+	})
+
+	t.Run("openai upstream without cache hints", func(t *testing.T) {
+		send("glm-4.6", &models.Provider{Name: "glm", BaseURL: server.URL, APIKey: "sk-test"})
+		if strings.Contains(messagesOf(), "cache_control") || !strings.Contains(messagesOf(), `{"content":"You are Claude Code.","role":"system"}`) {
+			t.Fatalf("cache_control should be dropped for plain upstreams, got %s", messagesOf())
+		}
+	})
+}
+
+// stream_options 仅对开启 stream_usage 的供应商发送，严格校验参数的上游不会收到未知字段
+func TestMessages_StreamUsageOption(t *testing.T) {
+	var captured map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		captured = nil
+		_ = json.NewDecoder(r.Body).Decode(&captured)
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte(`data: {"id":"c1","object":"chat.completion.chunk","created":1,"model":"m","choices":[{"index":0,"delta":{"content":"ok"},"finish_reason":"stop"}]}` + "\n\n"))
+		_, _ = w.Write([]byte("data: [DONE]\n\n"))
+	}))
+	defer server.Close()
+
+	send := func(prov *models.Provider) {
+		t.Helper()
+		handler := newMultiProviderTestHandler(t, "glm-4.6", prov)
+		engine := gin.New()
+		engine.POST("/v1/messages", handler.Messages)
+		w := httptest.NewRecorder()
+		body := `{"model":"my-model","max_tokens":16,"stream":true,"messages":[{"role":"user","content":"hi"}]}`
+		engine.ServeHTTP(w, httptest.NewRequest("POST", "/v1/messages", strings.NewReader(body)))
+		if w.Code != http.StatusOK {
+			t.Fatalf("unexpected status %d: %s", w.Code, w.Body.String())
+		}
+	}
+
+	send(&models.Provider{Name: "relay", BaseURL: server.URL, APIKey: "sk-test"})
+	if _, ok := captured["stream_options"]; ok {
+		t.Fatalf("stream_options should not be sent by default, got %+v", captured["stream_options"])
+	}
+
+	send(&models.Provider{Name: "openai", BaseURL: server.URL, APIKey: "sk-test", StreamUsage: true})
+	if options, _ := captured["stream_options"].(map[string]interface{}); options["include_usage"] != true {
+		t.Fatalf("stream_options.include_usage should be sent when stream_usage is enabled, got %+v", captured["stream_options"])
+	}
+}
+
+func TestMessages_DocumentBlocks(t *testing.T) {
+	var captured map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		captured = nil
+		_ = json.NewDecoder(r.Body).Decode(&captured)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"chatcmpl-1","object":"chat.completion","created":1,"model":"m","choices":[{"index":0,"message":{"role":"assistant","content":"ok"},"finish_reason":"stop"}],"usage":{"prompt_tokens":1,"completion_tokens":1,"total_tokens":2}}`))
+	}))
+	defer server.Close()
+
+	pdf := base64.StdEncoding.EncodeToString([]byte("%PDF-1.4\n4 0 obj << /Length 30 >>\nstream\nBT (Quarterly revenue) Tj ET\nendstream\nendobj\n%%EOF\n"))
+	send := func(prov *models.Provider, source string) *httptest.ResponseRecorder {
+		t.Helper()
+		handler := newMultiProviderTestHandler(t, "glm-4.6", prov)
+		engine := gin.New()
+		engine.POST("/v1/messages", handler.Messages)
+		body := `{"model":"my-model","max_tokens":16,"messages":[{"role":"user","content":[{"type":"document","source":` + source + `},{"type":"text","text":"Summarize"}]}]}`
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest("POST", "/v1/messages", strings.NewReader(body)))
+		return w
+	}
+	messagesOf := func() string {
+		encoded, _ := json.Marshal(captured["messages"])
+		return string(encoded)
+	}
+
+	t.Run("pdf sent as file part", func(t *testing.T) {
+		w := send(&models.Provider{Name: "openai", BaseURL: server.URL, APIKey: "sk-test"}, `{"type":"base64","media_type":"application/pdf","data":"`+pdf+`"}`)
+		if w.Code != http.StatusOK {
+			t.Fatalf("unexpected status %d: %s", w.Code, w.Body.String())
+		}
+		if !strings.Contains(messagesOf(), `"file_data":"data:application/pdf;base64,`) {
+			t.Fatalf("pdf should be forwarded as file part, got %s", messagesOf())
+		}
+	})
+
+	t.Run("pdf text extraction", func(t *testing.T) {
+		w := send(&models.Provider{Name: "glm", BaseURL: server.URL, APIKey: "sk-test", PDFTextExtraction: true}, `{"type":"base64","media_type":"application/pdf","data":"`+pdf+`"}`)
+		if w.Code != http.StatusOK {
+			t.Fatalf("unexpected status %d: %s", w.Code, w.Body.String())
+		}
+		if !strings.Contains(messagesOf(), `{"text":"Quarterly revenue","type":"text"}`) || strings.Contains(messagesOf(), "file_data") {
+			t.Fatalf("pdf text should be extracted, got %s", messagesOf())
+		}
+	})
+
+	t.Run("url document rejected", func(t *testing.T) {
+		captured = nil
+		w := send(&models.Provider{Name: "glm", BaseURL: server.URL, APIKey: "sk-test"}, `{"type":"url","url":"https://example.com/report.pdf"}`)
+		if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "URL") {
+			t.Fatalf("expected 400 explaining the unsupported document, got %d: %s", w.Code, w.Body.String())
+		}
+		if captured != nil {
+			t.Fatalf("request should not reach upstream")
+		}
+	})
+}
+
+// OpenAI 兼容上游产生的 thinking 块回传到原生 Claude 上游时移除代理签名
+// 工具调用轮次失去 thinking 块后同时关闭 thinking，避免上游要求以 thinking 块开头
+func TestMessages_GatewayThinkingStrippedForNativeUpstream(t *testing.T) {
+	openaiServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"c1","object":"chat.completion","model":"o3","choices":[{"index":0,"message":{"role":"assistant","content":null,"reasoning_content":"Need the weather.","tool_calls":[{"id":"call_1","type":"function","function":{"name":"get_weather","arguments":"{\"city\":\"Paris\"}"}}]},"finish_reason":"tool_calls"}],"usage":{"prompt_tokens":5,"completion_tokens":5,"total_tokens":10}}`))
+	}))
+	defer openaiServer.Close()
+
+	var nativeBody map[string]interface{}
+	nativeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(raw, &nativeBody)
+		if strings.Contains(string(raw), `"signature":"siriusx-`) {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"type":"error","error":{"type":"invalid_request_error","message":"Invalid signature in thinking block"}}`))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"msg_2","type":"message","role":"assistant","model":"claude-sonnet-4-5","content":[{"type":"text","text":"Sunny"}],"stop_reason":"end_turn","usage":{"input_tokens":9,"output_tokens":1}}`))
+	}))
+	defer nativeServer.Close()
+
+	// 第一轮：OpenAI 兼容上游产生带代理签名的 thinking 块
+	engine := gin.New()
+	engine.POST("/v1/messages", newMultiProviderTestHandler(t, "o3", &models.Provider{Name: "relay", BaseURL: openaiServer.URL, APIKey: "sk-test"}).Messages)
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest("POST", "/v1/messages", strings.NewReader(`{"model":"my-model","max_tokens":2048,"thinking":{"type":"enabled","budget_tokens":1024},"messages":[{"role":"user","content":"Weather in Paris?"}]}`)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("first turn failed: %d %s", w.Code, w.Body.String())
+	}
+	var first struct {
+		Content []map[string]interface{} `json:"content"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &first); err != nil || len(first.Content) != 2 || first.Content[0]["type"] != "thinking" {
+		t.Fatalf("expected thinking and tool_use blocks, got %s", w.Body.String())
+	}
+
+	// 第二轮：客户端原样回传，被路由到原生 Claude 上游
+	followUp, _ := json.Marshal(map[string]interface{}{
+		"model":      "my-model",
+		"max_tokens": 2048,
+		"thinking":   map[string]interface{}{"type": "enabled", "budget_tokens": 1024},
+		"messages": []interface{}{
+			map[string]interface{}{"role": "user", "content": "Weather in Paris?"},
+			map[string]interface{}{"role": "assistant", "content": first.Content},
+			map[string]interface{}{"role": "user", "content": []interface{}{
+				map[string]interface{}{"type": "tool_result", "tool_use_id": first.Content[1]["id"], "content": "Sunny"},
+			}},
+		},
+	})
+	engine = gin.New()
+	engine.POST("/v1/messages", newMultiProviderTestHandler(t, "claude-sonnet-4-5", &models.Provider{Name: "anthropic", BaseURL: nativeServer.URL, APIKey: "sk-ant"}).Messages)
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest("POST", "/v1/messages", bytes.NewReader(followUp)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("follow-up to native upstream failed: %d %s", w.Code, w.Body.String())
+	}
+
+	assistant := nativeBody["messages"].([]interface{})[1].(map[string]interface{})
+	if content := assistant["content"].([]interface{}); len(content) != 1 || content[0].(map[string]interface{})["type"] != "tool_use" {
+		t.Fatalf("unexpected assistant content sent upstream: %v", content)
+	}
+	if _, ok := nativeBody["thinking"]; ok {
+		t.Fatalf("thinking should be disabled for a tool-use turn without native thinking blocks")
+	}
+}
+
+// TestChatCompletions_ResponseFormatOnClaudeUpstream 测试 Chat Completions 经 Claude 原生上游时以强制工具调用模拟 response_format
+func TestChatCompletions_ResponseFormatOnClaudeUpstream(t *testing.T) {
+	var upstreamPath string
+	var upstreamBody map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstreamPath = r.URL.Path
+		raw, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(raw, &upstreamBody)
+		if upstreamBody["stream"] == true {
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = w.Write([]byte("event: message_start\r\ndata: {\"type\":\"message_start\",\"message\":{\"id\":\"msg_1\",\"type\":\"message\",\"role\":\"assistant\",\"model\":\"claude-sonnet-4-5\",\"content\":[],\"usage\":{\"input_tokens\":12,\"output_tokens\":1}}}\r\n\r\n" +
+				"event: content_block_start\r\ndata: {\"type\":\"content_block_start\",\"index\":0,\"content_block\":{\"type\":\"tool_use\",\"id\":\"toolu_1\",\"name\":\"structured_output\",\"input\":{}}}\r\n\r\n" +
+				"event: content_block_delta\r\ndata: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"input_json_delta\",\"partial_json\":\"{\\\"city\\\":\"}}\r\n\r\n" +
+				"event: content_block_delta\r\ndata: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"input_json_delta\",\"partial_json\":\"\\\"Paris\\\"}\"}}\r\n\r\n" +
+				"event: content_block_stop\r\ndata: {\"type\":\"content_block_stop\",\"index\":0}\r\n\r\n" +
+				"event: message_delta\r\ndata: {\"type\":\"message_delta\",\"delta\":{\"stop_reason\":\"tool_use\"},\"usage\":{\"output_tokens\":8}}\r\n\r\n" +
+				"event: message_stop\r\ndata: {\"type\":\"message_stop\"}\r\n\r\n"))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"msg_1","type":"message","role":"assistant","model":"claude-sonnet-4-5","content":[{"type":"tool_use","id":"toolu_1","name":"structured_output","input":{"city":"Paris"}}],"stop_reason":"tool_use","usage":{"input_tokens":12,"output_tokens":8}}`))
+	}))
+	defer server.Close()
+
+	engine := gin.New()
+	engine.POST("/v1/chat/completions", newMultiProviderTestHandler(t, "claude-sonnet-4-5", &models.Provider{Name: "anthropic", BaseURL: server.URL, APIKey: "sk-ant"}).ChatCompletions)
+
+	const body = `{"model":"my-model","messages":[{"role":"system","content":"Answer in JSON."},{"role":"user","content":"Capital of France?"}],` +
+		`"response_format":{"type":"json_schema","json_schema":{"name":"city","schema":{"type":"object","properties":{"city":{"type":"string"}},"required":["city"]}}}%s}`
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest("POST", "/v1/chat/completions", strings.NewReader(fmt.Sprintf(body, ""))))
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d %s", w.Code, w.Body.String())
+	}
+	if upstreamPath != "/v1/messages" {
+		t.Fatalf("expected upstream to hit /v1/messages, got %s", upstreamPath)
+	}
+	toolChoice, _ := upstreamBody["tool_choice"].(map[string]interface{})
+	if toolChoice["type"] != "tool" || toolChoice["name"] != converter.StructuredOutputToolName {
+		t.Fatalf("expected forced structured output tool, got %v", upstreamBody["tool_choice"])
+	}
+	if upstreamBody["system"] != "Answer in JSON." {
+		t.Fatalf("expected system prompt to be forwarded, got %v", upstreamBody["system"])
+	}
+
+	var resp converter.OpenAIResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(resp.Choices) != 1 || resp.Choices[0].Message.Content != `{"city":"Paris"}` || resp.Choices[0].FinishReason != "stop" {
+		t.Fatalf("unexpected choices: %+v", resp.Choices)
+	}
+	if len(resp.Choices[0].Message.ToolCalls) != 0 {
+		t.Fatalf("structured output tool call should not be exposed: %+v", resp.Choices[0].Message.ToolCalls)
+	}
+	if resp.Usage.PromptTokens != 12 || resp.Usage.CompletionTokens != 8 {
+		t.Fatalf("unexpected usage: %+v", resp.Usage)
+	}
+
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest("POST", "/v1/chat/completions", strings.NewReader(fmt.Sprintf(body, `,"stream":true`))))
+	if w.Code != http.StatusOK || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/event-stream") {
+		t.Fatalf("unexpected stream response: %d %s", w.Code, w.Header().Get("Content-Type"))
+	}
+
+	var content strings.Builder
+	var finishReason string
+	parser := converter.NewSSEParser(w.Body)
+	done := false
+	for {
+		data, err := parser.ParseEvent()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("failed to parse stream: %v", err)
+		}
+		if data == "[DONE]" {
+			done = true
+			continue
+		}
+		var chunk converter.OpenAIStreamChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			t.Fatalf("invalid chunk %q: %v", data, err)
+		}
+		if len(chunk.Choices[0].Delta.ToolCalls) > 0 {
+			t.Fatalf("structured output tool call should not be streamed: %s", data)
+		}
+		content.WriteString(chunk.Choices[0].Delta.Content)
+		if chunk.Choices[0].FinishReason != nil {
+			finishReason = *chunk.Choices[0].FinishReason
+		}
+	}
+	if content.String() != `{"city":"Paris"}` || finishReason != "stop" || !done {
+		t.Fatalf("unexpected stream: content=%q finish=%q done=%v", content.String(), finishReason, done)
+	}
+}
@@ -0,0 +1,292 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/Mieluoxxx/Siriusx-API/internal/converter"
+	"github.com/Mieluoxxx/Siriusx-API/internal/models"
+	"github.com/Mieluoxxx/Siriusx-API/internal/provider"
+	"github.com/gin-gonic/gin"
+)
+
+// forwardClaudeViaOllama 将 Claude Messages 请求转换为 Ollama /api/chat 请求再转发
+func (h *ProxyHandler) forwardClaudeViaOllama(c *gin.Context, prov *models.Provider, targetModel string, req map[string]interface{}) {
+	payloadBytes, err := json.Marshal(req)
+	if err != nil {
+		log.Printf("❌ [转换失败] 无法序列化 Claude 请求: %v", err)
+		h.respondClaudeError(c, http.StatusInternalServerError, "api_error", "生成上游请求失败")
+		return
+	}
+
+	var claudeReq converter.ClaudeRequest
+	if err := json.Unmarshal(payloadBytes, &claudeReq); err != nil {
+		log.Printf("❌ [解析失败] Claude 请求无法解析: %v", err)
+		h.respondClaudeError(c, http.StatusBadRequest, "invalid_request_error", "请求格式不符合 Claude Messages 规范")
+		return
+	}
+	claudeReq.Model = targetModel
+
+	ollamaReq, err := converter.ConvertClaudeToOllama(&claudeReq)
+	if err != nil {
+		log.Printf("❌ [转换失败] Claude→Ollama: %v", err)
+		h.respondClaudeError(c, http.StatusBadRequest, "invalid_request_error", fmt.Sprintf("Claude 请求转换 Ollama 格式失败: %v", err))
+		return
+	}
+
+	resp, status, err := h.sendOllamaRequest(c, prov, ollamaReq)
+	if err != nil {
+		h.respondClaudeError(c, status, "api_error", err.Error())
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		message := readOllamaError(resp)
+		h.respondClaudeError(c, resp.StatusCode, claudeErrorTypeForStatus(resp.StatusCode), message)
+		return
+	}
+
+	if claudeReq.Stream {
+		convertedReader, err := converter.ConvertOllamaStreamToClaude(c.Request.Context(), resp.Body, targetModel)
+		if err != nil {
+			log.Printf("❌ [流式转换失败] Provider: %s, 错误: %v", prov.Name, err)
+			h.respondClaudeError(c, http.StatusBadGateway, "api_error", "上游流式响应转换失败")
+			return
+		}
+
+		c.Header("Content-Type", "text/event-stream; charset=utf-8")
+		c.Header("Cache-Control", "no-cache")
+		c.Status(http.StatusOK)
+
+		totalBytes, err := pipeStream(c, convertedReader)
+		if err != nil {
+			log.Printf("❌ [流式转发] Ollama: %v", err)
+			return
+		}
+		log.Printf("✅ [完成] Ollama→Claude 流式响应转换完成，共 %d bytes", totalBytes)
+		return
+	}
+
+	ollamaResp, err := readOllamaResponse(resp)
+	if err != nil {
+		log.Printf("❌ [解析失败] Ollama 响应: %v", err)
+		h.respondClaudeError(c, http.StatusBadGateway, "api_error", "解析上游响应失败")
+		return
+	}
+
+	claudeResp, err := converter.ConvertOllamaToClaude(ollamaResp, targetModel)
+	if err != nil {
+		log.Printf("❌ [转换失败] Ollama→Claude: %v", err)
+		h.respondClaudeError(c, http.StatusBadGateway, "api_error", "上游响应转换 Claude 格式失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, claudeResp)
+	log.Printf("✅ [完成] Ollama→Claude 非流式响应转换成功，stop_reason: %s", claudeResp.StopReason)
+}
+
+// forwardOpenAIViaOllama 将 OpenAI Chat Completions 请求转换为 Ollama /api/chat 请求再转发
+func (h *ProxyHandler) forwardOpenAIViaOllama(c *gin.Context, prov *models.Provider, targetModel string, req map[string]interface{}) {
+	payloadBytes, err := json.Marshal(req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "序列化请求失败"})
+		return
+	}
+
+	var openaiReq converter.OpenAIRequest
+	if err := json.Unmarshal(payloadBytes, &openaiReq); err != nil {
+		log.Printf("❌ [解析失败] OpenAI 请求无法解析: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求格式不符合 OpenAI Chat Completions 规范"})
+		return
+	}
+	openaiReq.Model = targetModel
+
+	ollamaReq, err := converter.ConvertOpenAIToOllama(&openaiReq)
+	if err != nil {
+		log.Printf("❌ [转换失败] OpenAI→Ollama: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("OpenAI 请求转换 Ollama 格式失败: %v", err)})
+		return
+	}
+
+	resp, status, err := h.sendOllamaRequest(c, prov, ollamaReq)
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		c.JSON(resp.StatusCode, gin.H{
+			"error": gin.H{
+				"message": readOllamaError(resp),
+				"type":    "upstream_error",
+			},
+		})
+		return
+	}
+
+	if openaiReq.Stream {
+		convertedReader, err := converter.ConvertOllamaStreamToOpenAI(c.Request.Context(), resp.Body, targetModel)
+		if err != nil {
+			log.Printf("❌ [流式转换失败] Provider: %s, 错误: %v", prov.Name, err)
+			c.JSON(http.StatusBadGateway, gin.H{"error": "上游流式响应转换失败"})
+			return
+		}
+
+		c.Header("Content-Type", "text/event-stream; charset=utf-8")
+		c.Header("Cache-Control", "no-cache")
+		c.Status(http.StatusOK)
+
+		totalBytes, err := pipeStream(c, convertedReader)
+		if err != nil {
+			log.Printf("❌ [流式转发] Ollama: %v", err)
+			return
+		}
+		log.Printf("✅ [完成] Ollama→OpenAI 流式响应转换完成，共 %d bytes", totalBytes)
+		return
+	}
+
+	ollamaResp, err := readOllamaResponse(resp)
+	if err != nil {
+		log.Printf("❌ [解析失败] Ollama 响应: %v", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "解析上游响应失败"})
+		return
+	}
+
+	openaiResp, err := converter.ConvertOllamaToOpenAI(ollamaResp, targetModel)
+	if err != nil {
+		log.Printf("❌ [转换失败] Ollama→OpenAI: %v", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "上游响应转换 OpenAI 格式失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, openaiResp)
+	log.Printf("✅ [完成] Ollama→OpenAI 非流式响应转换成功，Tokens: prompt=%d + completion=%d = %d",
+		openaiResp.Usage.PromptTokens, openaiResp.Usage.CompletionTokens, openaiResp.Usage.TotalTokens)
+}
+
+// sendOllamaRequest 发送 /api/chat 请求（支持供应商自定义 chat 路径）
+// 返回的错误为网关侧错误，附带应返回给客户端的状态码；上游错误响应由调用方按客户端格式转换
+func (h *ProxyHandler) sendOllamaRequest(c *gin.Context, prov *models.Provider, ollamaReq *converter.OllamaChatRequest) (*http.Response, int, error) {
+	body, err := json.Marshal(ollamaReq)
+	if err != nil {
+		log.Printf("❌ [序列化失败] Ollama 请求: %v", err)
+		return nil, http.StatusInternalServerError, fmt.Errorf("生成上游请求失败")
+	}
+
+	targetURL := provider.EndpointURL(prov, provider.EndpointChat, h.templateVars(c, prov, ollamaReq.Model))
+	log.Printf("➡️  [转发] Ollama 目标URL: %s, 请求体大小: %d bytes", targetURL, len(body))
+	return h.sendUpstreamJSON(c, prov, targetURL, ollamaReq.Model, body)
+}
+
+// sendUpstreamJSON 以供应商的认证、请求头策略与连接池发送 JSON POST 请求
+// 返回的错误为网关侧错误，附带应返回给客户端的状态码
+func (h *ProxyHandler) sendUpstreamJSON(c *gin.Context, prov *models.Provider, targetURL, targetModel string, body []byte) (*http.Response, int, error) {
+	return h.sendUpstreamJSONWithHeaders(c, prov, targetURL, targetModel, body, nil)
+}
+
+// sendUpstreamJSONWithHeaders 同 sendUpstreamJSON，额外设置 headers（在转发策略与自定义请求头之前应用，可被其覆盖）
+func (h *ProxyHandler) sendUpstreamJSONWithHeaders(c *gin.Context, prov *models.Provider, targetURL, targetModel string, body []byte, headers http.Header) (*http.Response, int, error) {
+	proxyReq, err := http.NewRequestWithContext(c.Request.Context(), "POST", targetURL, bytes.NewReader(body))
+	if err != nil {
+		log.Printf("❌ [转发失败] 创建请求失败: %v", err)
+		return nil, http.StatusInternalServerError, fmt.Errorf("创建代理请求失败")
+	}
+
+	proxyReq.Header.Set("Content-Type", "application/json")
+	provider.ApplyAuth(proxyReq.Header, prov)
+	for key, values := range headers {
+		proxyReq.Header[key] = values
+	}
+
+	if err := h.copyForwardHeaders(c, prov, proxyReq.Header); err != nil {
+		log.Printf("❌ [转发失败] Provider: %s, 请求头转发策略无效: %v", prov.Name, err)
+		return nil, http.StatusInternalServerError, fmt.Errorf("供应商请求头转发配置无效")
+	}
+	if err := provider.ApplyCustomHeaders(proxyReq.Header, prov, h.templateVars(c, prov, targetModel)); err != nil {
+		log.Printf("❌ [转发失败] Provider: %s, 自定义请求头无效: %v", prov.Name, err)
+		return nil, http.StatusInternalServerError, fmt.Errorf("供应商自定义请求头配置无效")
+	}
+	if err := provider.AuthorizeRequest(c.Request.Context(), proxyReq, prov, body); err != nil {
+		log.Printf("❌ [转发失败] Provider: %s, 认证失败: %v", prov.Name, err)
+		return nil, http.StatusBadGateway, fmt.Errorf("供应商认证失败: %v", err)
+	}
+
+	client, err := h.httpClient(prov)
+	if err != nil {
+		log.Printf("❌ [转发失败] Provider: %s, 连接配置无效: %v", prov.Name, err)
+		return nil, http.StatusInternalServerError, fmt.Errorf("供应商连接配置无效: %v", err)
+	}
+
+	resp, err := client.Do(proxyReq)
+	if err != nil {
+		log.Printf("❌ [转发失败] Provider: %s, 错误: %v", prov.Name, err)
+		return nil, http.StatusBadGateway, fmt.Errorf("请求供应商失败: %v", err)
+	}
+	return resp, http.StatusOK, nil
+}
+
+// readOllamaResponse 读取并解析 Ollama 非流式响应
+func readOllamaResponse(resp *http.Response) (*converter.OllamaChatResponse, error) {
+	rawBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	body, _, err := decompressIfNeeded(rawBody, resp.Header)
+	if err != nil {
+		return nil, err
+	}
+
+	var ollamaResp converter.OllamaChatResponse
+	if err := json.Unmarshal(body, &ollamaResp); err != nil {
+		return nil, err
+	}
+	if ollamaResp.Error != "" {
+		return nil, fmt.Errorf("上游返回错误: %s", ollamaResp.Error)
+	}
+	return &ollamaResp, nil
+}
+
+// readOllamaError 读取 Ollama 错误响应（{"error": "..."}），无法解析时返回原始内容
+func readOllamaError(resp *http.Response) string {
+	respBody, _ := io.ReadAll(resp.Body)
+
+	var ollamaErr struct {
+		Error string `json:"error"`
+	}
+	message := strings.TrimSpace(string(respBody))
+	if err := json.Unmarshal(respBody, &ollamaErr); err == nil && ollamaErr.Error != "" {
+		message = ollamaErr.Error
+	}
+	if message == "" {
+		message = "上游返回错误响应"
+	}
+	log.Printf("❌ [Ollama 错误响应] 状态: %d, 内容: %s", resp.StatusCode, message)
+	return message
+}
+
+// claudeErrorTypeForStatus 按 HTTP 状态码推断 Claude 错误类型（上游错误响应不含错误类型时使用）
+func claudeErrorTypeForStatus(statusCode int) string {
+	switch statusCode {
+	case http.StatusUnauthorized:
+		return "authentication_error"
+	case http.StatusForbidden:
+		return "permission_error"
+	case http.StatusNotFound:
+		return "not_found_error"
+	case http.StatusTooManyRequests:
+		return "rate_limit_error"
+	case http.StatusServiceUnavailable:
+		return "overloaded_error"
+	}
+	if statusCode >= 500 {
+		return "api_error"
+	}
+	return "invalid_request_error"
+}
@@ -0,0 +1,250 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Mieluoxxx/Siriusx-API/internal/converter"
+	"github.com/Mieluoxxx/Siriusx-API/internal/models"
+	"github.com/Mieluoxxx/Siriusx-API/internal/provider"
+	"github.com/gin-gonic/gin"
+)
+
+// newOllamaHandlerStandIn 模拟 Ollama /api/chat：stream=true 时逐行输出 NDJSON，否则返回单个 JSON
+func newOllamaHandlerStandIn(t *testing.T, captured *converter.OllamaChatRequest) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		*captured = converter.OllamaChatRequest{}
+		_ = json.Unmarshal(body, captured)
+
+		if captured.Model == "missing" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":"model \"missing\" not found, try pulling it first"}`))
+			return
+		}
+
+		if captured.Stream {
+			w.Header().Set("Content-Type", "application/x-ndjson")
+			for _, line := range []string{
+				`{"model":"llama3.2","message":{"role":"assistant","content":"po"},"done":false}`,
+				`{"model":"llama3.2","message":{"role":"assistant","content":"ng"},"done":false}`,
+				`{"model":"llama3.2","message":{"role":"assistant","content":""},"done":true,"done_reason":"stop","prompt_eval_count":9,"eval_count":2}`,
+			} {
+				_, _ = w.Write([]byte(line + "\n"))
+				w.(http.Flusher).Flush()
+			}
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"model":"llama3.2","message":{"role":"assistant","content":"","tool_calls":[{"function":{"name":"get_weather","arguments":{"city":"Paris"}}}]},"done":true,"done_reason":"stop","prompt_eval_count":9,"eval_count":3}`))
+	}))
+}
+
+func newOllamaTestProvider(baseURL string) *models.Provider {
+	return &models.Provider{
+		Name:    "ollama",
+		Type:    provider.ProviderTypeOllama,
+		BaseURL: baseURL,
+		APIKey:  "ollama",
+	}
+}
+
+func TestForwardClaudeViaOllama(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var captured converter.OllamaChatRequest
+	server := newOllamaHandlerStandIn(t, &captured)
+	defer server.Close()
+	prov := newOllamaTestProvider(server.URL)
+
+	t.Run("non-stream tool call with image", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("POST", "/v1/messages", nil)
+
+		req := newBedrockTestRequest(false)
+		req["system"] = "Be brief."
+		req["messages"] = []interface{}{map[string]interface{}{
+			"role": "user",
+			"content": []interface{}{
+				map[string]interface{}{"type": "text", "text": "What is this?"},
+				map[string]interface{}{"type": "image", "source": map[string]interface{}{"type": "base64", "media_type": "image/png", "data": "iVBORw0KGgo="}},
+			},
+		}}
+
+		handler := &ProxyHandler{}
+		handler.forwardClaudeViaOllama(c, prov, "llama3.2", req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("unexpected status code: %d, body: %s", w.Code, w.Body.String())
+		}
+		if captured.Model != "llama3.2" || captured.Stream || len(captured.Tools) != 1 || captured.Options.NumPredict != 16 {
+			t.Fatalf("unexpected upstream request: %+v", captured)
+		}
+		if len(captured.Messages) != 2 || captured.Messages[0].Role != "system" || captured.Messages[1].Images[0] != "iVBORw0KGgo=" {
+			t.Fatalf("expected system message and inline image upstream, got %+v", captured.Messages)
+		}
+
+		var claudeResp converter.ClaudeResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &claudeResp); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if claudeResp.StopReason != "tool_use" || claudeResp.Content[0].Type != "tool_use" || claudeResp.Content[0].Input["city"] != "Paris" {
+			t.Fatalf("unexpected Claude response: %s", w.Body.String())
+		}
+		if claudeResp.Usage.InputTokens != 9 || claudeResp.Usage.OutputTokens != 3 {
+			t.Fatalf("unexpected usage: %+v", claudeResp.Usage)
+		}
+	})
+
+	t.Run("stream", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("POST", "/v1/messages", nil)
+
+		handler := &ProxyHandler{}
+		handler.forwardClaudeViaOllama(c, prov, "llama3.2", newBedrockTestRequest(true))
+
+		body := w.Body.String()
+		if !captured.Stream || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/event-stream") {
+			t.Fatalf("expected streaming upstream and SSE response, got %s", w.Header().Get("Content-Type"))
+		}
+		for _, expected := range []string{`"text":"po"`, `"text":"ng"`, `"stop_reason":"end_turn"`, `"input_tokens":9`, "event: message_stop\n"} {
+			if !strings.Contains(body, expected) {
+				t.Fatalf("expected SSE output to contain %q, got:\n%s", expected, body)
+			}
+		}
+	})
+
+	t.Run("upstream error", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("POST", "/v1/messages", nil)
+
+		handler := &ProxyHandler{}
+		handler.forwardClaudeViaOllama(c, prov, "missing", newBedrockTestRequest(false))
+
+		if w.Code != http.StatusNotFound || !strings.Contains(w.Body.String(), `"type":"not_found_error"`) || !strings.Contains(w.Body.String(), "try pulling it first") {
+			t.Fatalf("unexpected error response: %d %s", w.Code, w.Body.String())
+		}
+	})
+
+}
+
+func TestForwardOpenAIViaOllama(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var captured converter.OllamaChatRequest
+	server := newOllamaHandlerStandIn(t, &captured)
+	defer server.Close()
+	prov := newOllamaTestProvider(server.URL)
+
+	newRequest := func(stream bool) map[string]interface{} {
+		var req map[string]interface{}
+		_ = json.Unmarshal([]byte(`{
+			"model": "local",
+			"max_tokens": 32,
+			"messages": [
+				{"role": "system", "content": "Be brief."},
+				{"role": "user", "content": "weather?"},
+				{"role": "assistant", "content": null, "tool_calls": [{"id": "call_1", "type": "function", "function": {"name": "get_weather", "arguments": "{\"city\":\"Paris\"}"}}]},
+				{"role": "tool", "tool_call_id": "call_1", "content": "sunny"}
+			],
+			"tools": [{"type": "function", "function": {"name": "get_weather", "parameters": {"type": "object"}}}]
+		}`), &req)
+		req["stream"] = stream
+		return req
+	}
+
+	t.Run("non-stream", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("POST", "/v1/chat/completions", nil)
+
+		handler := &ProxyHandler{}
+		handler.forwardOpenAIViaOllama(c, prov, "llama3.2", newRequest(false))
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("unexpected status code: %d, body: %s", w.Code, w.Body.String())
+		}
+		if captured.Model != "llama3.2" || len(captured.Messages) != 4 {
+			t.Fatalf("unexpected upstream request: %+v", captured)
+		}
+		if tool := captured.Messages[3]; tool.Role != "tool" || tool.ToolName != "get_weather" || tool.Content != "sunny" {
+			t.Fatalf("expected tool result with function name upstream, got %+v", tool)
+		}
+		if call := captured.Messages[2].ToolCalls; len(call) != 1 || call[0].Function.Arguments["city"] != "Paris" {
+			t.Fatalf("expected tool call arguments as object upstream, got %+v", captured.Messages[2])
+		}
+
+		var openaiResp converter.OpenAIResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &openaiResp); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		choice := openaiResp.Choices[0]
+		if choice.FinishReason != "tool_calls" || len(choice.Message.ToolCalls) != 1 || choice.Message.ToolCalls[0].Function.Arguments != `{"city":"Paris"}` {
+			t.Fatalf("unexpected OpenAI response: %s", w.Body.String())
+		}
+		if openaiResp.Model != "llama3.2" || openaiResp.Usage.TotalTokens != 12 {
+			t.Fatalf("unexpected OpenAI response metadata: %+v", openaiResp)
+		}
+	})
+
+	t.Run("stream", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("POST", "/v1/chat/completions", nil)
+
+		handler := &ProxyHandler{}
+		handler.forwardOpenAIViaOllama(c, prov, "llama3.2", newRequest(true))
+
+		body := w.Body.String()
+		for _, expected := range []string{`"content":"po"`, `"content":"ng"`, `"finish_reason":"stop"`, `"total_tokens":11`, "data: [DONE]\n\n"} {
+			if !strings.Contains(body, expected) {
+				t.Fatalf("expected stream to contain %q, got:\n%s", expected, body)
+			}
+		}
+	})
+
+	t.Run("upstream error", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("POST", "/v1/chat/completions", nil)
+
+		handler := &ProxyHandler{}
+		handler.forwardOpenAIViaOllama(c, prov, "missing", newRequest(false))
+
+		if w.Code != http.StatusNotFound || !strings.Contains(w.Body.String(), "try pulling it first") {
+			t.Fatalf("unexpected error response: %d %s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("remote image rejected", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("POST", "/v1/chat/completions", nil)
+
+		handler := &ProxyHandler{}
+		handler.forwardOpenAIViaOllama(c, prov, "llama3.2", map[string]interface{}{
+			"model": "llama3.2",
+			"messages": []interface{}{map[string]interface{}{
+				"role":    "user",
+				"content": []interface{}{map[string]interface{}{"type": "image_url", "image_url": map[string]interface{}{"url": "https://example.com/cat.png"}}},
+			}},
+		})
+
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("expected 400 for remote image, got %d %s", w.Code, w.Body.String())
+		}
+	})
+}
@@ -0,0 +1,381 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/Mieluoxxx/Siriusx-API/internal/converter"
+	"github.com/Mieluoxxx/Siriusx-API/internal/mapping"
+	"github.com/Mieluoxxx/Siriusx-API/internal/models"
+	"github.com/Mieluoxxx/Siriusx-API/internal/provider"
+	"github.com/Mieluoxxx/Siriusx-API/internal/responses"
+	"github.com/gin-gonic/gin"
+)
+
+// responsesTurn 本轮对话信息，响应成功后写入本地历史
+type responsesTurn struct {
+	tokenID            uint
+	model              string
+	previousResponseID string
+	input              []converter.ResponsesInputItem
+	store              bool
+}
+
+// Responses 处理 OpenAI Responses API 请求
+// previous_response_id 由本地历史展开为完整输入；开启 responses_api 的上游直接转发，其他上游先转换为 Claude Messages 请求，
+// 复用 Messages 的转发逻辑（含 Claude→OpenAI Chat 转换），再将响应转换回 Responses 格式
+func (h *ProxyHandler) Responses(c *gin.Context) {
+	payload, bodyBytes, err := parseJSONBody(c)
+	if err != nil {
+		if bodyBytes == nil {
+			respondResponsesError(c, http.StatusBadRequest, "无法读取请求体")
+		} else {
+			respondResponsesError(c, http.StatusBadRequest, "无效的 JSON 格式")
+		}
+		return
+	}
+
+	var req converter.ResponsesRequest
+	if err := json.Unmarshal(bodyBytes, &req); err != nil {
+		respondResponsesError(c, http.StatusBadRequest, fmt.Sprintf("请求格式不符合 Responses API 规范: %v", err))
+		return
+	}
+	if req.Model == "" {
+		respondResponsesError(c, http.StatusBadRequest, "缺少 model 参数")
+		return
+	}
+
+	input, err := converter.ParseResponsesInput(req.Input)
+	if err != nil {
+		respondResponsesError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	turn := &responsesTurn{
+		tokenID:            contextTokenID(c),
+		model:              req.Model,
+		previousResponseID: req.PreviousResponseID,
+		input:              input,
+		store:              h.responseStore != nil && (req.Store == nil || *req.Store),
+	}
+
+	log.Printf("📥 [Responses] 收到请求 - 模型: %s, 流式: %v, previous_response_id: %s, IP: %s",
+		req.Model, req.Stream, req.PreviousResponseID, c.ClientIP())
+
+	var history []converter.ResponsesInputItem
+	if req.PreviousResponseID != "" {
+		if history, err = h.loadResponsesHistory(req.PreviousResponseID, turn.tokenID); err != nil {
+			h.handleResponsesHistoryError(c, req.PreviousResponseID, err)
+			return
+		}
+	}
+
+	selectedMapping, err := h.resolveMapping(c.Request.Context(), req.Model)
+	if err != nil {
+		if h.handleResponsesRouterError(c, err) {
+			return
+		}
+		respondResponsesError(c, http.StatusInternalServerError, "解析模型映射失败")
+		return
+	}
+
+	prov, err := h.providerService.GetProvider(selectedMapping.ProviderID)
+	if err != nil {
+		respondResponsesError(c, http.StatusInternalServerError, "获取供应商信息失败")
+		return
+	}
+
+	providerName := prov.Name
+	if selectedMapping.Provider != nil && selectedMapping.Provider.Name != "" {
+		providerName = selectedMapping.Provider.Name
+	}
+
+	items := append(history, input...)
+
+	if provider.SupportsResponsesAPI(prov) {
+		log.Printf("🔀 [Responses] 映射选择 - 统一模型: %s -> 供应商: %s (Responses), 目标模型: %s",
+			req.Model, providerName, selectedMapping.TargetModel)
+		h.forwardResponsesNative(c, prov, selectedMapping.TargetModel, payload, items, turn)
+		return
+	}
+
+	claudeReq, err := converter.ConvertResponsesToClaude(&req, items)
+	if err != nil {
+		log.Printf("❌ [转换失败] Responses→Claude: %v", err)
+		respondResponsesError(c, http.StatusBadRequest, fmt.Sprintf("Responses 请求转换失败: %v", err))
+		return
+	}
+	claudeReq.Model = selectedMapping.TargetModel
+	claudeReq.Stream = req.Stream
+
+	structured, err := converter.EmulateResponseFormat(claudeReq, converter.ResponseFormatFromResponses(req.Text))
+	if err != nil {
+		respondResponsesError(c, http.StatusBadRequest, fmt.Sprintf("Responses 请求转换失败: %v", err))
+		return
+	}
+
+	emulation := emulateClaudeTools(selectedMapping, claudeReq)
+
+	log.Printf("🔁 [Responses] 执行 Responses→Claude 转换 [Provider: %s, Target: %s]", providerName, selectedMapping.TargetModel)
+
+	translator := newResponsesTranslator(req.PreviousResponseID, req.Metadata)
+	writer := newClaudeResponseWriter(c.Writer, req.Stream, withToolEmulation(withStructuredOutput(translator, structured), emulation))
+	c.Writer = writer
+	h.dispatchConvertedClaudeRequest(c, writer, prov, providerName, req.Model, selectedMapping, claudeReq, structured, emulation)
+	c.Writer = writer.ResponseWriter
+	writer.finish()
+
+	h.saveResponsesTurn(turn, translator.final)
+}
+
+// forwardResponsesNative 将 Responses 请求转发到原生支持的上游
+// previous_response_id 已在本地展开为完整输入，转发时移除，避免上游查找其他供应商生成的响应
+func (h *ProxyHandler) forwardResponsesNative(c *gin.Context, prov *models.Provider, targetModel string, payload map[string]interface{}, items []converter.ResponsesInputItem, turn *responsesTurn) {
+	payload["model"] = targetModel
+	if turn.previousResponseID != "" {
+		delete(payload, "previous_response_id")
+		payload["input"] = items
+	}
+
+	recorder := &responsesRecorder{ResponseWriter: c.Writer}
+	c.Writer = recorder
+	h.forwardRequest(c, prov, payload, provider.EndpointResponses)
+	c.Writer = recorder.ResponseWriter
+
+	if recorder.Status() >= 400 {
+		return
+	}
+	resp, err := recorder.response()
+	if err != nil {
+		log.Printf("❌ [Responses] 解析上游响应失败，本轮不写入历史: %v", err)
+		return
+	}
+	h.saveResponsesTurn(turn, resp)
+}
+
+// loadResponsesHistory 加载 previous_response_id 对应的历史输入项
+func (h *ProxyHandler) loadResponsesHistory(previousResponseID string, tokenID uint) ([]converter.ResponsesInputItem, error) {
+	if h.responseStore == nil {
+		return nil, responses.ErrResponseNotFound
+	}
+	return h.responseStore.History(previousResponseID, tokenID)
+}
+
+// saveResponsesTurn 将成功完成的一轮对话写入本地历史（store=false 时跳过）
+func (h *ProxyHandler) saveResponsesTurn(turn *responsesTurn, resp *converter.ResponsesResponse) {
+	if !turn.store || resp == nil || resp.ID == "" || resp.Status == converter.ResponsesStatusFailed {
+		return
+	}
+	if err := h.responseStore.Save(resp.ID, turn.tokenID, turn.model, turn.previousResponseID, turn.input, resp.Output); err != nil {
+		log.Printf("❌ [Responses] 保存响应 %s 失败: %v", resp.ID, err)
+		return
+	}
+	log.Printf("💾 [Responses] 已保存响应 %s（输出项 %d 个）", resp.ID, len(resp.Output))
+}
+
+// handleResponsesHistoryError 将历史加载错误映射为 OpenAI 风格的响应
+func (h *ProxyHandler) handleResponsesHistoryError(c *gin.Context, previousResponseID string, err error) {
+	switch {
+	case errors.Is(err, responses.ErrResponseNotFound):
+		c.JSON(http.StatusNotFound, responsesErrorBody(http.StatusNotFound,
+			fmt.Sprintf("Previous response with id '%s' not found.", previousResponseID), "previous_response_not_found"))
+	case errors.Is(err, responses.ErrHistoryTooDeep):
+		respondResponsesError(c, http.StatusBadRequest, fmt.Sprintf("对话历史超过 %d 轮", responses.MaxHistoryDepth))
+	default:
+		log.Printf("❌ [Responses] 加载历史失败: %v", err)
+		respondResponsesError(c, http.StatusInternalServerError, "加载对话历史失败")
+	}
+}
+
+// handleResponsesRouterError 将路由错误映射为 OpenAI 风格的响应
+func (h *ProxyHandler) handleResponsesRouterError(c *gin.Context, err error) bool {
+	var routerErr *mapping.RouterError
+	if !errors.As(err, &routerErr) {
+		return false
+	}
+
+	switch routerErr.Code {
+	case mapping.ErrRouterModelNotFound.Code:
+		respondResponsesError(c, http.StatusNotFound, routerErr.Message)
+	case mapping.ErrRouterNoAvailableProviders.Code:
+		respondResponsesError(c, http.StatusServiceUnavailable, routerErr.Message)
+	default:
+		respondResponsesError(c, http.StatusInternalServerError, routerErr.Message)
+	}
+
+	return true
+}
+
+// respondResponsesError 返回 OpenAI API 格式的错误响应
+func respondResponsesError(c *gin.Context, status int, message string) {
+	c.JSON(status, responsesErrorBody(status, message, ""))
+}
+
+// responsesErrorBody 构建 OpenAI API 错误响应体 {"error":{"message","type","param","code"}}
+func responsesErrorBody(status int, message, code string) gin.H {
+	errorType := "server_error"
+	if status < 500 {
+		errorType = "invalid_request_error"
+	}
+
+	var codeValue interface{}
+	if code != "" {
+		codeValue = code
+	}
+	return gin.H{
+		"error": gin.H{
+			"message": message,
+			"type":    errorType,
+			"param":   nil,
+			"code":    codeValue,
+		},
+	}
+}
+
+// contextTokenID 获取认证中间件写入的 Token ID
+func contextTokenID(c *gin.Context) uint {
+	if value, exists := c.Get("token_id"); exists {
+		if id, ok := value.(uint); ok {
+			return id
+		}
+	}
+	return 0
+}
+
+// claudeRequestPayload 将 Claude 请求转换为 map 形式（与 Messages 入口一致）
+func claudeRequestPayload(req *converter.ClaudeRequest) (map[string]interface{}, error) {
+	payloadBytes, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	var payload map[string]interface{}
+	if err := json.Unmarshal(payloadBytes, &payload); err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
+
+// responsesTranslator 将 Claude 响应转换为 Responses API 格式，并记录最终响应用于写入历史
+type responsesTranslator struct {
+	converter          *converter.ClaudeResponsesStreamConverter
+	previousResponseID string
+	metadata           map[string]interface{}
+	final              *converter.ResponsesResponse
+}
+
+// newResponsesTranslator 创建 Responses 响应转换器
+func newResponsesTranslator(previousResponseID string, metadata map[string]interface{}) *responsesTranslator {
+	return &responsesTranslator{
+		converter:          converter.NewClaudeResponsesStreamConverter(previousResponseID, metadata),
+		previousResponseID: previousResponseID,
+		metadata:           metadata,
+	}
+}
+
+// label 日志中使用的协议名
+func (t *responsesTranslator) label() string {
+	return "Responses"
+}
+
+// streamEvent 将 Claude 事件转换为 Responses 流式事件（event: xxx\ndata: {...}\n\n）
+func (t *responsesTranslator) streamEvent(data []byte) ([]byte, error) {
+	events, err := t.converter.ProcessEvent(data)
+	if err != nil {
+		return nil, err
+	}
+	if t.converter.Finished() {
+		t.final = t.converter.Response()
+	}
+	return formatResponsesEvents(events)
+}
+
+// streamError 流式转换失败时写出 response.failed 事件
+func (t *responsesTranslator) streamError(err error) []byte {
+	out, _ := formatResponsesEvents([]converter.ResponsesStreamEvent{t.converter.Failed(err.Error())})
+	return out
+}
+
+// response 转换非流式响应
+func (t *responsesTranslator) response(resp *converter.ClaudeResponse) (interface{}, error) {
+	result, err := converter.ConvertClaudeResponseToResponses(resp)
+	if err != nil {
+		return nil, err
+	}
+	if t.previousResponseID != "" {
+		result.PreviousResponseID = converter.StringPtr(t.previousResponseID)
+	}
+	result.Metadata = t.metadata
+	t.final = result
+	return result, nil
+}
+
+// errorBody 构建 OpenAI API 错误响应体
+func (t *responsesTranslator) errorBody(status int, message string) interface{} {
+	return responsesErrorBody(status, message, "")
+}
+
+// formatResponsesEvents 将 Responses 流式事件格式化为 SSE
+func formatResponsesEvents(events []converter.ResponsesStreamEvent) ([]byte, error) {
+	var out bytes.Buffer
+	for _, event := range events {
+		formatted, err := converter.FormatSSEEvent(event.Type, event)
+		if err != nil {
+			return nil, err
+		}
+		out.WriteString(formatted)
+	}
+	return out.Bytes(), nil
+}
+
+// responsesRecorder 原样写出上游响应，同时记录响应体，用于提取原生 Responses 上游的最终响应
+type responsesRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+// Write 写出并记录响应数据
+func (w *responsesRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+// WriteString 同 Write
+func (w *responsesRecorder) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// response 从记录的响应体中提取最终响应（JSON 响应或 SSE 中的 response.completed / response.incomplete 事件）
+func (w *responsesRecorder) response() (*converter.ResponsesResponse, error) {
+	body := bytes.TrimSpace(w.body.Bytes())
+	if len(body) > 0 && body[0] == '{' {
+		var resp converter.ResponsesResponse
+		if err := json.Unmarshal(body, &resp); err != nil {
+			return nil, err
+		}
+		return &resp, nil
+	}
+
+	parser := converter.NewSSEParser(bytes.NewReader(body))
+	for {
+		event, err := parser.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		var streamEvent converter.ResponsesStreamEvent
+		if err := json.Unmarshal([]byte(event.Data), &streamEvent); err != nil {
+			continue
+		}
+		if (streamEvent.Type == "response.completed" || streamEvent.Type == "response.incomplete") && streamEvent.Response != nil {
+			return streamEvent.Response, nil
+		}
+	}
+	return nil, fmt.Errorf("未找到 response.completed 事件")
+}
@@ -0,0 +1,403 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Mieluoxxx/Siriusx-API/internal/balancer"
+	"github.com/Mieluoxxx/Siriusx-API/internal/converter"
+	"github.com/Mieluoxxx/Siriusx-API/internal/mapping"
+	"github.com/Mieluoxxx/Siriusx-API/internal/models"
+	"github.com/Mieluoxxx/Siriusx-API/internal/provider"
+	"github.com/Mieluoxxx/Siriusx-API/internal/responses"
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// newResponsesTestEngine 创建挂载 /v1/responses 的测试引擎（Token ID 固定为 1），统一模型 my-model 映射到指定供应商的目标模型
+func newResponsesTestEngine(t *testing.T, prov *models.Provider, targetModel string) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	database, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open test database: %v", err)
+	}
+	if err := database.AutoMigrate(&models.Provider{}, &models.StoredResponse{}); err != nil {
+		t.Fatalf("failed to migrate test database: %v", err)
+	}
+	if err := provider.NewRepository(database).Create(prov); err != nil {
+		t.Fatalf("failed to create test provider: %v", err)
+	}
+
+	handler := &ProxyHandler{
+		providerService: provider.NewService(provider.NewRepository(database)),
+		router: &stubModelRouter{
+			modelName: "my-model",
+			resolved:  &mapping.ResolvedMapping{ProviderID: prov.ID, TargetModel: targetModel, Weight: 1, Enabled: true},
+		},
+		balancer:      balancer.NewWeightedRandomBalancer(),
+		responseStore: responses.NewService(responses.NewRepository(database)),
+	}
+
+	engine := gin.New()
+	engine.POST("/v1/responses", func(c *gin.Context) {
+		c.Set("token_id", uint(1))
+	}, handler.Responses)
+	return engine
+}
+
+// postResponses 发送 /v1/responses 请求
+func postResponses(engine *gin.Engine, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest("POST", "/v1/responses", strings.NewReader(body)))
+	return w
+}
+
+// parseResponsesEvents 解析 Responses SSE 响应体
+func parseResponsesEvents(t *testing.T, body string) []converter.ResponsesStreamEvent {
+	t.Helper()
+	var events []converter.ResponsesStreamEvent
+	for _, block := range strings.Split(body, "\n\n") {
+		eventType, data := "", ""
+		for _, line := range strings.Split(block, "\n") {
+			if value, ok := strings.CutPrefix(line, "event: "); ok {
+				eventType = value
+			}
+			if value, ok := strings.CutPrefix(line, "data: "); ok {
+				data = value
+			}
+		}
+		if data == "" {
+			continue
+		}
+		var event converter.ResponsesStreamEvent
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			t.Fatalf("failed to decode event %q: %v", data, err)
+		}
+		if event.Type != eventType {
+			t.Fatalf("event name %q does not match type %q", eventType, event.Type)
+		}
+		events = append(events, event)
+	}
+	return events
+}
+
+func TestResponses_ViaOpenAI(t *testing.T) {
+	var captured converter.OpenAIRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		captured = converter.OpenAIRequest{}
+		body, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(body, &captured)
+
+		if captured.Stream {
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = w.Write([]byte(`data: {"id":"c1","object":"chat.completion.chunk","model":"gpt-4o","choices":[{"index":0,"delta":{"role":"assistant","content":"Hel"},"finish_reason":null}]}` + "\n\n"))
+			_, _ = w.Write([]byte(`data: {"id":"c1","object":"chat.completion.chunk","model":"gpt-4o","choices":[{"index":0,"delta":{"content":"lo"},"finish_reason":"stop"}]}` + "\n\n"))
+			_, _ = w.Write([]byte("data: [DONE]\n\n"))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if len(captured.Tools) > 0 {
+			_, _ = w.Write([]byte(`{"id":"c2","object":"chat.completion","model":"gpt-4o","choices":[{"index":0,"message":{"role":"assistant","content":null,"tool_calls":[{"id":"call_1","type":"function","function":{"name":"get_weather","arguments":"{\"city\":\"Paris\"}"}}]},"finish_reason":"tool_calls"}],"usage":{"prompt_tokens":20,"completion_tokens":6,"total_tokens":26}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"id":"c3","object":"chat.completion","model":"gpt-4o","choices":[{"index":0,"message":{"role":"assistant","content":"It is sunny."},"finish_reason":"length"}],"usage":{"prompt_tokens":30,"completion_tokens":4,"total_tokens":34}}`))
+	}))
+	defer server.Close()
+
+	engine := newResponsesTestEngine(t, &models.Provider{Name: "openai", BaseURL: server.URL, APIKey: "sk-test", Enabled: true}, "gpt-4o")
+
+	// 第一轮：工具调用
+	w := postResponses(engine, `{
+		"model": "my-model",
+		"instructions": "Be brief.",
+		"input": "weather in Paris?",
+		"tools": [{"type": "function", "name": "get_weather", "parameters": {"type": "object", "properties": {"city": {"type": "string"}}}}, {"type": "web_search"}],
+		"tool_choice": "required",
+		"max_output_tokens": 256
+	}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status code: %d, body: %s", w.Code, w.Body.String())
+	}
+	if captured.Model != "gpt-4o" || captured.MaxTokens != 256 || len(captured.Tools) != 1 || captured.ToolChoice != "required" {
+		t.Fatalf("unexpected upstream request: %+v", captured)
+	}
+	if len(captured.Messages) != 2 || captured.Messages[0].Role != "system" || captured.Messages[0].Content != "Be brief." {
+		t.Fatalf("unexpected upstream messages: %+v", captured.Messages)
+	}
+
+	var first converter.ResponsesResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &first); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if !strings.HasPrefix(first.ID, "resp_") || first.Object != "response" || first.Status != "completed" || len(first.Output) != 1 {
+		t.Fatalf("unexpected response: %s", w.Body.String())
+	}
+	call := first.Output[0]
+	if call.Type != "function_call" || call.Name != "get_weather" || call.Arguments != `{"city":"Paris"}` || call.CallID == "" {
+		t.Fatalf("unexpected function_call item: %+v", call)
+	}
+	if first.Usage.TotalTokens != 26 {
+		t.Fatalf("unexpected usage: %+v", first.Usage)
+	}
+
+	// 第二轮：通过 previous_response_id 续接，提交函数输出
+	w = postResponses(engine, `{
+		"model": "my-model",
+		"previous_response_id": "`+first.ID+`",
+		"input": [{"type": "function_call_output", "call_id": "`+call.CallID+`", "output": "sunny"}]
+	}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status code: %d, body: %s", w.Code, w.Body.String())
+	}
+	if len(captured.Messages) != 3 || captured.Messages[1].ToolCalls[0].ID != captured.Messages[2].ToolCallID || captured.Messages[2].Content != "sunny" {
+		t.Fatalf("expected history to be expanded, got %+v", captured.Messages)
+	}
+
+	var second converter.ResponsesResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &second); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if second.PreviousResponseID == nil || *second.PreviousResponseID != first.ID {
+		t.Fatalf("expected previous_response_id %s, got %v", first.ID, second.PreviousResponseID)
+	}
+	if second.Status != "incomplete" || second.IncompleteDetails == nil || second.IncompleteDetails.Reason != "max_output_tokens" {
+		t.Fatalf("expected incomplete response for finish_reason length, got %s", w.Body.String())
+	}
+	if second.Output[0].Content[0].Text != "It is sunny." {
+		t.Fatalf("unexpected output: %+v", second.Output)
+	}
+
+	// 第三轮：流式续接两轮历史
+	w = postResponses(engine, `{"model": "my-model", "stream": true, "previous_response_id": "`+second.ID+`", "input": "thanks"}`)
+	if w.Code != http.StatusOK || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/event-stream") {
+		t.Fatalf("unexpected stream response: %d %s", w.Code, w.Header().Get("Content-Type"))
+	}
+	if len(captured.Messages) != 5 || captured.Messages[4].Content != "thanks" {
+		t.Fatalf("expected full history upstream, got %+v", captured.Messages)
+	}
+
+	events := parseResponsesEvents(t, w.Body.String())
+	if events[0].Type != "response.created" || events[len(events)-1].Type != "response.completed" {
+		t.Fatalf("unexpected event sequence: %s", w.Body.String())
+	}
+	var text strings.Builder
+	for i, event := range events {
+		if event.SequenceNumber != i {
+			t.Fatalf("expected sequence number %d, got %d", i, event.SequenceNumber)
+		}
+		if event.Type == "response.output_text.delta" {
+			text.WriteString(event.Delta)
+		}
+	}
+	final := events[len(events)-1].Response
+	if text.String() != "Hello" || final.Output[0].Content[0].Text != "Hello" || *final.PreviousResponseID != second.ID {
+		t.Fatalf("unexpected streamed response: %s", w.Body.String())
+	}
+
+	// 流式响应同样写入历史
+	w = postResponses(engine, `{"model": "my-model", "previous_response_id": "`+final.ID+`", "input": "bye"}`)
+	if w.Code != http.StatusOK || len(captured.Messages) != 7 {
+		t.Fatalf("expected streamed turn to be stored, got %d messages, body: %s", len(captured.Messages), w.Body.String())
+	}
+}
+
+func TestResponses_Errors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = w.Write([]byte(`{"error":{"message":"Rate limit reached","type":"rate_limit_error"}}`))
+	}))
+	defer server.Close()
+
+	engine := newResponsesTestEngine(t, &models.Provider{Name: "openai", BaseURL: server.URL, APIKey: "sk-test", Enabled: true}, "gpt-4o")
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+		code   string
+	}{
+		{"invalid json", `{`, http.StatusBadRequest, ""},
+		{"missing model", `{"input":"hi"}`, http.StatusBadRequest, ""},
+		{"unknown model", `{"model":"other","input":"hi"}`, http.StatusNotFound, ""},
+		{"unknown previous response", `{"model":"my-model","previous_response_id":"resp_missing","input":"hi"}`, http.StatusNotFound, "previous_response_not_found"},
+		{"url image", `{"model":"my-model","input":[{"role":"user","content":[{"type":"input_image","image_url":"https://example.com/a.png"}]}]}`, http.StatusBadRequest, ""},
+		{"upstream error", `{"model":"my-model","input":"hi"}`, http.StatusTooManyRequests, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := postResponses(engine, tt.body)
+			if w.Code != tt.status {
+				t.Fatalf("expected status %d, got %d, body: %s", tt.status, w.Code, w.Body.String())
+			}
+
+			var errResp struct {
+				Error struct {
+					Message string      `json:"message"`
+					Type    string      `json:"type"`
+					Code    interface{} `json:"code"`
+				} `json:"error"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &errResp); err != nil || errResp.Error.Message == "" || errResp.Error.Type == "" {
+				t.Fatalf("expected OpenAI error body, got %s", w.Body.String())
+			}
+			if tt.code != "" && errResp.Error.Code != tt.code {
+				t.Fatalf("expected error code %s, got %v", tt.code, errResp.Error.Code)
+			}
+		})
+	}
+}
+
+func TestResponses_Native(t *testing.T) {
+	var captured map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/responses" {
+			t.Errorf("unexpected upstream path: %s", r.URL.Path)
+		}
+		captured = nil
+		body, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(body, &captured)
+
+		if captured["stream"] == true {
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = w.Write([]byte("event: response.created\ndata: {\"type\":\"response.created\",\"sequence_number\":0,\"response\":{\"id\":\"resp_up2\",\"object\":\"response\",\"status\":\"in_progress\",\"output\":[]}}\n\n"))
+			_, _ = w.Write([]byte("event: response.completed\ndata: {\"type\":\"response.completed\",\"sequence_number\":1,\"response\":{\"id\":\"resp_up2\",\"object\":\"response\",\"status\":\"completed\",\"output\":[{\"type\":\"message\",\"id\":\"msg_up2\",\"role\":\"assistant\",\"content\":[{\"type\":\"output_text\",\"text\":\"Bye.\",\"annotations\":[]}]}]}}\n\n"))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"resp_up1","object":"response","status":"completed","model":"gpt-5","output":[{"type":"reasoning","id":"rs_1","summary":[]},{"type":"message","id":"msg_up1","role":"assistant","content":[{"type":"output_text","text":"Hi!","annotations":[]}]}]}`))
+	}))
+	defer server.Close()
+
+	engine := newResponsesTestEngine(t, &models.Provider{Name: "openai", BaseURL: server.URL, APIKey: "sk-test", Enabled: true, ResponsesAPI: true}, "gpt-5")
+
+	w := postResponses(engine, `{"model": "my-model", "input": "hello", "reasoning": {"effort": "low"}}`)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"resp_up1"`) {
+		t.Fatalf("unexpected response: %d %s", w.Code, w.Body.String())
+	}
+	if captured["model"] != "gpt-5" || captured["input"] != "hello" || captured["reasoning"] == nil {
+		t.Fatalf("expected request to be forwarded as-is with target model, got %+v", captured)
+	}
+
+	w = postResponses(engine, `{"model": "my-model", "stream": true, "previous_response_id": "resp_up1", "input": "bye"}`)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "event: response.completed") {
+		t.Fatalf("unexpected stream response: %d %s", w.Code, w.Body.String())
+	}
+	if _, ok := captured["previous_response_id"]; ok {
+		t.Fatalf("expected previous_response_id to be expanded locally, got %+v", captured)
+	}
+	input, _ := captured["input"].([]interface{})
+	if len(input) != 3 {
+		t.Fatalf("expected user, assistant (reasoning dropped) and new user items, got %+v", captured["input"])
+	}
+	assistant := input[1].(map[string]interface{})
+	if assistant["role"] != "assistant" || assistant["id"] != nil {
+		t.Fatalf("unexpected replayed assistant item: %+v", assistant)
+	}
+
+	// 流式原生响应同样写入历史
+	postResponses(engine, `{"model": "my-model", "previous_response_id": "resp_up2", "input": "again"}`)
+	if input, _ := captured["input"].([]interface{}); len(input) != 5 {
+		t.Fatalf("expected streamed native turn to be stored, got %+v", captured["input"])
+	}
+}
+
+func TestResponses_StructuredOutputViaClaude(t *testing.T) {
+	var captured []converter.ClaudeRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/messages" {
+			t.Errorf("unexpected upstream path: %s", r.URL.Path)
+		}
+		var req converter.ClaudeRequest
+		body, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(body, &req)
+		captured = append(captured, req)
+
+		if req.Stream {
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = w.Write([]byte("event: message_start\ndata: {\"type\":\"message_start\",\"message\":{\"id\":\"msg_s\",\"type\":\"message\",\"role\":\"assistant\",\"model\":\"claude-sonnet-4-5\",\"content\":[],\"usage\":{\"input_tokens\":10,\"output_tokens\":0}}}\n\n"))
+			_, _ = w.Write([]byte("event: content_block_start\ndata: {\"type\":\"content_block_start\",\"index\":0,\"content_block\":{\"type\":\"tool_use\",\"id\":\"toolu_s\",\"name\":\"structured_output\",\"input\":{}}}\n\n"))
+			_, _ = w.Write([]byte("event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"input_json_delta\",\"partial_json\":\"{\\\"name\\\":\\\"Ada\\\"}\"}}\n\n"))
+			_, _ = w.Write([]byte("event: content_block_stop\ndata: {\"type\":\"content_block_stop\",\"index\":0}\n\n"))
+			_, _ = w.Write([]byte("event: message_delta\ndata: {\"type\":\"message_delta\",\"delta\":{\"stop_reason\":\"tool_use\"},\"usage\":{\"output_tokens\":5}}\n\n"))
+			_, _ = w.Write([]byte("event: message_stop\ndata: {\"type\":\"message_stop\"}\n\n"))
+			return
+		}
+
+		// 第一次返回不符合 schema 的输出，重试后返回合法输出
+		age := `-1`
+		if len(req.Messages) > 1 {
+			age = `36`
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"msg_1","type":"message","role":"assistant","model":"claude-sonnet-4-5","content":[{"type":"tool_use","id":"toolu_1","name":"structured_output","input":{"name":"Ada","age":` + age + `}}],"stop_reason":"tool_use","usage":{"input_tokens":10,"output_tokens":5}}`))
+	}))
+	defer server.Close()
+
+	engine := newResponsesTestEngine(t, &models.Provider{Name: "anthropic", BaseURL: server.URL, APIKey: "sk-test", Enabled: true}, "claude-sonnet-4-5")
+
+	w := postResponses(engine, `{
+		"model": "my-model",
+		"input": "Who wrote the first program?",
+		"text": {"format": {"type": "json_schema", "name": "person", "strict": true, "schema": {
+			"type": "object",
+			"properties": {"name": {"type": "string"}, "age": {"type": "integer", "minimum": 0}},
+			"required": ["name", "age"],
+			"additionalProperties": false
+		}}}
+	}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status code: %d, body: %s", w.Code, w.Body.String())
+	}
+	if len(captured) != 2 {
+		t.Fatalf("expected one retry after schema violation, got %d upstream requests", len(captured))
+	}
+	first := captured[0]
+	if len(first.Tools) != 1 || first.Tools[0].Name != converter.StructuredOutputToolName || first.Tools[0].InputSchema["required"] == nil {
+		t.Fatalf("expected structured output tool in upstream request, got %+v", first.Tools)
+	}
+	if first.ToolChoice == nil || first.ToolChoice.Type != "tool" || *first.ToolChoice.Name != converter.StructuredOutputToolName {
+		t.Fatalf("expected forced tool choice, got %+v", first.ToolChoice)
+	}
+	if retry := captured[1].Messages; len(retry) != 3 || retry[2].Content[0].Type != converter.ContentTypeToolResult {
+		t.Fatalf("expected retry to carry the schema violation as tool_result, got %+v", retry)
+	}
+
+	var resp converter.ResponsesResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Status != "completed" || len(resp.Output) != 1 || resp.Output[0].Type != "message" || resp.Output[0].Content[0].Text != `{"age":36,"name":"Ada"}` {
+		t.Fatalf("expected tool call to be unwrapped into output_text, got %+v", resp.Output)
+	}
+
+	// 流式：工具参数增量还原为文本增量
+	captured = nil
+	w = postResponses(engine, `{"model": "my-model", "stream": true, "input": "Who?", "text": {"format": {"type": "json_object"}}}`)
+	if w.Code != http.StatusOK || len(captured) != 1 {
+		t.Fatalf("unexpected stream response: %d %s", w.Code, w.Body.String())
+	}
+	events := parseResponsesEvents(t, w.Body.String())
+	var text string
+	for _, event := range events {
+		if event.Type == "response.output_text.delta" {
+			text += event.Delta
+		}
+		if event.Type == "response.function_call_arguments.delta" {
+			t.Fatalf("structured output should not surface as a function call: %+v", event)
+		}
+	}
+	if text != `{"name":"Ada"}` {
+		t.Fatalf("expected streamed JSON text, got %q", text)
+	}
+}
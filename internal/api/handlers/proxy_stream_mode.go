@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/Mieluoxxx/Siriusx-API/internal/converter"
+	"github.com/gin-gonic/gin"
+)
+
+// withStreamMode 保证写给客户端的响应与请求的 stream 参数一致
+// 上游忽略 stream: true 只返回 JSON 时回放为 SSE；上游只返回流式响应而客户端未请求流式时合并为单个 JSON 响应
+// kind 为 cacheKindMessages 或 cacheKindChat，决定响应协议
+func (h *ProxyHandler) withStreamMode(c *gin.Context, kind string, req map[string]interface{}, forward func()) {
+	stream, _ := req["stream"].(bool)
+	writer := newStreamModeWriter(c.Writer, kind, stream, req)
+	c.Writer = writer
+	forward()
+	c.Writer = writer.ResponseWriter
+	writer.finish()
+}
+
+// streamModeWriter 在首次写入时按状态码与 Content-Type 判断上游响应格式：
+// 错误响应或与请求一致的格式直接透传；不一致时缓存完整响应，由 finish 转换为请求的格式
+type streamModeWriter struct {
+	gin.ResponseWriter
+	kind    string
+	stream  bool
+	req     map[string]interface{}
+	status  int
+	decided bool // 已判断上游响应格式
+	convert bool // 格式与请求不一致，需要转换
+	buffer  bytes.Buffer
+}
+
+// newStreamModeWriter 创建流式模式适配 writer
+func newStreamModeWriter(w gin.ResponseWriter, kind string, stream bool, req map[string]interface{}) *streamModeWriter {
+	return &streamModeWriter{
+		ResponseWriter: w,
+		kind:           kind,
+		stream:         stream,
+		req:            req,
+		status:         http.StatusOK,
+	}
+}
+
+// WriteHeader 判断格式前仅记录状态码
+func (w *streamModeWriter) WriteHeader(code int) {
+	if !w.decided {
+		w.status = code
+	}
+}
+
+// WriteHeaderNow 透传时才写出响应头
+func (w *streamModeWriter) WriteHeaderNow() {
+	if w.decided && !w.convert {
+		w.ResponseWriter.WriteHeaderNow()
+	}
+}
+
+// Status 返回上游响应的状态码
+func (w *streamModeWriter) Status() int {
+	return w.status
+}
+
+// WriteString 同 Write
+func (w *streamModeWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Write 透传或缓存响应数据
+func (w *streamModeWriter) Write(data []byte) (int, error) {
+	if !w.decided {
+		w.decide()
+	}
+	if w.convert {
+		return w.buffer.Write(data)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+// Flush 透传时向客户端刷新
+func (w *streamModeWriter) Flush() {
+	if w.decided && !w.convert {
+		w.ResponseWriter.Flush()
+	}
+}
+
+// decide 按状态码与 Content-Type 判断是否需要转换
+func (w *streamModeWriter) decide() {
+	w.decided = true
+	isStream := strings.Contains(strings.ToLower(w.Header().Get("Content-Type")), "text/event-stream")
+	w.convert = w.status < 400 && isStream != w.stream
+	if !w.convert {
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+}
+
+// finish 写出转换后的响应
+func (w *streamModeWriter) finish() {
+	if !w.decided {
+		w.ResponseWriter.WriteHeader(w.status)
+		return
+	}
+	if !w.convert {
+		return
+	}
+
+	body, _, err := decompressIfNeeded(w.buffer.Bytes(), w.Header())
+	if err != nil {
+		body = w.buffer.Bytes()
+	}
+	w.Header().Del("Content-Length")
+	w.Header().Del("Content-Encoding")
+
+	if w.stream {
+		events, err := replayResponseStream(w.kind, body, w.req)
+		if err != nil {
+			// 无法识别的响应体原样写出
+			log.Printf("⚠️ [流式适配] 上游非流式响应无法回放为 SSE: %v", err)
+			w.writeBody(w.status, w.Header().Get("Content-Type"), body)
+			return
+		}
+		log.Printf("🔁 [流式适配] 上游未返回流式响应，已回放为 SSE")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.writeBody(http.StatusOK, "text/event-stream; charset=utf-8", events)
+		return
+	}
+
+	var aggregated []byte
+	if w.kind == cacheKindMessages {
+		aggregated, err = converter.AggregateClaudeStream(body)
+	} else {
+		aggregated, err = converter.AggregateOpenAIStream(body)
+	}
+	if err != nil {
+		log.Printf("❌ [流式适配] 上游流式响应合并失败: %v", err)
+		var errorBody interface{} = gin.H{"error": "上游流式响应合并失败: " + err.Error()}
+		if w.kind == cacheKindMessages {
+			errorBody = claudeTranslator{}.errorBody(http.StatusBadGateway, "上游流式响应合并失败: "+err.Error())
+		}
+		data, _ := json.Marshal(errorBody)
+		w.writeBody(http.StatusBadGateway, "application/json", data)
+		return
+	}
+	log.Printf("🔁 [流式适配] 上游返回流式响应，已合并为非流式响应")
+	w.writeBody(http.StatusOK, "application/json", aggregated)
+}
+
+func (w *streamModeWriter) writeBody(status int, contentType string, body []byte) {
+	w.Header().Set("Content-Type", contentType)
+	w.ResponseWriter.WriteHeader(status)
+	if _, err := w.ResponseWriter.Write(body); err != nil {
+		log.Printf("❌ [响应写入失败] 流式适配响应: %v", err)
+	}
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Mieluoxxx/Siriusx-API/internal/converter"
+	"github.com/Mieluoxxx/Siriusx-API/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// 上游忽略 stream: true 返回 JSON 时，回放为 Claude SSE 事件序列
+func TestMessages_StreamRequestedJSONUpstream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"msg_1","type":"message","role":"assistant","model":"claude-sonnet-4-5","content":[{"type":"text","text":"pong"}],"stop_reason":"end_turn","stop_sequence":null,"usage":{"input_tokens":3,"output_tokens":1}}`))
+	}))
+	defer server.Close()
+
+	handler := newMultiProviderTestHandler(t, "claude-sonnet-4-5", &models.Provider{Name: "relay", BaseURL: server.URL, APIKey: "sk-test"})
+	engine := gin.New()
+	engine.POST("/v1/messages", handler.Messages)
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest("POST", "/v1/messages", strings.NewReader(`{"model":"my-model","max_tokens":16,"stream":true,"messages":[{"role":"user","content":"ping"}]}`)))
+	if w.Code != http.StatusOK || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/event-stream") {
+		t.Fatalf("expected SSE response, got %d %q: %s", w.Code, w.Header().Get("Content-Type"), w.Body.String())
+	}
+
+	var types []string
+	for _, block := range strings.Split(w.Body.String(), "\n\n") {
+		if event, _, found := strings.Cut(strings.TrimPrefix(block, "event: "), "\n"); found {
+			types = append(types, event)
+		}
+	}
+	want := "message_start content_block_start content_block_delta content_block_stop message_delta message_stop"
+	if strings.Join(types, " ") != want {
+		t.Fatalf("unexpected event sequence: %v", types)
+	}
+	if !strings.Contains(w.Body.String(), `"text":"pong"`) {
+		t.Fatalf("text delta missing: %s", w.Body.String())
+	}
+}
+
+// 只返回流式响应的上游，非流式 Chat 请求合并为单个 JSON 响应
+func TestChatCompletions_NonStreamRequestedSSEUpstream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, chunk := range []string{"po", "ng"} {
+			_, _ = w.Write([]byte(`data: {"id":"c1","object":"chat.completion.chunk","created":1,"model":"m","choices":[{"index":0,"delta":{"content":"` + chunk + `"},"finish_reason":null}]}` + "\n\n"))
+		}
+		_, _ = w.Write([]byte(`data: {"id":"c1","object":"chat.completion.chunk","created":1,"model":"m","choices":[{"index":0,"delta":{},"finish_reason":"stop"}],"usage":{"prompt_tokens":3,"completion_tokens":2,"total_tokens":5}}` + "\n\n"))
+		_, _ = w.Write([]byte("data: [DONE]\n\n"))
+	}))
+	defer server.Close()
+
+	handler := newMultiProviderTestHandler(t, "base-model", &models.Provider{Name: "relay", BaseURL: server.URL, APIKey: "sk-test"})
+	engine := gin.New()
+	engine.POST("/v1/chat/completions", handler.ChatCompletions)
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest("POST", "/v1/chat/completions", strings.NewReader(`{"model":"my-model","messages":[{"role":"user","content":"ping"}]}`)))
+	if w.Code != http.StatusOK || !strings.HasPrefix(w.Header().Get("Content-Type"), "application/json") {
+		t.Fatalf("expected JSON response, got %d %q: %s", w.Code, w.Header().Get("Content-Type"), w.Body.String())
+	}
+
+	var resp converter.OpenAIResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(resp.Choices) != 1 || resp.Choices[0].Message.Content != "pong" || resp.Choices[0].FinishReason != "stop" || resp.Usage.TotalTokens != 5 {
+		t.Fatalf("unexpected aggregated response: %s", w.Body.String())
+	}
+}
+
+// Claude→OpenAI 转换链路：上游返回流式响应而客户端未请求流式时，合并为 Claude 响应
+func TestMessages_NonStreamRequestedSSEUpstreamViaOpenAI(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte(`data: {"id":"c1","object":"chat.completion.chunk","created":1,"model":"glm-4.6","choices":[{"index":0,"delta":{"role":"assistant","content":"pong"},"finish_reason":null}]}` + "\n\n"))
+		_, _ = w.Write([]byte(`data: {"id":"c1","object":"chat.completion.chunk","created":1,"model":"glm-4.6","choices":[{"index":0,"delta":{},"finish_reason":"stop"}]}` + "\n\n"))
+		_, _ = w.Write([]byte("data: [DONE]\n\n"))
+	}))
+	defer server.Close()
+
+	handler := newMultiProviderTestHandler(t, "glm-4.6", &models.Provider{Name: "relay", BaseURL: server.URL, APIKey: "sk-test"})
+	engine := gin.New()
+	engine.POST("/v1/messages", handler.Messages)
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest("POST", "/v1/messages", strings.NewReader(`{"model":"my-model","max_tokens":16,"messages":[{"role":"user","content":"ping"}]}`)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status %d: %s", w.Code, w.Body.String())
+	}
+
+	var resp converter.ClaudeResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("expected a single Claude JSON response, got %s", w.Body.String())
+	}
+	if len(resp.Content) != 1 || *resp.Content[0].Text != "pong" || resp.StopReason != "end_turn" {
+		t.Fatalf("unexpected aggregated response: %s", w.Body.String())
+	}
+}
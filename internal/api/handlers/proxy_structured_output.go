@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/Mieluoxxx/Siriusx-API/internal/converter"
+	"github.com/Mieluoxxx/Siriusx-API/internal/mapping"
+	"github.com/Mieluoxxx/Siriusx-API/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// structuredOutputTranslator 在转换为客户端协议前，将模拟结构化输出的工具调用还原为 JSON 文本
+type structuredOutputTranslator struct {
+	claudeResponseTranslator
+	output *converter.StructuredOutput
+	stream *converter.StructuredOutputStream
+}
+
+// withStructuredOutput 为响应转换器附加结构化输出还原，output 为 nil 时原样返回
+func withStructuredOutput(translator claudeResponseTranslator, output *converter.StructuredOutput) claudeResponseTranslator {
+	if output == nil {
+		return translator
+	}
+	return &structuredOutputTranslator{
+		claudeResponseTranslator: translator,
+		output:                   output,
+		stream:                   output.NewStream(),
+	}
+}
+
+func (t *structuredOutputTranslator) streamEvent(data []byte) ([]byte, error) {
+	data, err := t.stream.ProcessEvent(data)
+	if err != nil {
+		return nil, err
+	}
+	return t.claudeResponseTranslator.streamEvent(data)
+}
+
+func (t *structuredOutputTranslator) response(resp *converter.ClaudeResponse) (interface{}, error) {
+	t.output.UnwrapResponse(resp)
+	return t.claudeResponseTranslator.response(resp)
+}
+
+// dispatchConvertedClaudeRequest 转发由其他协议转换而来的 Claude 请求
+// strict 结构化输出的非流式响应不符合 schema 时，回传校验错误重试一次；流式响应已开始输出，无法重试
+// emulation 非 nil 时请求已按工具模拟改写，校验前先解析文本中的工具调用，重试追加的历史同样改写
+func (h *ProxyHandler) dispatchConvertedClaudeRequest(c *gin.Context, writer *claudeResponseWriter, prov *models.Provider, providerName, modelName string, selectedMapping *mapping.ResolvedMapping, claudeReq *converter.ClaudeRequest, structured *converter.StructuredOutput, emulation *converter.ToolEmulation) {
+	payload, err := claudeRequestPayload(claudeReq)
+	if err != nil {
+		h.respondClaudeError(c, http.StatusInternalServerError, "api_error", "序列化请求失败")
+		return
+	}
+	h.dispatchClaudeRequest(c, prov, providerName, modelName, selectedMapping, payload)
+
+	if structured == nil || !structured.Strict || claudeReq.Stream {
+		return
+	}
+	resp, ok := writer.claudeResponse()
+	if !ok {
+		return
+	}
+	if emulation != nil {
+		emulation.ConvertClaudeResponse(resp)
+	}
+	violation := structured.Validate(resp)
+	if violation == nil {
+		return
+	}
+
+	log.Printf("⚠️ [结构化输出] 输出不符合 schema，重试一次 [Provider: %s, Target: %s]: %v", providerName, selectedMapping.TargetModel, violation)
+	retry := structured.RetryRequest(claudeReq, resp, violation)
+	if emulation != nil {
+		retry.Messages = emulation.ConvertClaudeMessages(retry.Messages)
+	}
+	retryPayload, err := claudeRequestPayload(retry)
+	if err != nil {
+		return
+	}
+	writer.reset()
+	h.dispatchClaudeRequest(c, prov, providerName, modelName, selectedMapping, retryPayload)
+}
@@ -0,0 +1,378 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/Mieluoxxx/Siriusx-API/internal/converter"
+	"github.com/Mieluoxxx/Siriusx-API/internal/mapping"
+	"github.com/Mieluoxxx/Siriusx-API/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// emulateClaudeTools 映射开启工具模拟时改写 Claude 请求，返回 nil 表示无需模拟
+func emulateClaudeTools(selected *mapping.ResolvedMapping, req *converter.ClaudeRequest) *converter.ToolEmulation {
+	if !selected.EmulateTools {
+		return nil
+	}
+	emulation := converter.EmulateClaudeTools(req)
+	if emulation != nil {
+		log.Printf("🧰 [工具模拟] 工具定义已渲染进 system 提示词 [Target: %s]", selected.TargetModel)
+	}
+	return emulation
+}
+
+// replaceRequestFields 用 value 中的字段替换请求 map 中的对应字段（value 中不存在的字段从请求中删除）
+// 经 JSON 往返保持请求 map 的通用结构，供后续按 map 读取的逻辑使用
+func replaceRequestFields(req map[string]interface{}, value interface{}, keys ...string) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if field, ok := fields[key]; ok {
+			req[key] = field
+		} else {
+			delete(req, key)
+		}
+	}
+	return nil
+}
+
+// dispatchClaudeWithToolEmulation 以提示词模拟工具调用转发 Messages 请求，响应文本中的调用转换为 tool_use 块后写出
+func (h *ProxyHandler) dispatchClaudeWithToolEmulation(c *gin.Context, prov *models.Provider, providerName, modelName string, selectedMapping *mapping.ResolvedMapping, req map[string]interface{}) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		h.respondClaudeError(c, http.StatusInternalServerError, "api_error", "序列化请求失败")
+		return
+	}
+	var claudeReq converter.ClaudeRequest
+	if err := json.Unmarshal(data, &claudeReq); err != nil {
+		h.respondClaudeError(c, http.StatusBadRequest, "invalid_request_error", fmt.Sprintf("请求格式不符合 Claude Messages 规范: %v", err))
+		return
+	}
+
+	emulation := emulateClaudeTools(selectedMapping, &claudeReq)
+	if emulation == nil {
+		h.dispatchClaudeRequest(c, prov, providerName, modelName, selectedMapping, req)
+		return
+	}
+	if err := replaceRequestFields(req, &claudeReq, "system", "messages", "tools", "tool_choice"); err != nil {
+		h.respondClaudeError(c, http.StatusInternalServerError, "api_error", "序列化请求失败")
+		return
+	}
+
+	writer := newClaudeResponseWriter(c.Writer, claudeReq.Stream, withToolEmulation(claudeTranslator{}, emulation))
+	c.Writer = writer
+	h.dispatchClaudeRequest(c, prov, providerName, modelName, selectedMapping, req)
+	c.Writer = writer.ResponseWriter
+	writer.finish()
+}
+
+// dispatchChatWithToolEmulation 以提示词模拟工具调用转发 Chat Completions 请求，响应文本中的调用转换为 tool_calls 后写出
+func (h *ProxyHandler) dispatchChatWithToolEmulation(c *gin.Context, prov *models.Provider, selectedMapping *mapping.ResolvedMapping, modelName string, req map[string]interface{}) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "序列化请求失败"})
+		return
+	}
+	var openaiReq converter.OpenAIRequest
+	if err := json.Unmarshal(data, &openaiReq); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("请求格式不符合 OpenAI Chat Completions 规范: %v", err)})
+		return
+	}
+
+	emulation := converter.EmulateOpenAITools(&openaiReq)
+	if emulation == nil {
+		h.dispatchChatRequest(c, prov, selectedMapping, modelName, req)
+		return
+	}
+	if err := replaceRequestFields(req, &openaiReq, "messages", "tools", "tool_choice"); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "序列化请求失败"})
+		return
+	}
+	delete(req, "parallel_tool_calls")
+	log.Printf("🧰 [工具模拟] 工具定义已渲染进 system 消息 [Target: %s]", selectedMapping.TargetModel)
+
+	writer := newOpenAIToolEmulationWriter(c.Writer, emulation)
+	c.Writer = writer
+	h.dispatchChatRequest(c, prov, selectedMapping, modelName, req)
+	c.Writer = writer.ResponseWriter
+	writer.finish()
+}
+
+// claudeTranslator 保持 Claude 格式的响应转换器，Messages 入口需要在写出前改写响应时使用
+type claudeTranslator struct{}
+
+func (claudeTranslator) label() string {
+	return "Claude"
+}
+
+// streamEvent 按事件类型重新组装 Claude SSE 事件
+func (claudeTranslator) streamEvent(data []byte) ([]byte, error) {
+	var event struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(data, &event); err != nil {
+		return nil, fmt.Errorf("解析 Claude 流式事件失败: %w", err)
+	}
+	return []byte(fmt.Sprintf("event: %s\ndata: %s\n\n", event.Type, data)), nil
+}
+
+// streamError 流式转换失败时写出 error 事件
+func (t claudeTranslator) streamError(err error) []byte {
+	data, _ := json.Marshal(t.errorBody(http.StatusBadGateway, err.Error()))
+	return []byte(fmt.Sprintf("event: error\ndata: %s\n\n", data))
+}
+
+func (claudeTranslator) response(resp *converter.ClaudeResponse) (interface{}, error) {
+	return resp, nil
+}
+
+func (claudeTranslator) errorBody(status int, message string) interface{} {
+	return gin.H{
+		"type": "error",
+		"error": gin.H{
+			"type":    claudeErrorTypeForStatus(status),
+			"message": message,
+		},
+	}
+}
+
+// toolEmulationTranslator 在转换为客户端协议前，将响应文本中模拟的工具调用转换为 tool_use 块
+type toolEmulationTranslator struct {
+	claudeResponseTranslator
+	emulation *converter.ToolEmulation
+	stream    *converter.ClaudeToolEmulationStream
+}
+
+// withToolEmulation 为响应转换器附加工具调用解析，emulation 为 nil 时原样返回
+func withToolEmulation(translator claudeResponseTranslator, emulation *converter.ToolEmulation) claudeResponseTranslator {
+	if emulation == nil {
+		return translator
+	}
+	return &toolEmulationTranslator{
+		claudeResponseTranslator: translator,
+		emulation:                emulation,
+		stream:                   emulation.NewClaudeStream(),
+	}
+}
+
+func (t *toolEmulationTranslator) streamEvent(data []byte) ([]byte, error) {
+	events, err := t.stream.ProcessEvent(data)
+	if err != nil {
+		return nil, err
+	}
+	var out []byte
+	for _, event := range events {
+		translated, err := t.claudeResponseTranslator.streamEvent(event)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, translated...)
+	}
+	return out, nil
+}
+
+func (t *toolEmulationTranslator) response(resp *converter.ClaudeResponse) (interface{}, error) {
+	t.emulation.ConvertClaudeResponse(resp)
+	return t.claudeResponseTranslator.response(resp)
+}
+
+// openAIToolEmulationWriter 将 Chat Completions 转发链路写出的 OpenAI 响应中模拟的工具调用转换为 tool_calls
+// 非流式响应与错误响应先缓存，由 finish 统一转换；流式响应经 SSE 解析器按事件逐个转换并立即写出
+type openAIToolEmulationWriter struct {
+	gin.ResponseWriter
+	emulation *converter.ToolEmulation
+	stream    *converter.OpenAIToolEmulationStream
+	status    int
+	buffer    bytes.Buffer
+	parser    *converter.SSEParser
+	streaming bool // 上游返回了流式响应，响应头已写出
+	failed    bool // 流式响应解析出错，忽略后续数据
+}
+
+// newOpenAIToolEmulationWriter 创建响应转换 writer
+func newOpenAIToolEmulationWriter(w gin.ResponseWriter, emulation *converter.ToolEmulation) *openAIToolEmulationWriter {
+	return &openAIToolEmulationWriter{
+		ResponseWriter: w,
+		emulation:      emulation,
+		stream:         emulation.NewOpenAIStream(),
+		status:         http.StatusOK,
+		parser:         converter.NewSSEFeedParser(),
+	}
+}
+
+// WriteHeader 仅记录状态码，流式响应在首次写入时写出
+func (w *openAIToolEmulationWriter) WriteHeader(code int) {
+	if !w.streaming {
+		w.status = code
+	}
+}
+
+// WriteHeaderNow 延迟到转换后写出
+func (w *openAIToolEmulationWriter) WriteHeaderNow() {}
+
+// WriteString 同 Write
+func (w *openAIToolEmulationWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Write 缓存非流式响应，流式响应逐个事件转换
+func (w *openAIToolEmulationWriter) Write(data []byte) (int, error) {
+	if !w.streaming {
+		if w.status >= 400 || !strings.Contains(w.Header().Get("Content-Type"), "text/event-stream") {
+			return w.buffer.Write(data)
+		}
+		w.Header().Del("Content-Length")
+		w.ResponseWriter.WriteHeader(w.status)
+		w.streaming = true
+	}
+
+	if w.failed {
+		return len(data), nil
+	}
+
+	events, err := w.parser.Feed(data)
+	for _, event := range events {
+		if writeErr := w.writeStreamEvent(event); writeErr != nil {
+			return len(data), writeErr
+		}
+	}
+	if err != nil {
+		log.Printf("❌ [工具模拟] 解析流式响应失败: %v", err)
+		w.failed = true
+		errData, _ := json.Marshal(responsesErrorBody(http.StatusBadGateway, err.Error(), ""))
+		_, writeErr := w.ResponseWriter.Write([]byte(fmt.Sprintf("data: %s\n\ndata: [DONE]\n\n", errData)))
+		return len(data), writeErr
+	}
+	return len(data), nil
+}
+
+// Flush 流式响应开始后才向客户端刷新
+func (w *openAIToolEmulationWriter) Flush() {
+	if w.streaming {
+		w.ResponseWriter.Flush()
+	}
+}
+
+// writeStreamEvent 转换单个 SSE 事件并写出，[DONE] 与无法解析的事件按原字段重新编码写出
+func (w *openAIToolEmulationWriter) writeStreamEvent(event *converter.SSEEvent) error {
+	if event.Data == "[DONE]" {
+		_, err := w.ResponseWriter.Write(encodeSSEEvent(event))
+		return err
+	}
+
+	out, err := w.stream.ProcessChunk([]byte(event.Data))
+	if err != nil {
+		log.Printf("⚠️ [工具模拟] %v", err)
+		_, err = w.ResponseWriter.Write(encodeSSEEvent(event))
+		return err
+	}
+	if out == nil {
+		return nil
+	}
+	_, err = w.ResponseWriter.Write([]byte("data: " + string(out) + "\n\n"))
+	return err
+}
+
+// finish 写出缓存的非流式响应（成功响应先解析工具调用）
+func (w *openAIToolEmulationWriter) finish() {
+	if w.streaming {
+		if event := w.parser.Flush(); event != nil && !w.failed {
+			_ = w.writeStreamEvent(event)
+		}
+		w.ResponseWriter.Flush()
+		return
+	}
+
+	body := w.buffer.Bytes()
+	if w.status < 400 {
+		if converted, ok := w.convertResponse(body); ok {
+			body = converted
+		}
+	}
+
+	w.Header().Del("Content-Length")
+	w.Header().Del("Content-Encoding")
+	w.ResponseWriter.WriteHeader(w.status)
+	if _, err := w.ResponseWriter.Write(body); err != nil {
+		log.Printf("❌ [响应写入失败] 工具模拟响应: %v", err)
+	}
+}
+
+// convertResponse 解析非流式响应中的工具调用
+// 只改写发生转换的 choice 的 message.content、message.tool_calls 与 finish_reason，响应体中的其他字段
+// （system_fingerprint、logprobs、供应商扩展的 usage 明细等）原样保留
+func (w *openAIToolEmulationWriter) convertResponse(body []byte) ([]byte, bool) {
+	var resp converter.OpenAIResponse
+	if err := json.Unmarshal(body, &resp); err != nil || len(resp.Choices) == 0 {
+		return nil, false
+	}
+	original := make([]int, len(resp.Choices))
+	for i, choice := range resp.Choices {
+		original[i] = len(choice.Message.ToolCalls)
+	}
+	w.emulation.ConvertOpenAIResponse(&resp)
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var raw map[string]interface{}
+	if err := decoder.Decode(&raw); err != nil {
+		return nil, false
+	}
+	choices, ok := raw["choices"].([]interface{})
+	if !ok || len(choices) != len(resp.Choices) {
+		return nil, false
+	}
+
+	changed := false
+	for i, item := range choices {
+		converted := resp.Choices[i]
+		if len(converted.Message.ToolCalls) == original[i] {
+			continue
+		}
+		choice, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		message, ok := choice["message"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		message["content"] = converted.Message.Content
+		message["tool_calls"] = converted.Message.ToolCalls
+		choice["finish_reason"] = converted.FinishReason
+		changed = true
+	}
+	if !changed {
+		return nil, false
+	}
+
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, false
+	}
+	return data, true
+}
+
+// encodeSSEEvent 按 SSE 格式重新编码解析出的事件
+func encodeSSEEvent(event *converter.SSEEvent) []byte {
+	var out bytes.Buffer
+	if event.Event != "" {
+		out.WriteString("event: " + event.Event + "\n")
+	}
+	for _, line := range strings.Split(event.Data, "\n") {
+		out.WriteString("data: " + line + "\n")
+	}
+	out.WriteString("\n")
+	return out.Bytes()
+}
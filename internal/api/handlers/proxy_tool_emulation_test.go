@@ -0,0 +1,306 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Mieluoxxx/Siriusx-API/internal/converter"
+	"github.com/Mieluoxxx/Siriusx-API/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// emulatedCallText 上游按约定格式输出的工具调用文本
+const emulatedCallText = "Let me check.\n<tool_call>\n{\"name\": \"get_weather\", \"input\": {\"city\": \"Paris\"}}\n</tool_call>"
+
+func TestMessages_ToolEmulation(t *testing.T) {
+	var captured map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		captured = nil
+		_ = json.NewDecoder(r.Body).Decode(&captured)
+
+		if captured["stream"] == true {
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = w.Write([]byte("event: message_start\ndata: {\"type\":\"message_start\",\"message\":{\"id\":\"msg_s\",\"type\":\"message\",\"role\":\"assistant\",\"model\":\"m\",\"content\":[],\"usage\":{\"input_tokens\":10,\"output_tokens\":0}}}\n\n"))
+			_, _ = w.Write([]byte("event: content_block_start\ndata: {\"type\":\"content_block_start\",\"index\":0,\"content_block\":{\"type\":\"text\",\"text\":\"\"}}\n\n"))
+			for _, chunk := range []string{"Let me check.\\n<tool", "_call>\\n{\\\"name\\\": \\\"get_weather\\\", \\\"input\\\": {\\\"city\\\":", " \\\"Paris\\\"}}\\n</tool_call>"} {
+				_, _ = w.Write([]byte("event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"text_delta\",\"text\":\"" + chunk + "\"}}\n\n"))
+			}
+			_, _ = w.Write([]byte("event: content_block_stop\ndata: {\"type\":\"content_block_stop\",\"index\":0}\n\n"))
+			_, _ = w.Write([]byte("event: message_delta\ndata: {\"type\":\"message_delta\",\"delta\":{\"stop_reason\":\"end_turn\"},\"usage\":{\"output_tokens\":20}}\n\n"))
+			_, _ = w.Write([]byte("event: message_stop\ndata: {\"type\":\"message_stop\"}\n\n"))
+			return
+		}
+
+		text, _ := json.Marshal(emulatedCallText)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"msg_1","type":"message","role":"assistant","model":"m","content":[{"type":"text","text":` + string(text) + `}],"stop_reason":"end_turn","usage":{"input_tokens":10,"output_tokens":20}}`))
+	}))
+	defer server.Close()
+
+	handler := newMultiProviderTestHandler(t, "claude-sonnet-4-5", &models.Provider{Name: "relay", BaseURL: server.URL, APIKey: "sk-test"})
+	handler.router.(*stubMultiRouter).resolved[0].EmulateTools = true
+	engine := gin.New()
+	engine.POST("/v1/messages", handler.Messages)
+
+	send := func(stream bool) *httptest.ResponseRecorder {
+		t.Helper()
+		body := `{"model":"my-model","max_tokens":256,"stream":` + map[bool]string{true: "true", false: "false"}[stream] + `,
+			"system":"Be brief.",
+			"tools":[{"name":"get_weather","description":"Get the weather","input_schema":{"type":"object","properties":{"city":{"type":"string"}}}}],
+			"messages":[
+				{"role":"user","content":"weather in London?"},
+				{"role":"assistant","content":[{"type":"tool_use","id":"toolu_0","name":"get_weather","input":{"city":"London"}}]},
+				{"role":"user","content":[{"type":"tool_result","tool_use_id":"toolu_0","content":"Rain"}]},
+				{"role":"user","content":"and Paris?"}
+			]}`
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest("POST", "/v1/messages", strings.NewReader(body)))
+		if w.Code != http.StatusOK {
+			t.Fatalf("unexpected status %d: %s", w.Code, w.Body.String())
+		}
+		return w
+	}
+
+	w := send(false)
+	if _, ok := captured["tools"]; ok {
+		t.Fatalf("tools should not reach upstream, got %+v", captured["tools"])
+	}
+	system, _ := captured["system"].(string)
+	if !strings.HasPrefix(system, "Be brief.") || !strings.Contains(system, `"name":"get_weather"`) || !strings.Contains(system, "<tool_call>") {
+		t.Fatalf("system should carry the tool definitions, got %q", system)
+	}
+	var history strings.Builder
+	encoder := json.NewEncoder(&history)
+	encoder.SetEscapeHTML(false)
+	_ = encoder.Encode(captured["messages"])
+	if strings.Contains(history.String(), `"tool_use"`) || !strings.Contains(history.String(), `<tool_result id=\"toolu_0\">\nRain\n</tool_result>`) {
+		t.Fatalf("tool history should be rendered as text, got %s", history.String())
+	}
+
+	var resp converter.ClaudeResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.StopReason != "tool_use" || len(resp.Content) != 2 || *resp.Content[0].Text != "Let me check." {
+		t.Fatalf("unexpected converted response: %s", w.Body.String())
+	}
+	if call := resp.Content[1]; call.Type != "tool_use" || *call.Name != "get_weather" || call.Input["city"] != "Paris" || !strings.HasPrefix(*call.ID, "toolu_") {
+		t.Fatalf("expected get_weather tool_use, got %+v", call)
+	}
+
+	w = send(true)
+	var types []string
+	var arguments string
+	for _, block := range strings.Split(w.Body.String(), "\n\n") {
+		_, data, found := strings.Cut(block, "data: ")
+		if !found {
+			continue
+		}
+		var event map[string]interface{}
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			t.Fatalf("invalid event %q: %v", data, err)
+		}
+		types = append(types, event["type"].(string))
+		if delta, ok := event["delta"].(map[string]interface{}); ok {
+			if partial, ok := delta["partial_json"].(string); ok {
+				arguments += partial
+			}
+			if event["type"] == "message_delta" && delta["stop_reason"] != "tool_use" {
+				t.Fatalf("stop_reason should be tool_use, got %+v", delta)
+			}
+		}
+	}
+	want := "message_start content_block_start content_block_delta content_block_stop content_block_start content_block_delta content_block_stop message_delta message_stop"
+	if strings.Join(types, " ") != want {
+		t.Fatalf("unexpected event sequence:\ngot:  %s\nwant: %s", strings.Join(types, " "), want)
+	}
+	if arguments != `{"city":"Paris"}` {
+		t.Fatalf("unexpected tool arguments: %s", arguments)
+	}
+}
+
+func TestChatCompletions_ToolEmulation(t *testing.T) {
+	var captured converter.OpenAIRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		captured = converter.OpenAIRequest{}
+		_ = json.NewDecoder(r.Body).Decode(&captured)
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, chunk := range []string{"Let me check.\\n<tool_call>\\n{\\\"name\\\": \\\"get_weather\\\",", " \\\"input\\\": {\\\"city\\\": \\\"Paris\\\"}}\\n</tool_"} {
+			_, _ = w.Write([]byte(`data: {"id":"c1","object":"chat.completion.chunk","created":1,"model":"m","choices":[{"index":0,"delta":{"content":"` + chunk + `"},"finish_reason":null}]}` + "\n\n"))
+		}
+		_, _ = w.Write([]byte(`data: {"id":"c1","object":"chat.completion.chunk","created":1,"model":"m","choices":[{"index":0,"delta":{"content":"call>"},"finish_reason":"stop"}]}` + "\n\n"))
+		_, _ = w.Write([]byte("data: [DONE]\n\n"))
+	}))
+	defer server.Close()
+
+	handler := newMultiProviderTestHandler(t, "base-model", &models.Provider{Name: "relay", BaseURL: server.URL, APIKey: "sk-test"})
+	handler.router.(*stubMultiRouter).resolved[0].EmulateTools = true
+	engine := gin.New()
+	engine.POST("/v1/chat/completions", handler.ChatCompletions)
+
+	body := `{"model":"my-model","stream":true,"tool_choice":"required","parallel_tool_calls":true,
+		"tools":[{"type":"function","function":{"name":"get_weather","parameters":{"type":"object","properties":{"city":{"type":"string"}}}}}],
+		"messages":[
+			{"role":"user","content":"weather in London?"},
+			{"role":"assistant","content":null,"tool_calls":[{"id":"call_0","type":"function","function":{"name":"get_weather","arguments":"{\"city\":\"London\"}"}}]},
+			{"role":"tool","tool_call_id":"call_0","content":"Rain"},
+			{"role":"user","content":"and Paris?"}
+		]}`
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest("POST", "/v1/chat/completions", strings.NewReader(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status %d: %s", w.Code, w.Body.String())
+	}
+
+	if len(captured.Tools) != 0 || captured.ToolChoice != nil || len(captured.Messages) != 5 {
+		t.Fatalf("unexpected upstream request: %+v", captured)
+	}
+	if system, _ := captured.Messages[0].Content.(string); captured.Messages[0].Role != "system" || !strings.Contains(system, "You must call at least one tool") {
+		t.Fatalf("system message should carry the tool prompt, got %+v", captured.Messages[0])
+	}
+	if captured.Messages[3].Role != "user" || !strings.Contains(captured.Messages[3].Content.(string), `<tool_result id="call_0">`) {
+		t.Fatalf("tool message should become a user message, got %+v", captured.Messages[3])
+	}
+
+	var text string
+	var calls []converter.OpenAIStreamToolCall
+	var finish string
+	for _, block := range strings.Split(w.Body.String(), "\n\n") {
+		data, found := strings.CutPrefix(block, "data: ")
+		if !found || data == "[DONE]" {
+			continue
+		}
+		var chunk converter.OpenAIStreamChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			t.Fatalf("invalid chunk %q: %v", data, err)
+		}
+		text += chunk.Choices[0].Delta.Content
+		calls = append(calls, chunk.Choices[0].Delta.ToolCalls...)
+		if chunk.Choices[0].FinishReason != nil {
+			finish = *chunk.Choices[0].FinishReason
+		}
+	}
+	if !strings.HasSuffix(w.Body.String(), "data: [DONE]\n\n") {
+		t.Fatalf("stream should end with [DONE]: %s", w.Body.String())
+	}
+	if text != "Let me check.\n" || finish != "tool_calls" {
+		t.Fatalf("unexpected text %q / finish_reason %q", text, finish)
+	}
+	if len(calls) != 1 || calls[0].Function.Name != "get_weather" || calls[0].Function.Arguments != `{"city":"Paris"}` || calls[0].Index != 0 {
+		t.Fatalf("unexpected tool calls: %+v", calls)
+	}
+}
+
+// 非流式响应只改写发生转换的 choice，模型未建模的字段原样保留
+func TestChatCompletions_ToolEmulationPreservesFields(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"c1","object":"chat.completion","created":1,"model":"m","system_fingerprint":"fp_1","service_tier":"default",
+			"choices":[{"index":0,"message":{"role":"assistant","content":"<tool_call>\n{\"name\": \"get_weather\", \"input\": {\"city\": \"Paris\"}}\n</tool_call>","refusal":null},"logprobs":{"content":[]},"finish_reason":"stop"}],
+			"usage":{"prompt_tokens":5,"completion_tokens":7,"total_tokens":12,"completion_tokens_details":{"reasoning_tokens":3},"cost":12345678901234567}}`))
+	}))
+	defer server.Close()
+
+	handler := newMultiProviderTestHandler(t, "base-model", &models.Provider{Name: "relay", BaseURL: server.URL, APIKey: "sk-test"})
+	handler.router.(*stubMultiRouter).resolved[0].EmulateTools = true
+	engine := gin.New()
+	engine.POST("/v1/chat/completions", handler.ChatCompletions)
+
+	body := `{"model":"my-model","tools":[{"type":"function","function":{"name":"get_weather","parameters":{"type":"object"}}}],"messages":[{"role":"user","content":"weather in Paris?"}]}`
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest("POST", "/v1/chat/completions", strings.NewReader(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status %d: %s", w.Code, w.Body.String())
+	}
+
+	for _, field := range []string{`"system_fingerprint":"fp_1"`, `"service_tier":"default"`, `"logprobs":{"content":[]}`, `"refusal":null`, `"reasoning_tokens":3`, `"cost":12345678901234567`} {
+		if !strings.Contains(w.Body.String(), field) {
+			t.Fatalf("response lost %s: %s", field, w.Body.String())
+		}
+	}
+
+	var resp converter.OpenAIResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	choice := resp.Choices[0]
+	if choice.FinishReason != "tool_calls" || choice.Message.Content != nil || len(choice.Message.ToolCalls) != 1 || choice.Message.ToolCalls[0].Function.Arguments != `{"city":"Paris"}` {
+		t.Fatalf("unexpected choice: %+v", choice)
+	}
+}
+
+// 流式响应经 SSE 解析器切分：单独的 CR 换行、注释、多行 data 与缺少结尾空行的 [DONE] 都应正确处理
+func TestOpenAIToolEmulationWriter_SSEFraming(t *testing.T) {
+	emulation := converter.EmulateOpenAITools(&converter.OpenAIRequest{
+		Tools: []converter.OpenAITool{{Type: "function", Function: converter.OpenAIFunctionDef{Name: "get_weather"}}},
+	})
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	c.Writer.Header().Set("Content-Type", "text/event-stream")
+	writer := newOpenAIToolEmulationWriter(c.Writer, emulation)
+
+	chunk := func(content, finish string) string {
+		return `{"id":"c1","object":"chat.completion.chunk","created":1,"model":"m",` + "\r\ndata: " +
+			`"choices":[{"index":0,"delta":{"content":"` + content + `"},"finish_reason":` + finish + `}]}`
+	}
+	input := ": OPENROUTER PROCESSING\r\r" +
+		"data: " + chunk(`<tool_call>\n{\"name\": \"get_weather\", \"input\": {\"city\": \"Paris\"}}`, "null") + "\r\n\r\n" +
+		"data: " + chunk(`\n</tool_call>`, `"stop"`) + "\r\r" +
+		"data: [DONE]"
+	for i := 0; i < len(input); i += 5 {
+		end := min(i+5, len(input))
+		if _, err := writer.Write([]byte(input[i:end])); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	writer.finish()
+
+	var calls []converter.OpenAIStreamToolCall
+	var finish string
+	for _, block := range strings.Split(rec.Body.String(), "\n\n") {
+		data, found := strings.CutPrefix(block, "data: ")
+		if !found || data == "[DONE]" {
+			continue
+		}
+		var chunk converter.OpenAIStreamChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			t.Fatalf("invalid chunk %q: %v", data, err)
+		}
+		calls = append(calls, chunk.Choices[0].Delta.ToolCalls...)
+		if chunk.Choices[0].FinishReason != nil {
+			finish = *chunk.Choices[0].FinishReason
+		}
+	}
+	if len(calls) != 1 || calls[0].Function.Arguments != `{"city":"Paris"}` || finish != "tool_calls" {
+		t.Fatalf("unexpected converted stream: %s", rec.Body.String())
+	}
+	if strings.Contains(rec.Body.String(), "OPENROUTER") || !strings.HasSuffix(rec.Body.String(), "data: [DONE]\n\n") {
+		t.Fatalf("comments should be dropped and the stream should end with [DONE]: %q", rec.Body.String())
+	}
+}
+
+// 超出 MaxSSEEventSize 的事件应中止流式转换并写出错误事件
+func TestOpenAIToolEmulationWriter_OversizedEvent(t *testing.T) {
+	emulation := converter.EmulateOpenAITools(&converter.OpenAIRequest{
+		Tools: []converter.OpenAITool{{Type: "function", Function: converter.OpenAIFunctionDef{Name: "get_weather"}}},
+	})
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	c.Writer.Header().Set("Content-Type", "text/event-stream")
+	writer := newOpenAIToolEmulationWriter(c.Writer, emulation)
+
+	if _, err := writer.Write([]byte("data: " + strings.Repeat("a", converter.MaxSSEEventSize) + "\n\n")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := writer.Write([]byte("data: [DONE]\n\n")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	writer.finish()
+
+	if !strings.Contains(rec.Body.String(), "sse event exceeds size limit") || strings.Count(rec.Body.String(), "data: [DONE]") != 1 {
+		t.Fatalf("expected a single error event, got %q", rec.Body.String())
+	}
+}
@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/Mieluoxxx/Siriusx-API/internal/models"
+	"github.com/Mieluoxxx/Siriusx-API/internal/provider"
+	"github.com/gin-gonic/gin"
+)
+
+// forwardClaudeViaVertex 将 Claude Messages 请求转发到 Vertex AI 上的 Claude
+// 非流式请求走 rawPredict，流式请求走 streamRawPredict，响应本身即 Claude 格式，直接透传
+func (h *ProxyHandler) forwardClaudeViaVertex(c *gin.Context, prov *models.Provider, targetModel string, req map[string]interface{}) {
+	req = withoutGatewayThinking(req)
+	stream, _ := req["stream"].(bool)
+
+	body, err := json.Marshal(provider.BuildVertexClaudeBody(req, c.GetHeader("anthropic-beta")))
+	if err != nil {
+		log.Printf("❌ [序列化失败] Vertex 请求: %v", err)
+		h.respondClaudeError(c, http.StatusInternalServerError, "api_error", "生成上游请求失败")
+		return
+	}
+
+	targetURL := provider.VertexModelURL(prov, targetModel, stream)
+	log.Printf("➡️  [转发] Claude→Vertex 目标URL: %s, 请求体大小: %d bytes", targetURL, len(body))
+
+	proxyReq, err := http.NewRequestWithContext(c.Request.Context(), "POST", targetURL, bytes.NewReader(body))
+	if err != nil {
+		log.Printf("❌ [转发失败] 创建 Vertex 请求失败: %v", err)
+		h.respondClaudeError(c, http.StatusInternalServerError, "api_error", "创建代理请求失败")
+		return
+	}
+
+	proxyReq.Header.Set("Content-Type", "application/json")
+
+	if err := h.copyForwardHeaders(c, prov, proxyReq.Header); err != nil {
+		log.Printf("❌ [转发失败] Provider: %s, 请求头转发策略无效: %v", prov.Name, err)
+		h.respondClaudeError(c, http.StatusInternalServerError, "api_error", "供应商请求头转发配置无效")
+		return
+	}
+
+	vars := h.templateVars(c, prov, targetModel)
+	if err := provider.ApplyCustomHeaders(proxyReq.Header, prov, vars); err != nil {
+		log.Printf("❌ [转发失败] Provider: %s, 自定义请求头无效: %v", prov.Name, err)
+		h.respondClaudeError(c, http.StatusInternalServerError, "api_error", "供应商自定义请求头配置无效")
+		return
+	}
+
+	if err := provider.AuthorizeRequest(c.Request.Context(), proxyReq, prov, body); err != nil {
+		log.Printf("❌ [转发失败] Provider: %s, 认证失败: %v", prov.Name, err)
+		h.respondClaudeError(c, http.StatusBadGateway, "api_error", fmt.Sprintf("供应商认证失败: %v", err))
+		return
+	}
+
+	client, err := h.httpClient(prov)
+	if err != nil {
+		log.Printf("❌ [转发失败] Provider: %s, 连接配置无效: %v", prov.Name, err)
+		h.respondClaudeError(c, http.StatusInternalServerError, "api_error", fmt.Sprintf("供应商连接配置无效: %v", err))
+		return
+	}
+
+	resp, err := client.Do(proxyReq)
+	if err != nil {
+		log.Printf("❌ [转发失败] Provider: %s, 错误: %v", prov.Name, err)
+		h.respondClaudeError(c, http.StatusBadGateway, "api_error", fmt.Sprintf("请求供应商失败: %v", err))
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		if resp.StatusCode == http.StatusUnauthorized {
+			// 令牌可能已被吊销，下次请求重新换取
+			provider.DefaultGoogleTokenCache().Invalidate(prov.ID)
+		}
+		h.respondGoogleError(c, resp)
+		return
+	}
+
+	if stream {
+		c.Header("Content-Type", "text/event-stream; charset=utf-8")
+		c.Header("Cache-Control", "no-cache")
+		c.Status(http.StatusOK)
+
+		totalBytes, err := pipeStream(c, resp.Body)
+		if err != nil {
+			log.Printf("❌ [流式转发] Vertex: %v", err)
+			return
+		}
+		log.Printf("✅ [完成] Vertex 流式响应转发完成，共 %d bytes", totalBytes)
+		return
+	}
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		log.Printf("❌ [响应失败] 读取 Vertex 响应体失败: %v", err)
+		h.respondClaudeError(c, http.StatusInternalServerError, "api_error", "读取上游响应失败")
+		return
+	}
+
+	c.Data(resp.StatusCode, "application/json", respBody)
+	log.Printf("✅ [完成] Vertex 非流式响应，状态: %d, 响应体大小: %d bytes", resp.StatusCode, len(respBody))
+}
+
+// respondGoogleError 将 Google API（Vertex / Gemini）错误响应转换为 Claude 错误格式
+// 网关错误为 Google 格式 {"error":{"code","message","status"}}；Vertex 上 Claude 模型本身的错误为 Claude 格式，直接透传
+func (h *ProxyHandler) respondGoogleError(c *gin.Context, resp *http.Response) {
+	respBody, _ := io.ReadAll(resp.Body)
+
+	var claudeErr struct {
+		Type  string `json:"type"`
+		Error struct {
+			Type    string `json:"type"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	_ = json.Unmarshal(respBody, &claudeErr)
+
+	// Claude 格式的错误直接透传
+	if claudeErr.Type == "error" && claudeErr.Error.Type != "" {
+		log.Printf("❌ [Google 错误响应] 状态: %d, 类型: %s, 内容: %s", resp.StatusCode, claudeErr.Error.Type, claudeErr.Error.Message)
+		c.Data(resp.StatusCode, "application/json", respBody)
+		return
+	}
+
+	status, message := parseGoogleError(respBody)
+	log.Printf("❌ [Google 错误响应] 状态: %d, 类型: %s, 内容: %s", resp.StatusCode, status, message)
+	h.respondClaudeError(c, resp.StatusCode, googleErrorType(resp.StatusCode, status), message)
+}
+
+// googleErrorType 将 Google API 错误状态映射为 Claude 错误类型
+func googleErrorType(statusCode int, status string) string {
+	switch status {
+	case "INVALID_ARGUMENT", "FAILED_PRECONDITION", "OUT_OF_RANGE":
+		return "invalid_request_error"
+	case "UNAUTHENTICATED":
+		return "authentication_error"
+	case "PERMISSION_DENIED":
+		return "permission_error"
+	case "NOT_FOUND":
+		return "not_found_error"
+	case "RESOURCE_EXHAUSTED":
+		return "rate_limit_error"
+	case "UNAVAILABLE":
+		return "overloaded_error"
+	}
+	switch {
+	case statusCode == http.StatusTooManyRequests:
+		return "rate_limit_error"
+	case statusCode >= 500:
+		return "api_error"
+	}
+	return "invalid_request_error"
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Mieluoxxx/Siriusx-API/internal/models"
+	"github.com/Mieluoxxx/Siriusx-API/internal/provider"
+	"github.com/gin-gonic/gin"
+)
+
+const vertexTestModel = "claude-3-5-sonnet@20240620"
+
+// newVertexHandlerStandIn 模拟 Google 令牌端点与 Vertex rawPredict / streamRawPredict 接口
+func newVertexHandlerStandIn(t *testing.T, captured *map[string]interface{}) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"access_token":"ya29.vertex","expires_in":3600}`))
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer ya29.vertex" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error":{"code":401,"message":"Request had invalid authentication credentials.","status":"UNAUTHENTICATED"}}`))
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(body, captured)
+
+		prefix := "/v1/projects/test-project/locations/us-east5/publishers/anthropic/models/"
+		switch r.URL.Path {
+		case prefix + vertexTestModel + ":rawPredict":
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"id":"msg_vrtx","type":"message","role":"assistant","content":[{"type":"text","text":"pong"}],"stop_reason":"end_turn","usage":{"input_tokens":3,"output_tokens":1}}`))
+		case prefix + vertexTestModel + ":streamRawPredict":
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = w.Write([]byte("event: message_start\ndata: {\"type\":\"message_start\"}\n\n" +
+				"event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"text_delta\",\"text\":\"pong\"}}\n\n" +
+				"event: message_stop\ndata: {\"type\":\"message_stop\"}\n\n"))
+		case prefix + "claude-overloaded:rawPredict":
+			w.WriteHeader(http.StatusTooManyRequests)
+			_, _ = w.Write([]byte(`{"error":{"code":429,"message":"Quota exceeded","status":"RESOURCE_EXHAUSTED"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func newVertexTestProvider(t *testing.T, baseURL string) *models.Provider {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	der, _ := x509.MarshalPKCS8PrivateKey(key)
+	sa, _ := json.Marshal(map[string]string{
+		"type":         "service_account",
+		"project_id":   "test-project",
+		"private_key":  string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		"client_email": "gateway@test-project.iam.gserviceaccount.com",
+		"token_uri":    baseURL + "/token",
+	})
+	return &models.Provider{
+		Name:           "vertex",
+		Type:           provider.ProviderTypeVertex,
+		BaseURL:        baseURL,
+		APIKey:         string(sa),
+		VertexLocation: "us-east5",
+	}
+}
+
+func TestForwardClaudeViaVertex(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var captured map[string]interface{}
+	server := newVertexHandlerStandIn(t, &captured)
+	defer server.Close()
+	prov := newVertexTestProvider(t, server.URL)
+
+	tests := []struct {
+		name        string
+		model       string
+		stream      bool
+		status      int
+		contains    string
+		contentType string
+	}{
+		{"rawPredict", vertexTestModel, false, http.StatusOK, `"text":"pong"`, "application/json"},
+		{"streamRawPredict", vertexTestModel, true, http.StatusOK, "event: content_block_delta\n", "text/event-stream"},
+		{"google error", "claude-overloaded", false, http.StatusTooManyRequests, `"type":"rate_limit_error"`, "application/json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			captured = nil
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest("POST", "/v1/messages", nil)
+
+			req := newBedrockTestRequest(tt.stream)
+			req["model"] = tt.model
+
+			handler := &ProxyHandler{}
+			handler.forwardClaudeViaVertex(c, prov, tt.model, req)
+
+			if w.Code != tt.status {
+				t.Fatalf("unexpected status code: %d, body: %s", w.Code, w.Body.String())
+			}
+			if !strings.HasPrefix(w.Header().Get("Content-Type"), tt.contentType) {
+				t.Fatalf("expected content type %s, got %s", tt.contentType, w.Header().Get("Content-Type"))
+			}
+			if !strings.Contains(w.Body.String(), tt.contains) {
+				t.Fatalf("expected body to contain %q, got:\n%s", tt.contains, w.Body.String())
+			}
+			if captured["anthropic_version"] != provider.VertexAnthropicVersion {
+				t.Fatalf("expected vertex anthropic_version in body, got %v", captured["anthropic_version"])
+			}
+			if _, exists := captured["model"]; exists {
+				t.Fatalf("model should be carried by the URL, not the body")
+			}
+			if stream, _ := captured["stream"].(bool); stream != tt.stream {
+				t.Fatalf("expected stream=%v in body, got %v", tt.stream, captured["stream"])
+			}
+		})
+	}
+}
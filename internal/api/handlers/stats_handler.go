@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ToolArgumentRepairStats 工具调用参数修复统计
+type ToolArgumentRepairStats struct {
+	Repaired int64            `json:"repaired"` // 修复成功次数
+	Failed   int64            `json:"failed"`   // 无法修复而转换失败的次数
+	Kinds    map[string]int64 `json:"kinds"`    // 按修复类型统计的次数
+}
+
+// ToolArgumentStats 进程启动以来的工具调用参数修复统计，实现 converter.ToolArgumentRecorder
+// 由代理处理器在转换上游响应时记录，运行统计处理器读取
+type ToolArgumentStats struct {
+	mu    sync.Mutex
+	stats ToolArgumentRepairStats
+}
+
+// NewToolArgumentStats 创建工具调用参数修复统计
+func NewToolArgumentStats() *ToolArgumentStats {
+	return &ToolArgumentStats{stats: ToolArgumentRepairStats{Kinds: make(map[string]int64)}}
+}
+
+// RecordToolArgumentRepair 记录一次修复结果
+func (s *ToolArgumentStats) RecordToolArgumentRepair(kinds []string, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !ok {
+		s.stats.Failed++
+		return
+	}
+	s.stats.Repaired++
+	for _, kind := range kinds {
+		s.stats.Kinds[kind]++
+	}
+}
+
+// Snapshot 获取当前统计的副本
+func (s *ToolArgumentStats) Snapshot() ToolArgumentRepairStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := s.stats
+	stats.Kinds = make(map[string]int64, len(s.stats.Kinds))
+	for kind, count := range s.stats.Kinds {
+		stats.Kinds[kind] = count
+	}
+	return stats
+}
+
+// StatsHandler 运行统计处理器
+type StatsHandler struct {
+	toolArguments *ToolArgumentStats
+}
+
+// NewStatsHandler 创建运行统计处理器
+func NewStatsHandler(toolArguments *ToolArgumentStats) *StatsHandler {
+	return &StatsHandler{toolArguments: toolArguments}
+}
+
+// GetToolArgumentRepairs 获取上游工具调用参数修复统计（修复成功、失败次数与各修复类型次数）
+// @Summary 获取工具调用参数修复统计
+// @Tags stats
+// @Produce json
+// @Success 200 {object} handlers.ToolArgumentRepairStats
+// @Router /api/stats/tool-arguments [get]
+func (h *StatsHandler) GetToolArgumentRepairs(c *gin.Context) {
+	c.JSON(http.StatusOK, h.toolArguments.Snapshot())
+}
@@ -49,6 +49,12 @@ func (h *TokenHandler) CreateToken(c *gin.Context) {
 		h.handleTokenError(c, err)
 		return
 	}
+	if req.ResponseCache {
+		if tok, err = h.service.SetResponseCache(tok.ID, true); err != nil {
+			h.handleTokenError(c, err)
+			return
+		}
+	}
 
 	// 返回响应（包含完整 Token，仅此一次）
 	dto := token.ToTokenDTO(tok, true)
@@ -145,6 +151,50 @@ func (h *TokenHandler) DeleteToken(c *gin.Context) {
 	c.Status(http.StatusNoContent)
 }
 
+// UpdateTokenResponseCache 开启/关闭 Token 的响应缓存
+// @Summary 开启/关闭 Token 的响应缓存
+// @Tags tokens
+// @Accept json
+// @Produce json
+// @Param id path int true "Token ID"
+// @Param request body ToggleEnabledRequest true "启用状态"
+// @Success 200 {object} token.TokenDTO
+// @Failure 404 {object} ErrorResponse
+// @Router /api/tokens/{id}/response-cache [patch]
+func (h *TokenHandler) UpdateTokenResponseCache(c *gin.Context) {
+	// 解析 ID
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "INVALID_ID",
+				"message": "Invalid token ID",
+			},
+		})
+		return
+	}
+
+	var req ToggleEnabledRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Invalid request parameters",
+				"details": err.Error(),
+			},
+		})
+		return
+	}
+
+	tok, err := h.service.SetResponseCache(uint(id), req.Enabled)
+	if err != nil {
+		h.handleTokenError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, token.ToTokenDTO(tok, false))
+}
+
 // handleTokenError 处理 Token 相关错误
 func (h *TokenHandler) handleTokenError(c *gin.Context, err error) {
 	switch {
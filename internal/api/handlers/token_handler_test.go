@@ -3,6 +3,7 @@ package handlers
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -45,6 +46,7 @@ func setupTokenTestHandler(t *testing.T) (*gin.Engine, *token.Service, *gorm.DB)
 			tokens.POST("", handler.CreateToken)
 			tokens.GET("", handler.ListTokens)
 			tokens.DELETE("/:id", handler.DeleteToken)
+			tokens.PATCH("/:id/response-cache", handler.UpdateTokenResponseCache)
 		}
 	}
 
@@ -270,3 +272,41 @@ func TestTokenHandler_DeleteToken_InvalidID(t *testing.T) {
 		t.Errorf("Expected error code INVALID_ID, got %v", errorData["code"])
 	}
 }
+
+// TestTokenHandler_ResponseCache 测试创建时开启与单独切换响应缓存
+func TestTokenHandler_ResponseCache(t *testing.T) {
+	router, service, _ := setupTokenTestHandler(t)
+
+	body, _ := json.Marshal(token.CreateTokenRequest{Name: "Eval Token", ResponseCache: true})
+	req, _ := http.NewRequest("POST", "/api/tokens", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	var created token.TokenDTO
+	json.Unmarshal(resp.Body.Bytes(), &created)
+	if resp.Code != http.StatusCreated || !created.ResponseCache {
+		t.Fatalf("Expected token with response cache enabled, got %d: %s", resp.Code, resp.Body.String())
+	}
+
+	req, _ = http.NewRequest("PATCH", fmt.Sprintf("/api/tokens/%d/response-cache", created.ID), bytes.NewBufferString(`{"enabled":false}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d. Body: %s", resp.Code, resp.Body.String())
+	}
+	stored, err := service.GetToken(created.ID)
+	if err != nil || stored.ResponseCache {
+		t.Fatalf("Expected response cache disabled, got %+v (err: %v)", stored, err)
+	}
+
+	req, _ = http.NewRequest("PATCH", "/api/tokens/999/response-cache", bytes.NewBufferString(`{"enabled":true}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	if resp.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %d", resp.Code)
+	}
+}
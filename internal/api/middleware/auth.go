@@ -11,11 +11,22 @@ import (
 
 // TokenAuthMiddleware Token 验证中间件
 // 用于验证 API 请求中的 Bearer Token
+// 未携带 Authorization 头时，兼容 Gemini API 客户端的 x-goog-api-key 请求头与 ?key= 查询参数
 func TokenAuthMiddleware(tokenService *token.Service) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// 1. 提取 Authorization 头
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
+			// Gemini API 客户端（google-genai SDK、Gemini CLI）的认证方式
+			tokenValue := strings.TrimSpace(c.GetHeader("x-goog-api-key"))
+			if tokenValue == "" {
+				tokenValue = strings.TrimSpace(c.Query("key"))
+			}
+			if tokenValue != "" {
+				validateToken(c, tokenService, tokenValue)
+				return
+			}
+
 			c.JSON(http.StatusUnauthorized, gin.H{
 				"error": gin.H{
 					"code":    "MISSING_AUTH_HEADER",
@@ -39,22 +50,25 @@ func TokenAuthMiddleware(tokenService *token.Service) gin.HandlerFunc {
 			return
 		}
 
-		tokenValue := parts[1]
+		validateToken(c, tokenService, parts[1])
+	}
+}
 
-		// 3. 验证 Token
-		tok, err := tokenService.ValidateToken(tokenValue)
-		if err != nil {
-			handleAuthError(c, err)
-			c.Abort()
-			return
-		}
+// validateToken 验证 Token 并将 Token 信息存入 Context
+func validateToken(c *gin.Context, tokenService *token.Service, tokenValue string) {
+	// 3. 验证 Token
+	tok, err := tokenService.ValidateToken(tokenValue)
+	if err != nil {
+		handleAuthError(c, err)
+		c.Abort()
+		return
+	}
 
-		// 4. 将 Token 信息存入 Context
-		c.Set("token_id", tok.ID)
-		c.Set("token", tok)
+	// 4. 将 Token 信息存入 Context
+	c.Set("token_id", tok.ID)
+	c.Set("token", tok)
 
-		c.Next()
-	}
+	c.Next()
 }
 
 // handleAuthError 处理认证错误
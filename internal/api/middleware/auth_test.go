@@ -223,6 +223,55 @@ func TestTokenAuthMiddleware_ContextData(t *testing.T) {
 	}
 }
 
+// TestTokenAuthMiddleware_GeminiCredentials 测试 Gemini 客户端的 x-goog-api-key 请求头与 ?key= 查询参数
+func TestTokenAuthMiddleware_GeminiCredentials(t *testing.T) {
+	router, service, _ := setupAuthTestEnv(t)
+
+	tok, _ := service.CreateToken("Gemini Token", nil, "")
+
+	tests := []struct {
+		name     string
+		path     string
+		header   string
+		wantCode int
+	}{
+		{"x-goog-api-key", "/protected/resource", tok.Token, http.StatusOK},
+		{"query key", "/protected/resource?key=" + tok.Token, "", http.StatusOK},
+		{"invalid query key", "/protected/resource?key=sk-invalid", "", http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", tt.path, nil)
+			if tt.header != "" {
+				req.Header.Set("x-goog-api-key", tt.header)
+			}
+			resp := httptest.NewRecorder()
+
+			router.ServeHTTP(resp, req)
+
+			if resp.Code != tt.wantCode {
+				t.Errorf("Expected status %d, got %d. Body: %s", tt.wantCode, resp.Code, resp.Body.String())
+			}
+		})
+	}
+}
+
+// TestRedactQueryKey 测试访问日志隐藏 ?key= 中的 Token
+func TestRedactQueryKey(t *testing.T) {
+	tests := map[string]string{
+		"/v1/messages": "/v1/messages",
+		"/v1beta/models/gemini:streamGenerateContent?alt=sse&key=sk-secret": "/v1beta/models/gemini:streamGenerateContent?alt=sse&key=REDACTED",
+		"/v1beta/models/gemini:generateContent?alt=json":                    "/v1beta/models/gemini:generateContent?alt=json",
+	}
+
+	for path, want := range tests {
+		if got := redactQueryKey(path); got != want {
+			t.Errorf("redactQueryKey(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
 // contains 检查字符串是否包含子串
 func contains(s, substr string) bool {
 	return strings.Contains(s, substr)
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AccessLogger 访问日志中间件
+// 输出格式与 gin 默认日志一致，但会隐藏 ?key= 查询参数中的 Token（Gemini API 客户端的认证方式）
+func AccessLogger() gin.HandlerFunc {
+	return gin.LoggerWithFormatter(func(param gin.LogFormatterParams) string {
+		var statusColor, methodColor, resetColor string
+		if param.IsOutputColor() {
+			statusColor = param.StatusCodeColor()
+			methodColor = param.MethodColor()
+			resetColor = param.ResetColor()
+		}
+
+		if param.Latency > time.Minute {
+			param.Latency = param.Latency.Truncate(time.Second)
+		}
+		return fmt.Sprintf("[GIN] %v |%s %3d %s| %13v | %15s |%s %-7s %s %#v\n%s",
+			param.TimeStamp.Format("2006/01/02 - 15:04:05"),
+			statusColor, param.StatusCode, resetColor,
+			param.Latency,
+			param.ClientIP,
+			methodColor, param.Method, resetColor,
+			redactQueryKey(param.Path),
+			param.ErrorMessage,
+		)
+	})
+}
+
+// redactQueryKey 将路径中 key 查询参数的值替换为 REDACTED
+func redactQueryKey(path string) string {
+	base, rawQuery, found := strings.Cut(path, "?")
+	if !found {
+		return path
+	}
+
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil || !query.Has("key") {
+		return path
+	}
+	query.Set("key", "REDACTED")
+	return base + "?" + query.Encode()
+}
@@ -3,8 +3,10 @@ package api
 import (
 	"github.com/Mieluoxxx/Siriusx-API/internal/api/handlers"
 	"github.com/Mieluoxxx/Siriusx-API/internal/api/middleware"
+	"github.com/Mieluoxxx/Siriusx-API/internal/cache"
 	"github.com/Mieluoxxx/Siriusx-API/internal/mapping"
 	"github.com/Mieluoxxx/Siriusx-API/internal/provider"
+	"github.com/Mieluoxxx/Siriusx-API/internal/responses"
 	"github.com/Mieluoxxx/Siriusx-API/internal/token"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -13,15 +15,16 @@ import (
 
 // SetupRouter 配置路由
 func SetupRouter(db *gorm.DB, encryptionKey []byte) *gin.Engine {
-	// 创建 Gin 引擎
-	router := gin.Default()
+	// 创建 Gin 引擎（访问日志隐藏 ?key= 中的 Token）
+	router := gin.New()
+	router.Use(middleware.AccessLogger(), gin.Recovery())
 
 	// 配置 CORS 中间件
 	router.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"http://localhost:3000", "http://localhost:4321", "http://localhost:5173"},
 		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
 		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", "Accept"},
-		ExposeHeaders:    []string{"Content-Length"},
+		ExposeHeaders:    []string{"Content-Length", "X-Cache"},
 		AllowCredentials: true,
 	}))
 
@@ -33,10 +36,17 @@ func SetupRouter(db *gorm.DB, encryptionKey []byte) *gin.Engine {
 		})
 	})
 
-	// OpenAI 兼容的 API 路由
+	// 响应缓存（代理路由与管理 API 共享）
+	responseCache := cache.NewResponseCache(cache.LoadConfigFromEnv(), cache.NewRepository(db))
+
+	// 工具调用参数修复统计（代理路由记录，运行统计 API 读取）
+	toolArguments := handlers.NewToolArgumentStats()
+
+	// OpenAI / Claude 兼容的 API 路由（/v1）与 Gemini 兼容的 API 路由（/v1beta）
 	v1Group := router.Group("/v1")
+	v1betaGroup := router.Group("/v1beta")
 	{
-		setupProxyRoutes(v1Group, db, encryptionKey)
+		setupProxyRoutes(v1Group, v1betaGroup, db, encryptionKey, responseCache, toolArguments)
 	}
 
 	// API 路由组
@@ -50,13 +60,19 @@ func SetupRouter(db *gorm.DB, encryptionKey []byte) *gin.Engine {
 
 		// Token API
 		setupTokenRoutes(apiGroup, db)
+
+		// 响应缓存 API
+		setupCacheRoutes(apiGroup, responseCache)
+
+		// 运行统计 API
+		setupStatsRoutes(apiGroup, toolArguments)
 	}
 
 	return router
 }
 
 // setupProxyRoutes 配置代理路由
-func setupProxyRoutes(group *gin.RouterGroup, db *gorm.DB, encryptionKey []byte) {
+func setupProxyRoutes(group, geminiGroup *gin.RouterGroup, db *gorm.DB, encryptionKey []byte, responseCache *cache.ResponseCache, toolArguments *handlers.ToolArgumentStats) {
 	// 创建依赖
 	providerRepo := provider.NewRepository(db)
 	var providerService *provider.Service
@@ -72,8 +88,10 @@ func setupProxyRoutes(group *gin.RouterGroup, db *gorm.DB, encryptionKey []byte)
 	tokenRepo := token.NewRepository(db)
 	tokenService := token.NewService(tokenRepo)
 
+	responseService := responses.NewService(responses.NewRepository(db))
+
 	// 创建代理处理器
-	proxyHandler := handlers.NewProxyHandler(providerService, mappingRouter)
+	proxyHandler := handlers.NewProxyHandler(providerService, mappingRouter, responseService, responseCache, toolArguments)
 
 	// 注册路由（需要 Token 验证）
 	group.POST("/chat/completions",
@@ -90,6 +108,32 @@ func setupProxyRoutes(group *gin.RouterGroup, db *gorm.DB, encryptionKey []byte)
 		middleware.TokenAuthMiddleware(tokenService),
 		proxyHandler.MessagesCountTokens,
 	)
+
+	group.POST("/responses",
+		middleware.TokenAuthMiddleware(tokenService),
+		proxyHandler.Responses,
+	)
+
+	group.POST("/embeddings",
+		middleware.TokenAuthMiddleware(tokenService),
+		proxyHandler.Embeddings,
+	)
+
+	group.POST("/completions",
+		middleware.TokenAuthMiddleware(tokenService),
+		proxyHandler.Completions,
+	)
+
+	group.POST("/images/generations",
+		middleware.TokenAuthMiddleware(tokenService),
+		proxyHandler.ImageGenerations,
+	)
+
+	// Gemini generateContent / streamGenerateContent（路径形如 /models/{model}:generateContent）
+	geminiGroup.POST("/models/*action",
+		middleware.TokenAuthMiddleware(tokenService),
+		proxyHandler.GenerateContent,
+	)
 }
 
 // setupProviderRoutes 配置供应商路由
@@ -175,5 +219,23 @@ func setupTokenRoutes(group *gin.RouterGroup, db *gorm.DB) {
 		tokens.GET("", handler.ListTokens)
 		tokens.GET("/:id", handler.GetToken) // 获取单个 Token（包含完整值）
 		tokens.DELETE("/:id", handler.DeleteToken)
+
+		// 开启/关闭响应缓存
+		tokens.PATCH("/:id/response-cache", handler.UpdateTokenResponseCache)
 	}
 }
+
+// setupCacheRoutes 配置响应缓存路由
+func setupCacheRoutes(group *gin.RouterGroup, responseCache *cache.ResponseCache) {
+	handler := handlers.NewCacheHandler(responseCache)
+
+	group.GET("/cache/stats", handler.GetStats)
+	group.DELETE("/cache", handler.ClearCache)
+}
+
+// setupStatsRoutes 配置运行统计路由
+func setupStatsRoutes(group *gin.RouterGroup, toolArguments *handlers.ToolArgumentStats) {
+	handler := handlers.NewStatsHandler(toolArguments)
+
+	group.GET("/stats/tool-arguments", handler.GetToolArgumentRepairs)
+}
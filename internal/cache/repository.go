@@ -0,0 +1,55 @@
+package cache
+
+import (
+	"errors"
+	"time"
+
+	"github.com/Mieluoxxx/Siriusx-API/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Repository 响应缓存持久化数据访问层
+type Repository struct {
+	db *gorm.DB
+}
+
+// NewRepository 创建 Repository 实例
+func NewRepository(db *gorm.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// Find 查找未过期的缓存条目，不存在或已过期时返回 nil
+func (r *Repository) Find(key string, now time.Time) (*models.CachedResponse, error) {
+	var entry models.CachedResponse
+	err := r.db.Where("key = ? AND expires_at > ?", key, now).First(&entry).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// Save 保存缓存条目（键已存在时覆盖）
+func (r *Repository) Save(entry *models.CachedResponse) error {
+	return r.db.Clauses(clause.OnConflict{UpdateAll: true}).Create(entry).Error
+}
+
+// Prune 删除过期条目，并只保留最新的 maxEntries 条
+func (r *Repository) Prune(now time.Time, maxEntries int) error {
+	if err := r.db.Where("expires_at <= ?", now).Delete(&models.CachedResponse{}).Error; err != nil {
+		return err
+	}
+	if maxEntries <= 0 {
+		return nil
+	}
+	keep := r.db.Model(&models.CachedResponse{}).Select("key").Order("created_at DESC").Limit(maxEntries)
+	return r.db.Where("key NOT IN (?)", keep).Delete(&models.CachedResponse{}).Error
+}
+
+// Clear 删除全部条目
+func (r *Repository) Clear() error {
+	return r.db.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&models.CachedResponse{}).Error
+}
@@ -0,0 +1,279 @@
+// Package cache 提供按请求精确匹配的响应缓存
+// 相同的确定性请求（如 temperature 0 的评测提示词）重复发送时直接返回缓存的响应，不再请求上游
+package cache
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Mieluoxxx/Siriusx-API/internal/models"
+)
+
+// Config 响应缓存配置
+type Config struct {
+	TTL        time.Duration // 条目有效期
+	MaxEntries int           // 最大条目数（LRU 淘汰）
+	MaxBytes   int64         // 内存中响应体总大小上限，单个响应超过该值时不缓存
+	Persist    bool          // 是否持久化到 SQLite（重启后仍可命中）
+}
+
+// DefaultConfig 默认配置：10 分钟、1000 条、64 MiB，仅内存
+func DefaultConfig() *Config {
+	return &Config{
+		TTL:        10 * time.Minute,
+		MaxEntries: 1000,
+		MaxBytes:   64 << 20,
+	}
+}
+
+// LoadConfigFromEnv 从环境变量读取配置，未设置或无效时使用默认值
+// RESPONSE_CACHE_TTL（如 30m）、RESPONSE_CACHE_MAX_ENTRIES、RESPONSE_CACHE_MAX_BYTES、RESPONSE_CACHE_PERSIST（true/false）
+func LoadConfigFromEnv() *Config {
+	config := DefaultConfig()
+	if ttl, err := time.ParseDuration(os.Getenv("RESPONSE_CACHE_TTL")); err == nil && ttl > 0 {
+		config.TTL = ttl
+	}
+	if maxEntries, err := strconv.Atoi(os.Getenv("RESPONSE_CACHE_MAX_ENTRIES")); err == nil && maxEntries > 0 {
+		config.MaxEntries = maxEntries
+	}
+	if maxBytes, err := strconv.ParseInt(os.Getenv("RESPONSE_CACHE_MAX_BYTES"), 10, 64); err == nil && maxBytes > 0 {
+		config.MaxBytes = maxBytes
+	}
+	if persist, err := strconv.ParseBool(os.Getenv("RESPONSE_CACHE_PERSIST")); err == nil {
+		config.Persist = persist
+	}
+	return config
+}
+
+// Stats 响应缓存统计
+type Stats struct {
+	Entries   int           `json:"entries"`   // 内存中的条目数
+	Bytes     int64         `json:"bytes"`     // 内存中的响应体总大小
+	Hits      int64         `json:"hits"`      // 命中次数
+	Misses    int64         `json:"misses"`    // 未命中次数
+	Bypasses  int64         `json:"bypasses"`  // 客户端 Cache-Control 跳过缓存的次数
+	Stores    int64         `json:"stores"`    // 写入次数
+	Evictions int64         `json:"evictions"` // 因容量上限淘汰的次数
+	HitRate   float64       `json:"hit_rate"`  // 命中率（hits / (hits + misses)）
+	TTL       time.Duration `json:"ttl"`
+	Persist   bool          `json:"persist"`
+}
+
+// entry 内存缓存条目
+type entry struct {
+	key       string
+	body      []byte
+	expiresAt time.Time
+}
+
+// ResponseCache 精确匹配响应缓存：内存 LRU，可选 SQLite 持久化（写穿透，内存未命中时回查）
+// mu 只保护内存状态，SQLite 读写在锁外进行，持久化条目的清理在后台执行
+type ResponseCache struct {
+	mu         sync.Mutex
+	config     *Config
+	items      map[string]*list.Element
+	lru        *list.List // 队首为最近使用
+	bytes      int64
+	repo       *Repository // 为 nil 时仅使用内存
+	lastPruned time.Time
+	pruning    sync.WaitGroup // 进行中的后台清理
+	stats      Stats
+	now        func() time.Time
+}
+
+// NewResponseCache 创建响应缓存，config.Persist 为 false 或 repo 为 nil 时不持久化
+func NewResponseCache(config *Config, repo *Repository) *ResponseCache {
+	if config == nil {
+		config = DefaultConfig()
+	}
+	if !config.Persist {
+		repo = nil
+	}
+	return &ResponseCache{
+		config: config,
+		items:  make(map[string]*list.Element),
+		lru:    list.New(),
+		repo:   repo,
+		now:    time.Now,
+	}
+}
+
+// Key 计算缓存键：请求类型、统一模型、Token 与规范化请求体（键排序的 JSON）的 SHA-256
+// stream 与 stream_options 不参与计算，流式请求可以命中非流式请求写入的缓存
+func Key(kind, model string, tokenID uint, req map[string]interface{}) (string, error) {
+	canonical := make(map[string]interface{}, len(req))
+	for key, value := range req {
+		if key == "stream" || key == "stream_options" {
+			continue
+		}
+		canonical[key] = value
+	}
+	body, err := json.Marshal(canonical)
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.New()
+	hash.Write([]byte(kind + "\n" + model + "\n" + strconv.FormatUint(uint64(tokenID), 10) + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// Get 查找缓存的响应体
+func (c *ResponseCache) Get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	now := c.now()
+	if element, ok := c.items[key]; ok {
+		item := element.Value.(*entry)
+		if now.Before(item.expiresAt) {
+			c.lru.MoveToFront(element)
+			c.stats.Hits++
+			c.mu.Unlock()
+			return item.body, true
+		}
+		c.removeElement(element)
+	}
+	if c.repo == nil {
+		c.stats.Misses++
+		c.mu.Unlock()
+		return nil, false
+	}
+	c.mu.Unlock()
+
+	// 内存未命中时在锁外回查 SQLite
+	stored, err := c.repo.Find(key, now)
+	if err != nil {
+		log.Printf("⚠️  [响应缓存] 读取持久化条目失败: %v", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if stored == nil {
+		c.stats.Misses++
+		return nil, false
+	}
+	body := []byte(stored.Body)
+	c.insert(key, body, stored.ExpiresAt)
+	c.stats.Hits++
+	return body, true
+}
+
+// Set 写入响应体，超过 MaxBytes 的响应不缓存
+func (c *ResponseCache) Set(key string, body []byte) {
+	if int64(len(body)) > c.config.MaxBytes {
+		return
+	}
+
+	c.mu.Lock()
+	now := c.now()
+	expiresAt := now.Add(c.config.TTL)
+	c.insert(key, body, expiresAt)
+	c.stats.Stores++
+	// 每个 TTL 周期清理一次过期与超出上限的持久化条目
+	prune := c.repo != nil && now.Sub(c.lastPruned) >= c.config.TTL
+	if prune {
+		c.lastPruned = now
+	}
+	c.mu.Unlock()
+
+	if c.repo == nil {
+		return
+	}
+	if err := c.repo.Save(&models.CachedResponse{Key: key, Body: string(body), ExpiresAt: expiresAt, CreatedAt: now}); err != nil {
+		log.Printf("⚠️  [响应缓存] 持久化失败: %v", err)
+		return
+	}
+	if prune {
+		c.pruning.Add(1)
+		go func() {
+			defer c.pruning.Done()
+			if err := c.repo.Prune(now, c.config.MaxEntries); err != nil {
+				log.Printf("⚠️  [响应缓存] 清理持久化条目失败: %v", err)
+			}
+		}()
+	}
+}
+
+// RecordBypass 记录一次客户端要求跳过缓存的请求
+func (c *ResponseCache) RecordBypass() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stats.Bypasses++
+}
+
+// MaxBytes 单个响应可缓存的最大大小
+func (c *ResponseCache) MaxBytes() int64 {
+	return c.config.MaxBytes
+}
+
+// Clear 清空缓存（含持久化条目），统计计数保留
+func (c *ResponseCache) Clear() error {
+	c.mu.Lock()
+	c.items = make(map[string]*list.Element)
+	c.lru.Init()
+	c.bytes = 0
+	c.mu.Unlock()
+
+	if c.repo != nil {
+		return c.repo.Clear()
+	}
+	return nil
+}
+
+// Stats 获取缓存统计
+func (c *ResponseCache) Stats() *Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := c.stats
+	stats.Entries = c.lru.Len()
+	stats.Bytes = c.bytes
+	stats.TTL = c.config.TTL
+	stats.Persist = c.repo != nil
+	if total := stats.Hits + stats.Misses; total > 0 {
+		stats.HitRate = float64(stats.Hits) / float64(total)
+	}
+	return &stats
+}
+
+// insert 写入内存并按条目数与总大小淘汰最久未使用的条目（调用方持有锁）
+func (c *ResponseCache) insert(key string, body []byte, expiresAt time.Time) {
+	if element, ok := c.items[key]; ok {
+		c.removeElement(element)
+	}
+	c.items[key] = c.lru.PushFront(&entry{key: key, body: body, expiresAt: expiresAt})
+	c.bytes += int64(len(body))
+
+	for c.lru.Len() > c.config.MaxEntries || c.bytes > c.config.MaxBytes {
+		c.removeElement(c.lru.Back())
+		c.stats.Evictions++
+	}
+}
+
+func (c *ResponseCache) removeElement(element *list.Element) {
+	item := c.lru.Remove(element).(*entry)
+	delete(c.items, item.key)
+	c.bytes -= int64(len(item.body))
+}
+
+// ParseCacheControl 解析客户端 Cache-Control 请求头
+// no-cache：跳过查找但仍写入新响应；no-store：既不查找也不写入
+func ParseCacheControl(header string) (noCache, noStore bool) {
+	for _, directive := range strings.Split(header, ",") {
+		switch strings.ToLower(strings.TrimSpace(directive)) {
+		case "no-cache":
+			noCache = true
+		case "no-store":
+			noStore = true
+		}
+	}
+	return noCache, noStore
+}
@@ -0,0 +1,224 @@
+package cache
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Mieluoxxx/Siriusx-API/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func newTestRepository(t *testing.T) *Repository {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.CachedResponse{}))
+	return NewRepository(db)
+}
+
+// fakeClock 可手动推进的时钟
+type fakeClock struct{ now time.Time }
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (f *fakeClock) Now() time.Time { return f.now }
+
+func (f *fakeClock) Advance(d time.Duration) { f.now = f.now.Add(d) }
+
+// withClock 让缓存使用测试时钟
+func withClock(c *ResponseCache, clock *fakeClock) *ResponseCache {
+	c.now = clock.Now
+	return c
+}
+
+func TestKey(t *testing.T) {
+	req := map[string]interface{}{"model": "m", "temperature": 0.0, "messages": []interface{}{"hi"}}
+	key, err := Key("chat", "m", 1, req)
+	require.NoError(t, err)
+
+	// 字段顺序与 stream 参数不影响缓存键
+	streamKey, err := Key("chat", "m", 1, map[string]interface{}{"messages": []interface{}{"hi"}, "temperature": 0, "model": "m", "stream": true, "stream_options": map[string]interface{}{"include_usage": true}})
+	require.NoError(t, err)
+	assert.Equal(t, key, streamKey)
+
+	for _, other := range []struct {
+		kind, model string
+		tokenID     uint
+		req         map[string]interface{}
+	}{
+		{"messages", "m", 1, req},
+		{"chat", "other", 1, req},
+		{"chat", "m", 2, req},
+		{"chat", "m", 1, map[string]interface{}{"model": "m", "temperature": 0.5, "messages": []interface{}{"hi"}}},
+	} {
+		otherKey, err := Key(other.kind, other.model, other.tokenID, other.req)
+		require.NoError(t, err)
+		assert.NotEqual(t, key, otherKey)
+	}
+}
+
+func TestResponseCache_TTLAndStats(t *testing.T) {
+	clock := newFakeClock()
+	c := withClock(NewResponseCache(&Config{TTL: time.Minute, MaxEntries: 10, MaxBytes: 1024}, nil), clock)
+
+	_, ok := c.Get("a")
+	assert.False(t, ok)
+
+	c.Set("a", []byte(`{"id":1}`))
+	body, ok := c.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, `{"id":1}`, string(body))
+
+	clock.Advance(time.Minute)
+	_, ok = c.Get("a")
+	assert.False(t, ok, "expired entries should miss")
+
+	c.RecordBypass()
+	stats := c.Stats()
+	assert.Equal(t, int64(1), stats.Hits)
+	assert.Equal(t, int64(2), stats.Misses)
+	assert.Equal(t, int64(1), stats.Bypasses)
+	assert.Equal(t, int64(1), stats.Stores)
+	assert.Equal(t, 0, stats.Entries)
+	assert.InDelta(t, 1.0/3, stats.HitRate, 1e-9)
+}
+
+func TestResponseCache_Eviction(t *testing.T) {
+	t.Run("max entries evicts least recently used", func(t *testing.T) {
+		c := NewResponseCache(&Config{TTL: time.Hour, MaxEntries: 2, MaxBytes: 1024}, nil)
+		c.Set("a", []byte("1"))
+		c.Set("b", []byte("2"))
+		_, _ = c.Get("a")
+		c.Set("c", []byte("3"))
+
+		_, okA := c.Get("a")
+		_, okB := c.Get("b")
+		_, okC := c.Get("c")
+		assert.True(t, okA)
+		assert.False(t, okB)
+		assert.True(t, okC)
+		assert.Equal(t, int64(1), c.Stats().Evictions)
+	})
+
+	t.Run("max bytes", func(t *testing.T) {
+		c := NewResponseCache(&Config{TTL: time.Hour, MaxEntries: 10, MaxBytes: 10}, nil)
+		c.Set("a", []byte("123456"))
+		c.Set("b", []byte("123456"))
+		stats := c.Stats()
+		assert.Equal(t, 1, stats.Entries)
+		assert.Equal(t, int64(6), stats.Bytes)
+
+		// 单个响应超过上限时不缓存
+		c.Set("big", []byte("12345678901"))
+		_, ok := c.Get("big")
+		assert.False(t, ok)
+	})
+}
+
+func TestResponseCache_Persistence(t *testing.T) {
+	repo := newTestRepository(t)
+	clock := newFakeClock()
+	config := &Config{TTL: time.Minute, MaxEntries: 2, MaxBytes: 1024, Persist: true}
+
+	first := withClock(NewResponseCache(config, repo), clock)
+	first.Set("a", []byte(`{"id":"a"}`))
+
+	// 重启后从 SQLite 回查
+	second := withClock(NewResponseCache(config, repo), clock)
+	body, ok := second.Get("a")
+	require.True(t, ok)
+	assert.Equal(t, `{"id":"a"}`, string(body))
+	assert.Equal(t, 1, second.Stats().Entries)
+	assert.True(t, second.Stats().Persist)
+
+	clock.Advance(2 * time.Minute)
+	third := withClock(NewResponseCache(config, repo), clock)
+	_, ok = third.Get("a")
+	assert.False(t, ok, "expired persisted entries should miss")
+
+	// 写入时清理过期条目并按上限保留最新条目
+	third.Set("b", []byte("2"))
+	clock.Advance(time.Second)
+	third.Set("c", []byte("3"))
+	clock.Advance(time.Minute)
+	third.Set("d", []byte("4"))
+	third.pruning.Wait()
+	var count int64
+	require.NoError(t, repo.db.Model(&models.CachedResponse{}).Count(&count).Error)
+	assert.LessOrEqual(t, count, int64(2))
+
+	require.NoError(t, third.Clear())
+	require.NoError(t, repo.db.Model(&models.CachedResponse{}).Count(&count).Error)
+	assert.Zero(t, count)
+
+	// 未开启持久化时忽略 repo
+	memoryOnly := NewResponseCache(&Config{TTL: time.Minute, MaxEntries: 2, MaxBytes: 1024}, repo)
+	memoryOnly.Set("e", []byte("5"))
+	require.NoError(t, repo.db.Model(&models.CachedResponse{}).Count(&count).Error)
+	assert.Zero(t, count)
+}
+
+// 持久化开启时并发读写：SQLite 读写在锁外进行，内存状态保持一致
+func TestResponseCache_ConcurrentPersistence(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "cache.db")), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.CachedResponse{}))
+	cache := NewResponseCache(&Config{TTL: time.Minute, MaxEntries: 8, MaxBytes: 1024, Persist: true}, NewRepository(db))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			key := fmt.Sprintf("k%d", i%4)
+			for j := 0; j < 20; j++ {
+				cache.Set(key, []byte(key))
+				if body, ok := cache.Get(key); ok {
+					assert.Equal(t, key, string(body))
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	cache.pruning.Wait()
+
+	stats := cache.Stats()
+	assert.Equal(t, 4, stats.Entries)
+	assert.Equal(t, int64(8), stats.Bytes)
+	assert.Equal(t, int64(160), stats.Hits+stats.Misses)
+}
+
+func TestParseCacheControl(t *testing.T) {
+	noCache, noStore := ParseCacheControl("No-Cache, max-age=0")
+	assert.True(t, noCache)
+	assert.False(t, noStore)
+
+	noCache, noStore = ParseCacheControl("no-store")
+	assert.False(t, noCache)
+	assert.True(t, noStore)
+
+	noCache, noStore = ParseCacheControl("")
+	assert.False(t, noCache)
+	assert.False(t, noStore)
+}
+
+func TestLoadConfigFromEnv(t *testing.T) {
+	t.Setenv("RESPONSE_CACHE_TTL", "30m")
+	t.Setenv("RESPONSE_CACHE_MAX_ENTRIES", "50")
+	t.Setenv("RESPONSE_CACHE_MAX_BYTES", "invalid")
+	t.Setenv("RESPONSE_CACHE_PERSIST", "true")
+
+	config := LoadConfigFromEnv()
+	assert.Equal(t, 30*time.Minute, config.TTL)
+	assert.Equal(t, 50, config.MaxEntries)
+	assert.Equal(t, DefaultConfig().MaxBytes, config.MaxBytes)
+	assert.True(t, config.Persist)
+}
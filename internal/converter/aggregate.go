@@ -0,0 +1,329 @@
+package converter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// ========================
+// SSE 流聚合为非流式响应
+// ========================
+// 用于忽略 stream: false 只返回流式响应的上游：将完整的 SSE 流合并为单个 JSON 响应，与 Replay* 互为逆操作
+// 以通用 map 处理，保留上游返回的扩展字段
+
+// AggregateClaudeStream 将 Messages SSE 事件流合并为 Claude Messages 响应
+// 流中的 error 事件作为错误返回
+func AggregateClaudeStream(stream []byte) ([]byte, error) {
+	var message map[string]interface{}
+	blocks := make(map[int]map[string]interface{})
+	partialJSON := make(map[int]*strings.Builder)
+
+	err := forEachSSEData(stream, func(data string) error {
+		var event map[string]interface{}
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			return fmt.Errorf("failed to parse event: %w", err)
+		}
+		index := -1
+		if value, ok := event["index"].(float64); ok {
+			index = int(value)
+		}
+
+		switch event["type"] {
+		case EventTypeMessageStart:
+			message, _ = event["message"].(map[string]interface{})
+		case EventTypeContentBlockStart:
+			if block, ok := event["content_block"].(map[string]interface{}); ok {
+				blocks[index] = block
+			}
+		case EventTypeContentBlockDelta:
+			block, ok := blocks[index]
+			delta, _ := event["delta"].(map[string]interface{})
+			if !ok || delta == nil {
+				return nil
+			}
+			aggregateClaudeDelta(block, delta, index, partialJSON)
+		case EventTypeContentBlockStop:
+			block, ok := blocks[index]
+			if builder := partialJSON[index]; ok && builder != nil {
+				if input, _, err := DecodeToolArguments(builder.String(), nil); err == nil {
+					block["input"] = input
+				}
+			}
+		case EventTypeMessageDelta:
+			if message == nil {
+				return nil
+			}
+			if delta, ok := event["delta"].(map[string]interface{}); ok {
+				for key, value := range delta {
+					message[key] = value
+				}
+			}
+			if usage, ok := event["usage"].(map[string]interface{}); ok {
+				merged, _ := message["usage"].(map[string]interface{})
+				if merged == nil {
+					merged = make(map[string]interface{}, len(usage))
+				}
+				for key, value := range usage {
+					if value != nil {
+						merged[key] = value
+					}
+				}
+				message["usage"] = merged
+			}
+		case "error":
+			return streamEventError(event)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if message == nil {
+		return nil, fmt.Errorf("stream has no message_start event")
+	}
+
+	indexes := make([]int, 0, len(blocks))
+	for index := range blocks {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+	content := make([]interface{}, 0, len(indexes))
+	for _, index := range indexes {
+		content = append(content, blocks[index])
+	}
+	message["content"] = content
+	return json.Marshal(message)
+}
+
+// aggregateClaudeDelta 将内容块增量合并到内容块
+func aggregateClaudeDelta(block, delta map[string]interface{}, index int, partialJSON map[int]*strings.Builder) {
+	switch delta["type"] {
+	case DeltaTypeTextDelta:
+		text, _ := delta["text"].(string)
+		existing, _ := block["text"].(string)
+		block["text"] = existing + text
+	case DeltaTypeThinkingDelta:
+		thinking, _ := delta["thinking"].(string)
+		existing, _ := block["thinking"].(string)
+		block["thinking"] = existing + thinking
+	case DeltaTypeSignatureDelta:
+		signature, _ := delta["signature"].(string)
+		existing, _ := block["signature"].(string)
+		block["signature"] = existing + signature
+	case DeltaTypeInputJSONDelta:
+		builder, ok := partialJSON[index]
+		if !ok {
+			builder = &strings.Builder{}
+			partialJSON[index] = builder
+		}
+		partial, _ := delta["partial_json"].(string)
+		builder.WriteString(partial)
+	case "citations_delta":
+		citations, _ := block["citations"].([]interface{})
+		block["citations"] = append(citations, delta["citation"])
+	}
+}
+
+// AggregateOpenAIStream 将 chat.completion.chunk SSE 流合并为 Chat Completions 响应
+// 文本、推理内容与工具调用参数按 choice 拼接，usage 取流中最后一次报告的值；携带 error 的 chunk 作为错误返回
+func AggregateOpenAIStream(stream []byte) ([]byte, error) {
+	var resp map[string]interface{}
+	choices := make(map[int]*aggregatedChoice)
+
+	err := forEachSSEData(stream, func(data string) error {
+		var chunk map[string]interface{}
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return fmt.Errorf("failed to parse chunk: %w", err)
+		}
+		if _, ok := chunk["error"]; ok {
+			return streamEventError(chunk)
+		}
+
+		if resp == nil {
+			resp = make(map[string]interface{}, len(chunk))
+		}
+		for key, value := range chunk {
+			if key != "choices" && value != nil {
+				resp[key] = value
+			}
+		}
+
+		rawChoices, _ := chunk["choices"].([]interface{})
+		for _, rawChoice := range rawChoices {
+			choice, ok := rawChoice.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			index := 0
+			if value, ok := choice["index"].(float64); ok {
+				index = int(value)
+			}
+			aggregated, ok := choices[index]
+			if !ok {
+				aggregated = &aggregatedChoice{message: map[string]interface{}{"role": "assistant"}, toolCalls: make(map[int]map[string]interface{})}
+				choices[index] = aggregated
+			}
+			aggregated.add(choice)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if resp == nil {
+		return nil, fmt.Errorf("stream has no chunks")
+	}
+
+	indexes := make([]int, 0, len(choices))
+	for index := range choices {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+	result := make([]interface{}, 0, len(indexes))
+	for _, index := range indexes {
+		result = append(result, choices[index].build(index))
+	}
+
+	resp["object"] = "chat.completion"
+	resp["choices"] = result
+	return json.Marshal(resp)
+}
+
+// aggregatedChoice 单个 choice 的合并状态
+type aggregatedChoice struct {
+	message      map[string]interface{}
+	content      strings.Builder
+	hasContent   bool
+	toolCalls    map[int]map[string]interface{}
+	finishReason interface{}
+}
+
+// add 合并一个 chunk 中的 choice 增量
+func (a *aggregatedChoice) add(choice map[string]interface{}) {
+	if reason, ok := choice["finish_reason"]; ok && reason != nil {
+		a.finishReason = reason
+	}
+	delta, _ := choice["delta"].(map[string]interface{})
+	for key, value := range delta {
+		switch key {
+		case "content":
+			if text, ok := value.(string); ok {
+				a.content.WriteString(text)
+				a.hasContent = true
+			}
+		case "reasoning_content", "reasoning":
+			if text, ok := value.(string); ok {
+				existing, _ := a.message[key].(string)
+				a.message[key] = existing + text
+			}
+		case "tool_calls":
+			calls, _ := value.([]interface{})
+			for _, rawCall := range calls {
+				if call, ok := rawCall.(map[string]interface{}); ok {
+					a.addToolCall(call)
+				}
+			}
+		default:
+			if value != nil {
+				a.message[key] = value
+			}
+		}
+	}
+}
+
+// addToolCall 按 index 合并工具调用增量：id、type、name 取首次出现的值，arguments 拼接
+func (a *aggregatedChoice) addToolCall(call map[string]interface{}) {
+	index := len(a.toolCalls)
+	if value, ok := call["index"].(float64); ok {
+		index = int(value)
+	}
+	merged, ok := a.toolCalls[index]
+	if !ok {
+		merged = map[string]interface{}{"type": "function", "function": map[string]interface{}{"name": "", "arguments": ""}}
+		a.toolCalls[index] = merged
+	}
+	for _, key := range []string{"id", "type"} {
+		if value, ok := call[key].(string); ok && value != "" {
+			merged[key] = value
+		}
+	}
+	function, _ := call["function"].(map[string]interface{})
+	mergedFunction := merged["function"].(map[string]interface{})
+	if name, ok := function["name"].(string); ok && name != "" && mergedFunction["name"] == "" {
+		mergedFunction["name"] = name
+	}
+	if arguments, ok := function["arguments"].(string); ok {
+		mergedFunction["arguments"] = mergedFunction["arguments"].(string) + arguments
+	}
+}
+
+// build 生成非流式 choice；有工具调用且没有文本时 content 为 null
+func (a *aggregatedChoice) build(index int) map[string]interface{} {
+	message := a.message
+	if a.hasContent && (a.content.Len() > 0 || len(a.toolCalls) == 0) {
+		message["content"] = a.content.String()
+	} else if len(a.toolCalls) == 0 {
+		message["content"] = ""
+	} else {
+		message["content"] = nil
+	}
+
+	if len(a.toolCalls) > 0 {
+		indexes := make([]int, 0, len(a.toolCalls))
+		for i := range a.toolCalls {
+			indexes = append(indexes, i)
+		}
+		sort.Ints(indexes)
+		calls := make([]interface{}, 0, len(indexes))
+		for _, i := range indexes {
+			calls = append(calls, a.toolCalls[i])
+		}
+		message["tool_calls"] = calls
+	}
+
+	return map[string]interface{}{
+		"index":         index,
+		"message":       message,
+		"finish_reason": a.finishReason,
+	}
+}
+
+// forEachSSEData 依次处理 SSE 流中每个事件的 data，遇到 [DONE] 结束
+func forEachSSEData(stream []byte, handle func(data string) error) error {
+	parser := NewSSEParser(bytes.NewReader(stream))
+	for {
+		data, err := parser.ParseEvent()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if data == "[DONE]" {
+			return nil
+		}
+		if strings.TrimSpace(data) == "" {
+			continue
+		}
+		if err := handle(data); err != nil {
+			return err
+		}
+	}
+}
+
+// streamEventError 提取流中错误事件的错误信息
+func streamEventError(event map[string]interface{}) error {
+	switch detail := event["error"].(type) {
+	case string:
+		return fmt.Errorf("upstream stream error: %s", detail)
+	case map[string]interface{}:
+		if message, ok := detail["message"].(string); ok && message != "" {
+			return fmt.Errorf("upstream stream error: %s", message)
+		}
+	}
+	return fmt.Errorf("upstream stream error")
+}
@@ -0,0 +1,141 @@
+package converter
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestAggregateClaudeStream_ReplayRoundTrip 测试回放的 SSE 流可以合并回原响应
+func TestAggregateClaudeStream_ReplayRoundTrip(t *testing.T) {
+	body := []byte(`{"id":"msg_1","type":"message","role":"assistant","model":"claude-sonnet-4-5",
+		"content":[
+			{"type":"thinking","thinking":"Let me think.","signature":"sig"},
+			{"type":"text","text":"Checking the weather."},
+			{"type":"tool_use","id":"toolu_1","name":"get_weather","input":{"city":"Paris","days":[1,2]}}
+		],
+		"stop_reason":"tool_use","stop_sequence":null,
+		"usage":{"input_tokens":12,"output_tokens":34,"cache_read_input_tokens":5}}`)
+
+	stream, err := ReplayClaudeStream(body)
+	if err != nil {
+		t.Fatalf("replay failed: %v", err)
+	}
+	aggregated, err := AggregateClaudeStream(stream)
+	if err != nil {
+		t.Fatalf("aggregate failed: %v", err)
+	}
+
+	var want, got map[string]interface{}
+	_ = json.Unmarshal(body, &want)
+	if err := json.Unmarshal(aggregated, &got); err != nil {
+		t.Fatalf("invalid aggregated response: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("round trip mismatch:\ngot:  %s\nwant: %s", aggregated, body)
+	}
+}
+
+// TestAggregateClaudeStream_PartialDeltas 测试拼接多个增量并合并 message_delta 中的 usage
+func TestAggregateClaudeStream_PartialDeltas(t *testing.T) {
+	stream := "event: message_start\ndata: {\"type\":\"message_start\",\"message\":{\"id\":\"msg_2\",\"type\":\"message\",\"role\":\"assistant\",\"model\":\"m\",\"content\":[],\"stop_reason\":null,\"usage\":{\"input_tokens\":10,\"output_tokens\":1}}}\n\n" +
+		"event: content_block_start\ndata: {\"type\":\"content_block_start\",\"index\":0,\"content_block\":{\"type\":\"text\",\"text\":\"\"}}\n\n" +
+		"event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"text_delta\",\"text\":\"Hel\"}}\n\n" +
+		"event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"text_delta\",\"text\":\"lo\"}}\n\n" +
+		"event: content_block_stop\ndata: {\"type\":\"content_block_stop\",\"index\":0}\n\n" +
+		"event: content_block_start\ndata: {\"type\":\"content_block_start\",\"index\":1,\"content_block\":{\"type\":\"tool_use\",\"id\":\"toolu_1\",\"name\":\"f\",\"input\":{}}}\n\n" +
+		"event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":1,\"delta\":{\"type\":\"input_json_delta\",\"partial_json\":\"{\\\"a\\\":\"}}\n\n" +
+		"event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":1,\"delta\":{\"type\":\"input_json_delta\",\"partial_json\":\" 1}\"}}\n\n" +
+		"event: content_block_stop\ndata: {\"type\":\"content_block_stop\",\"index\":1}\n\n" +
+		"event: message_delta\ndata: {\"type\":\"message_delta\",\"delta\":{\"stop_reason\":\"tool_use\",\"stop_sequence\":null},\"usage\":{\"output_tokens\":20}}\n\n" +
+		"event: message_stop\ndata: {\"type\":\"message_stop\"}\n\n"
+
+	aggregated, err := AggregateClaudeStream([]byte(stream))
+	if err != nil {
+		t.Fatalf("aggregate failed: %v", err)
+	}
+	var resp ClaudeResponse
+	if err := json.Unmarshal(aggregated, &resp); err != nil {
+		t.Fatalf("invalid aggregated response: %v", err)
+	}
+	if resp.StopReason != "tool_use" || resp.Usage.InputTokens != 10 || resp.Usage.OutputTokens != 20 {
+		t.Fatalf("unexpected message fields: %s", aggregated)
+	}
+	if len(resp.Content) != 2 || *resp.Content[0].Text != "Hello" || resp.Content[1].Input["a"] != float64(1) {
+		t.Fatalf("unexpected content: %s", aggregated)
+	}
+}
+
+// TestAggregateClaudeStream_Errors 测试错误事件与不完整的流
+func TestAggregateClaudeStream_Errors(t *testing.T) {
+	stream := "event: error\ndata: {\"type\":\"error\",\"error\":{\"type\":\"overloaded_error\",\"message\":\"Overloaded\"}}\n\n"
+	if _, err := AggregateClaudeStream([]byte(stream)); err == nil || !strings.Contains(err.Error(), "Overloaded") {
+		t.Fatalf("expected overloaded error, got %v", err)
+	}
+	if _, err := AggregateClaudeStream([]byte("data: {\"type\":\"message_stop\"}\n\n")); err == nil {
+		t.Fatal("stream without message_start should fail")
+	}
+}
+
+// TestAggregateOpenAIStream 测试合并文本、工具调用增量与 usage
+func TestAggregateOpenAIStream(t *testing.T) {
+	stream := `data: {"id":"chatcmpl-1","object":"chat.completion.chunk","created":1,"model":"gpt-4o","choices":[{"index":0,"delta":{"role":"assistant","content":"","reasoning_content":"Think"},"finish_reason":null}]}
+
+data: {"id":"chatcmpl-1","object":"chat.completion.chunk","created":1,"model":"gpt-4o","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"get_weather","arguments":"{\"city\":"}}]},"finish_reason":null}]}
+
+data: {"id":"chatcmpl-1","object":"chat.completion.chunk","created":1,"model":"gpt-4o","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"\"Paris\"}"}}]},"finish_reason":null}]}
+
+data: {"id":"chatcmpl-1","object":"chat.completion.chunk","created":1,"model":"gpt-4o","choices":[{"index":0,"delta":{},"finish_reason":"tool_calls"}]}
+
+data: {"id":"chatcmpl-1","object":"chat.completion.chunk","created":1,"model":"gpt-4o","choices":[],"usage":{"prompt_tokens":5,"completion_tokens":7,"total_tokens":12}}
+
+data: [DONE]
+
+`
+	aggregated, err := AggregateOpenAIStream([]byte(stream))
+	if err != nil {
+		t.Fatalf("aggregate failed: %v", err)
+	}
+
+	var resp OpenAIResponse
+	if err := json.Unmarshal(aggregated, &resp); err != nil {
+		t.Fatalf("invalid aggregated response: %v", err)
+	}
+	if resp.ID != "chatcmpl-1" || resp.Object != "chat.completion" || resp.Usage.TotalTokens != 12 || len(resp.Choices) != 1 {
+		t.Fatalf("unexpected response: %s", aggregated)
+	}
+	choice := resp.Choices[0]
+	if choice.FinishReason != "tool_calls" || choice.Message.Content != nil || choice.Message.ReasoningContent != "Think" {
+		t.Fatalf("unexpected choice: %s", aggregated)
+	}
+	if len(choice.Message.ToolCalls) != 1 || choice.Message.ToolCalls[0].ID != "call_1" || choice.Message.ToolCalls[0].Function.Arguments != `{"city":"Paris"}` {
+		t.Fatalf("unexpected tool calls: %+v", choice.Message.ToolCalls)
+	}
+
+	// 回放后再合并应得到相同的响应
+	replayed, err := ReplayOpenAIStream(aggregated, true)
+	if err != nil {
+		t.Fatalf("replay failed: %v", err)
+	}
+	again, err := AggregateOpenAIStream(replayed)
+	if err != nil {
+		t.Fatalf("aggregate replay failed: %v", err)
+	}
+	var first, second map[string]interface{}
+	_ = json.Unmarshal(aggregated, &first)
+	_ = json.Unmarshal(again, &second)
+	if !reflect.DeepEqual(first, second) {
+		t.Fatalf("round trip mismatch:\nfirst:  %s\nsecond: %s", aggregated, again)
+	}
+}
+
+// TestAggregateOpenAIStream_Errors 测试错误 chunk 与空流
+func TestAggregateOpenAIStream_Errors(t *testing.T) {
+	if _, err := AggregateOpenAIStream([]byte(`data: {"error":{"message":"rate limited"}}` + "\n\n")); err == nil || !strings.Contains(err.Error(), "rate limited") {
+		t.Fatalf("expected rate limited error, got %v", err)
+	}
+	if _, err := AggregateOpenAIStream([]byte("data: [DONE]\n\n")); err == nil {
+		t.Fatal("empty stream should fail")
+	}
+}
@@ -0,0 +1,128 @@
+package converter
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// bedrockChunk Bedrock InvokeModelWithResponseStream 的 chunk 负载
+// bytes 为 base64 编码的 Claude 流式事件 JSON
+type bedrockChunk struct {
+	Bytes string `json:"bytes"`
+}
+
+// bedrockExceptionTypes Bedrock 异常类型到 Claude 错误类型的映射
+var bedrockExceptionTypes = map[string]string{
+	"throttlingException":           "rate_limit_error",
+	"validationException":           "invalid_request_error",
+	"serviceUnavailableException":   "overloaded_error",
+	"modelStreamErrorException":     "api_error",
+	"internalServerException":       "api_error",
+	"modelTimeoutException":         "api_error",
+	"accessDeniedException":         "permission_error",
+	"resourceNotFoundException":     "not_found_error",
+	"serviceQuotaExceededException": "rate_limit_error",
+}
+
+// BedrockErrorType 将 Bedrock 异常类型映射为 Claude 错误类型
+func BedrockErrorType(exceptionType string) string {
+	if errorType, ok := bedrockExceptionTypes[exceptionType]; ok {
+		return errorType
+	}
+	return "api_error"
+}
+
+// ConvertBedrockStream 将 Bedrock event-stream 响应转换为 Claude SSE 流
+func ConvertBedrockStream(ctx context.Context, bedrockStream io.Reader) (io.Reader, error) {
+	pipeReader, pipeWriter := io.Pipe()
+
+	go func() {
+		defer pipeWriter.Close()
+
+		decoder := NewEventStreamDecoder(bedrockStream)
+		for {
+			select {
+			case <-ctx.Done():
+				pipeWriter.CloseWithError(ctx.Err())
+				return
+			default:
+			}
+
+			msg, err := decoder.Next()
+			if err == io.EOF {
+				return
+			}
+			if err != nil {
+				pipeWriter.CloseWithError(err)
+				return
+			}
+
+			event, err := bedrockMessageToSSE(msg)
+			if err != nil {
+				pipeWriter.CloseWithError(err)
+				return
+			}
+			if event == "" {
+				continue
+			}
+			if _, err := pipeWriter.Write([]byte(event)); err != nil {
+				return
+			}
+		}
+	}()
+
+	return pipeReader, nil
+}
+
+// bedrockMessageToSSE 将单条 event-stream 消息转换为 Claude SSE 事件
+func bedrockMessageToSSE(msg *EventStreamMessage) (string, error) {
+	switch msg.Headers[":message-type"] {
+	case "exception", "error":
+		exceptionType := msg.Headers[":exception-type"]
+		if exceptionType == "" {
+			exceptionType = msg.Headers[":error-code"]
+		}
+		var detail struct {
+			Message string `json:"message"`
+		}
+		_ = json.Unmarshal(msg.Payload, &detail)
+		if detail.Message == "" {
+			detail.Message = msg.Headers[":error-message"]
+		}
+		if detail.Message == "" {
+			detail.Message = exceptionType
+		}
+		return FormatSSEEvent("error", map[string]interface{}{
+			"type": "error",
+			"error": map[string]interface{}{
+				"type":    BedrockErrorType(exceptionType),
+				"message": detail.Message,
+			},
+		})
+	}
+
+	if msg.Headers[":event-type"] != "chunk" {
+		return "", nil
+	}
+
+	var chunk bedrockChunk
+	if err := json.Unmarshal(msg.Payload, &chunk); err != nil {
+		return "", fmt.Errorf("invalid bedrock chunk: %w", err)
+	}
+	data, err := base64.StdEncoding.DecodeString(chunk.Bytes)
+	if err != nil {
+		return "", fmt.Errorf("invalid bedrock chunk bytes: %w", err)
+	}
+
+	var event struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(data, &event); err != nil {
+		return "", fmt.Errorf("invalid claude event in bedrock chunk: %w", err)
+	}
+
+	return fmt.Sprintf("event: %s\ndata: %s\n\n", event.Type, data), nil
+}
@@ -0,0 +1,359 @@
+package converter
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ConvertOpenAIRequestToClaude 将 OpenAI Chat Completions 请求转换为 Claude Messages API 请求
+// 调用方负责设置 model；response_format 由 EmulateResponseFormat 处理
+func ConvertOpenAIRequestToClaude(req *OpenAIRequest) (*ClaudeRequest, error) {
+	if err := ValidateNonNil(req, "OpenAI请求"); err != nil {
+		return nil, NewConversionError("request", "验证失败", err)
+	}
+
+	claudeReq := &ClaudeRequest{
+		MaxTokens:     DefaultClaudeMaxTokens,
+		Temperature:   req.Temperature,
+		TopP:          req.TopP,
+		Stream:        req.Stream,
+		StopSequences: req.Stop,
+	}
+	if req.MaxCompletionTokens > 0 {
+		claudeReq.MaxTokens = req.MaxCompletionTokens
+	} else if req.MaxTokens > 0 {
+		claudeReq.MaxTokens = req.MaxTokens
+	}
+
+	var system []string
+	for i, msg := range req.Messages {
+		blocks, err := chatContentToClaudeBlocks(msg.Content)
+		if err != nil {
+			return nil, NewConversionError("request", fmt.Sprintf("转换第 %d 条消息失败", i), err)
+		}
+
+		switch msg.Role {
+		case "system", "developer":
+			system = append(system, ExtractTextFromClaudeContent(blocks))
+
+		case ClaudeRoleUser:
+			claudeReq.Messages = appendClaudeMessage(claudeReq.Messages, ClaudeRoleUser, blocks)
+
+		case ClaudeRoleAssistant:
+			for _, call := range msg.ToolCalls {
+				input := map[string]interface{}{}
+				if strings.TrimSpace(call.Function.Arguments) != "" {
+					if err := json.Unmarshal([]byte(call.Function.Arguments), &input); err != nil {
+						return nil, NewConversionError("request", fmt.Sprintf("函数 %s 的参数不是合法 JSON 对象", call.Function.Name), err)
+					}
+				}
+				blocks = append(blocks, ClaudeContentBlock{
+					Type:  ContentTypeToolUse,
+					ID:    StringPtr(call.ID),
+					Name:  StringPtr(call.Function.Name),
+					Input: input,
+				})
+			}
+			claudeReq.Messages = appendClaudeMessage(claudeReq.Messages, ClaudeRoleAssistant, blocks)
+
+		case "tool":
+			if msg.ToolCallID == "" {
+				return nil, NewConversionError("request", fmt.Sprintf("转换第 %d 条消息失败", i), fmt.Errorf("tool 消息缺少 tool_call_id"))
+			}
+			claudeReq.Messages = appendClaudeMessage(claudeReq.Messages, ClaudeRoleUser, []ClaudeContentBlock{{
+				Type:      ContentTypeToolResult,
+				ToolUseID: StringPtr(msg.ToolCallID),
+				Content:   NewToolResultText(ExtractTextFromClaudeContent(blocks)),
+			}})
+
+		default:
+			return nil, NewConversionError("request", "转换消息失败", fmt.Errorf("不支持的角色: %s", msg.Role))
+		}
+	}
+	claudeReq.System = NewSystemPrompt(strings.Join(system, "\n"))
+
+	for _, tool := range req.Tools {
+		if tool.Type != OpenAIToolTypeFunction {
+			continue
+		}
+		schema := tool.Function.Parameters
+		if schema == nil {
+			schema = map[string]interface{}{"type": "object", "properties": map[string]interface{}{}}
+		}
+		claudeReq.Tools = append(claudeReq.Tools, ClaudeTool{
+			Name:        tool.Function.Name,
+			Description: tool.Function.Description,
+			InputSchema: schema,
+		})
+	}
+
+	switch choice := req.ToolChoice.(type) {
+	case string:
+		switch choice {
+		case "auto":
+			claudeReq.ToolChoice = &ClaudeToolChoice{Type: "auto"}
+		case "required":
+			claudeReq.ToolChoice = &ClaudeToolChoice{Type: "any"}
+		case "none":
+			claudeReq.ToolChoice = &ClaudeToolChoice{Type: "none"}
+		}
+	case map[string]interface{}:
+		function, _ := choice["function"].(map[string]interface{})
+		if name, ok := function["name"].(string); ok && choice["type"] == OpenAIToolTypeFunction {
+			claudeReq.ToolChoice = &ClaudeToolChoice{Type: "tool", Name: StringPtr(name)}
+		}
+	}
+
+	return claudeReq, nil
+}
+
+// chatContentToClaudeBlocks 转换消息内容（字符串或内容块数组）
+func chatContentToClaudeBlocks(content interface{}) ([]ClaudeContentBlock, error) {
+	switch v := content.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		if v == "" {
+			return nil, nil
+		}
+		return []ClaudeContentBlock{{Type: ContentTypeText, Text: StringPtr(v)}}, nil
+	}
+
+	raw, err := json.Marshal(content)
+	if err != nil {
+		return nil, err
+	}
+	var parts []OpenAIContentBlock
+	if err := json.Unmarshal(raw, &parts); err != nil {
+		return nil, fmt.Errorf("content 必须是字符串或内容块数组: %w", err)
+	}
+
+	var blocks []ClaudeContentBlock
+	for _, part := range parts {
+		switch part.Type {
+		case ContentTypeText:
+			if part.Text != nil && *part.Text != "" {
+				blocks = append(blocks, ClaudeContentBlock{Type: ContentTypeText, Text: part.Text, CacheControl: part.CacheControl})
+			}
+		case "image_url":
+			if part.ImageURL == nil {
+				return nil, fmt.Errorf("image_url 内容块缺少 image_url")
+			}
+			source := &ClaudeImageSource{Type: "url", URL: part.ImageURL.URL}
+			if mediaType, data, ok := parseDataURI(part.ImageURL.URL); ok {
+				source = &ClaudeImageSource{Type: "base64", MediaType: mediaType, Data: data}
+			}
+			blocks = append(blocks, ClaudeContentBlock{Type: ContentTypeImage, Source: source, CacheControl: part.CacheControl})
+		case "file":
+			if part.File == nil {
+				return nil, fmt.Errorf("file 内容块缺少 file")
+			}
+			mediaType, data, ok := parseDataURI(part.File.FileData)
+			if !ok || mediaType != mediaTypePDF {
+				return nil, fmt.Errorf("仅支持 base64 data URI 形式的 PDF 文件")
+			}
+			block := ClaudeContentBlock{
+				Type:         ContentTypeDocument,
+				Source:       &ClaudeImageSource{Type: "base64", MediaType: mediaType, Data: data},
+				CacheControl: part.CacheControl,
+			}
+			if part.File.Filename != "" {
+				block.Title = StringPtr(part.File.Filename)
+			}
+			blocks = append(blocks, block)
+		default:
+			return nil, fmt.Errorf("不支持的内容类型: %s", part.Type)
+		}
+	}
+	return blocks, nil
+}
+
+// ConvertClaudeResponseToOpenAI 将 Claude Messages API 响应转换为 OpenAI Chat Completions 响应
+func ConvertClaudeResponseToOpenAI(resp *ClaudeResponse) (*OpenAIResponse, error) {
+	if err := ValidateNonNil(resp, "Claude响应"); err != nil {
+		return nil, NewConversionError("response", "验证失败", err)
+	}
+
+	var text, reasoning strings.Builder
+	var toolCalls []OpenAIToolCall
+	for _, block := range resp.Content {
+		switch block.Type {
+		case ContentTypeText:
+			if block.Text != nil {
+				text.WriteString(*block.Text)
+			}
+		case ContentTypeThinking:
+			if block.Thinking != nil {
+				reasoning.WriteString(*block.Thinking)
+			}
+		case ContentTypeToolUse:
+			if block.ID == nil || block.Name == nil {
+				continue
+			}
+			input := block.Input
+			if input == nil {
+				input = map[string]interface{}{}
+			}
+			args, err := json.Marshal(input)
+			if err != nil {
+				return nil, NewConversionError("response", "序列化函数参数失败", err)
+			}
+			toolCalls = append(toolCalls, OpenAIToolCall{
+				ID:   *block.ID,
+				Type: OpenAIToolTypeFunction,
+				Function: OpenAIFunctionCall{
+					Name:      *block.Name,
+					Arguments: string(args),
+				},
+			})
+		}
+	}
+
+	message := OpenAIMessage{
+		Role:             ClaudeRoleAssistant,
+		ToolCalls:        toolCalls,
+		ReasoningContent: reasoning.String(),
+	}
+	if text.Len() > 0 || len(toolCalls) == 0 {
+		message.Content = text.String()
+	}
+
+	return &OpenAIResponse{
+		ID:      ConvertIDClaudeToOpenAI(resp.ID),
+		Object:  OpenAIObjectChatCompletion,
+		Created: time.Now().Unix(),
+		Model:   resp.Model,
+		Choices: []OpenAIChoice{{
+			Message:      message,
+			FinishReason: ConvertStopReasonToFinishReason(resp.StopReason),
+		}},
+		Usage: openAIUsageFromClaude(resp.Usage),
+	}, nil
+}
+
+// ClaudeOpenAIStreamConverter 将 Claude SSE 事件逐个转换为 OpenAI Chat Completions 流式响应块
+// 文本、思考与工具参数增量立即输出，message_stop 时输出带 finish_reason 与 usage 的最后一个块
+type ClaudeOpenAIStreamConverter struct {
+	id         string
+	model      string
+	created    int64
+	roleSent   bool
+	usage      ClaudeUsage
+	stopReason string
+	toolCalls  map[int]int // Claude 内容块索引 -> OpenAI tool_calls 索引
+}
+
+// NewClaudeOpenAIStreamConverter 创建 Claude→OpenAI 流式转换器
+func NewClaudeOpenAIStreamConverter() *ClaudeOpenAIStreamConverter {
+	return &ClaudeOpenAIStreamConverter{
+		created:   time.Now().Unix(),
+		toolCalls: make(map[int]int),
+	}
+}
+
+// ProcessEvent 处理一个 Claude SSE 事件的 data，返回需要输出的 OpenAI 流式响应块
+// 上游 error 事件以错误返回
+func (c *ClaudeOpenAIStreamConverter) ProcessEvent(data []byte) ([]*OpenAIStreamChunk, error) {
+	var event claudeStreamEvent
+	if err := json.Unmarshal(data, &event); err != nil {
+		return nil, NewConversionError("stream", "解析 Claude 事件失败", err)
+	}
+
+	switch event.Type {
+	case EventTypeMessageStart:
+		if event.Message != nil {
+			c.id = ConvertIDClaudeToOpenAI(event.Message.ID)
+			c.model = event.Message.Model
+			c.usage = event.Message.Usage
+		}
+		return []*OpenAIStreamChunk{c.chunk(OpenAIStreamDelta{}, nil, nil)}, nil
+
+	case EventTypeContentBlockStart:
+		block := event.ContentBlock
+		if block == nil {
+			return nil, nil
+		}
+		switch block.Type {
+		case ContentTypeToolUse:
+			index := len(c.toolCalls)
+			c.toolCalls[event.Index] = index
+			call := OpenAIStreamToolCall{Index: index, Type: OpenAIToolTypeFunction, Function: &OpenAIStreamFunctionCall{}}
+			if block.ID != nil {
+				call.ID = *block.ID
+			}
+			if block.Name != nil {
+				call.Function.Name = *block.Name
+			}
+			return []*OpenAIStreamChunk{c.chunk(OpenAIStreamDelta{ToolCalls: []OpenAIStreamToolCall{call}}, nil, nil)}, nil
+		case ContentTypeText:
+			if block.Text != nil && *block.Text != "" {
+				return []*OpenAIStreamChunk{c.chunk(OpenAIStreamDelta{Content: *block.Text}, nil, nil)}, nil
+			}
+		}
+
+	case EventTypeContentBlockDelta:
+		if event.Delta == nil {
+			return nil, nil
+		}
+		switch event.Delta.Type {
+		case DeltaTypeTextDelta:
+			if event.Delta.Text != "" {
+				return []*OpenAIStreamChunk{c.chunk(OpenAIStreamDelta{Content: event.Delta.Text}, nil, nil)}, nil
+			}
+		case DeltaTypeThinkingDelta:
+			if event.Delta.Thinking != "" {
+				return []*OpenAIStreamChunk{c.chunk(OpenAIStreamDelta{ReasoningContent: event.Delta.Thinking}, nil, nil)}, nil
+			}
+		case DeltaTypeInputJSONDelta:
+			index, ok := c.toolCalls[event.Index]
+			if !ok || event.Delta.PartialJSON == "" {
+				return nil, nil
+			}
+			call := OpenAIStreamToolCall{Index: index, Function: &OpenAIStreamFunctionCall{Arguments: event.Delta.PartialJSON}}
+			return []*OpenAIStreamChunk{c.chunk(OpenAIStreamDelta{ToolCalls: []OpenAIStreamToolCall{call}}, nil, nil)}, nil
+		}
+
+	case EventTypeMessageDelta:
+		if event.Delta != nil && event.Delta.StopReason != "" {
+			c.stopReason = event.Delta.StopReason
+		}
+		if event.Usage != nil {
+			mergeClaudeDeltaUsage(&c.usage, event.Usage)
+		}
+
+	case EventTypeMessageStop:
+		finishReason := ConvertStopReasonToFinishReason(c.stopReason)
+		usage := openAIUsageFromClaude(c.usage)
+		return []*OpenAIStreamChunk{c.chunk(OpenAIStreamDelta{}, &finishReason, &usage)}, nil
+
+	case "error":
+		if event.Error != nil && event.Error.Message != "" {
+			return nil, fmt.Errorf("上游返回错误: %s", event.Error.Message)
+		}
+		return nil, fmt.Errorf("上游返回错误")
+	}
+
+	return nil, nil
+}
+
+// chunk 构建 OpenAI 流式响应块，第一个块携带 role
+func (c *ClaudeOpenAIStreamConverter) chunk(delta OpenAIStreamDelta, finishReason *string, usage *OpenAIUsage) *OpenAIStreamChunk {
+	if !c.roleSent {
+		delta.Role = ClaudeRoleAssistant
+		c.roleSent = true
+	}
+	return &OpenAIStreamChunk{
+		ID:      c.id,
+		Object:  OpenAIObjectChatCompletionChunk,
+		Created: c.created,
+		Model:   c.model,
+		Choices: []OpenAIStreamChoice{{
+			Index:        0,
+			Delta:        delta,
+			FinishReason: finishReason,
+		}},
+		Usage: usage,
+	}
+}
@@ -0,0 +1,151 @@
+package converter
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// 测试 OpenAI→Claude 请求：system / developer、工具调用与 tool 消息配对、图片、max_completion_tokens、tool_choice
+func TestConvertOpenAIRequestToClaude(t *testing.T) {
+	raw := `{
+		"model": "gpt-4o",
+		"max_tokens": 100,
+		"max_completion_tokens": 200,
+		"stop": ["END"],
+		"messages": [
+			{"role": "system", "content": "Be brief."},
+			{"role": "developer", "content": [{"type": "text", "text": "Use tools."}]},
+			{"role": "user", "content": [
+				{"type": "text", "text": "Look at this"},
+				{"type": "image_url", "image_url": {"url": "data:image/png;base64,iVBORw0KGgo="}}
+			]},
+			{"role": "assistant", "content": null, "tool_calls": [
+				{"id": "call_1", "type": "function", "function": {"name": "lookup", "arguments": "{\"q\":\"cat\"}"}},
+				{"id": "call_2", "type": "function", "function": {"name": "lookup", "arguments": ""}}
+			]},
+			{"role": "tool", "tool_call_id": "call_1", "content": "a cat"},
+			{"role": "tool", "tool_call_id": "call_2", "content": [{"type": "text", "text": "nothing"}]}
+		],
+		"tools": [{"type": "function", "function": {"name": "lookup", "description": "Search"}}],
+		"tool_choice": {"type": "function", "function": {"name": "lookup"}}
+	}`
+
+	var req OpenAIRequest
+	if err := json.Unmarshal([]byte(raw), &req); err != nil {
+		t.Fatalf("failed to decode request: %v", err)
+	}
+
+	claudeReq, err := ConvertOpenAIRequestToClaude(&req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if claudeReq.System.PlainText() != "Be brief.\nUse tools." {
+		t.Fatalf("unexpected system: %q", claudeReq.System.PlainText())
+	}
+	if claudeReq.MaxTokens != 200 || claudeReq.StopSequences[0] != "END" {
+		t.Fatalf("unexpected generation params: %+v", claudeReq)
+	}
+	if len(claudeReq.Messages) != 3 {
+		t.Fatalf("expected 3 messages after merging, got %d", len(claudeReq.Messages))
+	}
+
+	user := claudeReq.Messages[0]
+	if user.Role != ClaudeRoleUser || len(user.Content) != 2 || user.Content[1].Source.Type != "base64" || user.Content[1].Source.MediaType != "image/png" {
+		t.Fatalf("unexpected user message: %+v", user)
+	}
+
+	assistant := claudeReq.Messages[1]
+	if assistant.Role != ClaudeRoleAssistant || len(assistant.Content) != 2 || assistant.Content[0].Input["q"] != "cat" || *assistant.Content[1].ID != "call_2" {
+		t.Fatalf("unexpected assistant message: %+v", assistant)
+	}
+
+	results := claudeReq.Messages[2]
+	if results.Role != ClaudeRoleUser || len(results.Content) != 2 || *results.Content[0].ToolUseID != "call_1" || results.Content[1].Content.PlainText() != "nothing" {
+		t.Fatalf("unexpected tool results: %+v", results)
+	}
+
+	if len(claudeReq.Tools) != 1 || claudeReq.Tools[0].InputSchema["type"] != "object" {
+		t.Fatalf("unexpected tools: %+v", claudeReq.Tools)
+	}
+	if claudeReq.ToolChoice == nil || claudeReq.ToolChoice.Type != "tool" || *claudeReq.ToolChoice.Name != "lookup" {
+		t.Fatalf("unexpected tool_choice: %+v", claudeReq.ToolChoice)
+	}
+
+	req.Messages = append(req.Messages, OpenAIMessage{Role: "tool", Content: "orphan"})
+	if _, err := ConvertOpenAIRequestToClaude(&req); err == nil {
+		t.Fatalf("expected error for tool message without tool_call_id")
+	}
+}
+
+// 测试 Claude→OpenAI 非流式响应与流式事件转换
+func TestConvertClaudeResponseToOpenAI(t *testing.T) {
+	resp := &ClaudeResponse{
+		ID:    "msg_1",
+		Model: "claude-sonnet-4-5",
+		Content: []ClaudeContentBlock{
+			{Type: ContentTypeThinking, Thinking: StringPtr("hmm")},
+			{Type: ContentTypeText, Text: StringPtr("Let me check.")},
+			{Type: ContentTypeToolUse, ID: StringPtr("toolu_1"), Name: StringPtr("lookup"), Input: map[string]interface{}{"q": "cat"}},
+		},
+		StopReason: "tool_use",
+		Usage:      ClaudeUsage{InputTokens: 5, OutputTokens: 3, CacheReadInputTokens: 10},
+	}
+
+	openaiResp, err := ConvertClaudeResponseToOpenAI(resp)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	choice := openaiResp.Choices[0]
+	if openaiResp.ID != "chatcmpl-1" || choice.FinishReason != "tool_calls" || choice.Message.Content != "Let me check." || choice.Message.ReasoningContent != "hmm" {
+		t.Fatalf("unexpected response: %+v", openaiResp)
+	}
+	if len(choice.Message.ToolCalls) != 1 || choice.Message.ToolCalls[0].Function.Arguments != `{"q":"cat"}` {
+		t.Fatalf("unexpected tool calls: %+v", choice.Message.ToolCalls)
+	}
+	if openaiResp.Usage.PromptTokens != 15 || openaiResp.Usage.PromptTokensDetails.CachedTokens != 10 {
+		t.Fatalf("unexpected usage: %+v", openaiResp.Usage)
+	}
+
+	events := []string{
+		`{"type":"message_start","message":{"id":"msg_2","model":"claude-sonnet-4-5","usage":{"input_tokens":5,"output_tokens":1}}}`,
+		`{"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`,
+		`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Hi"}}`,
+		`{"type":"content_block_start","index":1,"content_block":{"type":"tool_use","id":"toolu_2","name":"lookup","input":{}}}`,
+		`{"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"{\"q\":"}}`,
+		`{"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"\"dog\"}"}}`,
+		`{"type":"message_delta","delta":{"stop_reason":"tool_use"},"usage":{"output_tokens":7}}`,
+		`{"type":"message_stop"}`,
+	}
+
+	stream := NewClaudeOpenAIStreamConverter()
+	var text, args strings.Builder
+	var last *OpenAIStreamChunk
+	for i, event := range events {
+		chunks, err := stream.ProcessEvent([]byte(event))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		for _, chunk := range chunks {
+			if i == 0 && chunk.Choices[0].Delta.Role != ClaudeRoleAssistant {
+				t.Fatalf("first chunk should carry the role: %+v", chunk)
+			}
+			text.WriteString(chunk.Choices[0].Delta.Content)
+			for _, call := range chunk.Choices[0].Delta.ToolCalls {
+				args.WriteString(call.Function.Arguments)
+			}
+			last = chunk
+		}
+	}
+	if text.String() != "Hi" || args.String() != `{"q":"dog"}` {
+		t.Fatalf("unexpected stream content: %q %q", text.String(), args.String())
+	}
+	if last == nil || last.ID != "chatcmpl-2" || *last.Choices[0].FinishReason != "tool_calls" || last.Usage.CompletionTokens != 7 {
+		t.Fatalf("unexpected final chunk: %+v", last)
+	}
+
+	if _, err := stream.ProcessEvent([]byte(`{"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`)); err == nil || !strings.Contains(err.Error(), "Overloaded") {
+		t.Fatalf("expected upstream error, got %v", err)
+	}
+}
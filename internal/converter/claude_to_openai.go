@@ -6,8 +6,25 @@ import (
 	"strings"
 )
 
+// ClaudeToOpenAIOptions Claude→OpenAI 转换选项
+type ClaudeToOpenAIOptions struct {
+	// CacheHints 将 system、消息内容块上的 cache_control 保留为 OpenAI 内容块的 cache_control
+	// 仅 OpenRouter、DashScope 等支持该扩展的上游开启；工具定义与 tool_use 上的断点在 OpenAI 格式中无对应位置
+	CacheHints bool
+	// PDFTextExtraction 在进程内提取 PDF 文档的文本，用于不支持 file 内容块的上游；否则 PDF 以 file 内容块发送
+	PDFTextExtraction bool
+	// StreamUsage 流式请求携带 stream_options.include_usage，使上游在末尾 chunk 返回 token 用量（含缓存命中）
+	// 仅对接受该参数的上游开启，严格校验参数的上游会拒绝未知字段
+	StreamUsage bool
+}
+
 // ConvertClaudeToOpenAI 将 Claude Messages API 请求转换为 OpenAI Chat Completions API 请求
 func ConvertClaudeToOpenAI(req *ClaudeRequest) (*OpenAIRequest, error) {
+	return ConvertClaudeToOpenAIWithOptions(req, ClaudeToOpenAIOptions{})
+}
+
+// ConvertClaudeToOpenAIWithOptions 按转换选项将 Claude 请求转换为 OpenAI 请求
+func ConvertClaudeToOpenAIWithOptions(req *ClaudeRequest, opts ClaudeToOpenAIOptions) (*OpenAIRequest, error) {
 	// 验证输入
 	if err := ValidateNonNil(req, "Claude请求"); err != nil {
 		return nil, NewConversionError("request", "验证失败", err)
@@ -24,12 +41,17 @@ func ConvertClaudeToOpenAI(req *ClaudeRequest) (*OpenAIRequest, error) {
 	}
 
 	// 转换 messages
-	messages, err := convertMessages(req.Messages, req.System)
+	messages, err := convertMessages(req.Messages, req.System, opts)
 	if err != nil {
 		return nil, NewConversionError("request", "转换消息失败", err)
 	}
 	openaiReq.Messages = messages
 
+	// 流式请求需要显式开启 usage，否则上游不返回 token 用量（含缓存命中）
+	if req.Stream && opts.StreamUsage {
+		openaiReq.StreamOptions = &OpenAIStreamOptions{IncludeUsage: true}
+	}
+
 	// 转换 tools
 	if len(req.Tools) > 0 {
 		openaiReq.Tools = convertTools(req.Tools)
@@ -40,24 +62,41 @@ func ConvertClaudeToOpenAI(req *ClaudeRequest) (*OpenAIRequest, error) {
 		openaiReq.ToolChoice = convertToolChoice(req.ToolChoice)
 	}
 
+	// 转换 thinking 为推理参数
+	applyThinking(openaiReq, req.Thinking)
+
 	return openaiReq, nil
 }
 
 // convertMessages 转换消息数组
-func convertMessages(claudeMessages []ClaudeMessage, system string) ([]OpenAIMessage, error) {
+func convertMessages(claudeMessages []ClaudeMessage, system *ClaudeSystemPrompt, opts ClaudeToOpenAIOptions) ([]OpenAIMessage, error) {
 	var messages []OpenAIMessage
 
-	// 如果有 system 参数，添加为第一条消息
-	if system != "" {
+	// 如果有 system 参数，添加为第一条消息；需要保留缓存断点时使用内容块数组
+	if opts.CacheHints && system.HasCacheControl() {
+		var contentBlocks []OpenAIContentBlock
+		for _, block := range system.Blocks {
+			if block.Type != ContentTypeText {
+				continue
+			}
+			if converted, ok, _ := convertUserContentBlock(block, opts); ok {
+				contentBlocks = append(contentBlocks, converted)
+			}
+		}
 		messages = append(messages, OpenAIMessage{
 			Role:    "system",
-			Content: system,
+			Content: contentBlocks,
+		})
+	} else if text := system.PlainText(); text != "" {
+		messages = append(messages, OpenAIMessage{
+			Role:    "system",
+			Content: text,
 		})
 	}
 
 	// 转换每条消息
 	for _, msg := range claudeMessages {
-		converted, err := convertSingleMessage(msg)
+		converted, err := convertSingleMessage(msg, opts)
 		if err != nil {
 			return nil, fmt.Errorf("转换消息失败: %w", err)
 		}
@@ -68,7 +107,7 @@ func convertMessages(claudeMessages []ClaudeMessage, system string) ([]OpenAIMes
 }
 
 // convertSingleMessage 转换单条消息
-func convertSingleMessage(msg ClaudeMessage) ([]OpenAIMessage, error) {
+func convertSingleMessage(msg ClaudeMessage, opts ClaudeToOpenAIOptions) ([]OpenAIMessage, error) {
 	// 检查是否有 tool_result
 	hasToolResult := false
 	for _, block := range msg.Content {
@@ -80,74 +119,156 @@ func convertSingleMessage(msg ClaudeMessage) ([]OpenAIMessage, error) {
 
 	// 如果有 tool_result，需要特殊处理
 	if hasToolResult {
-		return convertToolResultMessage(msg)
+		return convertToolResultMessage(msg, opts)
 	}
 
 	// 普通消息转换
 	switch msg.Role {
 	case ClaudeRoleUser:
-		return []OpenAIMessage{convertUserMessage(msg)}, nil
+		converted, err := convertUserMessage(msg, opts)
+		if err != nil {
+			return nil, err
+		}
+		return []OpenAIMessage{converted}, nil
 	case ClaudeRoleAssistant:
-		return []OpenAIMessage{convertAssistantMessage(msg)}, nil
+		return []OpenAIMessage{convertAssistantMessage(msg, opts)}, nil
 	default:
 		return nil, fmt.Errorf("不支持的角色: %s", msg.Role)
 	}
 }
 
 // convertUserMessage 转换用户消息
-func convertUserMessage(msg ClaudeMessage) OpenAIMessage {
-	// 检查是否只有一个文本块
-	if len(msg.Content) == 1 && msg.Content[0].Type == ContentTypeText && msg.Content[0].Text != nil {
+func convertUserMessage(msg ClaudeMessage, opts ClaudeToOpenAIOptions) (OpenAIMessage, error) {
+	// 检查是否只有一个文本块（带缓存断点时保留数组形式）
+	if len(msg.Content) == 1 && msg.Content[0].Type == ContentTypeText && msg.Content[0].Text != nil &&
+		!(opts.CacheHints && msg.Content[0].CacheControl != nil) {
 		return OpenAIMessage{
 			Role:    ClaudeRoleUser,
 			Content: *msg.Content[0].Text,
-		}
+		}, nil
 	}
 
 	// 多个内容块，需要转换为数组
 	var contentBlocks []OpenAIContentBlock
 	for _, block := range msg.Content {
-		switch block.Type {
-		case ContentTypeText:
-			if block.Text != nil {
-				contentBlocks = append(contentBlocks, OpenAIContentBlock{
-					Type: ContentTypeText,
-					Text: block.Text,
-				})
-			}
-		case ContentTypeImage:
-			if block.Source != nil {
-				// 转换图片为 data URI
-				dataURI := fmt.Sprintf("data:%s;base64,%s",
-					block.Source.MediaType,
-					block.Source.Data)
-				contentBlocks = append(contentBlocks, OpenAIContentBlock{
-					Type: "image_url",
-					ImageURL: &OpenAIImageURL{
-						URL: dataURI,
-					},
-				})
-			}
+		converted, ok, err := convertUserContentBlock(block, opts)
+		if err != nil {
+			return OpenAIMessage{}, err
+		}
+		if ok {
+			contentBlocks = append(contentBlocks, converted)
 		}
 	}
 
 	return OpenAIMessage{
 		Role:    ClaudeRoleUser,
 		Content: contentBlocks,
+	}, nil
+}
+
+// convertUserContentBlock 转换用户消息中的 text / image / document 内容块，其他类型返回 false
+// 图片与文档无法转换时返回错误，不静默丢弃
+func convertUserContentBlock(block ClaudeContentBlock, opts ClaudeToOpenAIOptions) (OpenAIContentBlock, bool, error) {
+	var cacheControl *ClaudeCacheControl
+	if opts.CacheHints {
+		cacheControl = block.CacheControl
+	}
+
+	switch block.Type {
+	case ContentTypeText:
+		if block.Text != nil {
+			return OpenAIContentBlock{
+				Type:         ContentTypeText,
+				Text:         block.Text,
+				CacheControl: cacheControl,
+			}, true, nil
+		}
+	case ContentTypeImage:
+		url, err := imageSourceURL(block.Source)
+		if err != nil {
+			return OpenAIContentBlock{}, false, err
+		}
+		return OpenAIContentBlock{
+			Type: "image_url",
+			ImageURL: &OpenAIImageURL{
+				URL: url,
+			},
+			CacheControl: cacheControl,
+		}, true, nil
+	case ContentTypeDocument:
+		converted, err := convertDocumentBlock(block, opts)
+		if err != nil {
+			return OpenAIContentBlock{}, false, err
+		}
+		converted.CacheControl = cacheControl
+		return converted, true, nil
+	}
+	return OpenAIContentBlock{}, false, nil
+}
+
+// imageSourceURL 将图片来源转换为 image_url：base64 转为 data URI，url 原样传递
+func imageSourceURL(source *ClaudeImageSource) (string, error) {
+	if source == nil {
+		return "", fmt.Errorf("image 缺少 source")
+	}
+
+	switch source.Type {
+	case "base64":
+		return fmt.Sprintf("data:%s;base64,%s", source.MediaType, source.Data), nil
+	case "url":
+		if source.URL == "" {
+			return "", fmt.Errorf("url 类型的 image 缺少 url")
+		}
+		return source.URL, nil
+	default:
+		return "", fmt.Errorf("不支持的图片来源类型: %s", source.Type)
+	}
+}
+
+// convertDocumentBlock 转换 document 块：文本类文档转为 text，PDF 转为 file 内容块或提取的文本
+func convertDocumentBlock(block ClaudeContentBlock, opts ClaudeToOpenAIOptions) (OpenAIContentBlock, error) {
+	text, ok, err := documentText(block, opts.PDFTextExtraction)
+	if err != nil {
+		return OpenAIContentBlock{}, err
+	}
+	if ok {
+		return OpenAIContentBlock{Type: ContentTypeText, Text: StringPtr(text)}, nil
+	}
+
+	source := block.Source
+	switch {
+	case source.Type == "base64" && source.MediaType == mediaTypePDF:
+		return OpenAIContentBlock{
+			Type: "file",
+			File: &OpenAIFile{
+				Filename: documentFilename(block),
+				FileData: fmt.Sprintf("data:%s;base64,%s", source.MediaType, source.Data),
+			},
+		}, nil
+	case source.Type == "url":
+		return OpenAIContentBlock{}, fmt.Errorf("OpenAI 格式不支持 URL 形式的文档，请以 base64 发送: %s", source.URL)
+	default:
+		return OpenAIContentBlock{}, fmt.Errorf("不支持的文档来源: type=%s media_type=%s", source.Type, source.MediaType)
 	}
 }
 
 // convertAssistantMessage 转换助手消息
-func convertAssistantMessage(msg ClaudeMessage) OpenAIMessage {
+func convertAssistantMessage(msg ClaudeMessage, opts ClaudeToOpenAIOptions) OpenAIMessage {
 	var textParts []string
+	var textBlocks []OpenAIContentBlock
 	var toolCalls []OpenAIToolCall
+	hasCacheControl := false
 
 	// 分离文本和工具调用
+	// thinking / redacted_thinking 块不回传给上游：OpenAI 兼容接口不接受历史推理内容
 	for _, block := range msg.Content {
 		switch block.Type {
 		case ContentTypeText:
 			if block.Text != nil {
 				textParts = append(textParts, *block.Text)
+				converted, _, _ := convertUserContentBlock(block, opts)
+				textBlocks = append(textBlocks, converted)
+				hasCacheControl = hasCacheControl || converted.CacheControl != nil
 			}
 		case ContentTypeToolUse:
 			if block.ID != nil && block.Name != nil {
@@ -165,8 +286,11 @@ func convertAssistantMessage(msg ClaudeMessage) OpenAIMessage {
 		}
 	}
 
-	// 合并文本内容
-	content := strings.Join(textParts, "")
+	// 合并文本内容；带缓存断点时保留文本块数组
+	var content interface{} = strings.Join(textParts, "")
+	if hasCacheControl {
+		content = textBlocks
+	}
 
 	return OpenAIMessage{
 		Role:      ClaudeRoleAssistant,
@@ -176,27 +300,88 @@ func convertAssistantMessage(msg ClaudeMessage) OpenAIMessage {
 }
 
 // convertToolResultMessage 转换 tool_result 消息
-func convertToolResultMessage(msg ClaudeMessage) ([]OpenAIMessage, error) {
+// OpenAI tool 消息只接受文本：tool_result 中的图片与同一消息中的其他内容块放入紧随其后的 user 消息，
+// is_error 以内容前缀表示
+func convertToolResultMessage(msg ClaudeMessage, opts ClaudeToOpenAIOptions) ([]OpenAIMessage, error) {
 	var messages []OpenAIMessage
+	var followUp []OpenAIContentBlock
 
 	// 每个 tool_result 转换为独立的消息
 	for _, block := range msg.Content {
-		if block.Type == ContentTypeToolResult {
-			if block.ToolUseID == nil {
-				return nil, fmt.Errorf("tool_result 缺少 tool_use_id")
+		if block.Type != ContentTypeToolResult {
+			converted, ok, err := convertUserContentBlock(block, opts)
+			if err != nil {
+				return nil, err
 			}
-
-			content := ""
-			if block.Content != nil {
-				content = *block.Content
+			if ok {
+				followUp = append(followUp, converted)
 			}
+			continue
+		}
 
-			messages = append(messages, OpenAIMessage{
-				Role:       "tool",
-				Content:    content,
-				ToolCallID: *block.ToolUseID,
+		if block.ToolUseID == nil {
+			return nil, fmt.Errorf("tool_result 缺少 tool_use_id")
+		}
+
+		content := block.Content.PlainText()
+		if images := block.Content.Images(); len(images) > 0 {
+			if content == "" {
+				content = fmt.Sprintf("(%d image(s) returned, attached in the next user message)", len(images))
+			}
+			followUp = append(followUp, OpenAIContentBlock{
+				Type: ContentTypeText,
+				Text: StringPtr(fmt.Sprintf("Images returned by tool call %s:", *block.ToolUseID)),
 			})
+			for _, image := range images {
+				converted, _, err := convertUserContentBlock(image, opts)
+				if err != nil {
+					return nil, err
+				}
+				followUp = append(followUp, converted)
+			}
+		}
+		if documents := block.Content.Documents(); len(documents) > 0 {
+			if content == "" {
+				content = fmt.Sprintf("(%d document(s) returned, attached in the next user message)", len(documents))
+			}
+			followUp = append(followUp, OpenAIContentBlock{
+				Type: ContentTypeText,
+				Text: StringPtr(fmt.Sprintf("Documents returned by tool call %s:", *block.ToolUseID)),
+			})
+			for _, document := range documents {
+				converted, _, err := convertUserContentBlock(document, opts)
+				if err != nil {
+					return nil, err
+				}
+				followUp = append(followUp, converted)
+			}
+		}
+		if block.IsError != nil && *block.IsError {
+			content = toolResultErrorPrefix + content
+		}
+
+		// 带缓存断点时 tool 消息使用文本块数组
+		var toolContent interface{} = content
+		if opts.CacheHints && block.CacheControl != nil {
+			toolContent = []OpenAIContentBlock{{
+				Type:         ContentTypeText,
+				Text:         StringPtr(content),
+				CacheControl: block.CacheControl,
+			}}
 		}
+
+		messages = append(messages, OpenAIMessage{
+			Role:       "tool",
+			Content:    toolContent,
+			ToolCallID: *block.ToolUseID,
+		})
+	}
+
+	if len(followUp) > 0 {
+		messages = append(messages, OpenAIMessage{
+			Role:    ClaudeRoleUser,
+			Content: followUp,
+		})
 	}
 
 	return messages, nil
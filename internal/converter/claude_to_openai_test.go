@@ -58,7 +58,7 @@ func TestConvertBasicFields(t *testing.T) {
 func TestConvertSystemMessage(t *testing.T) {
 	req := &ClaudeRequest{
 		Model:   "claude-3-5-sonnet",
-		System:  "You are a helpful assistant",
+		System:  NewSystemPrompt("You are a helpful assistant"),
 		MaxTokens: 1024,
 		Messages: []ClaudeMessage{
 			{
@@ -303,7 +303,7 @@ func TestConvertToolResultMessage(t *testing.T) {
 					{
 						Type:      "tool_result",
 						ToolUseID: StringPtr("toolu_123"),
-						Content:   StringPtr(`{"temperature": 72, "unit": "fahrenheit"}`),
+						Content:   NewToolResultText(`{"temperature": 72, "unit": "fahrenheit"}`),
 					},
 				},
 			},
@@ -507,3 +507,44 @@ func BenchmarkConvertClaudeToOpenAI(b *testing.B) {
 		_, _ = ConvertClaudeToOpenAI(req)
 	}
 }
+
+// 测试 cache_control 缓存断点映射
+func TestConvertCacheControlHints(t *testing.T) {
+	raw := `{"model":"anthropic/claude-sonnet-4.5","max_tokens":1024,
+		"system":[{"type":"text","text":"static"},{"type":"text","text":"cached","cache_control":{"type":"ephemeral"}}],
+		"messages":[
+			{"role":"user","content":[{"type":"text","text":"q","cache_control":{"type":"ephemeral"}}]},
+			{"role":"assistant","content":[{"type":"text","text":"a","cache_control":{"type":"ephemeral"}},{"type":"tool_use","id":"toolu_1","name":"f","input":{}}]},
+			{"role":"user","content":[{"type":"tool_result","tool_use_id":"toolu_1","content":"r","cache_control":{"type":"ephemeral","ttl":"1h"}}]}
+		]}`
+	var req ClaudeRequest
+	if err := json.Unmarshal([]byte(raw), &req); err != nil {
+		t.Fatalf("解析请求失败: %v", err)
+	}
+
+	withHints, err := ConvertClaudeToOpenAIWithOptions(&req, ClaudeToOpenAIOptions{CacheHints: true})
+	if err != nil {
+		t.Fatalf("转换失败: %v", err)
+	}
+	got, _ := json.Marshal(withHints.Messages)
+	want := `[{"role":"system","content":[{"type":"text","text":"static"},{"type":"text","text":"cached","cache_control":{"type":"ephemeral"}}]},` +
+		`{"role":"user","content":[{"type":"text","text":"q","cache_control":{"type":"ephemeral"}}]},` +
+		`{"role":"assistant","content":[{"type":"text","text":"a","cache_control":{"type":"ephemeral"}}],"tool_calls":[{"id":"toolu_1","type":"function","function":{"name":"f","arguments":"{}"}}]},` +
+		`{"role":"tool","content":[{"type":"text","text":"r","cache_control":{"type":"ephemeral","ttl":"1h"}}],"tool_call_id":"toolu_1"}]`
+	if string(got) != want {
+		t.Errorf("缓存断点映射不匹配:\ngot:  %s\nwant: %s", got, want)
+	}
+
+	// 未开启时输出与普通转换一致（system 合并为字符串，不携带 cache_control）
+	plain, err := ConvertClaudeToOpenAI(&req)
+	if err != nil {
+		t.Fatalf("转换失败: %v", err)
+	}
+	got, _ = json.Marshal(plain.Messages)
+	want = `[{"role":"system","content":"static\ncached"},{"role":"user","content":"q"},` +
+		`{"role":"assistant","content":"a","tool_calls":[{"id":"toolu_1","type":"function","function":{"name":"f","arguments":"{}"}}]},` +
+		`{"role":"tool","content":"r","tool_call_id":"toolu_1"}]`
+	if string(got) != want {
+		t.Errorf("未开启缓存提示时不应保留断点:\ngot:  %s\nwant: %s", got, want)
+	}
+}
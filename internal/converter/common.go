@@ -178,6 +178,7 @@ const (
 	// OpenAI 相关常量
 	OpenAIObjectChatCompletion      = "chat.completion"
 	OpenAIObjectChatCompletionChunk = "chat.completion.chunk"
+	OpenAIObjectTextCompletion      = "text_completion"
 	OpenAIToolTypeFunction          = "function"
 
 	// Claude 相关常量
@@ -188,8 +189,11 @@ const (
 	// Content Block Types
 	ContentTypeText       = "text"
 	ContentTypeImage      = "image"
+	ContentTypeDocument   = "document"
 	ContentTypeToolUse    = "tool_use"
 	ContentTypeToolResult = "tool_result"
+	ContentTypeThinking   = "thinking"
+	ContentTypeRedactedThinking = "redacted_thinking"
 
 	// Claude Stream Event Types
 	EventTypeMessageStart       = "message_start"
@@ -202,4 +206,6 @@ const (
 	// Delta Types
 	DeltaTypeTextDelta      = "text_delta"
 	DeltaTypeInputJSONDelta = "input_json_delta"
+	DeltaTypeThinkingDelta  = "thinking_delta"
+	DeltaTypeSignatureDelta = "signature_delta"
 )
@@ -0,0 +1,180 @@
+package converter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// DefaultCompletionMaxTokens 旧版 Completions 未指定 max_tokens 时的默认值（与 OpenAI 一致）
+const DefaultCompletionMaxTokens = 16
+
+// completionPassthroughFields 可原样带入 Chat Completions 请求的旧版 Completions 参数
+var completionPassthroughFields = []string{
+	"model", "max_tokens", "temperature", "top_p", "n", "stream", "stream_options", "stop",
+	"presence_penalty", "frequency_penalty", "logit_bias", "seed", "user",
+}
+
+// ConvertCompletionToChat 将旧版 Completions 请求转换为 Chat Completions 请求（prompt 作为单条 user 消息）
+// suffix、echo、best_of、logprobs 在 Chat Completions 中没有等价参数，出现时返回错误而不是静默忽略
+func ConvertCompletionToChat(req map[string]interface{}) (map[string]interface{}, error) {
+	if err := ValidateNonNil(req, "Completions请求"); err != nil {
+		return nil, NewConversionError("request", "验证失败", err)
+	}
+
+	prompt, err := completionPrompt(req["prompt"])
+	if err != nil {
+		return nil, NewConversionError("request", "转换 prompt 失败", err)
+	}
+
+	if suffix, ok := req["suffix"].(string); ok && suffix != "" {
+		return nil, NewConversionError("request", "Chat Completions 上游不支持 suffix", nil)
+	}
+	if echo, ok := req["echo"].(bool); ok && echo {
+		return nil, NewConversionError("request", "Chat Completions 上游不支持 echo", nil)
+	}
+	if bestOf, ok := req["best_of"].(float64); ok && bestOf > 1 {
+		return nil, NewConversionError("request", "Chat Completions 上游不支持 best_of", nil)
+	}
+	if logprobs, ok := req["logprobs"]; ok && logprobs != nil {
+		return nil, NewConversionError("request", "Chat Completions 上游不支持旧版 logprobs", nil)
+	}
+
+	chatReq := map[string]interface{}{
+		"messages": []interface{}{
+			map[string]interface{}{"role": ClaudeRoleUser, "content": prompt},
+		},
+	}
+	for _, field := range completionPassthroughFields {
+		if value, ok := req[field]; ok && value != nil {
+			chatReq[field] = value
+		}
+	}
+	if _, ok := chatReq["max_tokens"]; !ok {
+		chatReq["max_tokens"] = DefaultCompletionMaxTokens
+	}
+	return chatReq, nil
+}
+
+// completionPrompt 提取 prompt 文本
+// 字符串数组表示多个独立补全，转换后只能发送一条消息，因此仅接受单元素数组；token 数组无法转换
+func completionPrompt(prompt interface{}) (string, error) {
+	switch v := prompt.(type) {
+	case nil:
+		return "", fmt.Errorf("缺少 prompt 参数")
+	case string:
+		return v, nil
+	case []interface{}:
+		if len(v) != 1 {
+			return "", fmt.Errorf("转换为 Chat Completions 时 prompt 数组只能包含一个元素，当前为 %d 个", len(v))
+		}
+		if text, ok := v[0].(string); ok {
+			return text, nil
+		}
+	}
+	return "", fmt.Errorf("转换为 Chat Completions 时 prompt 只支持文本，不支持 token 数组")
+}
+
+// ConvertChatToCompletion 将 Chat Completions 响应转换为旧版 Completions 响应
+func ConvertChatToCompletion(resp *OpenAIResponse) (*CompletionResponse, error) {
+	if err := ValidateNonNil(resp, "OpenAI响应"); err != nil {
+		return nil, NewConversionError("response", "验证失败", err)
+	}
+
+	choices := make([]CompletionChoice, 0, len(resp.Choices))
+	for _, choice := range resp.Choices {
+		finishReason := choice.FinishReason
+		choices = append(choices, CompletionChoice{
+			Text:         ExtractTextFromContent(choice.Message.Content),
+			Index:        choice.Index,
+			FinishReason: &finishReason,
+		})
+	}
+
+	usage := resp.Usage
+	return &CompletionResponse{
+		ID:      completionID(resp.ID),
+		Object:  OpenAIObjectTextCompletion,
+		Created: resp.Created,
+		Model:   resp.Model,
+		Choices: choices,
+		Usage:   &usage,
+	}, nil
+}
+
+// ConvertChatStreamToCompletion 将 Chat Completions 流式响应转换为旧版 Completions 流式响应（以 data: [DONE] 结尾）
+// 无法解析为 chunk 的数据（如上游错误对象）原样转发
+func ConvertChatStreamToCompletion(ctx context.Context, chatStream io.Reader) io.Reader {
+	pipeReader, pipeWriter := io.Pipe()
+
+	go func() {
+		defer pipeWriter.Close()
+
+		parser := NewSSEParser(chatStream)
+		for {
+			select {
+			case <-ctx.Done():
+				pipeWriter.CloseWithError(ctx.Err())
+				return
+			default:
+			}
+
+			eventData, err := parser.ParseEvent()
+			if err == io.EOF || eventData == "[DONE]" {
+				pipeWriter.Write([]byte("data: [DONE]\n\n"))
+				return
+			}
+			if err != nil {
+				pipeWriter.CloseWithError(err)
+				return
+			}
+			if eventData == "" {
+				continue
+			}
+
+			event := "data: " + eventData + "\n\n"
+			var chunk OpenAIStreamChunk
+			if json.Unmarshal([]byte(eventData), &chunk) == nil && chunk.Object == OpenAIObjectChatCompletionChunk {
+				if event, err = formatOpenAIStreamData(convertChatChunkToCompletion(&chunk)); err != nil {
+					pipeWriter.CloseWithError(err)
+					return
+				}
+			}
+			if !writeStreamEvents(pipeWriter, []string{event}) {
+				return
+			}
+		}
+	}()
+
+	return pipeReader
+}
+
+// convertChatChunkToCompletion 转换单个流式 chunk（只保留文本增量）
+func convertChatChunkToCompletion(chunk *OpenAIStreamChunk) *CompletionResponse {
+	choices := make([]CompletionChoice, 0, len(chunk.Choices))
+	for _, choice := range chunk.Choices {
+		choices = append(choices, CompletionChoice{
+			Text:         choice.Delta.Content,
+			Index:        choice.Index,
+			FinishReason: choice.FinishReason,
+		})
+	}
+	return &CompletionResponse{
+		ID:      completionID(chunk.ID),
+		Object:  OpenAIObjectTextCompletion,
+		Created: chunk.Created,
+		Model:   chunk.Model,
+		Choices: choices,
+		Usage:   chunk.Usage,
+	}
+}
+
+// completionID 将 chatcmpl- 前缀替换为 cmpl-
+func completionID(chatID string) string {
+	if strings.HasPrefix(chatID, "chatcmpl-") {
+		return "cmpl-" + strings.TrimPrefix(chatID, "chatcmpl-")
+	}
+	return chatID
+}
@@ -0,0 +1,75 @@
+package converter
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestConvertCompletionToChat(t *testing.T) {
+	var req map[string]interface{}
+	if err := json.Unmarshal([]byte(`{"model":"m","prompt":"Say hi","n":2,"logit_bias":{"50256":-100},"stream":true,"stream_options":{"include_usage":true}}`), &req); err != nil {
+		t.Fatalf("failed to decode request: %v", err)
+	}
+
+	chatReq, err := ConvertCompletionToChat(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if chatReq["n"] != float64(2) || chatReq["stream"] != true || chatReq["logit_bias"] == nil || chatReq["stream_options"] == nil {
+		t.Fatalf("expected passthrough fields to be preserved: %+v", chatReq)
+	}
+	if chatReq["max_tokens"] != DefaultCompletionMaxTokens {
+		t.Fatalf("expected default max_tokens, got %v", chatReq["max_tokens"])
+	}
+	if _, ok := chatReq["prompt"]; ok {
+		t.Fatalf("prompt should be converted to messages: %+v", chatReq)
+	}
+
+	for _, body := range []string{
+		`{"model":"m"}`,
+		`{"model":"m","prompt":[]}`,
+		`{"model":"m","prompt":"a","best_of":3}`,
+		`{"model":"m","prompt":"a","logprobs":5}`,
+	} {
+		var invalid map[string]interface{}
+		_ = json.Unmarshal([]byte(body), &invalid)
+		if _, err := ConvertCompletionToChat(invalid); err == nil {
+			t.Fatalf("expected %s to be rejected", body)
+		}
+	}
+}
+
+func TestConvertChatStreamToCompletion(t *testing.T) {
+	stream := strings.Join([]string{
+		`data: {"id":"chatcmpl-1","object":"chat.completion.chunk","created":1,"model":"m","choices":[{"index":0,"delta":{"role":"assistant","content":"Hi"},"finish_reason":null}]}`,
+		`data: {"id":"chatcmpl-1","object":"chat.completion.chunk","created":1,"model":"m","choices":[{"index":0,"delta":{},"finish_reason":"stop"}]}`,
+		`data: {"id":"chatcmpl-1","object":"chat.completion.chunk","created":1,"model":"m","choices":[],"usage":{"prompt_tokens":3,"completion_tokens":1,"total_tokens":4}}`,
+		`data: {"error":{"message":"boom"}}`,
+	}, "\n\n") + "\n\n"
+
+	out, err := io.ReadAll(ConvertChatStreamToCompletion(context.Background(), strings.NewReader(stream)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	events := strings.Split(strings.TrimSpace(string(out)), "\n\n")
+	if len(events) != 5 || events[4] != "data: [DONE]" {
+		t.Fatalf("unexpected events:\n%s", out)
+	}
+
+	var first, last CompletionResponse
+	_ = json.Unmarshal([]byte(strings.TrimPrefix(events[0], "data: ")), &first)
+	_ = json.Unmarshal([]byte(strings.TrimPrefix(events[2], "data: ")), &last)
+	if first.ID != "cmpl-1" || first.Choices[0].Text != "Hi" || first.Choices[0].FinishReason != nil {
+		t.Fatalf("unexpected first chunk: %s", events[0])
+	}
+	if last.Usage == nil || last.Usage.TotalTokens != 4 {
+		t.Fatalf("expected usage chunk, got %s", events[2])
+	}
+	if events[3] != `data: {"error":{"message":"boom"}}` {
+		t.Fatalf("expected error data to pass through, got %s", events[3])
+	}
+}
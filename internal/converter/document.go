@@ -0,0 +1,83 @@
+package converter
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+// mediaTypePDF PDF 文档的 MIME 类型
+const mediaTypePDF = "application/pdf"
+
+// documentText 提取 document 块的文本：text / content 来源、base64 编码的 text/* 文档，
+// 以及 extractPDF 时的 base64 PDF；其余来源（PDF、URL 等）返回 false，由调用方决定原生传递或报错
+func documentText(block ClaudeContentBlock, extractPDF bool) (string, bool, error) {
+	source := block.Source
+	if source == nil {
+		return "", false, fmt.Errorf("document 缺少 source")
+	}
+
+	var text string
+	switch {
+	case source.Type == "text":
+		text = source.Data
+
+	case source.Type == "content":
+		var texts []string
+		for _, item := range source.Content {
+			if item.Type != ContentTypeText || item.Text == nil {
+				return "", false, fmt.Errorf("document 的 content 来源只支持 text 内容块，不支持 %s", item.Type)
+			}
+			texts = append(texts, *item.Text)
+		}
+		text = strings.Join(texts, "\n")
+
+	case source.Type == "base64" && strings.HasPrefix(source.MediaType, "text/"):
+		data, err := base64.StdEncoding.DecodeString(source.Data)
+		if err != nil {
+			return "", false, fmt.Errorf("解码 %s 文档失败: %w", source.MediaType, err)
+		}
+		text = string(data)
+
+	case source.Type == "base64" && source.MediaType == mediaTypePDF && extractPDF:
+		data, err := base64.StdEncoding.DecodeString(source.Data)
+		if err != nil {
+			return "", false, fmt.Errorf("解码 PDF 文档失败: %w", err)
+		}
+		text, err = ExtractPDFText(data)
+		if err != nil {
+			return "", false, fmt.Errorf("提取 PDF 文本失败: %w", err)
+		}
+
+	default:
+		return "", false, nil
+	}
+
+	return formatDocumentText(block, text), true, nil
+}
+
+// formatDocumentText 在文档文本前附加 title / context，使上游仍能区分文档来源
+func formatDocumentText(block ClaudeContentBlock, text string) string {
+	var header []string
+	if block.Title != nil && *block.Title != "" {
+		header = append(header, "Document: "+*block.Title)
+	}
+	if block.Context != nil && *block.Context != "" {
+		header = append(header, "Context: "+*block.Context)
+	}
+	if len(header) == 0 {
+		return text
+	}
+	return strings.Join(header, "\n") + "\n\n" + text
+}
+
+// documentFilename 返回 PDF 文档的文件名（优先使用 title）
+func documentFilename(block ClaudeContentBlock) string {
+	if block.Title == nil || *block.Title == "" {
+		return "document.pdf"
+	}
+	if strings.HasSuffix(strings.ToLower(*block.Title), ".pdf") {
+		return *block.Title
+	}
+	return *block.Title + ".pdf"
+}
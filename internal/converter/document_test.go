@@ -0,0 +1,150 @@
+package converter
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// convertUserContent 将单条用户消息的内容块转换为 OpenAI 内容块 JSON
+func convertUserContent(t *testing.T, content string, opts ClaudeToOpenAIOptions) (string, error) {
+	t.Helper()
+	var blocks []ClaudeContentBlock
+	if err := json.Unmarshal([]byte(content), &blocks); err != nil {
+		t.Fatalf("解析内容块失败: %v", err)
+	}
+	req := &ClaudeRequest{Model: "gpt-4o", MaxTokens: 1024, Messages: []ClaudeMessage{{Role: "user", Content: blocks}}}
+	result, err := ConvertClaudeToOpenAIWithOptions(req, opts)
+	if err != nil {
+		return "", err
+	}
+	encoded, _ := json.Marshal(result.Messages[0].Content)
+	return string(encoded), nil
+}
+
+// 测试 image / document 内容块按来源类型转换为 OpenAI 内容块
+func TestConvertImageAndDocumentSources(t *testing.T) {
+	pdf := base64.StdEncoding.EncodeToString(buildTestPDF("BT (Invoice total: 42) Tj ET", true))
+	plain := base64.StdEncoding.EncodeToString([]byte("line one\nline two"))
+
+	tests := []struct {
+		name    string
+		content string
+		opts    ClaudeToOpenAIOptions
+		want    string
+	}{
+		{
+			"base64 图片",
+			`[{"type":"image","source":{"type":"base64","media_type":"image/png","data":"iVBOR"}}]`,
+			ClaudeToOpenAIOptions{},
+			`[{"type":"image_url","image_url":{"url":"data:image/png;base64,iVBOR"}}]`,
+		},
+		{
+			"URL 图片原样传递",
+			`[{"type":"image","source":{"type":"url","url":"https://example.com/cat.jpg"}}]`,
+			ClaudeToOpenAIOptions{},
+			`[{"type":"image_url","image_url":{"url":"https://example.com/cat.jpg"}}]`,
+		},
+		{
+			"text 来源文档",
+			`[{"type":"document","source":{"type":"text","media_type":"text/plain","data":"hello"},"title":"notes.txt"}]`,
+			ClaudeToOpenAIOptions{},
+			`[{"type":"text","text":"Document: notes.txt\n\nhello"}]`,
+		},
+		{
+			"base64 text/plain 文档",
+			`[{"type":"document","source":{"type":"base64","media_type":"text/plain","data":"` + plain + `"}}]`,
+			ClaudeToOpenAIOptions{},
+			`[{"type":"text","text":"line one\nline two"}]`,
+		},
+		{
+			"content 来源文档",
+			`[{"type":"document","source":{"type":"content","content":[{"type":"text","text":"a"},{"type":"text","text":"b"}]},"context":"chunks"}]`,
+			ClaudeToOpenAIOptions{},
+			`[{"type":"text","text":"Context: chunks\n\na\nb"}]`,
+		},
+		{
+			"PDF 以 file 内容块发送",
+			`[{"type":"document","source":{"type":"base64","media_type":"application/pdf","data":"JVBERi0="},"title":"invoice"}]`,
+			ClaudeToOpenAIOptions{},
+			`[{"type":"file","file":{"filename":"invoice.pdf","file_data":"data:application/pdf;base64,JVBERi0="}}]`,
+		},
+		{
+			"PDF 提取文本",
+			`[{"type":"document","source":{"type":"base64","media_type":"application/pdf","data":"` + pdf + `"}},{"type":"text","text":"Summarize"}]`,
+			ClaudeToOpenAIOptions{PDFTextExtraction: true},
+			`[{"type":"text","text":"Invoice total: 42"},{"type":"text","text":"Summarize"}]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := convertUserContent(t, tt.content, tt.opts)
+			if err != nil {
+				t.Fatalf("转换失败: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("转换结果不匹配:\ngot:  %s\nwant: %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// 测试无法转换的图片与文档返回错误而不是被丢弃
+func TestConvertUnsupportedSourcesFail(t *testing.T) {
+	scanned := base64.StdEncoding.EncodeToString(buildTestPDF("q /Im1 Do Q", false))
+
+	tests := []struct {
+		name    string
+		content string
+		opts    ClaudeToOpenAIOptions
+		wantErr string
+	}{
+		{"图片缺少 source", `[{"type":"image"}]`, ClaudeToOpenAIOptions{}, "image 缺少 source"},
+		{"未知图片来源", `[{"type":"image","source":{"type":"file","file_id":"file_1"}}]`, ClaudeToOpenAIOptions{}, "不支持的图片来源类型"},
+		{"URL 文档", `[{"type":"document","source":{"type":"url","url":"https://example.com/a.pdf"}}]`, ClaudeToOpenAIOptions{}, "URL 形式的文档"},
+		{"扫描件 PDF", `[{"type":"document","source":{"type":"base64","media_type":"application/pdf","data":"` + scanned + `"}}]`, ClaudeToOpenAIOptions{PDFTextExtraction: true}, "提取 PDF 文本失败"},
+		{"tool_result 中的 URL 文档", `[{"type":"tool_result","tool_use_id":"toolu_1","content":[{"type":"document","source":{"type":"url","url":"https://example.com/a.pdf"}}]}]`, ClaudeToOpenAIOptions{}, "URL 形式的文档"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := convertUserContent(t, tt.content, tt.opts)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("应返回包含 %q 的错误, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+// 测试 Gemini 转换中 URL 图片与文档的处理
+func TestConvertClaudeToGeminiDocuments(t *testing.T) {
+	raw := `{"model":"gemini-2.5-pro","max_tokens":1024,"messages":[{"role":"user","content":[
+		{"type":"image","source":{"type":"url","url":"https://example.com/cat.png"}},
+		{"type":"document","source":{"type":"base64","media_type":"application/pdf","data":"JVBERi0="}},
+		{"type":"document","source":{"type":"text","media_type":"text/plain","data":"hello"}}
+	]}]}`
+	var req ClaudeRequest
+	if err := json.Unmarshal([]byte(raw), &req); err != nil {
+		t.Fatalf("解析请求失败: %v", err)
+	}
+
+	result, err := ConvertClaudeToGemini(&req)
+	if err != nil {
+		t.Fatalf("转换失败: %v", err)
+	}
+	parts := result.Contents[0].Parts
+	if len(parts) != 3 {
+		t.Fatalf("应有 3 个 part, got %d", len(parts))
+	}
+	if parts[0].FileData == nil || parts[0].FileData.FileURI != "https://example.com/cat.png" || parts[0].FileData.MimeType != "image/png" {
+		t.Errorf("URL 图片应转换为 fileData: %+v", parts[0])
+	}
+	if parts[1].InlineData == nil || parts[1].InlineData.MimeType != "application/pdf" {
+		t.Errorf("PDF 应以 inlineData 传递: %+v", parts[1])
+	}
+	if parts[2].Text == nil || *parts[2].Text != "hello" {
+		t.Errorf("文本文档应转换为 text: %+v", parts[2])
+	}
+}
@@ -0,0 +1,147 @@
+package converter
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Embeddings encoding_format
+const (
+	EmbeddingEncodingFloat  = "float"
+	EmbeddingEncodingBase64 = "base64"
+)
+
+// ValidateEmbeddingRequest 校验 Embeddings 请求的通用参数
+func ValidateEmbeddingRequest(req *EmbeddingRequest) error {
+	if err := ValidateNonNil(req, "Embeddings请求"); err != nil {
+		return err
+	}
+	if req.Input == nil {
+		return fmt.Errorf("缺少 input 参数")
+	}
+	if items, ok := req.Input.([]interface{}); ok && len(items) == 0 {
+		return fmt.Errorf("input 不能为空数组")
+	}
+	switch req.EncodingFormat {
+	case "", EmbeddingEncodingFloat, EmbeddingEncodingBase64:
+	default:
+		return fmt.Errorf("不支持的 encoding_format: %s", req.EncodingFormat)
+	}
+	if req.Dimensions != nil && *req.Dimensions <= 0 {
+		return fmt.Errorf("dimensions 必须为正整数")
+	}
+	return nil
+}
+
+// EmbeddingInputTexts 将 input 转换为文本列表（单个字符串视为一条）
+// token 数组形式的 input 只有 OpenAI 兼容上游能处理，转换时返回错误
+func EmbeddingInputTexts(input interface{}) ([]string, error) {
+	switch v := input.(type) {
+	case string:
+		return []string{v}, nil
+	case []string:
+		return v, nil
+	case []interface{}:
+		texts := make([]string, 0, len(v))
+		for _, item := range v {
+			text, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("该供应商只支持文本 input，不支持 token 数组")
+			}
+			texts = append(texts, text)
+		}
+		return texts, nil
+	}
+	return nil, fmt.Errorf("不支持的 input 类型: %T", input)
+}
+
+// ConvertEmbeddingToGemini 将 Embeddings 请求转换为 Gemini batchEmbedContents 请求
+func ConvertEmbeddingToGemini(req *EmbeddingRequest, model string) (*GeminiBatchEmbedRequest, error) {
+	texts, err := EmbeddingInputTexts(req.Input)
+	if err != nil {
+		return nil, NewConversionError("request", "转换 input 失败", err)
+	}
+
+	modelName := "models/" + strings.TrimPrefix(model, "models/")
+	geminiReq := &GeminiBatchEmbedRequest{Requests: make([]GeminiEmbedContentRequest, 0, len(texts))}
+	for _, text := range texts {
+		geminiReq.Requests = append(geminiReq.Requests, GeminiEmbedContentRequest{
+			Model:                modelName,
+			Content:              GeminiContent{Parts: []GeminiPart{{Text: StringPtr(text)}}},
+			OutputDimensionality: req.Dimensions,
+		})
+	}
+	return geminiReq, nil
+}
+
+// ConvertGeminiEmbeddingToOpenAI 将 Gemini batchEmbedContents 响应转换为 Embeddings 响应
+// Gemini 不返回 token 用量，usage 为 0
+func ConvertGeminiEmbeddingToOpenAI(resp *GeminiBatchEmbedResponse, model, encodingFormat string) (*EmbeddingResponse, error) {
+	if err := ValidateNonNil(resp, "Gemini响应"); err != nil {
+		return nil, NewConversionError("response", "验证失败", err)
+	}
+
+	vectors := make([][]float64, 0, len(resp.Embeddings))
+	for _, embedding := range resp.Embeddings {
+		vectors = append(vectors, embedding.Values)
+	}
+	return newEmbeddingResponse(vectors, model, encodingFormat, 0), nil
+}
+
+// ConvertEmbeddingToOllama 将 Embeddings 请求转换为 Ollama /api/embed 请求
+func ConvertEmbeddingToOllama(req *EmbeddingRequest, model string) (*OllamaEmbedRequest, error) {
+	texts, err := EmbeddingInputTexts(req.Input)
+	if err != nil {
+		return nil, NewConversionError("request", "转换 input 失败", err)
+	}
+	return &OllamaEmbedRequest{
+		Model:      model,
+		Input:      texts,
+		Dimensions: req.Dimensions,
+	}, nil
+}
+
+// ConvertOllamaEmbeddingToOpenAI 将 Ollama /api/embed 响应转换为 Embeddings 响应
+func ConvertOllamaEmbeddingToOpenAI(resp *OllamaEmbedResponse, model, encodingFormat string) (*EmbeddingResponse, error) {
+	if err := ValidateNonNil(resp, "Ollama响应"); err != nil {
+		return nil, NewConversionError("response", "验证失败", err)
+	}
+	return newEmbeddingResponse(resp.Embeddings, model, encodingFormat, resp.PromptEvalCount), nil
+}
+
+// EncodeEmbedding 按 encoding_format 编码嵌入向量
+// base64 与 OpenAI 一致：float32 小端序字节的 base64
+func EncodeEmbedding(values []float64, encodingFormat string) interface{} {
+	if encodingFormat != EmbeddingEncodingBase64 {
+		if values == nil {
+			return []float64{}
+		}
+		return values
+	}
+	buf := make([]byte, 4*len(values))
+	for i, value := range values {
+		binary.LittleEndian.PutUint32(buf[i*4:], math.Float32bits(float32(value)))
+	}
+	return base64.StdEncoding.EncodeToString(buf)
+}
+
+// newEmbeddingResponse 构建 Embeddings 响应
+func newEmbeddingResponse(vectors [][]float64, model, encodingFormat string, promptTokens int) *EmbeddingResponse {
+	data := make([]EmbeddingData, 0, len(vectors))
+	for i, vector := range vectors {
+		data = append(data, EmbeddingData{
+			Object:    "embedding",
+			Index:     i,
+			Embedding: EncodeEmbedding(vector, encodingFormat),
+		})
+	}
+	return &EmbeddingResponse{
+		Object: "list",
+		Data:   data,
+		Model:  model,
+		Usage:  EmbeddingUsage{PromptTokens: promptTokens, TotalTokens: promptTokens},
+	}
+}
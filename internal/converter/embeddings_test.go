@@ -0,0 +1,79 @@
+package converter
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestEmbeddingInputTexts(t *testing.T) {
+	var req EmbeddingRequest
+	if err := json.Unmarshal([]byte(`{"model":"m","input":["a","b"]}`), &req); err != nil {
+		t.Fatalf("failed to decode request: %v", err)
+	}
+	texts, err := EmbeddingInputTexts(req.Input)
+	if err != nil || len(texts) != 2 || texts[1] != "b" {
+		t.Fatalf("unexpected texts: %v, err: %v", texts, err)
+	}
+
+	if texts, err := EmbeddingInputTexts("single"); err != nil || len(texts) != 1 {
+		t.Fatalf("unexpected texts for string input: %v, err: %v", texts, err)
+	}
+
+	if err := json.Unmarshal([]byte(`{"model":"m","input":[[1,2,3]]}`), &req); err != nil {
+		t.Fatalf("failed to decode request: %v", err)
+	}
+	if _, err := EmbeddingInputTexts(req.Input); err == nil {
+		t.Fatalf("expected token array input to be rejected")
+	}
+}
+
+func TestEncodeEmbedding(t *testing.T) {
+	// 1.0 与 2.0 的 float32 小端序字节
+	if got := EncodeEmbedding([]float64{1, 2}, EmbeddingEncodingBase64); got != "AACAPwAAAEA=" {
+		t.Fatalf("unexpected base64 embedding: %v", got)
+	}
+	if got, ok := EncodeEmbedding([]float64{0.5}, "").([]float64); !ok || got[0] != 0.5 {
+		t.Fatalf("unexpected float embedding: %v", got)
+	}
+}
+
+func TestConvertEmbeddingToGemini(t *testing.T) {
+	dimensions := 256
+	geminiReq, err := ConvertEmbeddingToGemini(&EmbeddingRequest{Input: []interface{}{"a", "b"}, Dimensions: &dimensions}, "text-embedding-004")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(geminiReq.Requests) != 2 || geminiReq.Requests[0].Model != "models/text-embedding-004" {
+		t.Fatalf("unexpected Gemini request: %+v", geminiReq)
+	}
+	if *geminiReq.Requests[1].Content.Parts[0].Text != "b" || *geminiReq.Requests[1].OutputDimensionality != 256 {
+		t.Fatalf("unexpected Gemini request: %+v", geminiReq.Requests[1])
+	}
+
+	var geminiResp GeminiBatchEmbedResponse
+	if err := json.Unmarshal([]byte(`{"embeddings":[{"values":[0.1]},{"values":[0.2]}]}`), &geminiResp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	resp, err := ConvertGeminiEmbeddingToOpenAI(&geminiResp, "text-embedding-004", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Object != "list" || len(resp.Data) != 2 || resp.Data[1].Index != 1 || resp.Model != "text-embedding-004" {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+}
+
+func TestConvertOllamaEmbeddingToOpenAI(t *testing.T) {
+	ollamaReq, err := ConvertEmbeddingToOllama(&EmbeddingRequest{Input: "hello"}, "nomic-embed-text")
+	if err != nil || ollamaReq.Model != "nomic-embed-text" || len(ollamaReq.Input) != 1 {
+		t.Fatalf("unexpected Ollama request: %+v, err: %v", ollamaReq, err)
+	}
+
+	resp, err := ConvertOllamaEmbeddingToOpenAI(&OllamaEmbedResponse{Embeddings: [][]float64{{1, 2}}, PromptEvalCount: 5}, "nomic-embed-text", EmbeddingEncodingBase64)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Data[0].Embedding != "AACAPwAAAEA=" || resp.Usage.PromptTokens != 5 || resp.Usage.TotalTokens != 5 {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+}
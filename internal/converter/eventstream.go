@@ -0,0 +1,169 @@
+package converter
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+)
+
+// AWS event-stream 帧格式：
+//
+//	[总长度 4B][头部长度 4B][前导 CRC 4B][头部][负载][消息 CRC 4B]
+//
+// 所有整数均为大端序，CRC 为 CRC32 (IEEE)
+const (
+	eventStreamPreludeLen = 12
+	eventStreamCRCLen     = 4
+	// eventStreamMaxMessage 单条消息上限，防止异常长度导致大量内存分配
+	eventStreamMaxMessage = 16 * 1024 * 1024
+)
+
+// 头部值类型
+const (
+	eventStreamHeaderTrue      = 0
+	eventStreamHeaderFalse     = 1
+	eventStreamHeaderByte      = 2
+	eventStreamHeaderShort     = 3
+	eventStreamHeaderInt       = 4
+	eventStreamHeaderLong      = 5
+	eventStreamHeaderBytes     = 6
+	eventStreamHeaderString    = 7
+	eventStreamHeaderTimestamp = 8
+	eventStreamHeaderUUID      = 9
+)
+
+// ErrEventStreamCRC event-stream 校验失败
+var ErrEventStreamCRC = errors.New("event stream checksum mismatch")
+
+// EventStreamMessage 解码后的 event-stream 消息
+// Headers 仅保留字符串类型的头部值（:event-type、:message-type 等均为字符串）
+type EventStreamMessage struct {
+	Headers map[string]string
+	Payload []byte
+}
+
+// EventStreamDecoder AWS event-stream 二进制帧解码器
+type EventStreamDecoder struct {
+	r io.Reader
+}
+
+// NewEventStreamDecoder 创建 event-stream 解码器
+func NewEventStreamDecoder(r io.Reader) *EventStreamDecoder {
+	return &EventStreamDecoder{r: r}
+}
+
+// Next 读取下一条消息，流结束时返回 io.EOF
+func (d *EventStreamDecoder) Next() (*EventStreamMessage, error) {
+	prelude := make([]byte, eventStreamPreludeLen)
+	if _, err := io.ReadFull(d.r, prelude); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return nil, fmt.Errorf("truncated event stream prelude: %w", err)
+		}
+		return nil, err
+	}
+
+	totalLen := binary.BigEndian.Uint32(prelude[0:4])
+	headersLen := binary.BigEndian.Uint32(prelude[4:8])
+	if crc32.ChecksumIEEE(prelude[0:8]) != binary.BigEndian.Uint32(prelude[8:12]) {
+		return nil, fmt.Errorf("%w: prelude", ErrEventStreamCRC)
+	}
+	if totalLen > eventStreamMaxMessage ||
+		uint64(totalLen) < uint64(eventStreamPreludeLen)+uint64(headersLen)+eventStreamCRCLen {
+		return nil, fmt.Errorf("invalid event stream message length %d (headers %d)", totalLen, headersLen)
+	}
+
+	rest := make([]byte, totalLen-eventStreamPreludeLen)
+	if _, err := io.ReadFull(d.r, rest); err != nil {
+		return nil, fmt.Errorf("truncated event stream message: %w", err)
+	}
+
+	body := rest[:len(rest)-eventStreamCRCLen]
+	crc := crc32.NewIEEE()
+	crc.Write(prelude)
+	crc.Write(body)
+	if crc.Sum32() != binary.BigEndian.Uint32(rest[len(rest)-eventStreamCRCLen:]) {
+		return nil, fmt.Errorf("%w: message", ErrEventStreamCRC)
+	}
+
+	headers, err := decodeEventStreamHeaders(body[:headersLen])
+	if err != nil {
+		return nil, err
+	}
+
+	return &EventStreamMessage{
+		Headers: headers,
+		Payload: body[headersLen:],
+	}, nil
+}
+
+// decodeEventStreamHeaders 解析头部区域
+func decodeEventStreamHeaders(data []byte) (map[string]string, error) {
+	headers := make(map[string]string)
+	for len(data) > 0 {
+		nameLen := int(data[0])
+		if len(data) < 1+nameLen+1 {
+			return nil, fmt.Errorf("truncated event stream header name")
+		}
+		name := string(data[1 : 1+nameLen])
+		valueType := data[1+nameLen]
+		data = data[2+nameLen:]
+
+		var size int
+		switch valueType {
+		case eventStreamHeaderTrue, eventStreamHeaderFalse:
+			size = 0
+		case eventStreamHeaderByte:
+			size = 1
+		case eventStreamHeaderShort:
+			size = 2
+		case eventStreamHeaderInt:
+			size = 4
+		case eventStreamHeaderLong, eventStreamHeaderTimestamp:
+			size = 8
+		case eventStreamHeaderUUID:
+			size = 16
+		case eventStreamHeaderBytes, eventStreamHeaderString:
+			if len(data) < 2 {
+				return nil, fmt.Errorf("truncated event stream header %s", name)
+			}
+			size = int(binary.BigEndian.Uint16(data[0:2]))
+			data = data[2:]
+		default:
+			return nil, fmt.Errorf("unknown event stream header type %d", valueType)
+		}
+
+		if len(data) < size {
+			return nil, fmt.Errorf("truncated event stream header %s", name)
+		}
+		if valueType == eventStreamHeaderString {
+			headers[name] = string(data[:size])
+		}
+		data = data[size:]
+	}
+	return headers, nil
+}
+
+// EncodeEventStreamMessage 编码 event-stream 消息（字符串头部）
+// 主要用于测试和模拟上游
+func EncodeEventStreamMessage(headers map[string]string, payload []byte) []byte {
+	var headerBytes []byte
+	for name, value := range headers {
+		headerBytes = append(headerBytes, byte(len(name)))
+		headerBytes = append(headerBytes, name...)
+		headerBytes = append(headerBytes, eventStreamHeaderString)
+		headerBytes = binary.BigEndian.AppendUint16(headerBytes, uint16(len(value)))
+		headerBytes = append(headerBytes, value...)
+	}
+
+	totalLen := eventStreamPreludeLen + len(headerBytes) + len(payload) + eventStreamCRCLen
+	msg := make([]byte, 0, totalLen)
+	msg = binary.BigEndian.AppendUint32(msg, uint32(totalLen))
+	msg = binary.BigEndian.AppendUint32(msg, uint32(len(headerBytes)))
+	msg = binary.BigEndian.AppendUint32(msg, crc32.ChecksumIEEE(msg[0:8]))
+	msg = append(msg, headerBytes...)
+	msg = append(msg, payload...)
+	msg = binary.BigEndian.AppendUint32(msg, crc32.ChecksumIEEE(msg))
+	return msg
+}
@@ -0,0 +1,107 @@
+package converter
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+// bedrockChunkMessage 构造 Bedrock chunk 消息
+func bedrockChunkMessage(event string) []byte {
+	payload := `{"bytes":"` + base64.StdEncoding.EncodeToString([]byte(event)) + `"}`
+	return EncodeEventStreamMessage(map[string]string{
+		":event-type":   "chunk",
+		":message-type": "event",
+		":content-type": "application/json",
+	}, []byte(payload))
+}
+
+func TestEventStreamDecoder_RoundTrip(t *testing.T) {
+	var stream bytes.Buffer
+	stream.Write(EncodeEventStreamMessage(map[string]string{":event-type": "chunk"}, []byte(`{"a":1}`)))
+	stream.Write(EncodeEventStreamMessage(map[string]string{":event-type": "chunk"}, nil))
+
+	decoder := NewEventStreamDecoder(&stream)
+
+	msg, err := decoder.Next()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if msg.Headers[":event-type"] != "chunk" || string(msg.Payload) != `{"a":1}` {
+		t.Fatalf("unexpected message: %+v", msg)
+	}
+
+	msg, err = decoder.Next()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(msg.Payload) != 0 {
+		t.Fatalf("expected empty payload, got %q", msg.Payload)
+	}
+
+	if _, err := decoder.Next(); err != io.EOF {
+		t.Fatalf("expected io.EOF, got %v", err)
+	}
+}
+
+func TestEventStreamDecoder_Corrupted(t *testing.T) {
+	msg := EncodeEventStreamMessage(map[string]string{":event-type": "chunk"}, []byte(`{"a":1}`))
+
+	corrupted := append([]byte{}, msg...)
+	corrupted[len(corrupted)-6] ^= 0xff
+	if _, err := NewEventStreamDecoder(bytes.NewReader(corrupted)).Next(); !errors.Is(err, ErrEventStreamCRC) {
+		t.Fatalf("expected checksum error, got %v", err)
+	}
+
+	if _, err := NewEventStreamDecoder(bytes.NewReader(msg[:len(msg)-3])).Next(); err == nil {
+		t.Fatalf("expected error for truncated message")
+	}
+}
+
+func TestConvertBedrockStream(t *testing.T) {
+	var stream bytes.Buffer
+	stream.Write(bedrockChunkMessage(`{"type":"message_start","message":{"id":"msg_1","role":"assistant"}}`))
+	stream.Write(bedrockChunkMessage(`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"pong"}}`))
+	stream.Write(bedrockChunkMessage(`{"type":"message_stop"}`))
+
+	reader, err := ConvertBedrockStream(context.Background(), &stream)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	output, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := "event: message_start\ndata: {\"type\":\"message_start\",\"message\":{\"id\":\"msg_1\",\"role\":\"assistant\"}}\n\n" +
+		"event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"text_delta\",\"text\":\"pong\"}}\n\n" +
+		"event: message_stop\ndata: {\"type\":\"message_stop\"}\n\n"
+	if string(output) != expected {
+		t.Fatalf("unexpected SSE output:\n%s", output)
+	}
+}
+
+func TestConvertBedrockStream_Exception(t *testing.T) {
+	var stream bytes.Buffer
+	stream.Write(bedrockChunkMessage(`{"type":"message_start","message":{"id":"msg_1"}}`))
+	stream.Write(EncodeEventStreamMessage(map[string]string{
+		":message-type":   "exception",
+		":exception-type": "throttlingException",
+	}, []byte(`{"message":"Too many requests"}`)))
+
+	reader, _ := ConvertBedrockStream(context.Background(), &stream)
+	output, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !strings.Contains(string(output), "event: error\n") ||
+		!strings.Contains(string(output), `"type":"rate_limit_error"`) ||
+		!strings.Contains(string(output), "Too many requests") {
+		t.Fatalf("expected Claude error event, got:\n%s", output)
+	}
+}
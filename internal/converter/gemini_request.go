@@ -0,0 +1,486 @@
+package converter
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"path"
+	"strings"
+)
+
+// Gemini 角色
+const (
+	GeminiRoleUser  = "user"
+	GeminiRoleModel = "model"
+)
+
+// Gemini 函数调用模式
+const (
+	GeminiFunctionModeAuto = "AUTO"
+	GeminiFunctionModeAny  = "ANY"
+	GeminiFunctionModeNone = "NONE"
+)
+
+// ConvertClaudeToGemini 将 Claude Messages API 请求转换为 Gemini generateContent 请求
+func ConvertClaudeToGemini(req *ClaudeRequest) (*GeminiRequest, error) {
+	if err := ValidateNonNil(req, "Claude请求"); err != nil {
+		return nil, NewConversionError("request", "验证失败", err)
+	}
+
+	geminiReq := &GeminiRequest{
+		GenerationConfig: newGeminiGenerationConfig(req.MaxTokens, req.Temperature, req.TopP, req.StopSequences),
+	}
+	if system := req.System.PlainText(); system != "" {
+		geminiReq.SystemInstruction = &GeminiContent{Parts: []GeminiPart{{Text: StringPtr(system)}}}
+	}
+
+	// tool_result 只携带 tool_use_id，Gemini 的 functionResponse 需要函数名，先建立映射
+	toolNames := make(map[string]string)
+	for _, msg := range req.Messages {
+		for _, block := range msg.Content {
+			if block.Type == ContentTypeToolUse && block.ID != nil && block.Name != nil {
+				toolNames[*block.ID] = *block.Name
+			}
+		}
+	}
+
+	var contents []GeminiContent
+	for _, msg := range req.Messages {
+		role, err := geminiRoleFromClaude(msg.Role)
+		if err != nil {
+			return nil, NewConversionError("request", "转换消息失败", err)
+		}
+
+		var parts []GeminiPart
+		for _, block := range msg.Content {
+			part, ok, err := claudeBlockToGeminiPart(block, toolNames)
+			if err != nil {
+				return nil, NewConversionError("request", "转换消息失败", err)
+			}
+			if ok {
+				parts = append(parts, part)
+			}
+		}
+		contents = appendGeminiContent(contents, role, parts)
+	}
+	geminiReq.Contents = contents
+
+	if len(req.Tools) > 0 {
+		declarations := make([]GeminiFunctionDeclaration, 0, len(req.Tools))
+		for _, tool := range req.Tools {
+			declarations = append(declarations, newGeminiFunctionDeclaration(tool.Name, tool.Description, tool.InputSchema))
+		}
+		geminiReq.Tools = []GeminiTool{{FunctionDeclarations: declarations}}
+	}
+
+	if req.ToolChoice != nil {
+		switch req.ToolChoice.Type {
+		case "auto":
+			geminiReq.ToolConfig = newGeminiToolConfig(GeminiFunctionModeAuto, "")
+		case "any":
+			geminiReq.ToolConfig = newGeminiToolConfig(GeminiFunctionModeAny, "")
+		case "none":
+			geminiReq.ToolConfig = newGeminiToolConfig(GeminiFunctionModeNone, "")
+		case "tool":
+			if req.ToolChoice.Name != nil {
+				geminiReq.ToolConfig = newGeminiToolConfig(GeminiFunctionModeAny, *req.ToolChoice.Name)
+			}
+		}
+	}
+
+	return geminiReq, nil
+}
+
+// geminiRoleFromClaude 转换 Claude 角色
+func geminiRoleFromClaude(role string) (string, error) {
+	switch role {
+	case ClaudeRoleUser:
+		return GeminiRoleUser, nil
+	case ClaudeRoleAssistant:
+		return GeminiRoleModel, nil
+	default:
+		return "", fmt.Errorf("不支持的角色: %s", role)
+	}
+}
+
+// claudeBlockToGeminiPart 转换单个 Claude 内容块，第二个返回值表示是否生成了 part
+func claudeBlockToGeminiPart(block ClaudeContentBlock, toolNames map[string]string) (GeminiPart, bool, error) {
+	switch block.Type {
+	case ContentTypeText:
+		if block.Text == nil || *block.Text == "" {
+			return GeminiPart{}, false, nil
+		}
+		return GeminiPart{Text: block.Text}, true, nil
+
+	case ContentTypeImage:
+		url, err := imageSourceURL(block.Source)
+		if err != nil {
+			return GeminiPart{}, false, err
+		}
+		return imageURLToGeminiPart(url), true, nil
+
+	case ContentTypeDocument:
+		part, err := claudeDocumentToGeminiPart(block)
+		if err != nil {
+			return GeminiPart{}, false, err
+		}
+		return part, true, nil
+
+	case ContentTypeToolUse:
+		if block.Name == nil {
+			return GeminiPart{}, false, fmt.Errorf("tool_use 缺少 name")
+		}
+		return GeminiPart{FunctionCall: &GeminiFunctionCall{
+			Name: *block.Name,
+			Args: block.Input,
+		}}, true, nil
+
+	case ContentTypeToolResult:
+		if block.ToolUseID == nil {
+			return GeminiPart{}, false, fmt.Errorf("tool_result 缺少 tool_use_id")
+		}
+		name, ok := toolNames[*block.ToolUseID]
+		if !ok {
+			return GeminiPart{}, false, fmt.Errorf("tool_result 引用了未知的 tool_use_id: %s", *block.ToolUseID)
+		}
+		content := block.Content.PlainText()
+		if block.IsError != nil && *block.IsError {
+			content = toolResultErrorPrefix + content
+		}
+		return GeminiPart{FunctionResponse: &GeminiFunctionResponse{
+			Name:     name,
+			Response: geminiFunctionResponseBody(content),
+		}}, true, nil
+	}
+
+	return GeminiPart{}, false, nil
+}
+
+// ConvertOpenAIToGemini 将 OpenAI Chat Completions API 请求转换为 Gemini generateContent 请求
+func ConvertOpenAIToGemini(req *OpenAIRequest) (*GeminiRequest, error) {
+	if err := ValidateNonNil(req, "OpenAI请求"); err != nil {
+		return nil, NewConversionError("request", "验证失败", err)
+	}
+
+	maxTokens := req.MaxCompletionTokens
+	if maxTokens == 0 {
+		maxTokens = req.MaxTokens
+	}
+	geminiReq := &GeminiRequest{
+		GenerationConfig: newGeminiGenerationConfig(maxTokens, req.Temperature, req.TopP, req.Stop),
+	}
+	applyGeminiResponseFormat(geminiReq, req.ResponseFormat)
+
+	// tool 消息只携带 tool_call_id，先建立到函数名的映射
+	toolNames := make(map[string]string)
+	for _, msg := range req.Messages {
+		for _, toolCall := range msg.ToolCalls {
+			toolNames[toolCall.ID] = toolCall.Function.Name
+		}
+	}
+
+	var systemParts []string
+	var contents []GeminiContent
+	for _, msg := range req.Messages {
+		switch msg.Role {
+		case "system", "developer":
+			if text := ExtractTextFromContent(msg.Content); text != "" {
+				systemParts = append(systemParts, text)
+			}
+
+		case "user":
+			parts, err := openAIContentToGeminiParts(msg.Content)
+			if err != nil {
+				return nil, NewConversionError("request", "转换消息失败", err)
+			}
+			contents = appendGeminiContent(contents, GeminiRoleUser, parts)
+
+		case "assistant":
+			var parts []GeminiPart
+			if text := ExtractTextFromContent(msg.Content); text != "" {
+				parts = append(parts, GeminiPart{Text: StringPtr(text)})
+			}
+			for _, toolCall := range msg.ToolCalls {
+				var args map[string]interface{}
+				if toolCall.Function.Arguments != "" {
+					if err := json.Unmarshal([]byte(toolCall.Function.Arguments), &args); err != nil {
+						return nil, NewConversionError("request", "解析 tool_calls arguments 失败", err)
+					}
+				}
+				parts = append(parts, GeminiPart{FunctionCall: &GeminiFunctionCall{
+					Name: toolCall.Function.Name,
+					Args: args,
+				}})
+			}
+			contents = appendGeminiContent(contents, GeminiRoleModel, parts)
+
+		case "tool":
+			name, ok := toolNames[msg.ToolCallID]
+			if !ok {
+				return nil, NewConversionError("request", "转换消息失败",
+					fmt.Errorf("tool 消息引用了未知的 tool_call_id: %s", msg.ToolCallID))
+			}
+			contents = appendGeminiContent(contents, GeminiRoleUser, []GeminiPart{{
+				FunctionResponse: &GeminiFunctionResponse{
+					Name:     name,
+					Response: geminiFunctionResponseBody(ExtractTextFromContent(msg.Content)),
+				},
+			}})
+
+		default:
+			return nil, NewConversionError("request", "转换消息失败", fmt.Errorf("不支持的角色: %s", msg.Role))
+		}
+	}
+	geminiReq.Contents = contents
+
+	if len(systemParts) > 0 {
+		geminiReq.SystemInstruction = &GeminiContent{Parts: []GeminiPart{{Text: StringPtr(strings.Join(systemParts, "\n"))}}}
+	}
+
+	if len(req.Tools) > 0 {
+		declarations := make([]GeminiFunctionDeclaration, 0, len(req.Tools))
+		for _, tool := range req.Tools {
+			declarations = append(declarations, newGeminiFunctionDeclaration(tool.Function.Name, tool.Function.Description, tool.Function.Parameters))
+		}
+		geminiReq.Tools = []GeminiTool{{FunctionDeclarations: declarations}}
+	}
+
+	switch choice := req.ToolChoice.(type) {
+	case string:
+		switch choice {
+		case "auto":
+			geminiReq.ToolConfig = newGeminiToolConfig(GeminiFunctionModeAuto, "")
+		case "required":
+			geminiReq.ToolConfig = newGeminiToolConfig(GeminiFunctionModeAny, "")
+		case "none":
+			geminiReq.ToolConfig = newGeminiToolConfig(GeminiFunctionModeNone, "")
+		}
+	case map[string]interface{}:
+		if function, ok := choice["function"].(map[string]interface{}); ok {
+			if name, ok := function["name"].(string); ok && name != "" {
+				geminiReq.ToolConfig = newGeminiToolConfig(GeminiFunctionModeAny, name)
+			}
+		}
+	}
+
+	return geminiReq, nil
+}
+
+// openAIContentToGeminiParts 转换 OpenAI 用户消息内容（字符串或内容块数组）
+func openAIContentToGeminiParts(content interface{}) ([]GeminiPart, error) {
+	switch v := content.(type) {
+	case string:
+		if v == "" {
+			return nil, nil
+		}
+		return []GeminiPart{{Text: StringPtr(v)}}, nil
+	case []interface{}:
+		var parts []GeminiPart
+		for _, item := range v {
+			block, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			switch block["type"] {
+			case ContentTypeText:
+				if text, ok := block["text"].(string); ok && text != "" {
+					parts = append(parts, GeminiPart{Text: StringPtr(text)})
+				}
+			case "image_url":
+				imageURL, _ := block["image_url"].(map[string]interface{})
+				rawURL, _ := imageURL["url"].(string)
+				if rawURL == "" {
+					return nil, fmt.Errorf("image_url 缺少 url")
+				}
+				parts = append(parts, imageURLToGeminiPart(rawURL))
+			}
+		}
+		return parts, nil
+	}
+	return nil, nil
+}
+
+// claudeDocumentToGeminiPart 转换 document 块：文本类文档转为 text，PDF 以 inlineData / fileData 原生传递
+func claudeDocumentToGeminiPart(block ClaudeContentBlock) (GeminiPart, error) {
+	text, ok, err := documentText(block, false)
+	if err != nil {
+		return GeminiPart{}, err
+	}
+	if ok {
+		return GeminiPart{Text: StringPtr(text)}, nil
+	}
+
+	source := block.Source
+	switch {
+	case source.Type == "base64" && source.MediaType == mediaTypePDF:
+		return GeminiPart{InlineData: &GeminiBlob{MimeType: source.MediaType, Data: source.Data}}, nil
+	case source.Type == "url" && source.URL != "":
+		return GeminiPart{FileData: &GeminiFileData{MimeType: mediaTypePDF, FileURI: source.URL}}, nil
+	default:
+		return GeminiPart{}, fmt.Errorf("不支持的文档来源: type=%s media_type=%s", source.Type, source.MediaType)
+	}
+}
+
+// imageURLToGeminiPart 将图片 URL 转换为 Gemini part
+// data URI 转为 inlineData，其余 URL 以 fileData 引用（MIME 类型按扩展名推断）
+func imageURLToGeminiPart(rawURL string) GeminiPart {
+	if mediaType, data, ok := parseDataURI(rawURL); ok {
+		return GeminiPart{InlineData: &GeminiBlob{MimeType: mediaType, Data: data}}
+	}
+
+	mimeType := mime.TypeByExtension(strings.ToLower(path.Ext(strings.SplitN(rawURL, "?", 2)[0])))
+	if mimeType == "" {
+		mimeType = "image/jpeg"
+	}
+	return GeminiPart{FileData: &GeminiFileData{MimeType: mimeType, FileURI: rawURL}}
+}
+
+// parseDataURI 解析 base64 data URI（data:image/png;base64,xxxx）
+func parseDataURI(uri string) (mediaType, data string, ok bool) {
+	rest, found := strings.CutPrefix(uri, "data:")
+	if !found {
+		return "", "", false
+	}
+	meta, data, found := strings.Cut(rest, ",")
+	if !found {
+		return "", "", false
+	}
+	mediaType, found = strings.CutSuffix(meta, ";base64")
+	if !found {
+		return "", "", false
+	}
+	return mediaType, data, true
+}
+
+// appendGeminiContent 追加消息；Gemini 要求角色交替，相邻同角色消息合并为一条，空消息丢弃
+func appendGeminiContent(contents []GeminiContent, role string, parts []GeminiPart) []GeminiContent {
+	if len(parts) == 0 {
+		return contents
+	}
+	if n := len(contents); n > 0 && contents[n-1].Role == role {
+		contents[n-1].Parts = append(contents[n-1].Parts, parts...)
+		return contents
+	}
+	return append(contents, GeminiContent{Role: role, Parts: parts})
+}
+
+// geminiFunctionResponseBody 构建 functionResponse.response
+// Gemini 要求为 JSON 对象：工具结果本身是 JSON 对象时直接使用，否则包装为 {"content": ...}
+func geminiFunctionResponseBody(content string) map[string]interface{} {
+	var obj map[string]interface{}
+	if err := json.Unmarshal([]byte(content), &obj); err == nil && obj != nil {
+		return obj
+	}
+	return map[string]interface{}{"content": content}
+}
+
+// newGeminiGenerationConfig 构建生成参数，全部为空时返回 nil
+func newGeminiGenerationConfig(maxTokens int, temperature, topP *float64, stop []string) *GeminiGenerationConfig {
+	if maxTokens == 0 && temperature == nil && topP == nil && len(stop) == 0 {
+		return nil
+	}
+	return &GeminiGenerationConfig{
+		Temperature:     temperature,
+		TopP:            topP,
+		MaxOutputTokens: maxTokens,
+		StopSequences:   stop,
+	}
+}
+
+// applyGeminiResponseFormat 将 response_format 映射为 Gemini 原生的 responseMimeType / responseJsonSchema
+func applyGeminiResponseFormat(geminiReq *GeminiRequest, format *OpenAIResponseFormat) {
+	if format == nil || (format.Type != "json_object" && format.Type != "json_schema") {
+		return
+	}
+	if geminiReq.GenerationConfig == nil {
+		geminiReq.GenerationConfig = &GeminiGenerationConfig{}
+	}
+	geminiReq.GenerationConfig.ResponseMimeType = "application/json"
+	if format.JSONSchema != nil {
+		geminiReq.GenerationConfig.ResponseJSONSchema = format.JSONSchema.Schema
+	}
+}
+
+// newGeminiToolConfig 构建函数调用配置，name 非空时限定只能调用该函数
+func newGeminiToolConfig(mode, name string) *GeminiToolConfig {
+	config := &GeminiFunctionCallingConfig{Mode: mode}
+	if name != "" {
+		config.AllowedFunctionNames = []string{name}
+	}
+	return &GeminiToolConfig{FunctionCallingConfig: config}
+}
+
+// newGeminiFunctionDeclaration 构建函数声明
+// Gemini 不接受没有属性的 object 参数，此时省略 parameters
+func newGeminiFunctionDeclaration(name, description string, schema map[string]interface{}) GeminiFunctionDeclaration {
+	declaration := GeminiFunctionDeclaration{Name: name, Description: description}
+	if props, ok := schema["properties"].(map[string]interface{}); ok && len(props) > 0 {
+		declaration.Parameters = sanitizeGeminiSchema(schema)
+	}
+	return declaration
+}
+
+// geminiSchemaKeys Gemini 函数参数支持的 Schema 字段（OpenAPI 3.0 子集）
+var geminiSchemaKeys = map[string]bool{
+	"type": true, "format": true, "title": true, "description": true, "nullable": true,
+	"enum": true, "properties": true, "required": true, "items": true, "anyOf": true,
+	"minItems": true, "maxItems": true, "minLength": true, "maxLength": true,
+	"minimum": true, "maximum": true, "pattern": true, "propertyOrdering": true,
+}
+
+// sanitizeGeminiSchema 将 JSON Schema 清洗为 Gemini 可接受的子集
+// 移除 $schema、additionalProperties 等不支持的字段；type 数组（如 ["string","null"]）转为 type + nullable；const 转为 enum
+func sanitizeGeminiSchema(schema map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(schema))
+	for key, value := range schema {
+		switch key {
+		case "type":
+			if types, ok := value.([]interface{}); ok {
+				for _, t := range types {
+					if t == "null" {
+						result["nullable"] = true
+					} else if _, exists := result["type"]; !exists {
+						result["type"] = t
+					}
+				}
+				continue
+			}
+			result[key] = value
+		case "const":
+			result["enum"] = []interface{}{value}
+		case "properties":
+			props, ok := value.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			cleaned := make(map[string]interface{}, len(props))
+			for name, prop := range props {
+				if propSchema, ok := prop.(map[string]interface{}); ok {
+					cleaned[name] = sanitizeGeminiSchema(propSchema)
+				}
+			}
+			result[key] = cleaned
+		case "items":
+			if itemSchema, ok := value.(map[string]interface{}); ok {
+				result[key] = sanitizeGeminiSchema(itemSchema)
+			}
+		case "anyOf":
+			options, ok := value.([]interface{})
+			if !ok {
+				continue
+			}
+			cleaned := make([]interface{}, 0, len(options))
+			for _, option := range options {
+				if optionSchema, ok := option.(map[string]interface{}); ok {
+					cleaned = append(cleaned, sanitizeGeminiSchema(optionSchema))
+				}
+			}
+			result[key] = cleaned
+		default:
+			if geminiSchemaKeys[key] {
+				result[key] = value
+			}
+		}
+	}
+	return result
+}
@@ -0,0 +1,235 @@
+package converter
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// Claude stop_reason：安全策略拦截
+const ClaudeStopReasonRefusal = "refusal"
+
+// geminiSafetyFinishReasons 因安全策略被拦截的 finishReason / blockReason
+var geminiSafetyFinishReasons = map[string]bool{
+	"SAFETY":             true,
+	"RECITATION":         true,
+	"BLOCKLIST":          true,
+	"PROHIBITED_CONTENT": true,
+	"SPII":               true,
+	"IMAGE_SAFETY":       true,
+}
+
+// ConvertGeminiFinishReasonToStopReason 转换 Gemini finishReason 为 Claude stop_reason
+// 有函数调用时为 tool_use；安全拦截统一为 refusal
+func ConvertGeminiFinishReasonToStopReason(finishReason string, hasToolCall bool) string {
+	switch {
+	case hasToolCall:
+		return "tool_use"
+	case finishReason == "MAX_TOKENS":
+		return "max_tokens"
+	case geminiSafetyFinishReasons[finishReason]:
+		return ClaudeStopReasonRefusal
+	default:
+		return "end_turn"
+	}
+}
+
+// ConvertGeminiFinishReasonToFinishReason 转换 Gemini finishReason 为 OpenAI finish_reason
+func ConvertGeminiFinishReasonToFinishReason(finishReason string, hasToolCall bool) string {
+	switch {
+	case hasToolCall:
+		return "tool_calls"
+	case finishReason == "MAX_TOKENS":
+		return "length"
+	case geminiSafetyFinishReasons[finishReason]:
+		return "content_filter"
+	default:
+		return "stop"
+	}
+}
+
+// ConvertGeminiToClaude 将 Gemini generateContent 响应转换为 Claude Messages API 响应
+func ConvertGeminiToClaude(resp *GeminiResponse, model string) (*ClaudeResponse, error) {
+	if err := ValidateNonNil(resp, "Gemini响应"); err != nil {
+		return nil, NewConversionError("response", "验证失败", err)
+	}
+
+	claudeResp := &ClaudeResponse{
+		ID:    geminiMessageID(resp.ResponseID),
+		Type:  ClaudeTypeMessage,
+		Role:  ClaudeRoleAssistant,
+		Model: model,
+		Usage: geminiClaudeUsage(resp.UsageMetadata),
+	}
+
+	candidate, blocked := geminiFirstCandidate(resp)
+	if candidate == nil {
+		if !blocked {
+			return nil, NewConversionError("response", "响应中没有candidates", nil)
+		}
+		// 提示词被拦截：返回空文本并标记 refusal
+		claudeResp.Content = []ClaudeContentBlock{{Type: ContentTypeText, Text: StringPtr("")}}
+		claudeResp.StopReason = ClaudeStopReasonRefusal
+		return claudeResp, nil
+	}
+
+	var content []ClaudeContentBlock
+	hasToolCall := false
+	for _, part := range candidate.Content.Parts {
+		switch {
+		case part.Thought:
+			continue
+		case part.FunctionCall != nil:
+			hasToolCall = true
+			input := part.FunctionCall.Args
+			if input == nil {
+				input = make(map[string]interface{})
+			}
+			content = append(content, ClaudeContentBlock{
+				Type:  ContentTypeToolUse,
+				ID:    StringPtr(geminiToolCallID("toolu_", part.FunctionCall.ID)),
+				Name:  StringPtr(part.FunctionCall.Name),
+				Input: input,
+			})
+		case part.Text != nil:
+			// 相邻文本片段合并为一个 text 块
+			if n := len(content); n > 0 && content[n-1].Type == ContentTypeText {
+				content[n-1].Text = StringPtr(*content[n-1].Text + *part.Text)
+				continue
+			}
+			content = append(content, ClaudeContentBlock{Type: ContentTypeText, Text: StringPtr(*part.Text)})
+		}
+	}
+	if len(content) == 0 {
+		content = append(content, ClaudeContentBlock{Type: ContentTypeText, Text: StringPtr("")})
+	}
+
+	claudeResp.Content = content
+	claudeResp.StopReason = ConvertGeminiFinishReasonToStopReason(candidate.FinishReason, hasToolCall)
+	return claudeResp, nil
+}
+
+// ConvertGeminiToOpenAI 将 Gemini generateContent 响应转换为 OpenAI Chat Completions API 响应
+func ConvertGeminiToOpenAI(resp *GeminiResponse, model string) (*OpenAIResponse, error) {
+	if err := ValidateNonNil(resp, "Gemini响应"); err != nil {
+		return nil, NewConversionError("response", "验证失败", err)
+	}
+
+	openaiResp := &OpenAIResponse{
+		ID:      ConvertIDClaudeToOpenAI(geminiMessageID(resp.ResponseID)),
+		Object:  OpenAIObjectChatCompletion,
+		Created: time.Now().Unix(),
+		Model:   model,
+		Usage:   geminiOpenAIUsage(resp.UsageMetadata),
+	}
+
+	candidate, blocked := geminiFirstCandidate(resp)
+	if candidate == nil {
+		if !blocked {
+			return nil, NewConversionError("response", "响应中没有candidates", nil)
+		}
+		openaiResp.Choices = []OpenAIChoice{{
+			Message:      OpenAIMessage{Role: ClaudeRoleAssistant, Content: ""},
+			FinishReason: "content_filter",
+		}}
+		return openaiResp, nil
+	}
+
+	var text strings.Builder
+	var toolCalls []OpenAIToolCall
+	for _, part := range candidate.Content.Parts {
+		switch {
+		case part.Thought:
+			continue
+		case part.FunctionCall != nil:
+			args, err := marshalGeminiArgs(part.FunctionCall.Args)
+			if err != nil {
+				return nil, NewConversionError("response", "序列化函数参数失败", err)
+			}
+			toolCalls = append(toolCalls, OpenAIToolCall{
+				ID:   geminiToolCallID("call_", part.FunctionCall.ID),
+				Type: OpenAIToolTypeFunction,
+				Function: OpenAIFunctionCall{
+					Name:      part.FunctionCall.Name,
+					Arguments: args,
+				},
+			})
+		case part.Text != nil:
+			text.WriteString(*part.Text)
+		}
+	}
+
+	message := OpenAIMessage{Role: ClaudeRoleAssistant, ToolCalls: toolCalls}
+	if text.Len() > 0 || len(toolCalls) == 0 {
+		message.Content = text.String()
+	}
+	openaiResp.Choices = []OpenAIChoice{{
+		Index:        0,
+		Message:      message,
+		FinishReason: ConvertGeminiFinishReasonToFinishReason(candidate.FinishReason, len(toolCalls) > 0),
+	}}
+	return openaiResp, nil
+}
+
+// marshalGeminiArgs 序列化函数参数，无参数时为 {}
+func marshalGeminiArgs(args map[string]interface{}) (string, error) {
+	if args == nil {
+		return "{}", nil
+	}
+	data, err := json.Marshal(args)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// geminiFirstCandidate 获取第一个候选结果；没有候选时返回提示词是否被拦截
+func geminiFirstCandidate(resp *GeminiResponse) (*GeminiCandidate, bool) {
+	if len(resp.Candidates) > 0 {
+		return &resp.Candidates[0], false
+	}
+	return nil, resp.PromptFeedback != nil && resp.PromptFeedback.BlockReason != ""
+}
+
+// geminiClaudeUsage 转换 token 用量（思考 token 计入输出，命中缓存的部分计入 cache_read_input_tokens）
+func geminiClaudeUsage(usage *GeminiUsageMetadata) ClaudeUsage {
+	if usage == nil {
+		return ClaudeUsage{}
+	}
+	cached := min(usage.CachedContentTokenCount, usage.PromptTokenCount)
+	return ClaudeUsage{
+		InputTokens:          usage.PromptTokenCount - cached,
+		OutputTokens:         usage.CandidatesTokenCount + usage.ThoughtsTokenCount,
+		CacheReadInputTokens: cached,
+	}
+}
+
+// geminiOpenAIUsage 转换 token 用量（思考 token 计入输出）
+func geminiOpenAIUsage(usage *GeminiUsageMetadata) OpenAIUsage {
+	return openAIUsageFromClaude(geminiClaudeUsage(usage))
+}
+
+// geminiMessageID 由 responseId 生成 Claude 消息 ID，缺失时随机生成
+func geminiMessageID(responseID string) string {
+	if responseID == "" {
+		responseID = randomHexID()
+	}
+	return "msg_" + responseID
+}
+
+// geminiToolCallID Gemini 的函数调用 ID 可能为空，此时随机生成
+func geminiToolCallID(prefix, id string) string {
+	if id != "" {
+		return prefix + id
+	}
+	return prefix + randomHexID()
+}
+
+// randomHexID 生成 24 位十六进制随机 ID
+func randomHexID() string {
+	buf := make([]byte, 12)
+	_, _ = rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
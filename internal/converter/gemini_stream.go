@@ -0,0 +1,445 @@
+package converter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// geminiStreamChunk Gemini 流式响应块（出错时 data 为 {"error": {...}}）
+type geminiStreamChunk struct {
+	GeminiResponse
+	Error *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+		Status  string `json:"status"`
+	} `json:"error,omitempty"`
+}
+
+// GeminiClaudeStreamConverter 将 Gemini streamGenerateContent SSE 转换为 Claude 流式事件
+// 复用 StreamConverter 的事件生成与块索引管理
+type GeminiClaudeStreamConverter struct {
+	*StreamConverter
+	stopReason string
+}
+
+// NewGeminiClaudeStreamConverter 创建 Gemini→Claude 流式转换器
+func NewGeminiClaudeStreamConverter(model string) *GeminiClaudeStreamConverter {
+	base := NewStreamConverter()
+	base.model = model
+	return &GeminiClaudeStreamConverter{StreamConverter: base}
+}
+
+// ConvertGeminiStreamToClaude 转换 Gemini 流式响应为 Claude 流式响应
+func ConvertGeminiStreamToClaude(ctx context.Context, geminiStream io.Reader, model string) (io.Reader, error) {
+	pipeReader, pipeWriter := io.Pipe()
+
+	go func() {
+		defer pipeWriter.Close()
+
+		converter := NewGeminiClaudeStreamConverter(model)
+		parser := NewSSEParser(geminiStream)
+
+		for {
+			select {
+			case <-ctx.Done():
+				pipeWriter.CloseWithError(ctx.Err())
+				return
+			default:
+			}
+
+			eventData, err := parser.ParseEvent()
+			if err == io.EOF {
+				events, err := converter.finish()
+				if err != nil {
+					pipeWriter.CloseWithError(err)
+					return
+				}
+				writeStreamEvents(pipeWriter, events)
+				return
+			}
+			if err != nil {
+				pipeWriter.CloseWithError(err)
+				return
+			}
+			if eventData == "" {
+				continue
+			}
+
+			var chunk geminiStreamChunk
+			if err := json.Unmarshal([]byte(eventData), &chunk); err != nil {
+				continue
+			}
+
+			if chunk.Error != nil {
+				event, err := FormatSSEEvent("error", map[string]interface{}{
+					"type": "error",
+					"error": map[string]string{
+						"type":    "api_error",
+						"message": chunk.Error.Message,
+					},
+				})
+				if err == nil {
+					pipeWriter.Write([]byte(event))
+				}
+				return
+			}
+
+			events, err := converter.processGeminiChunk(&chunk.GeminiResponse)
+			if err != nil {
+				pipeWriter.CloseWithError(err)
+				return
+			}
+			if !writeStreamEvents(pipeWriter, events) {
+				return
+			}
+		}
+	}()
+
+	return pipeReader, nil
+}
+
+// processGeminiChunk 处理单个 Gemini chunk，返回 Claude 事件列表
+func (c *GeminiClaudeStreamConverter) processGeminiChunk(chunk *GeminiResponse) ([]string, error) {
+	events := []string{}
+
+	// usageMetadata 为累计值，每个 chunk 直接覆盖
+	if chunk.UsageMetadata != nil {
+		c.usage = geminiClaudeUsage(chunk.UsageMetadata)
+	}
+
+	if !c.messageStarted {
+		c.messageID = geminiMessageID(chunk.ResponseID)
+		event, err := c.emitMessageStart()
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+		c.messageStarted = true
+	}
+
+	if chunk.PromptFeedback != nil && chunk.PromptFeedback.BlockReason != "" {
+		c.stopReason = ClaudeStopReasonRefusal
+	}
+	if len(chunk.Candidates) == 0 {
+		return events, nil
+	}
+
+	candidate := chunk.Candidates[0]
+	for _, part := range candidate.Content.Parts {
+		switch {
+		case part.Thought:
+			continue
+
+		case part.FunctionCall != nil:
+			// Gemini 一次性返回完整的函数调用：start + 完整参数 delta + stop
+			if c.blockStarted {
+				event, err := c.emitContentBlockStop()
+				if err != nil {
+					return nil, err
+				}
+				events = append(events, event)
+				c.blockStarted = false
+			}
+
+			args, err := marshalGeminiArgs(part.FunctionCall.Args)
+			if err != nil {
+				return nil, err
+			}
+
+			c.currentIndex++
+			c.currentBlockType = ContentTypeToolUse
+			for _, emit := range []func() (string, error){
+				func() (string, error) {
+					return c.emitToolUseStart(geminiToolCallID("toolu_", part.FunctionCall.ID), part.FunctionCall.Name)
+				},
+				func() (string, error) { return c.emitToolUseDelta(args) },
+				c.emitContentBlockStop,
+			} {
+				event, err := emit()
+				if err != nil {
+					return nil, err
+				}
+				events = append(events, event)
+			}
+			c.stopReason = "tool_use"
+
+		case part.Text != nil && *part.Text != "":
+			if !c.blockStarted || c.currentBlockType != ContentTypeText {
+				if c.blockStarted {
+					event, err := c.emitContentBlockStop()
+					if err != nil {
+						return nil, err
+					}
+					events = append(events, event)
+				}
+				c.currentIndex++
+				c.currentBlockType = ContentTypeText
+				event, err := c.emitContentBlockStart(ContentTypeText)
+				if err != nil {
+					return nil, err
+				}
+				events = append(events, event)
+				c.blockStarted = true
+			}
+
+			event, err := c.emitTextDelta(*part.Text)
+			if err != nil {
+				return nil, err
+			}
+			events = append(events, event)
+		}
+	}
+
+	if candidate.FinishReason != "" && c.stopReason != "tool_use" {
+		c.stopReason = ConvertGeminiFinishReasonToStopReason(candidate.FinishReason, false)
+	}
+
+	return events, nil
+}
+
+// finish 流结束：关闭当前块并发送 message_delta 与 message_stop
+func (c *GeminiClaudeStreamConverter) finish() ([]string, error) {
+	events := []string{}
+
+	if !c.messageStarted {
+		c.messageID = geminiMessageID("")
+		event, err := c.emitMessageStart()
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+		c.messageStarted = true
+	}
+
+	if c.blockStarted {
+		event, err := c.emitContentBlockStop()
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+		c.blockStarted = false
+	}
+
+	stopReason := c.stopReason
+	if stopReason == "" {
+		stopReason = "end_turn"
+	}
+	event, err := c.emitMessageDelta(stopReason)
+	if err != nil {
+		return nil, err
+	}
+	events = append(events, event)
+
+	event, err = c.emitMessageStop()
+	if err != nil {
+		return nil, err
+	}
+	return append(events, event), nil
+}
+
+// GeminiOpenAIStreamConverter 将 Gemini streamGenerateContent SSE 转换为 OpenAI 流式响应
+type GeminiOpenAIStreamConverter struct {
+	id            string
+	model         string
+	created       int64
+	roleSent      bool
+	toolCallIndex int
+	finishReason  string
+	usage         *OpenAIUsage
+}
+
+// NewGeminiOpenAIStreamConverter 创建 Gemini→OpenAI 流式转换器
+func NewGeminiOpenAIStreamConverter(model string) *GeminiOpenAIStreamConverter {
+	return &GeminiOpenAIStreamConverter{
+		model:   model,
+		created: time.Now().Unix(),
+	}
+}
+
+// ConvertGeminiStreamToOpenAI 转换 Gemini 流式响应为 OpenAI 流式响应（以 data: [DONE] 结尾）
+func ConvertGeminiStreamToOpenAI(ctx context.Context, geminiStream io.Reader, model string) (io.Reader, error) {
+	pipeReader, pipeWriter := io.Pipe()
+
+	go func() {
+		defer pipeWriter.Close()
+
+		converter := NewGeminiOpenAIStreamConverter(model)
+		parser := NewSSEParser(geminiStream)
+
+		for {
+			select {
+			case <-ctx.Done():
+				pipeWriter.CloseWithError(ctx.Err())
+				return
+			default:
+			}
+
+			eventData, err := parser.ParseEvent()
+			if err == io.EOF {
+				events, err := converter.finish()
+				if err != nil {
+					pipeWriter.CloseWithError(err)
+					return
+				}
+				writeStreamEvents(pipeWriter, events)
+				return
+			}
+			if err != nil {
+				pipeWriter.CloseWithError(err)
+				return
+			}
+			if eventData == "" {
+				continue
+			}
+
+			var chunk geminiStreamChunk
+			if err := json.Unmarshal([]byte(eventData), &chunk); err != nil {
+				continue
+			}
+
+			if chunk.Error != nil {
+				event, err := formatOpenAIStreamData(map[string]interface{}{
+					"error": map[string]interface{}{
+						"message": chunk.Error.Message,
+						"type":    "upstream_error",
+						"code":    chunk.Error.Code,
+					},
+				})
+				if err == nil {
+					pipeWriter.Write([]byte(event))
+				}
+				return
+			}
+
+			events, err := converter.processGeminiChunk(&chunk.GeminiResponse)
+			if err != nil {
+				pipeWriter.CloseWithError(err)
+				return
+			}
+			if !writeStreamEvents(pipeWriter, events) {
+				return
+			}
+		}
+	}()
+
+	return pipeReader, nil
+}
+
+// processGeminiChunk 处理单个 Gemini chunk，返回 OpenAI SSE 数据行
+func (c *GeminiOpenAIStreamConverter) processGeminiChunk(chunk *GeminiResponse) ([]string, error) {
+	if c.id == "" {
+		c.id = ConvertIDClaudeToOpenAI(geminiMessageID(chunk.ResponseID))
+	}
+	if chunk.UsageMetadata != nil {
+		usage := geminiOpenAIUsage(chunk.UsageMetadata)
+		c.usage = &usage
+	}
+	if chunk.PromptFeedback != nil && chunk.PromptFeedback.BlockReason != "" {
+		c.finishReason = "content_filter"
+	}
+	if len(chunk.Candidates) == 0 {
+		return nil, nil
+	}
+
+	candidate := chunk.Candidates[0]
+	delta := OpenAIStreamDelta{}
+	if !c.roleSent {
+		delta.Role = ClaudeRoleAssistant
+		c.roleSent = true
+	}
+
+	for _, part := range candidate.Content.Parts {
+		switch {
+		case part.Thought:
+			continue
+		case part.FunctionCall != nil:
+			args, err := marshalGeminiArgs(part.FunctionCall.Args)
+			if err != nil {
+				return nil, err
+			}
+			delta.ToolCalls = append(delta.ToolCalls, OpenAIStreamToolCall{
+				Index: c.toolCallIndex,
+				ID:    geminiToolCallID("call_", part.FunctionCall.ID),
+				Type:  OpenAIToolTypeFunction,
+				Function: &OpenAIStreamFunctionCall{
+					Name:      part.FunctionCall.Name,
+					Arguments: args,
+				},
+			})
+			c.toolCallIndex++
+		case part.Text != nil:
+			delta.Content += *part.Text
+		}
+	}
+
+	if candidate.FinishReason != "" {
+		c.finishReason = ConvertGeminiFinishReasonToFinishReason(candidate.FinishReason, c.toolCallIndex > 0)
+	}
+
+	if delta.Role == "" && delta.Content == "" && len(delta.ToolCalls) == 0 {
+		return nil, nil
+	}
+	event, err := formatOpenAIStreamData(c.chunk(delta, nil, nil))
+	if err != nil {
+		return nil, err
+	}
+	return []string{event}, nil
+}
+
+// finish 流结束：发送带 finish_reason 与 usage 的最后一个 chunk 和 [DONE]
+func (c *GeminiOpenAIStreamConverter) finish() ([]string, error) {
+	if c.id == "" {
+		c.id = ConvertIDClaudeToOpenAI(geminiMessageID(""))
+	}
+	finishReason := c.finishReason
+	if finishReason == "" {
+		finishReason = "stop"
+	}
+	if finishReason == "stop" && c.toolCallIndex > 0 {
+		finishReason = "tool_calls"
+	}
+
+	event, err := formatOpenAIStreamData(c.chunk(OpenAIStreamDelta{}, &finishReason, c.usage))
+	if err != nil {
+		return nil, err
+	}
+	return []string{event, "data: [DONE]\n\n"}, nil
+}
+
+// chunk 构建 OpenAI 流式响应块
+func (c *GeminiOpenAIStreamConverter) chunk(delta OpenAIStreamDelta, finishReason *string, usage *OpenAIUsage) OpenAIStreamChunk {
+	return OpenAIStreamChunk{
+		ID:      c.id,
+		Object:  OpenAIObjectChatCompletionChunk,
+		Created: c.created,
+		Model:   c.model,
+		Choices: []OpenAIStreamChoice{{
+			Index:        0,
+			Delta:        delta,
+			FinishReason: finishReason,
+		}},
+		Usage: usage,
+	}
+}
+
+// formatOpenAIStreamData 格式化 OpenAI SSE 数据行: data: {...}\n\n
+func formatOpenAIStreamData(data interface{}) (string, error) {
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal chunk: %w", err)
+	}
+	return fmt.Sprintf("data: %s\n\n", jsonData), nil
+}
+
+// writeStreamEvents 写入事件，写入失败（客户端断开）时返回 false
+func writeStreamEvents(w io.Writer, events []string) bool {
+	for _, event := range events {
+		if _, err := w.Write([]byte(event)); err != nil {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,391 @@
+package converter
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// 测试 Claude→Gemini：system、图片、工具调用与工具结果
+func TestConvertClaudeToGemini(t *testing.T) {
+	req := &ClaudeRequest{
+		Model:         "gemini-2.0-flash",
+		System:        NewSystemPrompt("You are helpful."),
+		MaxTokens:     256,
+		StopSequences: []string{"END"},
+		Messages: []ClaudeMessage{
+			{Role: "user", Content: []ClaudeContentBlock{
+				{Type: "text", Text: StringPtr("What's in this image and the weather?")},
+				{Type: "image", Source: &ClaudeImageSource{Type: "base64", MediaType: "image/png", Data: "iVBORw0KGgo="}},
+			}},
+			{Role: "assistant", Content: []ClaudeContentBlock{
+				{Type: "text", Text: StringPtr("Let me check.")},
+				{Type: "tool_use", ID: StringPtr("toolu_1"), Name: StringPtr("get_weather"), Input: map[string]interface{}{"city": "Paris"}},
+			}},
+			{Role: "user", Content: []ClaudeContentBlock{
+				{Type: "tool_result", ToolUseID: StringPtr("toolu_1"), Content: NewToolResultText(`{"temp":20}`)},
+			}},
+			{Role: "user", Content: []ClaudeContentBlock{
+				{Type: "text", Text: StringPtr("Thanks")},
+			}},
+		},
+		Tools: []ClaudeTool{{
+			Name:        "get_weather",
+			Description: "Get weather",
+			InputSchema: map[string]interface{}{
+				"$schema":              "http://json-schema.org/draft-07/schema#",
+				"type":                 "object",
+				"additionalProperties": false,
+				"properties": map[string]interface{}{
+					"city": map[string]interface{}{"type": []interface{}{"string", "null"}, "default": "Paris"},
+				},
+				"required": []interface{}{"city"},
+			},
+		}},
+		ToolChoice: &ClaudeToolChoice{Type: "tool", Name: StringPtr("get_weather")},
+	}
+
+	geminiReq, err := ConvertClaudeToGemini(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if geminiReq.SystemInstruction == nil || *geminiReq.SystemInstruction.Parts[0].Text != "You are helpful." {
+		t.Fatalf("expected systemInstruction, got %+v", geminiReq.SystemInstruction)
+	}
+	if geminiReq.GenerationConfig.MaxOutputTokens != 256 || geminiReq.GenerationConfig.StopSequences[0] != "END" {
+		t.Fatalf("unexpected generationConfig: %+v", geminiReq.GenerationConfig)
+	}
+
+	// 工具结果与后续用户文本合并为同一条 user 消息
+	if len(geminiReq.Contents) != 3 {
+		t.Fatalf("expected 3 contents after merging, got %d", len(geminiReq.Contents))
+	}
+	if image := geminiReq.Contents[0].Parts[1].InlineData; image == nil || image.MimeType != "image/png" {
+		t.Fatalf("expected inline image, got %+v", geminiReq.Contents[0].Parts[1])
+	}
+	model := geminiReq.Contents[1]
+	if model.Role != GeminiRoleModel || model.Parts[1].FunctionCall == nil || model.Parts[1].FunctionCall.Args["city"] != "Paris" {
+		t.Fatalf("expected model function call, got %+v", model)
+	}
+	last := geminiReq.Contents[2]
+	if last.Role != GeminiRoleUser || len(last.Parts) != 2 {
+		t.Fatalf("expected merged user content, got %+v", last)
+	}
+	response := last.Parts[0].FunctionResponse
+	if response == nil || response.Name != "get_weather" || response.Response["temp"] != float64(20) {
+		t.Fatalf("expected function response resolved by tool_use_id, got %+v", last.Parts[0])
+	}
+
+	params := geminiReq.Tools[0].FunctionDeclarations[0].Parameters
+	if _, exists := params["$schema"]; exists {
+		t.Fatalf("$schema should be removed: %v", params)
+	}
+	if _, exists := params["additionalProperties"]; exists {
+		t.Fatalf("additionalProperties should be removed: %v", params)
+	}
+	city := params["properties"].(map[string]interface{})["city"].(map[string]interface{})
+	if city["type"] != "string" || city["nullable"] != true || city["default"] != nil {
+		t.Fatalf("unexpected sanitized property: %v", city)
+	}
+
+	config := geminiReq.ToolConfig.FunctionCallingConfig
+	if config.Mode != GeminiFunctionModeAny || !reflect.DeepEqual(config.AllowedFunctionNames, []string{"get_weather"}) {
+		t.Fatalf("unexpected tool config: %+v", config)
+	}
+}
+
+// 测试 tool_result 引用未知的 tool_use_id 时报错
+func TestConvertClaudeToGemini_UnknownToolResult(t *testing.T) {
+	req := &ClaudeRequest{
+		Messages: []ClaudeMessage{{Role: "user", Content: []ClaudeContentBlock{
+			{Type: "tool_result", ToolUseID: StringPtr("toolu_missing"), Content: NewToolResultText("42")},
+		}}},
+	}
+	if _, err := ConvertClaudeToGemini(req); err == nil {
+		t.Fatal("expected error for unknown tool_use_id")
+	}
+}
+
+// 测试 OpenAI→Gemini：system/developer、图片、tool_calls 与 tool 消息
+func TestConvertOpenAIToGemini(t *testing.T) {
+	var req OpenAIRequest
+	raw := `{
+		"model": "gemini-2.0-flash",
+		"max_tokens": 100,
+		"max_completion_tokens": 200,
+		"messages": [
+			{"role": "system", "content": "Be brief."},
+			{"role": "developer", "content": "Use tools."},
+			{"role": "user", "content": [
+				{"type": "text", "text": "Describe"},
+				{"type": "image_url", "image_url": {"url": "data:image/jpeg;base64,/9j/4AAQ"}},
+				{"type": "image_url", "image_url": {"url": "https://example.com/cat.png?size=large"}}
+			]},
+			{"role": "assistant", "content": null, "tool_calls": [
+				{"id": "call_1", "type": "function", "function": {"name": "lookup", "arguments": "{\"q\":\"cat\"}"}}
+			]},
+			{"role": "tool", "tool_call_id": "call_1", "content": "a small cat"}
+		],
+		"tools": [{"type": "function", "function": {"name": "lookup", "parameters": {"type": "object", "properties": {}}}}],
+		"tool_choice": "required"
+	}`
+	if err := json.Unmarshal([]byte(raw), &req); err != nil {
+		t.Fatalf("failed to decode request: %v", err)
+	}
+
+	geminiReq, err := ConvertOpenAIToGemini(&req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := *geminiReq.SystemInstruction.Parts[0].Text; got != "Be brief.\nUse tools." {
+		t.Fatalf("unexpected system instruction: %q", got)
+	}
+	if geminiReq.GenerationConfig.MaxOutputTokens != 200 {
+		t.Fatalf("max_completion_tokens should take precedence, got %d", geminiReq.GenerationConfig.MaxOutputTokens)
+	}
+
+	user := geminiReq.Contents[0]
+	if user.Parts[1].InlineData == nil || user.Parts[1].InlineData.MimeType != "image/jpeg" || user.Parts[1].InlineData.Data != "/9j/4AAQ" {
+		t.Fatalf("expected inline data from data URI, got %+v", user.Parts[1])
+	}
+	if user.Parts[2].FileData == nil || user.Parts[2].FileData.MimeType != "image/png" {
+		t.Fatalf("expected file data for remote URL, got %+v", user.Parts[2])
+	}
+
+	call := geminiReq.Contents[1].Parts[0].FunctionCall
+	if call == nil || call.Name != "lookup" || call.Args["q"] != "cat" {
+		t.Fatalf("unexpected function call: %+v", geminiReq.Contents[1])
+	}
+	response := geminiReq.Contents[2].Parts[0].FunctionResponse
+	if response == nil || response.Name != "lookup" || response.Response["content"] != "a small cat" {
+		t.Fatalf("unexpected function response: %+v", geminiReq.Contents[2])
+	}
+
+	// 没有属性的参数对象应省略
+	if params := geminiReq.Tools[0].FunctionDeclarations[0].Parameters; params != nil {
+		t.Fatalf("expected empty parameters to be omitted, got %v", params)
+	}
+	if geminiReq.ToolConfig.FunctionCallingConfig.Mode != GeminiFunctionModeAny {
+		t.Fatalf("expected ANY mode for required tool choice, got %+v", geminiReq.ToolConfig.FunctionCallingConfig)
+	}
+}
+
+// 测试 Gemini→Claude / Gemini→OpenAI 非流式响应转换
+func TestConvertGeminiResponse(t *testing.T) {
+	tests := []struct {
+		name         string
+		raw          string
+		stopReason   string
+		finishReason string
+		text         string
+		toolName     string
+	}{
+		{
+			name:         "text",
+			raw:          `{"candidates":[{"content":{"role":"model","parts":[{"text":"Hel"},{"text":"lo"}]},"finishReason":"STOP"}],"usageMetadata":{"promptTokenCount":5,"candidatesTokenCount":2,"thoughtsTokenCount":3},"responseId":"abc"}`,
+			stopReason:   "end_turn",
+			finishReason: "stop",
+			text:         "Hello",
+		},
+		{
+			name:         "function call",
+			raw:          `{"candidates":[{"content":{"role":"model","parts":[{"text":"thinking","thought":true},{"functionCall":{"name":"lookup","args":{"q":"cat"}}}]},"finishReason":"STOP"}]}`,
+			stopReason:   "tool_use",
+			finishReason: "tool_calls",
+			toolName:     "lookup",
+		},
+		{
+			name:         "max tokens",
+			raw:          `{"candidates":[{"content":{"role":"model","parts":[{"text":"Hi"}]},"finishReason":"MAX_TOKENS"}]}`,
+			stopReason:   "max_tokens",
+			finishReason: "length",
+			text:         "Hi",
+		},
+		{
+			name:         "safety",
+			raw:          `{"candidates":[{"content":{"role":"model","parts":[]},"finishReason":"SAFETY"}]}`,
+			stopReason:   "refusal",
+			finishReason: "content_filter",
+		},
+		{
+			name:         "prompt blocked",
+			raw:          `{"promptFeedback":{"blockReason":"PROHIBITED_CONTENT"},"usageMetadata":{"promptTokenCount":5}}`,
+			stopReason:   "refusal",
+			finishReason: "content_filter",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var resp GeminiResponse
+			if err := json.Unmarshal([]byte(tt.raw), &resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+
+			claudeResp, err := ConvertGeminiToClaude(&resp, "gemini-2.0-flash")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if claudeResp.StopReason != tt.stopReason {
+				t.Fatalf("expected stop_reason %s, got %s", tt.stopReason, claudeResp.StopReason)
+			}
+			if tt.text != "" && ExtractTextFromClaudeContent(claudeResp.Content) != tt.text {
+				t.Fatalf("expected text %q, got %+v", tt.text, claudeResp.Content)
+			}
+
+			openaiResp, err := ConvertGeminiToOpenAI(&resp, "gemini-2.0-flash")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			choice := openaiResp.Choices[0]
+			if choice.FinishReason != tt.finishReason {
+				t.Fatalf("expected finish_reason %s, got %s", tt.finishReason, choice.FinishReason)
+			}
+
+			if tt.toolName != "" {
+				if len(claudeResp.Content) != 1 || claudeResp.Content[0].Type != ContentTypeToolUse || *claudeResp.Content[0].Name != tt.toolName {
+					t.Fatalf("expected only tool_use block (thought skipped), got %+v", claudeResp.Content)
+				}
+				if len(choice.Message.ToolCalls) != 1 || choice.Message.ToolCalls[0].Function.Arguments != `{"q":"cat"}` {
+					t.Fatalf("unexpected tool calls: %+v", choice.Message.ToolCalls)
+				}
+				if choice.Message.Content != nil {
+					t.Fatalf("expected null content with tool calls, got %v", choice.Message.Content)
+				}
+			}
+		})
+	}
+
+	var resp GeminiResponse
+	_ = json.Unmarshal([]byte(tests[0].raw), &resp)
+	claudeResp, _ := ConvertGeminiToClaude(&resp, "gemini-2.0-flash")
+	if claudeResp.ID != "msg_abc" || claudeResp.Usage.InputTokens != 5 || claudeResp.Usage.OutputTokens != 5 {
+		t.Fatalf("unexpected id/usage: %s %+v", claudeResp.ID, claudeResp.Usage)
+	}
+
+	if _, err := ConvertGeminiToClaude(&GeminiResponse{}, "gemini-2.0-flash"); err == nil {
+		t.Fatal("expected error for response without candidates")
+	}
+}
+
+// geminiTestStream 模拟 Gemini streamGenerateContent?alt=sse 输出（CRLF 分隔）
+const geminiTestStream = "data: {\"candidates\":[{\"content\":{\"role\":\"model\",\"parts\":[{\"text\":\"Hel\"}]}}],\"usageMetadata\":{\"promptTokenCount\":7},\"responseId\":\"r1\"}\r\n\r\n" +
+	"data: {\"candidates\":[{\"content\":{\"role\":\"model\",\"parts\":[{\"text\":\"lo\"}]}}]}\r\n\r\n" +
+	"data: {\"candidates\":[{\"content\":{\"role\":\"model\",\"parts\":[{\"functionCall\":{\"name\":\"lookup\",\"args\":{\"q\":\"cat\"}}}]},\"finishReason\":\"STOP\"}],\"usageMetadata\":{\"promptTokenCount\":7,\"candidatesTokenCount\":4}}\r\n\r\n"
+
+// 测试 Gemini 流式响应转换为 Claude 事件
+func TestConvertGeminiStreamToClaude(t *testing.T) {
+	reader, err := ConvertGeminiStreamToClaude(context.Background(), strings.NewReader(geminiTestStream), "gemini-2.0-flash")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	output, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("failed to read stream: %v", err)
+	}
+
+	var types []string
+	var stopReason string
+	var outputTokens int
+	for _, line := range strings.Split(string(output), "\n") {
+		data, ok := strings.CutPrefix(line, "data: ")
+		if !ok {
+			continue
+		}
+		var event struct {
+			Type  string `json:"type"`
+			Delta struct {
+				Type        string `json:"type"`
+				Text        string `json:"text"`
+				PartialJSON string `json:"partial_json"`
+				StopReason  string `json:"stop_reason"`
+			} `json:"delta"`
+			Usage struct {
+				OutputTokens int `json:"output_tokens"`
+			} `json:"usage"`
+		}
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			t.Fatalf("invalid event data %q: %v", data, err)
+		}
+		types = append(types, event.Type)
+		if event.Type == EventTypeMessageDelta {
+			stopReason = event.Delta.StopReason
+			outputTokens = event.Usage.OutputTokens
+		}
+	}
+
+	expected := []string{
+		"message_start",
+		"content_block_start", "content_block_delta", "content_block_delta", "content_block_stop",
+		"content_block_start", "content_block_delta", "content_block_stop",
+		"message_delta", "message_stop",
+	}
+	if !reflect.DeepEqual(types, expected) {
+		t.Fatalf("unexpected event sequence:\n got: %v\nwant: %v", types, expected)
+	}
+	if stopReason != "tool_use" || outputTokens != 4 {
+		t.Fatalf("unexpected message_delta: stop_reason=%s output_tokens=%d", stopReason, outputTokens)
+	}
+	if !strings.Contains(string(output), `"partial_json":"{\"q\":\"cat\"}"`) {
+		t.Fatalf("expected complete function args as input_json_delta, got:\n%s", output)
+	}
+}
+
+// 测试 Gemini 流式响应转换为 OpenAI chunk
+func TestConvertGeminiStreamToOpenAI(t *testing.T) {
+	reader, err := ConvertGeminiStreamToOpenAI(context.Background(), strings.NewReader(geminiTestStream), "gemini-2.0-flash")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	output, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("failed to read stream: %v", err)
+	}
+
+	var chunks []OpenAIStreamChunk
+	done := false
+	for _, line := range strings.Split(string(output), "\n") {
+		data, ok := strings.CutPrefix(line, "data: ")
+		if !ok {
+			continue
+		}
+		if data == "[DONE]" {
+			done = true
+			continue
+		}
+		var chunk OpenAIStreamChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			t.Fatalf("invalid chunk %q: %v", data, err)
+		}
+		chunks = append(chunks, chunk)
+	}
+
+	if !done {
+		t.Fatal("expected data: [DONE]")
+	}
+	if len(chunks) != 4 {
+		t.Fatalf("expected 4 chunks, got %d:\n%s", len(chunks), output)
+	}
+	if chunks[0].Choices[0].Delta.Role != "assistant" || chunks[0].Choices[0].Delta.Content != "Hel" {
+		t.Fatalf("unexpected first chunk: %+v", chunks[0])
+	}
+	toolCalls := chunks[2].Choices[0].Delta.ToolCalls
+	if len(toolCalls) != 1 || toolCalls[0].Function.Name != "lookup" || toolCalls[0].Function.Arguments != `{"q":"cat"}` {
+		t.Fatalf("unexpected tool call chunk: %+v", chunks[2])
+	}
+	final := chunks[3]
+	if final.Choices[0].FinishReason == nil || *final.Choices[0].FinishReason != "tool_calls" {
+		t.Fatalf("expected tool_calls finish reason, got %+v", final.Choices[0])
+	}
+	if final.Usage == nil || final.Usage.PromptTokens != 7 || final.Usage.CompletionTokens != 4 {
+		t.Fatalf("expected usage on final chunk, got %+v", final.Usage)
+	}
+	if final.ID != "chatcmpl-r1" {
+		t.Fatalf("expected id derived from responseId, got %s", final.ID)
+	}
+}
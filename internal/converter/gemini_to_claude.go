@@ -0,0 +1,477 @@
+package converter
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// DefaultClaudeMaxTokens Gemini 请求未指定 maxOutputTokens 时使用的 max_tokens（Claude 要求必填）
+const DefaultClaudeMaxTokens = 4096
+
+// ConvertGeminiRequestToClaude 将 Gemini generateContent 请求转换为 Claude Messages API 请求
+// 调用方负责设置 model 与 stream
+func ConvertGeminiRequestToClaude(req *GeminiRequest) (*ClaudeRequest, error) {
+	if err := ValidateNonNil(req, "Gemini请求"); err != nil {
+		return nil, NewConversionError("request", "验证失败", err)
+	}
+
+	claudeReq := &ClaudeRequest{MaxTokens: DefaultClaudeMaxTokens}
+	if config := req.GenerationConfig; config != nil {
+		if config.MaxOutputTokens > 0 {
+			claudeReq.MaxTokens = config.MaxOutputTokens
+		}
+		claudeReq.Temperature = config.Temperature
+		claudeReq.TopP = config.TopP
+		claudeReq.StopSequences = config.StopSequences
+	}
+
+	if req.SystemInstruction != nil {
+		var texts []string
+		for _, part := range req.SystemInstruction.Parts {
+			if part.Text != nil && *part.Text != "" {
+				texts = append(texts, *part.Text)
+			}
+		}
+		claudeReq.System = NewSystemPrompt(strings.Join(texts, "\n"))
+	}
+
+	// Gemini 的 functionCall / functionResponse 可以不带 ID，按函数名依次配对生成 tool_use_id
+	pendingCalls := make(map[string][]string)
+
+	for _, content := range req.Contents {
+		role, err := claudeRoleFromGemini(content.Role)
+		if err != nil {
+			return nil, NewConversionError("request", "转换消息失败", err)
+		}
+
+		var blocks []ClaudeContentBlock
+		for _, part := range content.Parts {
+			block, ok, err := geminiPartToClaudeBlock(part, pendingCalls)
+			if err != nil {
+				return nil, NewConversionError("request", "转换消息失败", err)
+			}
+			if ok {
+				blocks = append(blocks, block)
+			}
+		}
+		claudeReq.Messages = appendClaudeMessage(claudeReq.Messages, role, blocks)
+	}
+
+	for _, tool := range req.Tools {
+		for _, declaration := range tool.FunctionDeclarations {
+			schema := declaration.ParametersJSONSchema
+			if schema == nil {
+				schema = claudeSchemaFromGemini(declaration.Parameters)
+			}
+			if schema == nil {
+				schema = map[string]interface{}{"type": "object", "properties": map[string]interface{}{}}
+			}
+			claudeReq.Tools = append(claudeReq.Tools, ClaudeTool{
+				Name:        declaration.Name,
+				Description: declaration.Description,
+				InputSchema: schema,
+			})
+		}
+	}
+
+	if req.ToolConfig != nil && req.ToolConfig.FunctionCallingConfig != nil {
+		config := req.ToolConfig.FunctionCallingConfig
+		switch strings.ToUpper(config.Mode) {
+		case GeminiFunctionModeAny:
+			if len(config.AllowedFunctionNames) == 1 {
+				claudeReq.ToolChoice = &ClaudeToolChoice{Type: "tool", Name: StringPtr(config.AllowedFunctionNames[0])}
+			} else {
+				claudeReq.ToolChoice = &ClaudeToolChoice{Type: "any"}
+			}
+		case GeminiFunctionModeNone:
+			claudeReq.ToolChoice = &ClaudeToolChoice{Type: "none"}
+		case GeminiFunctionModeAuto:
+			claudeReq.ToolChoice = &ClaudeToolChoice{Type: "auto"}
+		}
+	}
+
+	return claudeReq, nil
+}
+
+// claudeRoleFromGemini 转换 Gemini 角色；role 可省略（单轮请求），旧版客户端的 function 角色视为 user
+func claudeRoleFromGemini(role string) (string, error) {
+	switch role {
+	case "", GeminiRoleUser, "function":
+		return ClaudeRoleUser, nil
+	case GeminiRoleModel:
+		return ClaudeRoleAssistant, nil
+	default:
+		return "", fmt.Errorf("不支持的角色: %s", role)
+	}
+}
+
+// geminiPartToClaudeBlock 转换单个 Gemini part，第二个返回值表示是否生成了内容块
+func geminiPartToClaudeBlock(part GeminiPart, pendingCalls map[string][]string) (ClaudeContentBlock, bool, error) {
+	switch {
+	case part.Thought:
+		// 思考过程由上游模型重新生成，不回传
+		return ClaudeContentBlock{}, false, nil
+
+	case part.FunctionCall != nil:
+		call := part.FunctionCall
+		id := call.ID
+		if id == "" {
+			id = "toolu_" + randomHexID()
+		}
+		pendingCalls[call.Name] = append(pendingCalls[call.Name], id)
+
+		input := call.Args
+		if input == nil {
+			input = map[string]interface{}{}
+		}
+		return ClaudeContentBlock{
+			Type:  ContentTypeToolUse,
+			ID:    StringPtr(id),
+			Name:  StringPtr(call.Name),
+			Input: input,
+		}, true, nil
+
+	case part.FunctionResponse != nil:
+		response := part.FunctionResponse
+		id, err := popPendingCall(pendingCalls, response.Name, response.ID)
+		if err != nil {
+			return ClaudeContentBlock{}, false, err
+		}
+		content, err := claudeToolResultContent(response.Response)
+		if err != nil {
+			return ClaudeContentBlock{}, false, err
+		}
+		return ClaudeContentBlock{
+			Type:      ContentTypeToolResult,
+			ToolUseID: StringPtr(id),
+			Content:   NewToolResultText(content),
+		}, true, nil
+
+	case part.InlineData != nil:
+		if !strings.HasPrefix(part.InlineData.MimeType, "image/") {
+			return ClaudeContentBlock{}, false, fmt.Errorf("不支持的 inlineData 类型: %s", part.InlineData.MimeType)
+		}
+		return ClaudeContentBlock{
+			Type: ContentTypeImage,
+			Source: &ClaudeImageSource{
+				Type:      "base64",
+				MediaType: part.InlineData.MimeType,
+				Data:      part.InlineData.Data,
+			},
+		}, true, nil
+
+	case part.FileData != nil:
+		return ClaudeContentBlock{}, false, fmt.Errorf("不支持 fileData 引用的文件: %s", part.FileData.FileURI)
+
+	case part.Text != nil && *part.Text != "":
+		return ClaudeContentBlock{Type: ContentTypeText, Text: part.Text}, true, nil
+	}
+
+	return ClaudeContentBlock{}, false, nil
+}
+
+// popPendingCall 为 functionResponse 找到对应的 tool_use_id
+// 带 ID 时直接使用，否则取同名函数中最早未配对的调用
+func popPendingCall(pendingCalls map[string][]string, name, id string) (string, error) {
+	ids := pendingCalls[name]
+	if id != "" {
+		for i, pending := range ids {
+			if pending == id {
+				pendingCalls[name] = append(ids[:i:i], ids[i+1:]...)
+				break
+			}
+		}
+		return id, nil
+	}
+
+	if len(ids) == 0 {
+		return "", fmt.Errorf("functionResponse 没有对应的 functionCall: %s", name)
+	}
+	pendingCalls[name] = ids[1:]
+	return ids[0], nil
+}
+
+// claudeToolResultContent 将 functionResponse.response 转换为 tool_result 文本
+// 只有一个字符串字段时（如 {"output": "..."}）直接使用该字符串，否则序列化整个对象
+func claudeToolResultContent(response map[string]interface{}) (string, error) {
+	if len(response) == 1 {
+		for _, value := range response {
+			if text, ok := value.(string); ok {
+				return text, nil
+			}
+		}
+	}
+	if response == nil {
+		return "", nil
+	}
+
+	data, err := json.Marshal(response)
+	if err != nil {
+		return "", fmt.Errorf("序列化 functionResponse 失败: %w", err)
+	}
+	return string(data), nil
+}
+
+// claudeSchemaFromGemini 将 Gemini（OpenAPI 风格）参数 Schema 转换为 JSON Schema
+// Gemini SDK 生成的 type 为大写枚举（如 OBJECT、STRING），JSON Schema 要求小写
+func claudeSchemaFromGemini(schema map[string]interface{}) map[string]interface{} {
+	if schema == nil {
+		return nil
+	}
+
+	result := make(map[string]interface{}, len(schema))
+	for key, value := range schema {
+		switch key {
+		case "type":
+			if t, ok := value.(string); ok {
+				result[key] = strings.ToLower(t)
+				continue
+			}
+			result[key] = value
+		case "properties":
+			props, ok := value.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			converted := make(map[string]interface{}, len(props))
+			for name, prop := range props {
+				if propSchema, ok := prop.(map[string]interface{}); ok {
+					converted[name] = claudeSchemaFromGemini(propSchema)
+				}
+			}
+			result[key] = converted
+		case "items":
+			if itemSchema, ok := value.(map[string]interface{}); ok {
+				result[key] = claudeSchemaFromGemini(itemSchema)
+			}
+		case "anyOf":
+			options, ok := value.([]interface{})
+			if !ok {
+				continue
+			}
+			converted := make([]interface{}, 0, len(options))
+			for _, option := range options {
+				if optionSchema, ok := option.(map[string]interface{}); ok {
+					converted = append(converted, claudeSchemaFromGemini(optionSchema))
+				}
+			}
+			result[key] = converted
+		default:
+			result[key] = value
+		}
+	}
+	return result
+}
+
+// appendClaudeMessage 追加消息；Claude 要求角色交替，相邻同角色消息合并为一条，空消息丢弃
+func appendClaudeMessage(messages []ClaudeMessage, role string, blocks []ClaudeContentBlock) []ClaudeMessage {
+	if len(blocks) == 0 {
+		return messages
+	}
+	if n := len(messages); n > 0 && messages[n-1].Role == role {
+		messages[n-1].Content = append(messages[n-1].Content, blocks...)
+		return messages
+	}
+	return append(messages, ClaudeMessage{Role: role, Content: blocks})
+}
+
+// ConvertStopReasonToGeminiFinishReason 转换 Claude stop_reason 为 Gemini finishReason
+func ConvertStopReasonToGeminiFinishReason(stopReason string) string {
+	switch stopReason {
+	case "max_tokens":
+		return "MAX_TOKENS"
+	case ClaudeStopReasonRefusal:
+		return "SAFETY"
+	default:
+		return "STOP"
+	}
+}
+
+// ConvertClaudeResponseToGemini 将 Claude Messages API 响应转换为 Gemini generateContent 响应
+func ConvertClaudeResponseToGemini(resp *ClaudeResponse) (*GeminiResponse, error) {
+	if err := ValidateNonNil(resp, "Claude响应"); err != nil {
+		return nil, NewConversionError("response", "验证失败", err)
+	}
+
+	parts := []GeminiPart{}
+	for _, block := range resp.Content {
+		switch block.Type {
+		case ContentTypeText:
+			if block.Text != nil && *block.Text != "" {
+				parts = append(parts, GeminiPart{Text: block.Text})
+			}
+		case ContentTypeToolUse:
+			if block.Name == nil {
+				continue
+			}
+			call := &GeminiFunctionCall{Name: *block.Name, Args: block.Input}
+			if block.ID != nil {
+				call.ID = *block.ID
+			}
+			parts = append(parts, GeminiPart{FunctionCall: call})
+		}
+	}
+
+	return &GeminiResponse{
+		Candidates: []GeminiCandidate{{
+			Content:      GeminiContent{Role: GeminiRoleModel, Parts: parts},
+			FinishReason: ConvertStopReasonToGeminiFinishReason(resp.StopReason),
+		}},
+		UsageMetadata: geminiUsageFromClaude(resp.Usage),
+		ModelVersion:  resp.Model,
+		ResponseID:    resp.ID,
+	}, nil
+}
+
+// geminiUsageFromClaude 转换 token 用量
+func geminiUsageFromClaude(usage ClaudeUsage) *GeminiUsageMetadata {
+	prompt := usage.TotalInputTokens()
+	return &GeminiUsageMetadata{
+		PromptTokenCount:        prompt,
+		CandidatesTokenCount:    usage.OutputTokens,
+		TotalTokenCount:         prompt + usage.OutputTokens,
+		CachedContentTokenCount: usage.CacheReadInputTokens,
+	}
+}
+
+// ClaudeGeminiStreamConverter 将 Claude SSE 事件逐个转换为 Gemini streamGenerateContent 响应块
+// 文本增量立即输出；工具调用的参数增量先累积，在 content_block_stop 时输出完整的 functionCall
+type ClaudeGeminiStreamConverter struct {
+	responseID string
+	model      string
+	usage      ClaudeUsage
+	stopReason string
+	toolCalls  map[int]*GeminiFunctionCall
+	toolArgs   map[int]*strings.Builder
+}
+
+// claudeStreamEvent Claude 流式事件（各事件类型的字段并集）
+type claudeStreamEvent struct {
+	Type         string              `json:"type"`
+	Index        int                 `json:"index"`
+	Message      *ClaudeResponse     `json:"message,omitempty"`
+	ContentBlock *ClaudeContentBlock `json:"content_block,omitempty"`
+	Delta        *struct {
+		Type        string `json:"type"`
+		Text        string `json:"text"`
+		Thinking    string `json:"thinking"`
+		PartialJSON string `json:"partial_json"`
+		StopReason  string `json:"stop_reason"`
+	} `json:"delta,omitempty"`
+	Usage *ClaudeUsage `json:"usage,omitempty"`
+	Error *struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+// NewClaudeGeminiStreamConverter 创建 Claude→Gemini 流式转换器
+func NewClaudeGeminiStreamConverter() *ClaudeGeminiStreamConverter {
+	return &ClaudeGeminiStreamConverter{
+		toolCalls: make(map[int]*GeminiFunctionCall),
+		toolArgs:  make(map[int]*strings.Builder),
+	}
+}
+
+// ProcessEvent 处理一个 Claude SSE 事件的 data，返回需要输出的 Gemini 响应块
+// 上游 error 事件以错误返回
+func (c *ClaudeGeminiStreamConverter) ProcessEvent(data []byte) ([]*GeminiResponse, error) {
+	var event claudeStreamEvent
+	if err := json.Unmarshal(data, &event); err != nil {
+		return nil, NewConversionError("stream", "解析 Claude 事件失败", err)
+	}
+
+	switch event.Type {
+	case EventTypeMessageStart:
+		if event.Message != nil {
+			c.responseID = event.Message.ID
+			c.model = event.Message.Model
+			c.usage = event.Message.Usage
+		}
+
+	case EventTypeContentBlockStart:
+		block := event.ContentBlock
+		if block == nil {
+			return nil, nil
+		}
+		switch block.Type {
+		case ContentTypeToolUse:
+			call := &GeminiFunctionCall{}
+			if block.ID != nil {
+				call.ID = *block.ID
+			}
+			if block.Name != nil {
+				call.Name = *block.Name
+			}
+			c.toolCalls[event.Index] = call
+			c.toolArgs[event.Index] = &strings.Builder{}
+		case ContentTypeText:
+			if block.Text != nil && *block.Text != "" {
+				return []*GeminiResponse{c.chunk([]GeminiPart{{Text: block.Text}}, "")}, nil
+			}
+		}
+
+	case EventTypeContentBlockDelta:
+		if event.Delta == nil {
+			return nil, nil
+		}
+		switch event.Delta.Type {
+		case "text_delta":
+			if event.Delta.Text != "" {
+				return []*GeminiResponse{c.chunk([]GeminiPart{{Text: StringPtr(event.Delta.Text)}}, "")}, nil
+			}
+		case "input_json_delta":
+			if args, ok := c.toolArgs[event.Index]; ok {
+				args.WriteString(event.Delta.PartialJSON)
+			}
+		}
+
+	case EventTypeContentBlockStop:
+		call, ok := c.toolCalls[event.Index]
+		if !ok {
+			return nil, nil
+		}
+		if raw := strings.TrimSpace(c.toolArgs[event.Index].String()); raw != "" {
+			if err := json.Unmarshal([]byte(raw), &call.Args); err != nil {
+				return nil, NewConversionError("stream", fmt.Sprintf("工具 %s 的参数不是合法 JSON", call.Name), err)
+			}
+		}
+		delete(c.toolCalls, event.Index)
+		delete(c.toolArgs, event.Index)
+		return []*GeminiResponse{c.chunk([]GeminiPart{{FunctionCall: call}}, "")}, nil
+
+	case EventTypeMessageDelta:
+		if event.Delta != nil && event.Delta.StopReason != "" {
+			c.stopReason = event.Delta.StopReason
+		}
+		if event.Usage != nil {
+			mergeClaudeDeltaUsage(&c.usage, event.Usage)
+		}
+
+	case EventTypeMessageStop:
+		final := c.chunk([]GeminiPart{{Text: StringPtr("")}}, ConvertStopReasonToGeminiFinishReason(c.stopReason))
+		final.UsageMetadata = geminiUsageFromClaude(c.usage)
+		return []*GeminiResponse{final}, nil
+
+	case "error":
+		if event.Error != nil && event.Error.Message != "" {
+			return nil, fmt.Errorf("上游返回错误: %s", event.Error.Message)
+		}
+		return nil, fmt.Errorf("上游返回错误")
+	}
+
+	return nil, nil
+}
+
+// chunk 构建单个 Gemini 响应块
+func (c *ClaudeGeminiStreamConverter) chunk(parts []GeminiPart, finishReason string) *GeminiResponse {
+	return &GeminiResponse{
+		Candidates: []GeminiCandidate{{
+			Content:      GeminiContent{Role: GeminiRoleModel, Parts: parts},
+			FinishReason: finishReason,
+		}},
+		ModelVersion: c.model,
+		ResponseID:   c.responseID,
+	}
+}
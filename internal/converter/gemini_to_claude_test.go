@@ -0,0 +1,215 @@
+package converter
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+)
+
+// 测试 Gemini→Claude 请求：system、角色合并、函数调用配对、大写 Schema、toolConfig
+func TestConvertGeminiRequestToClaude(t *testing.T) {
+	raw := `{
+		"systemInstruction": {"parts": [{"text": "Be brief."}, {"text": "Use tools."}]},
+		"contents": [
+			{"parts": [{"text": "Look at this"}]},
+			{"role": "user", "parts": [{"inlineData": {"mimeType": "image/png", "data": "iVBORw0KGgo="}}]},
+			{"role": "model", "parts": [
+				{"text": "hmm", "thought": true},
+				{"functionCall": {"name": "lookup", "args": {"q": "cat"}}},
+				{"functionCall": {"id": "call-2", "name": "lookup", "args": {"q": "dog"}}}
+			]},
+			{"role": "user", "parts": [
+				{"functionResponse": {"id": "call-2", "name": "lookup", "response": {"result": {"count": 2}}}},
+				{"functionResponse": {"name": "lookup", "response": {"output": "a cat"}}}
+			]}
+		],
+		"tools": [{"functionDeclarations": [
+			{"name": "lookup", "description": "Search", "parameters": {"type": "OBJECT", "properties": {"q": {"type": "STRING"}, "tags": {"type": "ARRAY", "items": {"type": "STRING"}}}, "required": ["q"]}},
+			{"name": "now"},
+			{"name": "raw", "parametersJsonSchema": {"type": "object", "properties": {"x": {"type": "integer"}}}}
+		]}],
+		"toolConfig": {"functionCallingConfig": {"mode": "ANY", "allowedFunctionNames": ["lookup"]}},
+		"generationConfig": {"temperature": 0.2, "stopSequences": ["END"]}
+	}`
+
+	var req GeminiRequest
+	if err := json.Unmarshal([]byte(raw), &req); err != nil {
+		t.Fatalf("failed to decode request: %v", err)
+	}
+
+	claudeReq, err := ConvertGeminiRequestToClaude(&req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if claudeReq.System.PlainText() != "Be brief.\nUse tools." {
+		t.Fatalf("unexpected system: %q", claudeReq.System.PlainText())
+	}
+	if claudeReq.MaxTokens != DefaultClaudeMaxTokens || *claudeReq.Temperature != 0.2 || claudeReq.StopSequences[0] != "END" {
+		t.Fatalf("unexpected generation params: %+v", claudeReq)
+	}
+	if len(claudeReq.Messages) != 3 {
+		t.Fatalf("expected 3 messages after merging, got %d", len(claudeReq.Messages))
+	}
+
+	user := claudeReq.Messages[0]
+	if user.Role != "user" || len(user.Content) != 2 || user.Content[1].Type != ContentTypeImage || user.Content[1].Source.MediaType != "image/png" {
+		t.Fatalf("unexpected first user message: %+v", user)
+	}
+
+	assistant := claudeReq.Messages[1]
+	if assistant.Role != "assistant" || len(assistant.Content) != 2 {
+		t.Fatalf("expected thought skipped and two tool_use blocks, got %+v", assistant.Content)
+	}
+	firstID := *assistant.Content[0].ID
+	if !strings.HasPrefix(firstID, "toolu_") || *assistant.Content[1].ID != "call-2" {
+		t.Fatalf("unexpected tool_use ids: %s %s", firstID, *assistant.Content[1].ID)
+	}
+
+	results := claudeReq.Messages[2].Content
+	if *results[0].ToolUseID != "call-2" || results[0].Content.PlainText() != `{"result":{"count":2}}` {
+		t.Fatalf("unexpected tool_result with id: %+v", results[0])
+	}
+	if *results[1].ToolUseID != firstID || results[1].Content.PlainText() != "a cat" {
+		t.Fatalf("expected tool_result without id to pair with first call, got %+v", results[1])
+	}
+
+	if len(claudeReq.Tools) != 3 {
+		t.Fatalf("expected 3 tools, got %d", len(claudeReq.Tools))
+	}
+	schema := claudeReq.Tools[0].InputSchema
+	props := schema["properties"].(map[string]interface{})
+	if schema["type"] != "object" || props["q"].(map[string]interface{})["type"] != "string" ||
+		props["tags"].(map[string]interface{})["items"].(map[string]interface{})["type"] != "string" {
+		t.Fatalf("expected lowercase schema types, got %+v", schema)
+	}
+	if claudeReq.Tools[1].InputSchema["type"] != "object" {
+		t.Fatalf("expected empty object schema for tool without parameters, got %+v", claudeReq.Tools[1].InputSchema)
+	}
+	if _, ok := claudeReq.Tools[2].InputSchema["properties"].(map[string]interface{})["x"]; !ok {
+		t.Fatalf("expected parametersJsonSchema to be used as-is, got %+v", claudeReq.Tools[2].InputSchema)
+	}
+
+	if claudeReq.ToolChoice == nil || claudeReq.ToolChoice.Type != "tool" || *claudeReq.ToolChoice.Name != "lookup" {
+		t.Fatalf("unexpected tool_choice: %+v", claudeReq.ToolChoice)
+	}
+}
+
+// 测试 Gemini→Claude 请求中无法转换的内容
+func TestConvertGeminiRequestToClaude_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+	}{
+		{"unpaired function response", `{"contents":[{"role":"user","parts":[{"functionResponse":{"name":"lookup","response":{}}}]}]}`},
+		{"file data", `{"contents":[{"role":"user","parts":[{"fileData":{"mimeType":"application/pdf","fileUri":"gs://b/a.pdf"}}]}]}`},
+		{"non-image inline data", `{"contents":[{"role":"user","parts":[{"inlineData":{"mimeType":"audio/wav","data":"UklGRg=="}}]}]}`},
+		{"unknown role", `{"contents":[{"role":"system","parts":[{"text":"hi"}]}]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var req GeminiRequest
+			if err := json.Unmarshal([]byte(tt.raw), &req); err != nil {
+				t.Fatalf("failed to decode request: %v", err)
+			}
+			if _, err := ConvertGeminiRequestToClaude(&req); err == nil {
+				t.Fatal("expected conversion error")
+			}
+		})
+	}
+}
+
+// 测试 Claude→Gemini 非流式响应
+func TestConvertClaudeResponseToGemini(t *testing.T) {
+	resp := &ClaudeResponse{
+		ID:    "msg_1",
+		Model: "claude-sonnet-4-5",
+		Content: []ClaudeContentBlock{
+			{Type: "text", Text: StringPtr("Checking.")},
+			{Type: "tool_use", ID: StringPtr("toolu_1"), Name: StringPtr("lookup"), Input: map[string]interface{}{"q": "cat"}},
+		},
+		StopReason: "tool_use",
+		Usage:      ClaudeUsage{InputTokens: 10, OutputTokens: 5},
+	}
+
+	geminiResp, err := ConvertClaudeResponseToGemini(resp)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	candidate := geminiResp.Candidates[0]
+	if candidate.FinishReason != "STOP" || candidate.Content.Role != GeminiRoleModel || len(candidate.Content.Parts) != 2 {
+		t.Fatalf("unexpected candidate: %+v", candidate)
+	}
+	call := candidate.Content.Parts[1].FunctionCall
+	if call == nil || call.ID != "toolu_1" || call.Name != "lookup" || call.Args["q"] != "cat" {
+		t.Fatalf("unexpected function call: %+v", call)
+	}
+	if geminiResp.UsageMetadata.TotalTokenCount != 15 || geminiResp.ResponseID != "msg_1" {
+		t.Fatalf("unexpected usage/id: %+v %s", geminiResp.UsageMetadata, geminiResp.ResponseID)
+	}
+
+	for stopReason, finishReason := range map[string]string{"end_turn": "STOP", "max_tokens": "MAX_TOKENS", "refusal": "SAFETY", "": "STOP"} {
+		if got := ConvertStopReasonToGeminiFinishReason(stopReason); got != finishReason {
+			t.Fatalf("stop_reason %q: expected %s, got %s", stopReason, finishReason, got)
+		}
+	}
+}
+
+// 测试 Claude 流式事件转换为 Gemini 响应块（以 Gemini→Claude 的输出作为输入，验证往返一致）
+func TestClaudeGeminiStreamConverter(t *testing.T) {
+	reader, err := ConvertGeminiStreamToClaude(context.Background(), strings.NewReader(geminiTestStream), "gemini-2.0-flash")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	claudeStream, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("failed to read stream: %v", err)
+	}
+
+	converter := NewClaudeGeminiStreamConverter()
+	var chunks []*GeminiResponse
+	for _, line := range strings.Split(string(claudeStream), "\n") {
+		data, ok := strings.CutPrefix(line, "data: ")
+		if !ok {
+			continue
+		}
+		out, err := converter.ProcessEvent([]byte(data))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		chunks = append(chunks, out...)
+	}
+
+	var text strings.Builder
+	var call *GeminiFunctionCall
+	for _, chunk := range chunks {
+		for _, part := range chunk.Candidates[0].Content.Parts {
+			if part.Text != nil {
+				text.WriteString(*part.Text)
+			}
+			if part.FunctionCall != nil {
+				call = part.FunctionCall
+			}
+		}
+	}
+	if text.String() != "Hello" {
+		t.Fatalf("expected text Hello, got %q", text.String())
+	}
+	if call == nil || call.Name != "lookup" || call.Args["q"] != "cat" {
+		t.Fatalf("unexpected function call: %+v", call)
+	}
+
+	final := chunks[len(chunks)-1]
+	if final.Candidates[0].FinishReason != "STOP" || final.UsageMetadata == nil ||
+		final.UsageMetadata.PromptTokenCount != 7 || final.UsageMetadata.CandidatesTokenCount != 4 {
+		t.Fatalf("unexpected final chunk: %+v", final)
+	}
+
+	if _, err := converter.ProcessEvent([]byte(`{"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`)); err == nil || !strings.Contains(err.Error(), "Overloaded") {
+		t.Fatalf("expected upstream error, got %v", err)
+	}
+}
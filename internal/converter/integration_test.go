@@ -14,7 +14,7 @@ func TestIntegration_CompleteConversionFlow(t *testing.T) {
 		// Step 1: Claude 请求 -> OpenAI 请求
 		claudeReq := &ClaudeRequest{
 			Model:     "claude-3-5-sonnet-20241022",
-			System:    "You are a helpful assistant",
+			System:    NewSystemPrompt("You are a helpful assistant"),
 			MaxTokens: 1024,
 			Messages: []ClaudeMessage{
 				{
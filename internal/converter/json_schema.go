@@ -0,0 +1,238 @@
+package converter
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"unicode/utf8"
+)
+
+// ValidateJSONSchema 按 JSON Schema 校验已解析的 JSON 值（encoding/json 解码结果）
+// 支持结构化输出常用的关键字：type、properties、required、additionalProperties、items、enum、const、
+// anyOf / oneOf / allOf、长度与数值范围，以及指向 $defs / definitions 的本地 $ref；其他关键字忽略
+func ValidateJSONSchema(value interface{}, schema map[string]interface{}) error {
+	return validateJSONSchema(value, schema, schema, "$")
+}
+
+func validateJSONSchema(value interface{}, schema, root map[string]interface{}, path string) error {
+	if ref, ok := schema["$ref"].(string); ok {
+		resolved, err := resolveJSONSchemaRef(root, ref)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		return validateJSONSchema(value, resolved, root, path)
+	}
+
+	if expected, ok := schema["type"]; ok && !matchesJSONSchemaType(value, expected) {
+		return fmt.Errorf("%s: 类型应为 %v，实际为 %s", path, expected, jsonTypeName(value))
+	}
+	if enum, ok := schema["enum"].([]interface{}); ok && !containsJSONValue(enum, value) {
+		return fmt.Errorf("%s: 值 %v 不在 enum %v 中", path, value, enum)
+	}
+	if constant, ok := schema["const"]; ok && !reflect.DeepEqual(constant, value) {
+		return fmt.Errorf("%s: 值应为 %v", path, constant)
+	}
+
+	if err := validateJSONSchemaCombinators(value, schema, root, path); err != nil {
+		return err
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return validateJSONObject(v, schema, root, path)
+	case []interface{}:
+		return validateJSONArray(v, schema, root, path)
+	case string:
+		length := utf8.RuneCountInString(v)
+		if limit, ok := jsonSchemaNumber(schema, "minLength"); ok && float64(length) < limit {
+			return fmt.Errorf("%s: 长度 %d 小于 minLength %v", path, length, limit)
+		}
+		if limit, ok := jsonSchemaNumber(schema, "maxLength"); ok && float64(length) > limit {
+			return fmt.Errorf("%s: 长度 %d 大于 maxLength %v", path, length, limit)
+		}
+	case float64:
+		if limit, ok := jsonSchemaNumber(schema, "minimum"); ok && v < limit {
+			return fmt.Errorf("%s: %v 小于 minimum %v", path, v, limit)
+		}
+		if limit, ok := jsonSchemaNumber(schema, "maximum"); ok && v > limit {
+			return fmt.Errorf("%s: %v 大于 maximum %v", path, v, limit)
+		}
+	}
+	return nil
+}
+
+// validateJSONSchemaCombinators 校验 anyOf / oneOf / allOf
+func validateJSONSchemaCombinators(value interface{}, schema, root map[string]interface{}, path string) error {
+	if all, ok := schema["allOf"].([]interface{}); ok {
+		for _, item := range all {
+			if sub, ok := item.(map[string]interface{}); ok {
+				if err := validateJSONSchema(value, sub, root, path); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	for _, keyword := range []string{"anyOf", "oneOf"} {
+		options, ok := schema[keyword].([]interface{})
+		if !ok {
+			continue
+		}
+		matched := 0
+		for _, item := range options {
+			if sub, ok := item.(map[string]interface{}); ok && validateJSONSchema(value, sub, root, path) == nil {
+				matched++
+			}
+		}
+		if matched == 0 {
+			return fmt.Errorf("%s: 不满足 %s 中的任一 schema", path, keyword)
+		}
+		if keyword == "oneOf" && matched > 1 {
+			return fmt.Errorf("%s: 同时满足 oneOf 中的 %d 个 schema", path, matched)
+		}
+	}
+	return nil
+}
+
+// validateJSONObject 校验对象的 required、properties 与 additionalProperties
+func validateJSONObject(object map[string]interface{}, schema, root map[string]interface{}, path string) error {
+	if required, ok := schema["required"].([]interface{}); ok {
+		for _, name := range required {
+			if key, ok := name.(string); ok {
+				if _, exists := object[key]; !exists {
+					return fmt.Errorf("%s: 缺少必填字段 %s", path, key)
+				}
+			}
+		}
+	}
+
+	properties, _ := schema["properties"].(map[string]interface{})
+	for key, item := range object {
+		childPath := path + "." + key
+		if propSchema, ok := properties[key].(map[string]interface{}); ok {
+			if err := validateJSONSchema(item, propSchema, root, childPath); err != nil {
+				return err
+			}
+			continue
+		}
+		switch additional := schema["additionalProperties"].(type) {
+		case bool:
+			if !additional {
+				return fmt.Errorf("%s: 不允许的字段", childPath)
+			}
+		case map[string]interface{}:
+			if err := validateJSONSchema(item, additional, root, childPath); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// validateJSONArray 校验数组元素与长度
+func validateJSONArray(array []interface{}, schema, root map[string]interface{}, path string) error {
+	if limit, ok := jsonSchemaNumber(schema, "minItems"); ok && float64(len(array)) < limit {
+		return fmt.Errorf("%s: 元素数 %d 小于 minItems %v", path, len(array), limit)
+	}
+	if limit, ok := jsonSchemaNumber(schema, "maxItems"); ok && float64(len(array)) > limit {
+		return fmt.Errorf("%s: 元素数 %d 大于 maxItems %v", path, len(array), limit)
+	}
+	if items, ok := schema["items"].(map[string]interface{}); ok {
+		for i, item := range array {
+			if err := validateJSONSchema(item, items, root, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// resolveJSONSchemaRef 解析 #/$defs/xxx 与 #/definitions/xxx 形式的本地引用
+func resolveJSONSchemaRef(root map[string]interface{}, ref string) (map[string]interface{}, error) {
+	if ref == "#" {
+		return root, nil
+	}
+	pointer, ok := strings.CutPrefix(ref, "#/")
+	if !ok {
+		return nil, fmt.Errorf("不支持的 $ref: %s", ref)
+	}
+
+	var current interface{} = root
+	for _, segment := range strings.Split(pointer, "/") {
+		segment = strings.ReplaceAll(strings.ReplaceAll(segment, "~1", "/"), "~0", "~")
+		object, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("无法解析 $ref: %s", ref)
+		}
+		current = object[segment]
+	}
+	resolved, ok := current.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("无法解析 $ref: %s", ref)
+	}
+	return resolved, nil
+}
+
+// matchesJSONSchemaType 判断值是否符合 type（字符串或字符串数组）
+func matchesJSONSchemaType(value interface{}, expected interface{}) bool {
+	switch t := expected.(type) {
+	case string:
+		return matchesJSONType(value, t)
+	case []interface{}:
+		for _, item := range t {
+			if name, ok := item.(string); ok && matchesJSONType(value, name) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func matchesJSONType(value interface{}, name string) bool {
+	switch name {
+	case "integer":
+		number, ok := value.(float64)
+		return ok && number == math.Trunc(number)
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	default:
+		return jsonTypeName(value) == name
+	}
+}
+
+// jsonTypeName 返回 JSON 值的类型名
+func jsonTypeName(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+func containsJSONValue(values []interface{}, value interface{}) bool {
+	for _, item := range values {
+		if reflect.DeepEqual(item, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func jsonSchemaNumber(schema map[string]interface{}, key string) (float64, bool) {
+	number, ok := schema[key].(float64)
+	return number, ok
+}
@@ -0,0 +1,182 @@
+package converter
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ConvertClaudeToOllama 将 Claude Messages API 请求转换为 Ollama /api/chat 请求
+// 复用 Claude→OpenAI 的消息转换，再转换为 Ollama 格式；Ollama 不支持文档输入，PDF 在进程内提取文本
+func ConvertClaudeToOllama(req *ClaudeRequest) (*OllamaChatRequest, error) {
+	openaiReq, err := ConvertClaudeToOpenAIWithOptions(req, ClaudeToOpenAIOptions{PDFTextExtraction: true})
+	if err != nil {
+		return nil, err
+	}
+	return ConvertOpenAIToOllama(openaiReq)
+}
+
+// ConvertOpenAIToOllama 将 OpenAI Chat Completions API 请求转换为 Ollama /api/chat 请求
+func ConvertOpenAIToOllama(req *OpenAIRequest) (*OllamaChatRequest, error) {
+	if err := ValidateNonNil(req, "OpenAI请求"); err != nil {
+		return nil, NewConversionError("request", "验证失败", err)
+	}
+
+	ollamaReq := &OllamaChatRequest{
+		Model:  req.Model,
+		Stream: req.Stream,
+	}
+
+	// Ollama 原生支持 format："json" 或 JSON Schema 对象
+	if format := req.ResponseFormat; format != nil {
+		switch {
+		case format.Type == "json_schema" && format.JSONSchema != nil && format.JSONSchema.Schema != nil:
+			ollamaReq.Format = format.JSONSchema.Schema
+		case format.Type == "json_object" || format.Type == "json_schema":
+			ollamaReq.Format = "json"
+		}
+	}
+
+	maxTokens := req.MaxCompletionTokens
+	if maxTokens == 0 {
+		maxTokens = req.MaxTokens
+	}
+	if maxTokens > 0 || req.Temperature != nil || req.TopP != nil || len(req.Stop) > 0 {
+		ollamaReq.Options = &OllamaOptions{
+			NumPredict:  maxTokens,
+			Temperature: req.Temperature,
+			TopP:        req.TopP,
+			Stop:        req.Stop,
+		}
+	}
+
+	// tool 消息只携带 tool_call_id，Ollama 需要函数名，先建立映射
+	toolNames := make(map[string]string)
+	for _, msg := range req.Messages {
+		for _, toolCall := range msg.ToolCalls {
+			toolNames[toolCall.ID] = toolCall.Function.Name
+		}
+	}
+
+	messages := make([]OllamaMessage, 0, len(req.Messages))
+	for _, msg := range req.Messages {
+		switch msg.Role {
+		case "system", "developer":
+			messages = append(messages, OllamaMessage{Role: "system", Content: ExtractTextFromContent(msg.Content)})
+
+		case "user":
+			text, images, err := ollamaContentFromOpenAI(msg.Content)
+			if err != nil {
+				return nil, NewConversionError("request", "转换消息失败", err)
+			}
+			messages = append(messages, OllamaMessage{Role: "user", Content: text, Images: images})
+
+		case "assistant":
+			message := OllamaMessage{Role: "assistant", Content: ExtractTextFromContent(msg.Content)}
+			for _, toolCall := range msg.ToolCalls {
+				args := make(map[string]interface{})
+				if toolCall.Function.Arguments != "" {
+					if err := json.Unmarshal([]byte(toolCall.Function.Arguments), &args); err != nil {
+						return nil, NewConversionError("request", "解析 tool_calls arguments 失败", err)
+					}
+				}
+				message.ToolCalls = append(message.ToolCalls, OllamaToolCall{Function: OllamaFunctionCall{
+					Name:      toolCall.Function.Name,
+					Arguments: args,
+				}})
+			}
+			messages = append(messages, message)
+
+		case "tool":
+			name, ok := toolNames[msg.ToolCallID]
+			if !ok {
+				return nil, NewConversionError("request", "转换消息失败",
+					fmt.Errorf("tool 消息引用了未知的 tool_call_id: %s", msg.ToolCallID))
+			}
+			messages = append(messages, OllamaMessage{
+				Role:     "tool",
+				Content:  ExtractTextFromContent(msg.Content),
+				ToolName: name,
+			})
+
+		default:
+			return nil, NewConversionError("request", "转换消息失败", fmt.Errorf("不支持的角色: %s", msg.Role))
+		}
+	}
+	ollamaReq.Messages = messages
+
+	// Ollama 不支持 tool_choice：none 时不发送工具，指定函数时只发送该函数
+	tools := req.Tools
+	switch choice := req.ToolChoice.(type) {
+	case string:
+		if choice == "none" {
+			tools = nil
+		}
+	case map[string]interface{}:
+		if function, ok := choice["function"].(map[string]interface{}); ok {
+			if name, ok := function["name"].(string); ok && name != "" {
+				tools = filterOpenAITools(tools, name)
+			}
+		}
+	}
+	ollamaReq.Tools = tools
+
+	return ollamaReq, nil
+}
+
+// ollamaContentFromOpenAI 转换 OpenAI 用户消息内容（字符串或内容块数组）为文本与 base64 图片
+// Ollama 只接受内联图片，远程图片 URL 无法转换
+func ollamaContentFromOpenAI(content interface{}) (string, []string, error) {
+	var blocks []OpenAIContentBlock
+	switch v := content.(type) {
+	case nil:
+		return "", nil, nil
+	case string:
+		return v, nil, nil
+	case []OpenAIContentBlock:
+		blocks = v
+	case []interface{}:
+		raw, err := json.Marshal(v)
+		if err != nil {
+			return "", nil, err
+		}
+		if err := json.Unmarshal(raw, &blocks); err != nil {
+			return "", nil, fmt.Errorf("解析内容块失败: %w", err)
+		}
+	default:
+		return "", nil, fmt.Errorf("不支持的 content 类型: %T", content)
+	}
+
+	var text strings.Builder
+	var images []string
+	for _, block := range blocks {
+		switch block.Type {
+		case ContentTypeText:
+			if block.Text != nil {
+				text.WriteString(*block.Text)
+			}
+		case "image_url":
+			if block.ImageURL == nil || block.ImageURL.URL == "" {
+				return "", nil, fmt.Errorf("image_url 缺少 url")
+			}
+			_, data, ok := parseDataURI(block.ImageURL.URL)
+			if !ok {
+				return "", nil, fmt.Errorf("Ollama 只支持 base64 data URI 图片: %s", block.ImageURL.URL)
+			}
+			images = append(images, data)
+		case "file":
+			return "", nil, fmt.Errorf("Ollama 不支持文件内容块")
+		}
+	}
+	return text.String(), images, nil
+}
+
+// filterOpenAITools 只保留指定名称的工具
+func filterOpenAITools(tools []OpenAITool, name string) []OpenAITool {
+	for _, tool := range tools {
+		if tool.Function.Name == name {
+			return []OpenAITool{tool}
+		}
+	}
+	return tools
+}
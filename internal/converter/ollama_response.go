@@ -0,0 +1,112 @@
+package converter
+
+import "time"
+
+// ConvertOllamaDoneReasonToFinishReason 转换 Ollama done_reason 为 OpenAI finish_reason
+func ConvertOllamaDoneReasonToFinishReason(doneReason string, hasToolCall bool) string {
+	switch {
+	case hasToolCall:
+		return "tool_calls"
+	case doneReason == "length":
+		return "length"
+	default:
+		return "stop"
+	}
+}
+
+// ConvertOllamaToClaude 将 Ollama /api/chat 非流式响应转换为 Claude Messages API 响应
+func ConvertOllamaToClaude(resp *OllamaChatResponse, model string) (*ClaudeResponse, error) {
+	if err := ValidateNonNil(resp, "Ollama响应"); err != nil {
+		return nil, NewConversionError("response", "验证失败", err)
+	}
+
+	var content []ClaudeContentBlock
+	if resp.Message.Content != "" || len(resp.Message.ToolCalls) == 0 {
+		content = append(content, ClaudeContentBlock{Type: ContentTypeText, Text: StringPtr(resp.Message.Content)})
+	}
+	for _, toolCall := range resp.Message.ToolCalls {
+		input := toolCall.Function.Arguments
+		if input == nil {
+			input = make(map[string]interface{})
+		}
+		content = append(content, ClaudeContentBlock{
+			Type:  ContentTypeToolUse,
+			ID:    StringPtr("toolu_" + randomHexID()),
+			Name:  StringPtr(toolCall.Function.Name),
+			Input: input,
+		})
+	}
+
+	finishReason := ConvertOllamaDoneReasonToFinishReason(resp.DoneReason, len(resp.Message.ToolCalls) > 0)
+	return &ClaudeResponse{
+		ID:         "msg_" + randomHexID(),
+		Type:       ClaudeTypeMessage,
+		Role:       ClaudeRoleAssistant,
+		Content:    content,
+		Model:      model,
+		StopReason: ConvertFinishReasonToStopReason(finishReason),
+		Usage: ClaudeUsage{
+			InputTokens:  resp.PromptEvalCount,
+			OutputTokens: resp.EvalCount,
+		},
+	}, nil
+}
+
+// ConvertOllamaToOpenAI 将 Ollama /api/chat 非流式响应转换为 OpenAI Chat Completions API 响应
+func ConvertOllamaToOpenAI(resp *OllamaChatResponse, model string) (*OpenAIResponse, error) {
+	if err := ValidateNonNil(resp, "Ollama响应"); err != nil {
+		return nil, NewConversionError("response", "验证失败", err)
+	}
+
+	toolCalls, err := ollamaToolCallsToOpenAI(resp.Message.ToolCalls)
+	if err != nil {
+		return nil, NewConversionError("response", "序列化函数参数失败", err)
+	}
+
+	message := OpenAIMessage{Role: ClaudeRoleAssistant, ToolCalls: toolCalls}
+	if resp.Message.Content != "" || len(toolCalls) == 0 {
+		message.Content = resp.Message.Content
+	}
+
+	return &OpenAIResponse{
+		ID:      ConvertIDClaudeToOpenAI("msg_" + randomHexID()),
+		Object:  OpenAIObjectChatCompletion,
+		Created: time.Now().Unix(),
+		Model:   model,
+		Choices: []OpenAIChoice{{
+			Index:        0,
+			Message:      message,
+			FinishReason: ConvertOllamaDoneReasonToFinishReason(resp.DoneReason, len(toolCalls) > 0),
+		}},
+		Usage: ollamaOpenAIUsage(resp),
+	}, nil
+}
+
+// ollamaToolCallsToOpenAI 转换工具调用；Ollama 不返回调用 ID，随机生成
+func ollamaToolCallsToOpenAI(toolCalls []OllamaToolCall) ([]OpenAIToolCall, error) {
+	var result []OpenAIToolCall
+	for _, toolCall := range toolCalls {
+		args, err := marshalGeminiArgs(toolCall.Function.Arguments)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, OpenAIToolCall{
+			ID:   "call_" + randomHexID(),
+			Type: OpenAIToolTypeFunction,
+			Function: OpenAIFunctionCall{
+				Name:      toolCall.Function.Name,
+				Arguments: args,
+			},
+		})
+	}
+	return result, nil
+}
+
+// ollamaOpenAIUsage 转换 token 用量
+func ollamaOpenAIUsage(resp *OllamaChatResponse) OpenAIUsage {
+	return OpenAIUsage{
+		PromptTokens:     resp.PromptEvalCount,
+		CompletionTokens: resp.EvalCount,
+		TotalTokens:      resp.PromptEvalCount + resp.EvalCount,
+	}
+}
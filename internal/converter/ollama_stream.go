@@ -0,0 +1,336 @@
+package converter
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// ollamaStreamHandler 处理 Ollama NDJSON 流的转换器
+type ollamaStreamHandler interface {
+	processOllamaChunk(chunk *OllamaChatResponse) ([]string, error)
+	finish() ([]string, error)
+	errorEvent(message string) (string, error)
+}
+
+// convertOllamaStream 逐行读取 Ollama NDJSON 流（每行一个 JSON 对象）并交给转换器处理
+func convertOllamaStream(ctx context.Context, ollamaStream io.Reader, handler ollamaStreamHandler) io.Reader {
+	pipeReader, pipeWriter := io.Pipe()
+
+	go func() {
+		defer pipeWriter.Close()
+
+		reader := bufio.NewReader(ollamaStream)
+		for {
+			select {
+			case <-ctx.Done():
+				pipeWriter.CloseWithError(ctx.Err())
+				return
+			default:
+			}
+
+			line, err := reader.ReadBytes('\n')
+			if err != nil && err != io.EOF {
+				pipeWriter.CloseWithError(err)
+				return
+			}
+			atEOF := err == io.EOF
+
+			if line = bytes.TrimSpace(line); len(line) > 0 {
+				var chunk OllamaChatResponse
+				if jsonErr := json.Unmarshal(line, &chunk); jsonErr == nil {
+					if chunk.Error != "" {
+						event, err := handler.errorEvent(chunk.Error)
+						if err == nil {
+							pipeWriter.Write([]byte(event))
+						}
+						return
+					}
+
+					events, err := handler.processOllamaChunk(&chunk)
+					if err != nil {
+						pipeWriter.CloseWithError(err)
+						return
+					}
+					if !writeStreamEvents(pipeWriter, events) {
+						return
+					}
+				}
+			}
+
+			if atEOF {
+				events, err := handler.finish()
+				if err != nil {
+					pipeWriter.CloseWithError(err)
+					return
+				}
+				writeStreamEvents(pipeWriter, events)
+				return
+			}
+		}
+	}()
+
+	return pipeReader
+}
+
+// OllamaClaudeStreamConverter 将 Ollama /api/chat NDJSON 流转换为 Claude 流式事件
+// 复用 StreamConverter 的事件生成与块索引管理
+type OllamaClaudeStreamConverter struct {
+	*StreamConverter
+	stopReason string
+}
+
+// NewOllamaClaudeStreamConverter 创建 Ollama→Claude 流式转换器
+func NewOllamaClaudeStreamConverter(model string) *OllamaClaudeStreamConverter {
+	base := NewStreamConverter()
+	base.model = model
+	base.messageID = "msg_" + randomHexID()
+	return &OllamaClaudeStreamConverter{StreamConverter: base}
+}
+
+// ConvertOllamaStreamToClaude 转换 Ollama 流式响应为 Claude 流式响应
+func ConvertOllamaStreamToClaude(ctx context.Context, ollamaStream io.Reader, model string) (io.Reader, error) {
+	return convertOllamaStream(ctx, ollamaStream, NewOllamaClaudeStreamConverter(model)), nil
+}
+
+// processOllamaChunk 处理单行 Ollama 响应，返回 Claude 事件列表
+func (c *OllamaClaudeStreamConverter) processOllamaChunk(chunk *OllamaChatResponse) ([]string, error) {
+	events := []string{}
+
+	if !c.messageStarted {
+		event, err := c.emitMessageStart()
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+		c.messageStarted = true
+	}
+
+	if chunk.Message.Content != "" {
+		if !c.blockStarted || c.currentBlockType != ContentTypeText {
+			if c.blockStarted {
+				event, err := c.emitContentBlockStop()
+				if err != nil {
+					return nil, err
+				}
+				events = append(events, event)
+			}
+			c.currentIndex++
+			c.currentBlockType = ContentTypeText
+			event, err := c.emitContentBlockStart(ContentTypeText)
+			if err != nil {
+				return nil, err
+			}
+			events = append(events, event)
+			c.blockStarted = true
+		}
+
+		event, err := c.emitTextDelta(chunk.Message.Content)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+
+	for _, toolCall := range chunk.Message.ToolCalls {
+		// Ollama 一次性返回完整的函数调用：start + 完整参数 delta + stop
+		if c.blockStarted {
+			event, err := c.emitContentBlockStop()
+			if err != nil {
+				return nil, err
+			}
+			events = append(events, event)
+			c.blockStarted = false
+		}
+
+		args, err := marshalGeminiArgs(toolCall.Function.Arguments)
+		if err != nil {
+			return nil, err
+		}
+
+		c.currentIndex++
+		c.currentBlockType = ContentTypeToolUse
+		name := toolCall.Function.Name
+		for _, emit := range []func() (string, error){
+			func() (string, error) { return c.emitToolUseStart("toolu_"+randomHexID(), name) },
+			func() (string, error) { return c.emitToolUseDelta(args) },
+			c.emitContentBlockStop,
+		} {
+			event, err := emit()
+			if err != nil {
+				return nil, err
+			}
+			events = append(events, event)
+		}
+		c.stopReason = "tool_use"
+	}
+
+	if chunk.Done {
+		c.usage.InputTokens = chunk.PromptEvalCount
+		c.usage.OutputTokens = chunk.EvalCount
+		if c.stopReason != "tool_use" {
+			c.stopReason = ConvertFinishReasonToStopReason(ConvertOllamaDoneReasonToFinishReason(chunk.DoneReason, false))
+		}
+	}
+
+	return events, nil
+}
+
+// finish 流结束：关闭当前块并发送 message_delta 与 message_stop
+func (c *OllamaClaudeStreamConverter) finish() ([]string, error) {
+	events := []string{}
+
+	if !c.messageStarted {
+		event, err := c.emitMessageStart()
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+		c.messageStarted = true
+	}
+
+	if c.blockStarted {
+		event, err := c.emitContentBlockStop()
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+		c.blockStarted = false
+	}
+
+	stopReason := c.stopReason
+	if stopReason == "" {
+		stopReason = "end_turn"
+	}
+	event, err := c.emitMessageDelta(stopReason)
+	if err != nil {
+		return nil, err
+	}
+	events = append(events, event)
+
+	event, err = c.emitMessageStop()
+	if err != nil {
+		return nil, err
+	}
+	return append(events, event), nil
+}
+
+// errorEvent 生成 Claude error 事件
+func (c *OllamaClaudeStreamConverter) errorEvent(message string) (string, error) {
+	return FormatSSEEvent("error", map[string]interface{}{
+		"type": "error",
+		"error": map[string]string{
+			"type":    "api_error",
+			"message": message,
+		},
+	})
+}
+
+// OllamaOpenAIStreamConverter 将 Ollama /api/chat NDJSON 流转换为 OpenAI 流式响应
+type OllamaOpenAIStreamConverter struct {
+	id            string
+	model         string
+	created       int64
+	roleSent      bool
+	toolCallIndex int
+	finishReason  string
+	usage         *OpenAIUsage
+}
+
+// NewOllamaOpenAIStreamConverter 创建 Ollama→OpenAI 流式转换器
+func NewOllamaOpenAIStreamConverter(model string) *OllamaOpenAIStreamConverter {
+	return &OllamaOpenAIStreamConverter{
+		id:      ConvertIDClaudeToOpenAI("msg_" + randomHexID()),
+		model:   model,
+		created: time.Now().Unix(),
+	}
+}
+
+// ConvertOllamaStreamToOpenAI 转换 Ollama 流式响应为 OpenAI 流式响应（以 data: [DONE] 结尾）
+func ConvertOllamaStreamToOpenAI(ctx context.Context, ollamaStream io.Reader, model string) (io.Reader, error) {
+	return convertOllamaStream(ctx, ollamaStream, NewOllamaOpenAIStreamConverter(model)), nil
+}
+
+// processOllamaChunk 处理单行 Ollama 响应，返回 OpenAI SSE 数据行
+func (c *OllamaOpenAIStreamConverter) processOllamaChunk(chunk *OllamaChatResponse) ([]string, error) {
+	delta := OpenAIStreamDelta{Content: chunk.Message.Content}
+	if !c.roleSent {
+		delta.Role = ClaudeRoleAssistant
+		c.roleSent = true
+	}
+
+	for _, toolCall := range chunk.Message.ToolCalls {
+		args, err := marshalGeminiArgs(toolCall.Function.Arguments)
+		if err != nil {
+			return nil, err
+		}
+		delta.ToolCalls = append(delta.ToolCalls, OpenAIStreamToolCall{
+			Index: c.toolCallIndex,
+			ID:    "call_" + randomHexID(),
+			Type:  OpenAIToolTypeFunction,
+			Function: &OpenAIStreamFunctionCall{
+				Name:      toolCall.Function.Name,
+				Arguments: args,
+			},
+		})
+		c.toolCallIndex++
+	}
+
+	if chunk.Done {
+		usage := ollamaOpenAIUsage(chunk)
+		c.usage = &usage
+		c.finishReason = ConvertOllamaDoneReasonToFinishReason(chunk.DoneReason, c.toolCallIndex > 0)
+	}
+
+	if delta.Role == "" && delta.Content == "" && len(delta.ToolCalls) == 0 {
+		return nil, nil
+	}
+	event, err := formatOpenAIStreamData(c.chunk(delta, nil, nil))
+	if err != nil {
+		return nil, err
+	}
+	return []string{event}, nil
+}
+
+// finish 流结束：发送带 finish_reason 与 usage 的最后一个 chunk 和 [DONE]
+func (c *OllamaOpenAIStreamConverter) finish() ([]string, error) {
+	finishReason := c.finishReason
+	if finishReason == "" {
+		finishReason = ConvertOllamaDoneReasonToFinishReason("", c.toolCallIndex > 0)
+	}
+
+	event, err := formatOpenAIStreamData(c.chunk(OpenAIStreamDelta{}, &finishReason, c.usage))
+	if err != nil {
+		return nil, err
+	}
+	return []string{event, "data: [DONE]\n\n"}, nil
+}
+
+// errorEvent 生成 OpenAI 格式的错误数据行
+func (c *OllamaOpenAIStreamConverter) errorEvent(message string) (string, error) {
+	return formatOpenAIStreamData(map[string]interface{}{
+		"error": map[string]interface{}{
+			"message": message,
+			"type":    "upstream_error",
+		},
+	})
+}
+
+// chunk 构建 OpenAI 流式响应块
+func (c *OllamaOpenAIStreamConverter) chunk(delta OpenAIStreamDelta, finishReason *string, usage *OpenAIUsage) OpenAIStreamChunk {
+	return OpenAIStreamChunk{
+		ID:      c.id,
+		Object:  OpenAIObjectChatCompletionChunk,
+		Created: c.created,
+		Model:   c.model,
+		Choices: []OpenAIStreamChoice{{
+			Index:        0,
+			Delta:        delta,
+			FinishReason: finishReason,
+		}},
+		Usage: usage,
+	}
+}
@@ -0,0 +1,296 @@
+package converter
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// 测试 Claude→Ollama 请求转换：system、图片、工具调用与结果、生成参数
+func TestConvertClaudeToOllama(t *testing.T) {
+	temperature := 0.2
+	req := &ClaudeRequest{
+		Model:       "llama3.2",
+		System:      NewSystemPrompt("Be brief."),
+		MaxTokens:   256,
+		Temperature: &temperature,
+		Messages: []ClaudeMessage{
+			{Role: "user", Content: []ClaudeContentBlock{
+				{Type: "text", Text: StringPtr("What is this?")},
+				{Type: "image", Source: &ClaudeImageSource{Type: "base64", MediaType: "image/png", Data: "iVBORw0KGgo="}},
+			}},
+			{Role: "assistant", Content: []ClaudeContentBlock{
+				{Type: "tool_use", ID: StringPtr("toolu_1"), Name: StringPtr("lookup"), Input: map[string]interface{}{"q": "cat"}},
+			}},
+			{Role: "user", Content: []ClaudeContentBlock{
+				{Type: "tool_result", ToolUseID: StringPtr("toolu_1"), Content: NewToolResultText("a cat")},
+			}},
+		},
+		Tools: []ClaudeTool{{Name: "lookup", Description: "Search", InputSchema: map[string]interface{}{"type": "object"}}},
+	}
+
+	ollamaReq, err := ConvertClaudeToOllama(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if ollamaReq.Model != "llama3.2" || ollamaReq.Stream {
+		t.Fatalf("unexpected request: %+v", ollamaReq)
+	}
+	if ollamaReq.Options == nil || ollamaReq.Options.NumPredict != 256 || *ollamaReq.Options.Temperature != 0.2 {
+		t.Fatalf("unexpected options: %+v", ollamaReq.Options)
+	}
+	if len(ollamaReq.Messages) != 4 {
+		t.Fatalf("expected 4 messages, got %+v", ollamaReq.Messages)
+	}
+	if ollamaReq.Messages[0].Role != "system" || ollamaReq.Messages[0].Content != "Be brief." {
+		t.Fatalf("unexpected system message: %+v", ollamaReq.Messages[0])
+	}
+	user := ollamaReq.Messages[1]
+	if user.Content != "What is this?" || !reflect.DeepEqual(user.Images, []string{"iVBORw0KGgo="}) {
+		t.Fatalf("unexpected user message: %+v", user)
+	}
+	assistant := ollamaReq.Messages[2]
+	if len(assistant.ToolCalls) != 1 || assistant.ToolCalls[0].Function.Name != "lookup" || assistant.ToolCalls[0].Function.Arguments["q"] != "cat" {
+		t.Fatalf("unexpected assistant message: %+v", assistant)
+	}
+	tool := ollamaReq.Messages[3]
+	if tool.Role != "tool" || tool.ToolName != "lookup" || tool.Content != "a cat" {
+		t.Fatalf("unexpected tool message: %+v", tool)
+	}
+	if len(ollamaReq.Tools) != 1 || ollamaReq.Tools[0].Function.Name != "lookup" {
+		t.Fatalf("unexpected tools: %+v", ollamaReq.Tools)
+	}
+
+	// stream 必须显式发送 false，否则 Ollama 默认流式
+	body, _ := json.Marshal(ollamaReq)
+	if !strings.Contains(string(body), `"stream":false`) {
+		t.Fatalf("expected explicit stream:false, got %s", body)
+	}
+}
+
+// 测试 OpenAI→Ollama 请求转换：JSON 解码的内容块、tool_choice 与无法转换的内容
+func TestConvertOpenAIToOllama(t *testing.T) {
+	raw := `{
+		"model": "qwen2.5",
+		"stream": true,
+		"messages": [
+			{"role": "developer", "content": "Use tools."},
+			{"role": "user", "content": [
+				{"type": "text", "text": "Describe"},
+				{"type": "image_url", "image_url": {"url": "data:image/jpeg;base64,/9j/4AAQ"}}
+			]}
+		],
+		"tools": [
+			{"type": "function", "function": {"name": "lookup", "parameters": {"type": "object"}}},
+			{"type": "function", "function": {"name": "now"}}
+		],
+		"tool_choice": {"type": "function", "function": {"name": "now"}}
+	}`
+	var req OpenAIRequest
+	if err := json.Unmarshal([]byte(raw), &req); err != nil {
+		t.Fatalf("failed to decode request: %v", err)
+	}
+
+	ollamaReq, err := ConvertOpenAIToOllama(&req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !ollamaReq.Stream || ollamaReq.Options != nil {
+		t.Fatalf("unexpected request: %+v", ollamaReq)
+	}
+	if ollamaReq.Messages[0].Role != "system" || ollamaReq.Messages[1].Images[0] != "/9j/4AAQ" {
+		t.Fatalf("unexpected messages: %+v", ollamaReq.Messages)
+	}
+	if len(ollamaReq.Tools) != 1 || ollamaReq.Tools[0].Function.Name != "now" {
+		t.Fatalf("expected only the forced tool, got %+v", ollamaReq.Tools)
+	}
+
+	req.ToolChoice = "none"
+	if ollamaReq, _ = ConvertOpenAIToOllama(&req); len(ollamaReq.Tools) != 0 {
+		t.Fatalf("expected tools dropped for tool_choice none, got %+v", ollamaReq.Tools)
+	}
+
+	errorCases := map[string]OpenAIMessage{
+		"url image":    {Role: "user", Content: []interface{}{map[string]interface{}{"type": "image_url", "image_url": map[string]interface{}{"url": "https://example.com/a.png"}}}},
+		"unknown tool": {Role: "tool", ToolCallID: "call_x", Content: "result"},
+		"unknown role": {Role: "function", Content: "hi"},
+	}
+	for name, msg := range errorCases {
+		if _, err := ConvertOpenAIToOllama(&OpenAIRequest{Model: "m", Messages: []OpenAIMessage{msg}}); err == nil {
+			t.Fatalf("%s: expected conversion error", name)
+		}
+	}
+}
+
+// 测试 Ollama 非流式响应转换为 Claude 与 OpenAI 响应
+func TestConvertOllamaResponse(t *testing.T) {
+	resp := &OllamaChatResponse{
+		Model: "llama3.2",
+		Message: OllamaMessage{
+			Role:    "assistant",
+			Content: "Checking.",
+			ToolCalls: []OllamaToolCall{
+				{Function: OllamaFunctionCall{Name: "lookup", Arguments: map[string]interface{}{"q": "cat"}}},
+			},
+		},
+		Done:            true,
+		DoneReason:      "stop",
+		PromptEvalCount: 12,
+		EvalCount:       6,
+	}
+
+	claudeResp, err := ConvertOllamaToClaude(resp, "local-model")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if claudeResp.Model != "local-model" || claudeResp.StopReason != "tool_use" || len(claudeResp.Content) != 2 {
+		t.Fatalf("unexpected Claude response: %+v", claudeResp)
+	}
+	toolUse := claudeResp.Content[1]
+	if !strings.HasPrefix(*toolUse.ID, "toolu_") || *toolUse.Name != "lookup" || toolUse.Input["q"] != "cat" {
+		t.Fatalf("unexpected tool_use block: %+v", toolUse)
+	}
+	if claudeResp.Usage.InputTokens != 12 || claudeResp.Usage.OutputTokens != 6 {
+		t.Fatalf("unexpected usage: %+v", claudeResp.Usage)
+	}
+
+	openaiResp, err := ConvertOllamaToOpenAI(resp, "local-model")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	choice := openaiResp.Choices[0]
+	if choice.FinishReason != "tool_calls" || len(choice.Message.ToolCalls) != 1 || choice.Message.ToolCalls[0].Function.Arguments != `{"q":"cat"}` {
+		t.Fatalf("unexpected OpenAI choice: %+v", choice)
+	}
+	if !strings.HasPrefix(openaiResp.ID, "chatcmpl-") || openaiResp.Usage.TotalTokens != 18 {
+		t.Fatalf("unexpected OpenAI response: %+v", openaiResp)
+	}
+
+	truncated := &OllamaChatResponse{Message: OllamaMessage{Content: "Once upon"}, Done: true, DoneReason: "length"}
+	claudeResp, _ = ConvertOllamaToClaude(truncated, "m")
+	openaiResp, _ = ConvertOllamaToOpenAI(truncated, "m")
+	if claudeResp.StopReason != "max_tokens" || openaiResp.Choices[0].FinishReason != "length" {
+		t.Fatalf("expected length stop, got %s / %s", claudeResp.StopReason, openaiResp.Choices[0].FinishReason)
+	}
+}
+
+// ollamaTestStream 模拟 Ollama NDJSON 流：两段文本、一次工具调用、带用量的结束行（最后一行没有换行）
+const ollamaTestStream = `{"model":"llama3.2","message":{"role":"assistant","content":"Hel"},"done":false}
+{"model":"llama3.2","message":{"role":"assistant","content":"lo"},"done":false}
+
+{"model":"llama3.2","message":{"role":"assistant","content":"","tool_calls":[{"function":{"name":"lookup","arguments":{"q":"cat"}}}]},"done":false}
+{"model":"llama3.2","message":{"role":"assistant","content":""},"done":true,"done_reason":"stop","prompt_eval_count":7,"eval_count":4}`
+
+// 测试 Ollama NDJSON 流转换为 Claude 事件
+func TestConvertOllamaStreamToClaude(t *testing.T) {
+	reader, err := ConvertOllamaStreamToClaude(context.Background(), strings.NewReader(ollamaTestStream), "local-model")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	output, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("failed to read stream: %v", err)
+	}
+
+	var types []string
+	var stopReason string
+	var inputTokens, outputTokens int
+	for _, line := range strings.Split(string(output), "\n") {
+		data, ok := strings.CutPrefix(line, "data: ")
+		if !ok {
+			continue
+		}
+		var event struct {
+			Type  string `json:"type"`
+			Delta struct {
+				StopReason string `json:"stop_reason"`
+			} `json:"delta"`
+			Usage struct {
+				InputTokens  int `json:"input_tokens"`
+				OutputTokens int `json:"output_tokens"`
+			} `json:"usage"`
+		}
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			t.Fatalf("invalid event data %q: %v", data, err)
+		}
+		types = append(types, event.Type)
+		if event.Type == EventTypeMessageDelta {
+			stopReason = event.Delta.StopReason
+			inputTokens, outputTokens = event.Usage.InputTokens, event.Usage.OutputTokens
+		}
+	}
+
+	expected := []string{
+		"message_start",
+		"content_block_start", "content_block_delta", "content_block_delta", "content_block_stop",
+		"content_block_start", "content_block_delta", "content_block_stop",
+		"message_delta", "message_stop",
+	}
+	if !reflect.DeepEqual(types, expected) {
+		t.Fatalf("unexpected event sequence:\n got: %v\nwant: %v", types, expected)
+	}
+	if stopReason != "tool_use" || inputTokens != 7 || outputTokens != 4 {
+		t.Fatalf("unexpected message_delta: stop_reason=%s usage=%d/%d", stopReason, inputTokens, outputTokens)
+	}
+	if !strings.Contains(string(output), `"partial_json":"{\"q\":\"cat\"}"`) {
+		t.Fatalf("expected complete function args as input_json_delta, got:\n%s", output)
+	}
+}
+
+// 测试 Ollama NDJSON 流转换为 OpenAI chunk，以及流中的错误行
+func TestConvertOllamaStreamToOpenAI(t *testing.T) {
+	reader, err := ConvertOllamaStreamToOpenAI(context.Background(), strings.NewReader(ollamaTestStream), "local-model")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	output, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("failed to read stream: %v", err)
+	}
+
+	var chunks []OpenAIStreamChunk
+	done := false
+	for _, line := range strings.Split(string(output), "\n") {
+		data, ok := strings.CutPrefix(line, "data: ")
+		if !ok {
+			continue
+		}
+		if data == "[DONE]" {
+			done = true
+			continue
+		}
+		var chunk OpenAIStreamChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			t.Fatalf("invalid chunk %q: %v", data, err)
+		}
+		chunks = append(chunks, chunk)
+	}
+
+	if !done || len(chunks) != 4 {
+		t.Fatalf("expected 4 chunks and [DONE], got:\n%s", output)
+	}
+	if chunks[0].Choices[0].Delta.Role != "assistant" || chunks[0].Choices[0].Delta.Content != "Hel" {
+		t.Fatalf("unexpected first chunk: %+v", chunks[0])
+	}
+	toolCalls := chunks[2].Choices[0].Delta.ToolCalls
+	if len(toolCalls) != 1 || !strings.HasPrefix(toolCalls[0].ID, "call_") || toolCalls[0].Function.Arguments != `{"q":"cat"}` {
+		t.Fatalf("unexpected tool call chunk: %+v", chunks[2])
+	}
+	final := chunks[3]
+	if *final.Choices[0].FinishReason != "tool_calls" || final.Usage == nil || final.Usage.TotalTokens != 11 {
+		t.Fatalf("unexpected final chunk: %+v", final)
+	}
+	if final.ID != chunks[0].ID || final.Model != "local-model" {
+		t.Fatalf("expected stable id and model, got %+v", final)
+	}
+
+	reader, _ = ConvertOllamaStreamToOpenAI(context.Background(), strings.NewReader(`{"error":"model not found"}`+"\n"), "m")
+	output, _ = io.ReadAll(reader)
+	if !strings.Contains(string(output), "model not found") || strings.Contains(string(output), "[DONE]") {
+		t.Fatalf("expected error chunk without [DONE], got:\n%s", output)
+	}
+}
@@ -1,12 +1,22 @@
 package converter
 
 import (
-	"encoding/json"
 	"fmt"
 )
 
+// OpenAIToClaudeOptions OpenAI→Claude 响应转换选项
+type OpenAIToClaudeOptions struct {
+	// ToolArguments 记录工具调用参数的修复结果，为 nil 时不记录
+	ToolArguments ToolArgumentRecorder
+}
+
 // ConvertOpenAIToClaude 将 OpenAI Chat Completions API 响应转换为 Claude Messages API 响应
 func ConvertOpenAIToClaude(resp *OpenAIResponse) (*ClaudeResponse, error) {
+	return ConvertOpenAIToClaudeWithOptions(resp, OpenAIToClaudeOptions{})
+}
+
+// ConvertOpenAIToClaudeWithOptions 按转换选项将 OpenAI 响应转换为 Claude 响应
+func ConvertOpenAIToClaudeWithOptions(resp *OpenAIResponse, opts OpenAIToClaudeOptions) (*ClaudeResponse, error) {
 	// 验证输入
 	if err := ValidateNonNil(resp, "OpenAI响应"); err != nil {
 		return nil, NewConversionError("response", "验证失败", err)
@@ -25,17 +35,14 @@ func ConvertOpenAIToClaude(resp *OpenAIResponse) (*ClaudeResponse, error) {
 		Type:  ClaudeTypeMessage,
 		Role:  ClaudeRoleAssistant,
 		Model: resp.Model,
-		Usage: ClaudeUsage{
-			InputTokens:  resp.Usage.PromptTokens,
-			OutputTokens: resp.Usage.CompletionTokens,
-		},
+		Usage: claudeUsageFromOpenAI(resp.Usage),
 	}
 
 	// 转换 stop_reason
 	claudeResp.StopReason = ConvertFinishReasonToStopReason(choice.FinishReason)
 
 	// 转换 content
-	content, err := convertResponseContent(choice.Message)
+	content, err := convertResponseContent(choice.Message, opts.ToolArguments)
 	if err != nil {
 		return nil, NewConversionError("response", "转换内容失败", err)
 	}
@@ -45,9 +52,18 @@ func ConvertOpenAIToClaude(resp *OpenAIResponse) (*ClaudeResponse, error) {
 }
 
 // convertResponseContent 转换响应内容
-func convertResponseContent(msg OpenAIMessage) ([]ClaudeContentBlock, error) {
+func convertResponseContent(msg OpenAIMessage, recorder ToolArgumentRecorder) ([]ClaudeContentBlock, error) {
 	var content []ClaudeContentBlock
 
+	// 处理推理内容，转换为位于最前面的 thinking 块
+	if reasoning := reasoningFromMessage(msg.ReasoningContent, msg.Reasoning); reasoning != "" {
+		content = append(content, ClaudeContentBlock{
+			Type:      ContentTypeThinking,
+			Thinking:  &reasoning,
+			Signature: StringPtr(ThinkingSignature(reasoning)),
+		})
+	}
+
 	// 处理文本内容
 	if msg.Content != nil {
 		textContent := ExtractTextFromContent(msg.Content)
@@ -62,7 +78,7 @@ func convertResponseContent(msg OpenAIMessage) ([]ClaudeContentBlock, error) {
 	// 处理 tool_calls
 	if len(msg.ToolCalls) > 0 {
 		for _, toolCall := range msg.ToolCalls {
-			block, err := convertToolCallToToolUse(toolCall)
+			block, err := convertToolCallToToolUse(toolCall, recorder)
 			if err != nil {
 				return nil, fmt.Errorf("转换 tool_call 失败: %w", err)
 			}
@@ -83,15 +99,11 @@ func convertResponseContent(msg OpenAIMessage) ([]ClaudeContentBlock, error) {
 }
 
 // convertToolCallToToolUse 转换 tool_call 为 tool_use
-func convertToolCallToToolUse(toolCall OpenAIToolCall) (ClaudeContentBlock, error) {
-	// 解析 arguments JSON 字符串为 map
-	var input map[string]interface{}
-	if toolCall.Function.Arguments != "" {
-		if err := json.Unmarshal([]byte(toolCall.Function.Arguments), &input); err != nil {
-			return ClaudeContentBlock{}, fmt.Errorf("解析 arguments 失败: %w", err)
-		}
-	} else {
-		input = make(map[string]interface{})
+func convertToolCallToToolUse(toolCall OpenAIToolCall, recorder ToolArgumentRecorder) (ClaudeContentBlock, error) {
+	// 解析 arguments JSON 字符串为 map，格式不合法时尝试修复
+	input, _, err := DecodeToolArguments(toolCall.Function.Arguments, recorder)
+	if err != nil {
+		return ClaudeContentBlock{}, err
 	}
 
 	return ClaudeContentBlock{
@@ -0,0 +1,411 @@
+package converter
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// maxPDFStreamSize 单个内容流解压后的最大字节数，防止压缩炸弹
+const maxPDFStreamSize = 16 << 20
+
+// maxPDFDecompressedSize 整个文档所有内容流解压后的总字节数上限，防止大量小压缩炸弹累积
+const maxPDFDecompressedSize = 64 << 20
+
+// ErrPDFNoText PDF 中没有可提取的文本（扫描件，或文本使用了无法还原的字体编码）
+var ErrPDFNoText = errors.New("未从 PDF 中提取到文本")
+
+// ErrPDFTooLarge PDF 内容流解压后的总大小超出上限
+var ErrPDFTooLarge = errors.New("PDF 内容流解压后超出大小上限")
+
+// ExtractPDFText 提取 PDF 中的文本（尽力而为，不依赖外部库）
+// 只解析内容流中 BT / ET 之间的文本绘制操作符（Tj、TJ、'、"），支持未压缩与 FlateDecode 压缩的流；
+// 使用 CID 字体等自定义编码的文本无法还原，未提取到文本时返回 ErrPDFNoText
+func ExtractPDFText(data []byte) (string, error) {
+	return extractPDFText(data, maxPDFDecompressedSize)
+}
+
+// extractPDFText 提取 PDF 文本，budget 为所有内容流解压后的总字节数上限
+func extractPDFText(data []byte, budget int) (string, error) {
+	if !bytes.HasPrefix(bytes.TrimLeft(data, "\x00\t\r\n "), []byte("%PDF-")) {
+		return "", fmt.Errorf("不是有效的 PDF 文件")
+	}
+
+	var text strings.Builder
+	rest := data
+	offset := 0
+	for {
+		start := bytes.Index(rest, []byte("stream"))
+		if start < 0 {
+			break
+		}
+		// 跳过 endstream 中的 stream
+		if start >= 3 && string(rest[start-3:start]) == "end" {
+			rest = rest[start+len("stream"):]
+			offset += start + len("stream")
+			continue
+		}
+
+		dict := pdfStreamDict(data[:offset+start])
+		body := rest[start+len("stream"):]
+		body = bytes.TrimPrefix(body, []byte("\r"))
+		body = bytes.TrimPrefix(body, []byte("\n"))
+		end := bytes.Index(body, []byte("endstream"))
+		if end < 0 {
+			break
+		}
+
+		content, ok, err := pdfContentStream(dict, body[:end], &budget)
+		if err != nil {
+			return "", err
+		}
+		if ok {
+			extractPDFContentText(content, &text)
+		}
+
+		consumed := len(rest) - len(body) + end + len("endstream")
+		rest = rest[consumed:]
+		offset += consumed
+	}
+
+	result := normalizePDFText(text.String())
+	if result == "" {
+		return "", ErrPDFNoText
+	}
+	return result, nil
+}
+
+// pdfStreamDict 返回 stream 关键字之前的流字典
+func pdfStreamDict(prefix []byte) string {
+	if i := bytes.LastIndex(prefix, []byte("obj")); i >= 0 {
+		prefix = prefix[i:]
+	}
+	return string(prefix)
+}
+
+// pdfContentStream 按流字典判断是否为可能包含文本的内容流，并解码流数据
+// 解压的数据计入 budget，超出时返回 ErrPDFTooLarge
+func pdfContentStream(dict string, raw []byte, budget *int) ([]byte, bool, error) {
+	compact := strings.ReplaceAll(dict, " ", "")
+	// 图片、字体程序、对象流与交叉引用流不包含文本绘制操作符；表单 XObject 可能包含文本
+	if strings.Contains(compact, "/Length1") || strings.Contains(compact, "/Type/ObjStm") ||
+		strings.Contains(compact, "/Type/XRef") ||
+		(strings.Contains(compact, "/Subtype") && !strings.Contains(compact, "/Subtype/Form")) {
+		return nil, false, nil
+	}
+
+	if !strings.Contains(compact, "/Filter") {
+		return raw, true, nil
+	}
+	if !strings.Contains(compact, "/FlateDecode") || strings.Contains(compact, "/DecodeParms") {
+		return nil, false, nil
+	}
+
+	reader, err := zlib.NewReader(bytes.NewReader(raw))
+	if err != nil {
+		return nil, false, nil
+	}
+	defer reader.Close()
+
+	// 多读一个字节以判断是否超出剩余额度
+	limit := min(maxPDFStreamSize, *budget+1)
+	// 部分 PDF 的流长度不精确，保留截断前已解压的数据
+	content, _ := io.ReadAll(io.LimitReader(reader, int64(limit)))
+	*budget -= len(content)
+	if *budget < 0 {
+		return nil, false, ErrPDFTooLarge
+	}
+	return content, len(content) > 0, nil
+}
+
+// extractPDFContentText 解析内容流中的文本绘制操作符
+func extractPDFContentText(content []byte, text *strings.Builder) {
+	var operands []pdfOperand
+	var array []pdfOperand
+	inArray := false
+	inText := false
+	lastY := ""
+
+	for i := 0; i < len(content); {
+		c := content[i]
+		switch {
+		case isPDFWhitespace(c):
+			i++
+
+		case c == '%':
+			for i < len(content) && content[i] != '\n' && content[i] != '\r' {
+				i++
+			}
+
+		case c == '(':
+			value, next := parsePDFLiteralString(content, i)
+			appendPDFOperand(&operands, &array, inArray, pdfOperand{text: value, isString: true})
+			i = next
+
+		case c == '<' && i+1 < len(content) && content[i+1] == '<', c == '>' && i+1 < len(content) && content[i+1] == '>':
+			i += 2
+
+		case c == '<':
+			value, next := parsePDFHexString(content, i)
+			appendPDFOperand(&operands, &array, inArray, pdfOperand{text: value, isString: true})
+			i = next
+
+		case c == '[':
+			inArray = true
+			array = nil
+			i++
+
+		case c == ']':
+			inArray = false
+			operands = append(operands, pdfOperand{array: array})
+			array = nil
+			i++
+
+		case c == '/':
+			i++
+			for i < len(content) && !isPDFWhitespace(content[i]) && !isPDFDelimiter(content[i]) {
+				i++
+			}
+
+		default:
+			start := i
+			for i < len(content) && !isPDFWhitespace(content[i]) && !isPDFDelimiter(content[i]) {
+				i++
+			}
+			if i == start {
+				i++
+				continue
+			}
+			token := string(content[start:i])
+			if number, err := strconv.ParseFloat(token, 64); err == nil {
+				appendPDFOperand(&operands, &array, inArray, pdfOperand{number: number, raw: token})
+				continue
+			}
+
+			switch token {
+			case "BT":
+				inText = true
+			case "ET":
+				inText = false
+				text.WriteString(" ")
+			case "BI":
+				// 内联图片：跳过 ID 与 EI 之间的二进制数据
+				if end := bytes.Index(content[i:], []byte("EI")); end >= 0 {
+					i += end + len("EI")
+				}
+			}
+			if inText {
+				writePDFTextOperator(token, operands, text, &lastY)
+			}
+			operands = operands[:0]
+		}
+	}
+}
+
+// pdfOperand 内容流操作数（只保留提取文本需要的信息）
+type pdfOperand struct {
+	text     string
+	isString bool
+	number   float64
+	raw      string
+	array    []pdfOperand
+}
+
+// appendPDFOperand 将操作数追加到数组或操作数栈
+func appendPDFOperand(operands, array *[]pdfOperand, inArray bool, operand pdfOperand) {
+	if inArray {
+		*array = append(*array, operand)
+		return
+	}
+	*operands = append(*operands, operand)
+}
+
+// writePDFTextOperator 处理文本绘制与文本定位操作符
+func writePDFTextOperator(token string, operands []pdfOperand, text *strings.Builder, lastY *string) {
+	last := func() (pdfOperand, bool) {
+		if len(operands) == 0 {
+			return pdfOperand{}, false
+		}
+		return operands[len(operands)-1], true
+	}
+
+	switch token {
+	case "Tj":
+		if operand, ok := last(); ok && operand.isString {
+			text.WriteString(operand.text)
+		}
+	case "'", "\"":
+		text.WriteString("\n")
+		if operand, ok := last(); ok && operand.isString {
+			text.WriteString(operand.text)
+		}
+	case "TJ":
+		operand, ok := last()
+		if !ok {
+			return
+		}
+		for _, item := range operand.array {
+			if item.isString {
+				text.WriteString(item.text)
+			} else if item.number <= -200 {
+				// 较大的负字距通常表示单词间隔
+				text.WriteString(" ")
+			}
+		}
+	case "T*":
+		text.WriteString("\n")
+	case "Td", "TD":
+		if len(operands) >= 2 && operands[len(operands)-1].number != 0 {
+			text.WriteString("\n")
+		} else {
+			text.WriteString(" ")
+		}
+	case "Tm":
+		if len(operands) >= 6 {
+			y := operands[len(operands)-1].raw
+			if *lastY != "" && y != *lastY {
+				text.WriteString("\n")
+			} else {
+				text.WriteString(" ")
+			}
+			*lastY = y
+		}
+	}
+}
+
+// parsePDFLiteralString 解析 (...) 字面量字符串，返回解码后的文本与下一个位置
+func parsePDFLiteralString(content []byte, start int) (string, int) {
+	var buf []byte
+	depth := 0
+	i := start
+	for i < len(content) {
+		c := content[i]
+		switch {
+		case c == '(':
+			if depth > 0 {
+				buf = append(buf, c)
+			}
+			depth++
+			i++
+		case c == ')':
+			depth--
+			i++
+			if depth == 0 {
+				return decodePDFString(buf), i
+			}
+			buf = append(buf, c)
+		case c == '\\' && i+1 < len(content):
+			i++
+			escaped := content[i]
+			switch escaped {
+			case 'n':
+				buf = append(buf, '\n')
+			case 'r':
+				buf = append(buf, '\r')
+			case 't':
+				buf = append(buf, '\t')
+			case 'b':
+				buf = append(buf, '\b')
+			case 'f':
+				buf = append(buf, '\f')
+			case '\r':
+				// 续行
+				if i+1 < len(content) && content[i+1] == '\n' {
+					i++
+				}
+			case '\n':
+			default:
+				if escaped >= '0' && escaped <= '7' {
+					value := 0
+					n := 0
+					for n < 3 && i < len(content) && content[i] >= '0' && content[i] <= '7' {
+						value = value*8 + int(content[i]-'0')
+						i++
+						n++
+					}
+					buf = append(buf, byte(value))
+					continue
+				}
+				buf = append(buf, escaped)
+			}
+			i++
+		default:
+			buf = append(buf, c)
+			i++
+		}
+	}
+	return decodePDFString(buf), i
+}
+
+// parsePDFHexString 解析 <...> 十六进制字符串
+func parsePDFHexString(content []byte, start int) (string, int) {
+	end := bytes.IndexByte(content[start:], '>')
+	if end < 0 {
+		return "", len(content)
+	}
+
+	var digits []byte
+	for _, c := range content[start+1 : start+end] {
+		if !isPDFWhitespace(c) {
+			digits = append(digits, c)
+		}
+	}
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+
+	buf := make([]byte, 0, len(digits)/2)
+	for i := 0; i+1 < len(digits); i += 2 {
+		value, err := strconv.ParseUint(string(digits[i:i+2]), 16, 8)
+		if err != nil {
+			return "", start + end + 1
+		}
+		buf = append(buf, byte(value))
+	}
+	return decodePDFString(buf), start + end + 1
+}
+
+// decodePDFString 解码 PDF 字符串：带 BOM 的按 UTF-16BE，其余按 PDFDocEncoding（近似 Latin-1）
+// 控制字符（多为 CID 字体的字形编号）被丢弃
+func decodePDFString(raw []byte) string {
+	if len(raw) >= 2 && raw[0] == 0xFE && raw[1] == 0xFF {
+		units := make([]uint16, 0, len(raw)/2)
+		for i := 2; i+1 < len(raw); i += 2 {
+			units = append(units, uint16(raw[i])<<8|uint16(raw[i+1]))
+		}
+		return string(utf16.Decode(units))
+	}
+
+	var b strings.Builder
+	for _, c := range raw {
+		if c < 0x20 && c != '\n' && c != '\t' {
+			continue
+		}
+		b.WriteRune(rune(c))
+	}
+	return b.String()
+}
+
+// normalizePDFText 合并多余空白并去除空行
+func normalizePDFText(text string) string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.Join(strings.Fields(line), " "); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func isPDFWhitespace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r' || c == '\n' || c == '\f' || c == 0
+}
+
+func isPDFDelimiter(c byte) bool {
+	return strings.IndexByte("()<>[]{}/%", c) >= 0
+}
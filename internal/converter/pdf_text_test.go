@@ -0,0 +1,99 @@
+package converter
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// buildTestPDF 生成包含给定内容流的最小 PDF；compress 时使用 FlateDecode 压缩
+func buildTestPDF(content string, compress bool) []byte {
+	stream := []byte(content)
+	filter := ""
+	if compress {
+		var buf bytes.Buffer
+		writer := zlib.NewWriter(&buf)
+		_, _ = writer.Write(stream)
+		_ = writer.Close()
+		stream = buf.Bytes()
+		filter = " /Filter /FlateDecode"
+	}
+
+	var pdf bytes.Buffer
+	pdf.WriteString("%PDF-1.4\n")
+	pdf.WriteString("1 0 obj << /Type /Catalog /Pages 2 0 R >> endobj\n")
+	pdf.WriteString("2 0 obj << /Type /Pages /Kids [3 0 R] /Count 1 >> endobj\n")
+	pdf.WriteString("3 0 obj << /Type /Page /Parent 2 0 R /Contents 4 0 R >> endobj\n")
+	fmt.Fprintf(&pdf, "4 0 obj << /Length %d%s >>\nstream\n", len(stream), filter)
+	pdf.Write(stream)
+	pdf.WriteString("\nendstream\nendobj\n")
+	pdf.WriteString("5 0 obj << /Subtype /Image /Width 1 /Height 1 /Length 9 >>\nstream\nBT (x) Tj\nendstream\nendobj\n")
+	pdf.WriteString("trailer << /Root 1 0 R >>\n%%EOF\n")
+	return pdf.Bytes()
+}
+
+// 测试从 PDF 内容流中提取文本
+func TestExtractPDFText(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		compress bool
+		want     string
+	}{
+		{"Tj 与换行", "BT /F1 12 Tf 72 720 Td (Hello World) Tj 0 -14 Td (Second line) Tj ET", false, "Hello World\nSecond line"},
+		{"FlateDecode 压缩", "BT /F1 12 Tf (Compressed text) Tj ET", true, "Compressed text"},
+		{"TJ 字距与转义", `BT [(Quar)20(terly) -300 (report \(Q3\))] TJ T* (\101\102C) ' ET`, false, "Quarterly report (Q3)\nABC"},
+		{"UTF-16 十六进制字符串", "BT <FEFF4E2D6587> Tj ET", false, "中文"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ExtractPDFText(buildTestPDF(tt.content, tt.compress))
+			if err != nil {
+				t.Fatalf("提取失败: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("文本不匹配: got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// 测试无法提取文本时返回错误
+func TestExtractPDFTextErrors(t *testing.T) {
+	if _, err := ExtractPDFText([]byte("not a pdf")); err == nil {
+		t.Error("非 PDF 数据应返回错误")
+	}
+
+	// 只绘制图片的页面（扫描件）
+	_, err := ExtractPDFText(buildTestPDF("q 100 0 0 100 0 0 cm /Im1 Do Q", true))
+	if !errors.Is(err, ErrPDFNoText) {
+		t.Errorf("无文本的 PDF 应返回 ErrPDFNoText, got %v", err)
+	}
+}
+
+// 测试所有内容流解压后的总大小超出上限时返回错误
+func TestExtractPDFTextDecompressionBudget(t *testing.T) {
+	var pdf bytes.Buffer
+	pdf.WriteString("%PDF-1.4\n")
+	for i := 0; i < 8; i++ {
+		var buf bytes.Buffer
+		writer := zlib.NewWriter(&buf)
+		_, _ = writer.Write([]byte("BT (hello) Tj ET "))
+		_, _ = writer.Write(make([]byte, 4096))
+		_ = writer.Close()
+		fmt.Fprintf(&pdf, "%d 0 obj << /Length %d /Filter /FlateDecode >>\nstream\n", i+1, buf.Len())
+		pdf.Write(buf.Bytes())
+		pdf.WriteString("\nendstream\nendobj\n")
+	}
+	pdf.WriteString("%%EOF\n")
+
+	if _, err := extractPDFText(pdf.Bytes(), 3*4096); !errors.Is(err, ErrPDFTooLarge) {
+		t.Errorf("超出解压总额度应返回 ErrPDFTooLarge, got %v", err)
+	}
+	if text, err := extractPDFText(pdf.Bytes(), 16*4096); err != nil || text == "" {
+		t.Errorf("额度内应正常提取: %q %v", text, err)
+	}
+}
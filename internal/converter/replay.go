@@ -0,0 +1,223 @@
+package converter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// ========================
+// 非流式响应回放为 SSE
+// ========================
+// 用于将完整的 JSON 响应（如缓存命中的响应）按流式协议一次性写出，客户端无需区分来源
+// 以通用 map 处理，保留上游返回的扩展字段（reasoning_content、thinking 块等）
+
+// ReplayOpenAIStream 将 Chat Completions 响应转换为 chat.completion.chunk SSE 流
+// 每个 choice 输出一个包含完整增量的 chunk 与一个携带 finish_reason 的 chunk；includeUsage 时追加 usage chunk
+func ReplayOpenAIStream(body []byte, includeUsage bool) ([]byte, error) {
+	var resp map[string]interface{}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse chat completion: %w", err)
+	}
+
+	var out bytes.Buffer
+	writeChunk := func(choices []interface{}, usage interface{}) error {
+		chunk := map[string]interface{}{
+			"id":      resp["id"],
+			"object":  "chat.completion.chunk",
+			"created": resp["created"],
+			"model":   resp["model"],
+			"choices": choices,
+		}
+		if fingerprint, ok := resp["system_fingerprint"]; ok {
+			chunk["system_fingerprint"] = fingerprint
+		}
+		if usage != nil {
+			chunk["usage"] = usage
+		}
+		data, err := json.Marshal(chunk)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(&out, "data: %s\n\n", data)
+		return nil
+	}
+
+	choices, _ := resp["choices"].([]interface{})
+	for i, rawChoice := range choices {
+		choice, _ := rawChoice.(map[string]interface{})
+		index := choice["index"]
+		if index == nil {
+			index = i
+		}
+
+		delta := map[string]interface{}{"role": "assistant"}
+		if message, ok := choice["message"].(map[string]interface{}); ok {
+			for key, value := range message {
+				if key == "tool_calls" {
+					delta[key] = replayToolCallDeltas(value)
+					continue
+				}
+				if value != nil {
+					delta[key] = value
+				}
+			}
+		}
+		if err := writeChunk([]interface{}{map[string]interface{}{"index": index, "delta": delta, "finish_reason": nil}}, nil); err != nil {
+			return nil, err
+		}
+		finish := map[string]interface{}{"index": index, "delta": map[string]interface{}{}, "finish_reason": choice["finish_reason"]}
+		if err := writeChunk([]interface{}{finish}, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	if usage, ok := resp["usage"]; ok && usage != nil && includeUsage {
+		if err := writeChunk([]interface{}{}, usage); err != nil {
+			return nil, err
+		}
+	}
+	out.WriteString("data: [DONE]\n\n")
+	return out.Bytes(), nil
+}
+
+// replayToolCallDeltas 为 tool_calls 增量补充 index 字段
+func replayToolCallDeltas(value interface{}) interface{} {
+	toolCalls, ok := value.([]interface{})
+	if !ok {
+		return value
+	}
+	deltas := make([]interface{}, 0, len(toolCalls))
+	for i, rawCall := range toolCalls {
+		call, ok := rawCall.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		delta := make(map[string]interface{}, len(call)+1)
+		for key, field := range call {
+			delta[key] = field
+		}
+		delta["index"] = i
+		deltas = append(deltas, delta)
+	}
+	return deltas
+}
+
+// ReplayClaudeStream 将 Claude Messages 响应转换为 Messages SSE 事件流
+// 每个内容块输出 start / 单个完整 delta / stop，usage 在 message_start 与 message_delta 中按官方流式格式拆分
+func ReplayClaudeStream(body []byte) ([]byte, error) {
+	var resp map[string]interface{}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse message: %w", err)
+	}
+
+	var out bytes.Buffer
+	emit := func(eventType string, data interface{}) error {
+		event, err := FormatSSEEvent(eventType, data)
+		if err != nil {
+			return err
+		}
+		out.WriteString(event)
+		return nil
+	}
+
+	usage, _ := resp["usage"].(map[string]interface{})
+	startUsage := make(map[string]interface{}, len(usage))
+	for key, value := range usage {
+		startUsage[key] = value
+	}
+	startUsage["output_tokens"] = 0
+
+	message := make(map[string]interface{}, len(resp))
+	for key, value := range resp {
+		message[key] = value
+	}
+	message["content"] = []interface{}{}
+	message["stop_reason"] = nil
+	message["stop_sequence"] = nil
+	message["usage"] = startUsage
+	if err := emit("message_start", map[string]interface{}{"type": "message_start", "message": message}); err != nil {
+		return nil, err
+	}
+
+	content, _ := resp["content"].([]interface{})
+	for index, rawBlock := range content {
+		block, ok := rawBlock.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		start, deltas := replayClaudeBlock(block)
+		if err := emit("content_block_start", map[string]interface{}{"type": "content_block_start", "index": index, "content_block": start}); err != nil {
+			return nil, err
+		}
+		for _, delta := range deltas {
+			if err := emit("content_block_delta", map[string]interface{}{"type": "content_block_delta", "index": index, "delta": delta}); err != nil {
+				return nil, err
+			}
+		}
+		if err := emit("content_block_stop", ClaudeContentBlockStop{Type: "content_block_stop", Index: index}); err != nil {
+			return nil, err
+		}
+	}
+
+	deltaUsage := map[string]interface{}{"output_tokens": 0}
+	for key, value := range usage {
+		deltaUsage[key] = value
+	}
+	messageDelta := map[string]interface{}{
+		"type":  "message_delta",
+		"delta": map[string]interface{}{"stop_reason": resp["stop_reason"], "stop_sequence": resp["stop_sequence"]},
+		"usage": deltaUsage,
+	}
+	if err := emit("message_delta", messageDelta); err != nil {
+		return nil, err
+	}
+	if err := emit("message_stop", ClaudeMessageStop{Type: "message_stop"}); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// replayClaudeBlock 拆分内容块为 content_block_start 的初始块与 delta 列表
+// 未知类型的块原样放入 content_block_start，不输出 delta
+func replayClaudeBlock(block map[string]interface{}) (map[string]interface{}, []map[string]interface{}) {
+	start := make(map[string]interface{}, len(block))
+	for key, value := range block {
+		start[key] = value
+	}
+
+	switch block["type"] {
+	case "text":
+		start["text"] = ""
+		delete(start, "citations")
+		text, _ := block["text"].(string)
+		deltas := []map[string]interface{}{{"type": "text_delta", "text": text}}
+		if citations, ok := block["citations"].([]interface{}); ok {
+			for _, citation := range citations {
+				deltas = append(deltas, map[string]interface{}{"type": "citations_delta", "citation": citation})
+			}
+		}
+		return start, deltas
+	case "thinking":
+		start["thinking"] = ""
+		start["signature"] = ""
+		thinking, _ := block["thinking"].(string)
+		deltas := []map[string]interface{}{{"type": "thinking_delta", "thinking": thinking}}
+		if signature, ok := block["signature"].(string); ok && signature != "" {
+			deltas = append(deltas, map[string]interface{}{"type": "signature_delta", "signature": signature})
+		}
+		return start, deltas
+	case "tool_use", "server_tool_use":
+		start["input"] = map[string]interface{}{}
+		input := block["input"]
+		if input == nil {
+			input = map[string]interface{}{}
+		}
+		partialJSON, err := json.Marshal(input)
+		if err != nil {
+			partialJSON = []byte("{}")
+		}
+		return start, []map[string]interface{}{{"type": "input_json_delta", "partial_json": string(partialJSON)}}
+	}
+	return start, nil
+}
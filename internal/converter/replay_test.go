@@ -0,0 +1,138 @@
+package converter
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// parseReplayEvents 解析 SSE 输出为 (event, data) 列表
+func parseReplayEvents(t *testing.T, out []byte) ([]string, []map[string]interface{}) {
+	t.Helper()
+	var names []string
+	var payloads []map[string]interface{}
+	for _, block := range strings.Split(strings.TrimSpace(string(out)), "\n\n") {
+		var name, data string
+		for _, line := range strings.Split(block, "\n") {
+			if value, ok := strings.CutPrefix(line, "event: "); ok {
+				name = value
+			}
+			if value, ok := strings.CutPrefix(line, "data: "); ok {
+				data = value
+			}
+		}
+		names = append(names, name)
+		if data == "[DONE]" {
+			payloads = append(payloads, nil)
+			continue
+		}
+		var payload map[string]interface{}
+		if err := json.Unmarshal([]byte(data), &payload); err != nil {
+			t.Fatalf("invalid event data %q: %v", data, err)
+		}
+		payloads = append(payloads, payload)
+	}
+	return names, payloads
+}
+
+func TestReplayOpenAIStream(t *testing.T) {
+	body := []byte(`{"id":"chatcmpl-1","object":"chat.completion","created":7,"model":"gpt-4o","system_fingerprint":"fp_1",
+		"choices":[{"index":0,"message":{"role":"assistant","content":"hi","reasoning_content":"think","tool_calls":[{"id":"call_1","type":"function","function":{"name":"f","arguments":"{\"a\":1}"}}]},"finish_reason":"tool_calls"}],
+		"usage":{"prompt_tokens":3,"completion_tokens":2,"total_tokens":5}}`)
+
+	out, err := ReplayOpenAIStream(body, true)
+	if err != nil {
+		t.Fatalf("replay failed: %v", err)
+	}
+	_, chunks := parseReplayEvents(t, out)
+	if len(chunks) != 4 || chunks[3] != nil {
+		t.Fatalf("expected 3 chunks and [DONE], got:\n%s", out)
+	}
+
+	first := chunks[0]
+	if first["object"] != "chat.completion.chunk" || first["id"] != "chatcmpl-1" || first["system_fingerprint"] != "fp_1" {
+		t.Fatalf("unexpected chunk metadata: %+v", first)
+	}
+	delta := first["choices"].([]interface{})[0].(map[string]interface{})["delta"].(map[string]interface{})
+	if delta["role"] != "assistant" || delta["content"] != "hi" || delta["reasoning_content"] != "think" {
+		t.Fatalf("unexpected delta: %+v", delta)
+	}
+	call := delta["tool_calls"].([]interface{})[0].(map[string]interface{})
+	if call["index"] != float64(0) || call["id"] != "call_1" || call["function"].(map[string]interface{})["arguments"] != `{"a":1}` {
+		t.Fatalf("unexpected tool call delta: %+v", call)
+	}
+
+	finish := chunks[1]["choices"].([]interface{})[0].(map[string]interface{})
+	if finish["finish_reason"] != "tool_calls" {
+		t.Fatalf("unexpected finish chunk: %+v", finish)
+	}
+	if usage := chunks[2]["usage"].(map[string]interface{}); usage["total_tokens"] != float64(5) || len(chunks[2]["choices"].([]interface{})) != 0 {
+		t.Fatalf("unexpected usage chunk: %+v", chunks[2])
+	}
+
+	withoutUsage, err := ReplayOpenAIStream(body, false)
+	if err != nil {
+		t.Fatalf("replay failed: %v", err)
+	}
+	if _, chunks := parseReplayEvents(t, withoutUsage); len(chunks) != 3 {
+		t.Fatalf("usage chunk should be omitted:\n%s", withoutUsage)
+	}
+}
+
+func TestReplayClaudeStream(t *testing.T) {
+	body := []byte(`{"id":"msg_1","type":"message","role":"assistant","model":"claude-sonnet-4-5",
+		"content":[
+			{"type":"thinking","thinking":"hmm","signature":"sig"},
+			{"type":"text","text":"Hello"},
+			{"type":"tool_use","id":"toolu_1","name":"get_weather","input":{"city":"Paris"}}
+		],
+		"stop_reason":"tool_use","stop_sequence":null,
+		"usage":{"input_tokens":10,"output_tokens":5,"cache_read_input_tokens":4}}`)
+
+	out, err := ReplayClaudeStream(body)
+	if err != nil {
+		t.Fatalf("replay failed: %v", err)
+	}
+	names, events := parseReplayEvents(t, out)
+
+	want := []string{
+		"message_start",
+		"content_block_start", "content_block_delta", "content_block_delta", "content_block_stop",
+		"content_block_start", "content_block_delta", "content_block_stop",
+		"content_block_start", "content_block_delta", "content_block_stop",
+		"message_delta", "message_stop",
+	}
+	if strings.Join(names, ",") != strings.Join(want, ",") {
+		t.Fatalf("unexpected event sequence: %v", names)
+	}
+	for i, name := range names {
+		if events[i]["type"] != name {
+			t.Fatalf("event %d type mismatch: %s vs %+v", i, name, events[i])
+		}
+	}
+
+	message := events[0]["message"].(map[string]interface{})
+	usage := message["usage"].(map[string]interface{})
+	if len(message["content"].([]interface{})) != 0 || message["stop_reason"] != nil || usage["input_tokens"] != float64(10) || usage["output_tokens"] != float64(0) || usage["cache_read_input_tokens"] != float64(4) {
+		t.Fatalf("unexpected message_start: %+v", events[0])
+	}
+
+	if delta := events[3]["delta"].(map[string]interface{}); delta["type"] != "signature_delta" || delta["signature"] != "sig" {
+		t.Fatalf("unexpected signature delta: %+v", events[3])
+	}
+	if delta := events[6]["delta"].(map[string]interface{}); delta["type"] != "text_delta" || delta["text"] != "Hello" || events[6]["index"] != float64(1) {
+		t.Fatalf("unexpected text delta: %+v", events[6])
+	}
+	toolStart := events[8]["content_block"].(map[string]interface{})
+	if toolStart["name"] != "get_weather" || len(toolStart["input"].(map[string]interface{})) != 0 {
+		t.Fatalf("unexpected tool_use start: %+v", events[8])
+	}
+	if delta := events[9]["delta"].(map[string]interface{}); delta["partial_json"] != `{"city":"Paris"}` {
+		t.Fatalf("unexpected input_json_delta: %+v", events[9])
+	}
+
+	messageDelta := events[11]
+	if messageDelta["delta"].(map[string]interface{})["stop_reason"] != "tool_use" || messageDelta["usage"].(map[string]interface{})["output_tokens"] != float64(5) {
+		t.Fatalf("unexpected message_delta: %+v", messageDelta)
+	}
+}
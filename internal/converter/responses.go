@@ -0,0 +1,344 @@
+package converter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Responses API 相关常量
+const (
+	ResponsesObjectResponse = "response"
+
+	ResponsesItemMessage            = "message"
+	ResponsesItemFunctionCall       = "function_call"
+	ResponsesItemFunctionCallOutput = "function_call_output"
+	ResponsesItemReasoning          = "reasoning"
+
+	ResponsesStatusCompleted  = "completed"
+	ResponsesStatusIncomplete = "incomplete"
+	ResponsesStatusInProgress = "in_progress"
+	ResponsesStatusFailed     = "failed"
+)
+
+// ParseResponsesInput 解析 Responses 请求的 input 字段
+// 字符串输入视为一条 user 消息；省略 type 的输入项视为 message
+func ParseResponsesInput(raw json.RawMessage) ([]ResponsesInputItem, error) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+		return nil, nil
+	}
+
+	if raw[0] == '"' {
+		var text string
+		if err := json.Unmarshal(raw, &text); err != nil {
+			return nil, fmt.Errorf("input 格式无效: %w", err)
+		}
+		content, _ := json.Marshal(text)
+		return []ResponsesInputItem{{Type: ResponsesItemMessage, Role: ClaudeRoleUser, Content: content}}, nil
+	}
+
+	var items []ResponsesInputItem
+	if err := json.Unmarshal(raw, &items); err != nil {
+		return nil, fmt.Errorf("input 必须是字符串或输入项数组: %w", err)
+	}
+	for i := range items {
+		if items[i].Type == "" && items[i].Role != "" {
+			items[i].Type = ResponsesItemMessage
+		}
+	}
+	return items, nil
+}
+
+// ConvertResponsesToClaude 将 Responses API 请求转换为 Claude Messages API 请求
+// items 为完整的对话输入（previous_response_id 对应的历史轮次在前，本轮 input 在后）；调用方负责设置 model 与 stream
+func ConvertResponsesToClaude(req *ResponsesRequest, items []ResponsesInputItem) (*ClaudeRequest, error) {
+	if err := ValidateNonNil(req, "Responses请求"); err != nil {
+		return nil, NewConversionError("request", "验证失败", err)
+	}
+
+	claudeReq := &ClaudeRequest{
+		MaxTokens:   DefaultClaudeMaxTokens,
+		Temperature: req.Temperature,
+		TopP:        req.TopP,
+	}
+	if req.MaxOutputTokens > 0 {
+		claudeReq.MaxTokens = req.MaxOutputTokens
+	}
+
+	var system []string
+	if req.Instructions != "" {
+		system = append(system, req.Instructions)
+	}
+
+	for i, item := range items {
+		switch item.Type {
+		case ResponsesItemMessage:
+			blocks, err := responsesContentToClaudeBlocks(item.Content)
+			if err != nil {
+				return nil, NewConversionError("request", fmt.Sprintf("转换第 %d 个输入项失败", i), err)
+			}
+			switch item.Role {
+			case "system", "developer":
+				system = append(system, ExtractTextFromClaudeContent(blocks))
+			case ClaudeRoleUser, ClaudeRoleAssistant:
+				claudeReq.Messages = appendClaudeMessage(claudeReq.Messages, item.Role, blocks)
+			default:
+				return nil, NewConversionError("request", "转换消息失败", fmt.Errorf("不支持的角色: %s", item.Role))
+			}
+
+		case ResponsesItemFunctionCall:
+			input := map[string]interface{}{}
+			if strings.TrimSpace(item.Arguments) != "" {
+				if err := json.Unmarshal([]byte(item.Arguments), &input); err != nil {
+					return nil, NewConversionError("request", fmt.Sprintf("函数 %s 的参数不是合法 JSON 对象", item.Name), err)
+				}
+			}
+			claudeReq.Messages = appendClaudeMessage(claudeReq.Messages, ClaudeRoleAssistant, []ClaudeContentBlock{{
+				Type:  ContentTypeToolUse,
+				ID:    StringPtr(item.CallID),
+				Name:  StringPtr(item.Name),
+				Input: input,
+			}})
+
+		case ResponsesItemFunctionCallOutput:
+			output, err := responsesOutputText(item.Output)
+			if err != nil {
+				return nil, NewConversionError("request", fmt.Sprintf("转换 %s 的函数输出失败", item.CallID), err)
+			}
+			claudeReq.Messages = appendClaudeMessage(claudeReq.Messages, ClaudeRoleUser, []ClaudeContentBlock{{
+				Type:      ContentTypeToolResult,
+				ToolUseID: StringPtr(item.CallID),
+				Content:   NewToolResultText(output),
+			}})
+
+		case ResponsesItemReasoning:
+			// 推理内容由上游模型重新生成，不回传
+
+		default:
+			return nil, NewConversionError("request", "转换输入项失败", fmt.Errorf("不支持的输入项类型: %s", item.Type))
+		}
+	}
+	claudeReq.System = NewSystemPrompt(strings.Join(system, "\n"))
+
+	// 仅转换 function 工具；web_search 等内置工具依赖 OpenAI 托管能力，无法在其他上游执行
+	for _, tool := range req.Tools {
+		if tool.Type != OpenAIToolTypeFunction {
+			continue
+		}
+		schema := tool.Parameters
+		if schema == nil {
+			schema = map[string]interface{}{"type": "object", "properties": map[string]interface{}{}}
+		}
+		claudeReq.Tools = append(claudeReq.Tools, ClaudeTool{
+			Name:        tool.Name,
+			Description: tool.Description,
+			InputSchema: schema,
+		})
+	}
+
+	switch choice := req.ToolChoice.(type) {
+	case string:
+		switch choice {
+		case "auto":
+			claudeReq.ToolChoice = &ClaudeToolChoice{Type: "auto"}
+		case "required":
+			claudeReq.ToolChoice = &ClaudeToolChoice{Type: "any"}
+		case "none":
+			claudeReq.ToolChoice = &ClaudeToolChoice{Type: "none"}
+		}
+	case map[string]interface{}:
+		if name, ok := choice["name"].(string); ok && choice["type"] == OpenAIToolTypeFunction {
+			claudeReq.ToolChoice = &ClaudeToolChoice{Type: "tool", Name: StringPtr(name)}
+		}
+	}
+
+	return claudeReq, nil
+}
+
+// responsesContentToClaudeBlocks 转换消息内容（字符串或内容块数组）
+func responsesContentToClaudeBlocks(raw json.RawMessage) ([]ClaudeContentBlock, error) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+		return nil, nil
+	}
+
+	if raw[0] == '"' {
+		var text string
+		if err := json.Unmarshal(raw, &text); err != nil {
+			return nil, err
+		}
+		if text == "" {
+			return nil, nil
+		}
+		return []ClaudeContentBlock{{Type: ContentTypeText, Text: StringPtr(text)}}, nil
+	}
+
+	var parts []ResponsesInputContent
+	if err := json.Unmarshal(raw, &parts); err != nil {
+		return nil, fmt.Errorf("content 必须是字符串或内容块数组: %w", err)
+	}
+
+	var blocks []ClaudeContentBlock
+	for _, part := range parts {
+		switch part.Type {
+		case "input_text", "output_text":
+			if part.Text != "" {
+				blocks = append(blocks, ClaudeContentBlock{Type: ContentTypeText, Text: StringPtr(part.Text)})
+			}
+		case "refusal":
+			if part.Refusal != "" {
+				blocks = append(blocks, ClaudeContentBlock{Type: ContentTypeText, Text: StringPtr(part.Refusal)})
+			}
+		case "input_image":
+			mediaType, data, ok := parseDataURI(part.ImageURL)
+			if !ok {
+				return nil, fmt.Errorf("仅支持 base64 data URI 图片")
+			}
+			blocks = append(blocks, ClaudeContentBlock{
+				Type:   ContentTypeImage,
+				Source: &ClaudeImageSource{Type: "base64", MediaType: mediaType, Data: data},
+			})
+		default:
+			return nil, fmt.Errorf("不支持的内容类型: %s", part.Type)
+		}
+	}
+	return blocks, nil
+}
+
+// responsesOutputText 将 function_call_output 的 output（字符串或内容块数组）转换为文本
+func responsesOutputText(raw json.RawMessage) (string, error) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+		return "", nil
+	}
+
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		return text, nil
+	}
+
+	blocks, err := responsesContentToClaudeBlocks(raw)
+	if err != nil {
+		return "", err
+	}
+	for _, block := range blocks {
+		if block.Type != ContentTypeText {
+			return "", fmt.Errorf("函数输出仅支持文本内容")
+		}
+	}
+	return ExtractTextFromClaudeContent(blocks), nil
+}
+
+// NewResponsesID 生成 Responses 响应 ID
+func NewResponsesID() string {
+	return "resp_" + randomHexID()
+}
+
+// ConvertClaudeResponseToResponses 将 Claude Messages API 响应转换为 Responses API 响应
+// 调用方负责设置 previous_response_id 与 metadata
+func ConvertClaudeResponseToResponses(resp *ClaudeResponse) (*ResponsesResponse, error) {
+	if err := ValidateNonNil(resp, "Claude响应"); err != nil {
+		return nil, NewConversionError("response", "验证失败", err)
+	}
+
+	output := []ResponsesOutputItem{}
+	var message *ResponsesOutputItem
+	for _, block := range resp.Content {
+		switch block.Type {
+		case ContentTypeText:
+			if block.Text == nil || *block.Text == "" {
+				continue
+			}
+			// 相邻的文本块合并到同一个 message 输出项
+			if message == nil {
+				output = append(output, newResponsesMessageItem(ResponsesStatusCompleted))
+				message = &output[len(output)-1]
+			}
+			message.Content = append(message.Content, newResponsesOutputText(*block.Text))
+
+		case ContentTypeToolUse:
+			if block.ID == nil || block.Name == nil {
+				continue
+			}
+			input := block.Input
+			if input == nil {
+				input = map[string]interface{}{}
+			}
+			arguments, err := json.Marshal(input)
+			if err != nil {
+				return nil, NewConversionError("response", fmt.Sprintf("序列化工具 %s 的参数失败", *block.Name), err)
+			}
+			item := newResponsesFunctionCallItem(*block.ID, *block.Name, ResponsesStatusCompleted)
+			item.Arguments = string(arguments)
+			output = append(output, item)
+			message = nil
+		}
+	}
+
+	result := &ResponsesResponse{
+		ID:        NewResponsesID(),
+		Object:    ResponsesObjectResponse,
+		CreatedAt: time.Now().Unix(),
+		Model:     resp.Model,
+		Output:    output,
+		Usage:     responsesUsageFromClaude(resp.Usage),
+	}
+	result.Status, result.IncompleteDetails = responsesStatusFromStopReason(resp.StopReason)
+	return result, nil
+}
+
+// responsesStatusFromStopReason 根据 Claude stop_reason 确定响应状态
+func responsesStatusFromStopReason(stopReason string) (string, *ResponsesIncompleteDetails) {
+	switch stopReason {
+	case "max_tokens":
+		return ResponsesStatusIncomplete, &ResponsesIncompleteDetails{Reason: "max_output_tokens"}
+	case ClaudeStopReasonRefusal:
+		return ResponsesStatusIncomplete, &ResponsesIncompleteDetails{Reason: "content_filter"}
+	default:
+		return ResponsesStatusCompleted, nil
+	}
+}
+
+// responsesUsageFromClaude 转换 token 用量
+func responsesUsageFromClaude(usage ClaudeUsage) *ResponsesUsage {
+	input := usage.TotalInputTokens()
+	result := &ResponsesUsage{
+		InputTokens:  input,
+		OutputTokens: usage.OutputTokens,
+		TotalTokens:  input + usage.OutputTokens,
+	}
+	if usage.CacheReadInputTokens > 0 {
+		result.InputTokensDetails = &ResponsesInputTokensDetails{CachedTokens: usage.CacheReadInputTokens}
+	}
+	return result
+}
+
+// newResponsesMessageItem 创建 assistant message 输出项
+func newResponsesMessageItem(status string) ResponsesOutputItem {
+	return ResponsesOutputItem{
+		Type:    ResponsesItemMessage,
+		ID:      "msg_" + randomHexID(),
+		Status:  status,
+		Role:    ClaudeRoleAssistant,
+		Content: []ResponsesOutputContent{},
+	}
+}
+
+// newResponsesFunctionCallItem 创建 function_call 输出项，call_id 沿用 Claude 的 tool_use_id
+func newResponsesFunctionCallItem(callID, name, status string) ResponsesOutputItem {
+	return ResponsesOutputItem{
+		Type:   ResponsesItemFunctionCall,
+		ID:     "fc_" + randomHexID(),
+		Status: status,
+		CallID: callID,
+		Name:   name,
+	}
+}
+
+// newResponsesOutputText 创建 output_text 内容块
+func newResponsesOutputText(text string) ResponsesOutputContent {
+	return ResponsesOutputContent{Type: "output_text", Text: text, Annotations: []interface{}{}}
+}
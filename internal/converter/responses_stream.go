@@ -0,0 +1,263 @@
+package converter
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ClaudeResponsesStreamConverter 将 Claude SSE 事件逐个转换为 Responses API 流式事件
+// 每个 Claude text 块对应一个 message 输出项，每个 tool_use 块对应一个 function_call 输出项
+type ClaudeResponsesStreamConverter struct {
+	response   *ResponsesResponse
+	sequence   int
+	blocks     map[int]*responsesStreamBlock
+	usage      ClaudeUsage
+	stopReason string
+	finished   bool
+}
+
+// responsesStreamBlock 进行中的输出项
+type responsesStreamBlock struct {
+	outputIndex int
+	item        ResponsesOutputItem
+	text        strings.Builder
+}
+
+// NewClaudeResponsesStreamConverter 创建 Claude→Responses 流式转换器
+// previousResponseID 与 metadata 会写入所有事件携带的 response 对象
+func NewClaudeResponsesStreamConverter(previousResponseID string, metadata map[string]interface{}) *ClaudeResponsesStreamConverter {
+	response := &ResponsesResponse{
+		ID:        NewResponsesID(),
+		Object:    ResponsesObjectResponse,
+		CreatedAt: time.Now().Unix(),
+		Status:    ResponsesStatusInProgress,
+		Output:    []ResponsesOutputItem{},
+		Metadata:  metadata,
+	}
+	if previousResponseID != "" {
+		response.PreviousResponseID = StringPtr(previousResponseID)
+	}
+	return &ClaudeResponsesStreamConverter{
+		response: response,
+		blocks:   make(map[int]*responsesStreamBlock),
+	}
+}
+
+// Response 返回当前累积的响应（流结束后为最终响应）
+func (c *ClaudeResponsesStreamConverter) Response() *ResponsesResponse {
+	return c.response
+}
+
+// Finished 是否已输出 response.completed / response.incomplete 事件
+func (c *ClaudeResponsesStreamConverter) Finished() bool {
+	return c.finished
+}
+
+// ProcessEvent 处理一个 Claude SSE 事件的 data，返回需要输出的 Responses 流式事件
+// 上游 error 事件以错误返回，调用方可用 Failed 生成 response.failed 事件
+func (c *ClaudeResponsesStreamConverter) ProcessEvent(data []byte) ([]ResponsesStreamEvent, error) {
+	var event claudeStreamEvent
+	if err := json.Unmarshal(data, &event); err != nil {
+		return nil, NewConversionError("stream", "解析 Claude 事件失败", err)
+	}
+
+	switch event.Type {
+	case EventTypeMessageStart:
+		if event.Message != nil {
+			c.response.Model = event.Message.Model
+			c.usage = event.Message.Usage
+		}
+		return []ResponsesStreamEvent{
+			c.responseEvent("response.created"),
+			c.responseEvent("response.in_progress"),
+		}, nil
+
+	case EventTypeContentBlockStart:
+		if event.ContentBlock == nil {
+			return nil, nil
+		}
+		return c.startBlock(event.Index, event.ContentBlock), nil
+
+	case EventTypeContentBlockDelta:
+		block, ok := c.blocks[event.Index]
+		if !ok || event.Delta == nil {
+			return nil, nil
+		}
+		switch event.Delta.Type {
+		case DeltaTypeTextDelta:
+			return c.textDelta(block, event.Delta.Text), nil
+		case DeltaTypeInputJSONDelta:
+			if event.Delta.PartialJSON == "" {
+				return nil, nil
+			}
+			block.text.WriteString(event.Delta.PartialJSON)
+			return []ResponsesStreamEvent{c.itemEvent("response.function_call_arguments.delta", block, func(e *ResponsesStreamEvent) {
+				e.Delta = event.Delta.PartialJSON
+			})}, nil
+		}
+
+	case EventTypeContentBlockStop:
+		block, ok := c.blocks[event.Index]
+		if !ok {
+			return nil, nil
+		}
+		delete(c.blocks, event.Index)
+		return c.stopBlock(block), nil
+
+	case EventTypeMessageDelta:
+		if event.Delta != nil && event.Delta.StopReason != "" {
+			c.stopReason = event.Delta.StopReason
+		}
+		if event.Usage != nil {
+			mergeClaudeDeltaUsage(&c.usage, event.Usage)
+		}
+
+	case EventTypeMessageStop:
+		c.finished = true
+		c.response.Usage = responsesUsageFromClaude(c.usage)
+		c.response.Status, c.response.IncompleteDetails = responsesStatusFromStopReason(c.stopReason)
+		eventType := "response.completed"
+		if c.response.Status == ResponsesStatusIncomplete {
+			eventType = "response.incomplete"
+		}
+		return []ResponsesStreamEvent{c.responseEvent(eventType)}, nil
+
+	case "error":
+		if event.Error != nil && event.Error.Message != "" {
+			return nil, fmt.Errorf("上游返回错误: %s", event.Error.Message)
+		}
+		return nil, fmt.Errorf("上游返回错误")
+	}
+
+	return nil, nil
+}
+
+// Failed 生成 response.failed 事件
+func (c *ClaudeResponsesStreamConverter) Failed(message string) ResponsesStreamEvent {
+	c.finished = true
+	c.response.Status = ResponsesStatusFailed
+	c.response.Error = &ResponsesError{Code: "server_error", Message: message}
+	return c.responseEvent("response.failed")
+}
+
+// startBlock 开始新的输出项；thinking 等无对应输出项的块被忽略
+func (c *ClaudeResponsesStreamConverter) startBlock(index int, contentBlock *ClaudeContentBlock) []ResponsesStreamEvent {
+	block := &responsesStreamBlock{outputIndex: len(c.response.Output)}
+	switch contentBlock.Type {
+	case ContentTypeText:
+		block.item = newResponsesMessageItem(ResponsesStatusInProgress)
+	case ContentTypeToolUse:
+		var id, name string
+		if contentBlock.ID != nil {
+			id = *contentBlock.ID
+		}
+		if contentBlock.Name != nil {
+			name = *contentBlock.Name
+		}
+		block.item = newResponsesFunctionCallItem(id, name, ResponsesStatusInProgress)
+	default:
+		return nil
+	}
+	c.blocks[index] = block
+	c.response.Output = append(c.response.Output, block.item)
+
+	added := block.item
+	events := []ResponsesStreamEvent{c.itemEvent("response.output_item.added", block, func(e *ResponsesStreamEvent) {
+		e.Item = &added
+	})}
+	if contentBlock.Type == ContentTypeText {
+		part := newResponsesOutputText("")
+		events = append(events, c.partEvent("response.content_part.added", block, &part))
+		if contentBlock.Text != nil {
+			events = append(events, c.textDelta(block, *contentBlock.Text)...)
+		}
+	}
+	return events
+}
+
+// textDelta 输出文本增量
+func (c *ClaudeResponsesStreamConverter) textDelta(block *responsesStreamBlock, text string) []ResponsesStreamEvent {
+	if text == "" || block.item.Type != ResponsesItemMessage {
+		return nil
+	}
+	block.text.WriteString(text)
+	event := c.itemEvent("response.output_text.delta", block, func(e *ResponsesStreamEvent) {
+		e.ContentIndex = intPtr(0)
+		e.Delta = text
+	})
+	return []ResponsesStreamEvent{event}
+}
+
+// stopBlock 完成输出项，写入累积的最终响应
+func (c *ClaudeResponsesStreamConverter) stopBlock(block *responsesStreamBlock) []ResponsesStreamEvent {
+	var events []ResponsesStreamEvent
+	block.item.Status = ResponsesStatusCompleted
+
+	if block.item.Type == ResponsesItemMessage {
+		part := newResponsesOutputText(block.text.String())
+		block.item.Content = []ResponsesOutputContent{part}
+		events = append(events,
+			c.itemEvent("response.output_text.done", block, func(e *ResponsesStreamEvent) {
+				e.ContentIndex = intPtr(0)
+				e.Text = part.Text
+			}),
+			c.partEvent("response.content_part.done", block, &part),
+		)
+	} else {
+		block.item.Arguments = block.text.String()
+		if block.item.Arguments == "" {
+			block.item.Arguments = "{}"
+		}
+		events = append(events, c.itemEvent("response.function_call_arguments.done", block, func(e *ResponsesStreamEvent) {
+			e.Arguments = block.item.Arguments
+		}))
+	}
+
+	c.response.Output[block.outputIndex] = block.item
+	done := block.item
+	events = append(events, c.itemEvent("response.output_item.done", block, func(e *ResponsesStreamEvent) {
+		e.Item = &done
+	}))
+	return events
+}
+
+// responseEvent 构建携带完整 response 快照的事件
+func (c *ClaudeResponsesStreamConverter) responseEvent(eventType string) ResponsesStreamEvent {
+	snapshot := *c.response
+	snapshot.Output = append([]ResponsesOutputItem{}, c.response.Output...)
+	return ResponsesStreamEvent{Type: eventType, SequenceNumber: c.nextSequence(), Response: &snapshot}
+}
+
+// itemEvent 构建输出项相关事件
+func (c *ClaudeResponsesStreamConverter) itemEvent(eventType string, block *responsesStreamBlock, fill func(*ResponsesStreamEvent)) ResponsesStreamEvent {
+	event := ResponsesStreamEvent{
+		Type:           eventType,
+		SequenceNumber: c.nextSequence(),
+		OutputIndex:    intPtr(block.outputIndex),
+		ItemID:         block.item.ID,
+	}
+	fill(&event)
+	return event
+}
+
+// partEvent 构建内容块相关事件
+func (c *ClaudeResponsesStreamConverter) partEvent(eventType string, block *responsesStreamBlock, part *ResponsesOutputContent) ResponsesStreamEvent {
+	return c.itemEvent(eventType, block, func(e *ResponsesStreamEvent) {
+		e.ContentIndex = intPtr(0)
+		e.Part = part
+	})
+}
+
+// nextSequence 返回下一个事件序号
+func (c *ClaudeResponsesStreamConverter) nextSequence() int {
+	seq := c.sequence
+	c.sequence++
+	return seq
+}
+
+// intPtr 返回 int 指针
+func intPtr(i int) *int {
+	return &i
+}
@@ -0,0 +1,231 @@
+package converter
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// 测试 Responses→Claude 请求：instructions 与 developer 消息、内容块、函数调用配对、工具与 tool_choice
+func TestConvertResponsesToClaude(t *testing.T) {
+	raw := `{
+		"model": "gpt-4o",
+		"instructions": "Be brief.",
+		"input": [
+			{"role": "developer", "content": "Use tools."},
+			{"role": "user", "content": [
+				{"type": "input_text", "text": "Look at this"},
+				{"type": "input_image", "image_url": "data:image/png;base64,iVBORw0KGgo="}
+			]},
+			{"type": "reasoning", "id": "rs_1", "summary": []},
+			{"type": "message", "role": "assistant", "content": [{"type": "output_text", "text": "Checking."}]},
+			{"type": "function_call", "call_id": "call_1", "name": "lookup", "arguments": "{\"q\":\"cat\"}"},
+			{"type": "function_call_output", "call_id": "call_1", "output": [{"type": "input_text", "text": "a cat"}]},
+			{"role": "user", "content": "thanks"}
+		],
+		"tools": [
+			{"type": "function", "name": "lookup", "description": "Search", "parameters": {"type": "object", "properties": {"q": {"type": "string"}}}},
+			{"type": "function", "name": "now"},
+			{"type": "web_search"}
+		],
+		"tool_choice": {"type": "function", "name": "lookup"},
+		"max_output_tokens": 512,
+		"temperature": 0.3
+	}`
+
+	var req ResponsesRequest
+	if err := json.Unmarshal([]byte(raw), &req); err != nil {
+		t.Fatalf("failed to decode request: %v", err)
+	}
+	items, err := ParseResponsesInput(req.Input)
+	if err != nil {
+		t.Fatalf("failed to parse input: %v", err)
+	}
+
+	claudeReq, err := ConvertResponsesToClaude(&req, items)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if claudeReq.System.PlainText() != "Be brief.\nUse tools." {
+		t.Fatalf("unexpected system: %q", claudeReq.System.PlainText())
+	}
+	if claudeReq.MaxTokens != 512 || *claudeReq.Temperature != 0.3 {
+		t.Fatalf("unexpected generation params: %+v", claudeReq)
+	}
+	if len(claudeReq.Messages) != 3 {
+		t.Fatalf("expected 3 messages after merging, got %d: %+v", len(claudeReq.Messages), claudeReq.Messages)
+	}
+
+	user := claudeReq.Messages[0]
+	if user.Role != "user" || len(user.Content) != 2 || user.Content[1].Source.MediaType != "image/png" || user.Content[1].Source.Data != "iVBORw0KGgo=" {
+		t.Fatalf("unexpected first user message: %+v", user)
+	}
+
+	assistant := claudeReq.Messages[1]
+	if assistant.Role != "assistant" || len(assistant.Content) != 2 || assistant.Content[1].Type != ContentTypeToolUse {
+		t.Fatalf("expected reasoning skipped and text + tool_use merged, got %+v", assistant.Content)
+	}
+	if *assistant.Content[1].ID != "call_1" || assistant.Content[1].Input["q"] != "cat" {
+		t.Fatalf("unexpected tool_use: %+v", assistant.Content[1])
+	}
+
+	results := claudeReq.Messages[2].Content
+	if len(results) != 2 || *results[0].ToolUseID != "call_1" || results[0].Content.PlainText() != "a cat" || *results[1].Text != "thanks" {
+		t.Fatalf("unexpected tool_result message: %+v", results)
+	}
+
+	if len(claudeReq.Tools) != 2 || claudeReq.Tools[1].InputSchema["type"] != "object" {
+		t.Fatalf("expected built-in tools skipped and default schema, got %+v", claudeReq.Tools)
+	}
+	if claudeReq.ToolChoice == nil || claudeReq.ToolChoice.Type != "tool" || *claudeReq.ToolChoice.Name != "lookup" {
+		t.Fatalf("unexpected tool_choice: %+v", claudeReq.ToolChoice)
+	}
+}
+
+// 测试 Responses 请求中无法转换的内容
+func TestConvertResponsesToClaude_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"url image", `[{"role":"user","content":[{"type":"input_image","image_url":"https://example.com/a.png"}]}]`},
+		{"input file", `[{"role":"user","content":[{"type":"input_file","file_id":"file_1"}]}]`},
+		{"invalid arguments", `[{"type":"function_call","call_id":"call_1","name":"lookup","arguments":"{"}]`},
+		{"unknown item", `[{"type":"item_reference","id":"msg_1"}]`},
+		{"unknown role", `[{"role":"tool","content":"hi"}]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items, err := ParseResponsesInput(json.RawMessage(tt.input))
+			if err != nil {
+				t.Fatalf("failed to parse input: %v", err)
+			}
+			if _, err := ConvertResponsesToClaude(&ResponsesRequest{}, items); err == nil {
+				t.Fatal("expected conversion error")
+			}
+		})
+	}
+
+	if _, err := ParseResponsesInput(json.RawMessage(`42`)); err == nil {
+		t.Fatal("expected error for non-string, non-array input")
+	}
+}
+
+// 测试 Claude→Responses 非流式响应
+func TestConvertClaudeResponseToResponses(t *testing.T) {
+	resp := &ClaudeResponse{
+		ID:    "msg_1",
+		Model: "claude-sonnet-4-5",
+		Content: []ClaudeContentBlock{
+			{Type: "text", Text: StringPtr("Checking.")},
+			{Type: "text", Text: StringPtr(" One moment.")},
+			{Type: "tool_use", ID: StringPtr("toolu_1"), Name: StringPtr("lookup"), Input: map[string]interface{}{"q": "cat"}},
+		},
+		StopReason: "tool_use",
+		Usage:      ClaudeUsage{InputTokens: 10, OutputTokens: 5},
+	}
+
+	result, err := ConvertClaudeResponseToResponses(resp)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !strings.HasPrefix(result.ID, "resp_") || result.Object != "response" || result.Status != "completed" || result.Model != "claude-sonnet-4-5" {
+		t.Fatalf("unexpected response: %+v", result)
+	}
+	if len(result.Output) != 2 {
+		t.Fatalf("expected message and function_call items, got %+v", result.Output)
+	}
+	message := result.Output[0]
+	if message.Type != "message" || message.Role != "assistant" || len(message.Content) != 2 || message.Content[1].Text != " One moment." {
+		t.Fatalf("unexpected message item: %+v", message)
+	}
+	call := result.Output[1]
+	if call.Type != "function_call" || call.CallID != "toolu_1" || call.Arguments != `{"q":"cat"}` || !strings.HasPrefix(call.ID, "fc_") {
+		t.Fatalf("unexpected function_call item: %+v", call)
+	}
+	if result.Usage.TotalTokens != 15 {
+		t.Fatalf("unexpected usage: %+v", result.Usage)
+	}
+
+	resp.StopReason = "max_tokens"
+	result, _ = ConvertClaudeResponseToResponses(resp)
+	if result.Status != "incomplete" || result.IncompleteDetails.Reason != "max_output_tokens" {
+		t.Fatalf("expected incomplete response, got %+v", result)
+	}
+}
+
+// 测试 Claude 流式事件转换为 Responses 流式事件
+func TestClaudeResponsesStreamConverter(t *testing.T) {
+	claudeEvents := []string{
+		`{"type":"message_start","message":{"id":"msg_1","type":"message","role":"assistant","content":[],"model":"claude-sonnet-4-5","usage":{"input_tokens":7,"output_tokens":0}}}`,
+		`{"type":"content_block_start","index":0,"content_block":{"type":"thinking","thinking":""}}`,
+		`{"type":"content_block_delta","index":0,"delta":{"type":"thinking_delta","thinking":"hmm"}}`,
+		`{"type":"content_block_stop","index":0}`,
+		`{"type":"content_block_start","index":1,"content_block":{"type":"text","text":""}}`,
+		`{"type":"content_block_delta","index":1,"delta":{"type":"text_delta","text":"Hel"}}`,
+		`{"type":"content_block_delta","index":1,"delta":{"type":"text_delta","text":"lo"}}`,
+		`{"type":"content_block_stop","index":1}`,
+		`{"type":"content_block_start","index":2,"content_block":{"type":"tool_use","id":"toolu_1","name":"lookup","input":{}}}`,
+		`{"type":"content_block_delta","index":2,"delta":{"type":"input_json_delta","partial_json":"{\"q\":"}}`,
+		`{"type":"content_block_delta","index":2,"delta":{"type":"input_json_delta","partial_json":"\"cat\"}"}}`,
+		`{"type":"content_block_stop","index":2}`,
+		`{"type":"message_delta","delta":{"stop_reason":"tool_use"},"usage":{"output_tokens":4}}`,
+		`{"type":"message_stop"}`,
+	}
+
+	converter := NewClaudeResponsesStreamConverter("resp_prev", nil)
+	var events []ResponsesStreamEvent
+	for _, data := range claudeEvents {
+		out, err := converter.ProcessEvent([]byte(data))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		events = append(events, out...)
+	}
+
+	expected := []string{
+		"response.created", "response.in_progress",
+		"response.output_item.added", "response.content_part.added",
+		"response.output_text.delta", "response.output_text.delta",
+		"response.output_text.done", "response.content_part.done", "response.output_item.done",
+		"response.output_item.added",
+		"response.function_call_arguments.delta", "response.function_call_arguments.delta",
+		"response.function_call_arguments.done", "response.output_item.done",
+		"response.completed",
+	}
+	if len(events) != len(expected) {
+		t.Fatalf("expected %d events, got %d: %+v", len(expected), len(events), events)
+	}
+	for i, event := range events {
+		if event.Type != expected[i] || event.SequenceNumber != i {
+			t.Fatalf("event %d: expected %s/%d, got %s/%d", i, expected[i], i, event.Type, event.SequenceNumber)
+		}
+	}
+
+	if events[0].Response.Status != "in_progress" || *events[0].Response.PreviousResponseID != "resp_prev" {
+		t.Fatalf("unexpected response.created: %+v", events[0].Response)
+	}
+	if events[6].Text != "Hello" || *events[9].OutputIndex != 1 || events[12].Arguments != `{"q":"cat"}` {
+		t.Fatalf("unexpected done events: %+v %+v %+v", events[6], events[9], events[12])
+	}
+
+	final := events[len(events)-1].Response
+	if final.Status != "completed" || len(final.Output) != 2 || final.Output[0].Content[0].Text != "Hello" ||
+		final.Output[1].CallID != "toolu_1" || final.Usage.InputTokens != 7 || final.Usage.OutputTokens != 4 {
+		t.Fatalf("unexpected final response: %+v", final)
+	}
+	if !converter.Finished() || converter.Response().ID != final.ID {
+		t.Fatal("expected converter to expose the final response")
+	}
+
+	if _, err := NewClaudeResponsesStreamConverter("", nil).ProcessEvent([]byte(`{"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`)); err == nil || !strings.Contains(err.Error(), "Overloaded") {
+		t.Fatalf("expected upstream error, got %v", err)
+	}
+	failed := converter.Failed("boom")
+	if failed.Type != "response.failed" || failed.Response.Status != "failed" || failed.Response.Error.Message != "boom" {
+		t.Fatalf("unexpected response.failed event: %+v", failed)
+	}
+}
@@ -3,80 +3,217 @@ package converter
 import (
 	"bufio"
 	"bytes"
+	"errors"
 	"io"
+	"strconv"
 	"strings"
+	"time"
 )
 
+// MaxSSEEventSize 单个 SSE 事件（含未结束的行）允许缓冲的最大字节数
+// 超出时返回 ErrSSEEventTooLarge，避免异常上游耗尽内存
+const MaxSSEEventSize = 8 << 20
+
+// ErrSSEEventTooLarge 单个事件超出缓冲上限
+var ErrSSEEventTooLarge = errors.New("sse event exceeds size limit")
+
+// SSEEvent 解析出的 SSE 事件
+type SSEEvent struct {
+	Event string // event 字段；未指定时为空，按规范视为 message
+	Data  string // 多行 data 以 \n 拼接
+	ID    string // 最近一次 id 字段的值，跨事件保持
+}
+
 // SSEParser SSE (Server-Sent Events) 事件解析器
+// 按 WHATWG HTML 规范的事件流格式逐行解析：
+//   - 行以 CRLF、LF 或单独的 CR 结尾，首行的 UTF-8 BOM 会被忽略
+//   - "field:value" 中冒号后的单个空格会被去除，没有冒号的行视为值为空的字段
+//   - 以冒号开头的注释行（如 ": ping" 心跳）被忽略
+//   - 空行派发事件，不含 data 的事件（如只有 event: ping）不派发
+//
+// 与规范不同的是，流在缺少结尾空行时结束，最后一个事件仍会派发
+// 既可用 Next 从 io.Reader 拉取事件，也可用 Feed 推入数据（如包装 ResponseWriter 时），两者不能混用
 type SSEParser struct {
-	scanner *bufio.Scanner
+	reader      *bufio.Reader
+	maxSize     int
+	line        bytes.Buffer
+	skipLF      bool // 上一行以 CR 结尾，紧随的 LF 属于同一个换行
+	started     bool
+	lastEventID string
+	retry       time.Duration
+
+	// 正在解析、尚未派发的事件
+	eventType string
+	data      strings.Builder
+	hasData   bool
 }
 
 // NewSSEParser 创建 SSE 解析器
 func NewSSEParser(r io.Reader) *SSEParser {
-	scanner := bufio.NewScanner(r)
-	// 自定义分隔函数：以双换行为分隔符
-	scanner.Split(splitSSEEvent)
 	return &SSEParser{
-		scanner: scanner,
+		reader:  bufio.NewReader(r),
+		maxSize: MaxSSEEventSize,
 	}
 }
 
-// ParseEvent 解析下一个 SSE 事件
-// 返回事件数据，或 io.EOF 表示流结束
-func (p *SSEParser) ParseEvent() (string, error) {
-	if !p.scanner.Scan() {
-		if err := p.scanner.Err(); err != nil {
-			return "", err
+// NewSSEFeedParser 创建通过 Feed 推入数据的 SSE 解析器
+func NewSSEFeedParser() *SSEParser {
+	return &SSEParser{maxSize: MaxSSEEventSize}
+}
+
+// Next 解析下一个事件，流结束时返回 io.EOF
+func (p *SSEParser) Next() (*SSEEvent, error) {
+	for {
+		b, err := p.reader.ReadByte()
+		if err == io.EOF {
+			if event := p.Flush(); event != nil {
+				return event, nil
+			}
+			return nil, io.EOF
+		}
+		if err != nil {
+			return nil, err
 		}
-		return "", io.EOF
-	}
 
-	eventText := p.scanner.Text()
-	if eventText == "" {
-		// 空事件，继续读取下一个
-		return p.ParseEvent()
+		line, ok, err := p.feedByte(b)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		if event := p.processLine(line); event != nil {
+			return event, nil
+		}
 	}
+}
 
-	// 解析事件内容
-	lines := strings.Split(eventText, "\n")
-	var data strings.Builder
-
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line == "" {
+// Feed 推入一段流数据，返回其中已完整的事件
+// 未结束的行与事件保留到下次调用；单个事件超出上限时返回 ErrSSEEventTooLarge
+func (p *SSEParser) Feed(data []byte) ([]*SSEEvent, error) {
+	var events []*SSEEvent
+	for _, b := range data {
+		line, ok, err := p.feedByte(b)
+		if err != nil {
+			return events, err
+		}
+		if !ok {
 			continue
 		}
-
-		// 处理 data: 行
-		if strings.HasPrefix(line, "data: ") {
-			content := strings.TrimPrefix(line, "data: ")
-			data.WriteString(content)
+		if event := p.processLine(line); event != nil {
+			events = append(events, event)
 		}
-		// 忽略 event:, id:, retry: 等字段（Claude API 不需要）
 	}
+	return events, nil
+}
 
-	return data.String(), nil
+// Flush 流结束时派发缺少结尾空行的最后一个事件，没有时返回 nil
+func (p *SSEParser) Flush() *SSEEvent {
+	if p.line.Len() > 0 {
+		p.processLine(p.takeLine())
+	}
+	return p.dispatch()
 }
 
-// splitSSEEvent 自定义分隔函数，以双换行为事件分隔符
-func splitSSEEvent(data []byte, atEOF bool) (advance int, token []byte, err error) {
-	// 查找双换行
-	delimiter := []byte("\n\n")
-	if i := bytes.Index(data, delimiter); i >= 0 {
-		// 找到分隔符，返回事件数据
-		return i + len(delimiter), data[0:i], nil
+// ParseEvent 解析下一个 SSE 事件
+// 返回事件数据，或 io.EOF 表示流结束
+func (p *SSEParser) ParseEvent() (string, error) {
+	event, err := p.Next()
+	if err != nil {
+		return "", err
 	}
+	return event.Data, nil
+}
+
+// LastEventID 返回最近一次 id 字段的值
+func (p *SSEParser) LastEventID() string {
+	return p.lastEventID
+}
+
+// Retry 返回上游通过 retry 字段建议的重连间隔，未指定时为 0
+func (p *SSEParser) Retry() time.Duration {
+	return p.retry
+}
 
-	// 如果到达 EOF
-	if atEOF {
-		if len(data) > 0 {
-			// 返回剩余数据
-			return len(data), data, nil
+// feedByte 处理一个字节，行结束时返回该行（不含换行符）
+// 以 CR 结尾的行不等待下一个字节，避免实时流中的事件被延迟
+func (p *SSEParser) feedByte(b byte) (string, bool, error) {
+	if p.skipLF {
+		p.skipLF = false
+		if b == '\n' {
+			return "", false, nil
 		}
-		return 0, nil, nil
+	}
+	switch b {
+	case '\n':
+		return p.takeLine(), true, nil
+	case '\r':
+		p.skipLF = true
+		return p.takeLine(), true, nil
+	}
+	if p.line.Len() >= p.maxSize-p.data.Len() {
+		return "", false, ErrSSEEventTooLarge
+	}
+	p.line.WriteByte(b)
+	return "", false, nil
+}
+
+// processLine 处理一行，空行结束事件时返回该事件
+func (p *SSEParser) processLine(line string) *SSEEvent {
+	if line == "" {
+		// 没有 data 的事件不派发，dispatch 同时丢弃已读取的字段
+		return p.dispatch()
+	}
+	if line[0] == ':' {
+		return nil
 	}
 
-	// 请求更多数据
-	return 0, nil, nil
+	field, value, found := strings.Cut(line, ":")
+	if found {
+		value = strings.TrimPrefix(value, " ")
+	}
+	switch field {
+	case "event":
+		p.eventType = value
+	case "data":
+		if p.hasData {
+			p.data.WriteByte('\n')
+		}
+		p.data.WriteString(value)
+		p.hasData = true
+	case "id":
+		if !strings.ContainsRune(value, 0) {
+			p.lastEventID = value
+		}
+	case "retry":
+		if value != "" && strings.Trim(value, "0123456789") == "" {
+			if ms, err := strconv.ParseInt(value, 10, 64); err == nil {
+				p.retry = time.Duration(ms) * time.Millisecond
+			}
+		}
+	}
+	return nil
+}
+
+// dispatch 取出正在解析的事件并重置状态，不含 data 时返回 nil
+func (p *SSEParser) dispatch() *SSEEvent {
+	var event *SSEEvent
+	if p.hasData {
+		event = &SSEEvent{Event: p.eventType, Data: p.data.String(), ID: p.lastEventID}
+	}
+	p.eventType = ""
+	p.data.Reset()
+	p.hasData = false
+	return event
+}
+
+// takeLine 取出当前行，首行去除 UTF-8 BOM
+func (p *SSEParser) takeLine() string {
+	line := p.line.String()
+	p.line.Reset()
+	if !p.started {
+		p.started = true
+		line = strings.TrimPrefix(line, "\uFEFF")
+	}
+	return line
 }
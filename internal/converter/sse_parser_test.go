@@ -0,0 +1,231 @@
+package converter
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// collectSSEEvents 读取全部事件直到 EOF 或出错
+func collectSSEEvents(parser *SSEParser) ([]SSEEvent, error) {
+	var events []SSEEvent
+	for {
+		event, err := parser.Next()
+		if err == io.EOF {
+			return events, nil
+		}
+		if err != nil {
+			return events, err
+		}
+		events = append(events, *event)
+	}
+}
+
+// TestSSEParser_Spec 测试规范中的字段、换行与派发规则
+func TestSSEParser_Spec(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []SSEEvent
+	}{
+		{"event name", "event: message_start\ndata: {}\n\n", []SSEEvent{{Event: "message_start", Data: "{}"}}},
+		{"no space after colon", "event:ping\ndata:{}\n\n", []SSEEvent{{Event: "ping", Data: "{}"}}},
+		{"only one space stripped", "data:  x \n\n", []SSEEvent{{Data: " x "}}},
+		{"multi-line data", "data: line1\ndata: line2\ndata\n\n", []SSEEvent{{Data: "line1\nline2\n"}}},
+		{"crlf", "event: a\r\ndata: 1\r\n\r\ndata: 2\r\n\r\n", []SSEEvent{{Event: "a", Data: "1"}, {Data: "2"}}},
+		{"bare cr", "data: 1\r\rdata: 2\r\r", []SSEEvent{{Data: "1"}, {Data: "2"}}},
+		{"comments and keep-alive", ": ping\n\n:\n\ndata: x\n: inline comment\n\n", []SSEEvent{{Data: "x"}}},
+		{"event without data", "event: ping\n\ndata: x\n\n", []SSEEvent{{Data: "x"}}},
+		{"id persists", "id: 1\ndata: a\n\ndata: b\n\nid\ndata: c\n\n", []SSEEvent{{Data: "a", ID: "1"}, {Data: "b", ID: "1"}, {Data: "c"}}},
+		{"id with null ignored", "id: 1\ndata: a\n\nid: 2\x003\ndata: b\n\n", []SSEEvent{{Data: "a", ID: "1"}, {Data: "b", ID: "1"}}},
+		{"unknown fields ignored", "foo: bar\ndata: x\n\n", []SSEEvent{{Data: "x"}}},
+		{"bom", "\uFEFFdata: x\n\n", []SSEEvent{{Data: "x"}}},
+		{"missing final blank line", "data: a\n\ndata: b", []SSEEvent{{Data: "a"}, {Data: "b"}}},
+		{"empty stream", "", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events, err := collectSSEEvents(NewSSEParser(strings.NewReader(tt.input)))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(events, tt.want) {
+				t.Errorf("expected %+v, got %+v", tt.want, events)
+			}
+		})
+	}
+}
+
+// TestSSEParser_Retry 测试 retry 字段只接受纯数字
+func TestSSEParser_Retry(t *testing.T) {
+	parser := NewSSEParser(strings.NewReader("retry: 1500\ndata: a\n\nretry: 2s\ndata: b\n\n"))
+	if _, err := collectSSEEvents(parser); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if parser.Retry() != 1500*time.Millisecond {
+		t.Errorf("expected 1.5s retry, got %v", parser.Retry())
+	}
+}
+
+// TestSSEParser_KeepAliveFlood 测试大量心跳不会导致递归或缓冲增长
+func TestSSEParser_KeepAliveFlood(t *testing.T) {
+	input := strings.Repeat(": ping\n\n\n", 200000) + "data: x\n\n"
+	events, err := collectSSEEvents(NewSSEParser(strings.NewReader(input)))
+	if err != nil || len(events) != 1 || events[0].Data != "x" {
+		t.Fatalf("unexpected result: %+v %v", events, err)
+	}
+}
+
+// TestSSEParser_SizeLimit 测试超出缓冲上限的事件返回错误
+func TestSSEParser_SizeLimit(t *testing.T) {
+	for _, input := range []string{
+		"data: " + strings.Repeat("a", 64) + "\n\n",
+		strings.Repeat("data: aaaaaaaa\n", 16) + "\n",
+	} {
+		parser := NewSSEParser(strings.NewReader(input))
+		parser.maxSize = 32
+		if _, err := parser.Next(); !errors.Is(err, ErrSSEEventTooLarge) {
+			t.Errorf("expected ErrSSEEventTooLarge, got %v", err)
+		}
+	}
+
+	parser := NewSSEParser(strings.NewReader(strings.Repeat("data: aaaaaaaa\n\n", 16)))
+	parser.maxSize = 32
+	if events, err := collectSSEEvents(parser); err != nil || len(events) != 16 {
+		t.Errorf("events within the limit should parse: %d %v", len(events), err)
+	}
+}
+
+// TestSSEParser_ChunkedReads 测试跨读取边界拆分的 CRLF
+func TestSSEParser_ChunkedReads(t *testing.T) {
+	input := "event: a\r\ndata: 1\r\n\r\ndata: 2\r\n\r\n"
+	events, err := collectSSEEvents(NewSSEParser(&oneByteReader{data: []byte(input)}))
+	want := []SSEEvent{{Event: "a", Data: "1"}, {Data: "2"}}
+	if err != nil || !reflect.DeepEqual(events, want) {
+		t.Fatalf("expected %+v, got %+v %v", want, events, err)
+	}
+}
+
+// TestSSEParser_Feed 测试逐字节推入的数据与一次性读取结果一致，未结束的事件由 Flush 派发
+func TestSSEParser_Feed(t *testing.T) {
+	input := "\uFEFF: ping\revent: a\r\ndata: 1\r\ndata: 2\r\rid: x\ndata: 3"
+	want := []SSEEvent{{Event: "a", Data: "1\n2"}, {Data: "3", ID: "x"}}
+
+	parser := NewSSEFeedParser()
+	var events []SSEEvent
+	for i := 0; i < len(input); i++ {
+		fed, err := parser.Feed([]byte{input[i]})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		for _, event := range fed {
+			events = append(events, *event)
+		}
+	}
+	if event := parser.Flush(); event != nil {
+		events = append(events, *event)
+	}
+	if !reflect.DeepEqual(events, want) {
+		t.Fatalf("expected %+v, got %+v", want, events)
+	}
+	if event := parser.Flush(); event != nil {
+		t.Fatalf("flush after end should be empty, got %+v", event)
+	}
+
+	parser = NewSSEFeedParser()
+	parser.maxSize = 32
+	if _, err := parser.Feed([]byte("data: " + strings.Repeat("a", 64))); !errors.Is(err, ErrSSEEventTooLarge) {
+		t.Fatalf("expected ErrSSEEventTooLarge, got %v", err)
+	}
+}
+
+// oneByteReader 每次只返回一个字节
+type oneByteReader struct {
+	data []byte
+}
+
+func (r *oneByteReader) Read(p []byte) (int, error) {
+	if len(r.data) == 0 {
+		return 0, io.EOF
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+	p[0] = r.data[0]
+	r.data = r.data[1:]
+	return 1, nil
+}
+
+// FuzzSSEParser 任意输入都应在有限步内结束，且不产生超出上限或含 CR 的数据
+func FuzzSSEParser(f *testing.F) {
+	for _, seed := range []string{
+		"data: x\n\n",
+		"event: a\r\ndata: 1\r\n\r\n",
+		"data: 1\r\rdata: 2",
+		": ping\n\nid: 1\nretry: 10\ndata\n\n",
+		"\uFEFFdata:x\n",
+		"data: " + strings.Repeat("a", 100),
+	} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, input string) {
+		parser := NewSSEParser(strings.NewReader(input))
+		parser.maxSize = 64
+		for i := 0; ; i++ {
+			if i > len(input) {
+				t.Fatalf("more events than input bytes for %q", input)
+			}
+			event, err := parser.Next()
+			if err != nil {
+				if err != io.EOF && !errors.Is(err, ErrSSEEventTooLarge) {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if len(event.Data) > parser.maxSize {
+				t.Fatalf("event data exceeds limit: %d", len(event.Data))
+			}
+			if strings.ContainsRune(event.Data, '\r') || strings.ContainsRune(event.Event, '\r') || strings.ContainsRune(event.Event, '\n') {
+				t.Fatalf("line terminator leaked into event: %+v", event)
+			}
+		}
+	})
+}
+
+// FuzzSSEParser_RoundTrip 按规范编码的事件应原样解析回来，与换行风格无关
+func FuzzSSEParser_RoundTrip(f *testing.F) {
+	f.Add("message_start", "{\"type\":\"message_start\"}", uint8(0))
+	f.Add("", "line1\nline2", uint8(1))
+	f.Add("ping", " leading space", uint8(2))
+
+	f.Fuzz(func(t *testing.T, name, data string, style uint8) {
+		if strings.ContainsAny(name, "\r\n") || strings.ContainsRune(data, '\r') {
+			t.Skip()
+		}
+		newline := []string{"\n", "\r\n", "\r"}[style%3]
+
+		var stream strings.Builder
+		stream.WriteString(": keep-alive" + newline + newline)
+		if name != "" {
+			stream.WriteString("event: " + name + newline)
+		}
+		for _, line := range strings.Split(data, "\n") {
+			stream.WriteString("data: " + line + newline)
+		}
+		stream.WriteString(newline)
+
+		events, err := collectSSEEvents(NewSSEParser(strings.NewReader(stream.String())))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		want := []SSEEvent{{Event: name, Data: data}}
+		if !reflect.DeepEqual(events, want) {
+			t.Fatalf("expected %+v, got %+v (stream %q)", want, events, stream.String())
+		}
+	})
+}
@@ -19,15 +19,21 @@ type StreamConverter struct {
 	currentIndex    int                    // 当前 content block 索引
 	messageStarted  bool                   // 是否已发送 message_start
 	blockStarted    bool                   // 当前块是否已开始
-	currentBlockType string                // 当前块类型 ("text" | "tool_use")
+	currentBlockType string                // 当前块类型 ("thinking" | "text" | "tool_use")
+	stopReason      string                 // 已收到的 stop_reason，结束时的 message_delta 重复携带
 
 	// 累积状态
 	textBuffer      strings.Builder        // 文本缓冲区
+	thinkingBuffer  strings.Builder        // 当前 thinking 块内容（用于生成签名）
 	toolCallsBuffer map[int]*ToolCallState // tool calls 缓冲区
+	currentToolCall *ToolCallState         // 当前 tool_use 块对应的 tool call
+
+	// 选项
+	bufferToolArguments bool                 // 缓冲工具调用参数，块结束时修复后一次性输出
+	toolArguments       ToolArgumentRecorder // 参数修复结果的记录者，可为 nil
 
 	// 统计
-	inputTokens  int
-	outputTokens int
+	usage ClaudeUsage // 上游最近一次报告的累计用量
 }
 
 // ToolCallState tool call 累积状态
@@ -37,6 +43,15 @@ type ToolCallState struct {
 	Arguments strings.Builder
 }
 
+// StreamOptions OpenAI→Claude 流式转换选项
+type StreamOptions struct {
+	// BufferToolArguments 不再逐个转发工具调用参数增量，而是在 tool_use 块结束时修复完整参数，
+	// 以单个 input_json_delta 输出；用于参数 JSON 不可靠的上游
+	BufferToolArguments bool
+	// ToolArguments 记录缓冲参数的修复结果，为 nil 时不记录
+	ToolArguments ToolArgumentRecorder
+}
+
 // NewStreamConverter 创建流式转换器
 func NewStreamConverter() *StreamConverter {
 	return &StreamConverter{
@@ -60,18 +75,25 @@ func (c *StreamConverter) ResetState() {
 	c.messageStarted = false
 	c.blockStarted = false
 	c.currentBlockType = ""
+	c.stopReason = ""
 
 	// 重置累积状态
 	c.textBuffer.Reset()
+	c.thinkingBuffer.Reset()
 	c.toolCallsBuffer = make(map[int]*ToolCallState)
+	c.currentToolCall = nil
 
 	// 重置统计
-	c.inputTokens = 0
-	c.outputTokens = 0
+	c.usage = ClaudeUsage{}
 }
 
 // ConvertStream 转换 OpenAI 流式响应为 Claude 流式响应
 func ConvertStream(ctx context.Context, openaiStream io.Reader) (io.Reader, error) {
+	return ConvertStreamWithOptions(ctx, openaiStream, StreamOptions{})
+}
+
+// ConvertStreamWithOptions 按转换选项将 OpenAI 流式响应转换为 Claude 流式响应
+func ConvertStreamWithOptions(ctx context.Context, openaiStream io.Reader, opts StreamOptions) (io.Reader, error) {
 	// 创建管道用于零拷贝传输
 	pipeReader, pipeWriter := io.Pipe()
 
@@ -80,6 +102,8 @@ func ConvertStream(ctx context.Context, openaiStream io.Reader) (io.Reader, erro
 		defer pipeWriter.Close()
 
 		converter := NewStreamConverter()
+		converter.bufferToolArguments = opts.BufferToolArguments
+		converter.toolArguments = opts.ToolArguments
 		parser := NewSSEParser(openaiStream)
 
 		for {
@@ -97,8 +121,10 @@ func ConvertStream(ctx context.Context, openaiStream io.Reader) (io.Reader, erro
 				// 流结束，发送 message_stop
 				if converter.blockStarted {
 					// 关闭当前块
-					if event, err := converter.emitContentBlockStop(); err == nil {
-						pipeWriter.Write([]byte(event))
+					if events, err := converter.closeCurrentBlock(); err == nil {
+						for _, event := range events {
+							pipeWriter.Write([]byte(event))
+						}
 					}
 				}
 				if event, err := converter.emitMessageDelta(""); err == nil {
@@ -118,8 +144,10 @@ func ConvertStream(ctx context.Context, openaiStream io.Reader) (io.Reader, erro
 			if eventData == "[DONE]" {
 				// 关闭当前块
 				if converter.blockStarted {
-					if event, err := converter.emitContentBlockStop(); err == nil {
-						pipeWriter.Write([]byte(event))
+					if events, err := converter.closeCurrentBlock(); err == nil {
+						for _, event := range events {
+							pipeWriter.Write([]byte(event))
+						}
 					}
 				}
 				// 发送 message_delta 和 message_stop
@@ -173,6 +201,12 @@ func (c *StreamConverter) processChunk(chunk *OpenAIStreamChunk) ([]string, erro
 		c.created = chunk.Created
 	}
 
+	// usage 为累计值：开启 stream_options.include_usage 时 OpenAI 在 choices 为空的末尾 chunk 中发送，
+	// DeepSeek 等上游随 finish_reason 所在 chunk 发送
+	if chunk.Usage != nil {
+		c.usage = claudeUsageFromOpenAI(*chunk.Usage)
+	}
+
 	// 处理第一个 choice
 	if len(chunk.Choices) == 0 {
 		return events, nil
@@ -196,8 +230,45 @@ func (c *StreamConverter) processChunk(chunk *OpenAIStreamChunk) ([]string, erro
 		c.messageStarted = true
 	}
 
+	// 处理推理内容，转换为 thinking 块
+	if reasoning := reasoningFromMessage(delta.ReasoningContent, delta.Reasoning); reasoning != "" {
+		if !c.blockStarted || c.currentBlockType != ContentTypeThinking {
+			if c.blockStarted {
+				closeEvents, err := c.closeCurrentBlock()
+				if err != nil {
+					return nil, err
+				}
+				events = append(events, closeEvents...)
+			}
+			c.currentIndex++
+			c.currentBlockType = ContentTypeThinking
+			event, err := c.emitContentBlockStart(ContentTypeThinking)
+			if err != nil {
+				return nil, err
+			}
+			events = append(events, event)
+			c.blockStarted = true
+		}
+
+		c.thinkingBuffer.WriteString(reasoning)
+		event, err := c.emitThinkingDelta(reasoning)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+
 	// 处理文本内容
 	if delta.Content != "" {
+		// thinking 块进行中时先结束（发送签名）
+		if c.blockStarted && c.currentBlockType == ContentTypeThinking {
+			closeEvents, err := c.closeCurrentBlock()
+			if err != nil {
+				return nil, err
+			}
+			events = append(events, closeEvents...)
+		}
+
 		// 如果当前块未开始，先开始文本块
 		if !c.blockStarted {
 			c.currentIndex++
@@ -228,14 +299,13 @@ func (c *StreamConverter) processChunk(chunk *OpenAIStreamChunk) ([]string, erro
 			state, exists := c.toolCallsBuffer[index]
 			if !exists {
 				// 新的 tool call
-				// 如果有文本块在进行，先关闭
-				if c.blockStarted && c.currentBlockType == "text" {
-					event, err := c.emitContentBlockStop()
+				// 如果有文本、thinking 或上一个 tool_use 块在进行，先关闭
+				if c.blockStarted {
+					closeEvents, err := c.closeCurrentBlock()
 					if err != nil {
 						return nil, err
 					}
-					events = append(events, event)
-					c.blockStarted = false
+					events = append(events, closeEvents...)
 				}
 
 				// 创建新状态
@@ -244,6 +314,7 @@ func (c *StreamConverter) processChunk(chunk *OpenAIStreamChunk) ([]string, erro
 					Name: toolCall.Function.Name,
 				}
 				c.toolCallsBuffer[index] = state
+				c.currentToolCall = state
 
 				// 开始新的 tool_use 块
 				c.currentIndex++
@@ -259,6 +330,9 @@ func (c *StreamConverter) processChunk(chunk *OpenAIStreamChunk) ([]string, erro
 			// 累积参数
 			if toolCall.Function != nil && toolCall.Function.Arguments != "" {
 				state.Arguments.WriteString(toolCall.Function.Arguments)
+				if c.bufferToolArguments {
+					continue
+				}
 
 				// 发送参数增量
 				event, err := c.emitToolUseDelta(toolCall.Function.Arguments)
@@ -274,12 +348,11 @@ func (c *StreamConverter) processChunk(chunk *OpenAIStreamChunk) ([]string, erro
 	if choice.FinishReason != nil && *choice.FinishReason != "" {
 		// 关闭当前块
 		if c.blockStarted {
-			event, err := c.emitContentBlockStop()
+			closeEvents, err := c.closeCurrentBlock()
 			if err != nil {
 				return nil, err
 			}
-			events = append(events, event)
-			c.blockStarted = false
+			events = append(events, closeEvents...)
 		}
 
 		// 转换 finish_reason
@@ -308,8 +381,10 @@ func (c *StreamConverter) emitMessageStart() (string, error) {
 			Model:      c.model,
 			StopReason: nil,
 			Usage: ClaudeUsage{
-				InputTokens:  c.inputTokens,
-				OutputTokens: 0,
+				InputTokens:              c.usage.InputTokens,
+				OutputTokens:             0,
+				CacheCreationInputTokens: c.usage.CacheCreationInputTokens,
+				CacheReadInputTokens:     c.usage.CacheReadInputTokens,
 			},
 		},
 	}
@@ -320,11 +395,17 @@ func (c *StreamConverter) emitMessageStart() (string, error) {
 // emitContentBlockStart 发送 content_block_start 事件
 func (c *StreamConverter) emitContentBlockStart(blockType string) (string, error) {
 	var contentBlock ClaudeContentBlock
-	if blockType == "text" {
+	switch blockType {
+	case "text":
 		contentBlock = ClaudeContentBlock{
 			Type: "text",
 			Text: StringPtr(""),
 		}
+	case ContentTypeThinking:
+		contentBlock = ClaudeContentBlock{
+			Type:     ContentTypeThinking,
+			Thinking: StringPtr(""),
+		}
 	}
 
 	data := ClaudeContentBlockStart{
@@ -382,6 +463,83 @@ func (c *StreamConverter) emitToolUseDelta(partialJSON string) (string, error) {
 	return FormatSSEEvent("content_block_delta", data)
 }
 
+// emitThinkingDelta 发送 thinking_delta 事件
+func (c *StreamConverter) emitThinkingDelta(thinking string) (string, error) {
+	data := ClaudeContentBlockDelta{
+		Type:  "content_block_delta",
+		Index: c.currentIndex,
+		Delta: ClaudeDelta{
+			Type:     DeltaTypeThinkingDelta,
+			Thinking: thinking,
+		},
+	}
+
+	return FormatSSEEvent("content_block_delta", data)
+}
+
+// emitSignatureDelta 发送 signature_delta 事件
+func (c *StreamConverter) emitSignatureDelta(signature string) (string, error) {
+	data := ClaudeContentBlockDelta{
+		Type:  "content_block_delta",
+		Index: c.currentIndex,
+		Delta: ClaudeDelta{
+			Type:      DeltaTypeSignatureDelta,
+			Signature: signature,
+		},
+	}
+
+	return FormatSSEEvent("content_block_delta", data)
+}
+
+// closeCurrentBlock 结束当前块，thinking 块在 content_block_stop 前补发 signature_delta，
+// 缓冲参数的 tool_use 块补发修复后的完整参数
+func (c *StreamConverter) closeCurrentBlock() ([]string, error) {
+	var events []string
+	if c.currentBlockType == ContentTypeToolUse && c.bufferToolArguments && c.currentToolCall != nil {
+		if event, ok, err := c.emitBufferedToolArguments(c.currentToolCall); err != nil {
+			return nil, err
+		} else if ok {
+			events = append(events, event)
+		}
+		c.currentToolCall = nil
+	}
+	if c.currentBlockType == ContentTypeThinking {
+		event, err := c.emitSignatureDelta(ThinkingSignature(c.thinkingBuffer.String()))
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+		c.thinkingBuffer.Reset()
+	}
+
+	event, err := c.emitContentBlockStop()
+	if err != nil {
+		return nil, err
+	}
+	c.blockStarted = false
+	return append(events, event), nil
+}
+
+// emitBufferedToolArguments 修复缓冲的完整参数并以单个 input_json_delta 输出；无法修复时原样输出
+func (c *StreamConverter) emitBufferedToolArguments(state *ToolCallState) (string, bool, error) {
+	arguments := state.Arguments.String()
+	if strings.TrimSpace(arguments) == "" {
+		return "", false, nil
+	}
+	if input, kinds, err := DecodeToolArguments(arguments, c.toolArguments); err == nil && len(kinds) > 0 {
+		data, err := json.Marshal(input)
+		if err != nil {
+			return "", false, err
+		}
+		arguments = string(data)
+	}
+	event, err := c.emitToolUseDelta(arguments)
+	if err != nil {
+		return "", false, err
+	}
+	return event, true, nil
+}
+
 // emitContentBlockStop 发送 content_block_stop 事件
 func (c *StreamConverter) emitContentBlockStop() (string, error) {
 	data := ClaudeContentBlockStop{
@@ -394,20 +552,23 @@ func (c *StreamConverter) emitContentBlockStop() (string, error) {
 
 // emitMessageDelta 发送 message_delta 事件
 func (c *StreamConverter) emitMessageDelta(stopReason string) (string, error) {
+	if stopReason == "" {
+		stopReason = c.stopReason
+	}
+	c.stopReason = stopReason
+
 	var stopReasonPtr *string
 	if stopReason != "" {
 		stopReasonPtr = &stopReason
 	}
 
+	usage := c.usage
 	data := ClaudeMessageDelta{
 		Type: "message_delta",
 		Delta: ClaudeMessageDeltaData{
 			StopReason: stopReasonPtr,
 		},
-		Usage: &ClaudeUsage{
-			InputTokens:  c.inputTokens,
-			OutputTokens: c.outputTokens,
-		},
+		Usage: &usage,
 	}
 
 	return FormatSSEEvent("message_delta", data)
@@ -0,0 +1,272 @@
+package converter
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// StructuredOutputToolName 以工具调用模拟结构化输出时使用的工具名
+const StructuredOutputToolName = "structured_output"
+
+// StructuredOutput 以强制单工具调用模拟的结构化输出（用于没有 response_format 对应参数的 Claude 上游）
+// 工具的 input_schema 即请求的 schema，响应中该工具的调用被还原为 JSON 文本内容
+type StructuredOutput struct {
+	Name   string
+	Schema map[string]interface{}
+	Strict bool // 校验输出是否符合 schema，不符合时重试一次
+}
+
+// ResponseFormatFromResponses 将 Responses 的 text.format 转换为 OpenAI response_format
+func ResponseFormatFromResponses(text *ResponsesTextConfig) *OpenAIResponseFormat {
+	if text == nil || text.Format == nil {
+		return nil
+	}
+	format := text.Format
+	result := &OpenAIResponseFormat{Type: format.Type}
+	if format.Type == "json_schema" {
+		result.JSONSchema = &OpenAIJSONSchema{
+			Name:        format.Name,
+			Description: format.Description,
+			Schema:      format.Schema,
+			Strict:      format.Strict,
+		}
+	}
+	return result
+}
+
+// ResponseFormatFromGemini 将 Gemini 的 responseMimeType / responseSchema 转换为 OpenAI response_format
+func ResponseFormatFromGemini(config *GeminiGenerationConfig) *OpenAIResponseFormat {
+	if config == nil || config.ResponseMimeType != "application/json" {
+		return nil
+	}
+
+	schema := config.ResponseJSONSchema
+	if schema == nil && config.ResponseSchema != nil {
+		schema = normalizeGeminiSchema(config.ResponseSchema)
+	}
+	if schema == nil {
+		return &OpenAIResponseFormat{Type: "json_object"}
+	}
+	// Gemini 的 schema 约束是强制的，对应 strict
+	return &OpenAIResponseFormat{Type: "json_schema", JSONSchema: &OpenAIJSONSchema{
+		Name:   "response",
+		Schema: schema,
+		Strict: BoolPtr(true),
+	}}
+}
+
+// normalizeGeminiSchema 将 Gemini OpenAPI 子集 schema 的大写 type 转换为 JSON Schema 的小写形式
+func normalizeGeminiSchema(schema map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(schema))
+	for key, value := range schema {
+		switch v := value.(type) {
+		case string:
+			if key == "type" {
+				value = strings.ToLower(v)
+			}
+		case map[string]interface{}:
+			value = normalizeGeminiSchema(v)
+		case []interface{}:
+			items := make([]interface{}, len(v))
+			for i, item := range v {
+				if nested, ok := item.(map[string]interface{}); ok {
+					items[i] = normalizeGeminiSchema(nested)
+				} else {
+					items[i] = item
+				}
+			}
+			value = items
+		}
+		result[key] = value
+	}
+	return result
+}
+
+// EmulateResponseFormat 为 Claude 请求添加结构化输出工具，format 为空或 text 时返回 nil
+// 请求中没有其他工具且未开启 thinking 时强制调用该工具；否则只在 system 提示词中要求以该工具给出最终答案
+func EmulateResponseFormat(req *ClaudeRequest, format *OpenAIResponseFormat) (*StructuredOutput, error) {
+	if format == nil || format.Type == "" || format.Type == "text" {
+		return nil, nil
+	}
+
+	output := &StructuredOutput{Name: "json_object", Schema: map[string]interface{}{"type": "object"}}
+	description := "Return the final answer as a JSON object by calling this tool."
+	switch format.Type {
+	case "json_object":
+	case "json_schema":
+		if format.JSONSchema == nil {
+			return nil, fmt.Errorf("json_schema 响应格式缺少 json_schema")
+		}
+		if format.JSONSchema.Name != "" {
+			output.Name = format.JSONSchema.Name
+		}
+		if format.JSONSchema.Schema != nil {
+			output.Schema = format.JSONSchema.Schema
+		}
+		if schemaType, ok := output.Schema["type"]; ok && schemaType != "object" {
+			return nil, fmt.Errorf("json_schema 的根节点必须是 object，实际为 %v", schemaType)
+		}
+		output.Strict = format.JSONSchema.Strict != nil && *format.JSONSchema.Strict
+		description = fmt.Sprintf("Return the final answer by calling this tool. The input must conform to the %q schema.", output.Name)
+		if format.JSONSchema.Description != "" {
+			description += " " + format.JSONSchema.Description
+		}
+	default:
+		return nil, fmt.Errorf("不支持的响应格式: %s", format.Type)
+	}
+
+	for _, tool := range req.Tools {
+		if tool.Name == StructuredOutputToolName {
+			return nil, fmt.Errorf("工具名 %s 已被占用，无法模拟结构化输出", StructuredOutputToolName)
+		}
+	}
+
+	forced := len(req.Tools) == 0 && (req.Thinking == nil || req.Thinking.Type != "enabled")
+	// 显式声明 custom 类型，避免被转发前的 tools 清洗当作无效参数移除
+	req.Tools = append(req.Tools, ClaudeTool{
+		Type:        "custom",
+		Name:        StructuredOutputToolName,
+		Description: description,
+		InputSchema: output.Schema,
+	})
+	if forced {
+		req.ToolChoice = &ClaudeToolChoice{Type: "tool", Name: StringPtr(StructuredOutputToolName)}
+	} else {
+		req.System = AppendSystemText(req.System, fmt.Sprintf(
+			"When you have the final answer, call the %s tool with it instead of replying in plain text.", StructuredOutputToolName))
+	}
+	return output, nil
+}
+
+// findCall 返回响应中结构化输出工具的调用
+func (s *StructuredOutput) findCall(resp *ClaudeResponse) (ClaudeContentBlock, bool) {
+	for _, block := range resp.Content {
+		if block.Type == ContentTypeToolUse && block.Name != nil && *block.Name == StructuredOutputToolName {
+			return block, true
+		}
+	}
+	return ClaudeContentBlock{}, false
+}
+
+// Validate 校验响应中的结构化输出是否符合 schema
+func (s *StructuredOutput) Validate(resp *ClaudeResponse) error {
+	call, ok := s.findCall(resp)
+	if !ok {
+		return fmt.Errorf("响应未调用 %s 工具", StructuredOutputToolName)
+	}
+	var input interface{} = call.Input
+	if call.Input == nil {
+		input = map[string]interface{}{}
+	}
+	return ValidateJSONSchema(input, s.Schema)
+}
+
+// RetryRequest 基于校验失败的响应构建重试请求：回传本次输出并附上校验错误，要求模型重新调用工具
+func (s *StructuredOutput) RetryRequest(req *ClaudeRequest, resp *ClaudeResponse, violation error) *ClaudeRequest {
+	retry := *req
+	retry.Messages = append(append([]ClaudeMessage(nil), req.Messages...), ClaudeMessage{
+		Role:    ClaudeRoleAssistant,
+		Content: resp.Content,
+	})
+
+	feedback := fmt.Sprintf("The output does not match the %q schema: %v. Call the %s tool again with corrected input.",
+		s.Name, violation, StructuredOutputToolName)
+	if call, ok := s.findCall(resp); ok && call.ID != nil {
+		retry.Messages = append(retry.Messages, ClaudeMessage{Role: ClaudeRoleUser, Content: []ClaudeContentBlock{{
+			Type:      ContentTypeToolResult,
+			ToolUseID: call.ID,
+			Content:   NewToolResultText(feedback),
+			IsError:   BoolPtr(true),
+		}}})
+	} else {
+		retry.Messages = append(retry.Messages, ClaudeMessage{Role: ClaudeRoleUser, Content: []ClaudeContentBlock{{
+			Type: ContentTypeText,
+			Text: StringPtr(feedback),
+		}}})
+	}
+	return &retry
+}
+
+// UnwrapResponse 将结构化输出工具的调用还原为 JSON 文本内容
+// 没有其他工具调用时 stop_reason 由 tool_use 改为 end_turn
+func (s *StructuredOutput) UnwrapResponse(resp *ClaudeResponse) {
+	unwrapped := false
+	otherCalls := false
+	for i, block := range resp.Content {
+		if block.Type != ContentTypeToolUse {
+			continue
+		}
+		if block.Name == nil || *block.Name != StructuredOutputToolName {
+			otherCalls = true
+			continue
+		}
+		input := block.Input
+		if input == nil {
+			input = map[string]interface{}{}
+		}
+		data, _ := json.Marshal(input)
+		resp.Content[i] = ClaudeContentBlock{Type: ContentTypeText, Text: StringPtr(string(data))}
+		unwrapped = true
+	}
+	if unwrapped && !otherCalls && resp.StopReason == "tool_use" {
+		resp.StopReason = "end_turn"
+	}
+}
+
+// StructuredOutputStream 在 Claude SSE 事件中将结构化输出工具的调用还原为文本增量
+type StructuredOutputStream struct {
+	outputBlocks map[int]bool // 结构化输出工具对应的内容块索引
+	otherCalls   bool         // 是否调用了其他工具
+}
+
+// NewStream 创建流式还原器
+func (s *StructuredOutput) NewStream() *StructuredOutputStream {
+	return &StructuredOutputStream{outputBlocks: make(map[int]bool)}
+}
+
+// ProcessEvent 转换一个 Claude SSE 事件的 data，无需修改的事件原样返回
+func (s *StructuredOutputStream) ProcessEvent(data []byte) ([]byte, error) {
+	var event map[string]interface{}
+	if err := json.Unmarshal(data, &event); err != nil {
+		return nil, fmt.Errorf("解析 Claude 流式事件失败: %w", err)
+	}
+
+	index := -1
+	if value, ok := event["index"].(float64); ok {
+		index = int(value)
+	}
+
+	switch event["type"] {
+	case EventTypeContentBlockStart:
+		block, _ := event["content_block"].(map[string]interface{})
+		if block["type"] != ContentTypeToolUse {
+			return data, nil
+		}
+		if block["name"] != StructuredOutputToolName {
+			s.otherCalls = true
+			return data, nil
+		}
+		s.outputBlocks[index] = true
+		event["content_block"] = map[string]interface{}{"type": ContentTypeText, "text": ""}
+
+	case EventTypeContentBlockDelta:
+		delta, _ := event["delta"].(map[string]interface{})
+		if !s.outputBlocks[index] || delta["type"] != DeltaTypeInputJSONDelta {
+			return data, nil
+		}
+		event["delta"] = map[string]interface{}{"type": DeltaTypeTextDelta, "text": delta["partial_json"]}
+
+	case EventTypeMessageDelta:
+		delta, _ := event["delta"].(map[string]interface{})
+		if len(s.outputBlocks) == 0 || s.otherCalls || delta["stop_reason"] != "tool_use" {
+			return data, nil
+		}
+		delta["stop_reason"] = "end_turn"
+
+	default:
+		return data, nil
+	}
+
+	return json.Marshal(event)
+}
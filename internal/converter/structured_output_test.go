@@ -0,0 +1,256 @@
+package converter
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// personFormat 测试用的 json_schema 响应格式
+func personFormat(strict bool) *OpenAIResponseFormat {
+	return &OpenAIResponseFormat{Type: "json_schema", JSONSchema: &OpenAIJSONSchema{
+		Name: "person",
+		Schema: map[string]interface{}{
+			"type":                 "object",
+			"properties":           map[string]interface{}{"name": map[string]interface{}{"type": "string"}, "age": map[string]interface{}{"type": "integer", "minimum": float64(0)}},
+			"required":             []interface{}{"name", "age"},
+			"additionalProperties": false,
+		},
+		Strict: BoolPtr(strict),
+	}}
+}
+
+// 测试以强制工具调用模拟 response_format
+func TestEmulateResponseFormat(t *testing.T) {
+	req := &ClaudeRequest{Model: "claude-sonnet-4-5", MaxTokens: 1024, Messages: []ClaudeMessage{{Role: "user", Content: []ClaudeContentBlock{{Type: ContentTypeText, Text: StringPtr("Who?")}}}}}
+	output, err := EmulateResponseFormat(req, personFormat(true))
+	if err != nil {
+		t.Fatalf("模拟失败: %v", err)
+	}
+	if output == nil || output.Name != "person" || !output.Strict {
+		t.Fatalf("结构化输出不正确: %+v", output)
+	}
+	if len(req.Tools) != 1 || req.Tools[0].Name != StructuredOutputToolName || req.Tools[0].Type != "custom" || req.Tools[0].InputSchema["type"] != "object" {
+		t.Fatalf("应添加结构化输出工具: %+v", req.Tools)
+	}
+	if req.ToolChoice == nil || req.ToolChoice.Type != "tool" || *req.ToolChoice.Name != StructuredOutputToolName {
+		t.Fatalf("没有其他工具时应强制调用: %+v", req.ToolChoice)
+	}
+
+	// 已有其他工具时不强制调用，改为 system 提示
+	req = &ClaudeRequest{Model: "claude-sonnet-4-5", MaxTokens: 1024, System: NewSystemPrompt("Be brief."),
+		Tools: []ClaudeTool{{Name: "lookup", InputSchema: map[string]interface{}{"type": "object"}}}}
+	if _, err := EmulateResponseFormat(req, &OpenAIResponseFormat{Type: "json_object"}); err != nil {
+		t.Fatalf("模拟失败: %v", err)
+	}
+	if req.ToolChoice != nil || len(req.Tools) != 2 {
+		t.Fatalf("有其他工具时不应强制调用: %+v %+v", req.ToolChoice, req.Tools)
+	}
+	if system := req.System.PlainText(); !strings.Contains(system, "Be brief.") || !strings.Contains(system, StructuredOutputToolName) {
+		t.Errorf("system 应追加工具提示: %q", system)
+	}
+
+	if output, err := EmulateResponseFormat(req, &OpenAIResponseFormat{Type: "text"}); output != nil || err != nil {
+		t.Errorf("text 格式不应模拟: %+v %v", output, err)
+	}
+}
+
+// 测试无法模拟的响应格式返回错误
+func TestEmulateResponseFormatErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     *ClaudeRequest
+		format  *OpenAIResponseFormat
+		wantErr string
+	}{
+		{"缺少 json_schema", &ClaudeRequest{}, &OpenAIResponseFormat{Type: "json_schema"}, "缺少 json_schema"},
+		{"根节点不是对象", &ClaudeRequest{}, &OpenAIResponseFormat{Type: "json_schema", JSONSchema: &OpenAIJSONSchema{Name: "list", Schema: map[string]interface{}{"type": "array"}}}, "根节点必须是 object"},
+		{"未知格式", &ClaudeRequest{}, &OpenAIResponseFormat{Type: "xml"}, "不支持的响应格式"},
+		{"工具名冲突", &ClaudeRequest{Tools: []ClaudeTool{{Name: StructuredOutputToolName}}}, &OpenAIResponseFormat{Type: "json_object"}, "已被占用"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := EmulateResponseFormat(tt.req, tt.format)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("应返回包含 %q 的错误, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+// 测试非流式响应中的工具调用还原为 JSON 文本，以及校验与重试请求
+func TestStructuredOutputResponse(t *testing.T) {
+	output, err := EmulateResponseFormat(&ClaudeRequest{}, personFormat(true))
+	if err != nil {
+		t.Fatalf("模拟失败: %v", err)
+	}
+
+	var resp ClaudeResponse
+	if err := json.Unmarshal([]byte(`{"id":"msg_1","type":"message","role":"assistant","content":[
+		{"type":"tool_use","id":"toolu_1","name":"structured_output","input":{"name":"Ada","age":-1}}
+	],"stop_reason":"tool_use"}`), &resp); err != nil {
+		t.Fatalf("解析响应失败: %v", err)
+	}
+
+	violation := output.Validate(&resp)
+	if violation == nil || !strings.Contains(violation.Error(), "$.age") {
+		t.Fatalf("应报告 age 不符合 schema, got %v", violation)
+	}
+
+	req := &ClaudeRequest{Messages: []ClaudeMessage{{Role: "user", Content: []ClaudeContentBlock{{Type: ContentTypeText, Text: StringPtr("Who?")}}}}}
+	retry := output.RetryRequest(req, &resp, violation)
+	if len(req.Messages) != 1 || len(retry.Messages) != 3 {
+		t.Fatalf("重试请求应追加助手输出与错误反馈且不修改原请求: %d %d", len(req.Messages), len(retry.Messages))
+	}
+	feedback := retry.Messages[2].Content
+	if len(feedback) != 1 || feedback[0].Type != ContentTypeToolResult || *feedback[0].ToolUseID != "toolu_1" || feedback[0].IsError == nil || !*feedback[0].IsError {
+		t.Fatalf("错误反馈应为 is_error 的 tool_result: %+v", retry.Messages[2].Content)
+	}
+
+	output.UnwrapResponse(&resp)
+	if resp.StopReason != "end_turn" || resp.Content[0].Type != ContentTypeText || *resp.Content[0].Text != `{"age":-1,"name":"Ada"}` {
+		t.Errorf("工具调用应还原为 JSON 文本: %+v %s", resp.Content[0], resp.StopReason)
+	}
+
+	if err := output.Validate(&ClaudeResponse{Content: []ClaudeContentBlock{{Type: ContentTypeText, Text: StringPtr("hi")}}}); err == nil {
+		t.Error("未调用工具时校验应失败")
+	}
+}
+
+// 测试流式事件中的工具调用还原为文本增量
+func TestStructuredOutputStream(t *testing.T) {
+	output, err := EmulateResponseFormat(&ClaudeRequest{}, &OpenAIResponseFormat{Type: "json_object"})
+	if err != nil {
+		t.Fatalf("模拟失败: %v", err)
+	}
+	stream := output.NewStream()
+
+	tests := []struct {
+		name  string
+		event string
+		want  string
+	}{
+		{"message_start 原样返回", `{"type":"message_start","message":{"id":"msg_1"}}`, `{"type":"message_start","message":{"id":"msg_1"}}`},
+		{"工具块转为文本块", `{"type":"content_block_start","index":0,"content_block":{"type":"tool_use","id":"toolu_1","name":"structured_output","input":{}}}`, `{"content_block":{"text":"","type":"text"},"index":0,"type":"content_block_start"}`},
+		{"参数增量转为文本增量", `{"type":"content_block_delta","index":0,"delta":{"type":"input_json_delta","partial_json":"{\"a\":"}}`, `{"delta":{"text":"{\"a\":","type":"text_delta"},"index":0,"type":"content_block_delta"}`},
+		{"stop_reason 改为 end_turn", `{"type":"message_delta","delta":{"stop_reason":"tool_use"},"usage":{"output_tokens":5}}`, `{"delta":{"stop_reason":"end_turn"},"type":"message_delta","usage":{"output_tokens":5}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := stream.ProcessEvent([]byte(tt.event))
+			if err != nil {
+				t.Fatalf("处理事件失败: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("事件不匹配:\ngot:  %s\nwant: %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// 测试 Gemini responseSchema 转换为 JSON Schema
+func TestResponseFormatFromGemini(t *testing.T) {
+	format := ResponseFormatFromGemini(&GeminiGenerationConfig{
+		ResponseMimeType: "application/json",
+		ResponseSchema: map[string]interface{}{
+			"type":       "OBJECT",
+			"properties": map[string]interface{}{"tags": map[string]interface{}{"type": "ARRAY", "items": map[string]interface{}{"type": "STRING"}}},
+		},
+	})
+	if format == nil || format.Type != "json_schema" || format.JSONSchema.Strict == nil || !*format.JSONSchema.Strict {
+		t.Fatalf("应转换为 strict json_schema: %+v", format)
+	}
+	encoded, _ := json.Marshal(format.JSONSchema.Schema)
+	if want := `{"properties":{"tags":{"items":{"type":"string"},"type":"array"}},"type":"object"}`; string(encoded) != want {
+		t.Errorf("schema 类型应转为小写:\ngot:  %s\nwant: %s", encoded, want)
+	}
+
+	if format := ResponseFormatFromGemini(&GeminiGenerationConfig{ResponseMimeType: "application/json"}); format == nil || format.Type != "json_object" {
+		t.Errorf("没有 schema 时应为 json_object: %+v", format)
+	}
+	if format := ResponseFormatFromGemini(&GeminiGenerationConfig{ResponseMimeType: "text/plain"}); format != nil {
+		t.Errorf("非 JSON 输出不应转换: %+v", format)
+	}
+}
+
+// 测试 response_format 映射为 Gemini / Ollama 的原生参数
+func TestNativeResponseFormat(t *testing.T) {
+	format := personFormat(true)
+	req := &OpenAIRequest{Model: "m", Messages: []OpenAIMessage{{Role: "user", Content: "hi"}}, ResponseFormat: format}
+
+	geminiReq, err := ConvertOpenAIToGemini(req)
+	if err != nil {
+		t.Fatalf("转换 Gemini 失败: %v", err)
+	}
+	config := geminiReq.GenerationConfig
+	if config == nil || config.ResponseMimeType != "application/json" || config.ResponseJSONSchema["type"] != "object" {
+		t.Errorf("Gemini 应使用 responseMimeType 与 responseJsonSchema: %+v", config)
+	}
+
+	ollamaReq, err := ConvertOpenAIToOllama(req)
+	if err != nil {
+		t.Fatalf("转换 Ollama 失败: %v", err)
+	}
+	if schema, ok := ollamaReq.Format.(map[string]interface{}); !ok || schema["type"] != "object" {
+		t.Errorf("Ollama format 应为 schema: %+v", ollamaReq.Format)
+	}
+
+	req.ResponseFormat = &OpenAIResponseFormat{Type: "json_object"}
+	if ollamaReq, _ = ConvertOpenAIToOllama(req); ollamaReq.Format != "json" {
+		t.Errorf("json_object 应映射为 format: json, got %+v", ollamaReq.Format)
+	}
+}
+
+// 测试 JSON Schema 校验
+func TestValidateJSONSchema(t *testing.T) {
+	schema := map[string]interface{}{
+		"type": "object",
+		"$defs": map[string]interface{}{
+			"tag": map[string]interface{}{"type": "string", "enum": []interface{}{"a", "b"}},
+		},
+		"properties": map[string]interface{}{
+			"id":    map[string]interface{}{"type": "integer"},
+			"name":  map[string]interface{}{"type": "string", "minLength": float64(1)},
+			"tags":  map[string]interface{}{"type": "array", "items": map[string]interface{}{"$ref": "#/$defs/tag"}, "maxItems": float64(2)},
+			"score": map[string]interface{}{"anyOf": []interface{}{map[string]interface{}{"type": "number"}, map[string]interface{}{"type": "null"}}},
+		},
+		"required":             []interface{}{"id"},
+		"additionalProperties": false,
+	}
+
+	tests := []struct {
+		name    string
+		value   string
+		wantErr string
+	}{
+		{"合法", `{"id":1,"name":"x","tags":["a","b"],"score":null}`, ""},
+		{"缺少必填字段", `{"name":"x"}`, "缺少必填字段 id"},
+		{"整数类型", `{"id":1.5}`, "$.id: 类型应为 integer"},
+		{"字符串长度", `{"id":1,"name":""}`, "minLength"},
+		{"$ref 与 enum", `{"id":1,"tags":["c"]}`, "$.tags[0]"},
+		{"数组长度", `{"id":1,"tags":["a","b","a"]}`, "maxItems"},
+		{"anyOf", `{"id":1,"score":"high"}`, "anyOf"},
+		{"额外字段", `{"id":1,"extra":true}`, "$.extra: 不允许的字段"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var value interface{}
+			if err := json.Unmarshal([]byte(tt.value), &value); err != nil {
+				t.Fatalf("解析 JSON 失败: %v", err)
+			}
+			err := ValidateJSONSchema(value, schema)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("不应返回错误: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("应返回包含 %q 的错误, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package converter
+
+import (
+	"fmt"
+	"strings"
+)
+
+// NewSystemPrompt 创建字符串形式的 system 提示词，text 为空时返回 nil
+func NewSystemPrompt(text string) *ClaudeSystemPrompt {
+	if text == "" {
+		return nil
+	}
+	return &ClaudeSystemPrompt{Text: text}
+}
+
+// AppendSystemText 在 system 提示词末尾追加一段文本（内容块形式追加 text 块，保留已有的缓存断点）
+func AppendSystemText(system *ClaudeSystemPrompt, text string) *ClaudeSystemPrompt {
+	if system == nil {
+		return NewSystemPrompt(text)
+	}
+	if system.Blocks != nil {
+		blocks := append(append([]ClaudeContentBlock(nil), system.Blocks...), ClaudeContentBlock{
+			Type: ContentTypeText,
+			Text: StringPtr(text),
+		})
+		return &ClaudeSystemPrompt{Blocks: blocks}
+	}
+	if system.Text == "" {
+		return NewSystemPrompt(text)
+	}
+	return &ClaudeSystemPrompt{Text: system.Text + "\n\n" + text}
+}
+
+// UnmarshalJSON 解析字符串或 text 内容块数组
+func (p *ClaudeSystemPrompt) UnmarshalJSON(data []byte) error {
+	text, blocks, err := unmarshalStringOrBlocks(data)
+	if err != nil {
+		return fmt.Errorf("system 必须是字符串或内容块数组: %w", err)
+	}
+	*p = ClaudeSystemPrompt{Text: text, Blocks: blocks}
+	return nil
+}
+
+// MarshalJSON 按原始形式序列化
+func (p ClaudeSystemPrompt) MarshalJSON() ([]byte, error) {
+	return marshalStringOrBlocks(p.Text, p.Blocks)
+}
+
+// PlainText 合并 system 提示词文本（内容块之间以换行分隔）
+func (p *ClaudeSystemPrompt) PlainText() string {
+	if p == nil {
+		return ""
+	}
+	if p.Blocks == nil {
+		return p.Text
+	}
+
+	var texts []string
+	for _, block := range p.Blocks {
+		if block.Type == ContentTypeText && block.Text != nil {
+			texts = append(texts, *block.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+// HasCacheControl system 提示词中是否设置了缓存断点
+func (p *ClaudeSystemPrompt) HasCacheControl() bool {
+	if p == nil {
+		return false
+	}
+	for _, block := range p.Blocks {
+		if block.CacheControl != nil {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,179 @@
+package converter
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"strings"
+)
+
+// 扩展思考在 OpenAI 兼容上游中的参数风格
+const (
+	thinkingStyleReasoningEffort = "reasoning_effort" // OpenAI o 系列、gpt-5、Gemini、grok-3-mini
+	thinkingStyleEnableThinking  = "enable_thinking"  // Qwen3 / QwQ（DashScope、SiliconFlow 兼容模式）
+	thinkingStyleNone            = "none"             // 始终推理（DeepSeek-R1）、不支持推理或未知的模型
+)
+
+// thinkingSignaturePrefix 代理生成的思考签名前缀，便于与 Anthropic 签发的签名区分
+const thinkingSignaturePrefix = "siriusx-"
+
+// ReasoningEffortForBudget 将 Claude thinking.budget_tokens 映射为 OpenAI reasoning_effort
+// 对应 Claude Code 的 think (4000) / megathink (10000) / ultrathink (31999) 三档
+func ReasoningEffortForBudget(budgetTokens int) string {
+	switch {
+	case budgetTokens <= 4096:
+		return "low"
+	case budgetTokens <= 16384:
+		return "medium"
+	default:
+		return "high"
+	}
+}
+
+// thinkingStyleForModel 根据目标模型名判断推理参数风格
+// 只对已知接受推理参数的模型族设置参数：GLM、Kimi、旧版 vLLM 等上游会以 400 拒绝未知参数，
+// 未知模型不发送任何推理参数，DeepSeek 通过模型选择推理（deepseek-reasoner）
+func thinkingStyleForModel(model string) string {
+	name := strings.ToLower(model)
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+
+	switch {
+	case strings.HasPrefix(name, "qwen"), strings.HasPrefix(name, "qwq"):
+		return thinkingStyleEnableThinking
+	case isOpenAIReasoningModel(name),
+		strings.HasPrefix(name, "gpt-5"),
+		strings.HasPrefix(name, "gemini-2.5"), strings.HasPrefix(name, "gemini-3"),
+		strings.HasPrefix(name, "grok-3-mini"):
+		return thinkingStyleReasoningEffort
+	default:
+		return thinkingStyleNone
+	}
+}
+
+// isOpenAIReasoningModel 判断是否为 OpenAI o 系列推理模型（o1、o3-mini、o4-mini 等）
+func isOpenAIReasoningModel(name string) bool {
+	return len(name) >= 2 && name[0] == 'o' && name[1] >= '1' && name[1] <= '9'
+}
+
+// applyThinking 按目标模型将 Claude thinking 配置写入 OpenAI 请求
+// reasoning_effort 风格的推理模型拒绝 max_tokens，输出上限改用 max_completion_tokens
+func applyThinking(openaiReq *OpenAIRequest, thinking *ClaudeThinking) {
+	style := thinkingStyleForModel(openaiReq.Model)
+	if style == thinkingStyleReasoningEffort && openaiReq.MaxTokens > 0 {
+		openaiReq.MaxCompletionTokens = openaiReq.MaxTokens
+		openaiReq.MaxTokens = 0
+	}
+	if thinking == nil {
+		return
+	}
+
+	enabled := thinking.Type == "enabled"
+	switch style {
+	case thinkingStyleReasoningEffort:
+		if enabled {
+			openaiReq.ReasoningEffort = ReasoningEffortForBudget(thinking.BudgetTokens)
+		}
+	case thinkingStyleEnableThinking:
+		openaiReq.EnableThinking = &enabled
+		if enabled {
+			openaiReq.ThinkingBudget = thinking.BudgetTokens
+		}
+	}
+}
+
+// reasoningFromMessage 提取 OpenAI 消息中的推理内容（reasoning_content 优先）
+func reasoningFromMessage(reasoningContent, reasoning string) string {
+	if reasoningContent != "" {
+		return reasoningContent
+	}
+	return reasoning
+}
+
+// ThinkingSignature 为上游推理内容生成 Claude thinking 块签名
+// OpenAI 兼容上游不提供签名，以内容摘要代替，使客户端可以原样回传 thinking 块
+func ThinkingSignature(thinking string) string {
+	sum := sha256.Sum256([]byte(thinking))
+	return thinkingSignaturePrefix + base64.RawStdEncoding.EncodeToString(sum[:])
+}
+
+// IsGatewayThinkingSignature 判断 thinking 签名是否由代理生成（而非 Anthropic 签发）
+func IsGatewayThinkingSignature(signature string) bool {
+	return strings.HasPrefix(signature, thinkingSignaturePrefix)
+}
+
+// StripGatewayThinking 返回移除了代理签名 thinking 块的 Claude Messages 请求副本及移除的块数，未移除时返回原请求
+// 客户端回传 OpenAI 兼容上游产生的对话后，原生 Claude 上游（Anthropic、Bedrock、Vertex）无法校验这些签名，会拒绝整个请求。
+// 被移除块的 assistant 消息是最后一条且包含 tool_use 时，开启 thinking 的上游要求其以 thinking 块开头，此时一并关闭本次请求的 thinking
+func StripGatewayThinking(req map[string]interface{}) (map[string]interface{}, int) {
+	messages, ok := req["messages"].([]interface{})
+	if !ok {
+		return req, 0
+	}
+
+	lastAssistant := -1
+	for i, rawMsg := range messages {
+		if msg, ok := rawMsg.(map[string]interface{}); ok && msg["role"] == "assistant" {
+			lastAssistant = i
+		}
+	}
+
+	var stripped []interface{}
+	removed := 0
+	disableThinking := false
+	for i, rawMsg := range messages {
+		msg, ok := rawMsg.(map[string]interface{})
+		if !ok || msg["role"] != "assistant" {
+			continue
+		}
+		content, ok := msg["content"].([]interface{})
+		if !ok {
+			continue
+		}
+
+		kept := make([]interface{}, 0, len(content))
+		hasToolUse := false
+		for _, rawBlock := range content {
+			block, _ := rawBlock.(map[string]interface{})
+			if block["type"] == "thinking" {
+				if signature, _ := block["signature"].(string); IsGatewayThinkingSignature(signature) {
+					continue
+				}
+			}
+			if block["type"] == "tool_use" {
+				hasToolUse = true
+			}
+			kept = append(kept, rawBlock)
+		}
+		if len(kept) == len(content) {
+			continue
+		}
+
+		if stripped == nil {
+			stripped = append([]interface{}(nil), messages...)
+		}
+		copied := make(map[string]interface{}, len(msg))
+		for key, value := range msg {
+			copied[key] = value
+		}
+		copied["content"] = kept
+		stripped[i] = copied
+		removed += len(content) - len(kept)
+		if i == lastAssistant && hasToolUse {
+			disableThinking = true
+		}
+	}
+	if removed == 0 {
+		return req, 0
+	}
+
+	result := make(map[string]interface{}, len(req))
+	for key, value := range req {
+		result[key] = value
+	}
+	result["messages"] = stripped
+	if disableThinking {
+		delete(result, "thinking")
+	}
+	return result, removed
+}
@@ -0,0 +1,237 @@
+package converter
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// 测试 thinking 配置按目标模型映射为推理参数
+func TestConvertThinkingToReasoningParams(t *testing.T) {
+	tests := []struct {
+		name              string
+		model             string
+		thinking          *ClaudeThinking
+		wantEffort        string
+		wantEnable        *bool
+		wantBudget        int
+		wantMaxCompletion bool // 推理模型使用 max_completion_tokens
+	}{
+		{"o 系列 think", "o4-mini", &ClaudeThinking{Type: "enabled", BudgetTokens: 4000}, "low", nil, 0, true},
+		{"o 系列 megathink", "o3", &ClaudeThinking{Type: "enabled", BudgetTokens: 10000}, "medium", nil, 0, true},
+		{"带前缀的模型 ultrathink", "openai/gpt-5", &ClaudeThinking{Type: "enabled", BudgetTokens: 31999}, "high", nil, 0, true},
+		{"Qwen 开启", "qwen3-235b-a22b", &ClaudeThinking{Type: "enabled", BudgetTokens: 8000}, "", boolPtr(true), 8000, false},
+		{"QwQ 关闭", "Qwen/QwQ-32B", &ClaudeThinking{Type: "disabled"}, "", boolPtr(false), 0, false},
+		{"DeepSeek 不设置参数", "deepseek-reasoner", &ClaudeThinking{Type: "enabled", BudgetTokens: 8000}, "", nil, 0, false},
+		{"GPT-4 不设置参数", "gpt-4o", &ClaudeThinking{Type: "enabled", BudgetTokens: 8000}, "", nil, 0, false},
+		{"Gemini", "gemini-2.5-pro", &ClaudeThinking{Type: "enabled", BudgetTokens: 8000}, "medium", nil, 0, true},
+		{"GLM 不设置参数", "glm-4.6", &ClaudeThinking{Type: "enabled", BudgetTokens: 8000}, "", nil, 0, false},
+		{"Kimi 不设置参数", "moonshotai/kimi-k2-instruct", &ClaudeThinking{Type: "enabled", BudgetTokens: 8000}, "", nil, 0, false},
+		{"未知模型不设置参数", "my-vllm-model", &ClaudeThinking{Type: "enabled", BudgetTokens: 8000}, "", nil, 0, false},
+		{"以 o 开头的非推理模型", "olmo-2", &ClaudeThinking{Type: "enabled", BudgetTokens: 8000}, "", nil, 0, false},
+		{"未开启 thinking", "o3", nil, "", nil, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &ClaudeRequest{
+				Model:     tt.model,
+				MaxTokens: 32000,
+				Thinking:  tt.thinking,
+				Messages: []ClaudeMessage{
+					{Role: "user", Content: []ClaudeContentBlock{{Type: "text", Text: StringPtr("Hi")}}},
+				},
+			}
+
+			result, err := ConvertClaudeToOpenAI(req)
+			if err != nil {
+				t.Fatalf("转换失败: %v", err)
+			}
+			if result.ReasoningEffort != tt.wantEffort {
+				t.Errorf("reasoning_effort 不匹配: got %q, want %q", result.ReasoningEffort, tt.wantEffort)
+			}
+			if (result.EnableThinking == nil) != (tt.wantEnable == nil) ||
+				(result.EnableThinking != nil && *result.EnableThinking != *tt.wantEnable) {
+				t.Errorf("enable_thinking 不匹配: got %v, want %v", result.EnableThinking, tt.wantEnable)
+			}
+			if result.ThinkingBudget != tt.wantBudget {
+				t.Errorf("thinking_budget 不匹配: got %d, want %d", result.ThinkingBudget, tt.wantBudget)
+			}
+			wantMaxTokens, wantCompletion := 32000, 0
+			if tt.wantMaxCompletion {
+				wantMaxTokens, wantCompletion = 0, 32000
+			}
+			if result.MaxTokens != wantMaxTokens || result.MaxCompletionTokens != wantCompletion {
+				t.Errorf("输出上限不匹配: max_tokens=%d max_completion_tokens=%d, want %d/%d",
+					result.MaxTokens, result.MaxCompletionTokens, wantMaxTokens, wantCompletion)
+			}
+		})
+	}
+}
+
+// 测试历史 thinking 块不回传给 OpenAI 上游
+func TestConvertAssistantThinkingBlocksDropped(t *testing.T) {
+	raw := `{"model":"o3","max_tokens":2048,"thinking":{"type":"enabled","budget_tokens":1024},"messages":[
+		{"role":"user","content":[{"type":"text","text":"Hi"}]},
+		{"role":"assistant","content":[
+			{"type":"thinking","thinking":"let me think","signature":"sig"},
+			{"type":"redacted_thinking","data":"opaque"},
+			{"type":"text","text":"Hello"}
+		]}
+	]}`
+	var req ClaudeRequest
+	if err := json.Unmarshal([]byte(raw), &req); err != nil {
+		t.Fatalf("解析请求失败: %v", err)
+	}
+
+	result, err := ConvertClaudeToOpenAI(&req)
+	if err != nil {
+		t.Fatalf("转换失败: %v", err)
+	}
+
+	body, _ := json.Marshal(result.Messages[1])
+	if string(body) != `{"role":"assistant","content":"Hello"}` {
+		t.Errorf("助手消息应只保留文本: %s", body)
+	}
+}
+
+// 测试非流式响应的 reasoning_content / reasoning 转换为 thinking 块
+func TestConvertReasoningResponse(t *testing.T) {
+	for _, message := range []OpenAIMessage{
+		{Role: "assistant", Content: "42", ReasoningContent: "6 x 7"},
+		{Role: "assistant", Content: "42", Reasoning: "6 x 7"},
+	} {
+		resp := &OpenAIResponse{
+			ID:      "chatcmpl-1",
+			Model:   "deepseek-reasoner",
+			Choices: []OpenAIChoice{{Message: message, FinishReason: "stop"}},
+		}
+
+		result, err := ConvertOpenAIToClaude(resp)
+		if err != nil {
+			t.Fatalf("转换失败: %v", err)
+		}
+		if len(result.Content) != 2 {
+			t.Fatalf("应有 thinking 和 text 两个块, got %d", len(result.Content))
+		}
+
+		thinking := result.Content[0]
+		if thinking.Type != "thinking" || thinking.Thinking == nil || *thinking.Thinking != "6 x 7" {
+			t.Errorf("thinking 块不匹配: %+v", thinking)
+		}
+		if thinking.Signature == nil || *thinking.Signature != ThinkingSignature("6 x 7") {
+			t.Errorf("thinking 块缺少签名: %+v", thinking)
+		}
+		if result.Content[1].Type != "text" || *result.Content[1].Text != "42" {
+			t.Errorf("text 块不匹配: %+v", result.Content[1])
+		}
+	}
+}
+
+// 测试流式 reasoning_content 增量转换为 thinking_delta + signature_delta
+func TestConvertStream_Reasoning(t *testing.T) {
+	openaiStream := `data: {"id":"chatcmpl-789","model":"deepseek-reasoner","choices":[{"delta":{"role":"assistant","reasoning_content":"Let me"},"finish_reason":null}]}
+
+data: {"id":"chatcmpl-789","choices":[{"delta":{"reasoning_content":" think"},"finish_reason":null}]}
+
+data: {"id":"chatcmpl-789","choices":[{"delta":{"content":"Answer"},"finish_reason":null}]}
+
+data: {"id":"chatcmpl-789","choices":[{"delta":{},"finish_reason":"stop"}]}
+
+data: [DONE]
+
+`
+
+	claudeStream, err := ConvertStream(context.Background(), strings.NewReader(openaiStream))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	events := readAllEvents(t, claudeStream)
+
+	expected := []string{
+		`"type":"message_start"`,
+		`"content_block":{"type":"thinking","thinking":""}`,
+		`"delta":{"type":"thinking_delta","thinking":"Let me"}`,
+		`"delta":{"type":"thinking_delta","thinking":" think"}`,
+		`"delta":{"type":"signature_delta","signature":"` + ThinkingSignature("Let me think") + `"}`,
+		`{"type":"content_block_stop","index":0}`,
+		`"index":1,"content_block":{"type":"text","text":""}`,
+		`"delta":{"type":"text_delta","text":"Answer"}`,
+		`{"type":"content_block_stop","index":1}`,
+		`"stop_reason":"end_turn"`,
+	}
+	if len(events) < len(expected) {
+		t.Fatalf("expected at least %d events, got %d: %v", len(expected), len(events), events)
+	}
+	for i, want := range expected {
+		if !strings.Contains(events[i], want) {
+			t.Errorf("event %d: expected %s, got %q", i, want, events[i])
+		}
+	}
+}
+
+// 测试仅有推理内容的流在结束时补发签名
+func TestConvertStream_ReasoningOnly(t *testing.T) {
+	openaiStream := `data: {"id":"chatcmpl-790","model":"o3","choices":[{"delta":{"role":"assistant","reasoning":"hmm"},"finish_reason":null}]}
+
+data: [DONE]
+
+`
+
+	claudeStream, err := ConvertStream(context.Background(), strings.NewReader(openaiStream))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	events := readAllEvents(t, claudeStream)
+
+	joined := strings.Join(events, "")
+	signature := strings.Index(joined, `"type":"signature_delta"`)
+	stop := strings.Index(joined, `"type":"content_block_stop"`)
+	if signature < 0 || stop < 0 || signature > stop {
+		t.Errorf("signature_delta should precede content_block_stop: %v", events)
+	}
+}
+
+// 测试移除代理签名的 thinking 块，保留 Anthropic 签发的签名且不修改原请求
+func TestStripGatewayThinking(t *testing.T) {
+	gateway := map[string]interface{}{"type": "thinking", "thinking": "hmm", "signature": ThinkingSignature("hmm")}
+	native := map[string]interface{}{"type": "thinking", "thinking": "ok", "signature": "EqQBCkYIBxgCKkA"}
+	text := map[string]interface{}{"type": "text", "text": "Hello"}
+	req := map[string]interface{}{
+		"thinking": map[string]interface{}{"type": "enabled", "budget_tokens": 1024},
+		"messages": []interface{}{
+			map[string]interface{}{"role": "user", "content": "Hi"},
+			map[string]interface{}{"role": "assistant", "content": []interface{}{gateway, text}},
+			map[string]interface{}{"role": "user", "content": "Again"},
+			map[string]interface{}{"role": "assistant", "content": []interface{}{native, text}},
+			map[string]interface{}{"role": "user", "content": "More"},
+		},
+	}
+
+	stripped, removed := StripGatewayThinking(req)
+	if removed != 1 {
+		t.Fatalf("expected 1 block removed, got %d", removed)
+	}
+	messages := stripped["messages"].([]interface{})
+	if content := messages[1].(map[string]interface{})["content"].([]interface{}); len(content) != 1 || content[0].(map[string]interface{})["type"] != "text" {
+		t.Errorf("gateway thinking block should be removed: %v", content)
+	}
+	if content := messages[3].(map[string]interface{})["content"].([]interface{}); len(content) != 2 {
+		t.Errorf("native thinking block should be kept: %v", content)
+	}
+	if _, ok := stripped["thinking"]; !ok {
+		t.Error("thinking should stay enabled outside tool-use turns")
+	}
+	if content := req["messages"].([]interface{})[1].(map[string]interface{})["content"].([]interface{}); len(content) != 2 {
+		t.Error("original request should not be modified")
+	}
+
+	if same, removed := StripGatewayThinking(map[string]interface{}{"messages": []interface{}{messages[3]}}); removed != 0 || len(same["messages"].([]interface{})) != 1 {
+		t.Errorf("request without gateway signatures should be returned as is")
+	}
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
@@ -0,0 +1,397 @@
+package converter
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// 工具调用参数的修复类型
+const (
+	RepairMarkdownFence     = "markdown_fence"     // 去除 ```json 代码块包裹
+	RepairSurroundingText   = "surrounding_text"   // 去除 JSON 前后的说明文字
+	RepairDoubleEncoded     = "double_encoded"     // 参数被再次编码为 JSON 字符串
+	RepairSingleQuotes      = "single_quotes"      // 单引号字符串改为双引号
+	RepairUnquotedKeys      = "unquoted_keys"      // 为未加引号的键补充引号
+	RepairBareLiterals      = "bare_literals"      // True / None 等非 JSON 字面量
+	RepairTrailingComma     = "trailing_comma"     // 删除对象、数组末尾多余的逗号
+	RepairControlCharacters = "control_characters" // 转义字符串中的原始换行等控制字符
+	RepairTruncated         = "truncated"          // 补全被截断的字符串、值与括号
+)
+
+// maxRepairDepth 修复时允许的最大嵌套深度
+const maxRepairDepth = 256
+
+// ToolArgumentRecorder 记录工具调用参数的修复结果，统计由调用方持有，converter 本身不保存状态
+type ToolArgumentRecorder interface {
+	// RecordToolArgumentRepair 记录一次修复：ok 为 false 表示无法修复，kinds 为采用的修复类型
+	RecordToolArgumentRepair(kinds []string, ok bool)
+}
+
+// DecodeToolArguments 解析上游返回的工具调用参数，参数不是合法 JSON 对象时先尝试修复
+// 返回解析结果与实际采用的修复类型（无需修复时为空）；recorder 非 nil 时记录修复结果
+func DecodeToolArguments(arguments string, recorder ToolArgumentRecorder) (map[string]interface{}, []string, error) {
+	if strings.TrimSpace(arguments) == "" {
+		return map[string]interface{}{}, nil, nil
+	}
+
+	var input map[string]interface{}
+	if err := json.Unmarshal([]byte(arguments), &input); err == nil {
+		if input == nil {
+			input = map[string]interface{}{}
+		}
+		return input, nil, nil
+	}
+
+	repaired, kinds, err := RepairJSON(arguments)
+	if err == nil {
+		input = nil
+		if err = json.Unmarshal([]byte(repaired), &input); err == nil && input == nil {
+			err = fmt.Errorf("参数不是 JSON 对象")
+		}
+	}
+	if recorder != nil {
+		recorder.RecordToolArgumentRepair(kinds, err == nil)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("解析 arguments 失败: %w", err)
+	}
+	return input, kinds, nil
+}
+
+// RepairJSON 修复较弱上游常见的 JSON 格式问题：代码块包裹、前后说明文字、单引号、未加引号的键、
+// Python 风格字面量、多余的尾逗号、字符串中的原始控制字符、输出截断；返回修复后的 JSON 与采用的修复类型
+func RepairJSON(raw string) (string, []string, error) {
+	if json.Valid([]byte(raw)) {
+		// 参数被再次编码为字符串时解开一层
+		var inner string
+		if err := json.Unmarshal([]byte(raw), &inner); err == nil {
+			repaired, kinds, err := RepairJSON(strings.TrimSpace(inner))
+			if err != nil {
+				return "", nil, err
+			}
+			return repaired, append([]string{RepairDoubleEncoded}, kinds...), nil
+		}
+		return raw, nil, nil
+	}
+
+	r := &jsonRepairer{kinds: make(map[string]bool)}
+	text := r.stripWrapping(strings.TrimSpace(raw))
+	repaired, err := r.rewrite(text)
+	if err != nil {
+		return "", nil, err
+	}
+	if !json.Valid([]byte(repaired)) {
+		return "", nil, fmt.Errorf("无法修复为合法 JSON")
+	}
+
+	var kinds []string
+	for _, kind := range []string{
+		RepairMarkdownFence, RepairSurroundingText, RepairSingleQuotes, RepairUnquotedKeys,
+		RepairBareLiterals, RepairTrailingComma, RepairControlCharacters, RepairTruncated,
+	} {
+		if r.kinds[kind] {
+			kinds = append(kinds, kind)
+		}
+	}
+	return repaired, kinds, nil
+}
+
+// jsonRepairer 单遍扫描改写 JSON 文本
+type jsonRepairer struct {
+	kinds map[string]bool
+	out   strings.Builder
+	stack []*repairFrame
+}
+
+// repairFrame 一层对象或数组的扫描状态
+type repairFrame struct {
+	object    bool
+	expectKey bool // 对象中下一个值是键
+	afterKey  bool // 已读入键，尚未读到冒号
+}
+
+// stripWrapping 去除代码块包裹以及第一个 { / [ 之前的说明文字
+func (r *jsonRepairer) stripWrapping(text string) string {
+	if strings.HasPrefix(text, "```") {
+		r.kinds[RepairMarkdownFence] = true
+		text = strings.TrimPrefix(text, "```")
+		if newline := strings.IndexByte(text, '\n'); newline >= 0 && !strings.ContainsAny(text[:newline], "{[") {
+			text = text[newline+1:]
+		}
+		if end := strings.LastIndex(text, "```"); end >= 0 {
+			text = text[:end]
+		}
+		text = strings.TrimSpace(text)
+	}
+	if start := strings.IndexAny(text, "{["); start > 0 {
+		r.kinds[RepairSurroundingText] = true
+		text = text[start:]
+	}
+	return text
+}
+
+func (r *jsonRepairer) top() *repairFrame {
+	if len(r.stack) == 0 {
+		return nil
+	}
+	return r.stack[len(r.stack)-1]
+}
+
+// valueDone 完成一个键或值
+func (r *jsonRepairer) valueDone() {
+	if frame := r.top(); frame != nil && frame.object && frame.expectKey {
+		frame.expectKey = false
+		frame.afterKey = true
+	}
+}
+
+// trimTrailingComma 删除输出末尾（忽略空白）多余的逗号
+func (r *jsonRepairer) trimTrailingComma() bool {
+	out := strings.TrimRight(r.out.String(), " \t\r\n")
+	if !strings.HasSuffix(out, ",") {
+		return false
+	}
+	r.out.Reset()
+	r.out.WriteString(out[:len(out)-1])
+	return true
+}
+
+// rewrite 扫描文本并输出修复后的 JSON，顶层值结束后的内容视为说明文字丢弃
+func (r *jsonRepairer) rewrite(text string) (string, error) {
+	started := false
+	for i := 0; i < len(text); {
+		ch := text[i]
+		if started && len(r.stack) == 0 {
+			if strings.TrimSpace(text[i:]) != "" {
+				r.kinds[RepairSurroundingText] = true
+			}
+			break
+		}
+
+		switch {
+		case ch == '{' || ch == '[':
+			if len(r.stack) >= maxRepairDepth {
+				return "", fmt.Errorf("嵌套层级超过 %d", maxRepairDepth)
+			}
+			r.valueDone()
+			r.stack = append(r.stack, &repairFrame{object: ch == '{', expectKey: ch == '{'})
+			r.out.WriteByte(ch)
+			started = true
+			i++
+
+		case ch == '}' || ch == ']':
+			if r.trimTrailingComma() {
+				r.kinds[RepairTrailingComma] = true
+			}
+			if frame := r.top(); frame != nil && frame.object && frame.afterKey {
+				r.out.WriteString(":null")
+				r.kinds[RepairTruncated] = true
+			}
+			r.closeFrame()
+			i++
+
+		case ch == '"' || ch == '\'':
+			if !started {
+				return "", fmt.Errorf("不是 JSON 对象或数组")
+			}
+			i = r.readString(text, i)
+
+		case ch == ',':
+			if frame := r.top(); frame != nil && frame.object {
+				frame.expectKey = true
+				frame.afterKey = false
+			}
+			r.out.WriteByte(ch)
+			i++
+
+		case ch == ':':
+			if frame := r.top(); frame != nil {
+				frame.afterKey = false
+			}
+			r.out.WriteByte(ch)
+			i++
+
+		case ch == ' ' || ch == '\t' || ch == '\r' || ch == '\n':
+			r.out.WriteByte(ch)
+			i++
+
+		case ch == '-' || (ch >= '0' && ch <= '9'):
+			if !started {
+				return "", fmt.Errorf("不是 JSON 对象或数组")
+			}
+			i = r.readNumber(text, i)
+
+		case isBareWordByte(ch):
+			if !started {
+				return "", fmt.Errorf("不是 JSON 对象或数组")
+			}
+			i = r.readBareWord(text, i)
+
+		default:
+			return "", fmt.Errorf("位置 %d 存在无法识别的字符 %q", i, ch)
+		}
+	}
+
+	if !started {
+		return "", fmt.Errorf("不是 JSON 对象或数组")
+	}
+	if len(r.stack) > 0 {
+		r.kinds[RepairTruncated] = true
+		r.closeTruncated()
+	}
+	return r.out.String(), nil
+}
+
+// closeFrame 结束当前对象或数组，括号类型以打开时为准
+func (r *jsonRepairer) closeFrame() {
+	frame := r.top()
+	if frame == nil {
+		return
+	}
+	r.stack = r.stack[:len(r.stack)-1]
+	if frame.object {
+		r.out.WriteByte('}')
+	} else {
+		r.out.WriteByte(']')
+	}
+}
+
+// closeTruncated 补全截断的结尾：删除尾逗号，为缺失的值补 null，再依次闭合括号
+func (r *jsonRepairer) closeTruncated() {
+	for len(r.stack) > 0 {
+		r.trimTrailingComma()
+		out := strings.TrimRight(r.out.String(), " \t\r\n")
+		r.out.Reset()
+		r.out.WriteString(out)
+		if frame := r.top(); strings.HasSuffix(out, ":") || (frame.object && frame.afterKey) {
+			if !strings.HasSuffix(out, ":") {
+				r.out.WriteByte(':')
+			}
+			r.out.WriteString("null")
+		}
+		r.closeFrame()
+	}
+}
+
+// readString 读取单引号或双引号字符串并以双引号输出，字符串未结束时补全引号
+func (r *jsonRepairer) readString(text string, start int) int {
+	quote := text[start]
+	if quote == '\'' {
+		r.kinds[RepairSingleQuotes] = true
+	}
+	r.out.WriteByte('"')
+
+	i := start + 1
+	for i < len(text) {
+		ch := text[i]
+		switch {
+		case ch == '\\' && i+1 < len(text):
+			next := text[i+1]
+			if next == '\'' {
+				// \' 不是合法的 JSON 转义
+				r.out.WriteByte('\'')
+			} else {
+				r.out.WriteByte(ch)
+				r.out.WriteByte(next)
+			}
+			i += 2
+			continue
+		case ch == '\\':
+			// 截断在转义符处
+			i++
+			continue
+		case ch == quote:
+			r.out.WriteByte('"')
+			r.valueDone()
+			return i + 1
+		case ch == '"':
+			r.out.WriteString(`\"`)
+		case ch == '\n':
+			r.kinds[RepairControlCharacters] = true
+			r.out.WriteString(`\n`)
+		case ch == '\r':
+			r.kinds[RepairControlCharacters] = true
+			r.out.WriteString(`\r`)
+		case ch == '\t':
+			r.kinds[RepairControlCharacters] = true
+			r.out.WriteString(`\t`)
+		case ch < 0x20:
+			r.kinds[RepairControlCharacters] = true
+			fmt.Fprintf(&r.out, `\u%04x`, ch)
+		default:
+			r.out.WriteByte(ch)
+		}
+		i++
+	}
+
+	r.kinds[RepairTruncated] = true
+	r.out.WriteByte('"')
+	r.valueDone()
+	return i
+}
+
+// readNumber 读取数字，截断在小数点、指数或负号处时补 0
+func (r *jsonRepairer) readNumber(text string, start int) int {
+	i := start
+	for i < len(text) && strings.IndexByte("+-0123456789.eE", text[i]) >= 0 {
+		i++
+	}
+	number := text[start:i]
+	r.out.WriteString(number)
+	if i == len(text) && strings.ContainsAny(number[len(number)-1:], "+-.eE") {
+		r.kinds[RepairTruncated] = true
+		r.out.WriteByte('0')
+	}
+	r.valueDone()
+	return i
+}
+
+// readBareWord 读取未加引号的单词：键位置补引号，Python / JavaScript 字面量转换为 JSON 字面量，其余作为字符串
+func (r *jsonRepairer) readBareWord(text string, start int) int {
+	i := start
+	for i < len(text) && (isBareWordByte(text[i]) || (text[i] >= '0' && text[i] <= '9')) {
+		i++
+	}
+	word := text[start:i]
+
+	if frame := r.top(); frame != nil && frame.object && frame.expectKey {
+		r.kinds[RepairUnquotedKeys] = true
+		data, _ := json.Marshal(word)
+		r.out.Write(data)
+		r.valueDone()
+		return i
+	}
+
+	switch word {
+	case "true", "false", "null":
+		r.out.WriteString(word)
+	case "True", "False":
+		r.kinds[RepairBareLiterals] = true
+		r.out.WriteString(strings.ToLower(word))
+	case "None", "undefined", "NaN", "Infinity":
+		r.kinds[RepairBareLiterals] = true
+		r.out.WriteString("null")
+	default:
+		if i == len(text) {
+			// 截断在字面量中间
+			for _, literal := range []string{"true", "false", "null"} {
+				if strings.HasPrefix(literal, word) {
+					r.kinds[RepairTruncated] = true
+					r.out.WriteString(literal)
+					r.valueDone()
+					return i
+				}
+			}
+		}
+		r.kinds[RepairBareLiterals] = true
+		data, _ := json.Marshal(word)
+		r.out.Write(data)
+	}
+	r.valueDone()
+	return i
+}
+
+func isBareWordByte(ch byte) bool {
+	return ch == '_' || ch == '$' || (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z')
+}
@@ -0,0 +1,191 @@
+package converter
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestRepairJSON 测试常见的参数格式问题修复
+func TestRepairJSON(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+		kinds []string
+	}{
+		{"valid", `{"a": 1}`, `{"a": 1}`, nil},
+		{"trailing comma", `{"a": 1, "b": [1, 2,],}`, `{"a":1,"b":[1,2]}`, []string{RepairTrailingComma}},
+		{"single quotes", `{'city': 'Paris', 'note': 'say "hi" it\'s'}`, `{"city":"Paris","note":"say \"hi\" it's"}`, []string{RepairSingleQuotes}},
+		{"markdown fence", "```json\n{\"a\": 1}\n```", `{"a":1}`, []string{RepairMarkdownFence}},
+		{"surrounding text", `Here are the arguments: {"a": 1} hope this helps`, `{"a":1}`, []string{RepairSurroundingText}},
+		{"unquoted keys and literals", `{city: "Paris", metric: True, unit: None}`, `{"city":"Paris","metric":true,"unit":null}`, []string{RepairUnquotedKeys, RepairBareLiterals}},
+		{"raw newline", "{\"text\": \"line1\nline2\"}", `{"text":"line1\nline2"}`, []string{RepairControlCharacters}},
+		{"truncated string", `{"city": "Par`, `{"city":"Par"}`, []string{RepairTruncated}},
+		{"truncated key", `{"a": 1, "b`, `{"a":1,"b":null}`, []string{RepairTruncated}},
+		{"truncated after colon", `{"a": {"b": [1, 2], "c":`, `{"a":{"b":[1,2],"c":null}}`, []string{RepairTruncated}},
+		{"truncated after comma", `{"a": [1, 2,`, `{"a":[1,2]}`, []string{RepairTruncated}},
+		{"truncated literal", `{"ok": tr`, `{"ok":true}`, []string{RepairTruncated}},
+		{"truncated number", `{"n": 1.`, `{"n":1.0}`, []string{RepairTruncated}},
+		{"double encoded", `"{\"a\": 1,}"`, `{"a":1}`, []string{RepairDoubleEncoded, RepairTrailingComma}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, kinds, err := RepairJSON(tt.input)
+			if err != nil {
+				t.Fatalf("修复失败: %v", err)
+			}
+			var gotValue, wantValue interface{}
+			if err := json.Unmarshal([]byte(got), &gotValue); err != nil {
+				t.Fatalf("修复结果不是合法 JSON %q: %v", got, err)
+			}
+			_ = json.Unmarshal([]byte(tt.want), &wantValue)
+			if !reflect.DeepEqual(gotValue, wantValue) {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+			if !reflect.DeepEqual(kinds, tt.kinds) {
+				t.Errorf("expected kinds %v, got %v", tt.kinds, kinds)
+			}
+		})
+	}
+}
+
+// TestRepairJSON_Unrepairable 测试无法修复的输入
+func TestRepairJSON_Unrepairable(t *testing.T) {
+	for _, input := range []string{"", "city is Paris", `"just a string"`, `{"a": 1 @}`, strings.Repeat("[", maxRepairDepth+1)} {
+		if repaired, _, err := RepairJSON(input); err == nil && input != `"just a string"` {
+			t.Errorf("%q 应无法修复，got %q", input, repaired)
+		}
+	}
+}
+
+// repairRecorder 记录修复结果的测试桩
+type repairRecorder struct {
+	repaired, failed int
+	kinds            []string
+}
+
+func (r *repairRecorder) RecordToolArgumentRepair(kinds []string, ok bool) {
+	if !ok {
+		r.failed++
+		return
+	}
+	r.repaired++
+	r.kinds = append(r.kinds, kinds...)
+}
+
+// TestDecodeToolArguments 测试参数解析与修复结果的记录
+func TestDecodeToolArguments(t *testing.T) {
+	recorder := &repairRecorder{}
+
+	input, kinds, err := DecodeToolArguments(`{"city": "Paris",}`, recorder)
+	if err != nil || input["city"] != "Paris" || !reflect.DeepEqual(kinds, []string{RepairTrailingComma}) {
+		t.Fatalf("unexpected result: %v %v %v", input, kinds, err)
+	}
+	if input, kinds, err := DecodeToolArguments(`{"city": "Paris"}`, recorder); err != nil || input["city"] != "Paris" || kinds != nil {
+		t.Fatalf("合法参数不应修复: %v %v %v", input, kinds, err)
+	}
+	if input, _, err := DecodeToolArguments("", recorder); err != nil || len(input) != 0 {
+		t.Fatalf("空参数应解析为空对象: %v %v", input, err)
+	}
+	if _, _, err := DecodeToolArguments(`[1, 2,]`, recorder); err == nil {
+		t.Fatal("非对象参数应返回错误")
+	}
+	if _, _, err := DecodeToolArguments(`{'city': 'Paris'}`, nil); err != nil {
+		t.Fatalf("recorder 为 nil 时仍应修复: %v", err)
+	}
+
+	if recorder.repaired != 1 || recorder.failed != 1 || !reflect.DeepEqual(recorder.kinds, []string{RepairTrailingComma}) {
+		t.Errorf("unexpected records: %+v", recorder)
+	}
+}
+
+// TestConvertToolCallWithMalformedArguments 测试非流式响应中修复工具调用参数
+func TestConvertToolCallWithMalformedArguments(t *testing.T) {
+	resp := &OpenAIResponse{
+		ID:    "chatcmpl-1",
+		Model: "glm-4.6",
+		Choices: []OpenAIChoice{{
+			Message: OpenAIMessage{Role: "assistant", ToolCalls: []OpenAIToolCall{{
+				ID:       "call_1",
+				Type:     "function",
+				Function: OpenAIFunctionCall{Name: "get_weather", Arguments: "```json\n{'city': 'Paris',}\n```"},
+			}}},
+			FinishReason: "tool_calls",
+		}},
+	}
+
+	claudeResp, err := ConvertOpenAIToClaude(resp)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(claudeResp.Content) != 1 || claudeResp.Content[0].Input["city"] != "Paris" {
+		t.Fatalf("unexpected content: %+v", claudeResp.Content)
+	}
+
+	resp.Choices[0].Message.ToolCalls[0].Function.Arguments = "not json"
+	if _, err := ConvertOpenAIToClaude(resp); err == nil {
+		t.Fatal("无法修复的参数应返回错误")
+	}
+}
+
+// TestConvertStream_BufferToolArguments 测试缓冲模式下工具参数修复后一次性输出
+func TestConvertStream_BufferToolArguments(t *testing.T) {
+	input := `data: {"id":"c1","model":"m","choices":[{"index":0,"delta":{"role":"assistant","tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"get_weather","arguments":""}}]}}]}
+
+data: {"id":"c1","model":"m","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"{'city': "}}]}}]}
+
+data: {"id":"c1","model":"m","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"'Paris',}"}}]}}]}
+
+data: {"id":"c1","model":"m","choices":[{"index":0,"delta":{"tool_calls":[{"index":1,"id":"call_2","type":"function","function":{"name":"get_time","arguments":"{\"tz\": \"UTC\"}"}}]}}]}
+
+data: {"id":"c1","model":"m","choices":[{"index":0,"delta":{},"finish_reason":"tool_calls"}]}
+
+data: [DONE]
+
+`
+
+	collect := func(opts StreamOptions) []string {
+		t.Helper()
+		reader, err := ConvertStreamWithOptions(context.Background(), strings.NewReader(input), opts)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		output, _ := io.ReadAll(reader)
+		var deltas []string
+		for _, block := range strings.Split(string(output), "\n\n") {
+			_, data, found := strings.Cut(block, "data: ")
+			if !found {
+				continue
+			}
+			var event struct {
+				Type  string `json:"type"`
+				Index int    `json:"index"`
+				Delta struct {
+					PartialJSON string `json:"partial_json"`
+				} `json:"delta"`
+			}
+			if err := json.Unmarshal([]byte(data), &event); err != nil {
+				t.Fatalf("invalid event %q: %v", data, err)
+			}
+			if event.Type == "content_block_delta" {
+				deltas = append(deltas, event.Delta.PartialJSON)
+			}
+		}
+		return deltas
+	}
+
+	// 默认逐个转发参数增量
+	if deltas := collect(StreamOptions{}); len(deltas) != 3 || deltas[0] != "{'city': " {
+		t.Fatalf("unexpected passthrough deltas: %q", deltas)
+	}
+
+	deltas := collect(StreamOptions{BufferToolArguments: true})
+	if !reflect.DeepEqual(deltas, []string{`{"city":"Paris"}`, `{"tz": "UTC"}`}) {
+		t.Fatalf("unexpected buffered deltas: %q", deltas)
+	}
+}
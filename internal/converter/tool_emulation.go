@@ -0,0 +1,691 @@
+package converter
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// 模拟工具调用时模型输出调用的标签
+const (
+	emulatedToolCallOpen  = "<tool_call>"
+	emulatedToolCallClose = "</tool_call>"
+)
+
+// ToolEmulation 为不支持原生函数调用的上游模拟工具调用：
+// 工具定义渲染进 system 提示词，模型按约定格式在文本中输出调用，响应中的调用再解析为 tool_use / tool_calls
+type ToolEmulation struct {
+	tools map[string]bool // 可调用的工具名，未声明的调用按普通文本处理
+}
+
+// emulatedToolDef 渲染进提示词的工具定义
+type emulatedToolDef struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description,omitempty"`
+	InputSchema map[string]interface{} `json:"input_schema"`
+}
+
+// emulatedToolCall 约定格式的工具调用
+type emulatedToolCall struct {
+	Name  string                 `json:"name"`
+	Input map[string]interface{} `json:"input"`
+}
+
+func newToolEmulation(defs []emulatedToolDef) *ToolEmulation {
+	tools := make(map[string]bool, len(defs))
+	for _, def := range defs {
+		tools[def.Name] = true
+	}
+	return &ToolEmulation{tools: tools}
+}
+
+// EmulateClaudeTools 将 Claude 请求的 tools 改写为提示词约定，请求没有工具时返回 nil
+// 历史消息中的 tool_use / tool_result 同步转换为约定格式的文本
+func EmulateClaudeTools(req *ClaudeRequest) *ToolEmulation {
+	if len(req.Tools) == 0 {
+		return nil
+	}
+
+	defs := make([]emulatedToolDef, 0, len(req.Tools))
+	for _, tool := range req.Tools {
+		defs = append(defs, emulatedToolDef{Name: tool.Name, Description: tool.Description, InputSchema: tool.InputSchema})
+	}
+	emulation := newToolEmulation(defs)
+
+	req.System = AppendSystemText(req.System, renderToolPrompt(defs, claudeToolChoiceInstruction(req.ToolChoice)))
+	req.Messages = emulation.ConvertClaudeMessages(req.Messages)
+	req.Tools = nil
+	req.ToolChoice = nil
+	return emulation
+}
+
+// EmulateOpenAITools 将 OpenAI Chat 请求的 tools 改写为提示词约定，请求没有工具时返回 nil
+// 历史消息中的 tool_calls 与 tool 消息同步转换为约定格式的文本
+func EmulateOpenAITools(req *OpenAIRequest) *ToolEmulation {
+	if len(req.Tools) == 0 {
+		return nil
+	}
+
+	defs := make([]emulatedToolDef, 0, len(req.Tools))
+	for _, tool := range req.Tools {
+		defs = append(defs, emulatedToolDef{Name: tool.Function.Name, Description: tool.Function.Description, InputSchema: tool.Function.Parameters})
+	}
+	emulation := newToolEmulation(defs)
+
+	prompt := renderToolPrompt(defs, openAIToolChoiceInstruction(req.ToolChoice))
+	messages := emulation.ConvertOpenAIMessages(req.Messages)
+	if len(messages) > 0 && messages[0].Role == "system" {
+		if system, ok := messages[0].Content.(string); ok {
+			messages[0].Content = system + "\n\n" + prompt
+			prompt = ""
+		}
+	}
+	if prompt != "" {
+		messages = append([]OpenAIMessage{{Role: "system", Content: prompt}}, messages...)
+	}
+
+	req.Messages = messages
+	req.Tools = nil
+	req.ToolChoice = nil
+	return emulation
+}
+
+// renderToolPrompt 渲染工具定义与调用约定
+func renderToolPrompt(defs []emulatedToolDef, instruction string) string {
+	var b strings.Builder
+	b.WriteString("# Tools\n\n")
+	b.WriteString("You have access to the following tools. Each line is a JSON object with the tool name, description and the JSON Schema of its input:\n\n<tools>\n")
+	for _, def := range defs {
+		data, _ := json.Marshal(def)
+		b.Write(data)
+		b.WriteString("\n")
+	}
+	b.WriteString("</tools>\n\n")
+	b.WriteString("To call a tool, output a block in exactly this format:\n\n")
+	b.WriteString(emulatedToolCallOpen + "\n{\"name\": \"tool_name\", \"input\": {\"argument\": \"value\"}}\n" + emulatedToolCallClose + "\n\n")
+	b.WriteString("Rules:\n")
+	b.WriteString("- The block must contain a single valid JSON object with \"name\" and \"input\"; \"input\" must match the tool's input schema.\n")
+	b.WriteString("- To call several tools at once, output several blocks.\n")
+	b.WriteString("- After your tool calls, end your reply. Results are returned in <tool_result> blocks in the next user message; never write <tool_result> blocks yourself.\n")
+	b.WriteString("- If no tool is needed, answer normally without any " + emulatedToolCallOpen + " block.")
+	if instruction != "" {
+		b.WriteString("\n- " + instruction)
+	}
+	return b.String()
+}
+
+// claudeToolChoiceInstruction 将 tool_choice 转换为提示词要求
+func claudeToolChoiceInstruction(choice *ClaudeToolChoice) string {
+	if choice == nil {
+		return ""
+	}
+	switch choice.Type {
+	case "any":
+		return "You must call at least one tool in this reply."
+	case "tool":
+		if choice.Name != nil {
+			return fmt.Sprintf("You must call the %s tool in this reply.", *choice.Name)
+		}
+	case "none":
+		return "Do not call any tool in this reply."
+	}
+	return ""
+}
+
+// openAIToolChoiceInstruction 将 OpenAI tool_choice（字符串或对象）转换为提示词要求
+func openAIToolChoiceInstruction(choice interface{}) string {
+	switch v := choice.(type) {
+	case string:
+		switch v {
+		case "required":
+			return claudeToolChoiceInstruction(&ClaudeToolChoice{Type: "any"})
+		case "none":
+			return claudeToolChoiceInstruction(&ClaudeToolChoice{Type: "none"})
+		}
+	case map[string]interface{}:
+		if function, ok := v["function"].(map[string]interface{}); ok {
+			if name, ok := function["name"].(string); ok {
+				return claudeToolChoiceInstruction(&ClaudeToolChoice{Type: "tool", Name: StringPtr(name)})
+			}
+		}
+	}
+	return ""
+}
+
+// formatToolCallText 将工具调用格式化为约定文本
+func formatToolCallText(name string, input interface{}) string {
+	if input == nil {
+		input = map[string]interface{}{}
+	}
+	data, _ := json.Marshal(struct {
+		Name  string      `json:"name"`
+		Input interface{} `json:"input"`
+	}{name, input})
+	return emulatedToolCallOpen + "\n" + string(data) + "\n" + emulatedToolCallClose
+}
+
+// formatToolResultText 将工具结果格式化为约定文本
+func formatToolResultText(id, content string, isError bool) string {
+	attrs := fmt.Sprintf(" id=%q", id)
+	if isError {
+		attrs += ` is_error="true"`
+	}
+	return "<tool_result" + attrs + ">\n" + content + "\n</tool_result>"
+}
+
+// ConvertClaudeMessages 将历史消息中的 tool_use / tool_result 块转换为约定格式的文本块
+// tool_result 中的图片与文档保留为独立内容块
+func (e *ToolEmulation) ConvertClaudeMessages(messages []ClaudeMessage) []ClaudeMessage {
+	result := make([]ClaudeMessage, len(messages))
+	for i, msg := range messages {
+		content := make([]ClaudeContentBlock, 0, len(msg.Content))
+		for _, block := range msg.Content {
+			switch block.Type {
+			case ContentTypeToolUse:
+				name := ""
+				if block.Name != nil {
+					name = *block.Name
+				}
+				content = append(content, ClaudeContentBlock{Type: ContentTypeText, Text: StringPtr(formatToolCallText(name, block.Input))})
+			case ContentTypeToolResult:
+				id := ""
+				if block.ToolUseID != nil {
+					id = *block.ToolUseID
+				}
+				isError := block.IsError != nil && *block.IsError
+				content = append(content, ClaudeContentBlock{Type: ContentTypeText, Text: StringPtr(formatToolResultText(id, block.Content.PlainText(), isError))})
+				content = append(content, block.Content.Images()...)
+				content = append(content, block.Content.Documents()...)
+			default:
+				content = append(content, block)
+			}
+		}
+		result[i] = ClaudeMessage{Role: msg.Role, Content: content}
+	}
+	return result
+}
+
+// ConvertOpenAIMessages 将历史消息中的 tool_calls 与 tool 消息转换为约定格式的文本，连续的 tool 消息合并为一条 user 消息
+func (e *ToolEmulation) ConvertOpenAIMessages(messages []OpenAIMessage) []OpenAIMessage {
+	result := make([]OpenAIMessage, 0, len(messages))
+	mergeToolResult := false
+	for _, msg := range messages {
+		switch {
+		case msg.Role == "assistant" && len(msg.ToolCalls) > 0:
+			var parts []string
+			if text := ExtractTextFromContent(msg.Content); text != "" {
+				parts = append(parts, text)
+			}
+			for _, call := range msg.ToolCalls {
+				var input interface{} = call.Function.Arguments
+				if json.Valid([]byte(call.Function.Arguments)) {
+					input = json.RawMessage(call.Function.Arguments)
+				}
+				parts = append(parts, formatToolCallText(call.Function.Name, input))
+			}
+			msg.Content = strings.Join(parts, "\n\n")
+			msg.ToolCalls = nil
+			result = append(result, msg)
+			mergeToolResult = false
+
+		case msg.Role == "tool":
+			text := formatToolResultText(msg.ToolCallID, ExtractTextFromContent(msg.Content), false)
+			if mergeToolResult {
+				last := &result[len(result)-1]
+				last.Content = last.Content.(string) + "\n\n" + text
+				continue
+			}
+			result = append(result, OpenAIMessage{Role: "user", Content: text})
+			mergeToolResult = true
+
+		default:
+			result = append(result, msg)
+			mergeToolResult = false
+		}
+	}
+	return result
+}
+
+// toolCallSegment 文本解析结果：普通文本或工具调用
+type toolCallSegment struct {
+	text string
+	call *emulatedToolCall
+}
+
+// toolCallParser 增量解析文本中的工具调用块
+// 未闭合的调用块，以及结尾处可能是起始标签前缀的文本会暂存到后续输入或 flush
+type toolCallParser struct {
+	tools  map[string]bool
+	buffer string
+	inCall bool
+}
+
+func (e *ToolEmulation) newParser() *toolCallParser {
+	return &toolCallParser{tools: e.tools}
+}
+
+// feed 追加文本，返回已能确定的片段
+func (p *toolCallParser) feed(text string) []toolCallSegment {
+	p.buffer += text
+	var segments []toolCallSegment
+	for {
+		if p.inCall {
+			end := strings.Index(p.buffer, emulatedToolCallClose)
+			if end < 0 {
+				return segments
+			}
+			segments = append(segments, p.callSegment(p.buffer[:end], p.buffer[:end+len(emulatedToolCallClose)]))
+			p.buffer = p.buffer[end+len(emulatedToolCallClose):]
+			p.inCall = false
+			continue
+		}
+
+		start := strings.Index(p.buffer, emulatedToolCallOpen)
+		if start < 0 {
+			// 保留可能是起始标签前缀的结尾
+			keep := partialTagSuffix(p.buffer, emulatedToolCallOpen)
+			if flushed := p.buffer[:len(p.buffer)-keep]; flushed != "" {
+				segments = append(segments, toolCallSegment{text: flushed})
+			}
+			p.buffer = p.buffer[len(p.buffer)-keep:]
+			return segments
+		}
+		if start > 0 {
+			segments = append(segments, toolCallSegment{text: p.buffer[:start]})
+		}
+		p.buffer = p.buffer[start+len(emulatedToolCallOpen):]
+		p.inCall = true
+	}
+}
+
+// flush 输出暂存内容；未闭合的调用块（如输出被截断或命中停止序列）能解析时仍视为调用
+func (p *toolCallParser) flush() []toolCallSegment {
+	var segments []toolCallSegment
+	switch {
+	case p.inCall:
+		segments = append(segments, p.callSegment(p.buffer, p.buffer))
+	case p.buffer != "":
+		segments = append(segments, toolCallSegment{text: p.buffer})
+	}
+	p.buffer = ""
+	p.inCall = false
+	return segments
+}
+
+// callSegment 解析调用块内容，无法解析或工具未声明时按原文本输出
+func (p *toolCallParser) callSegment(body, raw string) toolCallSegment {
+	if call, ok := p.parseCall(body); ok {
+		return toolCallSegment{call: call}
+	}
+	return toolCallSegment{text: emulatedToolCallOpen + raw}
+}
+
+// parseCall 解析 {"name": ..., "input": {...}}，兼容以 arguments 给出参数（对象或 JSON 字符串）
+func (p *toolCallParser) parseCall(body string) (*emulatedToolCall, bool) {
+	var raw struct {
+		Name      string          `json:"name"`
+		Input     json.RawMessage `json:"input"`
+		Arguments json.RawMessage `json:"arguments"`
+	}
+	if err := json.Unmarshal([]byte(strings.TrimSpace(body)), &raw); err != nil || !p.tools[raw.Name] {
+		return nil, false
+	}
+
+	args := raw.Input
+	if len(args) == 0 {
+		args = raw.Arguments
+	}
+	input, ok := decodeEmulatedToolInput(args)
+	if !ok {
+		return nil, false
+	}
+	return &emulatedToolCall{Name: raw.Name, Input: input}, true
+}
+
+// decodeEmulatedToolInput 解析工具参数：JSON 对象，或内容为 JSON 对象的字符串；缺省时为空对象
+func decodeEmulatedToolInput(data json.RawMessage) (map[string]interface{}, bool) {
+	if len(data) == 0 || string(data) == "null" {
+		return map[string]interface{}{}, true
+	}
+	var encoded string
+	if err := json.Unmarshal(data, &encoded); err == nil {
+		data = json.RawMessage(encoded)
+	}
+	var input map[string]interface{}
+	if err := json.Unmarshal(data, &input); err != nil || input == nil {
+		return nil, false
+	}
+	return input, true
+}
+
+// partialTagSuffix 返回 text 结尾与 tag 前缀重合的最大长度
+func partialTagSuffix(text, tag string) int {
+	for n := min(len(text), len(tag)-1); n > 0; n-- {
+		if strings.HasSuffix(text, tag[:n]) {
+			return n
+		}
+	}
+	return 0
+}
+
+// parseText 解析完整文本，合并相邻文本片段；存在工具调用时去掉调用前后的空白
+func (e *ToolEmulation) parseText(text string) ([]toolCallSegment, bool) {
+	parser := e.newParser()
+	segments := append(parser.feed(text), parser.flush()...)
+
+	var result []toolCallSegment
+	var pending strings.Builder
+	called := false
+	flushText := func() {
+		if text := strings.TrimSpace(pending.String()); text != "" {
+			result = append(result, toolCallSegment{text: text})
+		}
+		pending.Reset()
+	}
+	for _, segment := range segments {
+		if segment.call == nil {
+			pending.WriteString(segment.text)
+			continue
+		}
+		flushText()
+		result = append(result, segment)
+		called = true
+	}
+	flushText()
+	return result, called
+}
+
+// ConvertClaudeResponse 将响应文本中的工具调用转换为 tool_use 块
+func (e *ToolEmulation) ConvertClaudeResponse(resp *ClaudeResponse) {
+	content := make([]ClaudeContentBlock, 0, len(resp.Content))
+	called := false
+	for _, block := range resp.Content {
+		if block.Type != ContentTypeText || block.Text == nil {
+			content = append(content, block)
+			continue
+		}
+		segments, hasCall := e.parseText(*block.Text)
+		if !hasCall {
+			content = append(content, block)
+			continue
+		}
+		called = true
+		for _, segment := range segments {
+			if segment.call != nil {
+				content = append(content, ClaudeContentBlock{
+					Type:  ContentTypeToolUse,
+					ID:    StringPtr("toolu_" + randomHexID()),
+					Name:  StringPtr(segment.call.Name),
+					Input: segment.call.Input,
+				})
+				continue
+			}
+			content = append(content, ClaudeContentBlock{Type: ContentTypeText, Text: StringPtr(segment.text)})
+		}
+	}
+	resp.Content = content
+	if called && resp.StopReason != "max_tokens" {
+		resp.StopReason = "tool_use"
+	}
+}
+
+// ConvertOpenAIResponse 将响应文本中的工具调用转换为 tool_calls
+func (e *ToolEmulation) ConvertOpenAIResponse(resp *OpenAIResponse) {
+	for i := range resp.Choices {
+		choice := &resp.Choices[i]
+		text, ok := choice.Message.Content.(string)
+		if !ok {
+			continue
+		}
+		segments, hasCall := e.parseText(text)
+		if !hasCall {
+			continue
+		}
+
+		var texts []string
+		for _, segment := range segments {
+			if segment.call == nil {
+				texts = append(texts, segment.text)
+				continue
+			}
+			arguments, _ := json.Marshal(segment.call.Input)
+			choice.Message.ToolCalls = append(choice.Message.ToolCalls, OpenAIToolCall{
+				ID:       "call_" + randomHexID(),
+				Type:     "function",
+				Function: OpenAIFunctionCall{Name: segment.call.Name, Arguments: string(arguments)},
+			})
+		}
+		choice.Message.Content = nil
+		if len(texts) > 0 {
+			choice.Message.Content = strings.Join(texts, "\n\n")
+		}
+		if choice.FinishReason != "length" {
+			choice.FinishReason = "tool_calls"
+		}
+	}
+}
+
+// ClaudeToolEmulationStream 在 Claude SSE 事件中将文本里的工具调用转换为 tool_use 内容块
+// 文本块被重新切分，因此输出的内容块索引由本结构重新分配
+type ClaudeToolEmulationStream struct {
+	parser     *toolCallParser
+	textBlocks map[int]bool // 上游文本块索引
+	blocks     map[int]int  // 上游其他内容块索引 -> 输出索引
+	next       int          // 下一个输出内容块索引
+	textOpen   bool         // 输出中是否有未结束的文本块
+	called     bool
+}
+
+// NewClaudeStream 创建 Claude 流式转换器
+func (e *ToolEmulation) NewClaudeStream() *ClaudeToolEmulationStream {
+	return &ClaudeToolEmulationStream{
+		parser:     e.newParser(),
+		textBlocks: make(map[int]bool),
+		blocks:     make(map[int]int),
+	}
+}
+
+// ProcessEvent 转换一个 Claude SSE 事件的 data，返回需要依次写出的事件
+func (s *ClaudeToolEmulationStream) ProcessEvent(data []byte) ([][]byte, error) {
+	var event map[string]interface{}
+	if err := json.Unmarshal(data, &event); err != nil {
+		return nil, fmt.Errorf("解析 Claude 流式事件失败: %w", err)
+	}
+
+	index := -1
+	if value, ok := event["index"].(float64); ok {
+		index = int(value)
+	}
+
+	switch event["type"] {
+	case EventTypeContentBlockStart:
+		block, _ := event["content_block"].(map[string]interface{})
+		if block["type"] == ContentTypeText {
+			s.textBlocks[index] = true
+			text, _ := block["text"].(string)
+			return s.emitSegments(s.parser.feed(text)), nil
+		}
+		events := s.closeText()
+		s.blocks[index] = s.next
+		s.next++
+		event["index"] = s.blocks[index]
+		return append(events, marshalEmulatedEvent(event)), nil
+
+	case EventTypeContentBlockDelta:
+		if s.textBlocks[index] {
+			delta, _ := event["delta"].(map[string]interface{})
+			if delta["type"] != DeltaTypeTextDelta {
+				return nil, nil
+			}
+			text, _ := delta["text"].(string)
+			return s.emitSegments(s.parser.feed(text)), nil
+		}
+		if out, ok := s.blocks[index]; ok {
+			event["index"] = out
+		}
+		return [][]byte{marshalEmulatedEvent(event)}, nil
+
+	case EventTypeContentBlockStop:
+		if s.textBlocks[index] {
+			delete(s.textBlocks, index)
+			return append(s.emitSegments(s.parser.flush()), s.closeText()...), nil
+		}
+		if out, ok := s.blocks[index]; ok {
+			event["index"] = out
+		}
+		return [][]byte{marshalEmulatedEvent(event)}, nil
+
+	case EventTypeMessageDelta:
+		events := append(s.emitSegments(s.parser.flush()), s.closeText()...)
+		if delta, ok := event["delta"].(map[string]interface{}); ok && s.called && delta["stop_reason"] != "max_tokens" {
+			delta["stop_reason"] = "tool_use"
+		}
+		return append(events, marshalEmulatedEvent(event)), nil
+	}
+
+	return [][]byte{data}, nil
+}
+
+// emitSegments 将解析片段转换为文本增量或完整的 tool_use 内容块事件
+func (s *ClaudeToolEmulationStream) emitSegments(segments []toolCallSegment) [][]byte {
+	var events [][]byte
+	for _, segment := range segments {
+		if segment.call == nil {
+			text := segment.text
+			if !s.textOpen {
+				// 不为调用之间的空白单独开启文本块
+				if text = strings.TrimLeft(text, " \t\r\n"); text == "" {
+					continue
+				}
+				events = append(events, marshalEmulatedEvent(map[string]interface{}{
+					"type":          EventTypeContentBlockStart,
+					"index":         s.next,
+					"content_block": map[string]interface{}{"type": ContentTypeText, "text": ""},
+				}))
+				s.textOpen = true
+			}
+			events = append(events, marshalEmulatedEvent(map[string]interface{}{
+				"type":  EventTypeContentBlockDelta,
+				"index": s.next,
+				"delta": map[string]interface{}{"type": DeltaTypeTextDelta, "text": text},
+			}))
+			continue
+		}
+
+		events = append(events, s.closeText()...)
+		arguments, _ := json.Marshal(segment.call.Input)
+		events = append(events,
+			marshalEmulatedEvent(map[string]interface{}{
+				"type":  EventTypeContentBlockStart,
+				"index": s.next,
+				"content_block": map[string]interface{}{
+					"type":  ContentTypeToolUse,
+					"id":    "toolu_" + randomHexID(),
+					"name":  segment.call.Name,
+					"input": map[string]interface{}{},
+				},
+			}),
+			marshalEmulatedEvent(map[string]interface{}{
+				"type":  EventTypeContentBlockDelta,
+				"index": s.next,
+				"delta": map[string]interface{}{"type": DeltaTypeInputJSONDelta, "partial_json": string(arguments)},
+			}),
+			marshalEmulatedEvent(map[string]interface{}{"type": EventTypeContentBlockStop, "index": s.next}),
+		)
+		s.next++
+		s.called = true
+	}
+	return events
+}
+
+// closeText 结束输出中未结束的文本块
+func (s *ClaudeToolEmulationStream) closeText() [][]byte {
+	if !s.textOpen {
+		return nil
+	}
+	event := marshalEmulatedEvent(map[string]interface{}{"type": EventTypeContentBlockStop, "index": s.next})
+	s.textOpen = false
+	s.next++
+	return [][]byte{event}
+}
+
+func marshalEmulatedEvent(event map[string]interface{}) []byte {
+	data, _ := json.Marshal(event)
+	return data
+}
+
+// OpenAIToolEmulationStream 在 OpenAI 流式 chunk 中将文本里的工具调用转换为 tool_calls 增量
+type OpenAIToolEmulationStream struct {
+	emulation *ToolEmulation
+	parsers   map[int]*toolCallParser // 按 choice 索引
+	calls     map[int]int             // 每个 choice 已输出的工具调用数
+}
+
+// NewOpenAIStream 创建 OpenAI 流式转换器
+func (e *ToolEmulation) NewOpenAIStream() *OpenAIToolEmulationStream {
+	return &OpenAIToolEmulationStream{
+		emulation: e,
+		parsers:   make(map[int]*toolCallParser),
+		calls:     make(map[int]int),
+	}
+}
+
+// ProcessChunk 转换一个 chunk 的 data（不含 [DONE]），没有需要写出的内容时返回 nil
+// 工具调用以完整参数一次性输出；带 finish_reason 的 chunk 先输出暂存文本
+func (s *OpenAIToolEmulationStream) ProcessChunk(data []byte) ([]byte, error) {
+	var chunk OpenAIStreamChunk
+	if err := json.Unmarshal(data, &chunk); err != nil {
+		return nil, fmt.Errorf("解析 OpenAI 流式 chunk 失败: %w", err)
+	}
+	if len(chunk.Choices) == 0 {
+		return data, nil
+	}
+
+	keep := chunk.Usage != nil
+	for i := range chunk.Choices {
+		choice := &chunk.Choices[i]
+		parser, ok := s.parsers[choice.Index]
+		if !ok {
+			parser = s.emulation.newParser()
+			s.parsers[choice.Index] = parser
+		}
+
+		segments := parser.feed(choice.Delta.Content)
+		if choice.FinishReason != nil {
+			segments = append(segments, parser.flush()...)
+		}
+
+		var text strings.Builder
+		for _, segment := range segments {
+			if segment.call == nil {
+				// 调用之后的空白不再输出
+				if s.calls[choice.Index] == 0 || strings.TrimSpace(segment.text) != "" {
+					text.WriteString(segment.text)
+				}
+				continue
+			}
+			arguments, _ := json.Marshal(segment.call.Input)
+			choice.Delta.ToolCalls = append(choice.Delta.ToolCalls, OpenAIStreamToolCall{
+				Index:    s.calls[choice.Index],
+				ID:       "call_" + randomHexID(),
+				Type:     "function",
+				Function: &OpenAIStreamFunctionCall{Name: segment.call.Name, Arguments: string(arguments)},
+			})
+			s.calls[choice.Index]++
+		}
+		choice.Delta.Content = text.String()
+
+		if choice.FinishReason != nil && s.calls[choice.Index] > 0 && *choice.FinishReason != "length" {
+			choice.FinishReason = StringPtr("tool_calls")
+		}
+		delta := choice.Delta
+		if delta.Role != "" || delta.Content != "" || len(delta.ToolCalls) > 0 || delta.ReasoningContent != "" || delta.Reasoning != "" || choice.FinishReason != nil {
+			keep = true
+		}
+	}
+
+	if !keep {
+		return nil, nil
+	}
+	return json.Marshal(chunk)
+}
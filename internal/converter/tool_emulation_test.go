@@ -0,0 +1,244 @@
+package converter
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// weatherTool 测试用的工具定义
+func weatherTool() ClaudeTool {
+	return ClaudeTool{
+		Name:        "get_weather",
+		Description: "Get the weather",
+		InputSchema: map[string]interface{}{"type": "object", "properties": map[string]interface{}{"city": map[string]interface{}{"type": "string"}}},
+	}
+}
+
+// 测试工具定义渲染进 system 提示词，历史中的工具调用转换为文本
+func TestEmulateClaudeTools(t *testing.T) {
+	req := &ClaudeRequest{
+		Model:      "m",
+		MaxTokens:  256,
+		System:     NewSystemPrompt("Be brief."),
+		Tools:      []ClaudeTool{weatherTool()},
+		ToolChoice: &ClaudeToolChoice{Type: "tool", Name: StringPtr("get_weather")},
+		Messages: []ClaudeMessage{
+			{Role: "user", Content: []ClaudeContentBlock{{Type: ContentTypeText, Text: StringPtr("weather in London?")}}},
+			{Role: "assistant", Content: []ClaudeContentBlock{{Type: ContentTypeToolUse, ID: StringPtr("toolu_0"), Name: StringPtr("get_weather"), Input: map[string]interface{}{"city": "London"}}}},
+			{Role: "user", Content: []ClaudeContentBlock{{Type: ContentTypeToolResult, ToolUseID: StringPtr("toolu_0"), Content: NewToolResultText("Rain"), IsError: BoolPtr(true)}}},
+		},
+	}
+
+	emulation := EmulateClaudeTools(req)
+	if emulation == nil {
+		t.Fatal("有工具时应返回模拟器")
+	}
+	if req.Tools != nil || req.ToolChoice != nil {
+		t.Fatalf("tools 与 tool_choice 应被移除: %+v %+v", req.Tools, req.ToolChoice)
+	}
+	system := req.System.PlainText()
+	if !strings.HasPrefix(system, "Be brief.") || !strings.Contains(system, `"name":"get_weather"`) || !strings.Contains(system, "You must call the get_weather tool") {
+		t.Fatalf("system 提示词不正确: %q", system)
+	}
+	if text := *req.Messages[1].Content[0].Text; text != "<tool_call>\n{\"name\":\"get_weather\",\"input\":{\"city\":\"London\"}}\n</tool_call>" {
+		t.Fatalf("tool_use 转换不正确: %q", text)
+	}
+	if text := *req.Messages[2].Content[0].Text; text != "<tool_result id=\"toolu_0\" is_error=\"true\">\nRain\n</tool_result>" {
+		t.Fatalf("tool_result 转换不正确: %q", text)
+	}
+
+	if EmulateClaudeTools(&ClaudeRequest{Model: "m"}) != nil {
+		t.Fatal("没有工具时应返回 nil")
+	}
+}
+
+// 测试非流式响应中的工具调用解析，未声明的工具与无效 JSON 保留为文本
+func TestToolEmulation_ClaudeResponse(t *testing.T) {
+	emulation := EmulateClaudeTools(&ClaudeRequest{Tools: []ClaudeTool{weatherTool()}})
+
+	resp := &ClaudeResponse{StopReason: "end_turn", Content: []ClaudeContentBlock{{Type: ContentTypeText, Text: StringPtr(
+		"Checking.\n<tool_call>\n{\"name\": \"get_weather\", \"arguments\": \"{\\\"city\\\": \\\"Paris\\\"}\"}\n</tool_call>\n<tool_call>{\"name\": \"get_weather\", \"input\": {}}</tool_call>",
+	)}}}
+	emulation.ConvertClaudeResponse(resp)
+	if resp.StopReason != "tool_use" || len(resp.Content) != 3 {
+		t.Fatalf("应解析出两个调用: %+v", resp)
+	}
+	if *resp.Content[0].Text != "Checking." {
+		t.Fatalf("调用前的文本不正确: %q", *resp.Content[0].Text)
+	}
+	if call := resp.Content[1]; call.Type != ContentTypeToolUse || *call.Name != "get_weather" || call.Input["city"] != "Paris" || !strings.HasPrefix(*call.ID, "toolu_") {
+		t.Fatalf("第一个调用不正确: %+v", call)
+	}
+	if call := resp.Content[2]; call.Type != ContentTypeToolUse || len(call.Input) != 0 || *call.ID == *resp.Content[1].ID {
+		t.Fatalf("第二个调用不正确: %+v", call)
+	}
+
+	// 命中停止序列时结尾的调用块没有闭合标签
+	resp = &ClaudeResponse{StopReason: "stop_sequence", Content: []ClaudeContentBlock{{Type: ContentTypeText, Text: StringPtr("<tool_call>{\"name\": \"get_weather\", \"input\": {}}\n")}}}
+	emulation.ConvertClaudeResponse(resp)
+	if resp.StopReason != "tool_use" || len(resp.Content) != 1 || resp.Content[0].Type != ContentTypeToolUse {
+		t.Fatalf("未闭合但完整的调用应被解析: %+v", resp)
+	}
+
+	for _, text := range []string{
+		"<tool_call>{\"name\": \"rm_rf\", \"input\": {}}</tool_call>",
+		"<tool_call>{\"name\": \"get_weather\", \"input\": {</tool_call>",
+		"<tool_call>{\"name\": \"get_weather\", \"input\": {\"city\": \"Pa",
+	} {
+		resp := &ClaudeResponse{StopReason: "end_turn", Content: []ClaudeContentBlock{{Type: ContentTypeText, Text: StringPtr(text)}}}
+		emulation.ConvertClaudeResponse(resp)
+		if resp.StopReason != "end_turn" || len(resp.Content) != 1 || *resp.Content[0].Text != text {
+			t.Errorf("%q 应保留为文本: %+v", text, resp)
+		}
+	}
+}
+
+// 测试流式响应中跨增量的调用标签
+func TestToolEmulation_ClaudeStream(t *testing.T) {
+	stream := EmulateClaudeTools(&ClaudeRequest{Tools: []ClaudeTool{weatherTool()}}).NewClaudeStream()
+
+	var out []map[string]interface{}
+	feed := func(event string) {
+		t.Helper()
+		events, err := stream.ProcessEvent([]byte(event))
+		if err != nil {
+			t.Fatalf("处理事件失败: %v", err)
+		}
+		for _, data := range events {
+			var parsed map[string]interface{}
+			if err := json.Unmarshal(data, &parsed); err != nil {
+				t.Fatalf("输出事件无效 %s: %v", data, err)
+			}
+			out = append(out, parsed)
+		}
+	}
+
+	feed(`{"type":"message_start","message":{"id":"msg_1"}}`)
+	feed(`{"type":"content_block_start","index":0,"content_block":{"type":"thinking","thinking":""}}`)
+	feed(`{"type":"content_block_stop","index":0}`)
+	feed(`{"type":"content_block_start","index":1,"content_block":{"type":"text","text":""}}`)
+	for _, text := range []string{"Hi <", "tool_ca", "ll>{\"name\": \"get_weather\", ", "\"input\": {\"city\": \"Paris\"}}</tool_", "call> "} {
+		data, _ := json.Marshal(text)
+		feed(`{"type":"content_block_delta","index":1,"delta":{"type":"text_delta","text":` + string(data) + `}}`)
+	}
+	feed(`{"type":"content_block_stop","index":1}`)
+	feed(`{"type":"message_delta","delta":{"stop_reason":"end_turn"}}`)
+
+	var summary []string
+	for _, event := range out {
+		entry := event["type"].(string)
+		if index, ok := event["index"].(float64); ok {
+			entry += ":" + string(rune('0'+int(index)))
+		}
+		if block, ok := event["content_block"].(map[string]interface{}); ok {
+			entry += ":" + block["type"].(string)
+		}
+		if delta, ok := event["delta"].(map[string]interface{}); ok {
+			if text, ok := delta["text"].(string); ok {
+				entry += ":" + text
+			}
+			if partial, ok := delta["partial_json"].(string); ok {
+				entry += ":" + partial
+			}
+			if reason, ok := delta["stop_reason"].(string); ok {
+				entry += ":" + reason
+			}
+		}
+		summary = append(summary, entry)
+	}
+	want := []string{
+		"message_start",
+		"content_block_start:0:thinking",
+		"content_block_stop:0",
+		"content_block_start:1:text",
+		"content_block_delta:1:Hi ",
+		"content_block_stop:1",
+		"content_block_start:2:tool_use",
+		`content_block_delta:2:{"city":"Paris"}`,
+		"content_block_stop:2",
+		"message_delta:tool_use",
+	}
+	if strings.Join(summary, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("事件序列不正确:\ngot:\n%s\nwant:\n%s", strings.Join(summary, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+// 测试 OpenAI 请求改写与响应解析
+func TestEmulateOpenAITools(t *testing.T) {
+	req := &OpenAIRequest{
+		Model:      "m",
+		ToolChoice: map[string]interface{}{"type": "function", "function": map[string]interface{}{"name": "get_weather"}},
+		Tools:      []OpenAITool{{Type: "function", Function: OpenAIFunctionDef{Name: "get_weather", Parameters: weatherTool().InputSchema}}},
+		Messages: []OpenAIMessage{
+			{Role: "user", Content: "weather?"},
+			{Role: "assistant", ToolCalls: []OpenAIToolCall{
+				{ID: "call_a", Type: "function", Function: OpenAIFunctionCall{Name: "get_weather", Arguments: `{"city":"London"}`}},
+				{ID: "call_b", Type: "function", Function: OpenAIFunctionCall{Name: "get_weather", Arguments: `{"city":"Rome"}`}},
+			}},
+			{Role: "tool", ToolCallID: "call_a", Content: "Rain"},
+			{Role: "tool", ToolCallID: "call_b", Content: "Sun"},
+		},
+	}
+
+	emulation := EmulateOpenAITools(req)
+	if emulation == nil || req.Tools != nil || req.ToolChoice != nil {
+		t.Fatalf("请求改写不正确: %+v", req)
+	}
+	if len(req.Messages) != 4 || req.Messages[0].Role != "system" || !strings.Contains(req.Messages[0].Content.(string), "You must call the get_weather tool") {
+		t.Fatalf("应插入 system 消息: %+v", req.Messages)
+	}
+	if content := req.Messages[2].Content.(string); strings.Count(content, "<tool_call>") != 2 || req.Messages[2].ToolCalls != nil {
+		t.Fatalf("tool_calls 应转换为文本: %+v", req.Messages[2])
+	}
+	if msg := req.Messages[3]; msg.Role != "user" || msg.Content != "<tool_result id=\"call_a\">\nRain\n</tool_result>\n\n<tool_result id=\"call_b\">\nSun\n</tool_result>" {
+		t.Fatalf("连续的 tool 消息应合并: %+v", msg)
+	}
+
+	resp := &OpenAIResponse{Choices: []OpenAIChoice{{Message: OpenAIMessage{Role: "assistant", Content: "<tool_call>{\"name\":\"get_weather\",\"input\":{\"city\":\"Paris\"}}</tool_call>"}, FinishReason: "stop"}}}
+	emulation.ConvertOpenAIResponse(resp)
+	choice := resp.Choices[0]
+	if choice.FinishReason != "tool_calls" || choice.Message.Content != nil || len(choice.Message.ToolCalls) != 1 {
+		t.Fatalf("响应转换不正确: %+v", choice)
+	}
+	if call := choice.Message.ToolCalls[0]; call.Function.Name != "get_weather" || call.Function.Arguments != `{"city":"Paris"}` || !strings.HasPrefix(call.ID, "call_") {
+		t.Fatalf("tool_calls 不正确: %+v", call)
+	}
+}
+
+// 测试 OpenAI 流式 chunk 转换
+func TestToolEmulation_OpenAIStream(t *testing.T) {
+	stream := EmulateOpenAITools(&OpenAIRequest{Tools: []OpenAITool{{Type: "function", Function: OpenAIFunctionDef{Name: "get_weather"}}}}).NewOpenAIStream()
+
+	chunk := func(content, finish string) []byte {
+		finishJSON := "null"
+		if finish != "" {
+			finishJSON = `"` + finish + `"`
+		}
+		data, _ := json.Marshal(content)
+		return []byte(`{"id":"c","object":"chat.completion.chunk","choices":[{"index":0,"delta":{"content":` + string(data) + `},"finish_reason":` + finishJSON + `}]}`)
+	}
+
+	out, err := stream.ProcessChunk(chunk("OK <tool_c", ""))
+	if err != nil || !strings.Contains(string(out), `"content":"OK "`) {
+		t.Fatalf("调用前的文本应立即输出: %s %v", out, err)
+	}
+	if out, err := stream.ProcessChunk(chunk(`all>{"name":"get_weather"}`, "")); err != nil || out != nil {
+		t.Fatalf("未闭合的调用应暂存: %s %v", out, err)
+	}
+	out, err = stream.ProcessChunk(chunk("</tool_call>", "stop"))
+	if err != nil {
+		t.Fatalf("处理 chunk 失败: %v", err)
+	}
+	var parsed OpenAIStreamChunk
+	if err := json.Unmarshal(out, &parsed); err != nil {
+		t.Fatalf("输出 chunk 无效 %s: %v", out, err)
+	}
+	choice := parsed.Choices[0]
+	if choice.FinishReason == nil || *choice.FinishReason != "tool_calls" || len(choice.Delta.ToolCalls) != 1 {
+		t.Fatalf("最后的 chunk 不正确: %s", out)
+	}
+	if call := choice.Delta.ToolCalls[0]; call.Index != 0 || call.Function.Name != "get_weather" || call.Function.Arguments != "{}" {
+		t.Fatalf("tool_calls 增量不正确: %+v", call)
+	}
+}
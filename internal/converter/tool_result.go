@@ -0,0 +1,112 @@
+package converter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// toolResultErrorPrefix is_error 的 tool_result 转换为 OpenAI tool 消息时的内容前缀
+const toolResultErrorPrefix = "[ERROR] "
+
+// NewToolResultText 创建字符串形式的 tool_result 内容
+func NewToolResultText(text string) *ClaudeToolResultContent {
+	return &ClaudeToolResultContent{Text: text}
+}
+
+// UnmarshalJSON 解析字符串或内容块数组
+func (c *ClaudeToolResultContent) UnmarshalJSON(data []byte) error {
+	text, blocks, err := unmarshalStringOrBlocks(data)
+	if err != nil {
+		return fmt.Errorf("tool_result content 必须是字符串或内容块数组: %w", err)
+	}
+	*c = ClaudeToolResultContent{Text: text, Blocks: blocks}
+	return nil
+}
+
+// MarshalJSON 按原始形式序列化
+func (c ClaudeToolResultContent) MarshalJSON() ([]byte, error) {
+	return marshalStringOrBlocks(c.Text, c.Blocks)
+}
+
+// unmarshalStringOrBlocks 解析字符串或内容块数组形式的字段，数组形式返回非 nil 的 blocks
+func unmarshalStringOrBlocks(data []byte) (string, []ClaudeContentBlock, error) {
+	data = bytes.TrimSpace(data)
+	switch {
+	case bytes.Equal(data, []byte("null")):
+		return "", nil, nil
+	case len(data) > 0 && data[0] == '"':
+		var text string
+		if err := json.Unmarshal(data, &text); err != nil {
+			return "", nil, err
+		}
+		return text, nil, nil
+	case len(data) > 0 && data[0] == '[':
+		var blocks []ClaudeContentBlock
+		if err := json.Unmarshal(data, &blocks); err != nil {
+			return "", nil, err
+		}
+		if blocks == nil {
+			blocks = []ClaudeContentBlock{}
+		}
+		return "", blocks, nil
+	}
+	return "", nil, fmt.Errorf("不支持的 JSON 类型")
+}
+
+// marshalStringOrBlocks 按原始形式序列化：blocks 非 nil 时输出数组，否则输出字符串
+func marshalStringOrBlocks(text string, blocks []ClaudeContentBlock) ([]byte, error) {
+	if blocks != nil {
+		return json.Marshal(blocks)
+	}
+	return json.Marshal(text)
+}
+
+// PlainText 合并 tool_result 中的文本（内容块之间以换行分隔）
+func (c *ClaudeToolResultContent) PlainText() string {
+	if c == nil {
+		return ""
+	}
+	if c.Blocks == nil {
+		return c.Text
+	}
+
+	var texts []string
+	for _, block := range c.Blocks {
+		if block.Type == ContentTypeText && block.Text != nil {
+			texts = append(texts, *block.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+// Images 返回 tool_result 中的图片块
+func (c *ClaudeToolResultContent) Images() []ClaudeContentBlock {
+	if c == nil {
+		return nil
+	}
+
+	var images []ClaudeContentBlock
+	for _, block := range c.Blocks {
+		if block.Type == ContentTypeImage {
+			images = append(images, block)
+		}
+	}
+	return images
+}
+
+// Documents 返回 tool_result 中的文档块
+func (c *ClaudeToolResultContent) Documents() []ClaudeContentBlock {
+	if c == nil {
+		return nil
+	}
+
+	var documents []ClaudeContentBlock
+	for _, block := range c.Blocks {
+		if block.Type == ContentTypeDocument {
+			documents = append(documents, block)
+		}
+	}
+	return documents
+}
@@ -0,0 +1,112 @@
+package converter
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var updateGolden = flag.Bool("update", false, "重新生成 testdata 中的 golden 文件")
+
+// 测试 tool_result 转换结果与 golden 文件一致
+// 输入为 testdata/tool_result/*.claude.json 中的单条 Claude 消息，期望输出为同名 .openai.json
+func TestConvertToolResultGolden(t *testing.T) {
+	inputs, err := filepath.Glob(filepath.Join("testdata", "tool_result", "*.claude.json"))
+	if err != nil || len(inputs) == 0 {
+		t.Fatalf("未找到 golden 输入文件: %v", err)
+	}
+
+	for _, input := range inputs {
+		name := strings.TrimSuffix(filepath.Base(input), ".claude.json")
+		t.Run(name, func(t *testing.T) {
+			raw, err := os.ReadFile(input)
+			if err != nil {
+				t.Fatalf("读取输入失败: %v", err)
+			}
+			var msg ClaudeMessage
+			if err := json.Unmarshal(raw, &msg); err != nil {
+				t.Fatalf("解析 Claude 消息失败: %v", err)
+			}
+
+			messages, err := convertSingleMessage(msg, ClaudeToOpenAIOptions{})
+			if err != nil {
+				t.Fatalf("转换失败: %v", err)
+			}
+			got, err := json.MarshalIndent(messages, "", "  ")
+			if err != nil {
+				t.Fatalf("序列化失败: %v", err)
+			}
+			got = append(got, '\n')
+
+			golden := strings.TrimSuffix(input, ".claude.json") + ".openai.json"
+			if *updateGolden {
+				if err := os.WriteFile(golden, got, 0o644); err != nil {
+					t.Fatalf("写入 golden 文件失败: %v", err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("读取 golden 文件失败（使用 -update 生成）: %v", err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("转换结果与 %s 不一致:\ngot:\n%s\nwant:\n%s", golden, got, want)
+			}
+		})
+	}
+}
+
+// 测试 tool_result content 的字符串 / 数组形式解析与序列化往返
+func TestToolResultContentJSON(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		wantText  string
+		wantImage int
+	}{
+		{"字符串", `"done"`, "done", 0},
+		{"内容块数组", `[{"type":"text","text":"a"},{"type":"image","source":{"type":"base64","media_type":"image/png","data":"x"}},{"type":"text","text":"b"}]`, "a\nb", 1},
+		{"空数组", `[]`, "", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var content ClaudeToolResultContent
+			if err := json.Unmarshal([]byte(tt.input), &content); err != nil {
+				t.Fatalf("解析失败: %v", err)
+			}
+			if content.PlainText() != tt.wantText {
+				t.Errorf("文本不匹配: got %q, want %q", content.PlainText(), tt.wantText)
+			}
+			if len(content.Images()) != tt.wantImage {
+				t.Errorf("图片数量不匹配: got %d, want %d", len(content.Images()), tt.wantImage)
+			}
+
+			out, err := json.Marshal(content)
+			if err != nil {
+				t.Fatalf("序列化失败: %v", err)
+			}
+			var roundTrip, original interface{}
+			_ = json.Unmarshal(out, &roundTrip)
+			_ = json.Unmarshal([]byte(tt.input), &original)
+			if !jsonEqual(roundTrip, original) {
+				t.Errorf("序列化未保留原始形式: %s", out)
+			}
+		})
+	}
+
+	var content ClaudeToolResultContent
+	if err := json.Unmarshal([]byte(`{"type":"text"}`), &content); err == nil {
+		t.Error("对象形式的 content 应该解析失败")
+	}
+}
+
+// jsonEqual 比较两个已解析的 JSON 值
+func jsonEqual(a, b interface{}) bool {
+	left, _ := json.Marshal(a)
+	right, _ := json.Marshal(b)
+	return bytes.Equal(left, right)
+}
@@ -1,11 +1,13 @@
 package converter
 
+import "encoding/json"
+
 // Claude Types - Claude Messages API 请求和响应类型定义
 
 // ClaudeRequest Claude Messages API 请求
 type ClaudeRequest struct {
 	Model         string             `json:"model"`
-	System        string             `json:"system,omitempty"`
+	System        *ClaudeSystemPrompt `json:"system,omitempty"` // 字符串或 text 内容块数组
 	Messages      []ClaudeMessage    `json:"messages"`
 	MaxTokens     int                `json:"max_tokens"`
 	Temperature   *float64           `json:"temperature,omitempty"`
@@ -14,6 +16,13 @@ type ClaudeRequest struct {
 	StopSequences []string           `json:"stop_sequences,omitempty"`
 	Tools         []ClaudeTool       `json:"tools,omitempty"`
 	ToolChoice    *ClaudeToolChoice  `json:"tool_choice,omitempty"`
+	Thinking      *ClaudeThinking    `json:"thinking,omitempty"`
+}
+
+// ClaudeThinking 扩展思考配置
+type ClaudeThinking struct {
+	Type         string `json:"type"`                    // enabled | disabled
+	BudgetTokens int    `json:"budget_tokens,omitempty"` // 思考 token 预算，enabled 时必填
 }
 
 // ClaudeMessage Claude 消息
@@ -23,15 +32,17 @@ type ClaudeMessage struct {
 }
 
 // ClaudeContentBlock Claude 内容块
-// 支持多种类型: text, image, tool_use, tool_result
+// 支持多种类型: text, image, document, tool_use, tool_result, thinking, redacted_thinking
 type ClaudeContentBlock struct {
 	Type string `json:"type"`
 
 	// text 类型
 	Text *string `json:"text,omitempty"`
 
-	// image 类型
-	Source *ClaudeImageSource `json:"source,omitempty"`
+	// image / document 类型
+	Source  *ClaudeImageSource `json:"source,omitempty"`
+	Title   *string            `json:"title,omitempty"`   // document 类型
+	Context *string            `json:"context,omitempty"` // document 类型
 
 	// tool_use 类型
 	ID    *string                `json:"id,omitempty"`
@@ -39,22 +50,55 @@ type ClaudeContentBlock struct {
 	Input map[string]interface{} `json:"input,omitempty"`
 
 	// tool_result 类型
-	ToolUseID *string `json:"tool_use_id,omitempty"`
-	Content   *string `json:"content,omitempty"` // tool result content
+	ToolUseID *string                  `json:"tool_use_id,omitempty"`
+	Content   *ClaudeToolResultContent `json:"content,omitempty"` // 字符串或 text / image 内容块数组
+	IsError   *bool                    `json:"is_error,omitempty"`
+
+	// thinking 类型（redacted_thinking 的加密内容在 data 中）
+	Thinking  *string `json:"thinking,omitempty"`
+	Signature *string `json:"signature,omitempty"`
+	Data      *string `json:"data,omitempty"`
+
+	// 提示词缓存断点（text、image、tool_use、tool_result 等均可携带）
+	CacheControl *ClaudeCacheControl `json:"cache_control,omitempty"`
+}
+
+// ClaudeSystemPrompt system 提示词
+// Claude 允许字符串或 text 内容块数组两种形式，数组形式可在块上设置 cache_control，Blocks 非 nil 时按数组序列化
+type ClaudeSystemPrompt struct {
+	Text   string
+	Blocks []ClaudeContentBlock
+}
+
+// ClaudeCacheControl 提示词缓存断点
+type ClaudeCacheControl struct {
+	Type string `json:"type"`          // ephemeral
+	TTL  string `json:"ttl,omitempty"` // 5m（默认）| 1h
+}
+
+// ClaudeToolResultContent tool_result 内容
+// Claude 允许字符串或内容块数组两种形式，Blocks 非 nil 时按数组序列化
+type ClaudeToolResultContent struct {
+	Text   string
+	Blocks []ClaudeContentBlock
 }
 
-// ClaudeImageSource 图片来源
+// ClaudeImageSource 图片 / 文档来源
 type ClaudeImageSource struct {
-	Type      string `json:"type"`       // base64 | url
-	MediaType string `json:"media_type"` // image/jpeg, image/png, etc.
-	Data      string `json:"data"`       // base64 string or URL
+	Type      string               `json:"type"`                 // base64 | url | text（文档） | content（文档）
+	MediaType string               `json:"media_type,omitempty"` // image/jpeg, image/png, application/pdf, text/plain, etc.
+	Data      string               `json:"data,omitempty"`       // base64 数据，text 来源为纯文本
+	URL       string               `json:"url,omitempty"`        // url 来源
+	Content   []ClaudeContentBlock `json:"content,omitempty"`    // content 来源的内容块
 }
 
 // ClaudeTool Claude 工具定义
 type ClaudeTool struct {
-	Name        string                 `json:"name"`
-	Description string                 `json:"description"`
-	InputSchema map[string]interface{} `json:"input_schema"`
+	Type         string                 `json:"type,omitempty"` // custom（客户端工具，可省略）
+	Name         string                 `json:"name"`
+	Description  string                 `json:"description"`
+	InputSchema  map[string]interface{} `json:"input_schema"`
+	CacheControl *ClaudeCacheControl    `json:"cache_control,omitempty"`
 }
 
 // ClaudeToolChoice Claude 工具选择
@@ -77,8 +121,10 @@ type ClaudeResponse struct {
 
 // ClaudeUsage Claude token 使用情况
 type ClaudeUsage struct {
-	InputTokens  int `json:"input_tokens"`
-	OutputTokens int `json:"output_tokens"`
+	InputTokens              int `json:"input_tokens"` // 未命中缓存的输入 token
+	OutputTokens             int `json:"output_tokens"`
+	CacheCreationInputTokens int `json:"cache_creation_input_tokens,omitempty"` // 写入缓存的输入 token
+	CacheReadInputTokens     int `json:"cache_read_input_tokens,omitempty"`     // 命中缓存的输入 token
 }
 
 
@@ -89,12 +135,41 @@ type OpenAIRequest struct {
 	Model       string           `json:"model"`
 	Messages    []OpenAIMessage  `json:"messages"`
 	MaxTokens   int              `json:"max_tokens,omitempty"`
+	MaxCompletionTokens int      `json:"max_completion_tokens,omitempty"` // 新版客户端使用，优先于 max_tokens
 	Temperature *float64         `json:"temperature,omitempty"`
 	TopP        *float64         `json:"top_p,omitempty"`
 	Stream      bool             `json:"stream,omitempty"`
+	StreamOptions *OpenAIStreamOptions `json:"stream_options,omitempty"`
 	Stop        []string         `json:"stop,omitempty"`
 	Tools       []OpenAITool     `json:"tools,omitempty"`
 	ToolChoice  interface{}      `json:"tool_choice,omitempty"` // string or object
+
+	// 结构化输出：json_object | json_schema
+	ResponseFormat *OpenAIResponseFormat `json:"response_format,omitempty"`
+
+	// 推理参数：OpenAI o 系列等使用 reasoning_effort，Qwen3 / QwQ 使用 enable_thinking + thinking_budget
+	ReasoningEffort string `json:"reasoning_effort,omitempty"` // low | medium | high
+	EnableThinking  *bool  `json:"enable_thinking,omitempty"`
+	ThinkingBudget  int    `json:"thinking_budget,omitempty"`
+}
+
+// OpenAIResponseFormat 响应格式
+type OpenAIResponseFormat struct {
+	Type       string            `json:"type"` // text | json_object | json_schema
+	JSONSchema *OpenAIJSONSchema `json:"json_schema,omitempty"`
+}
+
+// OpenAIJSONSchema json_schema 响应格式的 schema 定义
+type OpenAIJSONSchema struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description,omitempty"`
+	Schema      map[string]interface{} `json:"schema,omitempty"`
+	Strict      *bool                  `json:"strict,omitempty"`
+}
+
+// OpenAIStreamOptions 流式选项
+type OpenAIStreamOptions struct {
+	IncludeUsage bool `json:"include_usage"` // 在末尾 chunk 中返回 usage
 }
 
 // OpenAIMessage OpenAI 消息
@@ -103,6 +178,10 @@ type OpenAIMessage struct {
 	Content    interface{}      `json:"content,omitempty"` // string or []OpenAIContentBlock
 	ToolCalls  []OpenAIToolCall `json:"tool_calls,omitempty"`
 	ToolCallID string           `json:"tool_call_id,omitempty"` // for tool role
+
+	// 推理内容（仅出现在响应中）：DeepSeek / Qwen / vLLM 使用 reasoning_content，OpenRouter 等使用 reasoning
+	ReasoningContent string `json:"reasoning_content,omitempty"`
+	Reasoning        string `json:"reasoning,omitempty"`
 }
 
 // OpenAIContentBlock OpenAI 内容块
@@ -110,6 +189,16 @@ type OpenAIContentBlock struct {
 	Type     string          `json:"type"`
 	Text     *string         `json:"text,omitempty"`
 	ImageURL *OpenAIImageURL `json:"image_url,omitempty"`
+	File     *OpenAIFile     `json:"file,omitempty"`
+
+	// 缓存断点（OpenRouter、DashScope 等 OpenAI 兼容上游支持，格式与 Claude 相同）
+	CacheControl *ClaudeCacheControl `json:"cache_control,omitempty"`
+}
+
+// OpenAIFile 文件内容块（OpenAI 原生支持 PDF）
+type OpenAIFile struct {
+	Filename string `json:"filename,omitempty"`
+	FileData string `json:"file_data,omitempty"` // data:application/pdf;base64,...
 }
 
 // OpenAIImageURL 图片 URL
@@ -162,9 +251,18 @@ type OpenAIChoice struct {
 
 // OpenAIUsage OpenAI token 使用情况
 type OpenAIUsage struct {
-	PromptTokens     int `json:"prompt_tokens"`
-	CompletionTokens int `json:"completion_tokens"`
-	TotalTokens      int `json:"total_tokens"`
+	PromptTokens          int                        `json:"prompt_tokens"` // 包含命中缓存的 token
+	CompletionTokens      int                        `json:"completion_tokens"`
+	TotalTokens           int                        `json:"total_tokens"`
+	PromptTokensDetails   *OpenAIPromptTokensDetails `json:"prompt_tokens_details,omitempty"`
+	PromptCacheHitTokens  int                        `json:"prompt_cache_hit_tokens,omitempty"`  // DeepSeek 扩展
+	PromptCacheMissTokens int                        `json:"prompt_cache_miss_tokens,omitempty"` // DeepSeek 扩展
+	CachedTokens          int                        `json:"cached_tokens,omitempty"`            // Moonshot (Kimi) 扩展
+}
+
+// OpenAIPromptTokensDetails OpenAI 输入 token 明细
+type OpenAIPromptTokensDetails struct {
+	CachedTokens int `json:"cached_tokens"`
 }
 
 
@@ -177,6 +275,7 @@ type OpenAIStreamChunk struct {
 	Created int64                `json:"created"`
 	Model   string               `json:"model"`
 	Choices []OpenAIStreamChoice `json:"choices"`
+	Usage   *OpenAIUsage         `json:"usage,omitempty"` // 仅最后一个 chunk 携带
 }
 
 // OpenAIStreamChoice OpenAI 流式选项
@@ -191,6 +290,10 @@ type OpenAIStreamDelta struct {
 	Role      string                    `json:"role,omitempty"`      // 第一个 chunk 包含 role
 	Content   string                    `json:"content,omitempty"`   // 文本增量
 	ToolCalls []OpenAIStreamToolCall    `json:"tool_calls,omitempty"` // tool calls 增量
+
+	// 推理内容增量（字段含义同 OpenAIMessage）
+	ReasoningContent string `json:"reasoning_content,omitempty"`
+	Reasoning        string `json:"reasoning,omitempty"`
 }
 
 // OpenAIStreamToolCall OpenAI 流式工具调用
@@ -247,9 +350,11 @@ type ClaudeContentBlockDelta struct {
 
 // ClaudeDelta 增量数据
 type ClaudeDelta struct {
-	Type        string `json:"type"` // "text_delta" | "input_json_delta"
+	Type        string `json:"type"` // "text_delta" | "input_json_delta" | "thinking_delta" | "signature_delta"
 	Text        string `json:"text,omitempty"` // text_delta 的文本
 	PartialJSON string `json:"partial_json,omitempty"` // input_json_delta 的 JSON
+	Thinking    string `json:"thinking,omitempty"` // thinking_delta 的思考内容
+	Signature   string `json:"signature,omitempty"` // signature_delta 的签名
 }
 
 // ClaudeContentBlockStop content_block_stop 事件数据
@@ -277,6 +382,416 @@ type ClaudeMessageStop struct {
 }
 
 
+// Gemini Types - Gemini generateContent API 请求和响应类型定义
+
+// GeminiRequest Gemini generateContent 请求
+type GeminiRequest struct {
+	Contents          []GeminiContent         `json:"contents"`
+	SystemInstruction *GeminiContent          `json:"systemInstruction,omitempty"`
+	Tools             []GeminiTool            `json:"tools,omitempty"`
+	ToolConfig        *GeminiToolConfig       `json:"toolConfig,omitempty"`
+	GenerationConfig  *GeminiGenerationConfig `json:"generationConfig,omitempty"`
+	SafetySettings    []GeminiSafetySetting   `json:"safetySettings,omitempty"`
+}
+
+// GeminiContent Gemini 消息（role: user | model）
+type GeminiContent struct {
+	Role  string       `json:"role,omitempty"`
+	Parts []GeminiPart `json:"parts"`
+}
+
+// GeminiPart Gemini 内容片段
+// 每个 part 只包含以下字段之一: text, inlineData, fileData, functionCall, functionResponse
+type GeminiPart struct {
+	Text             *string                 `json:"text,omitempty"`
+	Thought          bool                    `json:"thought,omitempty"` // 思考过程（不输出给客户端）
+	InlineData       *GeminiBlob             `json:"inlineData,omitempty"`
+	FileData         *GeminiFileData         `json:"fileData,omitempty"`
+	FunctionCall     *GeminiFunctionCall     `json:"functionCall,omitempty"`
+	FunctionResponse *GeminiFunctionResponse `json:"functionResponse,omitempty"`
+}
+
+// GeminiBlob 内联数据（base64）
+type GeminiBlob struct {
+	MimeType string `json:"mimeType"`
+	Data     string `json:"data"`
+}
+
+// GeminiFileData 文件 URI 引用
+type GeminiFileData struct {
+	MimeType string `json:"mimeType,omitempty"`
+	FileURI  string `json:"fileUri"`
+}
+
+// GeminiFunctionCall 模型发起的函数调用
+type GeminiFunctionCall struct {
+	ID   string                 `json:"id,omitempty"`
+	Name string                 `json:"name"`
+	Args map[string]interface{} `json:"args,omitempty"`
+}
+
+// GeminiFunctionResponse 函数执行结果
+type GeminiFunctionResponse struct {
+	ID       string                 `json:"id,omitempty"`
+	Name     string                 `json:"name"`
+	Response map[string]interface{} `json:"response"`
+}
+
+// GeminiTool Gemini 工具定义
+type GeminiTool struct {
+	FunctionDeclarations []GeminiFunctionDeclaration `json:"functionDeclarations,omitempty"`
+}
+
+// GeminiFunctionDeclaration Gemini 函数声明
+type GeminiFunctionDeclaration struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description,omitempty"`
+	Parameters  map[string]interface{} `json:"parameters,omitempty"`
+
+	// 标准 JSON Schema 形式的参数（google-genai SDK 使用），与 parameters 二选一
+	ParametersJSONSchema map[string]interface{} `json:"parametersJsonSchema,omitempty"`
+}
+
+// GeminiToolConfig 工具调用配置
+type GeminiToolConfig struct {
+	FunctionCallingConfig *GeminiFunctionCallingConfig `json:"functionCallingConfig,omitempty"`
+}
+
+// GeminiFunctionCallingConfig 函数调用模式
+type GeminiFunctionCallingConfig struct {
+	Mode                 string   `json:"mode"` // AUTO | ANY | NONE
+	AllowedFunctionNames []string `json:"allowedFunctionNames,omitempty"`
+}
+
+// GeminiGenerationConfig 生成参数
+type GeminiGenerationConfig struct {
+	Temperature     *float64 `json:"temperature,omitempty"`
+	TopP            *float64 `json:"topP,omitempty"`
+	MaxOutputTokens int      `json:"maxOutputTokens,omitempty"`
+	StopSequences   []string `json:"stopSequences,omitempty"`
+
+	// 结构化输出：responseMimeType 为 application/json 时按 responseJsonSchema（完整 JSON Schema）
+	// 或 responseSchema（OpenAPI 子集，type 为大写）约束输出
+	ResponseMimeType   string                 `json:"responseMimeType,omitempty"`
+	ResponseSchema     map[string]interface{} `json:"responseSchema,omitempty"`
+	ResponseJSONSchema map[string]interface{} `json:"responseJsonSchema,omitempty"`
+}
+
+// GeminiSafetySetting 安全设置
+type GeminiSafetySetting struct {
+	Category  string `json:"category"`
+	Threshold string `json:"threshold"`
+}
+
+// GeminiResponse Gemini generateContent 响应（流式响应的每个 chunk 也是该结构）
+type GeminiResponse struct {
+	Candidates     []GeminiCandidate     `json:"candidates"`
+	PromptFeedback *GeminiPromptFeedback `json:"promptFeedback,omitempty"`
+	UsageMetadata  *GeminiUsageMetadata  `json:"usageMetadata,omitempty"`
+	ModelVersion   string                `json:"modelVersion,omitempty"`
+	ResponseID     string                `json:"responseId,omitempty"`
+}
+
+// GeminiCandidate 候选结果
+type GeminiCandidate struct {
+	Content      GeminiContent `json:"content"`
+	FinishReason string        `json:"finishReason,omitempty"` // STOP | MAX_TOKENS | SAFETY | RECITATION | ...
+	Index        int           `json:"index"`
+}
+
+// GeminiPromptFeedback 提示词被拦截时的反馈
+type GeminiPromptFeedback struct {
+	BlockReason string `json:"blockReason,omitempty"`
+}
+
+// GeminiUsageMetadata Gemini token 使用情况
+type GeminiUsageMetadata struct {
+	PromptTokenCount        int `json:"promptTokenCount"`
+	CandidatesTokenCount    int `json:"candidatesTokenCount"`
+	ThoughtsTokenCount      int `json:"thoughtsTokenCount,omitempty"`
+	TotalTokenCount         int `json:"totalTokenCount"`
+	CachedContentTokenCount int `json:"cachedContentTokenCount,omitempty"` // 包含在 promptTokenCount 中
+}
+
+// GeminiBatchEmbedRequest Gemini batchEmbedContents 请求
+type GeminiBatchEmbedRequest struct {
+	Requests []GeminiEmbedContentRequest `json:"requests"`
+}
+
+// GeminiEmbedContentRequest Gemini 单条嵌入请求
+type GeminiEmbedContentRequest struct {
+	Model                string        `json:"model"` // models/{model}
+	Content              GeminiContent `json:"content"`
+	OutputDimensionality *int          `json:"outputDimensionality,omitempty"`
+}
+
+// GeminiBatchEmbedResponse Gemini batchEmbedContents 响应（不返回 token 用量）
+type GeminiBatchEmbedResponse struct {
+	Embeddings []struct {
+		Values []float64 `json:"values"`
+	} `json:"embeddings"`
+}
+
+
+// Ollama Types - Ollama 原生 /api/chat 请求和响应类型定义
+
+// OllamaChatRequest Ollama /api/chat 请求
+type OllamaChatRequest struct {
+	Model    string          `json:"model"`
+	Messages []OllamaMessage `json:"messages"`
+	Tools    []OpenAITool    `json:"tools,omitempty"` // 与 OpenAI tools 格式相同
+	Stream   bool            `json:"stream"`          // Ollama 默认流式，必须显式发送 false
+	Format   interface{}     `json:"format,omitempty"` // "json" 或 JSON Schema 对象
+	Options  *OllamaOptions  `json:"options,omitempty"`
+}
+
+// OllamaOptions Ollama 生成参数
+type OllamaOptions struct {
+	NumPredict  int      `json:"num_predict,omitempty"`
+	Temperature *float64 `json:"temperature,omitempty"`
+	TopP        *float64 `json:"top_p,omitempty"`
+	Stop        []string `json:"stop,omitempty"`
+}
+
+// OllamaMessage Ollama 消息（content 只能是字符串，图片以 base64 放在 images 中）
+type OllamaMessage struct {
+	Role      string           `json:"role"`
+	Content   string           `json:"content"`
+	Thinking  string           `json:"thinking,omitempty"`
+	Images    []string         `json:"images,omitempty"`
+	ToolCalls []OllamaToolCall `json:"tool_calls,omitempty"`
+	ToolName  string           `json:"tool_name,omitempty"` // tool 角色消息对应的函数名
+}
+
+// OllamaToolCall Ollama 工具调用（没有 ID，参数为 JSON 对象）
+type OllamaToolCall struct {
+	Function OllamaFunctionCall `json:"function"`
+}
+
+// OllamaFunctionCall Ollama 函数调用
+type OllamaFunctionCall struct {
+	Name      string                 `json:"name"`
+	Arguments map[string]interface{} `json:"arguments"`
+}
+
+// OllamaChatResponse Ollama /api/chat 响应（流式时每行一个，最后一行 done=true 携带用量）
+type OllamaChatResponse struct {
+	Model           string        `json:"model"`
+	CreatedAt       string        `json:"created_at"`
+	Message         OllamaMessage `json:"message"`
+	Done            bool          `json:"done"`
+	DoneReason      string        `json:"done_reason,omitempty"`
+	PromptEvalCount int           `json:"prompt_eval_count,omitempty"`
+	EvalCount       int           `json:"eval_count,omitempty"`
+	Error           string        `json:"error,omitempty"`
+}
+
+// OllamaEmbedRequest Ollama /api/embed 请求
+type OllamaEmbedRequest struct {
+	Model      string   `json:"model"`
+	Input      []string `json:"input"`
+	Dimensions *int     `json:"dimensions,omitempty"`
+}
+
+// OllamaEmbedResponse Ollama /api/embed 响应
+type OllamaEmbedResponse struct {
+	Model           string      `json:"model"`
+	Embeddings      [][]float64 `json:"embeddings"`
+	PromptEvalCount int         `json:"prompt_eval_count,omitempty"`
+}
+
+
+// Completions Types - OpenAI 旧版 Completions API 响应类型定义
+
+// CompletionResponse OpenAI 旧版 Completions 响应（流式 chunk 结构相同）
+type CompletionResponse struct {
+	ID      string             `json:"id"`
+	Object  string             `json:"object"` // text_completion
+	Created int64              `json:"created"`
+	Model   string             `json:"model"`
+	Choices []CompletionChoice `json:"choices"`
+	Usage   *OpenAIUsage       `json:"usage,omitempty"`
+}
+
+// CompletionChoice 旧版 Completions 选项
+type CompletionChoice struct {
+	Text         string      `json:"text"`
+	Index        int         `json:"index"`
+	Logprobs     interface{} `json:"logprobs"`      // 转换自 Chat Completions 时恒为 null
+	FinishReason *string     `json:"finish_reason"` // 流式响应中最后一个 chunk 之前为 null
+}
+
+// Embeddings Types - OpenAI Embeddings API 请求和响应类型定义
+
+// EmbeddingRequest OpenAI Embeddings 请求
+type EmbeddingRequest struct {
+	Model          string      `json:"model"`
+	Input          interface{} `json:"input"`                     // string、[]string、[]int 或 [][]int
+	Dimensions     *int        `json:"dimensions,omitempty"`
+	EncodingFormat string      `json:"encoding_format,omitempty"` // float（默认）或 base64
+	User           string      `json:"user,omitempty"`
+}
+
+// EmbeddingResponse OpenAI Embeddings 响应
+type EmbeddingResponse struct {
+	Object string          `json:"object"` // list
+	Data   []EmbeddingData `json:"data"`
+	Model  string          `json:"model"`
+	Usage  EmbeddingUsage  `json:"usage"`
+}
+
+// EmbeddingData 单条嵌入结果
+type EmbeddingData struct {
+	Object    string      `json:"object"` // embedding
+	Index     int         `json:"index"`
+	Embedding interface{} `json:"embedding"` // []float64，或 encoding_format=base64 时为 float32 小端序的 base64 字符串
+}
+
+// EmbeddingUsage 嵌入 token 用量
+type EmbeddingUsage struct {
+	PromptTokens int `json:"prompt_tokens"`
+	TotalTokens  int `json:"total_tokens"`
+}
+
+
+// Responses Types - OpenAI Responses API 请求、响应和流式事件类型定义
+
+// ResponsesRequest OpenAI Responses API 请求
+type ResponsesRequest struct {
+	Model              string                 `json:"model"`
+	Input              json.RawMessage        `json:"input,omitempty"` // string 或 []ResponsesInputItem
+	Instructions       string                 `json:"instructions,omitempty"`
+	PreviousResponseID string                 `json:"previous_response_id,omitempty"`
+	Tools              []ResponsesTool        `json:"tools,omitempty"`
+	ToolChoice         interface{}            `json:"tool_choice,omitempty"` // string or object
+	MaxOutputTokens    int                    `json:"max_output_tokens,omitempty"`
+	Temperature        *float64               `json:"temperature,omitempty"`
+	TopP               *float64               `json:"top_p,omitempty"`
+	Stream             bool                   `json:"stream,omitempty"`
+	Store              *bool                  `json:"store,omitempty"` // 默认 true
+	Metadata           map[string]interface{} `json:"metadata,omitempty"`
+	Text               *ResponsesTextConfig   `json:"text,omitempty"`
+}
+
+// ResponsesTextConfig Responses 文本输出配置
+type ResponsesTextConfig struct {
+	Format *ResponsesTextFormat `json:"format,omitempty"`
+}
+
+// ResponsesTextFormat Responses 文本输出格式（json_schema 的字段与 type 同级）
+type ResponsesTextFormat struct {
+	Type        string                 `json:"type"` // text | json_object | json_schema
+	Name        string                 `json:"name,omitempty"`
+	Description string                 `json:"description,omitempty"`
+	Schema      map[string]interface{} `json:"schema,omitempty"`
+	Strict      *bool                  `json:"strict,omitempty"`
+}
+
+// ResponsesTool Responses 工具定义（函数字段与 type 同级）
+type ResponsesTool struct {
+	Type        string                 `json:"type"` // function | web_search 等内置工具
+	Name        string                 `json:"name,omitempty"`
+	Description string                 `json:"description,omitempty"`
+	Parameters  map[string]interface{} `json:"parameters,omitempty"`
+	Strict      *bool                  `json:"strict,omitempty"`
+}
+
+// ResponsesInputItem Responses 输入项
+// 支持 message（type 可省略）、function_call、function_call_output、reasoning
+type ResponsesInputItem struct {
+	Type      string          `json:"type,omitempty"`
+	ID        string          `json:"id,omitempty"`
+	Status    string          `json:"status,omitempty"`
+	Role      string          `json:"role,omitempty"`      // user | assistant | system | developer
+	Content   json.RawMessage `json:"content,omitempty"`   // string 或 []ResponsesInputContent
+	CallID    string          `json:"call_id,omitempty"`   // function_call / function_call_output
+	Name      string          `json:"name,omitempty"`      // function_call
+	Arguments string          `json:"arguments,omitempty"` // function_call，JSON 字符串
+	Output    json.RawMessage `json:"output,omitempty"`    // function_call_output，string 或内容数组
+}
+
+// ResponsesInputContent Responses 消息内容块
+type ResponsesInputContent struct {
+	Type     string `json:"type"` // input_text | output_text | input_image | refusal
+	Text     string `json:"text,omitempty"`
+	ImageURL string `json:"image_url,omitempty"` // data URI 或 HTTP URL
+	Detail   string `json:"detail,omitempty"`
+	Refusal  string `json:"refusal,omitempty"`
+}
+
+// ResponsesResponse OpenAI Responses API 响应
+type ResponsesResponse struct {
+	ID                 string                      `json:"id"`
+	Object             string                      `json:"object"` // "response"
+	CreatedAt          int64                       `json:"created_at"`
+	Status             string                      `json:"status"` // completed | incomplete | in_progress | failed
+	Model              string                      `json:"model"`
+	Output             []ResponsesOutputItem       `json:"output"`
+	PreviousResponseID *string                     `json:"previous_response_id"`
+	IncompleteDetails  *ResponsesIncompleteDetails `json:"incomplete_details"`
+	Error              *ResponsesError             `json:"error"`
+	Usage              *ResponsesUsage             `json:"usage,omitempty"`
+	Metadata           map[string]interface{}      `json:"metadata,omitempty"`
+}
+
+// ResponsesOutputItem Responses 输出项（message 或 function_call）
+type ResponsesOutputItem struct {
+	Type      string                   `json:"type"`
+	ID        string                   `json:"id"`
+	Status    string                   `json:"status,omitempty"`
+	Role      string                   `json:"role,omitempty"`
+	Content   []ResponsesOutputContent `json:"content,omitempty"`
+	CallID    string                   `json:"call_id,omitempty"`
+	Name      string                   `json:"name,omitempty"`
+	Arguments string                   `json:"arguments,omitempty"`
+}
+
+// ResponsesOutputContent Responses 输出内容块
+type ResponsesOutputContent struct {
+	Type        string        `json:"type"` // output_text
+	Text        string        `json:"text"`
+	Annotations []interface{} `json:"annotations"`
+}
+
+// ResponsesIncompleteDetails 响应未完成的原因
+type ResponsesIncompleteDetails struct {
+	Reason string `json:"reason"` // max_output_tokens | content_filter
+}
+
+// ResponsesError 响应失败信息
+type ResponsesError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// ResponsesUsage Responses token 使用情况
+type ResponsesUsage struct {
+	InputTokens        int                          `json:"input_tokens"` // 包含命中缓存的 token
+	OutputTokens       int                          `json:"output_tokens"`
+	TotalTokens        int                          `json:"total_tokens"`
+	InputTokensDetails *ResponsesInputTokensDetails `json:"input_tokens_details,omitempty"`
+}
+
+// ResponsesInputTokensDetails Responses 输入 token 明细
+type ResponsesInputTokensDetails struct {
+	CachedTokens int `json:"cached_tokens"`
+}
+
+// ResponsesStreamEvent Responses 流式事件（各事件类型的字段并集）
+type ResponsesStreamEvent struct {
+	Type           string                  `json:"type"`
+	SequenceNumber int                     `json:"sequence_number"`
+	Response       *ResponsesResponse      `json:"response,omitempty"`
+	OutputIndex    *int                    `json:"output_index,omitempty"`
+	ContentIndex   *int                    `json:"content_index,omitempty"`
+	ItemID         string                  `json:"item_id,omitempty"`
+	Item           *ResponsesOutputItem    `json:"item,omitempty"`
+	Part           *ResponsesOutputContent `json:"part,omitempty"`
+	Delta          string                  `json:"delta,omitempty"`
+	Text           string                  `json:"text,omitempty"`
+	Arguments      string                  `json:"arguments,omitempty"`
+}
+
 // Helper functions
 
 // StringPtr 返回字符串指针
@@ -288,3 +803,8 @@ func StringPtr(s string) *string {
 func Float64Ptr(f float64) *float64 {
 	return &f
 }
+
+// BoolPtr 返回 bool 指针
+func BoolPtr(b bool) *bool {
+	return &b
+}
@@ -0,0 +1,60 @@
+package converter
+
+// Claude 的 input_tokens 不包含缓存读写的 token，OpenAI / Responses / Gemini 的输入 token 数包含命中缓存的部分，
+// 各方向转换时按此口径拆分或合并
+
+// CachedPromptTokens 返回命中缓存的输入 token 数，兼容 OpenAI、DeepSeek 与 Moonshot 的字段
+func (u OpenAIUsage) CachedPromptTokens() int {
+	switch {
+	case u.PromptTokensDetails != nil && u.PromptTokensDetails.CachedTokens > 0:
+		return u.PromptTokensDetails.CachedTokens
+	case u.PromptCacheHitTokens > 0:
+		return u.PromptCacheHitTokens
+	default:
+		return u.CachedTokens
+	}
+}
+
+// TotalInputTokens 返回包含缓存读写的输入 token 总数
+func (u ClaudeUsage) TotalInputTokens() int {
+	return u.InputTokens + u.CacheCreationInputTokens + u.CacheReadInputTokens
+}
+
+// claudeUsageFromOpenAI 转换 OpenAI token 用量，命中缓存的部分计入 cache_read_input_tokens
+// OpenAI 兼容上游的前缀缓存是自动的，不单独报告写入量
+func claudeUsageFromOpenAI(usage OpenAIUsage) ClaudeUsage {
+	cached := min(usage.CachedPromptTokens(), usage.PromptTokens)
+	return ClaudeUsage{
+		InputTokens:          usage.PromptTokens - cached,
+		OutputTokens:         usage.CompletionTokens,
+		CacheReadInputTokens: cached,
+	}
+}
+
+// openAIUsageFromClaude 转换 Claude token 用量，缓存读写均计入 prompt_tokens
+func openAIUsageFromClaude(usage ClaudeUsage) OpenAIUsage {
+	prompt := usage.TotalInputTokens()
+	result := OpenAIUsage{
+		PromptTokens:     prompt,
+		CompletionTokens: usage.OutputTokens,
+		TotalTokens:      prompt + usage.OutputTokens,
+	}
+	if usage.CacheReadInputTokens > 0 {
+		result.PromptTokensDetails = &OpenAIPromptTokensDetails{CachedTokens: usage.CacheReadInputTokens}
+	}
+	return result
+}
+
+// mergeClaudeDeltaUsage 合并 message_delta 中的累计用量（输入侧字段仅在非零时覆盖 message_start 的值）
+func mergeClaudeDeltaUsage(usage *ClaudeUsage, delta *ClaudeUsage) {
+	if delta.InputTokens > 0 {
+		usage.InputTokens = delta.InputTokens
+	}
+	if delta.CacheCreationInputTokens > 0 {
+		usage.CacheCreationInputTokens = delta.CacheCreationInputTokens
+	}
+	if delta.CacheReadInputTokens > 0 {
+		usage.CacheReadInputTokens = delta.CacheReadInputTokens
+	}
+	usage.OutputTokens = delta.OutputTokens
+}
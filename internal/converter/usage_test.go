@@ -0,0 +1,149 @@
+package converter
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// 测试非流式响应的缓存命中 token 转换为 cache_read_input_tokens
+func TestConvertOpenAIUsageCacheTokens(t *testing.T) {
+	tests := []struct {
+		name      string
+		usage     string
+		wantInput int
+		wantRead  int
+	}{
+		{"OpenAI prompt_tokens_details", `{"prompt_tokens":1200,"completion_tokens":30,"total_tokens":1230,"prompt_tokens_details":{"cached_tokens":1024}}`, 176, 1024},
+		{"DeepSeek prompt_cache_hit_tokens", `{"prompt_tokens":1200,"completion_tokens":30,"total_tokens":1230,"prompt_cache_hit_tokens":1152,"prompt_cache_miss_tokens":48}`, 48, 1152},
+		{"Kimi cached_tokens", `{"prompt_tokens":1200,"completion_tokens":30,"total_tokens":1230,"cached_tokens":1000}`, 200, 1000},
+		{"未命中缓存", `{"prompt_tokens":1200,"completion_tokens":30,"total_tokens":1230}`, 1200, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var usage OpenAIUsage
+			if err := json.Unmarshal([]byte(tt.usage), &usage); err != nil {
+				t.Fatalf("解析 usage 失败: %v", err)
+			}
+			resp := &OpenAIResponse{
+				ID:      "chatcmpl-1",
+				Model:   "deepseek-chat",
+				Choices: []OpenAIChoice{{Message: OpenAIMessage{Role: "assistant", Content: "ok"}, FinishReason: "stop"}},
+				Usage:   usage,
+			}
+
+			result, err := ConvertOpenAIToClaude(resp)
+			if err != nil {
+				t.Fatalf("转换失败: %v", err)
+			}
+			if result.Usage.InputTokens != tt.wantInput || result.Usage.CacheReadInputTokens != tt.wantRead {
+				t.Errorf("usage 不匹配: got %+v, want input=%d cache_read=%d", result.Usage, tt.wantInput, tt.wantRead)
+			}
+			if result.Usage.OutputTokens != 30 || result.Usage.TotalInputTokens() != 1200 {
+				t.Errorf("总量不匹配: %+v", result.Usage)
+			}
+		})
+	}
+}
+
+// 测试流式末尾 usage chunk 的缓存命中 token 出现在最后的 message_delta 中
+func TestConvertStream_CacheUsage(t *testing.T) {
+	openaiStream := `data: {"id":"chatcmpl-1","model":"gpt-4o","choices":[{"delta":{"role":"assistant","content":"Hi"},"finish_reason":null}]}
+
+data: {"id":"chatcmpl-1","choices":[{"delta":{},"finish_reason":"stop"}]}
+
+data: {"id":"chatcmpl-1","choices":[],"usage":{"prompt_tokens":2048,"completion_tokens":5,"total_tokens":2053,"prompt_tokens_details":{"cached_tokens":1920}}}
+
+data: [DONE]
+
+`
+
+	claudeStream, err := ConvertStream(context.Background(), strings.NewReader(openaiStream))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	events := readAllEvents(t, claudeStream)
+
+	var last *ClaudeMessageDelta
+	for _, event := range events {
+		if !strings.HasPrefix(event, "event: message_delta") {
+			continue
+		}
+		data := event[strings.Index(event, "data: ")+len("data: "):]
+		var delta ClaudeMessageDelta
+		if err := json.Unmarshal([]byte(strings.TrimSpace(data)), &delta); err != nil {
+			t.Fatalf("解析 message_delta 失败: %v", err)
+		}
+		last = &delta
+	}
+	if last == nil || last.Usage == nil {
+		t.Fatalf("缺少带 usage 的 message_delta: %v", events)
+	}
+	want := ClaudeUsage{InputTokens: 128, OutputTokens: 5, CacheReadInputTokens: 1920}
+	if *last.Usage != want {
+		t.Errorf("usage 不匹配: got %+v, want %+v", *last.Usage, want)
+	}
+	if last.Delta.StopReason == nil || *last.Delta.StopReason != "end_turn" {
+		t.Errorf("最后的 message_delta 应保留 stop_reason: %+v", last.Delta)
+	}
+}
+
+// 测试开启 StreamUsage 的流式请求携带 stream_options.include_usage，未开启时不发送
+func TestConvertClaudeToOpenAIStreamIncludeUsage(t *testing.T) {
+	req := &ClaudeRequest{
+		Model:     "deepseek-chat",
+		MaxTokens: 1024,
+		Stream:    true,
+		Messages: []ClaudeMessage{
+			{Role: "user", Content: []ClaudeContentBlock{{Type: "text", Text: StringPtr("Hi")}}},
+		},
+	}
+
+	result, err := ConvertClaudeToOpenAIWithOptions(req, ClaudeToOpenAIOptions{StreamUsage: true})
+	if err != nil {
+		t.Fatalf("转换失败: %v", err)
+	}
+	if result.StreamOptions == nil || !result.StreamOptions.IncludeUsage {
+		t.Errorf("流式请求应开启 include_usage: %+v", result.StreamOptions)
+	}
+
+	result, _ = ConvertClaudeToOpenAI(req)
+	if result.StreamOptions != nil {
+		t.Errorf("未开启 StreamUsage 时不应设置 stream_options: %+v", result.StreamOptions)
+	}
+
+	req.Stream = false
+	result, _ = ConvertClaudeToOpenAIWithOptions(req, ClaudeToOpenAIOptions{StreamUsage: true})
+	if result.StreamOptions != nil {
+		t.Errorf("非流式请求不应设置 stream_options: %+v", result.StreamOptions)
+	}
+}
+
+// 测试 Claude 用量转换为 Responses / Gemini 用量时缓存 token 计入输入总量
+func TestClaudeUsageCacheTokensReverse(t *testing.T) {
+	usage := ClaudeUsage{InputTokens: 100, OutputTokens: 20, CacheCreationInputTokens: 300, CacheReadInputTokens: 600}
+
+	responses := responsesUsageFromClaude(usage)
+	if responses.InputTokens != 1000 || responses.TotalTokens != 1020 ||
+		responses.InputTokensDetails == nil || responses.InputTokensDetails.CachedTokens != 600 {
+		t.Errorf("Responses usage 不匹配: %+v", responses)
+	}
+
+	gemini := geminiUsageFromClaude(usage)
+	if gemini.PromptTokenCount != 1000 || gemini.TotalTokenCount != 1020 || gemini.CachedContentTokenCount != 600 {
+		t.Errorf("Gemini usage 不匹配: %+v", gemini)
+	}
+
+	openai := openAIUsageFromClaude(usage)
+	if openai.PromptTokens != 1000 || openai.PromptTokensDetails == nil || openai.PromptTokensDetails.CachedTokens != 600 {
+		t.Errorf("OpenAI usage 不匹配: %+v", openai)
+	}
+
+	// 往返：Gemini cachedContentTokenCount 转回 Claude 时拆分为 cache_read_input_tokens
+	back := geminiClaudeUsage(gemini)
+	if back.InputTokens != 400 || back.CacheReadInputTokens != 600 || back.OutputTokens != 20 {
+		t.Errorf("Gemini→Claude usage 不匹配: %+v", back)
+	}
+}
@@ -60,6 +60,8 @@ func AutoMigrate(db *gorm.DB) error {
 		&models.UnifiedModel{},
 		&models.ModelMapping{},
 		&models.Token{},
+		&models.StoredResponse{},
+		&models.CachedResponse{},
 	)
 
 	if err != nil {
@@ -71,6 +73,8 @@ func AutoMigrate(db *gorm.DB) error {
 	log.Println("   - unified_models 表")
 	log.Println("   - model_mappings 表")
 	log.Println("   - tokens 表")
+	log.Println("   - responses 表")
+	log.Println("   - response_cache 表")
 
 	// 初始化默认数据
 	if err := initDefaultData(db); err != nil {
@@ -100,15 +100,18 @@ func (c *MemoryCache) Get(key string) ([]*ResolvedMapping, bool) {
 	for i, mapping := range entry.Data {
 		// 创建新的 ResolvedMapping 对象
 		result[i] = &ResolvedMapping{
-			ID:             mapping.ID,
-			UnifiedModelID: mapping.UnifiedModelID,
-			ProviderID:     mapping.ProviderID,
-			TargetModel:    mapping.TargetModel,
-			Weight:         mapping.Weight,
-			Priority:       mapping.Priority,
-			Enabled:        mapping.Enabled,
-			CreatedAt:      mapping.CreatedAt,
-			UpdatedAt:      mapping.UpdatedAt,
+			ID:                  mapping.ID,
+			UnifiedModelID:      mapping.UnifiedModelID,
+			ProviderID:          mapping.ProviderID,
+			TargetModel:         mapping.TargetModel,
+			Weight:              mapping.Weight,
+			Priority:            mapping.Priority,
+			Enabled:             mapping.Enabled,
+			ResponseCache:       mapping.ResponseCache,
+			EmulateTools:        mapping.EmulateTools,
+			UnreliableToolCalls: mapping.UnreliableToolCalls,
+			CreatedAt:           mapping.CreatedAt,
+			UpdatedAt:           mapping.UpdatedAt,
 		}
 
 		// 如果有供应商信息，也要深拷贝
@@ -8,26 +8,29 @@ import (
 
 // CreateModelRequest 创建统一模型请求
 type CreateModelRequest struct {
-	Name        string `json:"name" binding:"required,max=100"`
-	DisplayName string `json:"display_name" binding:"omitempty,max=200"`
-	Description string `json:"description" binding:"max=500"`
+	Name          string `json:"name" binding:"required,max=100"`
+	DisplayName   string `json:"display_name" binding:"omitempty,max=200"`
+	Description   string `json:"description" binding:"max=500"`
+	ResponseCache bool   `json:"response_cache"` // 相同请求直接返回缓存的响应
 }
 
 // UpdateModelRequest 更新统一模型请求
 type UpdateModelRequest struct {
-	Name        *string `json:"name" binding:"omitempty,max=100"`
-	DisplayName *string `json:"display_name" binding:"omitempty,max=200"`
-	Description *string `json:"description" binding:"omitempty,max=500"`
+	Name          *string `json:"name" binding:"omitempty,max=100"`
+	DisplayName   *string `json:"display_name" binding:"omitempty,max=200"`
+	Description   *string `json:"description" binding:"omitempty,max=500"`
+	ResponseCache *bool   `json:"response_cache"`
 }
 
 // ModelResponse 统一模型响应
 type ModelResponse struct {
-	ID          uint      `json:"id"`
-	Name        string    `json:"name"`
-	DisplayName string    `json:"display_name"`
-	Description string    `json:"description"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID            uint      `json:"id"`
+	Name          string    `json:"name"`
+	DisplayName   string    `json:"display_name"`
+	Description   string    `json:"description"`
+	ResponseCache bool      `json:"response_cache"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
 }
 
 // ListModelsRequest 查询模型列表请求
@@ -54,12 +57,13 @@ type PaginationInfo struct {
 // ToModelResponse 将模型实体转换为响应对象
 func ToModelResponse(model *models.UnifiedModel) *ModelResponse {
 	return &ModelResponse{
-		ID:          model.ID,
-		Name:        model.Name,
-		DisplayName: model.DisplayName,
-		Description: model.Description,
-		CreatedAt:   model.CreatedAt,
-		UpdatedAt:   model.UpdatedAt,
+		ID:            model.ID,
+		Name:          model.Name,
+		DisplayName:   model.DisplayName,
+		Description:   model.Description,
+		ResponseCache: model.ResponseCache,
+		CreatedAt:     model.CreatedAt,
+		UpdatedAt:     model.UpdatedAt,
 	}
 }
 
@@ -76,35 +80,41 @@ func ToModelResponseList(models []*models.UnifiedModel) []*ModelResponse {
 
 // CreateMappingRequest 创建映射请求
 type CreateMappingRequest struct {
-	UnifiedModelID uint   `json:"-"` // 从URL路径获取，不需要在JSON中提供
-	ProviderID     uint   `json:"provider_id" binding:"required"`
-	TargetModel    string `json:"target_model" binding:"required,max=100"`
-	Weight         int    `json:"weight" binding:"omitempty,min=1,max=100"` // 可选，默认50
-	Priority       int    `json:"priority" binding:"omitempty,min=1"`       // 可选，默认1
-	Enabled        bool   `json:"enabled"`
+	UnifiedModelID      uint   `json:"-"` // 从URL路径获取，不需要在JSON中提供
+	ProviderID          uint   `json:"provider_id" binding:"required"`
+	TargetModel         string `json:"target_model" binding:"required,max=100"`
+	Weight              int    `json:"weight" binding:"omitempty,min=1,max=100"` // 可选，默认50
+	Priority            int    `json:"priority" binding:"omitempty,min=1"`       // 可选，默认1
+	Enabled             bool   `json:"enabled"`
+	EmulateTools        bool   `json:"emulate_tools"`
+	UnreliableToolCalls bool   `json:"unreliable_tool_calls"`
 }
 
 // UpdateMappingRequest 更新映射请求
 type UpdateMappingRequest struct {
-	TargetModel *string `json:"target_model" binding:"omitempty,max=100"`
-	Weight      *int    `json:"weight" binding:"omitempty,min=1,max=100"`
-	Priority    *int    `json:"priority" binding:"omitempty,min=1"`
-	Enabled     *bool   `json:"enabled"`
+	TargetModel         *string `json:"target_model" binding:"omitempty,max=100"`
+	Weight              *int    `json:"weight" binding:"omitempty,min=1,max=100"`
+	Priority            *int    `json:"priority" binding:"omitempty,min=1"`
+	Enabled             *bool   `json:"enabled"`
+	EmulateTools        *bool   `json:"emulate_tools"`
+	UnreliableToolCalls *bool   `json:"unreliable_tool_calls"`
 }
 
 // MappingResponse 映射响应
 type MappingResponse struct {
-	ID             uint                  `json:"id"`
-	UnifiedModelID uint                  `json:"unified_model_id"`
-	ProviderID     uint                  `json:"provider_id"`
-	TargetModel    string                `json:"target_model"`
-	Weight         int                   `json:"weight"`
-	Priority       int                   `json:"priority"`
-	Enabled        bool                  `json:"enabled"`
-	Provider       *ProviderInfoResponse `json:"provider,omitempty"`
-	UnifiedModel   *ModelInfoResponse    `json:"unified_model,omitempty"`
-	CreatedAt      time.Time             `json:"created_at"`
-	UpdatedAt      time.Time             `json:"updated_at"`
+	ID                  uint                  `json:"id"`
+	UnifiedModelID      uint                  `json:"unified_model_id"`
+	ProviderID          uint                  `json:"provider_id"`
+	TargetModel         string                `json:"target_model"`
+	Weight              int                   `json:"weight"`
+	Priority            int                   `json:"priority"`
+	Enabled             bool                  `json:"enabled"`
+	EmulateTools        bool                  `json:"emulate_tools"`
+	UnreliableToolCalls bool                  `json:"unreliable_tool_calls"`
+	Provider            *ProviderInfoResponse `json:"provider,omitempty"`
+	UnifiedModel        *ModelInfoResponse    `json:"unified_model,omitempty"`
+	CreatedAt           time.Time             `json:"created_at"`
+	UpdatedAt           time.Time             `json:"updated_at"`
 }
 
 // ProviderInfoResponse 供应商基本信息响应（用于关联查询）
@@ -131,15 +141,17 @@ type ListMappingsResponse struct {
 // ToMappingResponse 将映射实体转换为响应对象
 func ToMappingResponse(mapping *models.ModelMapping) *MappingResponse {
 	response := &MappingResponse{
-		ID:             mapping.ID,
-		UnifiedModelID: mapping.UnifiedModelID,
-		ProviderID:     mapping.ProviderID,
-		TargetModel:    mapping.TargetModel,
-		Weight:         mapping.Weight,
-		Priority:       mapping.Priority,
-		Enabled:        mapping.Enabled,
-		CreatedAt:      mapping.CreatedAt,
-		UpdatedAt:      mapping.UpdatedAt,
+		ID:                  mapping.ID,
+		UnifiedModelID:      mapping.UnifiedModelID,
+		ProviderID:          mapping.ProviderID,
+		TargetModel:         mapping.TargetModel,
+		Weight:              mapping.Weight,
+		Priority:            mapping.Priority,
+		Enabled:             mapping.Enabled,
+		EmulateTools:        mapping.EmulateTools,
+		UnreliableToolCalls: mapping.UnreliableToolCalls,
+		CreatedAt:           mapping.CreatedAt,
+		UpdatedAt:           mapping.UpdatedAt,
 	}
 
 	// 如果包含供应商信息
@@ -33,7 +33,7 @@ func NewRepository(db *gorm.DB) *Repository {
 // Create 创建统一模型
 func (r *Repository) Create(model *models.UnifiedModel) error {
 	// 使用 Select 明确指定要保存的字段
-	return r.db.Select("Name", "DisplayName", "Description").Create(model).Error
+	return r.db.Select("Name", "DisplayName", "Description", "ResponseCache").Create(model).Error
 }
 
 // FindByID 根据 ID 查找模型
@@ -93,7 +93,7 @@ func (r *Repository) FindAll(page, pageSize int, search string) ([]*models.Unifi
 // Update 更新模型
 func (r *Repository) Update(model *models.UnifiedModel) error {
 	// 使用 Select 明确指定要更新的字段
-	return r.db.Select("Name", "DisplayName", "Description").Save(model).Error
+	return r.db.Select("Name", "DisplayName", "Description", "ResponseCache").Save(model).Error
 }
 
 // Delete 删除模型（软删除）
@@ -135,6 +135,9 @@ func (r *DefaultRouter) resolveMappingsFromDB(ctx context.Context, modelName str
 
 	// 转换为解析后的映射
 	resolvedMappings := ToResolvedMappingList(modelMappings)
+	for _, resolved := range resolvedMappings {
+		resolved.ResponseCache = unifiedModel.ResponseCache
+	}
 
 	// 过滤健康的供应商
 	if r.config.HealthCheck {
@@ -572,4 +572,35 @@ func BenchmarkRouter_ResolveModel_WithoutCache(b *testing.B) {
 			b.Fatal(err)
 		}
 	}
-}
\ No newline at end of file
+}
+func TestRouter_ResolveModel_ResponseCache(t *testing.T) {
+	router, repo := setupTestRouter(t)
+	defer router.Close()
+
+	model, providers := createTestModelAndProvidersForRouter(t, repo)
+	createTestMappings(t, repo, model, providers)
+
+	mappings, err := router.ResolveModel(context.Background(), model.Name)
+	require.NoError(t, err)
+	for _, mapping := range mappings {
+		assert.False(t, mapping.ResponseCache)
+	}
+
+	// 开启统一模型的响应缓存，缓存失效后生效
+	enabled := true
+	_, err = NewService(repo).UpdateModel(model.ID, UpdateModelRequest{ResponseCache: &enabled})
+	require.NoError(t, err)
+	router.InvalidateCache(model.Name)
+
+	mappings, err = router.ResolveModel(context.Background(), model.Name)
+	require.NoError(t, err)
+	require.NotEmpty(t, mappings)
+	for _, mapping := range mappings {
+		assert.True(t, mapping.ResponseCache)
+	}
+
+	// 缓存命中时同样保留该字段
+	mappings, err = router.ResolveModel(context.Background(), model.Name)
+	require.NoError(t, err)
+	assert.True(t, mappings[0].ResponseCache)
+}
@@ -78,9 +78,10 @@ func (s *Service) CreateModel(req CreateModelRequest) (*ModelResponse, error) {
 
 	// 创建模型实体
 	model := &models.UnifiedModel{
-		Name:        strings.TrimSpace(req.Name),
-		DisplayName: displayName,
-		Description: strings.TrimSpace(req.Description),
+		Name:          strings.TrimSpace(req.Name),
+		DisplayName:   displayName,
+		Description:   strings.TrimSpace(req.Description),
+		ResponseCache: req.ResponseCache,
 	}
 
 	// 保存到数据库
@@ -196,6 +197,11 @@ func (s *Service) UpdateModel(id uint, req UpdateModelRequest) (*ModelResponse,
 		}
 	}
 
+	if req.ResponseCache != nil && *req.ResponseCache != model.ResponseCache {
+		model.ResponseCache = *req.ResponseCache
+		updated = true
+	}
+
 	// 如果有更新，保存到数据库
 	if updated {
 		if err := s.repo.Update(model); err != nil {
@@ -299,12 +305,14 @@ func (s *Service) CreateMapping(req CreateMappingRequest) (*MappingResponse, err
 
 	// 创建映射实体
 	mapping := &models.ModelMapping{
-		UnifiedModelID: req.UnifiedModelID,
-		ProviderID:     req.ProviderID,
-		TargetModel:    strings.TrimSpace(req.TargetModel),
-		Weight:         req.Weight,
-		Priority:       req.Priority,
-		Enabled:        req.Enabled,
+		UnifiedModelID:      req.UnifiedModelID,
+		ProviderID:          req.ProviderID,
+		TargetModel:         strings.TrimSpace(req.TargetModel),
+		Weight:              req.Weight,
+		Priority:            req.Priority,
+		Enabled:             req.Enabled,
+		EmulateTools:        req.EmulateTools,
+		UnreliableToolCalls: req.UnreliableToolCalls,
 	}
 
 	// 保存到数据库
@@ -409,6 +417,16 @@ func (s *Service) UpdateMapping(id uint, req UpdateMappingRequest) (*MappingResp
 		}
 	}
 
+	if req.EmulateTools != nil && *req.EmulateTools != mapping.EmulateTools {
+		mapping.EmulateTools = *req.EmulateTools
+		updated = true
+	}
+
+	if req.UnreliableToolCalls != nil && *req.UnreliableToolCalls != mapping.UnreliableToolCalls {
+		mapping.UnreliableToolCalls = *req.UnreliableToolCalls
+		updated = true
+	}
+
 	// 如果有更新，保存到数据库
 	if updated {
 		if err := s.repo.UpdateMapping(mapping); err != nil {
@@ -10,16 +10,19 @@ import (
 
 // ResolvedMapping 解析后的映射信息
 type ResolvedMapping struct {
-	ID             uint          `json:"id"`
-	UnifiedModelID uint          `json:"unified_model_id"`
-	ProviderID     uint          `json:"provider_id"`
-	TargetModel    string        `json:"target_model"`
-	Weight         int           `json:"weight"`
-	Priority       int           `json:"priority"`
-	Enabled        bool          `json:"enabled"`
-	Provider       *ProviderInfo `json:"provider"`
-	CreatedAt      time.Time     `json:"created_at"`
-	UpdatedAt      time.Time     `json:"updated_at"`
+	ID                  uint          `json:"id"`
+	UnifiedModelID      uint          `json:"unified_model_id"`
+	ProviderID          uint          `json:"provider_id"`
+	TargetModel         string        `json:"target_model"`
+	Weight              int           `json:"weight"`
+	Priority            int           `json:"priority"`
+	Enabled             bool          `json:"enabled"`
+	ResponseCache       bool          `json:"response_cache"`        // 统一模型是否启用响应缓存
+	EmulateTools        bool          `json:"emulate_tools"`         // 以提示词模拟工具调用
+	UnreliableToolCalls bool          `json:"unreliable_tool_calls"` // 工具调用参数不可靠，流式参数缓冲修复
+	Provider            *ProviderInfo `json:"provider"`
+	CreatedAt           time.Time     `json:"created_at"`
+	UpdatedAt           time.Time     `json:"updated_at"`
 }
 
 // ProviderInfo 供应商信息
@@ -103,15 +106,17 @@ func NewNoAvailableProvidersError(modelName string) *RouterError {
 // ToResolvedMapping 将模型映射转换为解析后的映射
 func ToResolvedMapping(mapping *models.ModelMapping) *ResolvedMapping {
 	resolved := &ResolvedMapping{
-		ID:             mapping.ID,
-		UnifiedModelID: mapping.UnifiedModelID,
-		ProviderID:     mapping.ProviderID,
-		TargetModel:    mapping.TargetModel,
-		Weight:         mapping.Weight,
-		Priority:       mapping.Priority,
-		Enabled:        mapping.Enabled,
-		CreatedAt:      mapping.CreatedAt,
-		UpdatedAt:      mapping.UpdatedAt,
+		ID:                  mapping.ID,
+		UnifiedModelID:      mapping.UnifiedModelID,
+		ProviderID:          mapping.ProviderID,
+		TargetModel:         mapping.TargetModel,
+		Weight:              mapping.Weight,
+		Priority:            mapping.Priority,
+		Enabled:             mapping.Enabled,
+		EmulateTools:        mapping.EmulateTools,
+		UnreliableToolCalls: mapping.UnreliableToolCalls,
+		CreatedAt:           mapping.CreatedAt,
+		UpdatedAt:           mapping.UpdatedAt,
 	}
 
 	// 转换供应商信息
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// CachedResponse 响应缓存的持久化条目（开启 SQLite 持久化时使用）
+// 仅保存非流式请求的成功响应，键由规范化请求体、统一模型与 Token 计算
+type CachedResponse struct {
+	Key       string    `gorm:"type:varchar(64);primaryKey" json:"key"` // SHA-256 十六进制
+	Body      string    `gorm:"type:text;not null" json:"body"`         // 响应体（JSON）
+	ExpiresAt time.Time `gorm:"not null;index" json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName 指定表名
+func (CachedResponse) TableName() string {
+	return "response_cache"
+}
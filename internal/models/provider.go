@@ -16,6 +16,51 @@ type Provider struct {
 	TestModel    string         `gorm:"type:varchar(100);not null;default:'gpt-3.5-turbo'" json:"test_model"` // 用于健康检查的测试模型
 	Enabled      bool           `gorm:"not null" json:"enabled"`
 	HealthStatus string         `gorm:"type:varchar(20);default:'unknown'" json:"health_status"` // healthy/unhealthy/unknown
+
+	// 供应商类型
+	Type       string `gorm:"type:varchar(20);not null;default:'openai'" json:"type"`       // openai/azure/bedrock/vertex/gemini/ollama
+	APIVersion string `gorm:"type:varchar(50);not null;default:''" json:"api_version"` // Azure api-version，为空使用默认值
+
+	// AWS Bedrock 凭证（api_key 存放 Access Key ID）
+	AWSSecretKey string `gorm:"type:text;not null;default:''" json:"aws_secret_key"`        // Secret Access Key，加密存储
+	AWSRegion    string `gorm:"type:varchar(50);not null;default:''" json:"aws_region"` // 如 us-east-1
+
+	// Google Vertex AI 设置（api_key 存放服务账号 JSON）
+	VertexProjectID string `gorm:"type:varchar(100);not null;default:''" json:"vertex_project_id"` // 为空使用服务账号中的 project_id
+	VertexLocation  string `gorm:"type:varchar(50);not null;default:''" json:"vertex_location"`    // 如 us-east5、global
+	TokenURL        string `gorm:"type:varchar(255);not null;default:''" json:"token_url"`         // OAuth 令牌端点，为空使用服务账号中的 token_uri
+
+	// 出站连接设置（超时单位为秒，0 表示使用默认值）
+	ConnectTimeout        int  `gorm:"not null;default:0" json:"connect_timeout"`         // TCP 建连超时
+	ResponseHeaderTimeout int  `gorm:"not null;default:0" json:"response_header_timeout"` // 等待响应头超时
+	StreamIdleTimeout     int  `gorm:"not null;default:0" json:"stream_idle_timeout"`     // 流式响应空闲超时
+	MaxIdleConns          int  `gorm:"not null;default:0" json:"max_idle_conns"`          // 最大空闲连接数
+	DisableHTTP2          bool `gorm:"not null;default:false" json:"disable_http2"`       // 是否禁用 HTTP/2
+
+	// 出站代理与 TLS 设置
+	ProxyURL           string `gorm:"type:text;not null;default:''" json:"proxy_url"`         // http(s):// 或 socks5:// 代理地址，加密存储
+	CACert             string `gorm:"type:text;not null;default:''" json:"ca_cert"`           // 自定义 CA 证书（PEM）
+	ClientCert         string `gorm:"type:text;not null;default:''" json:"client_cert"`       // mTLS 客户端证书（PEM）
+	ClientKey          string `gorm:"type:text;not null;default:''" json:"client_key"`        // mTLS 客户端私钥（PEM），加密存储
+	InsecureSkipVerify bool   `gorm:"not null;default:false" json:"insecure_skip_verify"` // 跳过证书校验（仅用于调试）
+
+	// 上游端点路径覆盖（为空使用默认路径，支持 {{model}} 等模板变量）
+	ChatPath        string `gorm:"type:varchar(255);not null;default:''" json:"chat_path"`         // 默认 /v1/chat/completions
+	MessagesPath    string `gorm:"type:varchar(255);not null;default:''" json:"messages_path"`     // 默认 /v1/messages
+	ModelsPath      string `gorm:"type:varchar(255);not null;default:''" json:"models_path"`       // 默认 /v1/models
+	CountTokensPath string `gorm:"type:varchar(255);not null;default:''" json:"count_tokens_path"` // 默认 /v1/messages/count_tokens
+	ResponsesPath   string `gorm:"type:varchar(255);not null;default:''" json:"responses_path"`    // 默认 /v1/responses
+	ResponsesAPI    bool   `gorm:"not null;default:false" json:"responses_api"`                    // 上游原生支持 Responses API，/v1/responses 请求直接转发
+	EmbeddingsPath  string `gorm:"type:varchar(255);not null;default:''" json:"embeddings_path"`   // 默认 /v1/embeddings
+	CompletionsPath string `gorm:"type:varchar(255);not null;default:''" json:"completions_path"`  // 默认 /v1/completions
+	CompletionsAPI  bool   `gorm:"not null;default:false" json:"completions_api"`                  // 上游原生支持旧版 Completions API，否则转换为 Chat Completions
+	ImagesPath      string `gorm:"type:varchar(255);not null;default:''" json:"images_path"`       // 默认 /v1/images/generations
+	PromptCacheHints bool  `gorm:"not null;default:false" json:"prompt_cache_hints"`              // 上游接受 OpenAI 格式内容块上的 cache_control（OpenRouter、DashScope 等），Claude→OpenAI 转换时保留缓存断点
+	PDFTextExtraction bool `gorm:"not null;default:false" json:"pdf_text_extraction"`             // 上游不支持原生 PDF 文档时开启，Claude→OpenAI 转换时在进程内提取 PDF 文本
+	StreamUsage     bool   `gorm:"not null;default:false" json:"stream_usage"`                     // 上游接受 stream_options.include_usage，Claude→OpenAI 流式请求时开启以获取 token 用量
+	CustomHeaders   string `gorm:"type:text;not null;default:''" json:"custom_headers"`            // 自定义请求头（JSON 对象，值支持 {{token_name}} 等模板变量）
+	ForwardHeaders  string `gorm:"type:text;not null;default:''" json:"forward_headers"`           // 额外放行的客户端请求头（JSON 数组，支持 X-Foo-* 前缀）
+
 	CreatedAt    time.Time      `json:"created_at"`
 	UpdatedAt    time.Time      `json:"updated_at"`
 	DeletedAt    gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"` // 软删除支持
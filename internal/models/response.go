@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// StoredResponse Responses API 的历史轮次
+// 用于在本地实现 previous_response_id 多轮对话（与上游是否支持有状态存储无关）
+type StoredResponse struct {
+	ID                 string    `gorm:"type:varchar(100);primaryKey" json:"id"`  // resp_xxx
+	TokenID            uint      `gorm:"not null;index" json:"token_id"`          // 创建该响应的 Token，只允许同一 Token 续接
+	Model              string    `gorm:"type:varchar(100);not null" json:"model"` // 客户端请求的统一模型名
+	PreviousResponseID string    `gorm:"type:varchar(100);not null;default:''" json:"previous_response_id"`
+	Input              string    `gorm:"type:text;not null" json:"input"`  // 本轮输入项（JSON 数组）
+	Output             string    `gorm:"type:text;not null" json:"output"` // 本轮输出项（JSON 数组）
+	CreatedAt          time.Time `json:"created_at"`
+}
+
+// TableName 指定表名
+func (StoredResponse) TableName() string {
+	return "responses"
+}
@@ -9,14 +9,15 @@ import (
 // Token API 令牌
 // 用于验证客户端访问权限
 type Token struct {
-	ID        uint           `gorm:"primaryKey" json:"id"`
-	Name      string         `gorm:"type:varchar(100);not null" json:"name"`
-	Token     string         `gorm:"type:varchar(100);not null;uniqueIndex" json:"token"`
-	Enabled   bool           `gorm:"default:true;not null" json:"enabled"`
-	ExpiresAt *time.Time     `json:"expires_at,omitempty"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"` // 软删除支持
+	ID            uint           `gorm:"primaryKey" json:"id"`
+	Name          string         `gorm:"type:varchar(100);not null" json:"name"`
+	Token         string         `gorm:"type:varchar(100);not null;uniqueIndex" json:"token"`
+	Enabled       bool           `gorm:"default:true;not null" json:"enabled"`
+	ResponseCache bool           `gorm:"default:false;not null" json:"response_cache"` // 是否对该 Token 的请求启用响应缓存
+	ExpiresAt     *time.Time     `json:"expires_at,omitempty"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"` // 软删除支持
 }
 
 // TableName 指定表名
@@ -0,0 +1,104 @@
+package provider
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Mieluoxxx/Siriusx-API/internal/models"
+)
+
+// Bedrock 相关常量
+const (
+	// BedrockAnthropicVersion Bedrock 上 Claude 要求的 anthropic_version（放在请求体中）
+	BedrockAnthropicVersion = "bedrock-2023-05-31"
+	// bedrockRuntimeService 运行时接口的 SigV4 服务名
+	bedrockRuntimeService = "bedrock"
+)
+
+// BedrockCredentials 获取 Bedrock 访问凭证
+// api_key 存放 Access Key ID，aws_secret_key 存放 Secret Access Key
+func BedrockCredentials(prov *models.Provider) AWSCredentials {
+	return AWSCredentials{
+		AccessKeyID:     prov.APIKey,
+		SecretAccessKey: prov.AWSSecretKey,
+	}
+}
+
+// BedrockInvokeURL 构建 InvokeModel / InvokeModelWithResponseStream 的 URL
+// 模型 ID 中的冒号等字符按 AWS 规则转义
+func BedrockInvokeURL(prov *models.Provider, modelID string, stream bool) string {
+	action := "invoke"
+	if stream {
+		action = "invoke-with-response-stream"
+	}
+	return strings.TrimRight(prov.BaseURL, "/") + "/model/" + awsURIEncode(modelID) + "/" + action
+}
+
+// BedrockModelsURL 构建基础模型列表 URL
+// 模型列表位于控制面（bedrock.<region>），运行时地址（bedrock-runtime.<region>）自动替换
+func BedrockModelsURL(prov *models.Provider) string {
+	base := strings.TrimRight(prov.BaseURL, "/")
+	if parsed, err := url.Parse(base); err == nil && strings.HasPrefix(parsed.Host, "bedrock-runtime.") {
+		parsed.Host = strings.TrimPrefix(parsed.Host, "bedrock-runtime.")
+		parsed.Host = "bedrock." + parsed.Host
+		base = parsed.String()
+	}
+	return base + "/foundation-models?byProvider=anthropic"
+}
+
+// BuildBedrockBody 将 Claude Messages 请求体转换为 Bedrock InvokeModel 请求体
+// model 与 stream 由 URL 决定，anthropic_version 改为放在请求体中，anthropic-beta 请求头转为 anthropic_beta 字段
+func BuildBedrockBody(req map[string]interface{}, betaHeader string) map[string]interface{} {
+	return buildNativeClaudeBody(req, betaHeader, BedrockAnthropicVersion)
+}
+
+// buildNativeClaudeBody 构建云厂商托管 Claude 的请求体（Bedrock / Vertex 规则相同，仅版本号不同）
+func buildNativeClaudeBody(req map[string]interface{}, betaHeader, anthropicVersion string) map[string]interface{} {
+	body := make(map[string]interface{}, len(req)+1)
+	for key, value := range req {
+		switch key {
+		case "model", "stream", "metadata":
+			continue
+		}
+		body[key] = value
+	}
+	body["anthropic_version"] = anthropicVersion
+
+	if betaHeader != "" {
+		var betas []string
+		for _, beta := range strings.Split(betaHeader, ",") {
+			if beta = strings.TrimSpace(beta); beta != "" {
+				betas = append(betas, beta)
+			}
+		}
+		if len(betas) > 0 {
+			body["anthropic_beta"] = betas
+		}
+	}
+	return body
+}
+
+// SignBedrockRequest 使用供应商凭证对 Bedrock 请求进行 SigV4 签名
+// 必须在所有请求头设置完成后调用
+func SignBedrockRequest(req *http.Request, prov *models.Provider, body []byte) error {
+	region := strings.TrimSpace(prov.AWSRegion)
+	if region == "" {
+		return fmt.Errorf("aws_region is required for bedrock providers")
+	}
+	req.Header.Del("Authorization")
+	return SignV4(req, body, BedrockCredentials(prov), region, bedrockRuntimeService, time.Now())
+}
+
+// validateBedrockSettings 校验 Bedrock 供应商配置
+func validateBedrockSettings(prov *models.Provider) error {
+	if strings.TrimSpace(prov.AWSRegion) == "" {
+		return fmt.Errorf("aws_region is required for bedrock providers")
+	}
+	if prov.AWSSecretKey == "" {
+		return fmt.Errorf("aws_secret_key is required for bedrock providers")
+	}
+	return nil
+}
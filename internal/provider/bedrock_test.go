@@ -0,0 +1,216 @@
+package provider
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Mieluoxxx/Siriusx-API/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testAWSAccessKey = "AKIDEXAMPLE"
+	testAWSSecretKey = "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"
+	testAWSRegion    = "us-west-2"
+)
+
+// verifyTestSigV4 独立实现的 SigV4 校验（模拟 AWS 服务端）
+func verifyTestSigV4(r *http.Request, body []byte, secret string) error {
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 ") {
+		return fmt.Errorf("missing sigv4 authorization")
+	}
+	fields := map[string]string{}
+	for _, part := range strings.Split(strings.TrimPrefix(auth, "AWS4-HMAC-SHA256 "), ", ") {
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			return fmt.Errorf("malformed authorization part %q", part)
+		}
+		fields[kv[0]] = kv[1]
+	}
+	scope := strings.SplitN(fields["Credential"], "/", 2)
+	if len(scope) != 2 || scope[0] != testAWSAccessKey {
+		return fmt.Errorf("unexpected credential %q", fields["Credential"])
+	}
+	scopeParts := strings.Split(scope[1], "/")
+	if len(scopeParts) != 4 || scopeParts[1] != testAWSRegion || scopeParts[2] != "bedrock" {
+		return fmt.Errorf("unexpected scope %q", scope[1])
+	}
+
+	encode := func(s string) string {
+		return strings.ReplaceAll(strings.ReplaceAll(url.QueryEscape(s), "+", "%20"), "%7E", "~")
+	}
+	segments := strings.Split(r.URL.EscapedPath(), "/")
+	for i, segment := range segments {
+		segments[i] = encode(segment)
+	}
+	var query []string
+	for key, values := range r.URL.Query() {
+		for _, value := range values {
+			query = append(query, encode(key)+"="+encode(value))
+		}
+	}
+	sort.Strings(query)
+
+	var headers strings.Builder
+	for _, name := range strings.Split(fields["SignedHeaders"], ";") {
+		value := r.Header.Get(name)
+		if name == "host" {
+			value = r.Host
+		}
+		headers.WriteString(name + ":" + strings.TrimSpace(value) + "\n")
+	}
+
+	payloadHash := sha256.Sum256(body)
+	canonical := strings.Join([]string{
+		r.Method, strings.Join(segments, "/"), strings.Join(query, "&"),
+		headers.String(), fields["SignedHeaders"], hex.EncodeToString(payloadHash[:]),
+	}, "\n")
+	canonicalHash := sha256.Sum256([]byte(canonical))
+	stringToSign := "AWS4-HMAC-SHA256\n" + r.Header.Get("X-Amz-Date") + "\n" + scope[1] + "\n" + hex.EncodeToString(canonicalHash[:])
+
+	mac := func(key []byte, data string) []byte {
+		h := hmac.New(sha256.New, key)
+		h.Write([]byte(data))
+		return h.Sum(nil)
+	}
+	key := mac([]byte("AWS4"+secret), scopeParts[0])
+	key = mac(key, scopeParts[1])
+	key = mac(key, scopeParts[2])
+	key = mac(key, "aws4_request")
+	if expected := hex.EncodeToString(mac(key, stringToSign)); expected != fields["Signature"] {
+		return fmt.Errorf("signature mismatch")
+	}
+	return nil
+}
+
+// newBedrockStandIn 模拟 Bedrock：校验签名后响应 InvokeModel 与模型列表
+func newBedrockStandIn(t *testing.T, invoked *map[string]interface{}) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if err := verifyTestSigV4(r, body, testAWSSecretKey); err != nil {
+			w.Header().Set("X-Amzn-ErrorType", "InvalidSignatureException")
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprintf(w, `{"message":%q}`, err.Error())
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.EscapedPath() {
+		case "/foundation-models":
+			w.Write([]byte(`{"modelSummaries":[{"modelId":"anthropic.claude-3-5-sonnet-20240620-v1:0"}]}`))
+		case "/model/anthropic.claude-3-5-sonnet-20240620-v1%3A0/invoke":
+			if invoked != nil {
+				json.Unmarshal(body, invoked)
+			}
+			w.Write([]byte(`{"id":"msg_1","type":"message","role":"assistant","content":[{"type":"text","text":"Hi"}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestBedrockInvokeURL(t *testing.T) {
+	prov := &models.Provider{BaseURL: "https://bedrock-runtime.us-west-2.amazonaws.com/"}
+
+	assert.Equal(t, "https://bedrock-runtime.us-west-2.amazonaws.com/model/anthropic.claude-3-5-sonnet-20240620-v1%3A0/invoke",
+		BedrockInvokeURL(prov, "anthropic.claude-3-5-sonnet-20240620-v1:0", false))
+	assert.Equal(t, "https://bedrock-runtime.us-west-2.amazonaws.com/model/anthropic.claude-3-5-sonnet-20240620-v1%3A0/invoke-with-response-stream",
+		BedrockInvokeURL(prov, "anthropic.claude-3-5-sonnet-20240620-v1:0", true))
+	assert.Equal(t, "https://bedrock.us-west-2.amazonaws.com/foundation-models?byProvider=anthropic", BedrockModelsURL(prov))
+}
+
+func TestBuildBedrockBody(t *testing.T) {
+	body := BuildBedrockBody(map[string]interface{}{
+		"model":             "anthropic.claude-3-5-sonnet-20240620-v1:0",
+		"stream":            true,
+		"metadata":          map[string]interface{}{"user_id": "u1"},
+		"anthropic_version": "2023-06-01",
+		"max_tokens":        16,
+	}, "prompt-caching-2024-07-31, token-efficient-tools-2025-02-19")
+
+	assert.Equal(t, BedrockAnthropicVersion, body["anthropic_version"])
+	assert.Equal(t, []string{"prompt-caching-2024-07-31", "token-efficient-tools-2025-02-19"}, body["anthropic_beta"])
+	assert.Equal(t, 16, body["max_tokens"])
+	assert.NotContains(t, body, "model")
+	assert.NotContains(t, body, "stream")
+	assert.NotContains(t, body, "metadata")
+}
+
+func TestSignBedrockRequest_VerifiedByStandIn(t *testing.T) {
+	var invoked map[string]interface{}
+	server := newBedrockStandIn(t, &invoked)
+	defer server.Close()
+
+	prov := &models.Provider{
+		Type:         ProviderTypeBedrock,
+		BaseURL:      server.URL,
+		APIKey:       testAWSAccessKey,
+		AWSSecretKey: testAWSSecretKey,
+		AWSRegion:    testAWSRegion,
+	}
+
+	result, err := NewHealthChecker(5*time.Second).CheckProviderHealth(t.Context(), prov, "anthropic.claude-3-5-sonnet-20240620-v1:0")
+	require.NoError(t, err)
+	assert.True(t, result.Healthy, result.Error)
+	assert.Equal(t, BedrockAnthropicVersion, invoked["anthropic_version"])
+
+	// 错误的密钥无法通过校验
+	prov.AWSSecretKey = "wrong-secret"
+	result, err = NewHealthChecker(5*time.Second).CheckProviderHealth(t.Context(), prov, "anthropic.claude-3-5-sonnet-20240620-v1:0")
+	require.NoError(t, err)
+	assert.False(t, result.Healthy)
+	assert.Equal(t, http.StatusForbidden, result.StatusCode)
+}
+
+func TestService_BedrockProvider(t *testing.T) {
+	server := newBedrockStandIn(t, nil)
+	defer server.Close()
+
+	service := setupTestServiceWithEncryption(t, generateTestEncryptionKey(t))
+	created, err := service.CreateProvider(CreateProviderRequest{
+		Name:         "bedrock",
+		BaseURL:      server.URL,
+		APIKey:       testAWSAccessKey,
+		TestModel:    "anthropic.claude-3-5-sonnet-20240620-v1:0",
+		Type:         ProviderTypeBedrock,
+		AWSSecretKey: testAWSSecretKey,
+		AWSRegion:    testAWSRegion,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, testAWSSecretKey, created.AWSSecretKey, "plaintext should be returned after create")
+
+	// 数据库中的 Secret Key 应为密文
+	stored, err := service.repo.FindByID(created.ID)
+	require.NoError(t, err)
+	assert.NotEqual(t, testAWSSecretKey, stored.AWSSecretKey)
+	assert.NotEmpty(t, stored.AWSSecretKey)
+
+	available, err := service.GetAvailableModels(created.ID)
+	require.NoError(t, err)
+	require.Equal(t, 1, available.Total)
+	assert.Equal(t, "anthropic.claude-3-5-sonnet-20240620-v1:0", available.Models[0].ID)
+
+	// 缺少 region 的 Bedrock 供应商应被拒绝
+	_, err = service.CreateProvider(CreateProviderRequest{
+		Name:         "bedrock-no-region",
+		BaseURL:      server.URL,
+		APIKey:       testAWSAccessKey,
+		TestModel:    "m",
+		Type:         ProviderTypeBedrock,
+		AWSSecretKey: testAWSSecretKey,
+	})
+	assert.ErrorIs(t, err, ErrInvalidInput)
+}
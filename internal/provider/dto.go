@@ -13,6 +13,50 @@ type CreateProviderRequest struct {
 	APIKey    string `json:"api_key" binding:"required"`
 	TestModel string `json:"test_model" binding:"required"`
 	Enabled   *bool  `json:"enabled"`
+
+	// 供应商类型（openai/azure/bedrock/vertex/gemini/ollama，默认 openai）
+	Type       string `json:"type"`
+	APIVersion string `json:"api_version"`
+
+	// AWS Bedrock 凭证（api_key 填写 Access Key ID）
+	AWSSecretKey string `json:"aws_secret_key"`
+	AWSRegion    string `json:"aws_region"`
+
+	// Google Vertex AI 设置（api_key 填写服务账号 JSON）
+	VertexProjectID string `json:"vertex_project_id"`
+	VertexLocation  string `json:"vertex_location"`
+	TokenURL        string `json:"token_url"`
+
+	// 出站连接设置（秒，0 表示默认值）
+	ConnectTimeout        int  `json:"connect_timeout" binding:"omitempty,min=0"`
+	ResponseHeaderTimeout int  `json:"response_header_timeout" binding:"omitempty,min=0"`
+	StreamIdleTimeout     int  `json:"stream_idle_timeout" binding:"omitempty,min=0"`
+	MaxIdleConns          int  `json:"max_idle_conns" binding:"omitempty,min=0"`
+	DisableHTTP2          bool `json:"disable_http2"`
+
+	// 出站代理与 TLS 设置
+	ProxyURL           string `json:"proxy_url"`
+	CACert             string `json:"ca_cert"`
+	ClientCert         string `json:"client_cert"`
+	ClientKey          string `json:"client_key"`
+	InsecureSkipVerify bool   `json:"insecure_skip_verify"`
+
+	// 上游端点路径覆盖与自定义请求头
+	ChatPath          string            `json:"chat_path"`
+	MessagesPath      string            `json:"messages_path"`
+	ModelsPath        string            `json:"models_path"`
+	CountTokensPath   string            `json:"count_tokens_path"`
+	ResponsesPath     string            `json:"responses_path"`
+	ResponsesAPI      bool              `json:"responses_api"`
+	EmbeddingsPath    string            `json:"embeddings_path"`
+	CompletionsPath   string            `json:"completions_path"`
+	CompletionsAPI    bool              `json:"completions_api"`
+	ImagesPath        string            `json:"images_path"`
+	PromptCacheHints  bool              `json:"prompt_cache_hints"`
+	PDFTextExtraction bool              `json:"pdf_text_extraction"`
+	StreamUsage       bool              `json:"stream_usage"`
+	CustomHeaders     map[string]string `json:"custom_headers"`
+	ForwardHeaders    []string          `json:"forward_headers"`
 }
 
 // UpdateProviderRequest 更新供应商请求
@@ -22,19 +66,102 @@ type UpdateProviderRequest struct {
 	APIKey    *string `json:"api_key"`
 	TestModel *string `json:"test_model"`
 	Enabled   *bool   `json:"enabled"`
+
+	// 供应商类型
+	Type       *string `json:"type"`
+	APIVersion *string `json:"api_version"`
+
+	// AWS Bedrock 凭证
+	AWSSecretKey *string `json:"aws_secret_key"`
+	AWSRegion    *string `json:"aws_region"`
+
+	// Google Vertex AI 设置
+	VertexProjectID *string `json:"vertex_project_id"`
+	VertexLocation  *string `json:"vertex_location"`
+	TokenURL        *string `json:"token_url"`
+
+	// 出站连接设置（秒，0 表示默认值）
+	ConnectTimeout        *int  `json:"connect_timeout" binding:"omitempty,min=0"`
+	ResponseHeaderTimeout *int  `json:"response_header_timeout" binding:"omitempty,min=0"`
+	StreamIdleTimeout     *int  `json:"stream_idle_timeout" binding:"omitempty,min=0"`
+	MaxIdleConns          *int  `json:"max_idle_conns" binding:"omitempty,min=0"`
+	DisableHTTP2          *bool `json:"disable_http2"`
+
+	// 出站代理与 TLS 设置（传空字符串表示清除）
+	ProxyURL           *string `json:"proxy_url"`
+	CACert             *string `json:"ca_cert"`
+	ClientCert         *string `json:"client_cert"`
+	ClientKey          *string `json:"client_key"`
+	InsecureSkipVerify *bool   `json:"insecure_skip_verify"`
+
+	// 上游端点路径覆盖与自定义请求头（custom_headers 整体替换）
+	ChatPath          *string            `json:"chat_path"`
+	MessagesPath      *string            `json:"messages_path"`
+	ModelsPath        *string            `json:"models_path"`
+	CountTokensPath   *string            `json:"count_tokens_path"`
+	ResponsesPath     *string            `json:"responses_path"`
+	ResponsesAPI      *bool              `json:"responses_api"`
+	EmbeddingsPath    *string            `json:"embeddings_path"`
+	CompletionsPath   *string            `json:"completions_path"`
+	CompletionsAPI    *bool              `json:"completions_api"`
+	ImagesPath        *string            `json:"images_path"`
+	PromptCacheHints  *bool              `json:"prompt_cache_hints"`
+	PDFTextExtraction *bool              `json:"pdf_text_extraction"`
+	StreamUsage       *bool              `json:"stream_usage"`
+	CustomHeaders     *map[string]string `json:"custom_headers"`
+	ForwardHeaders    *[]string          `json:"forward_headers"`
 }
 
 // ProviderResponse 供应商响应（API Key 脱敏）
 type ProviderResponse struct {
-	ID           uint      `json:"id"`
-	Name         string    `json:"name"`
-	BaseURL      string    `json:"base_url"`
-	APIKey       string    `json:"api_key"` // 脱敏显示
-	TestModel    string    `json:"test_model"`
-	Enabled      bool      `json:"enabled"`
-	HealthStatus string    `json:"health_status"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
+	ID           uint   `json:"id"`
+	Name         string `json:"name"`
+	BaseURL      string `json:"base_url"`
+	APIKey       string `json:"api_key"` // 脱敏显示
+	TestModel    string `json:"test_model"`
+	Enabled      bool   `json:"enabled"`
+	HealthStatus string `json:"health_status"`
+
+	Type       string `json:"type"`
+	APIVersion string `json:"api_version"`
+
+	HasAWSSecretKey bool   `json:"has_aws_secret_key"` // Secret Key 不回显
+	AWSRegion       string `json:"aws_region"`
+
+	VertexProjectID string `json:"vertex_project_id"`
+	VertexLocation  string `json:"vertex_location"`
+	TokenURL        string `json:"token_url"`
+
+	ConnectTimeout        int  `json:"connect_timeout"`
+	ResponseHeaderTimeout int  `json:"response_header_timeout"`
+	StreamIdleTimeout     int  `json:"stream_idle_timeout"`
+	MaxIdleConns          int  `json:"max_idle_conns"`
+	DisableHTTP2          bool `json:"disable_http2"`
+
+	ProxyURL           string `json:"proxy_url"` // 隐藏密码
+	CACert             string `json:"ca_cert"`
+	ClientCert         string `json:"client_cert"`
+	HasClientKey       bool   `json:"has_client_key"` // 私钥不回显
+	InsecureSkipVerify bool   `json:"insecure_skip_verify"`
+
+	ChatPath          string            `json:"chat_path"`
+	MessagesPath      string            `json:"messages_path"`
+	ModelsPath        string            `json:"models_path"`
+	CountTokensPath   string            `json:"count_tokens_path"`
+	ResponsesPath     string            `json:"responses_path"`
+	ResponsesAPI      bool              `json:"responses_api"`
+	EmbeddingsPath    string            `json:"embeddings_path"`
+	CompletionsPath   string            `json:"completions_path"`
+	CompletionsAPI    bool              `json:"completions_api"`
+	ImagesPath        string            `json:"images_path"`
+	PromptCacheHints  bool              `json:"prompt_cache_hints"`
+	PDFTextExtraction bool              `json:"pdf_text_extraction"`
+	StreamUsage       bool              `json:"stream_usage"`
+	CustomHeaders     map[string]string `json:"custom_headers"`
+	ForwardHeaders    []string          `json:"forward_headers"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
 }
 
 // ProviderListResponse 供应商列表响应（带分页）
@@ -63,6 +190,33 @@ type ErrorDetail struct {
 	Details interface{} `json:"details,omitempty"`
 }
 
+// DecodeCustomHeaders 解析自定义请求头用于响应展示，解析失败返回空 map
+func DecodeCustomHeaders(raw string) map[string]string {
+	headers, err := ParseCustomHeaders(raw)
+	if err != nil {
+		return map[string]string{}
+	}
+	return headers
+}
+
+// DecodeForwardHeaders 解析额外放行请求头用于响应展示，解析失败返回空切片
+func DecodeForwardHeaders(raw string) []string {
+	headers, err := ParseForwardHeaders(raw)
+	if err != nil || headers == nil {
+		return []string{}
+	}
+	return headers
+}
+
+// MaskCustomHeaders 自定义请求头脱敏：保留请求头名称，值按 API Key 规则脱敏
+func MaskCustomHeaders(headers map[string]string) map[string]string {
+	masked := make(map[string]string, len(headers))
+	for name, value := range headers {
+		masked[name] = MaskAPIKey(value)
+	}
+	return masked
+}
+
 // MaskAPIKey API Key 脱敏
 // 格式: sk-****last4
 func MaskAPIKey(apiKey string) string {
@@ -81,40 +235,74 @@ func MaskEncryptedAPIKey() string {
 
 // ToProviderResponse 转换为响应（API Key 脱敏）
 func ToProviderResponse(provider *models.Provider, maskKey bool) *ProviderResponse {
-	resp := &ProviderResponse{
-		ID:           provider.ID,
-		Name:         provider.Name,
-		BaseURL:      provider.BaseURL,
-		TestModel:    provider.TestModel,
-		Enabled:      provider.Enabled,
-		HealthStatus: provider.HealthStatus,
-		CreatedAt:    provider.CreatedAt,
-		UpdatedAt:    provider.UpdatedAt,
-	}
-
-	// API Key 脱敏
+	// API Key 与自定义请求头脱敏
 	if maskKey {
-		resp.APIKey = MaskEncryptedAPIKey()
-	} else {
-		resp.APIKey = provider.APIKey
+		return newProviderResponse(provider, MaskEncryptedAPIKey(), true)
 	}
-
-	return resp
+	return newProviderResponse(provider, provider.APIKey, false)
 }
 
 // ToProviderResponseWithDecryption 转换为响应（解密并脱敏 API Key）
 func ToProviderResponseWithDecryption(provider *models.Provider, decryptedKey string) *ProviderResponse {
-	resp := &ProviderResponse{
+	return newProviderResponse(provider, MaskAPIKey(decryptedKey), true) // 脱敏明文 API Key
+}
+
+// newProviderResponse Provider 到响应的字段映射，所有响应构造都经由此处
+// apiKey 为调用方处理后的展示值，maskHeaders 为 true 时自定义请求头只展示脱敏后的值
+func newProviderResponse(provider *models.Provider, apiKey string, maskHeaders bool) *ProviderResponse {
+	customHeaders := DecodeCustomHeaders(provider.CustomHeaders)
+	if maskHeaders {
+		customHeaders = MaskCustomHeaders(customHeaders)
+	}
+
+	return &ProviderResponse{
 		ID:           provider.ID,
 		Name:         provider.Name,
 		BaseURL:      provider.BaseURL,
+		APIKey:       apiKey,
 		TestModel:    provider.TestModel,
 		Enabled:      provider.Enabled,
 		HealthStatus: provider.HealthStatus,
-		CreatedAt:    provider.CreatedAt,
-		UpdatedAt:    provider.UpdatedAt,
-		APIKey:       MaskAPIKey(decryptedKey), // 脱敏明文 API Key
-	}
 
-	return resp
+		Type:       ProviderType(provider),
+		APIVersion: provider.APIVersion,
+
+		HasAWSSecretKey: provider.AWSSecretKey != "",
+		AWSRegion:       provider.AWSRegion,
+
+		VertexProjectID: provider.VertexProjectID,
+		VertexLocation:  provider.VertexLocation,
+		TokenURL:        provider.TokenURL,
+
+		ConnectTimeout:        provider.ConnectTimeout,
+		ResponseHeaderTimeout: provider.ResponseHeaderTimeout,
+		StreamIdleTimeout:     provider.StreamIdleTimeout,
+		MaxIdleConns:          provider.MaxIdleConns,
+		DisableHTTP2:          provider.DisableHTTP2,
+
+		ProxyURL:           RedactProxyURL(provider.ProxyURL),
+		CACert:             provider.CACert,
+		ClientCert:         provider.ClientCert,
+		HasClientKey:       provider.ClientKey != "",
+		InsecureSkipVerify: provider.InsecureSkipVerify,
+
+		ChatPath:          provider.ChatPath,
+		MessagesPath:      provider.MessagesPath,
+		ModelsPath:        provider.ModelsPath,
+		CountTokensPath:   provider.CountTokensPath,
+		ResponsesPath:     provider.ResponsesPath,
+		ResponsesAPI:      provider.ResponsesAPI,
+		EmbeddingsPath:    provider.EmbeddingsPath,
+		CompletionsPath:   provider.CompletionsPath,
+		CompletionsAPI:    provider.CompletionsAPI,
+		ImagesPath:        provider.ImagesPath,
+		PromptCacheHints:  provider.PromptCacheHints,
+		PDFTextExtraction: provider.PDFTextExtraction,
+		StreamUsage:       provider.StreamUsage,
+		CustomHeaders:     customHeaders,
+		ForwardHeaders:    DecodeForwardHeaders(provider.ForwardHeaders),
+
+		CreatedAt: provider.CreatedAt,
+		UpdatedAt: provider.UpdatedAt,
+	}
 }
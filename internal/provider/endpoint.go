@@ -0,0 +1,242 @@
+package provider
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/Mieluoxxx/Siriusx-API/internal/models"
+)
+
+// EndpointKind 上游端点类型
+type EndpointKind string
+
+const (
+	EndpointChat        EndpointKind = "chat"         // OpenAI Chat Completions
+	EndpointMessages    EndpointKind = "messages"     // Claude Messages
+	EndpointModels      EndpointKind = "models"       // 模型列表
+	EndpointCountTokens EndpointKind = "count_tokens" // Claude count_tokens
+	EndpointResponses   EndpointKind = "responses"    // OpenAI Responses
+	EndpointEmbeddings  EndpointKind = "embeddings"   // OpenAI Embeddings
+	EndpointCompletions EndpointKind = "completions"  // OpenAI 旧版 Completions
+	EndpointImages      EndpointKind = "images"       // OpenAI Images 生成
+)
+
+// defaultEndpointPaths 各端点的默认路径
+var defaultEndpointPaths = map[EndpointKind]string{
+	EndpointChat:        "/v1/chat/completions",
+	EndpointMessages:    "/v1/messages",
+	EndpointModels:      "/v1/models",
+	EndpointCountTokens: "/v1/messages/count_tokens",
+	EndpointResponses:   "/v1/responses",
+	EndpointEmbeddings:  "/v1/embeddings",
+	EndpointCompletions: "/v1/completions",
+	EndpointImages:      "/v1/images/generations",
+}
+
+// 模板变量名
+const (
+	TemplateVarTokenName    = "token_name"
+	TemplateVarTokenID      = "token_id"
+	TemplateVarProviderName = "provider_name"
+	TemplateVarModel        = "model"
+	TemplateVarClientIP     = "client_ip"
+)
+
+// supportedTemplateVars 支持的模板变量
+var supportedTemplateVars = map[string]bool{
+	TemplateVarTokenName:    true,
+	TemplateVarTokenID:      true,
+	TemplateVarProviderName: true,
+	TemplateVarModel:        true,
+	TemplateVarClientIP:     true,
+}
+
+// templatePattern 匹配 {{name}} 形式的模板变量
+var templatePattern = regexp.MustCompile(`\{\{\s*([a-zA-Z_]+)\s*\}\}`)
+
+// TemplateVars 模板变量取值
+type TemplateVars map[string]string
+
+// EndpointPath 获取供应商指定端点的路径（未覆盖时使用默认路径）
+func EndpointPath(prov *models.Provider, kind EndpointKind) string {
+	var override string
+	switch kind {
+	case EndpointChat:
+		override = prov.ChatPath
+	case EndpointMessages:
+		override = prov.MessagesPath
+	case EndpointModels:
+		override = prov.ModelsPath
+	case EndpointCountTokens:
+		override = prov.CountTokensPath
+	case EndpointResponses:
+		override = prov.ResponsesPath
+	case EndpointEmbeddings:
+		override = prov.EmbeddingsPath
+	case EndpointCompletions:
+		override = prov.CompletionsPath
+	case EndpointImages:
+		override = prov.ImagesPath
+	}
+
+	if strings.TrimSpace(override) != "" {
+		return strings.TrimSpace(override)
+	}
+	if path, ok := typeEndpointPaths[ProviderType(prov)][kind]; ok {
+		return path
+	}
+	return defaultEndpointPaths[kind]
+}
+
+// EndpointURL 构建上游端点完整 URL，路径中的模板变量转义后替换
+// 配置了 api-version 时追加到查询参数（路径中已包含时不重复追加）
+func EndpointURL(prov *models.Provider, kind EndpointKind, vars TemplateVars) string {
+	path := renderPathTemplate(EndpointPath(prov, kind), vars)
+	if version := APIVersion(prov); version != "" && !strings.Contains(path, "api-version=") {
+		separator := "?"
+		if strings.Contains(path, "?") {
+			separator = "&"
+		}
+		path += separator + "api-version=" + url.QueryEscape(version)
+	}
+	return strings.TrimRight(prov.BaseURL, "/") + path
+}
+
+// RenderTemplate 替换 {{name}} 模板变量，未提供的变量替换为空字符串
+func RenderTemplate(tmpl string, vars TemplateVars) string {
+	return renderTemplate(tmpl, vars, func(value string) string { return value })
+}
+
+// renderPathTemplate 替换路径模板变量，变量值按所在位置转义
+// 客户端可控的值（如 client_ip、Token 名称）不能增加路径段或查询参数
+func renderPathTemplate(tmpl string, vars TemplateVars) string {
+	path, query, hasQuery := strings.Cut(tmpl, "?")
+	path = renderTemplate(path, vars, escapePathSegment)
+	if !hasQuery {
+		return path
+	}
+	return path + "?" + renderTemplate(query, vars, url.QueryEscape)
+}
+
+// escapePathSegment 转义路径段，. 与 .. 同样转义以免被上游解析为相对路径
+func escapePathSegment(value string) string {
+	if value == "." || value == ".." {
+		return strings.ReplaceAll(value, ".", "%2E")
+	}
+	return url.PathEscape(value)
+}
+
+// renderTemplate 替换 {{name}} 模板变量，变量值经 escape 处理
+func renderTemplate(tmpl string, vars TemplateVars, escape func(string) string) string {
+	if !strings.Contains(tmpl, "{{") {
+		return tmpl
+	}
+	return templatePattern.ReplaceAllStringFunc(tmpl, func(match string) string {
+		name := templatePattern.FindStringSubmatch(match)[1]
+		return escape(vars[name])
+	})
+}
+
+// ParseCustomHeaders 解析供应商存储的自定义请求头（JSON 对象）
+func ParseCustomHeaders(raw string) (map[string]string, error) {
+	headers := map[string]string{}
+	if strings.TrimSpace(raw) == "" {
+		return headers, nil
+	}
+	if err := json.Unmarshal([]byte(raw), &headers); err != nil {
+		return nil, fmt.Errorf("invalid custom_headers: %w", err)
+	}
+	return headers, nil
+}
+
+// EncodeCustomHeaders 将自定义请求头序列化为存储格式
+func EncodeCustomHeaders(headers map[string]string) (string, error) {
+	if len(headers) == 0 {
+		return "", nil
+	}
+	data, err := json.Marshal(headers)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// ApplyCustomHeaders 将供应商自定义请求头写入上游请求（覆盖同名请求头）
+func ApplyCustomHeaders(header http.Header, prov *models.Provider, vars TemplateVars) error {
+	headers, err := ParseCustomHeaders(prov.CustomHeaders)
+	if err != nil {
+		return err
+	}
+	for name, value := range headers {
+		header.Set(name, RenderTemplate(value, vars))
+	}
+	return nil
+}
+
+// ValidateEndpointSettings 校验路径覆盖与自定义请求头
+func ValidateEndpointSettings(prov *models.Provider) error {
+	paths := map[string]string{
+		"chat_path":         prov.ChatPath,
+		"messages_path":     prov.MessagesPath,
+		"models_path":       prov.ModelsPath,
+		"count_tokens_path": prov.CountTokensPath,
+		"responses_path":    prov.ResponsesPath,
+		"embeddings_path":   prov.EmbeddingsPath,
+		"completions_path":  prov.CompletionsPath,
+		"images_path":       prov.ImagesPath,
+	}
+	for field, path := range paths {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		if !strings.HasPrefix(path, "/") {
+			return fmt.Errorf("%s must start with /", field)
+		}
+		if err := validateTemplateVars(path); err != nil {
+			return fmt.Errorf("%s: %w", field, err)
+		}
+	}
+
+	headers, err := ParseCustomHeaders(prov.CustomHeaders)
+	if err != nil {
+		return err
+	}
+	for name, value := range headers {
+		if !isValidHeaderName(name) {
+			return fmt.Errorf("invalid custom header name %q", name)
+		}
+		if err := validateTemplateVars(value); err != nil {
+			return fmt.Errorf("custom header %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// validateTemplateVars 检查模板中是否引用了未知变量
+func validateTemplateVars(tmpl string) error {
+	for _, match := range templatePattern.FindAllStringSubmatch(tmpl, -1) {
+		if !supportedTemplateVars[match[1]] {
+			return fmt.Errorf("unknown template variable {{%s}}", match[1])
+		}
+	}
+	return nil
+}
+
+// isValidHeaderName 检查请求头名称是否为合法 token
+func isValidHeaderName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		if r <= ' ' || r >= 0x7f || strings.ContainsRune("()<>@,;:\\\"/[]?={}", r) {
+			return false
+		}
+	}
+	return true
+}
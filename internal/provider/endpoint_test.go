@@ -0,0 +1,111 @@
+package provider
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Mieluoxxx/Siriusx-API/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEndpointURL_DefaultsAndOverrides(t *testing.T) {
+	prov := &models.Provider{BaseURL: "https://open.bigmodel.cn/"}
+
+	assert.Equal(t, "https://open.bigmodel.cn/v1/chat/completions", EndpointURL(prov, EndpointChat, nil))
+	assert.Equal(t, "https://open.bigmodel.cn/v1/messages", EndpointURL(prov, EndpointMessages, nil))
+	assert.Equal(t, "https://open.bigmodel.cn/v1/models", EndpointURL(prov, EndpointModels, nil))
+	assert.Equal(t, "https://open.bigmodel.cn/v1/messages/count_tokens", EndpointURL(prov, EndpointCountTokens, nil))
+	assert.Equal(t, "https://open.bigmodel.cn/v1/responses", EndpointURL(prov, EndpointResponses, nil))
+	assert.Equal(t, "https://open.bigmodel.cn/v1/embeddings", EndpointURL(prov, EndpointEmbeddings, nil))
+	assert.Equal(t, "https://open.bigmodel.cn/v1/completions", EndpointURL(prov, EndpointCompletions, nil))
+	assert.Equal(t, "https://open.bigmodel.cn/v1/images/generations", EndpointURL(prov, EndpointImages, nil))
+
+	prov.ChatPath = "/api/paas/v4/chat/completions"
+	prov.ModelsPath = "/api/paas/v4/models/{{model}}"
+	prov.ResponsesPath = "/api/paas/v4/responses"
+	prov.EmbeddingsPath = "/api/paas/v4/embeddings"
+	assert.Equal(t, "https://open.bigmodel.cn/api/paas/v4/chat/completions", EndpointURL(prov, EndpointChat, nil))
+	assert.Equal(t, "https://open.bigmodel.cn/api/paas/v4/models/glm-4.6",
+		EndpointURL(prov, EndpointModels, TemplateVars{TemplateVarModel: "glm-4.6"}))
+	assert.Equal(t, "https://open.bigmodel.cn/api/paas/v4/responses", EndpointURL(prov, EndpointResponses, nil))
+	assert.Equal(t, "https://open.bigmodel.cn/api/paas/v4/embeddings", EndpointURL(prov, EndpointEmbeddings, nil))
+}
+
+func TestRenderTemplate(t *testing.T) {
+	vars := TemplateVars{TemplateVarTokenName: "ci-bot", TemplateVarProviderName: "openrouter"}
+
+	assert.Equal(t, "Siriusx/ci-bot", RenderTemplate("Siriusx/{{token_name}}", vars))
+	assert.Equal(t, "openrouter-ci-bot", RenderTemplate("{{ provider_name }}-{{token_name}}", vars))
+	assert.Equal(t, "model=", RenderTemplate("model={{model}}", vars))
+	assert.Equal(t, "plain", RenderTemplate("plain", vars))
+}
+
+func TestEndpointURL_EscapesTemplateVars(t *testing.T) {
+	prov := &models.Provider{BaseURL: "https://relay.example.com", ChatPath: "/{{client_ip}}/{{token_name}}/chat?user={{provider_name}}"}
+	vars := TemplateVars{
+		TemplateVarClientIP:     "..",
+		TemplateVarTokenName:    "a/../admin?x=1",
+		TemplateVarProviderName: "my relay&debug=1",
+	}
+
+	assert.Equal(t, "https://relay.example.com/%2E%2E/a%2F..%2Fadmin%3Fx=1/chat?user=my+relay%26debug%3D1",
+		EndpointURL(prov, EndpointChat, vars))
+}
+
+func TestApplyCustomHeaders(t *testing.T) {
+	prov := &models.Provider{
+		CustomHeaders: `{"HTTP-Referer":"https://siriusx.local","X-Title":"Siriusx {{token_name}}","User-Agent":"Siriusx-API/1.0"}`,
+	}
+
+	header := http.Header{}
+	header.Set("User-Agent", "curl/8.0")
+	require.NoError(t, ApplyCustomHeaders(header, prov, TemplateVars{TemplateVarTokenName: "alice"}))
+
+	assert.Equal(t, "https://siriusx.local", header.Get("HTTP-Referer"))
+	assert.Equal(t, "Siriusx alice", header.Get("X-Title"))
+	assert.Equal(t, "Siriusx-API/1.0", header.Get("User-Agent"), "custom header should override inbound value")
+}
+
+func TestValidateEndpointSettings(t *testing.T) {
+	valid := &models.Provider{
+		ChatPath:      "/api/paas/v4/chat/completions",
+		CustomHeaders: `{"X-Title":"{{token_name}}"}`,
+	}
+	assert.NoError(t, ValidateEndpointSettings(valid))
+
+	invalid := []*models.Provider{
+		{ChatPath: "api/paas/v4/chat/completions"},
+		{MessagesPath: "/v1/{{unknown}}"},
+		{CustomHeaders: `not json`},
+		{CustomHeaders: `{"Bad Header":"x"}`},
+		{CustomHeaders: `{"X-Title":"{{secret}}"}`},
+	}
+	for i, prov := range invalid {
+		assert.Error(t, ValidateEndpointSettings(prov), "case %d should be rejected", i)
+	}
+}
+
+func TestHealthChecker_CustomPathAndHeaders(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/paas/v4/chat/completions", r.URL.Path)
+		assert.Equal(t, "glm", r.Header.Get("X-Provider"))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	prov := &models.Provider{
+		Name:          "glm",
+		BaseURL:       server.URL,
+		APIKey:        "test-key",
+		ChatPath:      "/api/paas/v4/chat/completions",
+		CustomHeaders: `{"X-Provider":"{{provider_name}}"}`,
+	}
+
+	checker := NewHealthChecker(5 * time.Second)
+	result, err := checker.CheckProviderHealth(t.Context(), prov, "glm-4.6")
+	require.NoError(t, err)
+	assert.True(t, result.Healthy)
+}
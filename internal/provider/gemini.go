@@ -0,0 +1,70 @@
+package provider
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/Mieluoxxx/Siriusx-API/internal/models"
+)
+
+// DefaultGeminiAPIVersion Gemini API 默认版本路径
+const DefaultGeminiAPIVersion = "v1beta"
+
+// UsesGeminiAPI 目标模型是否通过 Gemini generateContent 接口调用
+// Gemini 供应商始终如此；Vertex 上非 Claude 模型（Google 发布）同样使用该接口
+func UsesGeminiAPI(prov *models.Provider, model string) bool {
+	switch ProviderType(prov) {
+	case ProviderTypeGemini:
+		return true
+	case ProviderTypeVertex:
+		return VertexPublisher(model) == VertexPublisherGoogle
+	}
+	return false
+}
+
+// geminiAPIBase 获取带版本号的 Gemini API 根地址
+// base_url 可以直接包含版本号（如 https://generativelanguage.googleapis.com/v1），否则默认使用 v1beta
+func geminiAPIBase(prov *models.Provider) string {
+	base := strings.TrimRight(prov.BaseURL, "/")
+	if strings.HasSuffix(base, "/v1") || strings.HasSuffix(base, "/v1beta") {
+		return base
+	}
+	return base + "/" + DefaultGeminiAPIVersion
+}
+
+// GeminiModelURL 构建 Gemini API 的 generateContent / streamGenerateContent URL
+func GeminiModelURL(prov *models.Provider, model string, stream bool) string {
+	method := "generateContent"
+	if stream {
+		method = "streamGenerateContent?alt=sse"
+	}
+	return geminiAPIBase(prov) + "/models/" + url.PathEscape(strings.TrimPrefix(model, "models/")) + ":" + method
+}
+
+// GeminiEmbedURL 构建 Gemini API 的 batchEmbedContents URL
+func GeminiEmbedURL(prov *models.Provider, model string) string {
+	return geminiAPIBase(prov) + "/models/" + url.PathEscape(strings.TrimPrefix(model, "models/")) + ":batchEmbedContents"
+}
+
+// GeminiModelsURL 构建 Gemini API 模型列表 URL
+func GeminiModelsURL(prov *models.Provider) string {
+	return geminiAPIBase(prov) + "/models"
+}
+
+// GenerateContentURL 按供应商类型构建 generateContent 调用 URL
+func GenerateContentURL(prov *models.Provider, model string, stream bool) string {
+	if ProviderType(prov) == ProviderTypeVertex {
+		return VertexModelURL(prov, model, stream)
+	}
+	return GeminiModelURL(prov, model, stream)
+}
+
+// BuildGeminiHealthCheckBody 构建 Gemini generateContent 的最小请求体
+func BuildGeminiHealthCheckBody() map[string]interface{} {
+	return map[string]interface{}{
+		"contents": []map[string]interface{}{
+			{"role": "user", "parts": []map[string]string{{"text": "Hi"}}},
+		},
+		"generationConfig": map[string]interface{}{"maxOutputTokens": 1},
+	}
+}
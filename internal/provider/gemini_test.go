@@ -0,0 +1,92 @@
+package provider
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Mieluoxxx/Siriusx-API/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newGeminiStandIn 模拟 Gemini API：校验 x-goog-api-key 后响应 generateContent 与模型列表
+func newGeminiStandIn(t *testing.T, invoked *map[string]interface{}) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("x-goog-api-key") != "gemini-key" || r.Header.Get("Authorization") != "" {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"error":{"code":403,"message":"API key not valid","status":"PERMISSION_DENIED"}}`))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/v1beta/models/gemini-2.0-flash:generateContent":
+			body, _ := io.ReadAll(r.Body)
+			if invoked != nil {
+				json.Unmarshal(body, invoked)
+			}
+			w.Write([]byte(`{"candidates":[{"content":{"role":"model","parts":[{"text":"Hi"}]},"finishReason":"STOP"}]}`))
+		case "/v1beta/models":
+			w.Write([]byte(`{"models":[{"name":"models/gemini-2.0-flash"},{"name":"models/text-embedding-004"}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestGeminiModelURL(t *testing.T) {
+	prov := &models.Provider{Type: ProviderTypeGemini, BaseURL: "https://generativelanguage.googleapis.com/"}
+	assert.Equal(t, "https://generativelanguage.googleapis.com/v1beta/models/gemini-2.0-flash:generateContent",
+		GeminiModelURL(prov, "gemini-2.0-flash", false))
+	assert.Equal(t, "https://generativelanguage.googleapis.com/v1beta/models/gemini-2.0-flash:streamGenerateContent?alt=sse",
+		GeminiModelURL(prov, "models/gemini-2.0-flash", true))
+	assert.Equal(t, "https://generativelanguage.googleapis.com/v1beta/models", GeminiModelsURL(prov))
+
+	// base_url 已包含版本号时不再追加
+	prov.BaseURL = "https://generativelanguage.googleapis.com/v1"
+	assert.Equal(t, "https://generativelanguage.googleapis.com/v1/models/gemini-2.0-flash:generateContent",
+		GenerateContentURL(prov, "gemini-2.0-flash", false))
+}
+
+func TestUsesGeminiAPI(t *testing.T) {
+	assert.True(t, UsesGeminiAPI(&models.Provider{Type: ProviderTypeGemini}, "gemini-2.0-flash"))
+	assert.True(t, UsesGeminiAPI(&models.Provider{Type: ProviderTypeVertex}, "gemini-2.0-flash"))
+	assert.False(t, UsesGeminiAPI(&models.Provider{Type: ProviderTypeVertex}, "claude-3-5-sonnet@20240620"))
+	assert.False(t, UsesGeminiAPI(&models.Provider{}, "gemini-2.0-flash"))
+
+	header := http.Header{"Authorization": {"Bearer client"}}
+	ApplyAuth(header, &models.Provider{Type: ProviderTypeGemini, APIKey: "gemini-key"})
+	assert.Equal(t, "gemini-key", header.Get("x-goog-api-key"))
+	assert.Empty(t, header.Get("Authorization"))
+}
+
+func TestService_GeminiProvider(t *testing.T) {
+	var invoked map[string]interface{}
+	server := newGeminiStandIn(t, &invoked)
+	defer server.Close()
+
+	service := setupTestService(t)
+	created, err := service.CreateProvider(CreateProviderRequest{
+		Name:      "gemini",
+		BaseURL:   server.URL,
+		APIKey:    "gemini-key",
+		TestModel: "gemini-2.0-flash",
+		Type:      ProviderTypeGemini,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, ProviderTypeGemini, created.Type)
+
+	available, err := service.GetAvailableModels(created.ID)
+	require.NoError(t, err)
+	require.Equal(t, 2, available.Total)
+	assert.Equal(t, "gemini-2.0-flash", available.Models[0].ID)
+
+	result, err := NewHealthChecker(5*time.Second).CheckProviderHealth(t.Context(), created, "gemini-2.0-flash")
+	require.NoError(t, err)
+	assert.True(t, result.Healthy, result.Error)
+	assert.Contains(t, invoked, "contents")
+}
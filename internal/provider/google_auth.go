@@ -0,0 +1,295 @@
+package provider
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Mieluoxxx/Siriusx-API/internal/models"
+)
+
+// Google OAuth 相关常量
+const (
+	// DefaultGoogleTokenURL 默认的 OAuth 令牌端点
+	DefaultGoogleTokenURL = "https://oauth2.googleapis.com/token"
+	// googleCloudPlatformScope Vertex AI 所需的授权范围
+	googleCloudPlatformScope = "https://www.googleapis.com/auth/cloud-platform"
+	// googleJWTLifetime 签名 JWT 的有效期
+	googleJWTLifetime = time.Hour
+	// googleTokenExpirySkew 令牌提前刷新的时间，避免临界过期
+	googleTokenExpirySkew = time.Minute
+)
+
+// ServiceAccount Google 服务账号 JSON 中用到的字段
+type ServiceAccount struct {
+	Type         string `json:"type"`
+	ProjectID    string `json:"project_id"`
+	PrivateKeyID string `json:"private_key_id"`
+	PrivateKey   string `json:"private_key"`
+	ClientEmail  string `json:"client_email"`
+	TokenURI     string `json:"token_uri"`
+}
+
+// ParseServiceAccount 解析服务账号 JSON
+func ParseServiceAccount(raw string) (*ServiceAccount, error) {
+	var sa ServiceAccount
+	if err := json.Unmarshal([]byte(raw), &sa); err != nil {
+		return nil, fmt.Errorf("invalid service account json: %w", err)
+	}
+	if sa.ClientEmail == "" {
+		return nil, fmt.Errorf("service account client_email is required")
+	}
+	if _, err := parseRSAPrivateKey(sa.PrivateKey); err != nil {
+		return nil, err
+	}
+	return &sa, nil
+}
+
+// parseRSAPrivateKey 解析 PEM 格式的 RSA 私钥（PKCS#8 或 PKCS#1）
+func parseRSAPrivateKey(pemKey string) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(pemKey))
+	if block == nil {
+		return nil, fmt.Errorf("service account private_key is not valid PEM")
+	}
+	if key, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		rsaKey, ok := key.(*rsa.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("service account private_key is not an RSA key")
+		}
+		return rsaKey, nil
+	}
+	key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse service account private_key: %w", err)
+	}
+	return key, nil
+}
+
+// signGoogleJWT 生成用于换取访问令牌的 RS256 JWT
+func signGoogleJWT(sa *ServiceAccount, tokenURL string, now time.Time) (string, error) {
+	key, err := parseRSAPrivateKey(sa.PrivateKey)
+	if err != nil {
+		return "", err
+	}
+
+	header := map[string]string{"alg": "RS256", "typ": "JWT"}
+	if sa.PrivateKeyID != "" {
+		header["kid"] = sa.PrivateKeyID
+	}
+	claims := map[string]interface{}{
+		"iss":   sa.ClientEmail,
+		"scope": googleCloudPlatformScope,
+		"aud":   tokenURL,
+		"iat":   now.Unix(),
+		"exp":   now.Add(googleJWTLifetime).Unix(),
+	}
+
+	headerJSON, err := json.Marshal(header)
+	if err != nil {
+		return "", err
+	}
+	claimsJSON, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	signingInput := base64.RawURLEncoding.EncodeToString(headerJSON) + "." + base64.RawURLEncoding.EncodeToString(claimsJSON)
+	digest := sha256.Sum256([]byte(signingInput))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign jwt: %w", err)
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// googleToken 缓存的访问令牌
+type googleToken struct {
+	accessToken string
+	expiresAt   time.Time
+}
+
+// GoogleTokenCache 按供应商缓存 OAuth 访问令牌，令牌过期前复用
+// 同一供应商的并发请求共享一次令牌换取，换取期间不持有全局锁，慢请求不会阻塞其他供应商
+type GoogleTokenCache struct {
+	mu         sync.Mutex
+	tokens     map[uint]*googleTokenEntry
+	transports *TransportRegistry
+	now        func() time.Time
+}
+
+// googleTokenEntry 单个供应商的令牌缓存
+type googleTokenEntry struct {
+	key     string            // 服务账号与令牌端点，变化时重新换取
+	token   *googleToken      // 已缓存的令牌
+	pending *googleTokenFetch // 进行中的换取请求
+}
+
+// googleTokenFetch 进行中的令牌换取，完成后关闭 done
+type googleTokenFetch struct {
+	done  chan struct{}
+	token *googleToken
+	err   error
+}
+
+var (
+	defaultGoogleTokenCache     *GoogleTokenCache
+	defaultGoogleTokenCacheOnce sync.Once
+)
+
+// NewGoogleTokenCache 创建令牌缓存，换取令牌的请求复用供应商连接池
+func NewGoogleTokenCache(transports *TransportRegistry) *GoogleTokenCache {
+	return &GoogleTokenCache{
+		tokens:     make(map[uint]*googleTokenEntry),
+		transports: transports,
+		now:        time.Now,
+	}
+}
+
+// DefaultGoogleTokenCache 返回全局共享的令牌缓存
+func DefaultGoogleTokenCache() *GoogleTokenCache {
+	defaultGoogleTokenCacheOnce.Do(func() {
+		defaultGoogleTokenCache = NewGoogleTokenCache(DefaultTransportRegistry())
+	})
+	return defaultGoogleTokenCache
+}
+
+// GoogleTokenURL 获取供应商的令牌端点：供应商配置 > 服务账号 token_uri > 默认值
+func GoogleTokenURL(prov *models.Provider, sa *ServiceAccount) string {
+	if tokenURL := strings.TrimSpace(prov.TokenURL); tokenURL != "" {
+		return tokenURL
+	}
+	if sa.TokenURI != "" {
+		return sa.TokenURI
+	}
+	return DefaultGoogleTokenURL
+}
+
+// AccessToken 获取供应商的访问令牌（优先使用缓存）
+func (tc *GoogleTokenCache) AccessToken(ctx context.Context, prov *models.Provider) (string, error) {
+	sa, err := ParseServiceAccount(prov.APIKey)
+	if err != nil {
+		return "", err
+	}
+	tokenURL := GoogleTokenURL(prov, sa)
+	cacheKey := sa.ClientEmail + "|" + sa.PrivateKeyID + "|" + tokenURL
+
+	tc.mu.Lock()
+	entry, ok := tc.tokens[prov.ID]
+	if !ok || entry.key != cacheKey {
+		entry = &googleTokenEntry{key: cacheKey}
+		tc.tokens[prov.ID] = entry
+	}
+	if entry.token != nil && tc.now().Before(entry.token.expiresAt) {
+		tc.mu.Unlock()
+		return entry.token.accessToken, nil
+	}
+	fetch := entry.pending
+	if fetch == nil {
+		// 由当前请求发起换取，其他请求等待结果
+		fetch = &googleTokenFetch{done: make(chan struct{})}
+		entry.pending = fetch
+		tc.mu.Unlock()
+
+		fetch.token, fetch.err = tc.fetchToken(ctx, prov, sa, tokenURL)
+
+		tc.mu.Lock()
+		// 换取期间缓存可能已被清除，此时不再写回
+		if tc.tokens[prov.ID] == entry {
+			entry.pending = nil
+			if fetch.err == nil {
+				entry.token = fetch.token
+			}
+		}
+		tc.mu.Unlock()
+		close(fetch.done)
+	} else {
+		tc.mu.Unlock()
+	}
+
+	select {
+	case <-fetch.done:
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+	if fetch.err != nil {
+		return "", fetch.err
+	}
+	return fetch.token.accessToken, nil
+}
+
+// Invalidate 清除供应商缓存的令牌（例如上游返回 401 或供应商被删除时）
+func (tc *GoogleTokenCache) Invalidate(providerID uint) {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	delete(tc.tokens, providerID)
+}
+
+// fetchToken 使用签名 JWT 换取访问令牌
+func (tc *GoogleTokenCache) fetchToken(ctx context.Context, prov *models.Provider, sa *ServiceAccount, tokenURL string) (*googleToken, error) {
+	now := tc.now()
+	assertion, err := signGoogleJWT(sa, tokenURL, now)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {assertion},
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	client, err := tc.transports.Client(prov)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read token response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("token endpoint returned HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var result struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("invalid token response: %w", err)
+	}
+	if result.AccessToken == "" {
+		return nil, errors.New("token endpoint returned empty access_token")
+	}
+	if result.ExpiresIn <= 0 {
+		result.ExpiresIn = int64(googleJWTLifetime / time.Second)
+	}
+
+	return &googleToken{
+		accessToken: result.AccessToken,
+		expiresAt:   now.Add(time.Duration(result.ExpiresIn)*time.Second - googleTokenExpirySkew),
+	}, nil
+}
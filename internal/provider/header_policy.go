@@ -0,0 +1,185 @@
+package provider
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/Mieluoxxx/Siriusx-API/internal/models"
+)
+
+// DefaultForwardHeaders 默认允许转发到上游的客户端请求头（默认拒绝，其余一律丢弃）
+var DefaultForwardHeaders = []string{
+	"Accept",
+	"Accept-Language",
+	"User-Agent",
+	"X-Request-Id",
+}
+
+// hopByHopHeaders 逐跳请求头（RFC 9110 7.6.1），任何情况下都不转发
+var hopByHopHeaders = map[string]bool{
+	"Connection":          true,
+	"Keep-Alive":          true,
+	"Proxy-Connection":    true,
+	"Proxy-Authenticate":  true,
+	"Proxy-Authorization": true,
+	"Te":                  true,
+	"Trailer":             true,
+	"Transfer-Encoding":   true,
+	"Upgrade":             true,
+}
+
+// protectedHeaders 由网关自行设置或涉及客户端凭证的请求头，不允许通过配置放行
+var protectedHeaders = map[string]bool{
+	"Host":           true,
+	"Authorization":  true,
+	"X-Api-Key":      true,
+	"X-Goog-Api-Key": true,
+	"Cookie":         true,
+	"Content-Length": true,
+}
+
+// HeaderPolicy 请求头转发策略
+type HeaderPolicy struct {
+	allowed  map[string]bool // 精确匹配（规范化后的名称）
+	prefixes []string        // 前缀匹配（配置中以 * 结尾的项）
+}
+
+// NewHeaderPolicy 基于默认白名单和额外放行项构建转发策略
+func NewHeaderPolicy(extra []string) *HeaderPolicy {
+	policy := &HeaderPolicy{allowed: make(map[string]bool)}
+	for _, name := range append(append([]string{}, DefaultForwardHeaders...), extra...) {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if strings.HasSuffix(name, "*") {
+			policy.prefixes = append(policy.prefixes, http.CanonicalHeaderKey(strings.TrimSuffix(name, "*")))
+			continue
+		}
+		policy.allowed[http.CanonicalHeaderKey(name)] = true
+	}
+	return policy
+}
+
+// HeaderPolicyForProvider 获取供应商的请求头转发策略
+func HeaderPolicyForProvider(prov *models.Provider) (*HeaderPolicy, error) {
+	extra, err := ParseForwardHeaders(prov.ForwardHeaders)
+	if err != nil {
+		return nil, err
+	}
+	return NewHeaderPolicy(extra), nil
+}
+
+// Allows 判断请求头是否允许转发
+func (p *HeaderPolicy) Allows(name string) bool {
+	key := http.CanonicalHeaderKey(name)
+	if hopByHopHeaders[key] || protectedHeaders[key] {
+		return false
+	}
+	if p.allowed[key] {
+		return true
+	}
+	for _, prefix := range p.prefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Copy 按策略将 src 中的请求头复制到 dst
+// dst 中已由网关设置的请求头不会被覆盖；Connection 中列出的请求头同样视为逐跳请求头
+// 返回被丢弃的请求头名称（已排序）
+func (p *HeaderPolicy) Copy(dst, src http.Header) []string {
+	connectionTokens := map[string]bool{}
+	for _, value := range src.Values("Connection") {
+		for _, token := range strings.Split(value, ",") {
+			if token = strings.TrimSpace(token); token != "" {
+				connectionTokens[http.CanonicalHeaderKey(token)] = true
+			}
+		}
+	}
+
+	var dropped []string
+	for key, values := range src {
+		canonical := http.CanonicalHeaderKey(key)
+		if connectionTokens[canonical] || !p.Allows(canonical) {
+			dropped = append(dropped, canonical)
+			continue
+		}
+		if _, exists := dst[canonical]; exists {
+			continue
+		}
+		for _, value := range values {
+			dst.Add(canonical, value)
+		}
+	}
+
+	sort.Strings(dropped)
+	return dropped
+}
+
+// String 返回策略描述（用于调试日志）
+func (p *HeaderPolicy) String() string {
+	names := make([]string, 0, len(p.allowed)+len(p.prefixes))
+	for name := range p.allowed {
+		names = append(names, name)
+	}
+	for _, prefix := range p.prefixes {
+		names = append(names, prefix+"*")
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// ParseForwardHeaders 解析供应商存储的额外放行请求头（JSON 数组）
+func ParseForwardHeaders(raw string) ([]string, error) {
+	var headers []string
+	if strings.TrimSpace(raw) == "" {
+		return headers, nil
+	}
+	if err := json.Unmarshal([]byte(raw), &headers); err != nil {
+		return nil, fmt.Errorf("invalid forward_headers: %w", err)
+	}
+	return headers, nil
+}
+
+// EncodeForwardHeaders 将额外放行请求头序列化为存储格式
+func EncodeForwardHeaders(headers []string) (string, error) {
+	cleaned := make([]string, 0, len(headers))
+	for _, name := range headers {
+		if name = strings.TrimSpace(name); name != "" {
+			cleaned = append(cleaned, name)
+		}
+	}
+	if len(cleaned) == 0 {
+		return "", nil
+	}
+	data, err := json.Marshal(cleaned)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// ValidateForwardHeaders 校验额外放行请求头：名称需合法，且不能放行逐跳或受保护的请求头
+func ValidateForwardHeaders(prov *models.Provider) error {
+	headers, err := ParseForwardHeaders(prov.ForwardHeaders)
+	if err != nil {
+		return err
+	}
+	for _, name := range headers {
+		base := strings.TrimSuffix(name, "*")
+		if !isValidHeaderName(base) {
+			return fmt.Errorf("invalid forward header name %q", name)
+		}
+		key := http.CanonicalHeaderKey(base)
+		if hopByHopHeaders[key] || protectedHeaders[key] {
+			return fmt.Errorf("header %q cannot be forwarded", name)
+		}
+	}
+	return nil
+}
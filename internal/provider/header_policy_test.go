@@ -0,0 +1,25 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/Mieluoxxx/Siriusx-API/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateForwardHeaders(t *testing.T) {
+	assert.NoError(t, ValidateForwardHeaders(&models.Provider{}))
+	assert.NoError(t, ValidateForwardHeaders(&models.Provider{ForwardHeaders: `["X-Forwarded-For","X-Stainless-*"]`}))
+
+	invalid := []string{
+		`not json`,
+		`["Authorization"]`,
+		`["x-api-key"]`,
+		`["Connection"]`,
+		`["Bad Header"]`,
+		`["*"]`,
+	}
+	for _, raw := range invalid {
+		assert.Error(t, ValidateForwardHeaders(&models.Provider{ForwardHeaders: raw}), raw)
+	}
+}
@@ -6,14 +6,15 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
-	"strings"
 	"time"
+
+	"github.com/Mieluoxxx/Siriusx-API/internal/models"
 )
 
 // HealthChecker 供应商健康检查器
 type HealthChecker struct {
-	client  *http.Client
-	timeout time.Duration
+	transports *TransportRegistry
+	timeout    time.Duration
 }
 
 // NewHealthChecker 创建健康检查器
@@ -23,11 +24,18 @@ func NewHealthChecker(timeout time.Duration) *HealthChecker {
 	}
 
 	return &HealthChecker{
-		client: &http.Client{
-			Timeout: timeout,
-		},
-		timeout: timeout,
+		transports: DefaultTransportRegistry(),
+		timeout:    timeout,
+	}
+}
+
+// NewHealthCheckerWithTransports 创建使用指定连接池注册表的健康检查器
+func NewHealthCheckerWithTransports(timeout time.Duration, transports *TransportRegistry) *HealthChecker {
+	hc := NewHealthChecker(timeout)
+	if transports != nil {
+		hc.transports = transports
 	}
+	return hc
 }
 
 // HealthCheckResult 健康检查结果
@@ -43,14 +51,33 @@ type HealthCheckResult struct {
 // 通过发送一个简单的聊天请求来测试指定模型是否可用
 // 使用 OpenAI 兼容的 API 格式
 func (hc *HealthChecker) CheckHealth(ctx context.Context, baseURL, apiKey, testModel string) (*HealthCheckResult, error) {
+	return hc.CheckProviderHealth(ctx, &models.Provider{BaseURL: baseURL, APIKey: apiKey}, testModel)
+}
+
+// CheckProviderHealth 使用供应商自身的连接设置执行健康检查
+func (hc *HealthChecker) CheckProviderHealth(ctx context.Context, prov *models.Provider, testModel string) (*HealthCheckResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, hc.timeout)
+	defer cancel()
+
 	startTime := time.Now()
 	result := &HealthCheckResult{
 		CheckedAt: startTime,
 	}
 
-	// 构建 OpenAI 兼容的聊天完成请求
-	// 标准化 baseURL，移除末尾斜杠以避免双斜杠问题
-	checkURL := strings.TrimRight(baseURL, "/") + "/v1/chat/completions"
+	// 构建 OpenAI 兼容的聊天完成请求（支持供应商自定义路径）
+	vars := TemplateVars{
+		TemplateVarProviderName: prov.Name,
+		TemplateVarModel:        testModel,
+	}
+	checkURL := EndpointURL(prov, EndpointChat, vars)
+	switch ProviderType(prov) {
+	case ProviderTypeBedrock:
+		checkURL = BedrockInvokeURL(prov, testModel, false)
+	case ProviderTypeVertex:
+		checkURL = VertexModelURL(prov, testModel, false)
+	case ProviderTypeGemini:
+		checkURL = GeminiModelURL(prov, testModel, false)
+	}
 
 	// 构建请求体
 	requestBody := map[string]interface{}{
@@ -63,6 +90,16 @@ func (hc *HealthChecker) CheckHealth(ctx context.Context, baseURL, apiKey, testM
 		},
 		"max_tokens": 1,
 	}
+	switch {
+	case ProviderType(prov) == ProviderTypeBedrock:
+		requestBody = BuildBedrockBody(requestBody, "")
+	case ProviderType(prov) == ProviderTypeVertex && VertexPublisher(testModel) == VertexPublisherAnthropic:
+		requestBody = BuildVertexClaudeBody(requestBody, "")
+	case UsesGeminiAPI(prov, testModel):
+		requestBody = BuildGeminiHealthCheckBody()
+	case UsesOllamaAPI(prov):
+		requestBody = BuildOllamaHealthCheckBody(testModel)
+	}
 
 	jsonData, err := json.Marshal(requestBody)
 	if err != nil {
@@ -76,22 +113,36 @@ func (hc *HealthChecker) CheckHealth(ctx context.Context, baseURL, apiKey, testM
 		return result, nil
 	}
 
-	// 设置 OpenAI 兼容的认证头
-	req.Header.Set("Authorization", "Bearer "+apiKey)
+	// 按供应商类型设置认证头
+	ApplyAuth(req.Header, prov)
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("User-Agent", "Siriusx-API/1.0")
+	if err := ApplyCustomHeaders(req.Header, prov, vars); err != nil {
+		result.Error = fmt.Sprintf("自定义请求头无效: %v", err)
+		return result, nil
+	}
+	if err := AuthorizeRequest(ctx, req, prov, jsonData); err != nil {
+		result.Error = fmt.Sprintf("认证失败: %v", err)
+		return result, nil
+	}
 
 	// 执行请求
-	resp, err := hc.client.Do(req)
+	client, err := hc.transports.Client(prov)
+	if err != nil {
+		result.Error = fmt.Sprintf("连接配置无效: %v", err)
+		return result, nil
+	}
+
+	resp, err := client.Do(req)
 	if err != nil {
 		result.Error = fmt.Sprintf("请求失败: %v", err)
-		result.ResponseTimeMs = time.Since(startTime).Milliseconds()
+		result.ResponseTimeMs = elapsedMs(startTime)
 		return result, nil
 	}
 	defer resp.Body.Close()
 
 	// 计算响应时间
-	result.ResponseTimeMs = time.Since(startTime).Milliseconds()
+	result.ResponseTimeMs = elapsedMs(startTime)
 	result.StatusCode = resp.StatusCode
 
 	// 判断健康状态
@@ -112,3 +163,9 @@ func (hc *HealthChecker) CheckHealthSimple(baseURL, apiKey, testModel string) (*
 
 	return hc.CheckHealth(ctx, baseURL, apiKey, testModel)
 }
+
+// elapsedMs 计算耗时毫秒数，不足 1ms 按 1ms 计
+// 复用连接后本地请求可能在 1ms 内完成，避免返回 0 被误认为未测量
+func elapsedMs(start time.Time) int64 {
+	return int64((time.Since(start) + time.Millisecond - 1) / time.Millisecond)
+}
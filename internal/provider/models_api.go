@@ -5,7 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
-	"strings"
+	"path"
 	"time"
 )
 
@@ -32,9 +32,17 @@ func (s *Service) GetAvailableModels(id uint) (*AvailableModelsResponse, error)
 		return nil, err
 	}
 
-	// 构建请求 URL
-	// 标准化 baseURL,移除末尾斜杠以避免双斜杠问题
-	modelsURL := strings.TrimRight(provider.BaseURL, "/") + "/v1/models"
+	// 构建请求 URL（支持供应商自定义路径）
+	vars := TemplateVars{TemplateVarProviderName: provider.Name}
+	modelsURL := EndpointURL(provider, EndpointModels, vars)
+	switch ProviderType(provider) {
+	case ProviderTypeBedrock:
+		modelsURL = BedrockModelsURL(provider)
+	case ProviderTypeVertex:
+		modelsURL = VertexModelsURL(provider)
+	case ProviderTypeGemini:
+		modelsURL = GeminiModelsURL(provider)
+	}
 
 	// 创建HTTP客户端
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -46,11 +54,20 @@ func (s *Service) GetAvailableModels(id uint) (*AvailableModelsResponse, error)
 	}
 
 	// 设置认证头
-	req.Header.Set("Authorization", "Bearer "+provider.APIKey)
+	ApplyAuth(req.Header, provider)
 	req.Header.Set("User-Agent", "Siriusx-API/1.0")
+	if err := ApplyCustomHeaders(req.Header, provider, vars); err != nil {
+		return nil, err
+	}
+	if err := AuthorizeRequest(ctx, req, provider, nil); err != nil {
+		return nil, fmt.Errorf("认证失败: %w", err)
+	}
 
-	// 发送请求
-	client := &http.Client{Timeout: 10 * time.Second}
+	// 发送请求（复用供应商连接池）
+	client, err := s.Transports().Client(provider)
+	if err != nil {
+		return nil, fmt.Errorf("连接配置无效: %w", err)
+	}
 	resp, err := client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("请求失败: %w", err)
@@ -63,13 +80,38 @@ func (s *Service) GetAvailableModels(id uint) (*AvailableModelsResponse, error)
 	}
 
 	// 解析响应
+	// Bedrock 返回 modelSummaries，Vertex 返回 publisherModels，Gemini 与 Ollama 返回 models，其余供应商为 OpenAI 格式的 data
 	var result struct {
-		Data []ModelInfo `json:"data"`
+		Data           []ModelInfo `json:"data"`
+		ModelSummaries []struct {
+			ModelID string `json:"modelId"`
+		} `json:"modelSummaries"`
+		PublisherModels []struct {
+			Name string `json:"name"`
+		} `json:"publisherModels"`
+		Models []struct {
+			Name string `json:"name"`
+		} `json:"models"`
 	}
 
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
 		return nil, fmt.Errorf("解析响应失败: %w", err)
 	}
+	for _, summary := range result.ModelSummaries {
+		result.Data = append(result.Data, ModelInfo{ID: summary.ModelID, Object: "model"})
+	}
+	for _, model := range result.PublisherModels {
+		// name 形如 publishers/google/models/gemini-2.0-flash
+		result.Data = append(result.Data, ModelInfo{ID: path.Base(model.Name), Object: "model"})
+	}
+	for _, model := range result.Models {
+		// Gemini 的 name 形如 models/gemini-2.0-flash；Ollama 的 name 即模型名（如 hf.co/org/repo:Q4_K_M），原样保留
+		id := model.Name
+		if ProviderType(provider) != ProviderTypeOllama {
+			id = path.Base(model.Name)
+		}
+		result.Data = append(result.Data, ModelInfo{ID: id, Object: "model"})
+	}
 
 	// 构建响应
 	return &AvailableModelsResponse{
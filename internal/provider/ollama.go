@@ -0,0 +1,28 @@
+package provider
+
+import "github.com/Mieluoxxx/Siriusx-API/internal/models"
+
+// ollamaEndpointPaths Ollama 原生接口的默认路径
+// llama.cpp server 等提供 OpenAI 兼容接口的本地运行时应使用 openai 类型
+var ollamaEndpointPaths = map[EndpointKind]string{
+	EndpointChat:       "/api/chat",
+	EndpointModels:     "/api/tags",
+	EndpointEmbeddings: "/api/embed",
+}
+
+// UsesOllamaAPI 供应商是否通过 Ollama 原生 /api/chat 接口调用（请求与响应由网关转换）
+func UsesOllamaAPI(prov *models.Provider) bool {
+	return ProviderType(prov) == ProviderTypeOllama
+}
+
+// BuildOllamaHealthCheckBody 构建 Ollama /api/chat 的最小非流式请求体
+func BuildOllamaHealthCheckBody(model string) map[string]interface{} {
+	return map[string]interface{}{
+		"model": model,
+		"messages": []map[string]string{
+			{"role": "user", "content": "Hi"},
+		},
+		"stream":  false,
+		"options": map[string]interface{}{"num_predict": 1},
+	}
+}
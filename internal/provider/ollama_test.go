@@ -0,0 +1,77 @@
+package provider
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Mieluoxxx/Siriusx-API/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newOllamaStandIn 模拟 Ollama：响应 /api/chat 与 /api/tags
+func newOllamaStandIn(t *testing.T, invoked *map[string]interface{}) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/chat":
+			body, _ := io.ReadAll(r.Body)
+			if invoked != nil {
+				json.Unmarshal(body, invoked)
+			}
+			w.Write([]byte(`{"model":"llama3.2","message":{"role":"assistant","content":"Hi"},"done":true,"done_reason":"length"}`))
+		case "/api/tags":
+			w.Write([]byte(`{"models":[{"name":"llama3.2:latest","model":"llama3.2:latest"},{"name":"hf.co/bartowski/Qwen2.5-7B-GGUF:Q4_K_M"}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestEndpointURL_Ollama(t *testing.T) {
+	prov := &models.Provider{Type: ProviderTypeOllama, BaseURL: "http://localhost:11434/"}
+	assert.Equal(t, "http://localhost:11434/api/chat", EndpointURL(prov, EndpointChat, nil))
+	assert.Equal(t, "http://localhost:11434/api/tags", EndpointURL(prov, EndpointModels, nil))
+
+	// 自定义路径优先于类型默认路径
+	prov.ChatPath = "/ollama/api/chat"
+	assert.Equal(t, "http://localhost:11434/ollama/api/chat", EndpointURL(prov, EndpointChat, nil))
+
+	assert.True(t, UsesOllamaAPI(prov))
+	assert.False(t, SupportsOpenAIChat(prov))
+	assert.False(t, UsesOllamaAPI(&models.Provider{}))
+}
+
+func TestService_OllamaProvider(t *testing.T) {
+	var invoked map[string]interface{}
+	server := newOllamaStandIn(t, &invoked)
+	defer server.Close()
+
+	service := setupTestService(t)
+	created, err := service.CreateProvider(CreateProviderRequest{
+		Name:      "ollama",
+		BaseURL:   server.URL,
+		APIKey:    "ollama",
+		TestModel: "llama3.2",
+		Type:      ProviderTypeOllama,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, ProviderTypeOllama, created.Type)
+
+	available, err := service.GetAvailableModels(created.ID)
+	require.NoError(t, err)
+	require.Equal(t, 2, available.Total)
+	assert.Equal(t, "llama3.2:latest", available.Models[0].ID)
+	assert.Equal(t, "hf.co/bartowski/Qwen2.5-7B-GGUF:Q4_K_M", available.Models[1].ID)
+
+	result, err := NewHealthChecker(5*time.Second).CheckProviderHealth(t.Context(), created, "llama3.2")
+	require.NoError(t, err)
+	assert.True(t, result.Healthy, result.Error)
+	assert.Equal(t, false, invoked["stream"])
+	assert.Equal(t, map[string]interface{}{"num_predict": float64(1)}, invoked["options"])
+}
@@ -0,0 +1,201 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/Mieluoxxx/Siriusx-API/internal/models"
+)
+
+// 供应商类型
+const (
+	ProviderTypeOpenAI  = "openai"  // OpenAI 兼容接口（默认）
+	ProviderTypeAzure   = "azure"   // Azure OpenAI（按部署名路由，api-key 认证）
+	ProviderTypeBedrock = "bedrock" // AWS Bedrock（Claude，SigV4 签名）
+	ProviderTypeVertex  = "vertex"  // Google Vertex AI（Claude / Gemini，服务账号 OAuth）
+	ProviderTypeGemini  = "gemini"  // Google Gemini API（generateContent，x-goog-api-key 认证）
+	ProviderTypeOllama  = "ollama"  // Ollama 原生接口（/api/chat，NDJSON 流式）
+)
+
+// DefaultAzureAPIVersion Azure OpenAI 默认 api-version
+const DefaultAzureAPIVersion = "2024-10-21"
+
+// supportedProviderTypes 支持的供应商类型
+var supportedProviderTypes = map[string]bool{
+	ProviderTypeOpenAI:  true,
+	ProviderTypeAzure:   true,
+	ProviderTypeBedrock: true,
+	ProviderTypeVertex:  true,
+	ProviderTypeGemini:  true,
+	ProviderTypeOllama:  true,
+}
+
+// azureEndpointPaths Azure OpenAI 各端点的默认路径（{{model}} 为部署名）
+var azureEndpointPaths = map[EndpointKind]string{
+	EndpointChat:        "/openai/deployments/{{model}}/chat/completions",
+	EndpointModels:      "/openai/models",
+	EndpointResponses:   "/openai/responses",
+	EndpointEmbeddings:  "/openai/deployments/{{model}}/embeddings",
+	EndpointCompletions: "/openai/deployments/{{model}}/completions",
+	EndpointImages:      "/openai/deployments/{{model}}/images/generations",
+}
+
+// typeEndpointPaths 各供应商类型覆盖的默认端点路径
+var typeEndpointPaths = map[string]map[EndpointKind]string{
+	ProviderTypeAzure:  azureEndpointPaths,
+	ProviderTypeOllama: ollamaEndpointPaths,
+}
+
+// NormalizeProviderType 规范化供应商类型，空值视为 openai
+func NormalizeProviderType(providerType string) string {
+	providerType = strings.ToLower(strings.TrimSpace(providerType))
+	if providerType == "" {
+		return ProviderTypeOpenAI
+	}
+	return providerType
+}
+
+// ProviderType 获取供应商类型（兼容历史数据中的空值）
+func ProviderType(prov *models.Provider) string {
+	return NormalizeProviderType(prov.Type)
+}
+
+// ValidateProviderType 校验供应商类型
+func ValidateProviderType(prov *models.Provider) error {
+	if !supportedProviderTypes[ProviderType(prov)] {
+		return fmt.Errorf("unsupported provider type %q", prov.Type)
+	}
+	switch ProviderType(prov) {
+	case ProviderTypeBedrock:
+		return validateBedrockSettings(prov)
+	case ProviderTypeVertex:
+		return validateVertexSettings(prov)
+	}
+	return nil
+}
+
+// IsOpenAIFormat 供应商是否只接受 OpenAI Chat Completions 格式
+// Azure 部署只提供 OpenAI 接口，Claude 请求必须转换
+func IsOpenAIFormat(prov *models.Provider) bool {
+	return ProviderType(prov) == ProviderTypeAzure
+}
+
+// APIVersion 获取供应商的 api-version（仅 Azure 使用）
+func APIVersion(prov *models.Provider) string {
+	if version := strings.TrimSpace(prov.APIVersion); version != "" {
+		return version
+	}
+	if ProviderType(prov) == ProviderTypeAzure {
+		return DefaultAzureAPIVersion
+	}
+	return ""
+}
+
+// SupportsOpenAIChat 供应商是否可以直接处理 OpenAI Chat Completions 请求
+// Bedrock、Vertex、Gemini 与 Ollama 只接收各自的原生格式（Gemini / Ollama 由网关转换，见 UsesGeminiAPI / UsesOllamaAPI）
+func SupportsOpenAIChat(prov *models.Provider) bool {
+	switch ProviderType(prov) {
+	case ProviderTypeBedrock, ProviderTypeVertex, ProviderTypeGemini, ProviderTypeOllama:
+		return false
+	}
+	return true
+}
+
+// SupportsResponsesAPI 供应商是否可以直接处理 OpenAI Responses 请求（需显式开启 responses_api）
+func SupportsResponsesAPI(prov *models.Provider) bool {
+	switch ProviderType(prov) {
+	case ProviderTypeOpenAI, ProviderTypeAzure:
+		return prov.ResponsesAPI
+	}
+	return false
+}
+
+// SupportsEmbeddings 供应商是否可以处理 Embeddings 请求
+// OpenAI 兼容与 Azure 直接转发，Gemini 与 Ollama 由网关转换为各自的原生嵌入接口
+func SupportsEmbeddings(prov *models.Provider) bool {
+	switch ProviderType(prov) {
+	case ProviderTypeBedrock, ProviderTypeVertex:
+		return false
+	}
+	return true
+}
+
+// SupportsCompletionsAPI 供应商是否可以直接处理旧版 Completions 请求（需显式开启 completions_api）
+// 未开启时网关将请求转换为 Chat Completions
+func SupportsCompletionsAPI(prov *models.Provider) bool {
+	switch ProviderType(prov) {
+	case ProviderTypeOpenAI, ProviderTypeAzure:
+		return prov.CompletionsAPI
+	}
+	return false
+}
+
+// SupportsPromptCacheHints 供应商是否接受 OpenAI 格式的 cache_control 缓存提示（需显式开启 prompt_cache_hints）
+// 仅对 Claude→OpenAI 转换生效；原生 Anthropic 上游直接透传 cache_control
+func SupportsPromptCacheHints(prov *models.Provider) bool {
+	switch ProviderType(prov) {
+	case ProviderTypeOpenAI, ProviderTypeAzure:
+		return prov.PromptCacheHints
+	}
+	return false
+}
+
+// SupportsStreamUsage 供应商是否接受 stream_options.include_usage（需显式开启 stream_usage）
+// 严格校验参数的中转与旧版 Azure api-version 会拒绝未知参数，因此默认不发送
+func SupportsStreamUsage(prov *models.Provider) bool {
+	switch ProviderType(prov) {
+	case ProviderTypeOpenAI, ProviderTypeAzure:
+		return prov.StreamUsage
+	}
+	return false
+}
+
+// NeedsPDFTextExtraction 供应商是否需要在 Claude→OpenAI 转换时提取 PDF 文本（需显式开启 pdf_text_extraction）
+// 未开启时 PDF 以 OpenAI file 内容块发送；Ollama 转换始终提取文本，Gemini 与 Anthropic 原生支持 PDF
+func NeedsPDFTextExtraction(prov *models.Provider) bool {
+	switch ProviderType(prov) {
+	case ProviderTypeOpenAI, ProviderTypeAzure:
+		return prov.PDFTextExtraction
+	}
+	return false
+}
+
+// SupportsImages 供应商是否可以处理 Images 生成请求（仅 OpenAI 兼容与 Azure，请求与响应原样转发）
+func SupportsImages(prov *models.Provider) bool {
+	switch ProviderType(prov) {
+	case ProviderTypeOpenAI, ProviderTypeAzure:
+		return true
+	}
+	return false
+}
+
+// ApplyAuth 按供应商类型设置上游认证头
+// Bedrock 与 Vertex 的凭证依赖完整请求或需要换取令牌，由 AuthorizeRequest 处理
+func ApplyAuth(header http.Header, prov *models.Provider) {
+	switch ProviderType(prov) {
+	case ProviderTypeBedrock, ProviderTypeVertex:
+		header.Del("Authorization")
+	case ProviderTypeAzure:
+		header.Del("Authorization")
+		header.Set("api-key", prov.APIKey)
+	case ProviderTypeGemini:
+		header.Del("Authorization")
+		header.Set("x-goog-api-key", prov.APIKey)
+	default:
+		header.Set("Authorization", "Bearer "+prov.APIKey)
+	}
+}
+
+// AuthorizeRequest 完成需要整个请求参与的认证（Bedrock 签名、Vertex 访问令牌）
+// 必须在所有请求头设置完成后调用；其余类型已由 ApplyAuth 处理，直接返回
+func AuthorizeRequest(ctx context.Context, req *http.Request, prov *models.Provider, body []byte) error {
+	switch ProviderType(prov) {
+	case ProviderTypeBedrock:
+		return SignBedrockRequest(req, prov, body)
+	case ProviderTypeVertex:
+		return AuthorizeVertexRequest(ctx, req, prov)
+	}
+	return nil
+}
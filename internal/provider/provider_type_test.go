@@ -0,0 +1,140 @@
+package provider
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Mieluoxxx/Siriusx-API/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newAzureStandIn 模拟 Azure OpenAI：校验 api-key 与 api-version，按部署名路由
+func newAzureStandIn(t *testing.T, apiVersion string) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("api-key") != "azure-key" || r.Header.Get("Authorization") != "" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Query().Get("api-version") != apiVersion {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/openai/models":
+			w.Write([]byte(`{"data":[{"id":"gpt-4o","object":"model"},{"id":"gpt-4o-mini","object":"model"}]}`))
+		case "/openai/deployments/prod-gpt4o/chat/completions":
+			w.Write([]byte(`{"id":"chatcmpl-1","object":"chat.completion","choices":[]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestEndpointURL_Azure(t *testing.T) {
+	prov := &models.Provider{Type: ProviderTypeAzure, BaseURL: "https://contoso.openai.azure.com/"}
+	vars := TemplateVars{TemplateVarModel: "prod-gpt4o"}
+
+	assert.Equal(t, "https://contoso.openai.azure.com/openai/deployments/prod-gpt4o/chat/completions?api-version="+DefaultAzureAPIVersion,
+		EndpointURL(prov, EndpointChat, vars))
+	assert.Equal(t, "https://contoso.openai.azure.com/openai/models?api-version="+DefaultAzureAPIVersion,
+		EndpointURL(prov, EndpointModels, vars))
+
+	prov.APIVersion = "2025-01-01-preview"
+	assert.Equal(t, "https://contoso.openai.azure.com/openai/models?api-version=2025-01-01-preview",
+		EndpointURL(prov, EndpointModels, vars))
+
+	// OpenAI 类型不追加 api-version
+	assert.Equal(t, "https://api.openai.com/v1/models", EndpointURL(&models.Provider{BaseURL: "https://api.openai.com"}, EndpointModels, nil))
+}
+
+func TestSupportsResponsesAPI(t *testing.T) {
+	assert.False(t, SupportsResponsesAPI(&models.Provider{}), "responses_api must be enabled explicitly")
+	assert.True(t, SupportsResponsesAPI(&models.Provider{ResponsesAPI: true}))
+	assert.True(t, SupportsResponsesAPI(&models.Provider{Type: ProviderTypeAzure, ResponsesAPI: true}))
+	assert.False(t, SupportsResponsesAPI(&models.Provider{Type: ProviderTypeGemini, ResponsesAPI: true}))
+
+	azure := &models.Provider{Type: ProviderTypeAzure, BaseURL: "https://contoso.openai.azure.com", APIVersion: "2025-03-01-preview"}
+	assert.Equal(t, "https://contoso.openai.azure.com/openai/responses?api-version=2025-03-01-preview", EndpointURL(azure, EndpointResponses, nil))
+}
+
+func TestSupportsEmbeddings(t *testing.T) {
+	assert.True(t, SupportsEmbeddings(&models.Provider{}))
+	assert.True(t, SupportsEmbeddings(&models.Provider{Type: ProviderTypeOllama}))
+	assert.False(t, SupportsEmbeddings(&models.Provider{Type: ProviderTypeBedrock}))
+
+	azure := &models.Provider{Type: ProviderTypeAzure, BaseURL: "https://contoso.openai.azure.com"}
+	assert.Equal(t, "https://contoso.openai.azure.com/openai/deployments/embed-small/embeddings?api-version="+DefaultAzureAPIVersion,
+		EndpointURL(azure, EndpointEmbeddings, TemplateVars{TemplateVarModel: "embed-small"}))
+}
+
+func TestSupportsCompletionsAPIAndImages(t *testing.T) {
+	assert.False(t, SupportsCompletionsAPI(&models.Provider{}), "completions_api must be enabled explicitly")
+	assert.True(t, SupportsCompletionsAPI(&models.Provider{CompletionsAPI: true}))
+	assert.False(t, SupportsCompletionsAPI(&models.Provider{Type: ProviderTypeOllama, CompletionsAPI: true}))
+
+	assert.True(t, SupportsImages(&models.Provider{Type: ProviderTypeAzure}))
+	assert.False(t, SupportsImages(&models.Provider{Type: ProviderTypeGemini}))
+
+	azure := &models.Provider{Type: ProviderTypeAzure, BaseURL: "https://contoso.openai.azure.com"}
+	assert.Equal(t, "https://contoso.openai.azure.com/openai/deployments/dalle3/images/generations?api-version="+DefaultAzureAPIVersion,
+		EndpointURL(azure, EndpointImages, TemplateVars{TemplateVarModel: "dalle3"}))
+}
+
+func TestApplyAuth(t *testing.T) {
+	header := http.Header{}
+	ApplyAuth(header, &models.Provider{APIKey: "sk-test"})
+	assert.Equal(t, "Bearer sk-test", header.Get("Authorization"))
+
+	header = http.Header{}
+	header.Set("Authorization", "Bearer leaked")
+	ApplyAuth(header, &models.Provider{Type: ProviderTypeAzure, APIKey: "azure-key"})
+	assert.Equal(t, "azure-key", header.Get("api-key"))
+	assert.Empty(t, header.Get("Authorization"))
+}
+
+func TestHealthChecker_Azure(t *testing.T) {
+	server := newAzureStandIn(t, DefaultAzureAPIVersion)
+	defer server.Close()
+
+	prov := &models.Provider{Type: ProviderTypeAzure, BaseURL: server.URL, APIKey: "azure-key"}
+	result, err := NewHealthChecker(5*time.Second).CheckProviderHealth(t.Context(), prov, "prod-gpt4o")
+	require.NoError(t, err)
+	assert.True(t, result.Healthy, result.Error)
+}
+
+func TestService_AzureProvider(t *testing.T) {
+	server := newAzureStandIn(t, "2025-01-01-preview")
+	defer server.Close()
+
+	service := setupTestServiceWithEncryption(t, generateTestEncryptionKey(t))
+	created, err := service.CreateProvider(CreateProviderRequest{
+		Name:       "azure-prod",
+		BaseURL:    server.URL,
+		APIKey:     "azure-key",
+		TestModel:  "prod-gpt4o",
+		Type:       "Azure",
+		APIVersion: "2025-01-01-preview",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, ProviderTypeAzure, created.Type)
+
+	available, err := service.GetAvailableModels(created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, available.Total)
+	assert.Equal(t, "gpt-4o", available.Models[0].ID)
+
+	_, err = service.CreateProvider(CreateProviderRequest{
+		Name:      "unknown-type",
+		BaseURL:   server.URL,
+		APIKey:    "key",
+		TestModel: "m",
+		Type:      "watsonx",
+	})
+	assert.True(t, errors.Is(err, ErrInvalidInput))
+}
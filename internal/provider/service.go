@@ -3,6 +3,7 @@ package provider
 import (
 	"errors"
 	"fmt"
+	"log"
 	"net/url"
 	"strings"
 
@@ -23,6 +24,7 @@ var (
 type Service struct {
 	repo          *Repository
 	encryptionKey []byte
+	transports    *TransportRegistry
 }
 
 // NewService 创建 Service 实例
@@ -30,6 +32,7 @@ func NewService(repo *Repository) *Service {
 	return &Service{
 		repo:          repo,
 		encryptionKey: nil, // 延迟加载
+		transports:    DefaultTransportRegistry(),
 	}
 }
 
@@ -38,9 +41,18 @@ func NewServiceWithEncryption(repo *Repository, encryptionKey []byte) *Service {
 	return &Service{
 		repo:          repo,
 		encryptionKey: encryptionKey,
+		transports:    DefaultTransportRegistry(),
 	}
 }
 
+// Transports 返回供应商出站连接池注册表
+func (s *Service) Transports() *TransportRegistry {
+	if s.transports == nil {
+		return DefaultTransportRegistry()
+	}
+	return s.transports
+}
+
 // CreateProvider 创建供应商
 func (s *Service) CreateProvider(req CreateProviderRequest) (*models.Provider, error) {
 	// 验证参数
@@ -64,6 +76,71 @@ func (s *Service) CreateProvider(req CreateProviderRequest) (*models.Provider, e
 		APIKey:       req.APIKey, // 将在保存前加密
 		TestModel:    req.TestModel,
 		HealthStatus: "unknown",
+
+		Type:         NormalizeProviderType(req.Type),
+		APIVersion:   strings.TrimSpace(req.APIVersion),
+		AWSSecretKey: req.AWSSecretKey,
+		AWSRegion:    strings.TrimSpace(req.AWSRegion),
+
+		VertexProjectID: strings.TrimSpace(req.VertexProjectID),
+		VertexLocation:  strings.TrimSpace(req.VertexLocation),
+		TokenURL:        strings.TrimSpace(req.TokenURL),
+
+		ConnectTimeout:        req.ConnectTimeout,
+		ResponseHeaderTimeout: req.ResponseHeaderTimeout,
+		StreamIdleTimeout:     req.StreamIdleTimeout,
+		MaxIdleConns:          req.MaxIdleConns,
+		DisableHTTP2:          req.DisableHTTP2,
+
+		ProxyURL:           strings.TrimSpace(req.ProxyURL),
+		CACert:             req.CACert,
+		ClientCert:         req.ClientCert,
+		ClientKey:          req.ClientKey,
+		InsecureSkipVerify: req.InsecureSkipVerify,
+
+		ChatPath:          strings.TrimSpace(req.ChatPath),
+		MessagesPath:      strings.TrimSpace(req.MessagesPath),
+		ModelsPath:        strings.TrimSpace(req.ModelsPath),
+		CountTokensPath:   strings.TrimSpace(req.CountTokensPath),
+		ResponsesPath:     strings.TrimSpace(req.ResponsesPath),
+		ResponsesAPI:      req.ResponsesAPI,
+		EmbeddingsPath:    strings.TrimSpace(req.EmbeddingsPath),
+		CompletionsPath:   strings.TrimSpace(req.CompletionsPath),
+		CompletionsAPI:    req.CompletionsAPI,
+		ImagesPath:        strings.TrimSpace(req.ImagesPath),
+		PromptCacheHints:  req.PromptCacheHints,
+		PDFTextExtraction: req.PDFTextExtraction,
+		StreamUsage:       req.StreamUsage,
+	}
+
+	customHeaders, err := EncodeCustomHeaders(req.CustomHeaders)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid custom_headers", ErrInvalidInput)
+	}
+	provider.CustomHeaders = customHeaders
+
+	forwardHeaders, err := EncodeForwardHeaders(req.ForwardHeaders)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid forward_headers", ErrInvalidInput)
+	}
+	provider.ForwardHeaders = forwardHeaders
+
+	// 校验供应商类型
+	if err := ValidateProviderType(provider); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+	}
+
+	// 校验代理与 TLS 设置
+	if err := ValidateTransportConfig(provider); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+	}
+
+	// 校验端点路径与自定义请求头
+	if err := ValidateEndpointSettings(provider); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+	}
+	if err := ValidateForwardHeaders(provider); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
 	}
 
 	// 应用 Enabled（默认值 true）
@@ -83,6 +160,12 @@ func (s *Service) CreateProvider(req CreateProviderRequest) (*models.Provider, e
 		provider.APIKey = encryptedKey
 	}
 
+	// 加密代理地址、客户端私钥等凭证
+	plaintextSecrets := secretValues(provider)
+	if err := s.encryptSecrets(provider); err != nil {
+		return nil, err
+	}
+
 	// 保存到数据库
 	if err := s.repo.Create(provider); err != nil {
 		return nil, err
@@ -90,6 +173,7 @@ func (s *Service) CreateProvider(req CreateProviderRequest) (*models.Provider, e
 
 	// 返回前恢复明文 API Key（Handler 会负责脱敏）
 	provider.APIKey = plaintextKey
+	restoreSecrets(provider, plaintextSecrets)
 
 	return provider, nil
 }
@@ -110,6 +194,10 @@ func (s *Service) GetProvider(id uint) (*models.Provider, error) {
 		provider.APIKey = decryptedKey
 	}
 
+	if err := s.decryptSecrets(provider); err != nil {
+		return nil, err
+	}
+
 	return provider, nil
 }
 
@@ -132,7 +220,25 @@ func (s *Service) ListProviders(page, pageSize int) ([]*models.Provider, int64,
 		pageSize = 100
 	}
 
-	return s.repo.FindAll(page, pageSize)
+	providers, total, err := s.repo.FindAll(page, pageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// 解密凭证字段，便于响应中展示脱敏后的自定义请求头与代理地址
+	// 单个字段解密失败（如密钥更换）时只清空该字段，避免一个供应商导致整个列表无法展示
+	if s.encryptionKey != nil {
+		for _, provider := range providers {
+			for _, field := range secretFields(provider) {
+				if err := s.decryptSecret(provider, field); err != nil {
+					log.Printf("⚠️  [供应商] 解密供应商 %d 的凭证失败，已在列表中清空: %v", provider.ID, err)
+					*field = ""
+				}
+			}
+		}
+	}
+
+	return providers, total, nil
 }
 
 // UpdateProvider 更新供应商
@@ -148,6 +254,11 @@ func (s *Service) UpdateProvider(id uint, req UpdateProviderRequest) (*models.Pr
 		return nil, err
 	}
 
+	// 解密现有的代理地址、客户端私钥等凭证，便于合并校验
+	if err := s.decryptSecrets(provider); err != nil {
+		return nil, err
+	}
+
 	// 如果要更新名称，检查名称是否已存在
 	if req.Name != nil && *req.Name != provider.Name {
 		exists, err := s.repo.CheckNameExists(*req.Name, id)
@@ -169,42 +280,170 @@ func (s *Service) UpdateProvider(id uint, req UpdateProviderRequest) (*models.Pr
 		provider.TestModel = *req.TestModel
 	}
 
-	var plaintextKey string // 保存明文用于返回
+	// 校验（如 Vertex 服务账号 JSON）与返回都使用明文 API Key，保存前再加密
 	if req.APIKey != nil {
-		plaintextKey = *req.APIKey
-		// 加密 API Key（如果配置了加密密钥）
-		if s.encryptionKey != nil {
-			encryptedKey, err := crypto.EncryptString(*req.APIKey, s.encryptionKey)
-			if err != nil {
-				return nil, fmt.Errorf("failed to encrypt API key: %w", err)
-			}
-			provider.APIKey = encryptedKey
-		} else {
-			provider.APIKey = *req.APIKey
+		provider.APIKey = *req.APIKey
+	} else if s.encryptionKey != nil && provider.APIKey != "" {
+		decryptedKey, err := crypto.DecryptString(provider.APIKey, s.encryptionKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt API key: %w", err)
 		}
+		provider.APIKey = decryptedKey
 	}
 
 	if req.Enabled != nil {
 		provider.Enabled = *req.Enabled
 	}
 
-	// 保存到数据库
-	if err := s.repo.Update(provider); err != nil {
-		return nil, err
+	// 更新供应商类型
+	if req.Type != nil {
+		provider.Type = NormalizeProviderType(*req.Type)
+	}
+	if req.APIVersion != nil {
+		provider.APIVersion = strings.TrimSpace(*req.APIVersion)
+	}
+	if req.AWSSecretKey != nil {
+		provider.AWSSecretKey = *req.AWSSecretKey
+	}
+	if req.AWSRegion != nil {
+		provider.AWSRegion = strings.TrimSpace(*req.AWSRegion)
+	}
+	if req.VertexProjectID != nil {
+		provider.VertexProjectID = strings.TrimSpace(*req.VertexProjectID)
+	}
+	if req.VertexLocation != nil {
+		provider.VertexLocation = strings.TrimSpace(*req.VertexLocation)
+	}
+	if req.TokenURL != nil {
+		provider.TokenURL = strings.TrimSpace(*req.TokenURL)
 	}
 
-	// 返回前恢复/解密 API Key（Handler 会负责脱敏）
-	if req.APIKey != nil {
-		provider.APIKey = plaintextKey
-	} else if s.encryptionKey != nil && provider.APIKey != "" {
-		// 如果没有更新 API Key，则解密现有的
-		decryptedKey, err := crypto.DecryptString(provider.APIKey, s.encryptionKey)
+	// 更新出站连接设置
+	if req.ConnectTimeout != nil {
+		provider.ConnectTimeout = *req.ConnectTimeout
+	}
+	if req.ResponseHeaderTimeout != nil {
+		provider.ResponseHeaderTimeout = *req.ResponseHeaderTimeout
+	}
+	if req.StreamIdleTimeout != nil {
+		provider.StreamIdleTimeout = *req.StreamIdleTimeout
+	}
+	if req.MaxIdleConns != nil {
+		provider.MaxIdleConns = *req.MaxIdleConns
+	}
+	if req.DisableHTTP2 != nil {
+		provider.DisableHTTP2 = *req.DisableHTTP2
+	}
+
+	// 更新出站代理与 TLS 设置
+	if req.ProxyURL != nil {
+		provider.ProxyURL = strings.TrimSpace(*req.ProxyURL)
+	}
+	if req.CACert != nil {
+		provider.CACert = *req.CACert
+	}
+	if req.ClientCert != nil {
+		provider.ClientCert = *req.ClientCert
+	}
+	if req.ClientKey != nil {
+		provider.ClientKey = *req.ClientKey
+	}
+	if req.InsecureSkipVerify != nil {
+		provider.InsecureSkipVerify = *req.InsecureSkipVerify
+	}
+
+	// 更新端点路径与自定义请求头
+	if req.ChatPath != nil {
+		provider.ChatPath = strings.TrimSpace(*req.ChatPath)
+	}
+	if req.MessagesPath != nil {
+		provider.MessagesPath = strings.TrimSpace(*req.MessagesPath)
+	}
+	if req.ModelsPath != nil {
+		provider.ModelsPath = strings.TrimSpace(*req.ModelsPath)
+	}
+	if req.CountTokensPath != nil {
+		provider.CountTokensPath = strings.TrimSpace(*req.CountTokensPath)
+	}
+	if req.ResponsesPath != nil {
+		provider.ResponsesPath = strings.TrimSpace(*req.ResponsesPath)
+	}
+	if req.ResponsesAPI != nil {
+		provider.ResponsesAPI = *req.ResponsesAPI
+	}
+	if req.EmbeddingsPath != nil {
+		provider.EmbeddingsPath = strings.TrimSpace(*req.EmbeddingsPath)
+	}
+	if req.CompletionsPath != nil {
+		provider.CompletionsPath = strings.TrimSpace(*req.CompletionsPath)
+	}
+	if req.CompletionsAPI != nil {
+		provider.CompletionsAPI = *req.CompletionsAPI
+	}
+	if req.ImagesPath != nil {
+		provider.ImagesPath = strings.TrimSpace(*req.ImagesPath)
+	}
+	if req.PromptCacheHints != nil {
+		provider.PromptCacheHints = *req.PromptCacheHints
+	}
+	if req.PDFTextExtraction != nil {
+		provider.PDFTextExtraction = *req.PDFTextExtraction
+	}
+	if req.StreamUsage != nil {
+		provider.StreamUsage = *req.StreamUsage
+	}
+	if req.CustomHeaders != nil {
+		customHeaders, err := EncodeCustomHeaders(*req.CustomHeaders)
 		if err != nil {
-			return nil, fmt.Errorf("failed to decrypt API key: %w", err)
+			return nil, fmt.Errorf("%w: invalid custom_headers", ErrInvalidInput)
 		}
-		provider.APIKey = decryptedKey
+		provider.CustomHeaders = customHeaders
+	}
+	if req.ForwardHeaders != nil {
+		forwardHeaders, err := EncodeForwardHeaders(*req.ForwardHeaders)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid forward_headers", ErrInvalidInput)
+		}
+		provider.ForwardHeaders = forwardHeaders
+	}
+
+	if err := ValidateProviderType(provider); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+	}
+	if err := ValidateTransportConfig(provider); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+	}
+	if err := ValidateEndpointSettings(provider); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+	}
+	if err := ValidateForwardHeaders(provider); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
 	}
 
+	// 加密 API Key（如果配置了加密密钥）
+	plaintextKey := provider.APIKey // 保存明文用于返回
+	if s.encryptionKey != nil && provider.APIKey != "" {
+		encryptedKey, err := crypto.EncryptString(provider.APIKey, s.encryptionKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encrypt API key: %w", err)
+		}
+		provider.APIKey = encryptedKey
+	}
+
+	plaintextSecrets := secretValues(provider)
+	if err := s.encryptSecrets(provider); err != nil {
+		return nil, err
+	}
+
+	// 保存到数据库
+	if err := s.repo.Update(provider); err != nil {
+		return nil, err
+	}
+
+	// 返回前恢复明文 API Key（Handler 会负责脱敏）
+	provider.APIKey = plaintextKey
+	restoreSecrets(provider, plaintextSecrets)
+
 	return provider, nil
 }
 
@@ -219,7 +458,14 @@ func (s *Service) DeleteProvider(id uint) error {
 		return ErrProviderLinked
 	}
 
-	return s.repo.Delete(id)
+	if err := s.repo.Delete(id); err != nil {
+		return err
+	}
+
+	// 释放该供应商的连接池与缓存的访问令牌
+	s.Transports().Invalidate(id)
+	DefaultGoogleTokenCache().Invalidate(id)
+	return nil
 }
 
 // UpdateProviderHealthStatus 更新供应商健康状态
@@ -227,6 +473,81 @@ func (s *Service) UpdateProviderHealthStatus(id uint, healthStatus string) error
 	return s.repo.UpdateHealthStatus(id, healthStatus)
 }
 
+// secretFields 需要加密存储的凭证字段（API Key 单独处理）
+// 代理地址可能包含用户名密码，自定义请求头常用于覆盖 Authorization 等认证头，与 API Key 一样加密存储
+func secretFields(provider *models.Provider) []*string {
+	return []*string{&provider.ProxyURL, &provider.ClientKey, &provider.AWSSecretKey, &provider.CustomHeaders}
+}
+
+// secretValues 保存凭证字段的明文，用于保存后恢复
+func secretValues(provider *models.Provider) []string {
+	fields := secretFields(provider)
+	values := make([]string, len(fields))
+	for i, field := range fields {
+		values[i] = *field
+	}
+	return values
+}
+
+// restoreSecrets 恢复凭证字段的明文
+func restoreSecrets(provider *models.Provider, values []string) {
+	for i, field := range secretFields(provider) {
+		*field = values[i]
+	}
+}
+
+// encryptSecrets 加密凭证字段（如果配置了加密密钥）
+func (s *Service) encryptSecrets(provider *models.Provider) error {
+	if s.encryptionKey == nil {
+		return nil
+	}
+
+	for _, field := range secretFields(provider) {
+		if *field == "" {
+			continue
+		}
+		encrypted, err := crypto.EncryptString(*field, s.encryptionKey)
+		if err != nil {
+			return fmt.Errorf("failed to encrypt provider secret: %w", err)
+		}
+		*field = encrypted
+	}
+
+	return nil
+}
+
+// decryptSecrets 解密凭证字段
+func (s *Service) decryptSecrets(provider *models.Provider) error {
+	if s.encryptionKey == nil {
+		return nil
+	}
+
+	for _, field := range secretFields(provider) {
+		if err := s.decryptSecret(provider, field); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// decryptSecret 解密单个凭证字段
+func (s *Service) decryptSecret(provider *models.Provider, field *string) error {
+	if *field == "" {
+		return nil
+	}
+	// 加密存储前保存的自定义请求头为明文 JSON 对象（密文为 Base64，不会以 { 开头）
+	if field == &provider.CustomHeaders && strings.HasPrefix(*field, "{") {
+		return nil
+	}
+	decrypted, err := crypto.DecryptString(*field, s.encryptionKey)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt provider secret: %w", err)
+	}
+	*field = decrypted
+	return nil
+}
+
 // validateCreateRequest 验证创建请求
 func (s *Service) validateCreateRequest(req CreateProviderRequest) error {
 	// 名称不能为空
@@ -249,6 +570,11 @@ func (s *Service) validateCreateRequest(req CreateProviderRequest) error {
 		return fmt.Errorf("%w: test_model is required", ErrInvalidInput)
 	}
 
+	// 出站连接设置不能为负数
+	if req.ConnectTimeout < 0 || req.ResponseHeaderTimeout < 0 || req.StreamIdleTimeout < 0 || req.MaxIdleConns < 0 {
+		return fmt.Errorf("%w: transport settings cannot be negative", ErrInvalidInput)
+	}
+
 	return nil
 }
 
@@ -276,6 +602,13 @@ func (s *Service) validateUpdateRequest(req UpdateProviderRequest) error {
 		return fmt.Errorf("%w: test_model cannot be empty", ErrInvalidInput)
 	}
 
+	// 出站连接设置验证
+	for _, v := range []*int{req.ConnectTimeout, req.ResponseHeaderTimeout, req.StreamIdleTimeout, req.MaxIdleConns} {
+		if v != nil && *v < 0 {
+			return fmt.Errorf("%w: transport settings cannot be negative", ErrInvalidInput)
+		}
+	}
+
 	return nil
 }
 
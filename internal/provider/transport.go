@@ -0,0 +1,388 @@
+package provider
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Mieluoxxx/Siriusx-API/internal/models"
+)
+
+// 出站连接默认参数
+const (
+	DefaultConnectTimeout        = 10 * time.Second
+	DefaultTLSHandshakeTimeout   = 10 * time.Second
+	DefaultResponseHeaderTimeout = 120 * time.Second
+	DefaultStreamIdleTimeout     = 300 * time.Second
+	DefaultIdleConnTimeout       = 90 * time.Second
+	DefaultMaxIdleConnsPerHost   = 32
+)
+
+// ErrStreamIdleTimeout 上游响应体在空闲超时时间内没有新数据
+var ErrStreamIdleTimeout = errors.New("upstream stream idle timeout")
+
+// TransportConfig 出站 HTTP 传输配置
+type TransportConfig struct {
+	ConnectTimeout        time.Duration // TCP 建连超时
+	TLSHandshakeTimeout   time.Duration // TLS 握手超时
+	ResponseHeaderTimeout time.Duration // 等待响应头超时
+	StreamIdleTimeout     time.Duration // 响应体两次读取之间的最大空闲时间
+	IdleConnTimeout       time.Duration // 空闲连接保留时间
+	MaxIdleConnsPerHost   int           // 每个 Host 最大空闲连接数
+	DisableHTTP2          bool          // 是否禁用 HTTP/2
+
+	ProxyURL           string // 出站代理地址（http/https/socks5），为空时使用环境变量
+	CACert             string // 额外信任的 CA 证书（PEM）
+	ClientCert         string // mTLS 客户端证书（PEM）
+	ClientKey          string // mTLS 客户端私钥（PEM）
+	InsecureSkipVerify bool   // 跳过服务端证书校验
+}
+
+// DefaultTransportConfig 返回默认传输配置
+func DefaultTransportConfig() TransportConfig {
+	return TransportConfig{
+		ConnectTimeout:        DefaultConnectTimeout,
+		TLSHandshakeTimeout:   DefaultTLSHandshakeTimeout,
+		ResponseHeaderTimeout: DefaultResponseHeaderTimeout,
+		StreamIdleTimeout:     DefaultStreamIdleTimeout,
+		IdleConnTimeout:       DefaultIdleConnTimeout,
+		MaxIdleConnsPerHost:   DefaultMaxIdleConnsPerHost,
+	}
+}
+
+// TransportConfigFromProvider 根据供应商配置生成传输配置（0 值使用默认值）
+func TransportConfigFromProvider(prov *models.Provider) TransportConfig {
+	cfg := DefaultTransportConfig()
+	if prov == nil {
+		return cfg
+	}
+
+	if prov.ConnectTimeout > 0 {
+		cfg.ConnectTimeout = time.Duration(prov.ConnectTimeout) * time.Second
+	}
+	if prov.ResponseHeaderTimeout > 0 {
+		cfg.ResponseHeaderTimeout = time.Duration(prov.ResponseHeaderTimeout) * time.Second
+	}
+	if prov.StreamIdleTimeout > 0 {
+		cfg.StreamIdleTimeout = time.Duration(prov.StreamIdleTimeout) * time.Second
+	}
+	if prov.MaxIdleConns > 0 {
+		cfg.MaxIdleConnsPerHost = prov.MaxIdleConns
+	}
+	cfg.DisableHTTP2 = prov.DisableHTTP2
+
+	cfg.ProxyURL = strings.TrimSpace(prov.ProxyURL)
+	cfg.CACert = prov.CACert
+	cfg.ClientCert = prov.ClientCert
+	cfg.ClientKey = prov.ClientKey
+	cfg.InsecureSkipVerify = prov.InsecureSkipVerify
+
+	return cfg
+}
+
+// TransportRegistry 按供应商复用的 HTTP 连接池注册表
+// 每个供应商持有独立的 http.Transport，配置变化时自动重建
+// 未保存的临时供应商（ID 为 0，如健康检查）按传输配置共享连接池，避免相互覆盖
+type TransportRegistry struct {
+	mu      sync.Mutex
+	entries map[uint]*transportEntry
+	adhoc   map[TransportConfig]*transportEntry
+}
+
+// transportEntry 注册表条目
+type transportEntry struct {
+	config    TransportConfig
+	transport *http.Transport
+	client    *http.Client
+}
+
+// NewTransportRegistry 创建连接池注册表
+func NewTransportRegistry() *TransportRegistry {
+	return &TransportRegistry{
+		entries: make(map[uint]*transportEntry),
+		adhoc:   make(map[TransportConfig]*transportEntry),
+	}
+}
+
+var defaultTransportRegistry = NewTransportRegistry()
+
+// DefaultTransportRegistry 返回进程级共享的连接池注册表
+func DefaultTransportRegistry() *TransportRegistry {
+	return defaultTransportRegistry
+}
+
+// Client 获取供应商对应的 HTTP 客户端
+// 客户端不设置整体超时，由建连、响应头和流空闲超时分别控制
+func (r *TransportRegistry) Client(prov *models.Provider) (*http.Client, error) {
+	cfg := TransportConfigFromProvider(prov)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if prov == nil || prov.ID == 0 {
+		if entry, ok := r.adhoc[cfg]; ok {
+			return entry.client, nil
+		}
+		entry, err := newTransportEntry(cfg)
+		if err != nil {
+			return nil, err
+		}
+		r.adhoc[cfg] = entry
+		return entry.client, nil
+	}
+
+	if entry, ok := r.entries[prov.ID]; ok {
+		if entry.config == cfg {
+			return entry.client, nil
+		}
+		// 配置已变化，释放旧连接
+		entry.transport.CloseIdleConnections()
+		delete(r.entries, prov.ID)
+	}
+
+	entry, err := newTransportEntry(cfg)
+	if err != nil {
+		return nil, err
+	}
+	r.entries[prov.ID] = entry
+
+	return entry.client, nil
+}
+
+// newTransportEntry 根据配置创建注册表条目
+func newTransportEntry(cfg TransportConfig) (*transportEntry, error) {
+	transport, err := newTransport(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &transportEntry{
+		config:    cfg,
+		transport: transport,
+		client: &http.Client{
+			Transport: &idleTimeoutTransport{base: transport, idle: cfg.StreamIdleTimeout},
+		},
+	}, nil
+}
+
+// Invalidate 移除供应商的连接池（供应商被删除或更新时调用）
+func (r *TransportRegistry) Invalidate(providerID uint) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if entry, ok := r.entries[providerID]; ok {
+		entry.transport.CloseIdleConnections()
+		delete(r.entries, providerID)
+	}
+}
+
+// CloseIdleConnections 关闭所有供应商的空闲连接
+func (r *TransportRegistry) CloseIdleConnections() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, entry := range r.entries {
+		entry.transport.CloseIdleConnections()
+	}
+	for _, entry := range r.adhoc {
+		entry.transport.CloseIdleConnections()
+	}
+}
+
+// newTransport 根据配置创建 http.Transport
+func newTransport(cfg TransportConfig) (*http.Transport, error) {
+	proxy, err := proxyFunc(cfg.ProxyURL)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig, err := buildTLSConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	dialer := &net.Dialer{
+		Timeout:   cfg.ConnectTimeout,
+		KeepAlive: 30 * time.Second,
+	}
+
+	transport := &http.Transport{
+		Proxy:                 proxy,
+		DialContext:           dialer.DialContext,
+		TLSClientConfig:       tlsConfig,
+		ForceAttemptHTTP2:     !cfg.DisableHTTP2,
+		MaxIdleConns:          cfg.MaxIdleConnsPerHost * 4,
+		MaxIdleConnsPerHost:   cfg.MaxIdleConnsPerHost,
+		IdleConnTimeout:       cfg.IdleConnTimeout,
+		TLSHandshakeTimeout:   cfg.TLSHandshakeTimeout,
+		ResponseHeaderTimeout: cfg.ResponseHeaderTimeout,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+
+	if cfg.DisableHTTP2 {
+		// 非 nil 的空 map 会阻止 net/http 自动启用 HTTP/2
+		transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	}
+
+	return transport, nil
+}
+
+// proxyFunc 解析代理地址
+// net/http 原生支持 http、https 和 socks5 代理
+func proxyFunc(rawURL string) (func(*http.Request) (*url.URL, error), error) {
+	if rawURL == "" {
+		return http.ProxyFromEnvironment, nil
+	}
+
+	proxyURL, err := ParseProxyURL(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return http.ProxyURL(proxyURL), nil
+}
+
+// ParseProxyURL 解析并校验代理地址
+func ParseProxyURL(rawURL string) (*url.URL, error) {
+	proxyURL, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy_url: %w", err)
+	}
+
+	switch strings.ToLower(proxyURL.Scheme) {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf("invalid proxy_url: unsupported scheme %q (expected http, https or socks5)", proxyURL.Scheme)
+	}
+
+	if proxyURL.Host == "" {
+		return nil, errors.New("invalid proxy_url: missing host")
+	}
+
+	return proxyURL, nil
+}
+
+// buildTLSConfig 根据配置构建 TLS 设置，未配置任何选项时返回 nil 使用默认值
+func buildTLSConfig(cfg TransportConfig) (*tls.Config, error) {
+	if cfg.CACert == "" && cfg.ClientCert == "" && cfg.ClientKey == "" && !cfg.InsecureSkipVerify {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+	}
+
+	if cfg.CACert != "" {
+		// 在系统证书基础上追加自定义 CA
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM([]byte(cfg.CACert)) {
+			return nil, errors.New("invalid ca_cert: no PEM certificates found")
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if cfg.ClientCert != "" || cfg.ClientKey != "" {
+		if cfg.ClientCert == "" || cfg.ClientKey == "" {
+			return nil, errors.New("client_cert and client_key must be provided together")
+		}
+		cert, err := tls.X509KeyPair([]byte(cfg.ClientCert), []byte(cfg.ClientKey))
+		if err != nil {
+			return nil, fmt.Errorf("invalid client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+// ValidateTransportConfig 校验供应商的代理与 TLS 设置
+func ValidateTransportConfig(prov *models.Provider) error {
+	cfg := TransportConfigFromProvider(prov)
+	if cfg.ProxyURL != "" {
+		if _, err := ParseProxyURL(cfg.ProxyURL); err != nil {
+			return err
+		}
+	}
+	_, err := buildTLSConfig(cfg)
+	return err
+}
+
+// RedactProxyURL 隐藏代理地址中的密码，无法解析时（如加密后的值）返回固定脱敏格式
+func RedactProxyURL(rawURL string) string {
+	if rawURL == "" {
+		return ""
+	}
+	proxyURL, err := url.Parse(rawURL)
+	if err != nil || proxyURL.Scheme == "" || proxyURL.Host == "" {
+		return MaskEncryptedAPIKey()
+	}
+	return proxyURL.Redacted()
+}
+
+// idleTimeoutTransport 为响应体增加空闲超时控制
+type idleTimeoutTransport struct {
+	base http.RoundTripper
+	idle time.Duration
+}
+
+// RoundTrip 实现 http.RoundTripper
+func (t *idleTimeoutTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || t.idle <= 0 || resp.Body == nil {
+		return resp, err
+	}
+
+	resp.Body = newIdleTimeoutBody(resp.Body, t.idle)
+	return resp, nil
+}
+
+// idleTimeoutBody 在指定时间内没有读到数据时关闭底层响应体
+type idleTimeoutBody struct {
+	io.ReadCloser
+	idle     time.Duration
+	timer    *time.Timer
+	timedOut atomic.Bool
+}
+
+// newIdleTimeoutBody 包装响应体
+func newIdleTimeoutBody(body io.ReadCloser, idle time.Duration) *idleTimeoutBody {
+	b := &idleTimeoutBody{
+		ReadCloser: body,
+		idle:       idle,
+	}
+	b.timer = time.AfterFunc(idle, func() {
+		b.timedOut.Store(true)
+		body.Close()
+	})
+	return b
+}
+
+// Read 读取数据并重置空闲计时器
+func (b *idleTimeoutBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err != nil && b.timedOut.Load() {
+		return n, ErrStreamIdleTimeout
+	}
+	if n > 0 {
+		b.timer.Reset(b.idle)
+	}
+	return n, err
+}
+
+// Close 停止计时器并关闭响应体
+func (b *idleTimeoutBody) Close() error {
+	b.timer.Stop()
+	return b.ReadCloser.Close()
+}
@@ -0,0 +1,109 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/Mieluoxxx/Siriusx-API/internal/models"
+)
+
+// VertexAnthropicVersion Vertex 上 Claude 要求的 anthropic_version（放在请求体中）
+const VertexAnthropicVersion = "vertex-2023-10-16"
+
+// Vertex 模型发布方
+const (
+	VertexPublisherAnthropic = "anthropic"
+	VertexPublisherGoogle    = "google"
+)
+
+// VertexPublisher 根据模型名判断发布方：claude-* 为 Anthropic，其余视为 Gemini
+func VertexPublisher(model string) string {
+	if strings.HasPrefix(strings.ToLower(model), "claude") {
+		return VertexPublisherAnthropic
+	}
+	return VertexPublisherGoogle
+}
+
+// VertexProjectID 获取项目 ID：供应商配置优先，其次为服务账号中的 project_id
+func VertexProjectID(prov *models.Provider) string {
+	if projectID := strings.TrimSpace(prov.VertexProjectID); projectID != "" {
+		return projectID
+	}
+	if sa, err := ParseServiceAccount(prov.APIKey); err == nil {
+		return sa.ProjectID
+	}
+	return ""
+}
+
+// VertexModelURL 构建 Vertex 模型调用 URL
+// Claude: :rawPredict / :streamRawPredict；Gemini: :generateContent / :streamGenerateContent?alt=sse
+func VertexModelURL(prov *models.Provider, model string, stream bool) string {
+	publisher := VertexPublisher(model)
+
+	var method string
+	switch {
+	case publisher == VertexPublisherAnthropic && stream:
+		method = "streamRawPredict"
+	case publisher == VertexPublisherAnthropic:
+		method = "rawPredict"
+	case stream:
+		method = "streamGenerateContent?alt=sse"
+	default:
+		method = "generateContent"
+	}
+
+	return fmt.Sprintf("%s/v1/projects/%s/locations/%s/publishers/%s/models/%s:%s",
+		strings.TrimRight(prov.BaseURL, "/"),
+		url.PathEscape(VertexProjectID(prov)),
+		url.PathEscape(strings.TrimSpace(prov.VertexLocation)),
+		publisher,
+		url.PathEscape(model),
+		method)
+}
+
+// VertexModelsURL 构建 Gemini 模型列表 URL（publisher models 接口仅在 v1beta1 提供）
+func VertexModelsURL(prov *models.Provider) string {
+	return strings.TrimRight(prov.BaseURL, "/") + "/v1beta1/publishers/google/models"
+}
+
+// BuildVertexClaudeBody 将 Claude Messages 请求体转换为 Vertex rawPredict 请求体
+// model 由 URL 决定，anthropic_version 改为放在请求体中；streamRawPredict 仍需要 stream 字段
+func BuildVertexClaudeBody(req map[string]interface{}, betaHeader string) map[string]interface{} {
+	body := buildNativeClaudeBody(req, betaHeader, VertexAnthropicVersion)
+	if stream, ok := req["stream"].(bool); ok && stream {
+		body["stream"] = true
+	}
+	return body
+}
+
+// AuthorizeVertexRequest 为 Vertex 请求设置 OAuth 访问令牌
+func AuthorizeVertexRequest(ctx context.Context, req *http.Request, prov *models.Provider) error {
+	token, err := DefaultGoogleTokenCache().AccessToken(ctx, prov)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return nil
+}
+
+// validateVertexSettings 校验 Vertex 供应商配置
+func validateVertexSettings(prov *models.Provider) error {
+	if _, err := ParseServiceAccount(prov.APIKey); err != nil {
+		return err
+	}
+	if strings.TrimSpace(prov.VertexLocation) == "" {
+		return fmt.Errorf("vertex_location is required for vertex providers")
+	}
+	if VertexProjectID(prov) == "" {
+		return fmt.Errorf("vertex_project_id is required when the service account has no project_id")
+	}
+	if tokenURL := strings.TrimSpace(prov.TokenURL); tokenURL != "" {
+		if parsed, err := url.Parse(tokenURL); err != nil || parsed.Scheme == "" || parsed.Host == "" {
+			return fmt.Errorf("invalid token_url")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,362 @@
+package provider
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Mieluoxxx/Siriusx-API/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testVertexEmail = "gateway@test-project.iam.gserviceaccount.com"
+
+// newTestServiceAccount 生成测试用服务账号 JSON（PKCS#8 私钥）
+func newTestServiceAccount(t *testing.T, tokenURI string) (string, *rsa.PublicKey) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+
+	raw, err := json.Marshal(ServiceAccount{
+		Type:         "service_account",
+		ProjectID:    "test-project",
+		PrivateKeyID: "key-1",
+		PrivateKey:   string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		ClientEmail:  testVertexEmail,
+		TokenURI:     tokenURI,
+	})
+	require.NoError(t, err)
+	return string(raw), &key.PublicKey
+}
+
+// verifyTestJWT 校验 JWT 签名与声明（模拟 Google 令牌端点）
+func verifyTestJWT(assertion string, pub *rsa.PublicKey, audience string) error {
+	parts := strings.Split(assertion, ".")
+	if len(parts) != 3 {
+		return fmt.Errorf("malformed jwt")
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return err
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], signature); err != nil {
+		return fmt.Errorf("bad signature: %w", err)
+	}
+
+	claimsJSON, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return err
+	}
+	var claims struct {
+		Iss   string `json:"iss"`
+		Scope string `json:"scope"`
+		Aud   string `json:"aud"`
+		Iat   int64  `json:"iat"`
+		Exp   int64  `json:"exp"`
+	}
+	if err := json.Unmarshal(claimsJSON, &claims); err != nil {
+		return err
+	}
+	if claims.Iss != testVertexEmail || claims.Aud != audience || claims.Scope != googleCloudPlatformScope {
+		return fmt.Errorf("unexpected claims %s", claimsJSON)
+	}
+	if claims.Exp-claims.Iat != int64(googleJWTLifetime/time.Second) {
+		return fmt.Errorf("unexpected lifetime")
+	}
+	return nil
+}
+
+// newGoogleTokenStandIn 模拟 Google 令牌端点，每次签发新的令牌并记录调用次数
+func newGoogleTokenStandIn(t *testing.T, pub **rsa.PublicKey, hits *int32) *httptest.Server {
+	t.Helper()
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil || r.Form.Get("grant_type") != "urn:ietf:params:oauth:grant-type:jwt-bearer" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if err := verifyTestJWT(r.Form.Get("assertion"), *pub, server.URL+r.URL.Path); err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprintf(w, `{"error":"invalid_grant","error_description":%q}`, err.Error())
+			return
+		}
+		n := atomic.AddInt32(hits, 1)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token":"ya29.token-%d","expires_in":3600,"token_type":"Bearer"}`, n)
+	}))
+	return server
+}
+
+// newVertexStandIn 模拟 Vertex 推理接口：校验 Bearer 令牌后响应 rawPredict 与 generateContent
+func newVertexStandIn(t *testing.T, invoked *map[string]interface{}) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "Bearer ya29.token-") {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error":{"code":401,"message":"missing token","status":"UNAUTHENTICATED"}}`))
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		if invoked != nil {
+			json.Unmarshal(body, invoked)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/v1/projects/test-project/locations/us-east5/publishers/anthropic/models/claude-3-5-sonnet@20240620:rawPredict":
+			w.Write([]byte(`{"id":"msg_1","type":"message","role":"assistant","content":[{"type":"text","text":"Hi"}]}`))
+		case "/v1/projects/test-project/locations/us-east5/publishers/google/models/gemini-1.5-pro:generateContent":
+			w.Write([]byte(`{"candidates":[{"content":{"role":"model","parts":[{"text":"Hi"}]}}]}`))
+		case "/v1beta1/publishers/google/models":
+			w.Write([]byte(`{"publisherModels":[{"name":"publishers/google/models/gemini-1.5-pro"}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestVertexModelURL(t *testing.T) {
+	sa, _ := newTestServiceAccount(t, "")
+	prov := &models.Provider{
+		BaseURL:        "https://us-east5-aiplatform.googleapis.com/",
+		APIKey:         sa,
+		VertexLocation: "us-east5",
+	}
+
+	base := "https://us-east5-aiplatform.googleapis.com/v1/projects/test-project/locations/us-east5/publishers/"
+	assert.Equal(t, base+"anthropic/models/claude-3-5-sonnet@20240620:rawPredict", VertexModelURL(prov, "claude-3-5-sonnet@20240620", false))
+	assert.Equal(t, base+"anthropic/models/claude-3-5-sonnet@20240620:streamRawPredict", VertexModelURL(prov, "claude-3-5-sonnet@20240620", true))
+	assert.Equal(t, base+"google/models/gemini-1.5-pro:generateContent", VertexModelURL(prov, "gemini-1.5-pro", false))
+	assert.Equal(t, base+"google/models/gemini-1.5-pro:streamGenerateContent?alt=sse", VertexModelURL(prov, "gemini-1.5-pro", true))
+
+	// 显式配置的项目 ID 优先于服务账号中的 project_id
+	prov.VertexProjectID = "other-project"
+	assert.Contains(t, VertexModelURL(prov, "gemini-1.5-pro", false), "/projects/other-project/")
+}
+
+func TestBuildVertexClaudeBody(t *testing.T) {
+	body := BuildVertexClaudeBody(map[string]interface{}{
+		"model":      "claude-3-5-sonnet@20240620",
+		"stream":     true,
+		"max_tokens": 16,
+	}, "")
+
+	assert.Equal(t, VertexAnthropicVersion, body["anthropic_version"])
+	assert.Equal(t, true, body["stream"])
+	assert.NotContains(t, body, "model")
+	assert.NotContains(t, body, "anthropic_beta")
+}
+
+func TestGoogleTokenCache_CachesAndRefreshes(t *testing.T) {
+	var pub *rsa.PublicKey
+	var hits int32
+	tokenServer := newGoogleTokenStandIn(t, &pub, &hits)
+	defer tokenServer.Close()
+
+	sa, key := newTestServiceAccount(t, tokenServer.URL+"/token")
+	pub = key
+	prov := &models.Provider{Type: ProviderTypeVertex, APIKey: sa}
+
+	now := time.Now()
+	cache := NewGoogleTokenCache(NewTransportRegistry())
+	cache.now = func() time.Time { return now }
+
+	first, err := cache.AccessToken(t.Context(), prov)
+	require.NoError(t, err)
+	second, err := cache.AccessToken(t.Context(), prov)
+	require.NoError(t, err)
+	assert.Equal(t, first, second)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&hits))
+
+	// 临近过期时重新换取
+	now = now.Add(time.Hour - 30*time.Second)
+	third, err := cache.AccessToken(t.Context(), prov)
+	require.NoError(t, err)
+	assert.NotEqual(t, first, third)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&hits))
+
+	// token_url 覆盖服务账号中的 token_uri
+	prov.TokenURL = tokenServer.URL + "/override"
+	_, err = cache.AccessToken(t.Context(), prov)
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&hits))
+
+	// 令牌端点拒绝时返回错误
+	other, _ := newTestServiceAccount(t, tokenServer.URL+"/rejected")
+	_, err = cache.AccessToken(t.Context(), &models.Provider{Type: ProviderTypeVertex, APIKey: other})
+	assert.ErrorContains(t, err, "HTTP 401")
+}
+
+// 慢速令牌换取只阻塞同一供应商的请求，且并发请求共享一次换取；Invalidate 只清除指定供应商
+func TestGoogleTokenCache_PerProvider(t *testing.T) {
+	var pub *rsa.PublicKey
+	var hits int32
+	tokenServer := newGoogleTokenStandIn(t, &pub, &hits)
+	defer tokenServer.Close()
+
+	var slowHits int32
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	slowServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&slowHits, 1)
+		started <- struct{}{}
+		<-release
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token":"ya29.slow-%d","expires_in":3600}`, n)
+	}))
+	defer slowServer.Close()
+
+	slowSA, _ := newTestServiceAccount(t, slowServer.URL+"/token")
+	fastSA, key := newTestServiceAccount(t, tokenServer.URL+"/token")
+	pub = key
+	slowProv := &models.Provider{ID: 1, Type: ProviderTypeVertex, APIKey: slowSA}
+	fastProv := &models.Provider{ID: 2, Type: ProviderTypeVertex, APIKey: fastSA}
+
+	cache := NewGoogleTokenCache(NewTransportRegistry())
+	results := make(chan string, 3)
+	for i := 0; i < 3; i++ {
+		go func() {
+			token, err := cache.AccessToken(t.Context(), slowProv)
+			assert.NoError(t, err)
+			results <- token
+		}()
+	}
+	<-started
+
+	// 慢供应商换取期间，其他供应商不受影响
+	_, err := cache.AccessToken(t.Context(), fastProv)
+	require.NoError(t, err)
+
+	close(release)
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, "ya29.slow-1", <-results)
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&slowHits))
+
+	cache.Invalidate(fastProv.ID)
+	_, err = cache.AccessToken(t.Context(), fastProv)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&hits))
+	token, err := cache.AccessToken(t.Context(), slowProv)
+	require.NoError(t, err)
+	assert.Equal(t, "ya29.slow-1", token)
+}
+
+func TestHealthChecker_Vertex(t *testing.T) {
+	var pub *rsa.PublicKey
+	var hits int32
+	tokenServer := newGoogleTokenStandIn(t, &pub, &hits)
+	defer tokenServer.Close()
+
+	var invoked map[string]interface{}
+	server := newVertexStandIn(t, &invoked)
+	defer server.Close()
+
+	sa, key := newTestServiceAccount(t, tokenServer.URL+"/token")
+	pub = key
+	prov := &models.Provider{
+		Type:           ProviderTypeVertex,
+		BaseURL:        server.URL,
+		APIKey:         sa,
+		VertexLocation: "us-east5",
+	}
+
+	result, err := NewHealthChecker(5*time.Second).CheckProviderHealth(t.Context(), prov, "claude-3-5-sonnet@20240620")
+	require.NoError(t, err)
+	assert.True(t, result.Healthy, result.Error)
+	assert.Equal(t, VertexAnthropicVersion, invoked["anthropic_version"])
+
+	invoked = nil
+	result, err = NewHealthChecker(5*time.Second).CheckProviderHealth(t.Context(), prov, "gemini-1.5-pro")
+	require.NoError(t, err)
+	assert.True(t, result.Healthy, result.Error)
+	assert.Contains(t, invoked, "contents")
+}
+
+func TestService_VertexProvider(t *testing.T) {
+	var pub *rsa.PublicKey
+	var hits int32
+	tokenServer := newGoogleTokenStandIn(t, &pub, &hits)
+	defer tokenServer.Close()
+
+	server := newVertexStandIn(t, nil)
+	defer server.Close()
+
+	sa, key := newTestServiceAccount(t, tokenServer.URL+"/token")
+	pub = key
+
+	service := setupTestServiceWithEncryption(t, generateTestEncryptionKey(t))
+	created, err := service.CreateProvider(CreateProviderRequest{
+		Name:           "vertex",
+		BaseURL:        server.URL,
+		APIKey:         sa,
+		TestModel:      "gemini-1.5-pro",
+		Type:           ProviderTypeVertex,
+		VertexLocation: "us-east5",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, ProviderTypeVertex, created.Type)
+
+	available, err := service.GetAvailableModels(created.ID)
+	require.NoError(t, err)
+	require.Equal(t, 1, available.Total)
+	assert.Equal(t, "gemini-1.5-pro", available.Models[0].ID)
+
+	tests := []struct {
+		name string
+		req  CreateProviderRequest
+	}{
+		{"invalid service account", CreateProviderRequest{APIKey: `{"client_email":"x"}`, VertexLocation: "us-east5"}},
+		{"missing location", CreateProviderRequest{APIKey: sa}},
+		{"invalid token url", CreateProviderRequest{APIKey: sa, VertexLocation: "us-east5", TokenURL: "not a url"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.req.Name = "vertex-" + tt.name
+			tt.req.BaseURL = server.URL
+			tt.req.TestModel = "gemini-1.5-pro"
+			tt.req.Type = ProviderTypeVertex
+			_, err := service.CreateProvider(tt.req)
+			assert.ErrorIs(t, err, ErrInvalidInput)
+		})
+	}
+
+	// 更新时服务账号以明文校验：不修改 api_key 或重新提交 api_key 都应成功
+	location := "europe-west1"
+	updated, err := service.UpdateProvider(created.ID, UpdateProviderRequest{VertexLocation: &location})
+	require.NoError(t, err)
+	assert.Equal(t, sa, updated.APIKey)
+	assert.Equal(t, location, updated.VertexLocation)
+
+	updated, err = service.UpdateProvider(created.ID, UpdateProviderRequest{APIKey: &sa})
+	require.NoError(t, err)
+	assert.Equal(t, sa, updated.APIKey)
+
+	var stored models.Provider
+	require.NoError(t, service.repo.db.First(&stored, created.ID).Error)
+	assert.NotEqual(t, sa, stored.APIKey, "service account should stay encrypted at rest")
+
+	got, err := service.GetProvider(created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, sa, got.APIKey)
+
+	invalid := `{"client_email":"x"}`
+	_, err = service.UpdateProvider(created.ID, UpdateProviderRequest{APIKey: &invalid})
+	assert.ErrorIs(t, err, ErrInvalidInput)
+}
@@ -0,0 +1,41 @@
+package responses
+
+import (
+	"errors"
+
+	"github.com/Mieluoxxx/Siriusx-API/internal/models"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrResponseNotFound 响应不存在（或不属于当前 Token）
+	ErrResponseNotFound = errors.New("response not found")
+)
+
+// Repository Responses 历史数据访问层
+type Repository struct {
+	db *gorm.DB
+}
+
+// NewRepository 创建 Repository 实例
+func NewRepository(db *gorm.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// Create 保存一轮响应
+func (r *Repository) Create(resp *models.StoredResponse) error {
+	return r.db.Create(resp).Error
+}
+
+// FindByID 根据响应 ID 查找
+func (r *Repository) FindByID(id string) (*models.StoredResponse, error) {
+	var resp models.StoredResponse
+	err := r.db.Where("id = ?", id).First(&resp).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrResponseNotFound
+		}
+		return nil, err
+	}
+	return &resp, nil
+}
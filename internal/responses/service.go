@@ -0,0 +1,91 @@
+package responses
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/Mieluoxxx/Siriusx-API/internal/converter"
+	"github.com/Mieluoxxx/Siriusx-API/internal/models"
+)
+
+// MaxHistoryDepth previous_response_id 链的最大回溯轮数
+const MaxHistoryDepth = 100
+
+// ErrHistoryTooDeep 对话链超过最大回溯轮数
+var ErrHistoryTooDeep = errors.New("response history too deep")
+
+// Service Responses 历史业务逻辑层
+type Service struct {
+	repo *Repository
+}
+
+// NewService 创建 Service 实例
+func NewService(repo *Repository) *Service {
+	return &Service{repo: repo}
+}
+
+// Save 保存一轮对话（本轮输入项与输出项）
+// 仅保存 message 与 function_call 输出项；reasoning 等项依赖上游的服务端状态，无法跨上游重放
+func (s *Service) Save(id string, tokenID uint, model, previousResponseID string, input []converter.ResponsesInputItem, output []converter.ResponsesOutputItem) error {
+	inputJSON, err := json.Marshal(input)
+	if err != nil {
+		return fmt.Errorf("序列化输入项失败: %w", err)
+	}
+
+	replayable := make([]converter.ResponsesOutputItem, 0, len(output))
+	for _, item := range output {
+		if item.Type == converter.ResponsesItemMessage || item.Type == converter.ResponsesItemFunctionCall {
+			replayable = append(replayable, item)
+		}
+	}
+	outputJSON, err := json.Marshal(replayable)
+	if err != nil {
+		return fmt.Errorf("序列化输出项失败: %w", err)
+	}
+
+	return s.repo.Create(&models.StoredResponse{
+		ID:                 id,
+		TokenID:            tokenID,
+		Model:              model,
+		PreviousResponseID: previousResponseID,
+		Input:              string(inputJSON),
+		Output:             string(outputJSON),
+	})
+}
+
+// History 沿 previous_response_id 链回溯，按时间顺序返回截至该响应（含）的全部输入项与输出项（输出项可直接作为输入项重放）
+// 响应不存在或属于其他 Token 时返回 ErrResponseNotFound
+func (s *Service) History(id string, tokenID uint) ([]converter.ResponsesInputItem, error) {
+	var turns []*models.StoredResponse
+	for current := id; current != ""; {
+		if len(turns) >= MaxHistoryDepth {
+			return nil, ErrHistoryTooDeep
+		}
+		turn, err := s.repo.FindByID(current)
+		if err != nil {
+			return nil, err
+		}
+		if turn.TokenID != tokenID {
+			return nil, ErrResponseNotFound
+		}
+		turns = append(turns, turn)
+		current = turn.PreviousResponseID
+	}
+
+	var items []converter.ResponsesInputItem
+	for i := len(turns) - 1; i >= 0; i-- {
+		for _, raw := range []string{turns[i].Input, turns[i].Output} {
+			var turnItems []converter.ResponsesInputItem
+			if err := json.Unmarshal([]byte(raw), &turnItems); err != nil {
+				return nil, fmt.Errorf("解析响应 %s 的历史记录失败: %w", turns[i].ID, err)
+			}
+			// 输出项 ID 由生成它的上游分配，重放时去掉，避免其他上游按 ID 查找服务端状态
+			for j := range turnItems {
+				turnItems[j].ID = ""
+			}
+			items = append(items, turnItems...)
+		}
+	}
+	return items, nil
+}
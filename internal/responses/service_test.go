@@ -0,0 +1,99 @@
+package responses
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/Mieluoxxx/Siriusx-API/internal/converter"
+	"github.com/Mieluoxxx/Siriusx-API/internal/models"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// setupTestService 创建使用内存数据库的 Service
+func setupTestService(t *testing.T) *Service {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to create test database: %v", err)
+	}
+	if err := db.AutoMigrate(&models.StoredResponse{}); err != nil {
+		t.Fatalf("failed to migrate test database: %v", err)
+	}
+	return NewService(NewRepository(db))
+}
+
+// userItem 构建 user 文本输入项
+func userItem(text string) converter.ResponsesInputItem {
+	content, _ := json.Marshal(text)
+	return converter.ResponsesInputItem{Type: converter.ResponsesItemMessage, Role: "user", Content: content}
+}
+
+// TestService_History 测试沿 previous_response_id 链按时间顺序还原历史
+func TestService_History(t *testing.T) {
+	service := setupTestService(t)
+
+	first := []converter.ResponsesOutputItem{
+		{Type: converter.ResponsesItemReasoning, ID: "rs_1"},
+		{Type: converter.ResponsesItemFunctionCall, ID: "fc_1", CallID: "call_1", Name: "lookup", Arguments: `{"q":"cat"}`},
+	}
+	if err := service.Save("resp_1", 1, "gpt-4o", "", []converter.ResponsesInputItem{userItem("find a cat")}, first); err != nil {
+		t.Fatalf("Save() failed: %v", err)
+	}
+
+	output, _ := json.Marshal("a cat")
+	second := []converter.ResponsesOutputItem{{
+		Type: converter.ResponsesItemMessage, ID: "msg_2", Role: "assistant",
+		Content: []converter.ResponsesOutputContent{{Type: "output_text", Text: "Found it."}},
+	}}
+	input := []converter.ResponsesInputItem{{Type: converter.ResponsesItemFunctionCallOutput, CallID: "call_1", Output: output}}
+	if err := service.Save("resp_2", 1, "gpt-4o", "resp_1", input, second); err != nil {
+		t.Fatalf("Save() failed: %v", err)
+	}
+
+	items, err := service.History("resp_2", 1)
+	if err != nil {
+		t.Fatalf("History() failed: %v", err)
+	}
+
+	types := []string{converter.ResponsesItemMessage, converter.ResponsesItemFunctionCall, converter.ResponsesItemFunctionCallOutput, converter.ResponsesItemMessage}
+	if len(items) != len(types) {
+		t.Fatalf("expected %d items (reasoning dropped), got %d: %+v", len(types), len(items), items)
+	}
+	for i, item := range items {
+		if item.Type != types[i] {
+			t.Errorf("item %d: expected type %s, got %s", i, types[i], item.Type)
+		}
+		if item.ID != "" {
+			t.Errorf("item %d: expected id to be cleared, got %s", i, item.ID)
+		}
+	}
+	if items[1].CallID != "call_1" || items[1].Arguments != `{"q":"cat"}` {
+		t.Errorf("unexpected function_call item: %+v", items[1])
+	}
+	if items[3].Role != "assistant" {
+		t.Errorf("expected assistant message last, got %+v", items[3])
+	}
+}
+
+// TestService_History_NotFound 测试不存在或属于其他 Token 的响应
+func TestService_History_NotFound(t *testing.T) {
+	service := setupTestService(t)
+
+	if err := service.Save("resp_1", 1, "gpt-4o", "", []converter.ResponsesInputItem{userItem("hi")}, nil); err != nil {
+		t.Fatalf("Save() failed: %v", err)
+	}
+
+	if _, err := service.History("resp_missing", 1); !errors.Is(err, ErrResponseNotFound) {
+		t.Errorf("expected ErrResponseNotFound for unknown id, got %v", err)
+	}
+	if _, err := service.History("resp_1", 2); !errors.Is(err, ErrResponseNotFound) {
+		t.Errorf("expected ErrResponseNotFound for another token, got %v", err)
+	}
+	if err := service.Save("resp_2", 1, "gpt-4o", "resp_gone", nil, nil); err != nil {
+		t.Fatalf("Save() failed: %v", err)
+	}
+	if _, err := service.History("resp_2", 1); !errors.Is(err, ErrResponseNotFound) {
+		t.Errorf("expected ErrResponseNotFound for broken chain, got %v", err)
+	}
+}
@@ -78,6 +78,18 @@ func (r *Repository) Delete(id uint) error {
 	return nil
 }
 
+// UpdateResponseCache 更新 Token 的响应缓存开关
+func (r *Repository) UpdateResponseCache(id uint, enabled bool) error {
+	result := r.db.Model(&models.Token{}).Where("id = ?", id).Update("response_cache", enabled)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrTokenNotFound
+	}
+	return nil
+}
+
 // CheckValueExists 检查 Token 值是否存在
 func (r *Repository) CheckValueExists(tokenValue string) (bool, error) {
 	var count int64
@@ -137,6 +137,14 @@ func (s *Service) DeleteToken(id uint) error {
 	return s.repo.Delete(id)
 }
 
+// SetResponseCache 开启或关闭 Token 的响应缓存，返回更新后的 Token
+func (s *Service) SetResponseCache(id uint, enabled bool) (*models.Token, error) {
+	if err := s.repo.UpdateResponseCache(id, enabled); err != nil {
+		return nil, err
+	}
+	return s.repo.FindByID(id)
+}
+
 // ValidateToken 验证 Token (用于认证中间件)
 // 检查 Token 是否存在、是否启用、是否过期
 func (s *Service) ValidateToken(tokenValue string) (*models.Token, error) {
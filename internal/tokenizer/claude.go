@@ -0,0 +1,185 @@
+package tokenizer
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"image"
+	_ "image/gif"  // 注册 GIF 解码器，用于读取图片尺寸
+	_ "image/jpeg" // 注册 JPEG 解码器
+	_ "image/png"  // 注册 PNG 解码器
+	"math"
+	"strings"
+)
+
+// Claude 请求计数的固定开销
+const (
+	tokensPerMessage   = 3 // 每条消息的角色与分隔标记
+	replyPrimingTokens = 3 // assistant 回复起始标记
+
+	// Anthropic 公布的工具调用系统提示开销：tool_choice 为 auto/none 时 346，any/tool 时 313
+	toolSystemPromptAuto = 346
+	toolSystemPromptAny  = 313
+
+	// 图片按 宽×高/750 计费，长边超过 1568px 时先等比缩放，单张约不超过 1600 tokens
+	imageTokenPixels = 750
+	maxImageEdge     = 1568
+	maxImageTokens   = 1600
+)
+
+// CountClaudeRequest 计算 Claude Messages 请求的输入 token 数
+// 覆盖 system（字符串或块数组）、messages 中的各类内容块、tools 定义与图片；无法解析尺寸的图片按上限计
+func (e *Encoding) CountClaudeRequest(req map[string]interface{}) int {
+	total := replyPrimingTokens + e.countClaudeContent(req["system"])
+
+	if messages, ok := req["messages"].([]interface{}); ok {
+		for _, rawMsg := range messages {
+			msg, ok := rawMsg.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			role, _ := msg["role"].(string)
+			total += tokensPerMessage + e.Count(role) + e.countClaudeContent(msg["content"])
+		}
+	}
+
+	if tools, ok := req["tools"].([]interface{}); ok && len(tools) > 0 {
+		total += toolSystemPromptTokens(req["tool_choice"])
+		for _, rawTool := range tools {
+			total += e.countClaudeTool(rawTool)
+		}
+	}
+	return total
+}
+
+// countClaudeContent 计算 content（字符串、单个块或块数组）的 token 数
+func (e *Encoding) countClaudeContent(content interface{}) int {
+	switch v := content.(type) {
+	case string:
+		return e.Count(v)
+	case map[string]interface{}:
+		return e.countClaudeBlock(v)
+	case []interface{}:
+		total := 0
+		for _, item := range v {
+			switch block := item.(type) {
+			case string:
+				total += e.Count(block)
+			case map[string]interface{}:
+				total += e.countClaudeBlock(block)
+			}
+		}
+		return total
+	}
+	return 0
+}
+
+// countClaudeBlock 计算单个内容块的 token 数
+func (e *Encoding) countClaudeBlock(block map[string]interface{}) int {
+	blockType, _ := block["type"].(string)
+	switch blockType {
+	case "text":
+		text, _ := block["text"].(string)
+		return e.Count(text)
+	case "image":
+		source, _ := block["source"].(map[string]interface{})
+		return imageTokens(source)
+	case "tool_use", "server_tool_use":
+		name, _ := block["name"].(string)
+		return e.Count(name) + e.countJSON(block["input"])
+	case "tool_result":
+		return e.countClaudeContent(block["content"])
+	case "thinking":
+		thinking, _ := block["thinking"].(string)
+		return e.Count(thinking)
+	case "redacted_thinking":
+		// 加密内容无法还原，按密文长度近似
+		data, _ := block["data"].(string)
+		return e.Count(data)
+	case "document":
+		total := 0
+		for _, field := range []string{"title", "context"} {
+			if text, ok := block[field].(string); ok {
+				total += e.Count(text)
+			}
+		}
+		if source, ok := block["source"].(map[string]interface{}); ok {
+			switch source["type"] {
+			case "text":
+				data, _ := source["data"].(string)
+				total += e.Count(data)
+			case "content":
+				total += e.countClaudeContent(source["content"])
+			}
+		}
+		return total
+	}
+	// 未知块类型按 JSON 文本计
+	return e.countJSON(block)
+}
+
+// countClaudeTool 计算工具定义的 token 数（名称、描述与 input_schema；服务端工具按整个定义计）
+func (e *Encoding) countClaudeTool(rawTool interface{}) int {
+	tool, ok := rawTool.(map[string]interface{})
+	if !ok {
+		return 0
+	}
+	schema, ok := tool["input_schema"]
+	if !ok {
+		return e.countJSON(tool)
+	}
+	name, _ := tool["name"].(string)
+	description, _ := tool["description"].(string)
+	return e.Count(name) + e.Count(description) + e.countJSON(schema)
+}
+
+func (e *Encoding) countJSON(value interface{}) int {
+	if value == nil {
+		return 0
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return 0
+	}
+	return e.Count(string(data))
+}
+
+// toolSystemPromptTokens 按 tool_choice 返回工具调用系统提示开销
+func toolSystemPromptTokens(toolChoice interface{}) int {
+	if choice, ok := toolChoice.(map[string]interface{}); ok {
+		switch choice["type"] {
+		case "any", "tool":
+			return toolSystemPromptAny
+		}
+	}
+	return toolSystemPromptAuto
+}
+
+// imageTokens 按图片尺寸估算 token 数；URL / 文件引用或无法识别的格式按上限计
+func imageTokens(source map[string]interface{}) int {
+	if source["type"] != "base64" {
+		return maxImageTokens
+	}
+	data, _ := source["data"].(string)
+	config, _, err := image.DecodeConfig(base64.NewDecoder(base64.StdEncoding, strings.NewReader(data)))
+	if err != nil {
+		return maxImageTokens
+	}
+	return ImageTokensForSize(config.Width, config.Height)
+}
+
+// ImageTokensForSize 按 Claude 的缩放规则计算指定尺寸图片的 token 数
+func ImageTokensForSize(width, height int) int {
+	if width <= 0 || height <= 0 {
+		return maxImageTokens
+	}
+	w, h := float64(width), float64(height)
+	if longEdge := math.Max(w, h); longEdge > maxImageEdge {
+		scale := maxImageEdge / longEdge
+		w, h = w*scale, h*scale
+	}
+	tokens := int(math.Ceil(w * h / imageTokenPixels))
+	if tokens > maxImageTokens {
+		return maxImageTokens
+	}
+	return tokens
+}
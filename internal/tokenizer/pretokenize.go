@@ -0,0 +1,224 @@
+package tokenizer
+
+import (
+	"strings"
+	"unicode"
+)
+
+// tiktoken 的预分词正则依赖 (?!\S) 前瞻与回溯，Go 的 RE2 不支持，这里按分支顺序手写等价的匹配逻辑。
+//
+// cl100k_base:
+//
+//	'(?i:[sdmt]|ll|ve|re)|[^\r\n\p{L}\p{N}]?+\p{L}++|\p{N}{1,3}+| ?[^\s\p{L}\p{N}]++[\r\n]*+|\s++$|\s*[\r\n]|\s+(?!\S)|\s
+//
+// o200k_base:
+//
+//	[^\r\n\p{L}\p{N}]?[\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]*[\p{Ll}\p{Lm}\p{Lo}\p{M}]+(?i:'s|'t|'re|'ve|'m|'ll|'d)?
+//	|[^\r\n\p{L}\p{N}]?[\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]+[\p{Ll}\p{Lm}\p{Lo}\p{M}]*(?i:'s|'t|'re|'ve|'m|'ll|'d)?
+//	|\p{N}{1,3}| ?[^\s\p{L}\p{N}]+[\r\n/]*|\s*[\r\n]+|\s+(?!\S)|\s+
+
+// matcher 返回从 rs[i] 开始的匹配结束位置（不含），无法匹配时返回 -1
+type matcher func(rs []rune, i int) int
+
+func splitCL100K(text string) []string {
+	return splitPieces(text, matchCL100K)
+}
+
+func splitO200K(text string) []string {
+	return splitPieces(text, matchO200K)
+}
+
+// splitPieces 按匹配器切分文本；各分支覆盖所有字符，片段保持原始字节
+func splitPieces(text string, match matcher) []string {
+	rs := make([]rune, 0, len(text))
+	offsets := make([]int, 0, len(text)+1)
+	for offset, r := range text {
+		rs = append(rs, r)
+		offsets = append(offsets, offset)
+	}
+	offsets = append(offsets, len(text))
+
+	pieces := make([]string, 0, len(rs)/3+1)
+	for i := 0; i < len(rs); {
+		j := match(rs, i)
+		if j <= i {
+			j = i + 1
+		}
+		pieces = append(pieces, text[offsets[i]:offsets[j]])
+		i = j
+	}
+	return pieces
+}
+
+func matchCL100K(rs []rune, i int) int {
+	if n := contractionAt(rs, i); n > 0 {
+		return i + n
+	}
+
+	// [^\r\n\p{L}\p{N}]?+\p{L}++
+	start := i
+	if isWordPrefix(rs[i]) {
+		start++
+	}
+	if end := scan(rs, start, unicode.IsLetter); end > start {
+		return end
+	}
+
+	if end := scanNumbers(rs, i); end > i {
+		return end
+	}
+	if end := matchPunctuation(rs, i, "\r\n"); end > i {
+		return end
+	}
+
+	// \s++$
+	spaceEnd := scan(rs, i, unicode.IsSpace)
+	if spaceEnd > i && spaceEnd == len(rs) {
+		return spaceEnd
+	}
+	return matchTrailingSpaces(rs, i, spaceEnd)
+}
+
+func matchO200K(rs []rune, i int) int {
+	if end := withWordPrefix(rs, i, matchLowerWord); end > i {
+		return end
+	}
+	if end := withWordPrefix(rs, i, matchUpperWord); end > i {
+		return end
+	}
+	if end := scanNumbers(rs, i); end > i {
+		return end
+	}
+	if end := matchPunctuation(rs, i, "\r\n/"); end > i {
+		return end
+	}
+	return matchTrailingSpaces(rs, i, scan(rs, i, unicode.IsSpace))
+}
+
+// matchTrailingSpaces 处理空白分支：\s*[\r\n] | \s+(?!\S) | \s（单个空白时 o200k 的 \s+ 与 \s 等价）
+func matchTrailingSpaces(rs []rune, i, spaceEnd int) int {
+	if spaceEnd == i {
+		return -1
+	}
+	// \s*[\r\n]：回溯到空白串中最后一个换行
+	for k := spaceEnd - 1; k >= i; k-- {
+		if isNewline(rs[k]) {
+			return k + 1
+		}
+	}
+	// \s+(?!\S)：后面是非空白字符时让出最后一个空白
+	if spaceEnd == len(rs) {
+		return spaceEnd
+	}
+	if spaceEnd-i >= 2 {
+		return spaceEnd - 1
+	}
+	return i + 1
+}
+
+// withWordPrefix 先尝试带 [^\r\n\p{L}\p{N}] 前缀的匹配，失败后从当前位置重试
+func withWordPrefix(rs []rune, i int, match matcher) int {
+	if isWordPrefix(rs[i]) {
+		if end := match(rs, i+1); end >= 0 {
+			return end
+		}
+	}
+	return match(rs, i)
+}
+
+// matchLowerWord [A]*[B]+ 后接可选缩写，A 为大写类字母，B 为小写类字母（两者共享 Lm、Lo、M）
+func matchLowerWord(rs []rune, start int) int {
+	upperEnd := scan(rs, start, isUpperClass)
+	for k := upperEnd; k >= start; k-- {
+		if k < len(rs) && isLowerClass(rs[k]) {
+			end := scan(rs, k, isLowerClass)
+			return end + contractionAt(rs, end)
+		}
+	}
+	return -1
+}
+
+// matchUpperWord [A]+[B]* 后接可选缩写
+func matchUpperWord(rs []rune, start int) int {
+	upperEnd := scan(rs, start, isUpperClass)
+	if upperEnd == start {
+		return -1
+	}
+	end := scan(rs, upperEnd, isLowerClass)
+	return end + contractionAt(rs, end)
+}
+
+// matchPunctuation ` ?[^\s\p{L}\p{N}]+` 后接 tail 中的字符
+func matchPunctuation(rs []rune, i int, tail string) int {
+	start := i
+	if rs[start] == ' ' {
+		start++
+	}
+	end := scan(rs, start, isPunctuation)
+	if end == start {
+		return -1
+	}
+	return scan(rs, end, func(r rune) bool { return strings.ContainsRune(tail, r) })
+}
+
+// scanNumbers \p{N}{1,3}
+func scanNumbers(rs []rune, i int) int {
+	end := i
+	for end < len(rs) && end-i < 3 && unicode.IsNumber(rs[end]) {
+		end++
+	}
+	return end
+}
+
+// contractionAt 匹配 (?i:'s|'t|'re|'ve|'m|'ll|'d)，返回匹配的字符数
+func contractionAt(rs []rune, i int) int {
+	if i+1 >= len(rs) || rs[i] != '\'' {
+		return 0
+	}
+	if i+2 < len(rs) {
+		suffix := string(rs[i+1 : i+3])
+		for _, contraction := range []string{"re", "ve", "ll"} {
+			if strings.EqualFold(suffix, contraction) {
+				return 3
+			}
+		}
+	}
+	suffix := string(rs[i+1])
+	for _, contraction := range []string{"s", "t", "m", "d"} {
+		if strings.EqualFold(suffix, contraction) {
+			return 2
+		}
+	}
+	return 0
+}
+
+func scan(rs []rune, i int, accept func(rune) bool) int {
+	for i < len(rs) && accept(rs[i]) {
+		i++
+	}
+	return i
+}
+
+func isNewline(r rune) bool {
+	return r == '\r' || r == '\n'
+}
+
+// isWordPrefix [^\r\n\p{L}\p{N}]
+func isWordPrefix(r rune) bool {
+	return !isNewline(r) && !unicode.IsLetter(r) && !unicode.IsNumber(r)
+}
+
+// isPunctuation [^\s\p{L}\p{N}]
+func isPunctuation(r rune) bool {
+	return !unicode.IsSpace(r) && !unicode.IsLetter(r) && !unicode.IsNumber(r)
+}
+
+// isUpperClass [\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]
+func isUpperClass(r rune) bool {
+	return unicode.In(r, unicode.Lu, unicode.Lt, unicode.Lm, unicode.Lo, unicode.M)
+}
+
+// isLowerClass [\p{Ll}\p{Lm}\p{Lo}\p{M}]
+func isLowerClass(r rune) bool {
+	return unicode.In(r, unicode.Ll, unicode.Lm, unicode.Lo, unicode.M)
+}
@@ -0,0 +1,243 @@
+// Package tokenizer 提供离线 BPE 分词（tiktoken 的 cl100k_base / o200k_base 编码）
+// 词表为 OpenAI 发布的 .tiktoken 文件经 gzip 压缩后内嵌（解压后 SHA-256 与官方一致）：
+//
+//	cl100k_base 223921b76ee99bde995b7ff738513eef100fb51d18c93597a113bcffe865b2a7
+//	o200k_base  446a9538cb6c348e3516120d7c08b09f57c36495e2acfffe59a5bf8b0cfb1a2d
+package tokenizer
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"container/heap"
+	"embed"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// 编码名称
+const (
+	CL100KBase = "cl100k_base"
+	O200KBase  = "o200k_base"
+)
+
+//go:embed data/*.tiktoken.gz
+var vocabFS embed.FS
+
+// Encoding BPE 编码（词表首次使用时加载）
+type Encoding struct {
+	name  string
+	split func(text string) []string
+
+	once    sync.Once
+	ranks   map[string]int
+	loadErr error
+}
+
+var encodings = map[string]*Encoding{
+	CL100KBase: {name: CL100KBase, split: splitCL100K},
+	O200KBase:  {name: O200KBase, split: splitO200K},
+}
+
+// Get 按名称获取编码
+func Get(name string) (*Encoding, error) {
+	enc, ok := encodings[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown encoding %q", name)
+	}
+	if err := enc.load(); err != nil {
+		return nil, err
+	}
+	return enc, nil
+}
+
+// ForModel 按模型名选择编码：GPT-4o / GPT-4.1 / GPT-5 / o 系列使用 o200k_base，其余（含 Claude 等非 OpenAI 模型）使用 cl100k_base
+func ForModel(model string) *Encoding {
+	name := CL100KBase
+	if usesO200K(model) {
+		name = O200KBase
+	}
+	enc, err := Get(name)
+	if err != nil {
+		// 内嵌词表损坏属于构建问题
+		panic(err)
+	}
+	return enc
+}
+
+// usesO200K 模型是否使用 o200k_base 编码
+func usesO200K(model string) bool {
+	model = strings.ToLower(model)
+	if i := strings.LastIndex(model, "/"); i >= 0 {
+		model = model[i+1:]
+	}
+	for _, prefix := range []string{"gpt-4o", "chatgpt-4o", "gpt-4.1", "gpt-4.5", "gpt-5", "gpt-oss", "o1", "o3", "o4"} {
+		if strings.HasPrefix(model, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Name 编码名称
+func (e *Encoding) Name() string {
+	return e.name
+}
+
+// Encode 将文本编码为 token 序列（特殊 token 按普通文本处理）
+func (e *Encoding) Encode(text string) []int {
+	var tokens []int
+	for _, piece := range e.split(text) {
+		if rank, ok := e.ranks[piece]; ok {
+			tokens = append(tokens, rank)
+			continue
+		}
+		tokens = append(tokens, e.bytePairEncode([]byte(piece))...)
+	}
+	return tokens
+}
+
+// Count 计算文本的 token 数
+func (e *Encoding) Count(text string) int {
+	count := 0
+	for _, piece := range e.split(text) {
+		if _, ok := e.ranks[piece]; ok {
+			count++
+			continue
+		}
+		count += len(e.bytePairEncode([]byte(piece)))
+	}
+	return count
+}
+
+// bytePairEncode 对单个预分词片段执行 BPE 合并：每轮合并 rank 最小（相同时取最左）的相邻片段，直到无法合并
+// 使用最小堆与链表，长片段（如大段空白）的复杂度为 O(n log n)
+func (e *Encoding) bytePairEncode(piece []byte) []int {
+	n := len(piece)
+	// 以片段起始偏移标识片段：next[i] 为下一片段的起始偏移（最后一个片段为 n）
+	next := make([]int, n)
+	prev := make([]int, n)
+	merged := make([]bool, n)
+	for i := range next {
+		next[i] = i + 1
+		prev[i] = i - 1
+	}
+
+	pairEnd := func(start int) int {
+		if mid := next[start]; mid < n {
+			return next[mid]
+		}
+		return -1
+	}
+	candidates := &mergeHeap{}
+	pushPair := func(start int) {
+		end := pairEnd(start)
+		if end < 0 {
+			return
+		}
+		if rank, ok := e.ranks[string(piece[start:end])]; ok {
+			heap.Push(candidates, mergeCandidate{rank: rank, start: start, end: end})
+		}
+	}
+	for i := 0; i < n; i++ {
+		pushPair(i)
+	}
+
+	for candidates.Len() > 0 {
+		candidate := heap.Pop(candidates).(mergeCandidate)
+		// 片段已被合并或相邻片段已变化的候选项作废
+		if merged[candidate.start] || pairEnd(candidate.start) != candidate.end {
+			continue
+		}
+		mid := next[candidate.start]
+		merged[mid] = true
+		next[candidate.start] = next[mid]
+		if next[mid] < n {
+			prev[next[mid]] = candidate.start
+		}
+		if p := prev[candidate.start]; p >= 0 {
+			pushPair(p)
+		}
+		pushPair(candidate.start)
+	}
+
+	var tokens []int
+	for start := 0; start < n; start = next[start] {
+		// 单字节一定在词表中
+		tokens = append(tokens, e.ranks[string(piece[start:next[start]])])
+	}
+	return tokens
+}
+
+// mergeCandidate 可合并的相邻片段对 piece[start:end]
+type mergeCandidate struct {
+	rank, start, end int
+}
+
+// mergeHeap 按 (rank, start) 排序的最小堆
+type mergeHeap []mergeCandidate
+
+func (h mergeHeap) Len() int { return len(h) }
+func (h mergeHeap) Less(i, j int) bool {
+	if h[i].rank != h[j].rank {
+		return h[i].rank < h[j].rank
+	}
+	return h[i].start < h[j].start
+}
+func (h mergeHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *mergeHeap) Push(x interface{}) { *h = append(*h, x.(mergeCandidate)) }
+func (h *mergeHeap) Pop() interface{} {
+	old := *h
+	item := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return item
+}
+
+// load 加载内嵌词表（每行: base64(token bytes) rank）
+func (e *Encoding) load() error {
+	e.once.Do(func() {
+		e.ranks, e.loadErr = loadRanks(e.name)
+	})
+	return e.loadErr
+}
+
+func loadRanks(name string) (map[string]int, error) {
+	compressed, err := vocabFS.ReadFile("data/" + name + ".tiktoken.gz")
+	if err != nil {
+		return nil, fmt.Errorf("read %s vocabulary: %w", name, err)
+	}
+	reader, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, fmt.Errorf("decompress %s vocabulary: %w", name, err)
+	}
+	defer reader.Close()
+
+	ranks := make(map[string]int, 200000)
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+		encoded, rankText, ok := strings.Cut(line, " ")
+		if !ok {
+			return nil, fmt.Errorf("invalid %s vocabulary line %q", name, line)
+		}
+		token, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s vocabulary token %q: %w", name, encoded, err)
+		}
+		rank, err := strconv.Atoi(rankText)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s vocabulary rank %q: %w", name, rankText, err)
+		}
+		ranks[string(token)] = rank
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read %s vocabulary: %w", name, err)
+	}
+	return ranks, nil
+}
@@ -0,0 +1,149 @@
+package tokenizer
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncode_KnownVectors(t *testing.T) {
+	cl100k, err := Get(CL100KBase)
+	require.NoError(t, err)
+	o200k, err := Get(O200KBase)
+	require.NoError(t, err)
+
+	// 与 tiktoken 官方实现的输出一致
+	tests := []struct {
+		text   string
+		cl100k []int
+		o200k  []int
+	}{
+		{"hello world", []int{15339, 1917}, []int{24912, 2375}},
+		{"tiktoken is great!", []int{83, 1609, 5963, 374, 2294, 0}, []int{83, 8251, 2488, 382, 2212, 0}},
+		{"2 + 2 = 4", []int{17, 489, 220, 17, 284, 220, 19}, []int{17, 659, 220, 17, 314, 220, 19}},
+		{"お誕生日おめでとう", []int{33334, 45918, 243, 21990, 9080, 33334, 62004, 16556, 78699}, []int{8930, 9697, 243, 128225, 8930, 17693, 4344, 48669}},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.cl100k, cl100k.Encode(tt.text), "cl100k: %q", tt.text)
+		assert.Equal(t, tt.o200k, o200k.Encode(tt.text), "o200k: %q", tt.text)
+		assert.Equal(t, len(tt.cl100k), cl100k.Count(tt.text))
+	}
+
+	assert.Equal(t, []int{519, 85342, 34500, 479, 8997, 2191}, cl100k.Encode("antidisestablishmentarianism"))
+	assert.Empty(t, cl100k.Encode(""))
+}
+
+func TestEncode_LongPieces(t *testing.T) {
+	enc := ForModel("claude-sonnet-4-5")
+
+	// 大段空白与无空格长串只产生少量片段，BPE 合并需保持近线性
+	spaces := strings.Repeat(" ", 100000)
+	assert.Greater(t, enc.Count(spaces), 0)
+	assert.Less(t, enc.Count(spaces), 10000)
+
+	word := strings.Repeat("a", 50000)
+	assert.Greater(t, enc.Count(word), 0)
+	assert.Less(t, enc.Count(word), 50000)
+}
+
+func TestForModel(t *testing.T) {
+	assert.Equal(t, O200KBase, ForModel("gpt-4o-mini").Name())
+	assert.Equal(t, O200KBase, ForModel("openai/gpt-4.1").Name())
+	assert.Equal(t, O200KBase, ForModel("o3-mini").Name())
+	assert.Equal(t, CL100KBase, ForModel("gpt-4-turbo").Name())
+	assert.Equal(t, CL100KBase, ForModel("claude-sonnet-4-5").Name())
+	assert.Equal(t, CL100KBase, ForModel("").Name())
+
+	_, err := Get("p50k_base")
+	assert.Error(t, err)
+}
+
+func TestCountClaudeRequest(t *testing.T) {
+	enc := ForModel("claude-sonnet-4-5")
+
+	base := map[string]interface{}{
+		"messages": []interface{}{
+			map[string]interface{}{"role": "user", "content": "hello world"},
+		},
+	}
+	baseTokens := enc.CountClaudeRequest(base)
+	assert.Equal(t, replyPrimingTokens+tokensPerMessage+enc.Count("user")+2, baseTokens)
+
+	t.Run("system blocks", func(t *testing.T) {
+		req := map[string]interface{}{
+			"system": []interface{}{
+				map[string]interface{}{"type": "text", "text": "You are helpful.", "cache_control": map[string]interface{}{"type": "ephemeral"}},
+			},
+			"messages": base["messages"],
+		}
+		assert.Equal(t, baseTokens+enc.Count("You are helpful."), enc.CountClaudeRequest(req))
+	})
+
+	t.Run("tools", func(t *testing.T) {
+		tool := map[string]interface{}{
+			"name":         "get_weather",
+			"description":  "Get the weather",
+			"input_schema": map[string]interface{}{"type": "object", "properties": map[string]interface{}{"city": map[string]interface{}{"type": "string"}}},
+		}
+		req := map[string]interface{}{"messages": base["messages"], "tools": []interface{}{tool}}
+		auto := enc.CountClaudeRequest(req)
+		assert.Greater(t, auto, baseTokens+toolSystemPromptAuto)
+
+		req["tool_choice"] = map[string]interface{}{"type": "any"}
+		assert.Equal(t, auto-toolSystemPromptAuto+toolSystemPromptAny, enc.CountClaudeRequest(req))
+	})
+
+	t.Run("images", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, png.Encode(&buf, image.NewGray(image.Rect(0, 0, 200, 150))))
+		imageBlock := func(source map[string]interface{}) map[string]interface{} {
+			return map[string]interface{}{
+				"messages": []interface{}{
+					map[string]interface{}{"role": "user", "content": []interface{}{
+						map[string]interface{}{"type": "image", "source": source},
+					}},
+				},
+			}
+		}
+
+		empty := enc.CountClaudeRequest(imageBlock(nil)) - maxImageTokens
+		small := imageBlock(map[string]interface{}{"type": "base64", "media_type": "image/png", "data": base64.StdEncoding.EncodeToString(buf.Bytes())})
+		assert.Equal(t, empty+40, enc.CountClaudeRequest(small))
+
+		remote := imageBlock(map[string]interface{}{"type": "url", "url": "https://example.com/cat.png"})
+		assert.Equal(t, empty+maxImageTokens, enc.CountClaudeRequest(remote))
+	})
+
+	t.Run("tool use and result", func(t *testing.T) {
+		req := map[string]interface{}{
+			"messages": []interface{}{
+				map[string]interface{}{"role": "assistant", "content": []interface{}{
+					map[string]interface{}{"type": "tool_use", "id": "toolu_1", "name": "get_weather", "input": map[string]interface{}{"city": "Paris"}},
+				}},
+				map[string]interface{}{"role": "user", "content": []interface{}{
+					map[string]interface{}{"type": "tool_result", "tool_use_id": "toolu_1", "content": []interface{}{
+						map[string]interface{}{"type": "text", "text": "sunny"},
+					}},
+				}},
+			},
+		}
+		expected := replyPrimingTokens + 2*tokensPerMessage + enc.Count("assistant") + enc.Count("user") +
+			enc.Count("get_weather") + enc.Count(`{"city":"Paris"}`) + enc.Count("sunny")
+		assert.Equal(t, expected, enc.CountClaudeRequest(req))
+	})
+}
+
+func TestImageTokensForSize(t *testing.T) {
+	assert.Equal(t, 40, ImageTokensForSize(200, 150))
+	assert.Equal(t, 1334, ImageTokensForSize(1000, 1000))
+	// 长边缩放到 1568 后仍超过上限
+	assert.Equal(t, maxImageTokens, ImageTokensForSize(4000, 3000))
+	assert.Equal(t, 820, ImageTokensForSize(3136, 784))
+	assert.Equal(t, maxImageTokens, ImageTokensForSize(0, 100))
+}